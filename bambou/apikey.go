@@ -0,0 +1,98 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+)
+
+// apiKeyPath is the path, under the URL of the root object, of the API key of the root user.
+const apiKeyPath = "/apikey"
+
+// RevokeAPIKey revokes the API key of the session on the server, and forgets it: the
+// next request authenticates with the password again and gets a new API key. It allows
+// to invalidate a key that may have leaked without waiting for its expiry.
+func (s *Session) RevokeAPIKey() *Error {
+
+	url, berr := s.getPersonalURL(s.root)
+	if berr != nil {
+		return berr
+	}
+
+	request, err := http.NewRequest("DELETE", url+apiKeyPath, nil)
+	if err != nil {
+		return NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	response, berr := s.send(request, nil)
+	if berr != nil {
+		return berr
+	}
+	response.Body.Close()
+
+	s.SetAPIKey("")
+
+	return nil
+}
+
+// RegenerateAPIKey makes the server replace the API key of the session with a new one,
+// set to the root object, to rotate the keys on schedule instead of waiting for their
+// expiry. The previous key is no longer valid.
+func (s *Session) RegenerateAPIKey() *Error {
+
+	url, berr := s.getPersonalURL(s.root)
+	if berr != nil {
+		return berr
+	}
+
+	request, err := http.NewRequest("PUT", url+apiKeyPath, nil)
+	if err != nil {
+		return NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	response, berr := s.send(request, nil)
+	if berr != nil {
+		return berr
+	}
+	defer response.Body.Close()
+
+	received := readBody(response)
+	defer putBuffer(received)
+
+	// The previous key is no longer valid, even if the response has no new key.
+	unlock := s.lockRoot(s.root)
+	s.root.SetAPIKey("")
+	arr := IdentifiablesList{s.root}
+	err = s.getCodec().Unmarshal(received.Bytes(), &arr)
+	unlock()
+	if err != nil {
+		return NewBambouError("JSON unmarshalling error", err.Error())
+	}
+
+	if s.APIKey() == "" {
+		return NewBambouError("Invalid response", "the server returned no API key")
+	}
+
+	return nil
+}
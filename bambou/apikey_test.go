@@ -0,0 +1,146 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_APIKey(t *testing.T) {
+
+	Convey("Given I have a server rotating the API keys and a started session", t, func() {
+
+		var lock sync.Mutex
+		var requests []string
+		var authorizations []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			authorizations = append(authorizations, r.Header.Get("Authorization"))
+			lock.Unlock()
+
+			switch r.Method + " " + r.URL.Path {
+			case "GET /root":
+				w.Write([]byte(`[{"ID": "root", "APIKey": "first"}]`))
+			case "PUT /root/apikey":
+				w.Write([]byte(`[{"ID": "root", "APIKey": "second"}]`))
+			case "DELETE /root/apikey":
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}))
+		defer ts.Close()
+
+		root := NewFakeRootObject()
+		session := NewSession("username", "password", "organization", ts.URL, root)
+		So(session.Start(), ShouldBeNil)
+		So(root.APIKey(), ShouldEqual, "first")
+
+		xrest := func(key string) string {
+			return "XREST " + base64.StdEncoding.EncodeToString([]byte("username:"+key))
+		}
+
+		Convey("When I regenerate the API key", func() {
+
+			err := session.RegenerateAPIKey()
+
+			Convey("Then the request should be authenticated with the previous key", func() {
+				So(err, ShouldBeNil)
+				So(requests[1], ShouldEqual, "PUT /root/apikey")
+				So(authorizations[1], ShouldEqual, xrest("first"))
+			})
+
+			Convey("Then the session should use the new key", func() {
+				So(root.APIKey(), ShouldEqual, "second")
+			})
+		})
+
+		Convey("When I revoke the API key", func() {
+
+			err := session.RevokeAPIKey()
+
+			Convey("Then the revoked key should be forgotten", func() {
+				So(err, ShouldBeNil)
+				So(requests[1], ShouldEqual, "DELETE /root/apikey")
+				So(authorizations[1], ShouldEqual, xrest("first"))
+				So(root.APIKey(), ShouldBeEmpty)
+			})
+
+			Convey("When I start the session again", func() {
+
+				So(session.Start(), ShouldBeNil)
+
+				Convey("Then it should authenticate with the password", func() {
+					So(authorizations[2], ShouldEqual, xrest("password"))
+					So(root.APIKey(), ShouldEqual, "first")
+				})
+			})
+		})
+	})
+
+	Convey("Given I have a server failing to rotate the API keys", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PUT" {
+				w.Write([]byte(`[{"ID": "root"}]`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer ts.Close()
+
+		root := NewFakeRootObject()
+		root.SetAPIKey("first")
+		session := NewSession("username", "password", "organization", ts.URL, root)
+
+		Convey("When I revoke the API key", func() {
+
+			err := session.RevokeAPIKey()
+
+			Convey("Then I should get an error and keep the key", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusForbidden)
+				So(root.APIKey(), ShouldEqual, "first")
+			})
+		})
+
+		Convey("When I regenerate an API key that the server does not return", func() {
+
+			err := session.RegenerateAPIKey()
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Invalid response")
+			})
+		})
+	})
+}
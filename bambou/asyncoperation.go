@@ -0,0 +1,236 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// OperationStatusFunc is the prototype of a function that inspects the object
+// of a long running operation. It returns done as true when the operation is
+// completed, with its result payload, or a non nil error if the operation failed.
+type OperationStatusFunc func(Identifiable) (done bool, result interface{}, err *Error)
+
+// AsyncOperation is a handle on a long running server operation.
+// It is created by StartOperation and refreshes the state of the operation
+// object until it completes.
+// The operation object must not be accessed until the operation is done.
+type AsyncOperation struct {
+	object       Identifiable
+	identity     Identity
+	identifier   string
+	storer       Storer
+	statusFunc   OperationStatusFunc
+	pollInterval time.Duration
+	timeout      time.Duration
+	clock        Clock
+	done         chan struct{}
+	trigger      chan struct{}
+	cancel       context.CancelFunc
+	result       interface{}
+	err          *Error
+	stopped      error
+}
+
+// StartOperation starts a long running operation by creating the given object under
+// the given parent, and returns a handle to follow its completion.
+// The state of the object is fetched every pollInterval, or as soon as Refresh is called,
+// and given to statusFunc to decide if the operation is completed.
+// The operation is abandoned if the given context is done. The poll interval must be positive.
+func StartOperation(ctx context.Context, storer Storer, parent Identifiable, object Identifiable, pollInterval time.Duration, statusFunc OperationStatusFunc) (*AsyncOperation, *Error) {
+
+	return startOperation(ctx, storer, parent, object, pollInterval, 0, statusFunc)
+}
+
+// startOperation works like StartOperation, abandoning the operation after the
+// given timeout, timed by the Clock of the session, if it is not 0.
+func startOperation(ctx context.Context, storer Storer, parent Identifiable, object Identifiable, pollInterval time.Duration, timeout time.Duration, statusFunc OperationStatusFunc) (*AsyncOperation, *Error) {
+
+	o, err := newAsyncOperation(storer, object, pollInterval, timeout, statusFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storer.CreateChild(parent, object); err != nil {
+		return nil, err
+	}
+
+	o.start(ctx)
+
+	return o, nil
+}
+
+// newAsyncOperation returns a new *AsyncOperation following the given object with the given
+// Storer, checking its state with the given function every pollInterval once it is started,
+// until the given timeout expires if it is not 0. The poll interval must be positive.
+func newAsyncOperation(storer Storer, object Identifiable, pollInterval time.Duration, timeout time.Duration, statusFunc OperationStatusFunc) (*AsyncOperation, *Error) {
+
+	if pollInterval <= 0 {
+		return nil, NewBambouError("Invalid poll interval", fmt.Sprintf("the poll interval must be positive, not %s", pollInterval))
+	}
+
+	return &AsyncOperation{
+		object:       object,
+		storer:       storer,
+		statusFunc:   statusFunc,
+		pollInterval: pollInterval,
+		timeout:      timeout,
+		clock:        clockOf(storer),
+		done:         make(chan struct{}),
+		trigger:      make(chan struct{}, 1),
+	}, nil
+}
+
+// start starts following the operation until it is done or the given context is done.
+func (o *AsyncOperation) start(ctx context.Context) {
+
+	o.identity = o.object.Identity()
+	o.identifier = o.object.Identifier()
+
+	ctx, o.cancel = context.WithCancel(ctx)
+
+	go o.run(ctx)
+}
+
+// Object returns the object of the operation.
+func (o *AsyncOperation) Object() Identifiable {
+
+	return o.object
+}
+
+// Done returns a channel that is closed when the operation is completed, failed or abandoned.
+func (o *AsyncOperation) Done() <-chan struct{} {
+
+	return o.done
+}
+
+// Wait waits for the operation to be done and returns its result.
+func (o *AsyncOperation) Wait() (interface{}, *Error) {
+
+	<-o.done
+
+	return o.result, o.err
+}
+
+// Poll returns the result of the operation if it is done, without blocking.
+func (o *AsyncOperation) Poll() (done bool, result interface{}, err *Error) {
+
+	select {
+	case <-o.done:
+		return true, o.result, o.err
+	default:
+		return false, nil, nil
+	}
+}
+
+// Cancel abandons the operation. This does not cancel the operation on the server.
+func (o *AsyncOperation) Cancel() {
+
+	o.cancel()
+}
+
+// Refresh asks the operation to fetch the state of its object immediately.
+func (o *AsyncOperation) Refresh() {
+
+	select {
+	case o.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// HandleEvent is an EventHandler that refreshes the operation when it receives
+// an event about the operation object. It can be registered in a PushCenter
+// for the Identity of the operation object to avoid waiting for the next poll.
+func (o *AsyncOperation) HandleEvent(event *Event) {
+
+	if event.EntityType != o.identity.Name {
+		return
+	}
+
+	for _, entity := range event.DataMap {
+		if entity["ID"] == o.identifier {
+			o.Refresh()
+			return
+		}
+	}
+}
+
+func (o *AsyncOperation) run(ctx context.Context) {
+
+	defer close(o.done)
+	defer o.cancel()
+
+	var deadline <-chan time.Time
+	if o.timeout > 0 {
+		timer := o.clock.NewTimer(o.timeout)
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
+	for {
+
+		done, result, err := o.statusFunc(o.object)
+		if err != nil {
+			o.err = err
+			return
+		}
+
+		if done {
+			o.result = result
+			return
+		}
+
+		if o.stopped = o.wait(ctx, deadline); o.stopped != nil {
+			o.err = NewBambouError("Operation timeout", fmt.Sprintf("%s %s did not complete: %s", o.identity.Name, o.identifier, o.stopped))
+			return
+		}
+
+		if err := refreshEntity(o.storer, o.object); err != nil {
+			o.err = err
+			return
+		}
+	}
+}
+
+// wait waits for the next poll, timed by the Clock of the session, or for Refresh.
+// It returns the reason to stop polling if the given context is done or the given
+// deadline is reached first.
+func (o *AsyncOperation) wait(ctx context.Context, deadline <-chan time.Time) error {
+
+	timer := o.clock.NewTimer(o.pollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-deadline:
+		return context.DeadlineExceeded
+	case <-timer.C():
+	case <-o.trigger:
+	}
+
+	return nil
+}
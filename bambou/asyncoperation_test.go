@@ -0,0 +1,138 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAsyncOperation_StartOperation(t *testing.T) {
+
+	Convey("Given I have a parent and a job", t, func() {
+
+		parent := NewFakeObject("parent")
+		job := &fakeJob{Command: "EXPORT"}
+
+		Convey("When I start an operation that succeeds", func() {
+
+			ts := newFakeJobServer(2, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			operation, err := StartOperation(context.Background(), session, parent, job, 10*time.Millisecond, JobStatus)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the object should be the job", func() {
+				So(operation.Object(), ShouldEqual, job)
+			})
+
+			Convey("When I wait for it", func() {
+
+				result, err := operation.Wait()
+
+				Convey("Then the result should be correct", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, map[string]interface{}{"value": float64(42)})
+				})
+
+				Convey("Then polling should return the result", func() {
+					done, result, err := operation.Poll()
+					So(done, ShouldBeTrue)
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+				})
+			})
+		})
+
+		Convey("When I start an operation and refresh it from an event", func() {
+
+			ts := newFakeJobServer(2, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			operation, _ := StartOperation(context.Background(), session, parent, job, time.Hour, JobStatus)
+
+			operation.HandleEvent(&Event{EntityType: "notjob", DataMap: []map[string]interface{}{{"ID": "job1"}}})
+			operation.HandleEvent(&Event{EntityType: "job", DataMap: []map[string]interface{}{{"ID": "job1"}}})
+			time.Sleep(50 * time.Millisecond)
+			operation.HandleEvent(&Event{EntityType: "job", DataMap: []map[string]interface{}{{"ID": "job1"}}})
+
+			Convey("Then it should complete without waiting for the poll interval", func() {
+				select {
+				case <-operation.Done():
+				case <-time.After(time.Second):
+				}
+				done, result, err := operation.Poll()
+				So(done, ShouldBeTrue)
+				So(err, ShouldBeNil)
+				So(result, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I start an operation with no poll interval", func() {
+
+			ts := newFakeJobServer(2, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			operation, err := StartOperation(context.Background(), session, parent, job, 0, JobStatus)
+
+			Convey("Then it should fail before creating the job", func() {
+				So(operation, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Invalid poll interval")
+				So(job.ID, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I start an operation and cancel it", func() {
+
+			ts := newFakeJobServer(1000, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			operation, _ := StartOperation(context.Background(), session, parent, job, time.Hour, JobStatus)
+
+			Convey("Then it should not be done", func() {
+				done, _, _ := operation.Poll()
+				So(done, ShouldBeFalse)
+			})
+
+			operation.Cancel()
+			_, err := operation.Wait()
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Operation timeout")
+			})
+		})
+	})
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditOperation is the kind of a mutating operation recorded in an AuditEntry.
+type AuditOperation string
+
+// The audited operations.
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditDelete AuditOperation = "delete"
+	AuditAssign AuditOperation = "assign"
+)
+
+// AuditEntry records a mutating operation done by a Session.
+// For AuditAssign, Identity is the identity of the assigned children, and
+// Changes contains their IDs under the name of the identity.
+type AuditEntry struct {
+	Time           time.Time              `json:"time"`
+	Actor          string                 `json:"actor"`
+	Operation      AuditOperation         `json:"operation"`
+	Identity       string                 `json:"identity"`
+	ID             string                 `json:"ID,omitempty"`
+	ParentIdentity string                 `json:"parentIdentity,omitempty"`
+	ParentID       string                 `json:"parentID,omitempty"`
+	Changes        map[string]interface{} `json:"changes,omitempty"`
+	Summary        string                 `json:"summary"`
+	RequestID      string                 `json:"requestID,omitempty"`
+	DryRun         bool                   `json:"dryRun,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+}
+
+// AuditHook is the prototype of the functions called with every mutating
+// operation done by a Session. See Session.SetAuditHook.
+type AuditHook func(*AuditEntry)
+
+// SetAuditHook sets the function called with every SaveEntity, CreateChild,
+// DeleteEntity and AssignChildren sent by the session, whether they succeed
+// or not. The operations rejected before being sent, by the validation for
+// instance, are not audited. The operations of the dry run mode are audited
+// with DryRun set, as they did not modify anything. Use nil to remove it.
+func (s *Session) SetAuditHook(hook AuditHook) {
+
+	s.auditHook = hook
+}
+
+// audit calls the audit hook, if any, with the given operation on the given object,
+// under the given parent if any, sent with the given request and body, that
+// returned the given error.
+func (s *Session) audit(operation AuditOperation, object Identifiable, parent Identifiable, request *http.Request, body []byte, berr *Error) {
+
+	if s.auditHook == nil {
+		return
+	}
+
+	entry := &AuditEntry{
+		Time:      clockOrSystem(s.clock).Now(),
+		Actor:     s.actor(),
+		Operation: operation,
+		Identity:  object.Identity().Name,
+		ID:        object.Identifier(),
+		RequestID: request.Header.Get(RequestIDHeader),
+		DryRun:    s.dryRun,
+	}
+
+	if parent != nil {
+		entry.ParentIdentity = parent.Identity().Name
+		entry.ParentID = parent.Identifier()
+	}
+
+	if berr != nil {
+		entry.Error = berr.Error()
+	}
+
+	switch operation {
+	case AuditCreate, AuditUpdate:
+		entry.Changes = auditedChanges(body)
+		entry.Summary = fmt.Sprintf("%s %s %s", operation, entry.Identity, entry.ID)
+		if len(entry.Changes) > 0 {
+			entry.Summary += ": " + strings.Join(sortedKeys(entry.Changes), ", ")
+		}
+	case AuditDelete:
+		entry.Summary = fmt.Sprintf("delete %s %s", entry.Identity, entry.ID)
+	}
+
+	s.auditHook(entry)
+}
+
+// auditAssign calls the audit hook, if any, with the assignment of the given children
+// of the given identity to the given parent, sent with the given request, that
+// returned the given error.
+func (s *Session) auditAssign(parent Identifiable, identity Identity, IDs []string, request *http.Request, berr *Error) {
+
+	if s.auditHook == nil {
+		return
+	}
+
+	entry := &AuditEntry{
+		Time:           clockOrSystem(s.clock).Now(),
+		Actor:          s.actor(),
+		Operation:      AuditAssign,
+		Identity:       identity.Name,
+		ParentIdentity: parent.Identity().Name,
+		ParentID:       parent.Identifier(),
+		Changes:        map[string]interface{}{identity.Category: IDs},
+		Summary:        fmt.Sprintf("assign %d %s to %s %s", len(IDs), identity.Category, parent.Identity().Name, parent.Identifier()),
+		RequestID:      request.Header.Get(RequestIDHeader),
+		DryRun:         s.dryRun,
+	}
+
+	if berr != nil {
+		entry.Error = berr.Error()
+	}
+
+	s.auditHook(entry)
+}
+
+// actor returns the name of the user of the session: its username, or the
+// common name of its certificate.
+func (s *Session) actor() string {
+
+	if s.Certificate == nil || len(s.Certificate.Certificate) == 0 {
+		return s.Username
+	}
+
+	leaf := s.Certificate.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(s.Certificate.Certificate[0]); err != nil {
+			return s.Username
+		}
+	}
+
+	return leaf.Subject.CommonName
+}
+
+// auditedChanges returns the attributes of the given body, without the
+// SystemAttributes, and with the values of the RedactedAttributes redacted.
+func auditedChanges(body []byte) map[string]interface{} {
+
+	changes := map[string]interface{}{}
+	if err := json.Unmarshal(body, &changes); err != nil {
+		return nil
+	}
+
+	for _, name := range SystemAttributes {
+		delete(changes, name)
+	}
+
+	redactValue(changes)
+
+	return changes
+}
+
+// sortedKeys returns the keys of the given map, sorted.
+func sortedKeys(m map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// AuditLog writes AuditEntries to a writer, one JSON entry per line.
+// Its Record method can be given to Session.SetAuditHook.
+type AuditLog struct {
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+// NewAuditLog returns a new *AuditLog writing to the given writer.
+func NewAuditLog(w io.Writer) *AuditLog {
+
+	return &AuditLog{writer: w}
+}
+
+// Record writes the given entry to the log.
+// Errors are logged to the default logger, as the operation is already done.
+func (l *AuditLog) Record(entry *AuditEntry) {
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		DefaultLogger().Errorf("Unable to encode audit entry: %s", err)
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, err := l.writer.Write(append(line, '\n')); err != nil {
+		DefaultLogger().Errorf("Unable to write audit entry: %s", err)
+	}
+}
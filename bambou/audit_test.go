@@ -0,0 +1,148 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAudit_Session(t *testing.T) {
+
+	Convey("Given I have a session with an audit hook", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "DELETE":
+				w.WriteHeader(http.StatusInternalServerError)
+			case r.Method == "POST":
+				w.Write([]byte(`[{"ID": "new", "name": "child"}]`))
+			default:
+				w.Write([]byte(`[{"ID": "xxx", "name": "local"}]`))
+			}
+		}))
+		defer ts.Close()
+
+		var entries []*AuditEntry
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetAuditHook(func(entry *AuditEntry) { entries = append(entries, entry) })
+
+		Convey("When I save an entity", func() {
+
+			session.SaveEntity(&FakeObject{ID: "xxx", Name: "local"})
+
+			Convey("Then the update should be audited", func() {
+				So(len(entries), ShouldEqual, 1)
+				So(entries[0].Actor, ShouldEqual, "username")
+				So(entries[0].Operation, ShouldEqual, AuditUpdate)
+				So(entries[0].Identity, ShouldEqual, "fake")
+				So(entries[0].ID, ShouldEqual, "xxx")
+				So(entries[0].Changes, ShouldResemble, map[string]interface{}{"name": "local"})
+				So(entries[0].Summary, ShouldEqual, "update fake xxx: name")
+				So(entries[0].RequestID, ShouldNotBeEmpty)
+				So(entries[0].Error, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I create a child", func() {
+
+			parent := NewFakeObject("parent")
+			session.CreateChild(parent, &FakeObject{Name: "child"})
+
+			Convey("Then the creation should be audited with the new ID", func() {
+				So(len(entries), ShouldEqual, 1)
+				So(entries[0].Operation, ShouldEqual, AuditCreate)
+				So(entries[0].ID, ShouldEqual, "new")
+				So(entries[0].ParentIdentity, ShouldEqual, "fake")
+				So(entries[0].ParentID, ShouldEqual, "parent")
+				So(entries[0].Summary, ShouldEqual, "create fake new: name")
+			})
+		})
+
+		Convey("When a deletion fails", func() {
+
+			session.DeleteEntity(NewFakeObject("xxx"))
+
+			Convey("Then the failure should be audited", func() {
+				So(len(entries), ShouldEqual, 1)
+				So(entries[0].Operation, ShouldEqual, AuditDelete)
+				So(entries[0].Summary, ShouldEqual, "delete fake xxx")
+				So(entries[0].Error, ShouldContainSubstring, "500")
+			})
+		})
+
+		Convey("When I assign children", func() {
+
+			session.AssignChildren(NewFakeObject("parent"), []Identifiable{NewFakeObject("a"), NewFakeObject("b")}, FakeIdentity)
+
+			Convey("Then the assignment should be audited", func() {
+				So(len(entries), ShouldEqual, 1)
+				So(entries[0].Operation, ShouldEqual, AuditAssign)
+				So(entries[0].Changes, ShouldResemble, map[string]interface{}{"fakes": []string{"a", "b"}})
+				So(entries[0].Summary, ShouldEqual, "assign 2 fakes to fake parent")
+			})
+		})
+
+		Convey("When I fetch an entity", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then nothing should be audited", func() {
+				So(entries, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestAudit_AuditLog(t *testing.T) {
+
+	Convey("Given I have an audit log", t, func() {
+
+		var buffer bytes.Buffer
+		log := NewAuditLog(&buffer)
+
+		Convey("When I record entries with secrets", func() {
+
+			log.Record(&AuditEntry{Operation: AuditUpdate, Identity: "user", ID: "a", Changes: auditedChanges([]byte(`{"ID":"a","password":"secret","name":"n"}`))})
+			log.Record(&AuditEntry{Operation: AuditDelete, Identity: "user", ID: "b"})
+
+			lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+
+			Convey("Then they should be written one per line without the secrets", func() {
+				So(len(lines), ShouldEqual, 2)
+				So(buffer.String(), ShouldNotContainSubstring, "secret")
+
+				entry := &AuditEntry{}
+				So(json.Unmarshal([]byte(lines[0]), entry), ShouldBeNil)
+				So(entry.Changes, ShouldResemble, map[string]interface{}{"password": "REDACTED", "name": "n"})
+			})
+		})
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package boltcache persists the bambou.Cache and bambou.Store to a bbolt
+// database, so CLI tools and restarting daemons start warm instead of listing
+// the whole VSD again.
+//
+//	db, err := boltcache.Open("/var/lib/controller/cache.db")
+//	if err != nil {
+//		return err
+//	}
+//	defer db.Close()
+//
+//	cache := bambou.NewCache(10*time.Minute, 10000)
+//	if err := cache.SetStore(db); err != nil {
+//		return err
+//	}
+//	session.SetCache(cache)
+package boltcache
+
+import (
+	"bytes"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	cacheBucket = []byte("cache")
+	storeBucket = []byte("store")
+	storeKey    = []byte("objects")
+)
+
+// DB is a bbolt database implementing bambou.CacheStore, that can also hold the content of a bambou.Store.
+type DB struct {
+	db *bolt.DB
+}
+
+var _ bambou.CacheStore = (*DB)(nil)
+
+// Open opens the database at the given path, creating it if needed.
+func Open(path string) (*DB, error) {
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(cacheBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(storeBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+
+	return d.db.Close()
+}
+
+// Put stores the given cache entry.
+func (d *DB) Put(key string, value []byte) error {
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).Put([]byte(key), value)
+	})
+}
+
+// Delete removes the given cache entry.
+func (d *DB) Delete(key string) error {
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).Delete([]byte(key))
+	})
+}
+
+// Walk calls the given function with every cache entry.
+func (d *DB) Walk(fn func(key string, value []byte) error) error {
+
+	return d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).ForEach(func(key []byte, value []byte) error {
+			return fn(string(key), append([]byte(nil), value...))
+		})
+	})
+}
+
+// SaveStore replaces the saved content of the store with the content of the given Store.
+func (d *DB) SaveStore(store *bambou.Store) error {
+
+	buffer := &bytes.Buffer{}
+	if err := store.Save(buffer); err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(storeBucket).Put(storeKey, buffer.Bytes())
+	})
+}
+
+// LoadStore adds the objects saved by SaveStore to the given Store, and returns
+// false if nothing was saved.
+func (d *DB) LoadStore(store *bambou.Store) (bool, error) {
+
+	var data []byte
+
+	d.db.View(func(tx *bolt.Tx) error {
+		data = append([]byte(nil), tx.Bucket(storeBucket).Get(storeKey)...)
+		return nil
+	})
+
+	if len(data) == 0 {
+		return false, nil
+	}
+
+	return true, store.Load(bytes.NewReader(data))
+}
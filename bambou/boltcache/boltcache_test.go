@@ -0,0 +1,164 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package boltcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	rootIdentity       = bambou.Identity{Name: "me", Category: "me"}
+	enterpriseIdentity = bambou.Identity{Name: "enterprise", Category: "enterprises"}
+)
+
+type root struct {
+	ID  string `json:"ID,omitempty"`
+	Key string `json:"APIKey,omitempty"`
+}
+
+func (o *root) Identity() bambou.Identity { return rootIdentity }
+func (o *root) Identifier() string        { return o.ID }
+func (o *root) SetIdentifier(ID string)   { o.ID = ID }
+func (o *root) APIKey() string            { return o.Key }
+func (o *root) SetAPIKey(key string)      { o.Key = key }
+
+type enterprise struct {
+	ID   string `json:"ID,omitempty"`
+	Name string `json:"name"`
+}
+
+func (o *enterprise) Identity() bambou.Identity { return enterpriseIdentity }
+func (o *enterprise) Identifier() string        { return o.ID }
+func (o *enterprise) SetIdentifier(ID string)   { o.ID = ID }
+
+func TestBoltCache_Cache(t *testing.T) {
+
+	Convey("Given I have a cache persisted to a database", t, func() {
+
+		hits := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.Write([]byte(`[{"ID": "xxx", "name": "acme"}]`))
+		}))
+		defer ts.Close()
+
+		path := filepath.Join(t.TempDir(), "cache.db")
+		db, err := Open(path)
+		So(err, ShouldBeNil)
+
+		cache := bambou.NewCache(time.Minute, 100)
+		So(cache.SetStore(db), ShouldBeNil)
+
+		session := bambou.NewSession("username", "password", "organization", ts.URL, &root{})
+		session.SetCache(cache)
+		session.FetchEntity(&enterprise{ID: "xxx"})
+		db.Close()
+
+		Convey("When I reopen the database in a new cache and fetch the entity", func() {
+
+			db, err := Open(path)
+			So(err, ShouldBeNil)
+			defer db.Close()
+
+			cache := bambou.NewCache(time.Minute, 100)
+			err = cache.SetStore(db)
+
+			session := bambou.NewSession("username", "password", "organization", ts.URL, &root{})
+			session.SetCache(cache)
+			object := &enterprise{ID: "xxx"}
+			session.FetchEntity(object)
+
+			Convey("Then it should be served by the cache", func() {
+				So(err, ShouldBeNil)
+				So(cache.Len(), ShouldEqual, 1)
+				So(object.Name, ShouldEqual, "acme")
+				So(hits, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I invalidate the entity and reopen the database", func() {
+
+			db, _ := Open(path)
+			cache := bambou.NewCache(time.Minute, 100)
+			cache.SetStore(db)
+			cache.Invalidate(enterpriseIdentity, "xxx")
+			db.Close()
+
+			db, _ = Open(path)
+			defer db.Close()
+			count := 0
+			db.Walk(func(string, []byte) error { count++; return nil })
+
+			Convey("Then the entry should be gone", func() {
+				So(count, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestBoltCache_Store(t *testing.T) {
+
+	Convey("Given I have a database", t, func() {
+
+		db, err := Open(filepath.Join(t.TempDir(), "store.db"))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		store := bambou.NewStore()
+		store.Track(enterpriseIdentity, func() bambou.Identifiable { return &enterprise{} })
+
+		Convey("When I load a store before saving any", func() {
+
+			found, err := db.LoadStore(store)
+
+			Convey("Then nothing should be found", func() {
+				So(err, ShouldBeNil)
+				So(found, ShouldBeFalse)
+			})
+		})
+
+		Convey("When I save a store and load it in another one", func() {
+
+			store.Add(&enterprise{ID: "xxx", Name: "acme"}, "")
+			So(db.SaveStore(store), ShouldBeNil)
+
+			loaded := bambou.NewStore()
+			loaded.Track(enterpriseIdentity, func() bambou.Identifiable { return &enterprise{} })
+			found, err := db.LoadStore(loaded)
+
+			Convey("Then it should get the objects", func() {
+				So(err, ShouldBeNil)
+				So(found, ShouldBeTrue)
+				So(loaded.Get("xxx").(*enterprise).Name, ShouldEqual, "acme")
+			})
+		})
+	})
+}
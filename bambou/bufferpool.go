@@ -0,0 +1,74 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which the buffers are not put back
+// in the pool, so a single huge response does not stay in memory for good.
+const maxPooledBufferSize = 1 << 20
+
+// bufferPool holds the buffers the responses are read into.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+
+	return buffer
+}
+
+// putBuffer puts the given buffer back in the pool.
+// Its content must not be used afterwards.
+func putBuffer(buffer *bytes.Buffer) {
+
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	bufferPool.Put(buffer)
+}
+
+// readBody reads the body of the given response into a buffer from the pool,
+// that must be given back with putBuffer once the body is decoded.
+func readBody(response *http.Response) *bytes.Buffer {
+
+	buffer := getBuffer()
+
+	if response.ContentLength > 0 && response.ContentLength <= maxPooledBufferSize {
+		buffer.Grow(int(response.ContentLength))
+	}
+
+	buffer.ReadFrom(response.Body)
+
+	return buffer
+}
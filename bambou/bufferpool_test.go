@@ -0,0 +1,117 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBufferPool_readBody(t *testing.T) {
+
+	Convey("Given I have a response", t, func() {
+
+		response := &http.Response{Body: ioutil.NopCloser(strings.NewReader(`[{"ID": "xxx"}]`)), ContentLength: 15}
+
+		Convey("When I read its body", func() {
+
+			buffer := readBody(response)
+
+			Convey("Then I should get its content", func() {
+				So(buffer.String(), ShouldEqual, `[{"ID": "xxx"}]`)
+			})
+
+			Convey("Then the buffer should be empty once reused", func() {
+				putBuffer(buffer)
+				So(getBuffer().Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I put back a huge buffer", func() {
+
+			buffer := bytes.NewBuffer(make([]byte, 0, 2*maxPooledBufferSize))
+			putBuffer(buffer)
+
+			Convey("Then it should not be reused", func() {
+				So(getBuffer(), ShouldNotPointTo, buffer)
+			})
+		})
+	})
+}
+
+func newBenchResponse(data []byte) *http.Response {
+
+	return &http.Response{Body: ioutil.NopCloser(bytes.NewReader(data)), ContentLength: int64(len(data))}
+}
+
+func BenchmarkBufferPool_ReadAll(b *testing.B) {
+
+	data := newBenchListing(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ioutil.ReadAll(newBenchResponse(data).Body)
+	}
+}
+
+func BenchmarkBufferPool_readBody(b *testing.B) {
+
+	data := newBenchListing(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		putBuffer(readBody(newBenchResponse(data)))
+	}
+}
+
+func BenchmarkBufferPool_FetchChildren(b *testing.B) {
+
+	data := newBenchListing(100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer ts.Close()
+
+	session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+	session.SetCodec(FastCodec)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var list benchObjectsList
+		if err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
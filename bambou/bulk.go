@@ -0,0 +1,66 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import "context"
+
+// BulkCreateChildren creates the given children under the given parent, running the
+// creations with the given Executor, or with DefaultExecutorWorkers if it is nil.
+// The result of the creation of each child is at its index in the BatchResult.
+func BulkCreateChildren(ctx context.Context, storer Storer, executor *Executor, parent Identifiable, children []Identifiable) *BatchResult {
+
+	return executorOrDefault(executor).Run(ctx, len(children), func(ctx context.Context, index int) *Error {
+		return storer.CreateChild(parent, children[index])
+	})
+}
+
+// BulkSaveEntities saves the given objects, running the updates with the given Executor,
+// or with DefaultExecutorWorkers if it is nil.
+// The result of the update of each object is at its index in the BatchResult.
+func BulkSaveEntities(ctx context.Context, storer Storer, executor *Executor, objects []Identifiable) *BatchResult {
+
+	return executorOrDefault(executor).Run(ctx, len(objects), func(ctx context.Context, index int) *Error {
+		return storer.SaveEntity(objects[index])
+	})
+}
+
+// BulkDeleteEntities deletes the given objects, running the deletions with the given Executor,
+// or with DefaultExecutorWorkers if it is nil.
+// The result of the deletion of each object is at its index in the BatchResult.
+func BulkDeleteEntities(ctx context.Context, storer Storer, executor *Executor, objects []Identifiable) *BatchResult {
+
+	return executorOrDefault(executor).Run(ctx, len(objects), func(ctx context.Context, index int) *Error {
+		return storer.DeleteEntity(objects[index])
+	})
+}
+
+// executorOrDefault returns the given executor, or a new one with DefaultExecutorWorkers if it is nil.
+func executorOrDefault(executor *Executor) *Executor {
+
+	if executor == nil {
+		return NewExecutor(DefaultExecutorWorkers)
+	}
+
+	return executor
+}
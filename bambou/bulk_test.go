@@ -0,0 +1,80 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBulk_Operations(t *testing.T) {
+
+	Convey("Given I have a session to a fake server", t, func() {
+
+		fs := newFakeServer()
+		defer fs.Close()
+		fs.failOn = "child-7"
+
+		session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+		parent := NewFakeObject("parent")
+
+		children := make([]Identifiable, 10)
+		for i := range children {
+			children[i] = &FakeObject{Name: fmt.Sprintf("child-%d", i)}
+		}
+
+		Convey("When I create children in bulk", func() {
+
+			result := BulkCreateChildren(context.Background(), session, NewExecutor(4), parent, children)
+
+			Convey("Then all but the failing one should be created", func() {
+				So(result.Succeeded(), ShouldEqual, 9)
+				So(result.Failures()[0].Index, ShouldEqual, 7)
+				So(fs.count(), ShouldEqual, 9)
+				So(children[0].Identifier(), ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When I save and delete the created children in bulk", func() {
+
+			BulkCreateChildren(context.Background(), session, nil, parent, children[:5])
+			for _, child := range children[:5] {
+				child.(*FakeObject).Name = "renamed"
+			}
+			saved := BulkSaveEntities(context.Background(), session, nil, children[:5])
+			renamed := fs.get(children[0].Identifier())["name"]
+			deleted := BulkDeleteEntities(context.Background(), session, nil, children[:5])
+
+			Convey("Then they should be updated then deleted", func() {
+				So(saved.Err(), ShouldBeNil)
+				So(renamed, ShouldEqual, "renamed")
+				So(deleted.Err(), ShouldBeNil)
+				So(fs.count(), ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,436 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheEntry is a response cached by a Cache: the body of an entity, or of a listing of children.
+type cacheEntry struct {
+	key      string
+	identity string
+	ID       string
+	body     []byte
+	etag     string
+	header   http.Header
+	expires  time.Time
+}
+
+// Cache is a read-through client-side cache of the responses to FetchEntity and
+// FetchChildren, for the workloads reading slowly changing objects over and over.
+// The entries expire after a TTL, and the least recently used ones are evicted
+// when the cache is full.
+//
+// The Session invalidates the entries of the objects it saves, creates, deletes and
+// assigns. The changes made by others can be applied by giving the Cache to
+// PushCenter.SetCache, or by invalidating the entries explicitly.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	disabled   map[string]bool
+	entries    map[string]*list.Element
+	lru        *list.List
+	store      CacheStore
+	clock      Clock
+	lock       sync.Mutex
+}
+
+// NewCache returns a new *Cache keeping the entries for the given TTL, and at most
+// the given number of entries. A TTL of 0 keeps the entries until they are invalidated
+// or evicted, and a maximum of 0 does not limit the number of entries.
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		disabled:   map[string]bool{},
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// SetCache sets the Cache used by FetchEntity and FetchChildren. Passing nil removes it.
+func (s *Session) SetCache(cache *Cache) {
+
+	s.cache = cache
+}
+
+// SetClock sets the Clock the entries expire with. The default is SystemClock.
+func (c *Cache) SetClock(clock Clock) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.clock = clock
+}
+
+// Disable stops caching the objects of the given identities. All identities are cached by default.
+func (c *Cache) Disable(identities ...Identity) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, identity := range identities {
+		c.disabled[identity.Name] = true
+		c.removeIdentity(identity.Name)
+	}
+}
+
+// Enable resumes caching the objects of the given identities.
+func (c *Cache) Enable(identities ...Identity) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, identity := range identities {
+		delete(c.disabled, identity.Name)
+	}
+}
+
+// Invalidate removes the object of the given identity and ID from the cache,
+// with the cached listings of the objects of the identity.
+// It implements the CacheInvalidator interface.
+func (c *Cache) Invalidate(identity Identity, ID string) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.remove(entityCacheKey(identity.Name, ID))
+	c.removeListings(identity.Name)
+}
+
+// InvalidateIdentity removes all the objects of the given identity from the cache.
+func (c *Cache) InvalidateIdentity(identity Identity) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.removeIdentity(identity.Name)
+}
+
+// Clear removes all the entries from the cache.
+func (c *Cache) Clear() {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key := range c.entries {
+		c.remove(key)
+	}
+}
+
+// Update replaces the cached version of the given object, and invalidates
+// the cached listings of its identity. It implements the CacheUpdater interface.
+// The root object is never cached, so that its API key is never kept in memory
+// or persisted to a CacheStore.
+func (c *Cache) Update(object Identifiable) {
+
+	if _, ok := object.(Rootable); ok {
+		return
+	}
+
+	body, err := json.Marshal([]Identifiable{object})
+	if err != nil {
+		c.Invalidate(object.Identity(), object.Identifier())
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.disabled[object.Identity().Name] {
+		return
+	}
+
+	c.removeListings(object.Identity().Name)
+	c.set(&cacheEntry{
+		key:      entityCacheKey(object.Identity().Name, object.Identifier()),
+		identity: object.Identity().Name,
+		ID:       object.Identifier(),
+		body:     body,
+	})
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() int {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.entries)
+}
+
+// get returns the unexpired entry with the given key, if any.
+func (c *Cache) get(key string) (*cacheEntry, bool) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && clockOrSystem(c.clock).Now().After(entry.expires) {
+		c.remove(key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry, true
+}
+
+// add adds the given entry, unless its identity is disabled.
+func (c *Cache) add(entry *cacheEntry) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.disabled[entry.identity] {
+		return
+	}
+
+	c.set(entry)
+}
+
+// enabled returns true if the objects of the identity with the given name are cached.
+func (c *Cache) enabled(identity string) bool {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return !c.disabled[identity]
+}
+
+// set adds or replaces the given entry and evicts the least recently used entries if needed.
+func (c *Cache) set(entry *cacheEntry) {
+
+	if c.ttl > 0 {
+		entry.expires = clockOrSystem(c.clock).Now().Add(c.ttl)
+	}
+
+	if element, ok := c.entries[entry.key]; ok {
+		c.lru.Remove(element)
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.persist(entry)
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back().Value.(*cacheEntry).key)
+	}
+}
+
+// remove removes the entry with the given key, if any.
+func (c *Cache) remove(key string) {
+
+	if element, ok := c.entries[key]; ok {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		c.unpersist(key)
+	}
+}
+
+// removeListings removes the listings of the identity with the given name.
+func (c *Cache) removeListings(identity string) {
+
+	for key, element := range c.entries {
+		if entry := element.Value.(*cacheEntry); entry.identity == identity && entry.ID == "" {
+			c.remove(key)
+		}
+	}
+}
+
+// removeIdentity removes the objects and listings of the identity with the given name.
+func (c *Cache) removeIdentity(identity string) {
+
+	for key, element := range c.entries {
+		if element.Value.(*cacheEntry).identity == identity {
+			c.remove(key)
+		}
+	}
+}
+
+// entityCacheKey returns the key of the entity of the given identity and ID.
+func entityCacheKey(identity string, ID string) string {
+
+	return "entity " + identity + "/" + ID
+}
+
+// listingCacheKey returns the key of the listing at the given URL, with the given fetching information.
+func listingCacheKey(listingURL string, info *FetchingInfo) string {
+
+	if info == nil {
+		return "listing " + listingURL
+	}
+
+	return "listing " + listingURL + "?" + strings.Join([]string{
+		info.Filter,
+		info.OrderBy,
+		strconv.Itoa(info.Page),
+		strconv.Itoa(info.PageSize),
+		strings.Join(info.GroupBy, ","),
+		info.query(url.Values{}).Encode(),
+	}, "|")
+}
+
+// invalidateCache removes the object of the given identity and ID, and the listings of the
+// identity, from the cache of the session, if any. Nothing is removed in dry run mode, since
+// the server is not modified.
+func (s *Session) invalidateCache(identity Identity, ID string) {
+
+	if s.cache != nil && !s.dryRun {
+		s.cache.Invalidate(identity, ID)
+	}
+}
+
+// invalidateCachedListings removes the listings of the given identity from the cache of the session,
+// if any. Nothing is removed in dry run mode, since the server is not modified.
+func (s *Session) invalidateCachedListings(identity Identity) {
+
+	if s.cache == nil || s.dryRun {
+		return
+	}
+
+	s.cache.lock.Lock()
+	defer s.cache.lock.Unlock()
+
+	s.cache.removeListings(identity.Name)
+}
+
+// Refresher is implemented by the Storer that can fetch an entity from the server
+// bypassing their client-side cache, like *Session.
+type Refresher interface {
+	RefreshEntity(Identifiable) *Error
+}
+
+// RefreshEntity fetches the given Identifiable from the server like FetchEntity, but never
+// from the cache, which is updated with the fetched object. The pollers use it to see the
+// changes of the objects.
+func (s *Session) RefreshEntity(object Identifiable) *Error {
+
+	if cache := s.cache; cache != nil {
+		cache.lock.Lock()
+		cache.remove(entityCacheKey(object.Identity().Name, object.Identifier()))
+		cache.lock.Unlock()
+	}
+
+	return s.FetchEntity(object)
+}
+
+// refreshEntity fetches the given Identifiable with the given Storer, bypassing its cache if it is a Refresher.
+func refreshEntity(storer Storer, object Identifiable) *Error {
+
+	if refresher, ok := storer.(Refresher); ok {
+		return refresher.RefreshEntity(object)
+	}
+
+	return storer.FetchEntity(object)
+}
+
+// fetchEntityBody returns the body and ETag of the object of the given identity and
+// ID at the given URL, from the cache of the session if possible.
+// The given function must be called once the body is decoded.
+func (s *Session) fetchEntityBody(url string, identity Identity, ID string) ([]byte, string, func(), *Error) {
+
+	cache := s.cache
+	if cache == nil || !cache.enabled(identity.Name) {
+		return s.fetchBody(url)
+	}
+
+	key := entityCacheKey(identity.Name, ID)
+	if entry, ok := cache.get(key); ok {
+		return entry.body, entry.etag, func() {}, nil
+	}
+
+	body, etag, release, berr := s.fetchBody(url)
+	if berr != nil {
+		return nil, "", nil, berr
+	}
+
+	entry := &cacheEntry{key: key, identity: identity.Name, ID: ID, body: append([]byte(nil), body...), etag: etag}
+	release()
+	cache.add(entry)
+
+	return entry.body, etag, func() {}, nil
+}
+
+// fetchChildrenBody returns the body of the listing of the children of the given identity at
+// the given URL, from the cache of the session if possible, and fills the given information.
+// The given function must be called once the body is decoded.
+func (s *Session) fetchChildrenBody(url string, identity Identity, info *FetchingInfo) ([]byte, func(), *Error) {
+
+	cache := s.cache
+	if cache == nil || !cache.enabled(identity.Name) {
+		body, _, release, berr := s.getChildrenBody(url, info)
+		return body, release, berr
+	}
+
+	key := listingCacheKey(url, info)
+	if entry, ok := cache.get(key); ok {
+		s.readHeaders(&http.Response{Header: entry.header}, info)
+		return entry.body, func() {}, nil
+	}
+
+	body, header, release, berr := s.getChildrenBody(url, info)
+	if berr != nil {
+		return nil, nil, berr
+	}
+
+	entry := &cacheEntry{key: key, identity: identity.Name, body: append([]byte(nil), body...), header: header}
+	release()
+	cache.add(entry)
+
+	return entry.body, func() {}, nil
+}
+
+// getChildrenBody sends a GET request for the children at the given URL, with
+// the given fetching information, and returns the body and headers of the response.
+// The body is a pooled buffer, released by the given function.
+func (s *Session) getChildrenBody(url string, info *FetchingInfo) ([]byte, http.Header, func(), *Error) {
+
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, nil, NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	response, berr := s.send(request, info)
+	if berr != nil {
+		return nil, nil, nil, berr
+	}
+	defer response.Body.Close()
+
+	received := readBody(response)
+
+	return received.Bytes(), response.Header, func() { putBuffer(received) }, nil
+}
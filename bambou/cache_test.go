@@ -0,0 +1,226 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCache_Session(t *testing.T) {
+
+	Convey("Given I have a session with a cache", t, func() {
+
+		var lock sync.Mutex
+		hits := map[string]int{}
+		name := "remote"
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			hits[r.Method+" "+r.URL.Path]++
+
+			switch r.Method {
+			case "GET":
+				w.Header().Set("X-Nuage-Count", "1")
+				w.Write([]byte(`[{"ID": "xxx", "name": "` + name + `"}]`))
+			case "POST":
+				w.Write([]byte(`[{"ID": "yyy"}]`))
+			}
+		}))
+		defer ts.Close()
+
+		cache := NewCache(time.Minute, 10)
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetCache(cache)
+
+		hitsOf := func(key string) int {
+			lock.Lock()
+			defer lock.Unlock()
+			return hits[key]
+		}
+
+		Convey("When I start the session again after a reset", func() {
+
+			So(session.Start(), ShouldBeNil)
+			session.Reset()
+			So(session.Start(), ShouldBeNil)
+			session.Reset()
+			err := session.Start()
+
+			Convey("Then every start should authenticate with the server", func() {
+				So(err, ShouldBeNil)
+				So(hitsOf("GET /root"), ShouldEqual, 3)
+				So(cache.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I fetch an entity twice", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			object := NewFakeObject("xxx")
+			err := session.FetchEntity(object)
+
+			Convey("Then the second fetch should be served by the cache", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "remote")
+				So(hitsOf("GET /fakes/xxx"), ShouldEqual, 1)
+				So(cache.Len(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I fetch children with and without information", func() {
+
+			var list []*FakeObject
+			session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, nil)
+			list = nil
+			err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, &FetchingInfo{Filter: "a"})
+
+			Convey("Then the fetches should not share the entries", func() {
+				So(err, ShouldBeNil)
+				So(len(list), ShouldEqual, 1)
+				So(hitsOf("GET /fakes/parent/fakes"), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I fetch children twice with the same information", func() {
+
+			var list []*FakeObject
+			session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, &FetchingInfo{Filter: "a"})
+			info := &FetchingInfo{Filter: "a"}
+			err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, info)
+
+			Convey("Then the second fetch should be served by the cache with its information", func() {
+				So(err, ShouldBeNil)
+				So(hitsOf("GET /fakes/parent/fakes"), ShouldEqual, 1)
+				So(info.TotalCount, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I save an entity between two fetches", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			session.SaveEntity(&FakeObject{ID: "xxx", Name: "local"})
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then the entry should be invalidated", func() {
+				So(hitsOf("GET /fakes/xxx"), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I create a child between two listings", func() {
+
+			var list []*FakeObject
+			session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, nil)
+			session.CreateChild(NewFakeObject("parent"), &FakeObject{Name: "new"})
+			session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, nil)
+
+			Convey("Then the listing should be invalidated", func() {
+				So(hitsOf("GET /fakes/parent/fakes"), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I disable the cache of the identity", func() {
+
+			cache.Disable(FakeIdentity)
+			session.FetchEntity(NewFakeObject("xxx"))
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then nothing should be cached", func() {
+				So(hitsOf("GET /fakes/xxx"), ShouldEqual, 2)
+				So(cache.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the PushCenter updates a cached entity", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			invalidateCache(cache, &Event{Type: EventTypeUpdate, EntityType: "fake", Entities: []Identifiable{&FakeObject{ID: "xxx", Name: "updated"}}})
+			object := NewFakeObject("xxx")
+			session.FetchEntity(object)
+
+			Convey("Then the fetch should return the updated entity", func() {
+				So(hitsOf("GET /fakes/xxx"), ShouldEqual, 1)
+				So(object.Name, ShouldEqual, "updated")
+			})
+		})
+	})
+}
+
+func TestCache_Cache(t *testing.T) {
+
+	Convey("Given I have a cache", t, func() {
+
+		cache := NewCache(20*time.Millisecond, 2)
+		add := func(ID string) {
+			cache.add(&cacheEntry{key: entityCacheKey("fake", ID), identity: "fake", ID: ID})
+		}
+
+		Convey("When I add more entries than the maximum", func() {
+
+			add("a")
+			add("b")
+			cache.get(entityCacheKey("fake", "a"))
+			add("c")
+
+			Convey("Then the least recently used entry should be evicted", func() {
+				_, okA := cache.get(entityCacheKey("fake", "a"))
+				_, okB := cache.get(entityCacheKey("fake", "b"))
+				So(okA, ShouldBeTrue)
+				So(okB, ShouldBeFalse)
+				So(cache.Len(), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When an entry is older than the TTL", func() {
+
+			add("a")
+			time.Sleep(30 * time.Millisecond)
+
+			Convey("Then it should be expired", func() {
+				_, ok := cache.get(entityCacheKey("fake", "a"))
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When I invalidate an identity and clear the cache", func() {
+
+			add("a")
+			cache.InvalidateIdentity(FakeIdentity)
+			empty := cache.Len()
+			add("b")
+			cache.Clear()
+
+			Convey("Then the entries should be removed", func() {
+				So(empty, ShouldEqual, 0)
+				So(cache.Len(), ShouldEqual, 0)
+			})
+		})
+	})
+}
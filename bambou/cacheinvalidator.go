@@ -0,0 +1,65 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+// CacheInvalidator is the interface of the client-side caches of entities
+// the PushCenter can keep coherent with the server.
+type CacheInvalidator interface {
+	Invalidate(identity Identity, ID string)
+}
+
+// CacheUpdater is the interface of the CacheInvalidator that can also
+// replace a cached entity with a new version of it.
+type CacheUpdater interface {
+	CacheInvalidator
+	Update(Identifiable)
+}
+
+// invalidateCache updates the given cache according to the given event.
+// Deleted entities are invalidated. Updated entities are refreshed if the
+// cache is a CacheUpdater and the entities were decoded, invalidated otherwise.
+func invalidateCache(cache CacheInvalidator, event *Event) {
+
+	if event.Type != EventTypeUpdate && event.Type != EventTypeDelete {
+		return
+	}
+
+	if updater, ok := cache.(CacheUpdater); ok && event.Type == EventTypeUpdate && event.Entities != nil {
+		for _, entity := range event.Entities {
+			updater.Update(entity)
+		}
+		return
+	}
+
+	identity, ok := IdentityFromName(event.EntityType)
+	if !ok {
+		identity = Identity{Name: event.EntityType}
+	}
+
+	for _, data := range event.DataMap {
+		if ID, ok := data["ID"].(string); ok {
+			cache.Invalidate(identity, ID)
+		}
+	}
+}
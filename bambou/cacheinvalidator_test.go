@@ -0,0 +1,123 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeCache is a CacheUpdater recording the calls.
+type fakeCache struct {
+	invalidated []string
+	updated     []Identifiable
+}
+
+func (c *fakeCache) Invalidate(identity Identity, ID string) {
+	c.invalidated = append(c.invalidated, identity.Name+"/"+ID)
+}
+
+func (c *fakeCache) Update(object Identifiable) {
+	c.updated = append(c.updated, object)
+}
+
+// fakeInvalidator is a CacheInvalidator recording the calls.
+type fakeInvalidator struct {
+	invalidated []string
+}
+
+func (c *fakeInvalidator) Invalidate(identity Identity, ID string) {
+	c.invalidated = append(c.invalidated, identity.Name+"/"+ID)
+}
+
+func TestCacheInvalidator_invalidateCache(t *testing.T) {
+
+	Convey("Given I have a cache invalidator", t, func() {
+
+		cache := &fakeInvalidator{}
+
+		Convey("When I give it a delete event", func() {
+
+			invalidateCache(cache, &Event{EntityType: "fake", Type: EventTypeDelete, DataMap: []map[string]interface{}{{"ID": "x"}}})
+
+			Convey("Then the entity should be invalidated", func() {
+				So(cache.invalidated, ShouldResemble, []string{"fake/x"})
+			})
+		})
+
+		Convey("When I give it an update event", func() {
+
+			invalidateCache(cache, &Event{EntityType: "fake", Type: EventTypeUpdate, DataMap: []map[string]interface{}{{"ID": "x"}}})
+
+			Convey("Then the entity should be invalidated", func() {
+				So(cache.invalidated, ShouldResemble, []string{"fake/x"})
+			})
+		})
+
+		Convey("When I give it a create event", func() {
+
+			invalidateCache(cache, &Event{EntityType: "fake", Type: EventTypeCreate, DataMap: []map[string]interface{}{{"ID": "x"}}})
+
+			Convey("Then nothing should be invalidated", func() {
+				So(cache.invalidated, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given I have a cache updater", t, func() {
+
+		cache := &fakeCache{}
+		object := &FakeObject{ID: "x"}
+
+		Convey("When I give it an update event with decoded entities", func() {
+
+			invalidateCache(cache, &Event{EntityType: "fake", Type: EventTypeUpdate, DataMap: []map[string]interface{}{{"ID": "x"}}, Entities: IdentifiablesList{object}})
+
+			Convey("Then the entity should be updated", func() {
+				So(cache.updated, ShouldResemble, []Identifiable{object})
+				So(cache.invalidated, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I give it an update event without decoded entities", func() {
+
+			invalidateCache(cache, &Event{EntityType: "fake", Type: EventTypeUpdate, DataMap: []map[string]interface{}{{"ID": "x"}}})
+
+			Convey("Then the entity should be invalidated", func() {
+				So(cache.invalidated, ShouldResemble, []string{"fake/x"})
+				So(cache.updated, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I give it a delete event with decoded entities", func() {
+
+			invalidateCache(cache, &Event{EntityType: "fake", Type: EventTypeDelete, DataMap: []map[string]interface{}{{"ID": "x"}}, Entities: IdentifiablesList{object}})
+
+			Convey("Then the entity should be invalidated", func() {
+				So(cache.invalidated, ShouldResemble, []string{"fake/x"})
+			})
+		})
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// CacheStore is the interface of the embedded stores a Cache can persist its
+// entries to, so the processes restarting with the same store start with a
+// warm cache. See Cache.SetStore, and the boltcache package.
+type CacheStore interface {
+
+	// Put stores the given value under the given key.
+	Put(key string, value []byte) error
+
+	// Delete removes the value stored under the given key, if any.
+	Delete(key string) error
+
+	// Walk calls the given function with every key and value stored.
+	Walk(fn func(key string, value []byte) error) error
+}
+
+// persistedCacheEntry is the form of the cache entries in a CacheStore.
+type persistedCacheEntry struct {
+	Identity string      `json:"identity"`
+	ID       string      `json:"ID,omitempty"`
+	Body     []byte      `json:"body"`
+	ETag     string      `json:"etag,omitempty"`
+	Header   http.Header `json:"header,omitempty"`
+	Expires  time.Time   `json:"expires"`
+}
+
+// SetStore makes the cache persist its entries to the given store, after loading
+// the unexpired entries already there, up to the maximum number of entries.
+// Passing nil stops the persistence.
+func (c *Cache) SetStore(store CacheStore) error {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.store = nil
+	if store == nil {
+		return nil
+	}
+
+	now := clockOrSystem(c.clock).Now()
+	var expired []string
+
+	err := store.Walk(func(key string, value []byte) error {
+
+		persisted := persistedCacheEntry{}
+		if err := json.Unmarshal(value, &persisted); err != nil {
+			expired = append(expired, key)
+			return nil
+		}
+
+		if (!persisted.Expires.IsZero() && now.After(persisted.Expires)) || c.disabled[persisted.Identity] {
+			expired = append(expired, key)
+			return nil
+		}
+
+		entry := &cacheEntry{
+			key:      key,
+			identity: persisted.Identity,
+			ID:       persisted.ID,
+			body:     persisted.Body,
+			etag:     persisted.ETag,
+			header:   persisted.Header,
+			expires:  persisted.Expires,
+		}
+		c.entries[key] = c.lru.PushFront(entry)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range expired {
+		store.Delete(key)
+	}
+
+	c.store = store
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back().Value.(*cacheEntry).key)
+	}
+
+	return nil
+}
+
+// persist writes the given entry to the store, if any.
+func (c *Cache) persist(entry *cacheEntry) {
+
+	if c.store == nil {
+		return
+	}
+
+	value, err := json.Marshal(&persistedCacheEntry{
+		Identity: entry.identity,
+		ID:       entry.ID,
+		Body:     entry.body,
+		ETag:     entry.etag,
+		Header:   entry.header,
+		Expires:  entry.expires,
+	})
+	if err != nil {
+		DefaultLogger().Errorf("Unable to encode cache entry %s: %s", entry.key, err)
+		return
+	}
+
+	if err := c.store.Put(entry.key, value); err != nil {
+		DefaultLogger().Errorf("Unable to persist cache entry %s: %s", entry.key, err)
+	}
+}
+
+// unpersist removes the entry with the given key from the store, if any.
+func (c *Cache) unpersist(key string) {
+
+	if c.store == nil {
+		return
+	}
+
+	if err := c.store.Delete(key); err != nil {
+		DefaultLogger().Errorf("Unable to remove cache entry %s: %s", key, err)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mapCacheStore is a CacheStore keeping the entries in memory.
+type mapCacheStore map[string][]byte
+
+func (m mapCacheStore) Put(key string, value []byte) error { m[key] = value; return nil }
+func (m mapCacheStore) Delete(key string) error            { delete(m, key); return nil }
+
+func (m mapCacheStore) Walk(fn func(key string, value []byte) error) error {
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := fn(key, m[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestCacheStore_Cache(t *testing.T) {
+
+	Convey("Given I have a cache with a store", t, func() {
+
+		store := mapCacheStore{}
+		cache := NewCache(time.Minute, 10)
+		cache.SetStore(store)
+
+		Convey("When I update an entry", func() {
+
+			cache.Update(&FakeObject{ID: "xxx", Name: "name"})
+
+			Convey("Then it should be persisted", func() {
+				persisted := persistedCacheEntry{}
+				So(json.Unmarshal(store[entityCacheKey("fake", "xxx")], &persisted), ShouldBeNil)
+				So(persisted.Identity, ShouldEqual, "fake")
+				So(string(persisted.Body), ShouldEqual, `[{"ID":"xxx","name":"name"}]`)
+			})
+
+			Convey("Then it should be loaded by another cache", func() {
+				other := NewCache(time.Minute, 10)
+				So(other.SetStore(store), ShouldBeNil)
+				entry, ok := other.get(entityCacheKey("fake", "xxx"))
+				So(ok, ShouldBeTrue)
+				So(entry.ID, ShouldEqual, "xxx")
+			})
+		})
+
+		Convey("When I update the root object", func() {
+
+			root := NewFakeRootObject()
+			root.Token = "secret"
+			cache.Update(root)
+
+			Convey("Then its API key should not be persisted", func() {
+				So(cache.Len(), ShouldEqual, 0)
+				So(store, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I start a session using the cache", func() {
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[{"ID": "root", "APIKey": "secret"}]`))
+			}))
+			defer ts.Close()
+
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+			session.SetCache(cache)
+			err := session.Start()
+
+			Convey("Then the root object should not be persisted", func() {
+				So(err, ShouldBeNil)
+				So(session.Root().APIKey(), ShouldEqual, "secret")
+				So(store, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I clear the cache", func() {
+
+			cache.Update(&FakeObject{ID: "xxx"})
+			cache.Clear()
+
+			Convey("Then the store should be empty", func() {
+				So(store, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the store has expired and invalid entries", func() {
+
+			expired, _ := json.Marshal(&persistedCacheEntry{Identity: "fake", Expires: time.Now().Add(-time.Minute)})
+			store["expired"] = expired
+			store["invalid"] = []byte("{")
+
+			other := NewCache(time.Minute, 10)
+			other.SetStore(store)
+
+			Convey("Then they should be dropped", func() {
+				So(other.Len(), ShouldEqual, 0)
+				So(store, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the store has more entries than the maximum", func() {
+
+			for _, ID := range []string{"a", "b", "c"} {
+				cache.Update(&FakeObject{ID: ID})
+			}
+
+			other := NewCache(time.Minute, 2)
+			other.SetStore(store)
+
+			Convey("Then the extra entries should be evicted", func() {
+				So(other.Len(), ShouldEqual, 2)
+				So(len(store), ShouldEqual, 2)
+			})
+		})
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// CapturedRequest is the exact serialized form of a request sent by a Session.
+type CapturedRequest struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    []byte
+}
+
+// RequestHook is the prototype of the functions called with every request
+// a Session is about to send. See Session.SetRequestHook.
+type RequestHook func(*CapturedRequest)
+
+// SetRequestHook sets the function called with every request the session is about
+// to send, after its headers are set. In dry run mode, it is also called with the
+// requests that are not sent. Use nil to remove it.
+func (s *Session) SetRequestHook(hook RequestHook) {
+
+	s.requestHook = hook
+}
+
+// captureRequest calls the request hook, if any, with the given request.
+// The body of the request is read and replaced, so it can still be sent.
+func (s *Session) captureRequest(request *http.Request) {
+
+	if s.requestHook == nil {
+		return
+	}
+
+	var body []byte
+	if request.Body != nil {
+		body, _ = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	s.requestHook(&CapturedRequest{
+		Method:  request.Method,
+		URL:     request.URL.String(),
+		Headers: request.Header.Clone(),
+		Body:    body,
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCapture_RequestHook(t *testing.T) {
+
+	Convey("Given I have a session with a request hook", t, func() {
+
+		var received []byte
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`[{"ID": "xxx", "name": "new"}]`))
+		}))
+		defer ts.Close()
+
+		var captured []*CapturedRequest
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetRequestHook(func(request *CapturedRequest) { captured = append(captured, request) })
+
+		Convey("When I create a child", func() {
+
+			err := session.CreateChild(NewFakeObject("yyy"), &FakeObject{Name: "new"})
+
+			Convey("Then the hook should get the request", func() {
+				So(err, ShouldBeNil)
+				So(len(captured), ShouldEqual, 1)
+				So(captured[0].Method, ShouldEqual, "POST")
+				So(captured[0].URL, ShouldEqual, ts.URL+"/fakes/yyy/fakes")
+				So(captured[0].Headers.Get("X-Nuage-Organization"), ShouldEqual, "organization")
+				So(string(captured[0].Body), ShouldContainSubstring, `"name":"new"`)
+			})
+
+			Convey("Then the server should still get the body", func() {
+				So(string(received), ShouldEqual, string(captured[0].Body))
+			})
+		})
+
+		Convey("When I delete an entity in dry run mode", func() {
+
+			session.SetDryRun(true)
+			err := session.DeleteEntity(NewFakeObject("xxx"))
+
+			Convey("Then the hook should get the request that was not sent", func() {
+				So(err, ShouldBeNil)
+				So(len(captured), ShouldEqual, 1)
+				So(captured[0].Method, ShouldEqual, "DELETE")
+			})
+		})
+
+		Convey("When I remove the hook", func() {
+
+			session.SetRequestHook(nil)
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then nothing should be captured", func() {
+				So(captured, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package cassette records the HTTP interactions of a bambou.Session with a
+// server to a fixture file, and replays them, so tests can run deterministically
+// without the server. The secrets, like the Authorization header and the API
+// keys, are redacted from the fixtures.
+//
+//	recorder, err := cassette.New("fixtures/enterprises.json", cassette.ModeAuto)
+//	session.WrapTransport(recorder.Wrap)
+//	...
+//	recorder.Save()
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+// Supported values for Mode.
+const (
+	// ModeReplay replays the interactions of the fixture file, and never sends any request.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends the requests and records the interactions.
+	ModeRecord
+
+	// ModeAuto replays the interactions if the fixture file exists, and records them otherwise.
+	ModeAuto
+)
+
+// Redacted is the value replacing the redacted secrets.
+const Redacted = "REDACTED"
+
+// DefaultRedactedHeaders are the headers redacted by default.
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Nuage-Token", "X-Nuage-OTP"}
+
+// DefaultRedactedAttributes are the JSON attributes redacted by default from the bodies.
+var DefaultRedactedAttributes = []string{"APIKey", "password"}
+
+// Request is a recorded request.
+type Request struct {
+	Method  string      `json:"method"`
+	URI     string      `json:"uri"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying interactions.
+type Recorder struct {
+	path               string
+	recording          bool
+	next               http.RoundTripper
+	interactions       []*Interaction
+	replayed           []bool
+	redactedHeaders    []string
+	redactedAttributes []string
+	lock               sync.Mutex
+}
+
+// New returns a new *Recorder using the fixture file at the given path, in the given Mode.
+// In replay mode, the fixture file is loaded.
+func New(path string, mode Mode) (*Recorder, error) {
+
+	r := &Recorder{
+		path:               path,
+		next:               http.DefaultTransport,
+		redactedHeaders:    DefaultRedactedHeaders,
+		redactedAttributes: DefaultRedactedAttributes,
+	}
+
+	if mode == ModeAuto {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			mode = ModeRecord
+		} else {
+			mode = ModeReplay
+		}
+	}
+
+	if mode == ModeRecord {
+		r.recording = true
+		return r, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	r.replayed = make([]bool, len(r.interactions))
+
+	return r, nil
+}
+
+// Wrap sets the http.RoundTripper the requests are sent with when recording, and returns the Recorder.
+// It is meant to be given to bambou.Session.WrapTransport.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+
+	r.next = next
+
+	return r
+}
+
+// Recording returns true if the Recorder records the interactions, and false if it replays them.
+func (r *Recorder) Recording() bool {
+
+	return r.recording
+}
+
+// RedactHeaders sets the headers redacted from the recorded interactions.
+// The default is DefaultRedactedHeaders.
+func (r *Recorder) RedactHeaders(headers ...string) {
+
+	r.redactedHeaders = headers
+}
+
+// RedactAttributes sets the JSON attributes redacted from the recorded bodies, at any depth.
+// The default is DefaultRedactedAttributes.
+func (r *Recorder) RedactAttributes(attributes ...string) {
+
+	r.redactedAttributes = attributes
+}
+
+// Interactions returns the recorded or loaded interactions.
+func (r *Recorder) Interactions() []*Interaction {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]*Interaction{}, r.interactions...)
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	if r.recording {
+		return r.record(request)
+	}
+
+	return r.replay(request)
+}
+
+// Save writes the recorded interactions to the fixture file. It does nothing when replaying.
+func (r *Recorder) Save() error {
+
+	if !r.recording {
+		return nil
+	}
+
+	r.lock.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// record sends the given request and records the interaction, redacted.
+func (r *Recorder) record(request *http.Request) (*http.Response, error) {
+
+	var requestBody []byte
+	if request.Body != nil {
+		requestBody, _ = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	response, err := r.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := &Interaction{
+		Request: Request{
+			Method:  request.Method,
+			URI:     request.URL.RequestURI(),
+			Headers: r.redactHeaders(request.Header),
+			Body:    r.redactBody(requestBody),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Headers:    r.redactHeaders(response.Header),
+			Body:       r.redactBody(responseBody),
+		},
+	}
+
+	r.lock.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.lock.Unlock()
+
+	return response, nil
+}
+
+// replay returns the response of the first interaction not replayed yet
+// with the same method and URI as the given request.
+func (r *Recorder) replay(request *http.Request) (*http.Response, error) {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, interaction := range r.interactions {
+
+		if r.replayed[i] || interaction.Request.Method != request.Method || interaction.Request.URI != request.URL.RequestURI() {
+			continue
+		}
+
+		r.replayed[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Headers,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette %s: no interaction recorded for %s %s", r.path, request.Method, request.URL.RequestURI())
+}
+
+// redactHeaders returns a copy of the given headers with the redacted headers redacted.
+func (r *Recorder) redactHeaders(headers http.Header) http.Header {
+
+	redacted := http.Header{}
+	for name, values := range headers {
+		redacted[name] = append([]string{}, values...)
+	}
+
+	for _, name := range r.redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, Redacted)
+		}
+	}
+
+	return redacted
+}
+
+// redactBody returns the given body with the redacted attributes redacted, if it is JSON.
+func (r *Recorder) redactBody(body []byte) string {
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return string(body)
+	}
+
+	if !r.redactValue(value) {
+		return string(body)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+
+	return string(data)
+}
+
+// redactValue redacts the redacted attributes of the given decoded JSON value, and returns true if any was.
+func (r *Recorder) redactValue(value interface{}) bool {
+
+	redacted := false
+
+	switch v := value.(type) {
+
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.isRedactedAttribute(key) {
+				if item != nil && item != "" {
+					v[key] = Redacted
+					redacted = true
+				}
+				continue
+			}
+			redacted = r.redactValue(item) || redacted
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			redacted = r.redactValue(item) || redacted
+		}
+	}
+
+	return redacted
+}
+
+func (r *Recorder) isRedactedAttribute(name string) bool {
+
+	for _, attribute := range r.redactedAttributes {
+		if strings.EqualFold(attribute, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package cassette
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	rootIdentity       = bambou.Identity{Name: "me", Category: "me"}
+	enterpriseIdentity = bambou.Identity{Name: "enterprise", Category: "enterprises"}
+)
+
+type root struct {
+	ID  string `json:"ID,omitempty"`
+	Key string `json:"APIKey,omitempty"`
+}
+
+func (o *root) Identity() bambou.Identity { return rootIdentity }
+func (o *root) Identifier() string        { return o.ID }
+func (o *root) SetIdentifier(ID string)   { o.ID = ID }
+func (o *root) APIKey() string            { return o.Key }
+func (o *root) SetAPIKey(key string)      { o.Key = key }
+
+type enterprise struct {
+	ID   string `json:"ID,omitempty"`
+	Name string `json:"name"`
+}
+
+func (o *enterprise) Identity() bambou.Identity { return enterpriseIdentity }
+func (o *enterprise) Identifier() string        { return o.ID }
+func (o *enterprise) SetIdentifier(ID string)   { o.ID = ID }
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+
+	Convey("Given I have a fake VSD and a fixture path", t, func() {
+
+		s := fakevsd.New("admin", "secret", "csp")
+		defer s.Close()
+
+		dir, _ := ioutil.TempDir("", "cassette")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "fixture.json")
+
+		Convey("When I record a session creating and fetching an enterprise", func() {
+
+			recorder, err := New(path, ModeAuto)
+			So(err, ShouldBeNil)
+			So(recorder.Recording(), ShouldBeTrue)
+
+			r := &root{}
+			session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+			session.WrapTransport(recorder.Wrap)
+
+			So(session.Start(), ShouldBeNil)
+			e := &enterprise{Name: "acme"}
+			So(session.CreateChild(r, e), ShouldBeNil)
+			So(session.FetchEntity(&enterprise{ID: e.ID}), ShouldBeNil)
+			So(recorder.Save(), ShouldBeNil)
+
+			data, _ := ioutil.ReadFile(path)
+
+			Convey("Then the interactions should be recorded", func() {
+				So(len(recorder.Interactions()), ShouldEqual, 3)
+				So(recorder.Interactions()[1].Request.Method, ShouldEqual, "POST")
+				So(recorder.Interactions()[1].Response.StatusCode, ShouldEqual, 201)
+			})
+
+			Convey("Then the secrets should be redacted from the fixture", func() {
+				So(string(data), ShouldContainSubstring, Redacted)
+				So(string(data), ShouldNotContainSubstring, s.APIKey())
+				So(recorder.Interactions()[0].Request.Headers.Get("Authorization"), ShouldEqual, Redacted)
+			})
+
+			Convey("When I replay it without the server", func() {
+
+				s.Close()
+
+				replayer, err := New(path, ModeAuto)
+				So(err, ShouldBeNil)
+				So(replayer.Recording(), ShouldBeFalse)
+
+				r := &root{}
+				session := bambou.NewSession("admin", "secret", "csp", "http://replay.invalid", r)
+				session.WrapTransport(replayer.Wrap)
+
+				serr := session.Start()
+				e := &enterprise{Name: "acme"}
+				cerr := session.CreateChild(r, e)
+				fetched := &enterprise{ID: e.ID}
+				ferr := session.FetchEntity(fetched)
+
+				Convey("Then I should get the recorded responses", func() {
+					So(serr, ShouldBeNil)
+					So(r.Key, ShouldEqual, Redacted)
+					So(cerr, ShouldBeNil)
+					So(ferr, ShouldBeNil)
+					So(fetched.Name, ShouldEqual, "acme")
+				})
+
+				Convey("When I send a request that was not recorded", func() {
+
+					err := session.FetchEntity(fetched)
+
+					Convey("Then I should get an error", func() {
+						So(err, ShouldNotBeNil)
+					})
+				})
+			})
+		})
+
+		Convey("When I replay a fixture that does not exist", func() {
+
+			_, err := New(path, ModeReplay)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestRecorder_Redaction(t *testing.T) {
+
+	Convey("Given I have a recorder", t, func() {
+
+		recorder, _ := New("unused.json", ModeRecord)
+
+		Convey("When I redact a body with nested secrets", func() {
+
+			body := recorder.redactBody([]byte(`[{"name":"a","apikey":"k","children":[{"password":"p"}]}]`))
+
+			Convey("Then they should be redacted at any depth", func() {
+				So(body, ShouldEqual, `[{"apikey":"REDACTED","children":[{"password":"REDACTED"}],"name":"a"}]`)
+			})
+		})
+
+		Convey("When I redact a body that is not JSON", func() {
+
+			body := recorder.redactBody([]byte("not json"))
+
+			Convey("Then it should be left untouched", func() {
+				So(body, ShouldEqual, "not json")
+			})
+		})
+
+		Convey("When I set custom redacted headers and attributes", func() {
+
+			recorder.RedactHeaders("X-Nuage-Organization")
+			recorder.RedactAttributes("name")
+
+			headers := recorder.redactHeaders(map[string][]string{"X-Nuage-Organization": {"csp"}, "Authorization": {"XREST abc"}})
+			body := recorder.redactBody([]byte(`{"name":"a","APIKey":"k"}`))
+
+			Convey("Then only those should be redacted", func() {
+				So(headers.Get("X-Nuage-Organization"), ShouldEqual, Redacted)
+				So(headers.Get("Authorization"), ShouldEqual, "XREST abc")
+				So(body, ShouldEqual, `{"APIKey":"k","name":"REDACTED"}`)
+			})
+		})
+	})
+}
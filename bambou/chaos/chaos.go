@@ -0,0 +1,308 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package chaos provides an http.RoundTripper injecting faults in the requests of a
+// bambou.Session, like latency, timeouts, connection resets, bursts of server errors
+// and malformed bodies, to test the handling of the failures of the applications:
+//
+//	injector := chaos.New(42)
+//	injector.Add(chaos.Fault{Kind: chaos.ServerError, Probability: 0.1, Burst: 3})
+//	session.WrapTransport(injector.Wrap)
+//
+// The faults are drawn from a random source seeded with the given seed, so a
+// sequence of requests always gets the same faults.
+package chaos
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Kind is a kind of fault.
+type Kind int
+
+// Supported values for Kind.
+const (
+	// Latency delays the request by the Latency of the Fault, plus a random Jitter.
+	// The other faults are still drawn.
+	Latency Kind = iota
+
+	// Timeout fails the request with a timeout error after the Latency of the Fault.
+	Timeout
+
+	// ConnectionReset fails the request with a connection reset error, without sending it.
+	ConnectionReset
+
+	// ServerError answers the request with the StatusCode of the Fault, without sending it.
+	// With a Burst greater than 1, the next requests matching the Fault get the same answer.
+	ServerError
+
+	// MalformedBody sends the request and truncates the body of the response.
+	MalformedBody
+)
+
+// String returns the string representation of the Kind.
+func (k Kind) String() string {
+
+	switch k {
+	case Latency:
+		return "latency"
+	case Timeout:
+		return "timeout"
+	case ConnectionReset:
+		return "connection reset"
+	case ServerError:
+		return "server error"
+	case MalformedBody:
+		return "malformed body"
+	}
+
+	return fmt.Sprintf("kind %d", int(k))
+}
+
+// Fault describes a fault to inject.
+type Fault struct {
+	Kind Kind
+
+	// Probability is the probability, from 0 to 1, that the fault is injected in a request.
+	// A Probability of 0 is the same as 1: the fault is always injected.
+	Probability float64
+
+	// Match restricts the fault to the requests for which it returns true, if not nil.
+	Match func(*http.Request) bool
+
+	// Latency is the delay of Latency and Timeout.
+	Latency time.Duration
+
+	// Jitter is the maximum random delay added to the Latency.
+	Jitter time.Duration
+
+	// StatusCode is the status code of ServerError. The default is http.StatusServiceUnavailable.
+	StatusCode int
+
+	// Burst is the number of consecutive matching requests failing with ServerError.
+	Burst int
+}
+
+// timeoutError is the error of the Timeout faults.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "chaos: injected timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// Injector is an http.RoundTripper injecting faults in the requests it sends.
+type Injector struct {
+	next     http.RoundTripper
+	faults   []Fault
+	bursts   map[int]int
+	injected map[Kind]int
+	random   *rand.Rand
+	lock     sync.Mutex
+}
+
+// New returns a new *Injector drawing the faults with the given seed.
+func New(seed int64) *Injector {
+
+	return &Injector{
+		next:     http.DefaultTransport,
+		bursts:   map[int]int{},
+		injected: map[Kind]int{},
+		random:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Add adds the given faults. The faults are drawn in the order they are added,
+// and the first one failing a request prevents the next ones from being drawn.
+func (i *Injector) Add(faults ...Fault) {
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.faults = append(i.faults, faults...)
+}
+
+// Clear removes all the faults.
+func (i *Injector) Clear() {
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.faults = nil
+	i.bursts = map[int]int{}
+}
+
+// Injected returns the number of faults of the given Kind injected so far.
+func (i *Injector) Injected(kind Kind) int {
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.injected[kind]
+}
+
+// Wrap sets the http.RoundTripper the requests are sent with, and returns the Injector.
+// It is meant to be given to bambou.Session.WrapTransport.
+func (i *Injector) Wrap(next http.RoundTripper) http.RoundTripper {
+
+	i.next = next
+
+	return i
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (i *Injector) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	delay, fault := i.draw(request)
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+
+	if fault == nil {
+		return i.next.RoundTrip(request)
+	}
+
+	switch fault.Kind {
+
+	case Timeout:
+		return nil, timeoutError{}
+
+	case ConnectionReset:
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+	case ServerError:
+		return serverErrorResponse(request, fault.StatusCode), nil
+
+	case MalformedBody:
+		response, err := i.next.RoundTrip(request)
+		if err != nil {
+			return nil, err
+		}
+		return truncateBody(response)
+	}
+
+	return i.next.RoundTrip(request)
+}
+
+// draw draws the faults of the given request, and returns the delay
+// to wait before handling it and the fault failing it, if any.
+func (i *Injector) draw(request *http.Request) (time.Duration, *Fault) {
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var delay time.Duration
+
+	for index := range i.faults {
+
+		fault := i.faults[index]
+
+		if fault.Match != nil && !fault.Match(request) {
+			continue
+		}
+
+		if i.bursts[index] > 0 {
+			i.bursts[index]--
+			i.injected[fault.Kind]++
+			return delay, &fault
+		}
+
+		if fault.Probability > 0 && i.random.Float64() >= fault.Probability {
+			continue
+		}
+
+		i.injected[fault.Kind]++
+
+		if fault.Kind == Latency || fault.Kind == Timeout {
+			delay += fault.Latency
+			if fault.Jitter > 0 {
+				delay += time.Duration(i.random.Int63n(int64(fault.Jitter)))
+			}
+		}
+
+		if fault.Kind == Latency {
+			continue
+		}
+
+		if fault.Kind == ServerError && fault.Burst > 1 {
+			i.bursts[index] = fault.Burst - 1
+		}
+
+		return delay, &fault
+	}
+
+	return delay, nil
+}
+
+// serverErrorResponse returns a response to the given request with the given status code.
+func serverErrorResponse(request *http.Request, code int) *http.Response {
+
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+
+	body := fmt.Sprintf(`{"errors":[{"property":"","descriptions":[{"title":"%s","description":"injected by chaos"}]}]}`, http.StatusText(code))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
+
+// truncateBody replaces the body of the given response by its first half.
+func truncateBody(response *http.Response) (*http.Response, error) {
+
+	data, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[:len(data)/2]
+	response.Body = ioutil.NopCloser(bytes.NewReader(data))
+	response.ContentLength = int64(len(data))
+	response.Header.Del("Content-Length")
+
+	return response, nil
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type root struct {
+	ID  string `json:"ID,omitempty"`
+	Key string `json:"APIKey,omitempty"`
+}
+
+func (o *root) Identity() bambou.Identity { return bambou.Identity{Name: "me", Category: "me"} }
+func (o *root) Identifier() string        { return o.ID }
+func (o *root) SetIdentifier(ID string)   { o.ID = ID }
+func (o *root) APIKey() string            { return o.Key }
+func (o *root) SetAPIKey(key string)      { o.Key = key }
+
+func TestInjector_Faults(t *testing.T) {
+
+	Convey("Given I have a server and a client injecting faults", t, func() {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"value"}`))
+		}))
+		defer server.Close()
+
+		injector := New(1)
+		client := &http.Client{Transport: injector.Wrap(http.DefaultTransport)}
+
+		Convey("When I inject no fault", func() {
+
+			response, err := client.Get(server.URL)
+
+			Convey("Then the request should succeed", func() {
+				So(err, ShouldBeNil)
+				So(response.StatusCode, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When I inject latency", func() {
+
+			injector.Add(Fault{Kind: Latency, Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond})
+
+			start := time.Now()
+			response, err := client.Get(server.URL)
+
+			Convey("Then the request should be delayed and succeed", func() {
+				So(err, ShouldBeNil)
+				So(response.StatusCode, ShouldEqual, http.StatusOK)
+				So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 20*time.Millisecond)
+				So(injector.Injected(Latency), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I inject latency in a request with a canceled context", func() {
+
+			injector.Add(Fault{Kind: Latency, Latency: time.Minute})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			request, _ := http.NewRequest("GET", server.URL, nil)
+			_, err := client.Do(request.WithContext(ctx))
+
+			Convey("Then the request should fail early", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I inject a timeout", func() {
+
+			injector.Add(Fault{Kind: Timeout})
+
+			_, err := client.Get(server.URL)
+
+			Convey("Then I should get a timeout error", func() {
+				So(err, ShouldNotBeNil)
+				nerr, ok := err.(net.Error)
+				So(ok, ShouldBeTrue)
+				So(nerr.Timeout(), ShouldBeTrue)
+			})
+		})
+
+		Convey("When I inject a connection reset", func() {
+
+			injector.Add(Fault{Kind: ConnectionReset})
+
+			_, err := injector.RoundTrip(httptest.NewRequest("GET", server.URL, nil))
+
+			Convey("Then I should get a connection reset error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.(*net.OpError).Err.(*os.SyscallError).Err, ShouldEqual, syscall.ECONNRESET)
+			})
+		})
+
+		Convey("When I inject a burst of server errors", func() {
+
+			injector.Add(Fault{Kind: ServerError, Burst: 3, StatusCode: http.StatusBadGateway, Probability: 0.5})
+
+			var codes []int
+			for i := 0; i < 8; i++ {
+				response, err := client.Get(server.URL)
+				So(err, ShouldBeNil)
+				codes = append(codes, response.StatusCode)
+			}
+
+			Convey("Then the server errors should come in bursts", func() {
+				So(injector.Injected(ServerError), ShouldBeGreaterThanOrEqualTo, 3)
+				first := -1
+				for i, code := range codes {
+					if code == http.StatusBadGateway {
+						first = i
+						break
+					}
+				}
+				So(first, ShouldBeGreaterThanOrEqualTo, 0)
+				So(first, ShouldBeLessThanOrEqualTo, 5)
+				So(codes[first+1], ShouldEqual, http.StatusBadGateway)
+				So(codes[first+2], ShouldEqual, http.StatusBadGateway)
+			})
+		})
+
+		Convey("When I inject malformed bodies", func() {
+
+			injector.Add(Fault{Kind: MalformedBody})
+
+			response, err := client.Get(server.URL)
+			body, _ := ioutil.ReadAll(response.Body)
+
+			Convey("Then the body should not be valid JSON", func() {
+				So(err, ShouldBeNil)
+				So(json.Valid(body), ShouldBeFalse)
+				So(string(body), ShouldEqual, `{"name":`)
+			})
+		})
+
+		Convey("When I inject a fault matching some requests only", func() {
+
+			injector.Add(Fault{Kind: ServerError, Match: func(r *http.Request) bool { return r.Method == "POST" }})
+
+			get, _ := client.Get(server.URL)
+			post, _ := client.Post(server.URL, "application/json", nil)
+
+			Convey("Then only those should fail", func() {
+				So(get.StatusCode, ShouldEqual, http.StatusOK)
+				So(post.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+			})
+		})
+
+		Convey("When I clear the faults", func() {
+
+			injector.Add(Fault{Kind: Timeout})
+			injector.Clear()
+
+			_, err := client.Get(server.URL)
+
+			Convey("Then the request should succeed", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestInjector_Deterministic(t *testing.T) {
+
+	Convey("Given I have two injectors with the same seed", t, func() {
+
+		draws := func() []bool {
+			injector := New(7)
+			injector.Add(Fault{Kind: ServerError, Probability: 0.3})
+			var failed []bool
+			for i := 0; i < 20; i++ {
+				_, fault := injector.draw(httptest.NewRequest("GET", "/", nil))
+				failed = append(failed, fault != nil)
+			}
+			return failed
+		}
+
+		Convey("Then they should inject the same faults", func() {
+			So(draws(), ShouldResemble, draws())
+		})
+	})
+}
+
+func TestInjector_Session(t *testing.T) {
+
+	Convey("Given I have a session on a fake VSD injecting server errors", t, func() {
+
+		s := fakevsd.New("admin", "secret", "csp")
+		defer s.Close()
+
+		injector := New(1)
+		session := bambou.NewSession("admin", "secret", "csp", s.URL, &root{})
+		session.WrapTransport(injector.Wrap)
+		injector.Add(Fault{Kind: ServerError})
+
+		Convey("When I start it", func() {
+
+			err := session.Start()
+
+			Convey("Then I should get the server error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusServiceUnavailable)
+			})
+
+			Convey("When I clear the faults and start it again", func() {
+
+				injector.Clear()
+				err := session.Start()
+
+				Convey("Then it should succeed", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// EventCheckpointer is the interface of objects that can persist the last
+// event ID received by a PushCenter, so the event stream can be resumed
+// after a restart.
+type EventCheckpointer interface {
+
+	// Load returns the last saved event ID, or an empty string if there is none.
+	Load() (string, error)
+
+	// Save saves the given event ID.
+	Save(string) error
+}
+
+// MemoryCheckpointer is an EventCheckpointer that keeps the last event ID in memory.
+type MemoryCheckpointer struct {
+	eventID string
+	lock    sync.Mutex
+}
+
+// NewMemoryCheckpointer returns a new *MemoryCheckpointer.
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+
+	return &MemoryCheckpointer{}
+}
+
+// Load returns the last saved event ID.
+func (c *MemoryCheckpointer) Load() (string, error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.eventID, nil
+}
+
+// Save saves the given event ID.
+func (c *MemoryCheckpointer) Save(eventID string) error {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.eventID = eventID
+
+	return nil
+}
+
+// FileCheckpointer is an EventCheckpointer that keeps the last event ID in a file.
+type FileCheckpointer struct {
+	path string
+	lock sync.Mutex
+}
+
+// NewFileCheckpointer returns a new *FileCheckpointer that will use the file at the given path.
+func NewFileCheckpointer(path string) *FileCheckpointer {
+
+	return &FileCheckpointer{
+		path: path,
+	}
+}
+
+// Load returns the event ID saved in the file. If the file doesn't exist, it returns an empty string.
+func (c *FileCheckpointer) Load() (string, error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save atomically writes the given event ID into the file.
+func (c *FileCheckpointer) Save(eventID string) error {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	f, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(eventID + "\n"); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), c.path)
+}
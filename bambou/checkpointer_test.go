@@ -0,0 +1,111 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckpointer_MemoryCheckpointer(t *testing.T) {
+
+	Convey("Given I create a new MemoryCheckpointer", t, func() {
+
+		c := NewMemoryCheckpointer()
+
+		Convey("When I load the event ID", func() {
+
+			eventID, err := c.Load()
+
+			Convey("Then it should be empty", func() {
+				So(err, ShouldBeNil)
+				So(eventID, ShouldEqual, "")
+			})
+		})
+
+		Convey("When I save an event ID and load it", func() {
+
+			c.Save("uuid")
+			eventID, err := c.Load()
+
+			Convey("Then it should be the saved one", func() {
+				So(err, ShouldBeNil)
+				So(eventID, ShouldEqual, "uuid")
+			})
+		})
+	})
+}
+
+func TestCheckpointer_FileCheckpointer(t *testing.T) {
+
+	Convey("Given I create a new FileCheckpointer", t, func() {
+
+		dir, _ := ioutil.TempDir("", "bambou")
+		defer os.RemoveAll(dir)
+
+		c := NewFileCheckpointer(filepath.Join(dir, "checkpoint"))
+
+		Convey("When I load the event ID and the file doesn't exist", func() {
+
+			eventID, err := c.Load()
+
+			Convey("Then it should be empty", func() {
+				So(err, ShouldBeNil)
+				So(eventID, ShouldEqual, "")
+			})
+		})
+
+		Convey("When I save an event ID and load it with another checkpointer", func() {
+
+			err := c.Save("uuid")
+			eventID, _ := NewFileCheckpointer(filepath.Join(dir, "checkpoint")).Load()
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then it should be the saved one", func() {
+				So(eventID, ShouldEqual, "uuid")
+			})
+
+			Convey("Then no temporary file should be left", func() {
+				files, _ := ioutil.ReadDir(dir)
+				So(len(files), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I save an event ID in a directory that doesn't exist", func() {
+
+			err := NewFileCheckpointer(filepath.Join(dir, "nope", "checkpoint")).Save("uuid")
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
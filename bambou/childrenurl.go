@@ -0,0 +1,97 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// childrenURLKey is the key of a children URL template: the names of the
+// identities of the parent and the children.
+type childrenURLKey struct {
+	parent   string
+	children string
+}
+
+var (
+	childrenURLTemplates    = map[childrenURLKey]string{}
+	childrenURLTemplateLock sync.RWMutex
+)
+
+// RegisterChildrenURL registers the URL of the children of the given identity of the
+// objects of the given parent identity, for the relationships that do not follow the
+// parentCategory/parentID/childrenCategory convention, like the deployment of a domain
+// or the aggregated statistics. The template is relative to the URL of the session,
+// and can contain the placeholders {parentCategory}, {parentID} and {category}, the
+// category of the children, like "/domains/{parentID}/deploy". Use AllIdentity as the
+// parent identity for the children of any parent. An empty template unregisters it.
+func RegisterChildrenURL(parent Identity, children Identity, template string) {
+
+	childrenURLTemplateLock.Lock()
+	defer childrenURLTemplateLock.Unlock()
+
+	key := childrenURLKey{parent: parent.Name, children: children.Name}
+	if template == "" {
+		delete(childrenURLTemplates, key)
+		return
+	}
+
+	childrenURLTemplates[key] = template
+}
+
+// childrenURLTemplate returns the template registered for the children of the given
+// identity of the given parent identity, or for any parent.
+func childrenURLTemplate(parent Identity, children Identity) (string, bool) {
+
+	childrenURLTemplateLock.RLock()
+	defer childrenURLTemplateLock.RUnlock()
+
+	if template, ok := childrenURLTemplates[childrenURLKey{parent: parent.Name, children: children.Name}]; ok {
+		return template, true
+	}
+
+	template, ok := childrenURLTemplates[childrenURLKey{parent: AllIdentity.Name, children: children.Name}]
+
+	return template, ok
+}
+
+// expandChildrenURL returns the URL of the given template for the children of the given
+// identity of the given parent.
+func (s *Session) expandChildrenURL(template string, parent Identifiable, children Identity) (string, *Error) {
+
+	if strings.Contains(template, "{parentID}") && parent.Identifier() == "" {
+		return "", NewBambouError("VSD error", fmt.Sprintf("Cannot get the URL of the %s of a %s with no ID set", children.Category, parent.Identity().Name))
+	}
+
+	path := strings.NewReplacer(
+		"{parentCategory}", url.PathEscape(parent.Identity().Category),
+		"{parentID}", url.PathEscape(parent.Identifier()),
+		"{category}", url.PathEscape(children.Category),
+	).Replace(template)
+
+	return s.URL + "/" + strings.TrimPrefix(path, "/"), nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_RegisterChildrenURL(t *testing.T) {
+
+	Convey("Given I have a server with irregular endpoints", t, func() {
+
+		var paths []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.EscapedPath())
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		deployment := Identity{Name: "deployment", Category: "deployments"}
+		statistics := Identity{Name: "aggregatestatistic", Category: "aggregatestatistics"}
+
+		RegisterChildrenURL(FakeIdentity, deployment, "/{parentCategory}/{parentID}/deploy")
+		RegisterChildrenURL(AllIdentity, statistics, "aggregates/{category}")
+		defer RegisterChildrenURL(FakeIdentity, deployment, "")
+		defer RegisterChildrenURL(AllIdentity, statistics, "")
+
+		Convey("When I fetch children with registered URLs", func() {
+
+			var dest []*FakeObject
+			deployErr := session.FetchChildren(NewFakeObject("a/b"), deployment, &dest, nil)
+			statisticsErr := session.FetchChildren(session.Root(), statistics, &dest, nil)
+
+			Convey("Then the registered URLs should be used", func() {
+				So(deployErr, ShouldBeNil)
+				So(statisticsErr, ShouldBeNil)
+				So(paths, ShouldResemble, []string{"/fakes/a%2Fb/deploy", "/aggregates/aggregatestatistics"})
+			})
+		})
+
+		Convey("When I fetch children with a registered URL of a parent without ID", func() {
+
+			var dest []*FakeObject
+			err := session.FetchChildren(NewFakeObject(""), deployment, &dest, nil)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(paths, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I fetch children without registered URL", func() {
+
+			var dest []*FakeObject
+			err := session.FetchChildren(NewFakeObject("1"), FakeIdentity, &dest, nil)
+
+			Convey("Then the conventional URL should be used", func() {
+				So(err, ShouldBeNil)
+				So(paths, ShouldResemble, []string{"/fakes/1/fakes"})
+			})
+		})
+
+		Convey("When I unregister a URL", func() {
+
+			RegisterChildrenURL(FakeIdentity, deployment, "")
+			var dest []*FakeObject
+			err := session.FetchChildren(NewFakeObject("1"), deployment, &dest, nil)
+
+			Convey("Then the conventional URL should be used", func() {
+				So(err, ShouldBeNil)
+				So(paths, ShouldResemble, []string{"/fakes/1/deployments"})
+			})
+		})
+	})
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock gives the time to the time dependent features: the retries and backoffs of
+// the Executor, the PushCenter and the WebhookForwarder, the stale timeout and the
+// handler timeout of the PushCenter, the TTL of the Cache, the expiry of the tokens
+// of the sessions, the polls of the operations and of WaitFor, and the measured
+// durations. Setting a FakeClock makes them testable without waiting.
+type Clock interface {
+
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a new Timer sending the current time on its channel
+	// after the given duration.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock.
+type Timer interface {
+
+	// C returns the channel the time is sent on when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer
+	// already fired or has been stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock of the system, used by default.
+var SystemClock Clock = systemClock{}
+
+// systemClock is the Clock of the package time.
+type systemClock struct{}
+
+// Now implements the Clock interface.
+func (systemClock) Now() time.Time {
+
+	return time.Now()
+}
+
+// NewTimer implements the Clock interface.
+func (systemClock) NewTimer(d time.Duration) Timer {
+
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTimer is a Timer of the package time.
+type systemTimer struct {
+	*time.Timer
+}
+
+// C implements the Timer interface.
+func (t systemTimer) C() <-chan time.Time {
+
+	return t.Timer.C
+}
+
+// clockOrSystem returns the given Clock, or SystemClock if it is nil.
+func clockOrSystem(clock Clock) Clock {
+
+	if clock == nil {
+		return SystemClock
+	}
+
+	return clock
+}
+
+// clockOf returns the Clock of the given Storer if it is a *Session, or SystemClock.
+func clockOf(storer interface{}) Clock {
+
+	if session, ok := storer.(*Session); ok && session != nil {
+		return clockOrSystem(session.clock)
+	}
+
+	return SystemClock
+}
+
+// FakeClock is a Clock whose time only changes when it is advanced, for the tests.
+type FakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	lock   sync.Mutex
+}
+
+// NewFakeClock returns a new *FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+
+	return &FakeClock{now: now}
+}
+
+// Now implements the Clock interface.
+func (c *FakeClock) Now() time.Time {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// NewTimer implements the Clock interface. The timer fires when the clock
+// is advanced past its deadline.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// Advance moves the time of the clock forward by the given duration,
+// and fires the timers whose deadline is reached.
+func (c *FakeClock) Advance(d time.Duration) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+}
+
+// Timers returns the number of timers waiting for the clock to be advanced.
+// It allows the tests to wait until the code under test waits.
+func (c *FakeClock) Timers() int {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.timers)
+}
+
+// fakeTimer is a Timer of a FakeClock.
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	c        chan time.Time
+}
+
+// C implements the Timer interface.
+func (t *fakeTimer) C() <-chan time.Time {
+
+	return t.c
+}
+
+// Stop implements the Timer interface.
+func (t *fakeTimer) Stop() bool {
+
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFakeClock(t *testing.T) {
+
+	Convey("Given I have a fake clock", t, func() {
+
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := NewFakeClock(start)
+
+		Convey("When I create timers and advance the clock", func() {
+
+			short := clock.NewTimer(time.Second)
+			long := clock.NewTimer(time.Minute)
+			stopped := clock.NewTimer(time.Second)
+			wasPending := stopped.Stop()
+			pending := clock.Timers()
+
+			clock.Advance(2 * time.Second)
+
+			Convey("Then only the expired timers should fire", func() {
+				So(wasPending, ShouldBeTrue)
+				So(pending, ShouldEqual, 2)
+				So(clock.Now(), ShouldEqual, start.Add(2*time.Second))
+				So(<-short.C(), ShouldEqual, start.Add(2*time.Second))
+				So(short.Stop(), ShouldBeFalse)
+				So(long.C(), ShouldBeEmpty)
+				So(stopped.C(), ShouldBeEmpty)
+				So(clock.Timers(), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestClock_Subsystems(t *testing.T) {
+
+	Convey("Given I have a fake clock", t, func() {
+
+		clock := NewFakeClock(time.Now())
+
+		Convey("When I cache an object with a TTL", func() {
+
+			cache := NewCache(time.Minute, 0)
+			cache.SetClock(clock)
+			cache.Update(NewFakeObject("1"))
+
+			key := entityCacheKey(FakeIdentity.Name, "1")
+			_, beforeTTL := cache.get(key)
+			clock.Advance(2 * time.Minute)
+			_, afterTTL := cache.get(key)
+
+			Convey("Then it should expire with the time of the clock", func() {
+				So(beforeTTL, ShouldBeTrue)
+				So(afterTTL, ShouldBeFalse)
+			})
+		})
+
+		Convey("When I retry an operation with an executor", func() {
+
+			executor := NewExecutor(1)
+			executor.SetClock(clock)
+			executor.SetRetries(2, time.Hour)
+
+			results := make(chan *BatchResult)
+			go func() {
+				results <- executor.Run(context.Background(), 1, func(ctx context.Context, index int) *Error {
+					return newHTTPError(http.StatusServiceUnavailable, "HTTP error", "unavailable")
+				})
+			}()
+
+			for i := 0; i < 2; i++ {
+				for clock.Timers() == 0 {
+					time.Sleep(time.Millisecond)
+				}
+				clock.Advance(2 * time.Hour)
+			}
+			result := <-results
+
+			Convey("Then the backoffs should be waited on the clock", func() {
+				So(result.Results[0].Attempts, ShouldEqual, 3)
+			})
+		})
+
+		// waitTimers waits until the given number of timers wait for the clock.
+		waitTimers := func(count int) {
+			for clock.Timers() < count {
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		Convey("When I wait for an object to change on the server", func() {
+
+			fs := newFakeServer()
+			defer fs.Close()
+			fs.add("", map[string]interface{}{"ID": "x", "status": "PENDING"})
+
+			session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+			session.SetClock(clock)
+			object := &providedObject{FakeObject: FakeObject{ID: "x"}, Status: "PENDING"}
+
+			errs := make(chan *Error)
+			go func() {
+				errs <- WaitForAttribute(context.Background(), session, object, "status", time.Hour, 3*time.Hour, "READY")
+			}()
+
+			waitTimers(2)
+			fs.lock.Lock()
+			fs.objects["x"]["status"] = "READY"
+			fs.lock.Unlock()
+			clock.Advance(time.Hour)
+			err := <-errs
+
+			Convey("Then the polls should be timed by the clock", func() {
+				So(err, ShouldBeNil)
+				So(object.Status, ShouldEqual, "READY")
+			})
+		})
+
+		Convey("When I wait for an object that never changes", func() {
+
+			fs := newFakeServer()
+			defer fs.Close()
+			fs.add("", map[string]interface{}{"ID": "x", "status": "PENDING"})
+
+			session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+			session.SetClock(clock)
+			object := &providedObject{FakeObject: FakeObject{ID: "x"}, Status: "PENDING"}
+
+			errs := make(chan *Error)
+			go func() {
+				errs <- WaitForAttribute(context.Background(), session, object, "status", time.Hour, 3*time.Hour, "READY")
+			}()
+
+			waitTimers(2)
+			clock.Advance(3 * time.Hour)
+			err := <-errs
+
+			Convey("Then the timeout should be timed by the clock", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Wait timeout")
+			})
+		})
+
+		Convey("When a handler does not return before its timeout", func() {
+
+			unblock := make(chan struct{})
+			defer close(unblock)
+
+			errs := make(chan error)
+			go func() {
+				errs <- callHandler(func(*Event) { <-unblock }, &Event{}, time.Minute, clock)
+			}()
+
+			waitTimers(1)
+			clock.Advance(time.Minute)
+			err := <-errs
+
+			Convey("Then the timeout should be timed by the clock", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "timed out after 1m0s")
+			})
+		})
+
+		Convey("When I use a token session", func() {
+
+			session := NewTokenSession("token", clock.Now().Add(time.Hour), "organization", "https://vsd", NewFakeRootObject())
+			session.SetClock(clock)
+
+			beforeExpiry := session.TokenExpired()
+			clock.Advance(time.Hour)
+			afterExpiry := session.TokenExpired()
+
+			Convey("Then the token should expire with the time of the clock", func() {
+				So(beforeExpiry, ShouldBeFalse)
+				So(afterExpiry, ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// SystemAttributes is the list of the attributes managed by the server,
+// that are stripped from the clones. See Clone.
+var SystemAttributes = []string{
+	"ID",
+	"parentID",
+	"parentType",
+	"owner",
+	"creationDate",
+	"lastUpdatedDate",
+	"lastUpdatedBy",
+}
+
+// Clone returns a detached copy of the given object, without its system attributes,
+// that can be created anywhere. The given object must be a pointer.
+func Clone(object Identifiable) (Identifiable, *Error) {
+
+	clone := newIdentifiableLike(object)
+	if clone == nil {
+		return nil, NewBambouError("Invalid object", fmt.Sprintf("%s must be a pointer", object.Identity().Name))
+	}
+
+	attributes, err := attributesOf(object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range SystemAttributes {
+		delete(attributes, name)
+	}
+
+	data, _ := json.Marshal(attributes)
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	return clone, nil
+}
+
+// CopyChild creates a clone of the given object under the given parent, and returns it.
+// The children of the object of the given identities are copied under the clone,
+// recursively. The identities of the children must be registered: see RegisterIdentity.
+// The copy is not transactional: if it fails, the objects already created are left as is.
+func CopyChild(storer Storer, object Identifiable, parent Identifiable, children ...Identity) (Identifiable, *Error) {
+
+	clone, err := Clone(object)
+	if err != nil {
+		return nil, err
+	}
+
+	// The children are fetched before creating the clone, so that copying
+	// an object under itself does not copy the clone again.
+	var subtree []Identifiable
+	for _, identity := range children {
+
+		list, err := fetchRegisteredChildren(storer, object, identity)
+		if err != nil {
+			return nil, err
+		}
+
+		subtree = append(subtree, list...)
+	}
+
+	if err := storer.CreateChild(parent, clone); err != nil {
+		return nil, err
+	}
+
+	for _, child := range subtree {
+		if _, err := CopyChild(storer, child, clone, children...); err != nil {
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+// fetchRegisteredChildren returns the children of the given registered Identity of the given parent.
+func fetchRegisteredChildren(storer Storer, parent Identifiable, identity Identity) ([]Identifiable, *Error) {
+
+	prototype := NewIdentifiable(identity.Name)
+	if prototype == nil {
+		return nil, NewBambouError("Unregistered identity", fmt.Sprintf("%s is not registered", identity.Name))
+	}
+
+	dest := reflect.New(reflect.SliceOf(reflect.TypeOf(prototype)))
+	if err := storer.FetchChildren(parent, identity, dest.Interface(), nil); err != nil {
+		return nil, err
+	}
+
+	list := make([]Identifiable, dest.Elem().Len())
+	for i := range list {
+		list[i] = dest.Elem().Index(i).Interface().(Identifiable)
+	}
+
+	return list, nil
+}
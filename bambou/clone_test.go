@@ -0,0 +1,123 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type ownedObject struct {
+	FakeObject
+
+	Owner    string   `json:"owner"`
+	ParentID string   `json:"parentID"`
+	Tags     []string `json:"tags"`
+}
+
+func TestClone_Clone(t *testing.T) {
+
+	Convey("Given I have an object with system attributes", t, func() {
+
+		o := &ownedObject{FakeObject: FakeObject{ID: "x", Name: "name"}, Owner: "me", ParentID: "p", Tags: []string{"a"}}
+
+		Convey("When I clone it", func() {
+
+			clone, err := Clone(o)
+
+			Convey("Then I should get a detached copy", func() {
+				So(err, ShouldBeNil)
+				So(clone, ShouldResemble, &ownedObject{FakeObject: FakeObject{Name: "name"}, Tags: []string{"a"}})
+			})
+
+			Convey("Then the copy should not share anything with the object", func() {
+				clone.(*ownedObject).Tags[0] = "b"
+				So(o.Tags[0], ShouldEqual, "a")
+			})
+		})
+	})
+}
+
+func TestClone_CopyChild(t *testing.T) {
+
+	Convey("Given I have a server with a tree", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return NewFakeObject("") })
+		defer UnregisterIdentity(FakeIdentity)
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "src", "name": "template"})
+		ts.add("src", map[string]interface{}{"ID": "child", "name": "child"})
+		ts.add("child", map[string]interface{}{"ID": "grandchild", "name": "grandchild"})
+		ts.add("", map[string]interface{}{"ID": "dst", "name": "destination"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I copy the object alone", func() {
+
+			clone, err := CopyChild(session, &FakeObject{ID: "src", Name: "template"}, NewFakeObject("dst"))
+
+			Convey("Then only the object should be created under the parent", func() {
+				So(err, ShouldBeNil)
+				So(clone.Identifier(), ShouldNotEqual, "src")
+				So(ts.get(clone.Identifier())["name"], ShouldEqual, "template")
+				So(ts.count(), ShouldEqual, 5)
+			})
+		})
+
+		Convey("When I copy the object with its subtree", func() {
+
+			clone, err := CopyChild(session, &FakeObject{ID: "src", Name: "template"}, NewFakeObject("dst"), FakeIdentity)
+
+			Convey("Then the whole subtree should be created", func() {
+				So(err, ShouldBeNil)
+				So(ts.count(), ShouldEqual, 7)
+				So(ts.parents[clone.Identifier()], ShouldEqual, "dst")
+			})
+		})
+
+		Convey("When I copy the object under itself", func() {
+
+			_, err := CopyChild(session, &FakeObject{ID: "src", Name: "template"}, NewFakeObject("src"), FakeIdentity)
+
+			Convey("Then the subtree should be copied once", func() {
+				So(err, ShouldBeNil)
+				So(ts.count(), ShouldEqual, 7)
+			})
+		})
+
+		Convey("When I copy the object with unregistered children", func() {
+
+			_, err := CopyChild(session, &FakeObject{ID: "src"}, NewFakeObject("dst"), Identity{Name: "unknown", Category: "unknowns"})
+
+			Convey("Then I should get an error and nothing should be created", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Unregistered identity")
+				So(ts.count(), ShouldEqual, 4)
+			})
+		})
+	})
+}
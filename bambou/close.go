@@ -0,0 +1,81 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"sync/atomic"
+)
+
+// ErrSessionClosed is returned by the requests of a closed Session.
+var ErrSessionClosed = NewBambouError("Session closed", "the session is closed")
+
+// Close closes the session for good: it stops the PushCenters created with
+// NewPushCenter for the session, revokes the API key on the server, forgets it
+// and closes the idle connections of the session. The key is revoked on a best
+// effort basis, the session is closed even if the server cannot be reached. The
+// requests sent afterwards fail with ErrSessionClosed. Closing a closed session
+// does nothing.
+func (s *Session) Close() *Error {
+
+	if !atomic.CompareAndSwapInt32(&s.closing, 0, 1) {
+		return nil
+	}
+
+	s.lock.Lock()
+	pushCenters := s.pushCenters
+	s.pushCenters = nil
+	s.lock.Unlock()
+
+	for _, p := range pushCenters {
+		// The push centers that are not started return an error, which does not matter.
+		p.Stop()
+	}
+
+	if s.APIKey() != "" {
+		if berr := s.RevokeAPIKey(); berr != nil {
+			s.getLogger().Warnf("Unable to revoke the API key of the closed session: %s", berr.Description)
+		}
+	}
+
+	atomic.StoreInt32(&s.closed, 1)
+	s.Reset()
+	s.client.CloseIdleConnections()
+
+	return nil
+}
+
+// Closed returns true if the session has been closed. See Close.
+func (s *Session) Closed() bool {
+
+	return atomic.LoadInt32(&s.closed) == 1
+}
+
+// addPushCenter registers the given PushCenter of the session, stopped by Close.
+func (s *Session) addPushCenter(p *PushCenter) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pushCenters = append(s.pushCenters, p)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// closeCountingConn is a net.Conn counting the closed connections.
+type closeCountingConn struct {
+	net.Conn
+	closed *int32
+}
+
+func (c *closeCountingConn) Close() error {
+
+	atomic.AddInt32(c.closed, 1)
+	return c.Conn.Close()
+}
+
+func TestSession_Close(t *testing.T) {
+
+	Convey("Given I have a started session with a push center", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "1", "name": "object"})
+
+		root := NewFakeRootObject()
+		session := NewSession("username", "password", "organization", ts.URL, root)
+
+		var closedConns int32
+		session.SetDialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			return &closeCountingConn{Conn: conn, closed: &closedConns}, nil
+		})
+		So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+		root.SetAPIKey("key")
+
+		started := NewPushCenter(session)
+		So(started.Start(), ShouldBeNil)
+		stopped := NewPushCenter(session)
+
+		Convey("When I close the session", func() {
+
+			err := session.Close()
+
+			Convey("Then everything should be stopped", func() {
+				So(err, ShouldBeNil)
+				So(session.Closed(), ShouldBeTrue)
+				So(started.Stop(), ShouldNotBeNil)
+				So(stopped.Stop(), ShouldNotBeNil)
+				So(root.APIKey(), ShouldBeEmpty)
+			})
+
+			Convey("Then the API key should be revoked on the server", func() {
+				So(ts.methods(), ShouldContain, "DELETE")
+			})
+
+			Convey("Then the idle connections should be closed", func() {
+				So(atomic.LoadInt32(&closedConns), ShouldBeGreaterThan, 0)
+			})
+
+			Convey("Then the requests should fail", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldEqual, ErrSessionClosed)
+			})
+
+			Convey("Then closing it again should do nothing", func() {
+				So(session.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("When I close another session while it is the current session", func() {
+
+			currentSession = session
+			defer func() { currentSession = nil }()
+
+			other := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+			err := other.Close()
+
+			Convey("Then it should still be the current session", func() {
+				So(err, ShouldBeNil)
+				So(CurrentSession(), ShouldEqual, session)
+			})
+
+			Convey("Then closing it should reset the current session", func() {
+				So(session.Close(), ShouldBeNil)
+				So(CurrentSession(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+)
+
+// Codec encodes the bodies of the requests and decodes the bodies of the responses.
+// See Session.SetCodec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the default Codec, using encoding/json.
+var JSONCodec Codec = jsonCodec{}
+
+// FastCodec is a Codec calling the MarshalJSON and UnmarshalJSON methods of the
+// objects and lists directly, when they implement them, instead of going through
+// encoding/json that validates their input and output. It is meant for objects and
+// lists with generated marshalers, like the ones generated by easyjson, that do not
+// use reflection: decoding large listings is then about twice as fast. See the
+// benchmarks of the package.
+// The other values are handled by encoding/json.
+var FastCodec Codec = fastCodec{}
+
+// codecOf returns the Codec of the given Storer if it is a *Session, or JSONCodec.
+func codecOf(storer interface{}) Codec {
+
+	if session, ok := storer.(*Session); ok && session != nil {
+		return session.getCodec()
+	}
+
+	return JSONCodec
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+
+	return json.Unmarshal(data, v)
+}
+
+type fastCodec struct{}
+
+func (fastCodec) Marshal(v interface{}) ([]byte, error) {
+
+	if m, ok := v.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+
+	return json.Marshal(v)
+}
+
+func (fastCodec) Unmarshal(data []byte, v interface{}) error {
+
+	switch dest := v.(type) {
+
+	case json.Unmarshaler:
+		return dest.UnmarshalJSON(data)
+
+	case *IdentifiablesList:
+		// The objects of the list are decoded in place, like encoding/json does.
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+
+		for i, item := range items {
+
+			if i >= len(*dest) {
+				break
+			}
+
+			if u, ok := (*dest)[i].(json.Unmarshaler); ok {
+				if err := u.UnmarshalJSON(item); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := json.Unmarshal(item, (*dest)[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// benchObject and benchObjectsList have marshalers generated by easyjson in
+// codec_easyjson_test.go. To regenerate them, copy the two types into a
+// regular file and run easyjson on it.
+
+//easyjson:json
+type benchObject struct {
+	ID          string   `json:"ID"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Address     string   `json:"address"`
+	Enabled     bool     `json:"enabled"`
+	Priority    int      `json:"priority"`
+	Tags        []string `json:"tags"`
+}
+
+func (o *benchObject) Identity() Identity      { return FakeIdentity }
+func (o *benchObject) Identifier() string      { return o.ID }
+func (o *benchObject) SetIdentifier(ID string) { o.ID = ID }
+
+//easyjson:json
+type benchObjectsList []*benchObject
+
+func newBenchListing(size int) []byte {
+
+	list := make(benchObjectsList, size)
+	for i := range list {
+		list[i] = &benchObject{
+			ID:          fmt.Sprintf("b0a2c4f6-%04d-4a1b-9c3d-5e7f9a1b3c5d", i),
+			Name:        fmt.Sprintf("object-%d", i),
+			Description: "an object of a large listing",
+			Address:     "10.0.0.0",
+			Enabled:     i%2 == 0,
+			Priority:    i,
+			Tags:        []string{"a", "b"},
+		}
+	}
+
+	data, _ := json.Marshal(list)
+
+	return data
+}
+
+func TestCodec_Codecs(t *testing.T) {
+
+	Convey("Given I have the codecs", t, func() {
+
+		for name, codec := range map[string]Codec{"json": JSONCodec, "fast": FastCodec} {
+
+			Convey(fmt.Sprintf("When I round trip a listing with the %s codec", name), func() {
+
+				data := newBenchListing(3)
+
+				var list benchObjectsList
+				err := codec.Unmarshal(data, &list)
+				encoded, merr := codec.Marshal(list)
+
+				Convey("Then I should get the same listing", func() {
+					So(err, ShouldBeNil)
+					So(merr, ShouldBeNil)
+					So(list, ShouldHaveLength, 3)
+					So(list[2].Name, ShouldEqual, "object-2")
+					So(string(encoded), ShouldEqual, string(data))
+				})
+			})
+
+			Convey(fmt.Sprintf("When I decode a single object in place with the %s codec", name), func() {
+
+				generated := &benchObject{ID: "1"}
+				reflected := &FakeObject{ID: "2"}
+				list := IdentifiablesList{generated, reflected}
+				err := codec.Unmarshal([]byte(`[{"ID": "1", "name": "generated"}, {"ID": "2", "name": "reflected"}]`), &list)
+
+				Convey("Then the objects should be updated", func() {
+					So(err, ShouldBeNil)
+					So(generated.Name, ShouldEqual, "generated")
+					So(reflected.Name, ShouldEqual, "reflected")
+				})
+			})
+
+			Convey(fmt.Sprintf("When I decode invalid JSON with the %s codec", name), func() {
+
+				var list benchObjectsList
+				err := codec.Unmarshal([]byte(`[{"ID": `), &list)
+
+				Convey("Then I should get an error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+		}
+	})
+}
+
+func TestCodec_Session(t *testing.T) {
+
+	Convey("Given I have a session with the fast codec", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "parent"})
+		ts.add("parent", map[string]interface{}{"ID": "child", "name": "child", "priority": 2})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetCodec(FastCodec)
+
+		Convey("When I fetch children", func() {
+
+			var list benchObjectsList
+			err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &list, nil)
+
+			Convey("Then I should get them", func() {
+				So(err, ShouldBeNil)
+				So(list, ShouldHaveLength, 1)
+				So(list[0].Priority, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I create a child", func() {
+
+			child := &benchObject{Name: "new", Tags: []string{"t"}}
+			err := session.CreateChild(NewFakeObject("parent"), child)
+
+			Convey("Then it should be created", func() {
+				So(err, ShouldBeNil)
+				So(child.ID, ShouldNotBeEmpty)
+				So(ts.get(child.ID)["name"], ShouldEqual, "new")
+			})
+		})
+	})
+}
+
+// reflectedBenchObject has the attributes of benchObject, without its generated marshalers.
+type reflectedBenchObject benchObject
+
+func benchmarkCodecUnmarshal(b *testing.B, codec Codec, reflected bool) {
+
+	data := newBenchListing(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var list interface{} = &benchObjectsList{}
+		if reflected {
+			list = &[]*reflectedBenchObject{}
+		}
+		if err := codec.Unmarshal(data, list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkCodecMarshal(b *testing.B, codec Codec) {
+
+	var list benchObjectsList
+	json.Unmarshal(newBenchListing(1000), &list)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Marshal(list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodec_ReflectionUnmarshal(b *testing.B) { benchmarkCodecUnmarshal(b, JSONCodec, true) }
+func BenchmarkCodec_JSONUnmarshal(b *testing.B)       { benchmarkCodecUnmarshal(b, JSONCodec, false) }
+func BenchmarkCodec_FastUnmarshal(b *testing.B)       { benchmarkCodecUnmarshal(b, FastCodec, false) }
+func BenchmarkCodec_JSONMarshal(b *testing.B)         { benchmarkCodecMarshal(b, JSONCodec) }
+func BenchmarkCodec_FastMarshal(b *testing.B)         { benchmarkCodecMarshal(b, FastCodec) }
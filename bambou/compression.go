@@ -0,0 +1,114 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// compressionTransport is an http.RoundTripper compressing the large request bodies with gzip.
+// If the server rejects a compressed body with a 415 status, the request is sent again
+// uncompressed, and the compression is disabled for good.
+type compressionTransport struct {
+	next      http.RoundTripper
+	threshold int
+	rejected  bool
+	lock      sync.RWMutex
+}
+
+// SetRequestCompression makes the session compress with gzip the bodies of the PUT and POST
+// requests of at least the given size in bytes, which saves a lot of bandwidth on the bulk
+// imports over slow links. If the server does not support compressed requests, it answers
+// the first one with a 415 status: the request is then sent again uncompressed, and the
+// compression is disabled. A threshold of 0 disables the compression.
+func (s *Session) SetRequestCompression(threshold int) {
+
+	if s.compression == nil {
+		if threshold <= 0 {
+			return
+		}
+		s.compression = &compressionTransport{next: s.client.Transport}
+		s.client.Transport = s.compression
+	}
+
+	s.compression.lock.Lock()
+	defer s.compression.lock.Unlock()
+
+	s.compression.threshold = threshold
+	s.compression.rejected = false
+}
+
+// RoundTrip sends the given request, with a compressed body if it is large enough.
+func (t *compressionTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	t.lock.RLock()
+	threshold, rejected := t.threshold, t.rejected
+	t.lock.RUnlock()
+
+	if threshold <= 0 || rejected || request.Body == nil || (request.Method != "PUT" && request.Method != "POST") || request.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(request)
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if len(body) < threshold {
+		return t.next.RoundTrip(request)
+	}
+
+	compressed := &bytes.Buffer{}
+	writer := gzip.NewWriter(compressed)
+	writer.Write(body)
+	writer.Close()
+
+	zipped := request.Clone(request.Context())
+	zipped.Header.Set("Content-Encoding", "gzip")
+	zipped.Body = ioutil.NopCloser(bytes.NewReader(compressed.Bytes()))
+	zipped.ContentLength = int64(compressed.Len())
+	zipped.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(compressed.Bytes())), nil
+	}
+
+	response, err := t.next.RoundTrip(zipped)
+	if err != nil || response.StatusCode != http.StatusUnsupportedMediaType {
+		return response, err
+	}
+
+	response.Body.Close()
+
+	t.lock.Lock()
+	t.rejected = true
+	t.lock.Unlock()
+
+	return t.next.RoundTrip(request)
+}
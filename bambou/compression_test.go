@@ -0,0 +1,117 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCompression_Session(t *testing.T) {
+
+	Convey("Given I have a session to a server", t, func() {
+
+		var lock sync.Mutex
+		var encodings, names []string
+		acceptGzip := true
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			encoding := r.Header.Get("Content-Encoding")
+			encodings = append(encodings, encoding)
+
+			var reader io.Reader = r.Body
+			if encoding == "gzip" {
+				if !acceptGzip {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				reader, _ = gzip.NewReader(r.Body)
+			}
+			body, _ := ioutil.ReadAll(reader)
+			names = append(names, string(body))
+
+			w.Write([]byte(`[{"ID": "xxx"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetRequestCompression(100)
+
+		Convey("When I save a small and a large entity", func() {
+
+			session.SaveEntity(&FakeObject{ID: "xxx", Name: "small"})
+			err := session.SaveEntity(&FakeObject{ID: "xxx", Name: strings.Repeat("a", 200)})
+
+			Convey("Then only the large body should be compressed", func() {
+				So(err, ShouldBeNil)
+				So(encodings, ShouldResemble, []string{"", "gzip"})
+				So(names[1], ShouldContainSubstring, strings.Repeat("a", 200))
+			})
+		})
+
+		Convey("When the server does not support compressed requests", func() {
+
+			acceptGzip = false
+			err1 := session.SaveEntity(&FakeObject{ID: "xxx", Name: strings.Repeat("a", 200)})
+			err2 := session.SaveEntity(&FakeObject{ID: "xxx", Name: strings.Repeat("b", 200)})
+
+			Convey("Then the request should be sent again uncompressed, and the compression disabled", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+				So(encodings, ShouldResemble, []string{"gzip", "", ""})
+				So(names[0], ShouldContainSubstring, strings.Repeat("a", 200))
+			})
+		})
+
+		Convey("When I disable the compression", func() {
+
+			session.SetRequestCompression(0)
+			session.SaveEntity(&FakeObject{ID: "xxx", Name: strings.Repeat("a", 200)})
+
+			Convey("Then nothing should be compressed", func() {
+				So(encodings, ShouldResemble, []string{""})
+			})
+		})
+
+		Convey("When I fetch an entity", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then nothing should be compressed", func() {
+				So(encodings, ShouldResemble, []string{""})
+			})
+		})
+	})
+}
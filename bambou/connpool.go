@@ -0,0 +1,105 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// ConnectionPoolOptions are the options of the pool of connections of a Session.
+// See Session.SetConnectionPool.
+type ConnectionPoolOptions struct {
+
+	// MaxIdleConns is the maximum number of idle connections to all the hosts. 0 means no limit.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept per host.
+	// 0 means http.DefaultMaxIdleConnsPerHost, which is too low for controllers
+	// sending many concurrent requests to the same server: the connections
+	// above it are closed after each request, and the ephemeral ports run out.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost is the maximum number of connections per host, idle or not. 0 means no limit.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is the duration after which an idle connection is closed. 0 means no limit.
+	IdleConnTimeout time.Duration
+}
+
+// DefaultConnectionPoolOptions are the options of the pool of connections of the new sessions.
+var DefaultConnectionPoolOptions = ConnectionPoolOptions{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 16,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// SetConnectionPool sets the options of the pool of connections of the session.
+// The connections are shared by all the requests of the session, so it must be
+// called before the session is used.
+func (s *Session) SetConnectionPool(options ConnectionPoolOptions) {
+
+	if s.transport == nil {
+		return
+	}
+
+	s.transport.MaxIdleConns = options.MaxIdleConns
+	s.transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	s.transport.MaxConnsPerHost = options.MaxConnsPerHost
+	s.transport.IdleConnTimeout = options.IdleConnTimeout
+}
+
+// ConnectionPool returns the options of the pool of connections of the session.
+func (s *Session) ConnectionPool() ConnectionPoolOptions {
+
+	if s.transport == nil {
+		return ConnectionPoolOptions{}
+	}
+
+	return ConnectionPoolOptions{
+		MaxIdleConns:        s.transport.MaxIdleConns,
+		MaxIdleConnsPerHost: s.transport.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     s.transport.MaxConnsPerHost,
+		IdleConnTimeout:     s.transport.IdleConnTimeout,
+	}
+}
+
+// newTransport returns the http.Transport of a new session, using the given TLS
+// configuration and the DefaultConnectionPoolOptions. The transport is created once
+// per session, and reused by all its requests so their connections are pooled.
+func newTransport(config *tls.Config) *http.Transport {
+
+	options := DefaultConnectionPoolOptions
+
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     config,
+		MaxIdleConns:        options.MaxIdleConns,
+		MaxIdleConnsPerHost: options.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     options.MaxConnsPerHost,
+		IdleConnTimeout:     options.IdleConnTimeout,
+	}
+}
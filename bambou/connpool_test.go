@@ -0,0 +1,101 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConnectionPool_Session(t *testing.T) {
+
+	Convey("Given I have a new session", t, func() {
+
+		session := NewSession("username", "password", "organization", "https://vsd", NewFakeRootObject())
+
+		Convey("Then it should use the default pool options", func() {
+			So(session.ConnectionPool(), ShouldResemble, DefaultConnectionPoolOptions)
+		})
+
+		Convey("When I set the pool options", func() {
+
+			options := ConnectionPoolOptions{MaxIdleConns: 10, MaxIdleConnsPerHost: 5, MaxConnsPerHost: 20, IdleConnTimeout: time.Minute}
+			session.SetConnectionPool(options)
+
+			Convey("Then they should be used", func() {
+				So(session.ConnectionPool(), ShouldResemble, options)
+			})
+		})
+
+		Convey("When I wrap its transport", func() {
+
+			session.WrapTransport(func(next http.RoundTripper) http.RoundTripper { return next })
+			session.SetConnectionPool(ConnectionPoolOptions{MaxIdleConnsPerHost: 3})
+
+			Convey("Then the pool options should still be set", func() {
+				So(session.ConnectionPool().MaxIdleConnsPerHost, ShouldEqual, 3)
+			})
+		})
+	})
+
+	Convey("Given I have a session to a server counting its connections", t, func() {
+
+		var lock sync.Mutex
+		connections := 0
+
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"ID": "xxx"}]`))
+		}))
+		ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				lock.Lock()
+				connections++
+				lock.Unlock()
+			}
+		}
+		ts.Start()
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch entities one after the other", func() {
+
+			for i := 0; i < 10; i++ {
+				session.FetchEntity(NewFakeObject("xxx"))
+			}
+
+			Convey("Then a single connection should be used", func() {
+				lock.Lock()
+				defer lock.Unlock()
+				So(connections, ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_EnableCookies(t *testing.T) {
+
+	Convey("Given I have a load balancer setting a sticky session cookie", t, func() {
+
+		var cookies []string
+		var lock sync.Mutex
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			if cookie, err := r.Cookie("affinity"); err == nil {
+				cookies = append(cookies, cookie.Value)
+			} else {
+				cookies = append(cookies, "")
+			}
+			lock.Unlock()
+
+			http.SetCookie(w, &http.Cookie{Name: "affinity", Value: "backend-2", Path: "/"})
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I send requests without cookies", func() {
+
+			session.FetchEntity(NewFakeObject("1"))
+			session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the cookie should not be sent back", func() {
+				So(session.CookieJar(), ShouldBeNil)
+				So(cookies, ShouldResemble, []string{"", ""})
+			})
+		})
+
+		Convey("When I send requests with the cookies enabled", func() {
+
+			session.EnableCookies()
+			session.FetchEntity(NewFakeObject("1"))
+			session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the cookie should be sent back", func() {
+				So(session.CookieJar(), ShouldNotBeNil)
+				So(cookies, ShouldResemble, []string{"", "backend-2"})
+			})
+		})
+	})
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// CSVExporter renders lists of objects as CSV, one object per row.
+type CSVExporter struct {
+	columns []string
+	comma   rune
+	header  bool
+}
+
+// NewCSVExporter returns a new *CSVExporter exporting the attributes with
+// the given JSON names, in the given order. If no column is given, all the
+// attributes set on at least one of the exported objects are exported, sorted
+// by name, so that a listing fetched with a projection only contains its attributes.
+func NewCSVExporter(columns ...string) *CSVExporter {
+
+	return &CSVExporter{
+		columns: columns,
+		comma:   ',',
+		header:  true,
+	}
+}
+
+// SetComma sets the field delimiter. Use '\t' to export TSV.
+func (e *CSVExporter) SetComma(comma rune) {
+
+	e.comma = comma
+}
+
+// SetHeader sets if the first row contains the names of the columns. It does by default.
+func (e *CSVExporter) SetHeader(header bool) {
+
+	e.header = header
+}
+
+// Export writes the given list of objects to the given writer.
+// The list must be a slice of Identifiables, like the destination
+// given to FetchChildren.
+func (e *CSVExporter) Export(w io.Writer, list interface{}) error {
+
+	v := reflect.ValueOf(list)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot export %T: not a slice", list)
+	}
+
+	rows := make([]map[string]interface{}, v.Len())
+	for i := range rows {
+
+		attributes, err := attributesOf(v.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+
+		rows[i] = attributes
+	}
+
+	columns := e.columns
+	if len(columns) == 0 {
+		columns = csvColumnsOf(rows)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = e.comma
+
+	if e.header {
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = csvValue(row[column])
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// csvColumnsOf returns the sorted names of the attributes that are not null in at least one of the given rows.
+func csvColumnsOf(rows []map[string]interface{}) []string {
+
+	names := map[string]struct{}{}
+	for _, row := range rows {
+		for name, value := range row {
+			if value != nil {
+				names[name] = struct{}{}
+			}
+		}
+	}
+
+	columns := make([]string, 0, len(names))
+	for name := range names {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	return columns
+}
+
+// csvValue returns the CSV representation of the given decoded JSON value.
+// Lists and objects are rendered as JSON.
+func csvValue(value interface{}) string {
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	data, _ := json.Marshal(value)
+
+	return string(data)
+}
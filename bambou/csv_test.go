@@ -0,0 +1,96 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type csvObject struct {
+	FakeObject
+
+	Size    int      `json:"size"`
+	Enabled bool     `json:"enabled"`
+	Tags    []string `json:"tags"`
+	Address *string  `json:"address"`
+}
+
+func TestCSV_Export(t *testing.T) {
+
+	Convey("Given I have a list of objects", t, func() {
+
+		list := []*csvObject{
+			{FakeObject: FakeObject{ID: "1", Name: "first, with comma"}, Size: 1024, Enabled: true, Tags: []string{"a", "b"}},
+			{FakeObject: FakeObject{ID: "2", Name: "second"}, Size: 2},
+		}
+
+		Convey("When I export it with columns", func() {
+
+			buffer := &bytes.Buffer{}
+			err := NewCSVExporter("name", "ID", "size", "tags", "missing").Export(buffer, list)
+
+			Convey("Then I should get the selected columns", func() {
+				So(err, ShouldBeNil)
+				So(buffer.String(), ShouldEqual, "name,ID,size,tags,missing\n\"first, with comma\",1,1024,\"[\"\"a\"\",\"\"b\"\"]\",\nsecond,2,2,,\n")
+			})
+		})
+
+		Convey("When I export it without columns", func() {
+
+			buffer := &bytes.Buffer{}
+			err := NewCSVExporter().Export(buffer, &list)
+
+			Convey("Then I should get all the attributes that are set", func() {
+				So(err, ShouldBeNil)
+				So(buffer.String(), ShouldStartWith, "ID,enabled,name,size,tags\n")
+			})
+		})
+
+		Convey("When I export it as TSV without header", func() {
+
+			buffer := &bytes.Buffer{}
+			exporter := NewCSVExporter("ID", "enabled")
+			exporter.SetComma('\t')
+			exporter.SetHeader(false)
+			err := exporter.Export(buffer, list)
+
+			Convey("Then I should get the rows separated by tabs", func() {
+				So(err, ShouldBeNil)
+				So(buffer.String(), ShouldEqual, "1\ttrue\n2\tfalse\n")
+			})
+		})
+
+		Convey("When I export something that is not a list", func() {
+
+			err := NewCSVExporter().Export(&bytes.Buffer{}, list[0])
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
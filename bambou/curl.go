@@ -0,0 +1,89 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// curlIgnoredHeaders are the headers left out of the curl commands, because curl sets them itself.
+var curlIgnoredHeaders = []string{"Accept-Encoding", "Content-Length", "User-Agent"}
+
+// SetDebugCurl makes the session write, in debug mode, an equivalent curl command
+// before the dump of every request, so failing calls can be reproduced and shared
+// without writing Go. The values of the RedactedHeaders and RedactedAttributes are
+// masked, and must be filled in before running the command. See SetDebug.
+func (s *Session) SetDebugCurl(enabled bool) {
+
+	s.debugCurl = enabled
+}
+
+// curlCommand returns the curl command sending the given request, with the given
+// body, if any, and the values of the RedactedHeaders masked.
+func (s *Session) curlCommand(request *http.Request, body *string) string {
+
+	args := []string{"curl"}
+
+	if s.insecureSkipVerify() {
+		args = append(args, "--insecure")
+	}
+
+	args = append(args, "-X", request.Method, shellQuote(request.URL.String()))
+
+	names := make([]string, 0, len(request.Header))
+	for name := range request.Header {
+		if !isRedacted(name, curlIgnoredHeaders) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.Join(request.Header[name], ", ")
+		if isRedacted(name, RedactedHeaders) {
+			value = redactedValue
+		}
+		args = append(args, "-H", shellQuote(name+": "+value))
+	}
+
+	if body != nil {
+		args = append(args, "--data-raw", shellQuote(*body))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// insecureSkipVerify returns true if the session does not verify the certificate of the server.
+func (s *Session) insecureSkipVerify() bool {
+
+	return s.transport != nil && s.transport.TLSClientConfig != nil && s.transport.TLSClientConfig.InsecureSkipVerify
+}
+
+// shellQuote returns the given string single quoted for a POSIX shell.
+func shellQuote(s string) string {
+
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCurl_Session(t *testing.T) {
+
+	Convey("Given I have a session in debug mode", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"ID": "xxx"}]`))
+		}))
+		defer ts.Close()
+
+		var dump bytes.Buffer
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetDebug(&dump)
+
+		Convey("When I enable the curl commands and save an entity", func() {
+
+			session.SetDebugCurl(true)
+			session.SaveEntity(&FakeObject{ID: "xxx", Name: "it's"})
+
+			lines := strings.Split(dump.String(), "\n")
+
+			Convey("Then the curl command should be written before the request without credentials", func() {
+				So(lines[0], ShouldStartWith, "---> CURL ")
+				So(lines[1], ShouldStartWith, "curl --insecure -X PUT '"+ts.URL+"/fakes/xxx?responseChoice=1' ")
+				So(lines[1], ShouldContainSubstring, "-H 'Authorization: REDACTED'")
+				So(lines[1], ShouldContainSubstring, "-H 'X-Nuage-Organization: organization'")
+				So(lines[1], ShouldContainSubstring, `--data-raw '{"ID":"xxx","name":"it'\''s"}'`)
+				So(lines[1], ShouldNotContainSubstring, "User-Agent")
+				So(dump.String(), ShouldNotContainSubstring, "XREST")
+			})
+		})
+
+		Convey("When I fetch an entity without enabling the curl commands", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then no curl command should be written", func() {
+				So(dump.String(), ShouldNotContainSubstring, "curl")
+			})
+		})
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"time"
+)
+
+// FallibleEventHandler is the prototype of a Push Center Handler that can fail.
+// A failed call is retried as configured with SetHandlerRetries, then the event
+// is sent to the DeadLetterHandler.
+type FallibleEventHandler func(*Event) error
+
+// DeadLetter contains an event a handler failed to process.
+type DeadLetter struct {
+	Event    *Event
+	Error    error
+	Attempts int
+}
+
+// String returns the string representation of the DeadLetter.
+func (d *DeadLetter) String() string {
+
+	return fmt.Sprintf("<DeadLetter %s %s attempts: %d error: %s>", d.Event.EntityType, d.Event.Type, d.Attempts, d.Error)
+}
+
+// DeadLetterHandler is the prototype of the function receiving the events
+// a handler failed to process.
+type DeadLetterHandler func(*DeadLetter)
+
+// handlerFailure carries the error returned by a FallibleEventHandler
+// through the recovery of the handler calls.
+type handlerFailure struct {
+	err error
+}
+
+// infallible returns an EventHandler calling the given FallibleEventHandler.
+func infallible(handler FallibleEventHandler) EventHandler {
+
+	return func(event *Event) {
+		if err := handler(event); err != nil {
+			panic(&handlerFailure{err: err})
+		}
+	}
+}
+
+// handlerPolicy defines how the handlers are called.
+type handlerPolicy struct {
+	timeout    time.Duration
+	retries    int
+	deadLetter DeadLetterHandler
+	clock      Clock
+}
+
+// run calls the given handler with the given event, retrying on failure.
+// After the last attempt, the event is sent to the dead letter handler, if any.
+func (h handlerPolicy) run(handler EventHandler, event *Event) {
+
+	var err error
+	attempts := 0
+
+	for attempts <= h.retries {
+
+		attempts++
+
+		if err = callHandler(handler, event, h.timeout, clockOrSystem(h.clock)); err == nil {
+			return
+		}
+
+		DefaultLogger().Warnf("Handler of %s %s event failed (attempt %d): %s", event.EntityType, event.Type, attempts, err)
+	}
+
+	if h.deadLetter == nil {
+		DefaultLogger().Errorf("Handler of %s %s event failed, dropping the event: %s", event.EntityType, event.Type, err)
+		return
+	}
+
+	h.deadLetter(&DeadLetter{Event: event, Error: err, Attempts: attempts})
+}
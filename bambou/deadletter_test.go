@@ -0,0 +1,101 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeadLetter_run(t *testing.T) {
+
+	Convey("Given I have a policy with 2 retries and a dead letter handler", t, func() {
+
+		var letters []*DeadLetter
+		policy := handlerPolicy{retries: 2, deadLetter: func(d *DeadLetter) { letters = append(letters, d) }}
+		e := &Event{EntityType: "fake", Type: EventTypeUpdate}
+
+		Convey("When I run a handler that always fails", func() {
+
+			calls := 0
+			policy.run(infallible(func(*Event) error { calls++; return errors.New("woops") }), e)
+
+			Convey("Then the handler should have been called 3 times", func() {
+				So(calls, ShouldEqual, 3)
+			})
+
+			Convey("Then the event should be sent to the dead letter handler", func() {
+				So(len(letters), ShouldEqual, 1)
+				So(letters[0].Event, ShouldEqual, e)
+				So(letters[0].Error.Error(), ShouldEqual, "woops")
+				So(letters[0].Attempts, ShouldEqual, 3)
+				So(letters[0].String(), ShouldEqual, "<DeadLetter fake UPDATE attempts: 3 error: woops>")
+			})
+		})
+
+		Convey("When I run a handler that panics once", func() {
+
+			calls := 0
+			policy.run(func(*Event) {
+				calls++
+				if calls == 1 {
+					panic("woops")
+				}
+			}, e)
+
+			Convey("Then the handler should have been called 2 times", func() {
+				So(calls, ShouldEqual, 2)
+			})
+
+			Convey("Then the dead letter handler should not be called", func() {
+				So(letters, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I run a handler that succeeds", func() {
+
+			calls := 0
+			policy.run(infallible(func(*Event) error { calls++; return nil }), e)
+
+			Convey("Then the handler should have been called once", func() {
+				So(calls, ShouldEqual, 1)
+				So(letters, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given I have a policy without dead letter handler", t, func() {
+
+		policy := handlerPolicy{}
+
+		Convey("When I run a handler that fails", func() {
+
+			Convey("Then it should not panic", func() {
+				So(func() { policy.run(func(*Event) { panic("woops") }, &Event{}) }, ShouldNotPanic)
+			})
+		})
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debugWriter writes the wire dumps of the requests and responses of a Session.
+type debugWriter struct {
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+// SetDebug makes the session dump every request it sends and every response
+// it receives, headers and bodies included, to the given writer. The values of
+// the RedactedHeaders and RedactedAttributes are redacted, so the dumps can be
+// shared as they are, for instance in a support bundle. A nil writer disables
+// the dumps.
+func (s *Session) SetDebug(w io.Writer) {
+
+	if w == nil {
+		s.debug = nil
+		return
+	}
+
+	s.debug = &debugWriter{writer: w}
+}
+
+// dumpRequest dumps the given request to the debug writer, if any.
+func (s *Session) dumpRequest(request *http.Request) {
+
+	if s.debug == nil {
+		return
+	}
+
+	dumped := request.Clone(request.Context())
+	dumped.Header = redactDumpedHeaders(request.Header)
+
+	var redacted *string
+
+	if request.Body != nil {
+		body, _ := ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		redactedBody := redactBody(body)
+		redacted = &redactedBody
+		dumped.Body = ioutil.NopCloser(strings.NewReader(redactedBody))
+		dumped.ContentLength = int64(len(redactedBody))
+	}
+
+	requestID := request.Header.Get(RequestIDHeader)
+
+	if s.debugCurl {
+		s.debug.write(fmt.Sprintf("---> CURL %s", requestID), []byte(s.curlCommand(request, redacted)))
+	}
+
+	dump, err := httputil.DumpRequestOut(dumped, true)
+	if err != nil {
+		dump = []byte(err.Error())
+	}
+
+	s.debug.write(fmt.Sprintf("---> REQUEST %s", requestID), dump)
+}
+
+// dumpResponse dumps the given response, received after the given duration, to the debug writer, if any.
+// The body of a stream of events is not dumped.
+func (s *Session) dumpResponse(request *http.Request, response *http.Response, duration time.Duration) {
+
+	if s.debug == nil {
+		return
+	}
+
+	dumped := *response
+	dumped.Header = redactDumpedHeaders(response.Header)
+
+	streaming := isStreamingResponse(response)
+	if streaming {
+		// The stream of events never ends: it is left to its reader.
+		dumped.Body = nil
+		dumped.ContentLength = 0
+	} else if response.Body != nil {
+		body, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		redacted := redactBody(body)
+		dumped.Body = ioutil.NopCloser(strings.NewReader(redacted))
+		dumped.ContentLength = int64(len(redacted))
+	}
+
+	dump, err := httputil.DumpResponse(&dumped, !streaming)
+	if err != nil {
+		dump = []byte(err.Error())
+	}
+	if streaming {
+		dump = append(bytes.TrimSpace(dump), "\n\n(stream not dumped)"...)
+	}
+
+	s.debug.write(fmt.Sprintf("<--- RESPONSE %s (%s)", request.Header.Get(RequestIDHeader), duration), dump)
+}
+
+// write writes the given title and dump, followed by an empty line.
+func (d *debugWriter) write(title string, dump []byte) {
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	fmt.Fprintf(d.writer, "%s\n%s\n\n", title, bytes.TrimSpace(dump))
+}
+
+// redactDumpedHeaders returns a copy of the given headers, with the values of the RedactedHeaders redacted.
+func redactDumpedHeaders(headers http.Header) http.Header {
+
+	redacted := headers.Clone()
+
+	for name := range redacted {
+		if isRedacted(name, RedactedHeaders) {
+			redacted[name] = []string{redactedValue}
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDebug_Session(t *testing.T) {
+
+	Convey("Given I have a session with a debug writer", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Set-Cookie", "session=secret-cookie")
+			w.Write([]byte(`[{"ID": "xxx", "name": "remote", "APIKey": "secret-key"}]`))
+		}))
+		defer ts.Close()
+
+		var dump bytes.Buffer
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetDebug(&dump)
+
+		Convey("When I save an entity", func() {
+
+			object := &FakeObject{ID: "xxx", Name: "local"}
+			err := session.SaveEntity(object)
+
+			Convey("Then the request and response should be dumped without secrets", func() {
+				So(err, ShouldBeNil)
+				So(dump.String(), ShouldContainSubstring, "---> REQUEST ")
+				So(dump.String(), ShouldContainSubstring, "PUT /fakes/xxx?responseChoice=1 HTTP/1.1")
+				So(dump.String(), ShouldContainSubstring, `"name":"local"`)
+				So(dump.String(), ShouldContainSubstring, "Authorization: REDACTED")
+				So(dump.String(), ShouldContainSubstring, "<--- RESPONSE ")
+				So(dump.String(), ShouldContainSubstring, "HTTP/1.1 200 OK")
+				So(dump.String(), ShouldContainSubstring, `"APIKey":"REDACTED"`)
+				So(dump.String(), ShouldNotContainSubstring, "XREST")
+				So(dump.String(), ShouldNotContainSubstring, "secret-key")
+				So(dump.String(), ShouldNotContainSubstring, "secret-cookie")
+			})
+
+			Convey("Then the object should still get the response", func() {
+				So(object.Name, ShouldEqual, "remote")
+			})
+		})
+
+		Convey("When I read a stream of events", func() {
+
+			stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, "data: {\"uuid\": \"a\", \"events\": [{\"type\": \"CREATE\", \"entityType\": \"fake\"}]}\n\n")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}))
+			defer stream.Close()
+
+			session.URL = stream.URL
+			transport := NewSSETransport(session)
+			defer transport.Close()
+
+			channel := make(NotificationsChannel, 1)
+			errs := make(chan *Error, 1)
+			go func() { errs <- transport.NextEvent(channel, "") }()
+
+			Convey("Then the event should be received and the stream not dumped", func() {
+				select {
+				case err := <-errs:
+					So(err, ShouldBeNil)
+				case <-time.After(2 * time.Second):
+					So("timeout", ShouldBeEmpty)
+				}
+				So((<-channel).UUID, ShouldEqual, "a")
+				So(dump.String(), ShouldContainSubstring, "(stream not dumped)")
+			})
+		})
+
+		Convey("When I disable the dumps and fetch an entity", func() {
+
+			session.SetDebug(nil)
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then nothing should be dumped", func() {
+				So(dump.Len(), ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import "fmt"
+
+// EventOrderError is given as Details of the Error sent to the ResyncRequiredHandler
+// when the PushCenter receives an event older than an event it already received.
+type EventOrderError struct {
+	NotificationUUID string
+	LastReceivedTime int64
+	ReceivedTime     int64
+}
+
+// Error returns the string representation of the EventOrderError.
+func (e *EventOrderError) Error() string {
+
+	return fmt.Sprintf("event of notification %s received at %d is older than the last event received at %d", e.NotificationUUID, e.ReceivedTime, e.LastReceivedTime)
+}
+
+// deduplicator remembers the last identifiers it has seen.
+type deduplicator struct {
+	size  int
+	seen  map[string]bool
+	order []string
+}
+
+// newDeduplicator returns a new *deduplicator remembering the given number of identifiers.
+func newDeduplicator(size int) *deduplicator {
+
+	return &deduplicator{
+		size: size,
+		seen: map[string]bool{},
+	}
+}
+
+// isDuplicate returns true if the given identifier has already been seen.
+// Otherwise, the identifier is remembered, forgetting the oldest one if needed.
+func (d *deduplicator) isDuplicate(ID string) bool {
+
+	if ID == "" {
+		return false
+	}
+
+	if d.seen[ID] {
+		return true
+	}
+
+	if len(d.order) == d.size {
+		delete(d.seen, d.order[0])
+		d.order = d.order[1:]
+	}
+
+	d.seen[ID] = true
+	d.order = append(d.order, ID)
+
+	return false
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDedup_isDuplicate(t *testing.T) {
+
+	Convey("Given I create a deduplicator of size 2", t, func() {
+
+		d := newDeduplicator(2)
+
+		Convey("When I check new identifiers", func() {
+
+			Convey("Then they should not be duplicates", func() {
+				So(d.isDuplicate("a"), ShouldBeFalse)
+				So(d.isDuplicate("b"), ShouldBeFalse)
+			})
+		})
+
+		Convey("When I check an identifier twice", func() {
+
+			d.isDuplicate("a")
+
+			Convey("Then the second one should be a duplicate", func() {
+				So(d.isDuplicate("a"), ShouldBeTrue)
+			})
+		})
+
+		Convey("When I check more identifiers than the size", func() {
+
+			d.isDuplicate("a")
+			d.isDuplicate("b")
+			d.isDuplicate("c")
+
+			Convey("Then the oldest one should be forgotten", func() {
+				So(d.isDuplicate("b"), ShouldBeTrue)
+				So(d.isDuplicate("c"), ShouldBeTrue)
+				So(d.isDuplicate("a"), ShouldBeFalse)
+			})
+		})
+
+		Convey("When I check empty identifiers", func() {
+
+			d.isDuplicate("")
+
+			Convey("Then they should never be duplicates", func() {
+				So(d.isDuplicate(""), ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestDedup_EventOrderError(t *testing.T) {
+
+	Convey("Given I have an EventOrderError", t, func() {
+
+		e := &EventOrderError{NotificationUUID: "x", LastReceivedTime: 2, ReceivedTime: 1}
+
+		Convey("Then Error should describe it", func() {
+			So(e.Error(), ShouldEqual, "event of notification x received at 1 is older than the last event received at 2")
+		})
+	})
+}
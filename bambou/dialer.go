@@ -0,0 +1,79 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DialContextFunc dials a connection to the given address on the given network.
+// See net.Dialer.DialContext.
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// SetDialContext sets the function dialing the connections of the session, to the
+// server or to its proxy, for instance through a tunnel. A nil function restores the
+// default dialer. It must be called before the session is used.
+func (s *Session) SetDialContext(dial DialContextFunc) *Error {
+
+	if s.transport == nil {
+		return NewBambouError("Invalid session", "the transport of the session is not configurable")
+	}
+
+	s.transport.DialContext = dial
+
+	return nil
+}
+
+// SetResolver sets the resolver of the host names of the session, for instance to
+// use a specific DNS server of a management network. It replaces the dialer of the
+// session by a net.Dialer using the given resolver. See SetDialContext.
+func (s *Session) SetResolver(resolver *net.Resolver) *Error {
+
+	dialer := &net.Dialer{
+		Resolver:  resolver,
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	return s.SetDialContext(dialer.DialContext)
+}
+
+// SetUnixSocket sets the session to connect to the unix socket at the given path,
+// like the socket of a local sidecar forwarding the requests to the server, instead
+// of the host of its URL. The URL still gives the scheme and the path of the API,
+// like http://localhost/nuage/api/v5_0. See SetDialContext.
+func (s *Session) SetUnixSocket(path string) *Error {
+
+	if path == "" {
+		return NewBambouError("Invalid unix socket", "the path of the socket is empty")
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	return s.SetDialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	})
+}
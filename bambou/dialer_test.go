@@ -0,0 +1,252 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeDNSServer is a minimal DNS server answering all the A queries with 127.0.0.1,
+// and all the SRV queries with its targets, given as host:port.
+type fakeDNSServer struct {
+	conn    net.PacketConn
+	targets []string
+	queries []string
+	lock    sync.Mutex
+}
+
+func newFakeDNSServer(targets ...string) *fakeDNSServer {
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &fakeDNSServer{conn: conn, targets: targets}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeDNSServer) Close() { s.conn.Close() }
+
+func (s *fakeDNSServer) names() []string {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.queries...)
+}
+
+func (s *fakeDNSServer) serve() {
+
+	buffer := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		if response := s.answer(buffer[:n]); response != nil {
+			s.conn.WriteTo(response, addr)
+		}
+	}
+}
+
+func (s *fakeDNSServer) answer(query []byte) []byte {
+
+	if len(query) < 12 {
+		return nil
+	}
+
+	// The question follows the header: a name as labels ended by a zero length, a type and a class.
+	end := 12
+	var labels []string
+	for end < len(query) && query[end] != 0 {
+		labels = append(labels, string(query[end+1:end+1+int(query[end])]))
+		end += 1 + int(query[end])
+	}
+	end += 5
+	if end > len(query) {
+		return nil
+	}
+	question := query[12:end]
+	queryType := binary.BigEndian.Uint16(question[len(question)-4:])
+
+	s.lock.Lock()
+	s.queries = append(s.queries, strings.Join(labels, "."))
+	s.lock.Unlock()
+
+	response := []byte{query[0], query[1], 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0}
+	response = append(response, question...)
+
+	switch queryType {
+
+	case 1:
+		response[7] = 1
+		response = append(response, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+
+	case 33:
+		for i, target := range s.targets {
+			host, port, _ := net.SplitHostPort(target)
+			number, _ := strconv.Atoi(port)
+
+			data := []byte{0, byte(i), 0, 0, byte(number >> 8), byte(number)}
+			for _, label := range strings.Split(host, ".") {
+				data = append(data, byte(len(label)))
+				data = append(data, label...)
+			}
+			data = append(data, 0)
+
+			response = append(response, 0xc0, 12, 0, 33, 0, 1, 0, 0, 0, 60, 0, byte(len(data)))
+			response = append(response, data...)
+		}
+		response[7] = byte(len(s.targets))
+	}
+
+	return response
+}
+
+func TestSession_SetDialContext(t *testing.T) {
+
+	Convey("Given I have a server with an unresolvable host name", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "1", "name": "object"})
+
+		u, _ := url.Parse(ts.URL)
+		_, port, _ := net.SplitHostPort(u.Host)
+		session := NewSession("username", "password", "organization", "http://vsd.bambou.test:"+port, NewFakeRootObject())
+
+		Convey("When I fetch an object with a dialer redirecting the connections", func() {
+
+			var addresses []string
+			var lock sync.Mutex
+			session.SetDialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+				lock.Lock()
+				addresses = append(addresses, address)
+				lock.Unlock()
+				return (&net.Dialer{}).DialContext(ctx, network, u.Host)
+			})
+
+			object := NewFakeObject("1")
+			err := session.FetchEntity(object)
+
+			Convey("Then the connection should be dialed by the dialer", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "object")
+				So(addresses, ShouldResemble, []string{"vsd.bambou.test:" + port})
+			})
+		})
+
+		Convey("When I fetch an object with a resolver using a specific DNS server", func() {
+
+			dns := newFakeDNSServer()
+			defer dns.Close()
+
+			session.SetResolver(&net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "udp", dns.conn.LocalAddr().String())
+				},
+			})
+
+			object := NewFakeObject("1")
+			err := session.FetchEntity(object)
+
+			Convey("Then the host name should be resolved by the DNS server", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "object")
+				So(dns.names(), ShouldContain, "vsd.bambou.test")
+			})
+		})
+
+		Convey("When I fetch an object with the default dialer", func() {
+
+			session.SetDialContext(nil)
+			err := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the host name should not be resolved", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestSession_SetUnixSocket(t *testing.T) {
+
+	Convey("Given I have a server listening on a unix socket", t, func() {
+
+		dir, _ := ioutil.TempDir("", "bambou")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "vsd.sock")
+
+		listener, err := net.Listen("unix", path)
+		So(err, ShouldBeNil)
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "1", "name": "object"})
+
+		server := &http.Server{Handler: ts.Config.Handler}
+		go server.Serve(listener)
+		defer server.Close()
+
+		session := NewSession("username", "password", "organization", "http://localhost/nuage/api/v5_0", NewFakeRootObject())
+
+		Convey("When I fetch an object through the socket", func() {
+
+			So(session.SetUnixSocket(path), ShouldBeNil)
+			object := NewFakeObject("1")
+			err := session.FetchEntity(object)
+
+			Convey("Then it should be fetched", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "object")
+			})
+		})
+
+		Convey("When I set an empty socket path", func() {
+
+			err := session.SetUnixSocket("")
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// DryRunIDPrefix prefixes the placeholder IDs given to the objects created in dry run mode.
+const DryRunIDPrefix = "dry-run-"
+
+// DryRunRequest represents a mutating request that would have been sent
+// to the server if the session was not in dry run mode.
+type DryRunRequest struct {
+	Method string
+	URL    string
+	Body   []byte
+}
+
+// dryRunRecorder keeps the list of requests intercepted in dry run mode.
+type dryRunRecorder struct {
+	requests []DryRunRequest
+	nextID   int
+	lock     sync.Mutex
+}
+
+// record records the given request, logs it to the given logger, and returns
+// a synthesized successful response.
+// For requests with a body, the response echoes the body back as the
+// server would have done. The created objects get a placeholder ID, so that
+// their children can be created in turn.
+func (r *dryRunRecorder) record(request *http.Request, logger LeveledLogger) *http.Response {
+
+	var body []byte
+	if request.Body != nil {
+		body, _ = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+	}
+
+	r.lock.Lock()
+	r.requests = append(r.requests, DryRunRequest{
+		Method: request.Method,
+		URL:    request.URL.String(),
+		Body:   body,
+	})
+	r.nextID++
+	ID := fmt.Sprintf("%s%d", DryRunIDPrefix, r.nextID)
+	r.lock.Unlock()
+
+	logger.Infof("Dry run: %s %s %s", request.Method, request.URL, redactBody(body))
+
+	echoed := bytes.TrimSpace(body)
+	if request.Method == http.MethodPost {
+		echoed = withPlaceholderID(echoed, ID)
+	}
+
+	responseBody := []byte{}
+	if len(echoed) > 0 {
+		responseBody = append(append([]byte("["), echoed...), ']')
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       request,
+	}
+}
+
+// withPlaceholderID returns the given JSON object with the given ID if it has none.
+func withPlaceholderID(body []byte, ID string) []byte {
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(body, &object); err != nil || object == nil {
+		return body
+	}
+
+	if existing, ok := object["ID"].(string); ok && existing != "" {
+		return body
+	}
+	object["ID"] = ID
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return body
+	}
+
+	return data
+}
+
+func (r *dryRunRecorder) list() []DryRunRequest {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	out := make([]DryRunRequest, len(r.requests))
+	copy(out, r.requests)
+
+	return out
+}
+
+func (r *dryRunRecorder) clear() {
+
+	r.lock.Lock()
+	r.requests = nil
+	r.lock.Unlock()
+}
+
+// SetDryRun enables or disables the dry run mode.
+// In dry run mode, SaveEntity, CreateChild, DeleteEntity and AssignChildren
+// log and record what they would send, but never hit the server and
+// return a synthesized success. Read operations are still sent. The cache of
+// the session is then left untouched, and the audited operations have DryRun set.
+func (s *Session) SetDryRun(dryRun bool) {
+
+	s.dryRun = dryRun
+}
+
+// DryRunRequests returns the list of requests that have been recorded in dry run mode.
+func (s *Session) DryRunRequests() []DryRunRequest {
+
+	return s.dryRunRecorder.list()
+}
+
+// ClearDryRunRequests clears the list of requests recorded in dry run mode.
+func (s *Session) ClearDryRunRequests() {
+
+	s.dryRunRecorder.clear()
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDryRun_Operations(t *testing.T) {
+
+	Convey("Given I have a session in dry run mode", t, func() {
+
+		called := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"ID": "xxx", "name": "remote"}]`)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetDryRun(true)
+
+		Convey("When I create a child", func() {
+
+			child := &FakeObject{Name: "new"}
+			err := session.CreateChild(NewFakeObject("yyy"), child)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the server should not be called", func() {
+				So(called, ShouldEqual, 0)
+			})
+
+			Convey("Then the child should only get a placeholder ID", func() {
+				So(child.Name, ShouldEqual, "new")
+				So(child.ID, ShouldStartWith, DryRunIDPrefix)
+			})
+
+			Convey("When I create a child of the child", func() {
+
+				grandChild := &FakeObject{Name: "nested"}
+				err := session.CreateChild(child, grandChild)
+
+				Convey("Then it should be recorded under the placeholder ID", func() {
+					So(err, ShouldBeNil)
+					So(grandChild.ID, ShouldStartWith, DryRunIDPrefix)
+					So(grandChild.ID, ShouldNotEqual, child.ID)
+					requests := session.DryRunRequests()
+					So(len(requests), ShouldEqual, 2)
+					So(requests[1].URL, ShouldEqual, ts.URL+"/fakes/"+child.ID+"/fakes")
+					So(called, ShouldEqual, 0)
+				})
+			})
+
+			Convey("Then the request should be recorded", func() {
+				requests := session.DryRunRequests()
+				So(len(requests), ShouldEqual, 1)
+				So(requests[0].Method, ShouldEqual, "POST")
+				So(requests[0].URL, ShouldEqual, ts.URL+"/fakes/yyy/fakes")
+				So(string(requests[0].Body), ShouldContainSubstring, `"name":"new"`)
+			})
+		})
+
+		Convey("When I save, delete and assign entities", func() {
+
+			e := NewFakeObject("xxx")
+			err1 := session.SaveEntity(e)
+			err2 := session.DeleteEntity(e)
+			err3 := session.AssignChildren(NewFakeObject("yyy"), []Identifiable{e}, FakeIdentity)
+
+			Convey("Then errors should be nil", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+				So(err3, ShouldBeNil)
+			})
+
+			Convey("Then the server should not be called", func() {
+				So(called, ShouldEqual, 0)
+			})
+
+			Convey("Then the 3 requests should be recorded", func() {
+				requests := session.DryRunRequests()
+				So(len(requests), ShouldEqual, 3)
+				So(requests[0].Method, ShouldEqual, "PUT")
+				So(requests[1].Method, ShouldEqual, "DELETE")
+				So(requests[2].Method, ShouldEqual, "PUT")
+			})
+
+			Convey("When I clear the recorded requests", func() {
+
+				session.ClearDryRunRequests()
+
+				Convey("Then there should be no recorded request", func() {
+					So(len(session.DryRunRequests()), ShouldEqual, 0)
+				})
+			})
+		})
+
+		Convey("When I save and create entities with a cache and an audit hook", func() {
+
+			var entries []*AuditEntry
+			session.SetAuditHook(func(entry *AuditEntry) { entries = append(entries, entry) })
+			session.SetCache(NewCache(time.Hour, 100))
+
+			e := NewFakeObject("xxx")
+			session.FetchEntity(e)
+			var children FakeObjectsList
+			session.FetchChildren(NewFakeObject("yyy"), FakeIdentity, &children, nil)
+
+			err1 := session.SaveEntity(e)
+			err2 := session.CreateChild(NewFakeObject("yyy"), &FakeObject{Name: "new"})
+			session.FetchEntity(NewFakeObject("xxx"))
+			session.FetchChildren(NewFakeObject("yyy"), FakeIdentity, &children, nil)
+
+			Convey("Then the cached objects and listings should not be invalidated", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+				So(called, ShouldEqual, 2)
+			})
+
+			Convey("Then the audit entries should be marked as dry run", func() {
+				So(entries, ShouldHaveLength, 2)
+				So(entries[0].DryRun, ShouldBeTrue)
+				So(entries[1].DryRun, ShouldBeTrue)
+			})
+		})
+
+		Convey("When I fetch an entity", func() {
+
+			e := NewFakeObject("xxx")
+			err := session.FetchEntity(e)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the server should be called", func() {
+				So(called, ShouldEqual, 1)
+				So(e.Name, ShouldEqual, "remote")
+			})
+		})
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// endpointTransport is an http.RoundTripper sending the requests to a list of
+// equivalent endpoints of a cluster. The requests are sent to the endpoint that
+// answered last, and sent again to the next endpoints when it cannot be reached.
+// The requests that may have been handled by the server are sent again only if
+// they are safe to repeat.
+type endpointTransport struct {
+	next      http.RoundTripper
+	endpoints []*url.URL
+	current   int
+	lock      sync.Mutex
+}
+
+// SetEndpoints sets the base URLs, like https://vsd2:8443, of the equivalent servers
+// of a cluster the session fails over to when the server cannot be reached. The
+// paths of the requests are kept, so only the schemes and hosts of the URLs are used.
+// The requests are sent to the endpoint that answered last, then to the next ones
+// in order until one is reached. The requests failing once sent, like on a reset
+// connection, are only sent again if their method is GET, HEAD or OPTIONS, so that
+// an object is never created or changed twice. Without endpoints, the requests are
+// sent to the URL of the session. The events received over a WebSocket do not fail over.
+func (s *Session) SetEndpoints(endpoints ...string) *Error {
+
+	urls := make([]*url.URL, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return NewBambouError("Invalid endpoint", fmt.Sprintf("invalid endpoint URL %q", endpoint))
+		}
+		urls = append(urls, u)
+	}
+
+	if s.endpoints == nil {
+		if len(urls) == 0 {
+			return nil
+		}
+		s.endpoints = &endpointTransport{next: s.client.Transport}
+		s.client.Transport = s.endpoints
+	}
+
+	s.endpoints.lock.Lock()
+	defer s.endpoints.lock.Unlock()
+
+	s.endpoints.endpoints = urls
+	s.endpoints.current = 0
+
+	return nil
+}
+
+// Endpoints returns the base URLs of the servers the session fails over to. See SetEndpoints.
+func (s *Session) Endpoints() []string {
+
+	if s.endpoints == nil {
+		return nil
+	}
+
+	s.endpoints.lock.Lock()
+	defer s.endpoints.lock.Unlock()
+
+	endpoints := make([]string, 0, len(s.endpoints.endpoints))
+	for _, u := range s.endpoints.endpoints {
+		endpoints = append(endpoints, u.String())
+	}
+
+	return endpoints
+}
+
+// DiscoverEndpoints looks up the given DNS SRV record, like _vsd-api._tcp.example.com,
+// with the given resolver, or the default one if nil, and sets the endpoints of the
+// session to the https URLs of its targets, by priority. See SetEndpoints. It can be
+// called again to follow the changes of the addresses of a cluster.
+func (s *Session) DiscoverEndpoints(ctx context.Context, resolver *net.Resolver, record string) *Error {
+
+	endpoints, err := LookupEndpoints(ctx, resolver, "https", record)
+	if err != nil {
+		return err
+	}
+
+	return s.SetEndpoints(endpoints...)
+}
+
+// LookupEndpoints looks up the given DNS SRV record, like _vsd-api._tcp.example.com,
+// with the given resolver, or the default one if nil, and returns the base URLs of
+// its targets with the given scheme, ordered by priority and randomly by weight.
+func LookupEndpoints(ctx context.Context, resolver *net.Resolver, scheme string, record string) ([]string, *Error) {
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, "", "", record)
+	if err != nil {
+		return nil, NewBambouError("Endpoint discovery error", err.Error())
+	}
+
+	endpoints := make([]string, 0, len(records))
+	for _, srv := range records {
+		host := strings.TrimSuffix(srv.Target, ".")
+		if host == "" {
+			continue
+		}
+		endpoints = append(endpoints, scheme+"://"+net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+	}
+
+	if len(endpoints) == 0 {
+		return nil, NewBambouError("Endpoint discovery error", fmt.Sprintf("no endpoint found in %s", record))
+	}
+
+	return endpoints, nil
+}
+
+// RoundTrip sends the given request to the current endpoint, or to the next ones
+// if it cannot be reached. See failsOver.
+func (t *endpointTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	t.lock.Lock()
+	endpoints, current := t.endpoints, t.current
+	t.lock.Unlock()
+
+	if len(endpoints) == 0 {
+		return t.next.RoundTrip(request)
+	}
+
+	var body []byte
+	if request.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var lastErr error
+	for i := range endpoints {
+
+		index := (current + i) % len(endpoints)
+
+		attempt := request.Clone(request.Context())
+		attempt.URL.Scheme = endpoints[index].Scheme
+		attempt.URL.Host = endpoints[index].Host
+		attempt.Host = ""
+		if request.Body != nil {
+			attempt.Body = ioutil.NopCloser(bytes.NewReader(body))
+			attempt.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+
+		response, err := t.next.RoundTrip(attempt)
+		if err == nil {
+			t.lock.Lock()
+			t.current = index
+			t.lock.Unlock()
+			return response, nil
+		}
+
+		if request.Context().Err() != nil || !failsOver(request, err) {
+			return nil, err
+		}
+
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// failsOver tells if the given request, failed with the given error, can be sent to the next
+// endpoint. It can if the connection to the endpoint could not be established, or if it is safe
+// to repeat: the server may have handled the other ones before failing.
+func failsOver(request *http.Request, err error) bool {
+
+	if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
+		return true
+	}
+
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
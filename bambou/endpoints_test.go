@@ -0,0 +1,180 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetEndpoints(t *testing.T) {
+
+	Convey("Given I have a session of a cluster whose first server is down", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "1", "name": "object"})
+
+		down, _ := net.Listen("tcp", "127.0.0.1:0")
+		downURL := "http://" + down.Addr().String()
+		down.Close()
+
+		session := NewSession("username", "password", "organization", downURL+"/nuage/api/v5_0", NewFakeRootObject())
+
+		Convey("When I save an object without endpoints", func() {
+
+			err := session.SaveEntity(&FakeObject{ID: "1", Name: "renamed"})
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(ts.get("1")["name"], ShouldEqual, "object")
+			})
+		})
+
+		Convey("When I save an object with the endpoints of the cluster", func() {
+
+			So(session.SetEndpoints(downURL, ts.URL), ShouldBeNil)
+			err := session.SaveEntity(&FakeObject{ID: "1", Name: "renamed"})
+
+			Convey("Then it should be sent to the next server with its path and body", func() {
+				So(err, ShouldBeNil)
+				So(ts.get("1")["name"], ShouldEqual, "renamed")
+				So(session.Endpoints(), ShouldResemble, []string{downURL, ts.URL})
+				So(session.endpoints.current, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I set an invalid endpoint", func() {
+
+			err := session.SetEndpoints("vsd:8443")
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I discover the endpoints from a SRV record", func() {
+
+			u, _ := url.Parse(ts.URL)
+			_, port, _ := net.SplitHostPort(u.Host)
+
+			dns := newFakeDNSServer("vsd1.bambou.test:8443", "localhost:"+port)
+			defer dns.Close()
+
+			resolver := &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "udp", dns.conn.LocalAddr().String())
+				},
+			}
+			err := session.DiscoverEndpoints(context.Background(), resolver, "_vsd-api._tcp.bambou.test")
+
+			Convey("Then the endpoints should be the targets by priority", func() {
+				So(err, ShouldBeNil)
+				So(dns.names(), ShouldContain, "_vsd-api._tcp.bambou.test")
+				So(session.Endpoints(), ShouldResemble, []string{"https://vsd1.bambou.test:8443", "https://localhost:" + port})
+			})
+		})
+
+		Convey("When I discover the endpoints from a SRV record without target", func() {
+
+			dns := newFakeDNSServer()
+			defer dns.Close()
+
+			resolver := &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "udp", dns.conn.LocalAddr().String())
+				},
+			}
+			err := session.DiscoverEndpoints(context.Background(), resolver, "_vsd-api._tcp.bambou.test")
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(session.Endpoints(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestSession_SetEndpoints_SentRequests(t *testing.T) {
+
+	Convey("Given I have a session of a cluster whose first server drops the connections once the requests are sent", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "1", "name": "object"})
+
+		var received []string
+		var lock sync.Mutex
+		dropping := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			received = append(received, r.Method)
+			lock.Unlock()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}))
+		defer dropping.Close()
+
+		dropped := func() []string {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]string{}, received...)
+		}
+
+		session := NewSession("username", "password", "organization", dropping.URL, NewFakeRootObject())
+		So(session.SetEndpoints(dropping.URL, ts.URL), ShouldBeNil)
+
+		Convey("When I create an object", func() {
+
+			err := session.CreateChild(session.Root(), &FakeObject{Name: "new"})
+
+			Convey("Then it should fail without being sent to the next server", func() {
+				So(err, ShouldNotBeNil)
+				So(dropped(), ShouldResemble, []string{"POST"})
+				So(ts.methods(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I fetch an object", func() {
+
+			object := NewFakeObject("1")
+			err := session.FetchEntity(object)
+
+			Convey("Then it should be sent to the next server", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "object")
+				So(dropped(), ShouldResemble, []string{"GET"})
+				So(ts.methods(), ShouldResemble, []string{"GET"})
+			})
+		})
+	})
+}
@@ -43,8 +43,29 @@ type VsdError struct {
 type Error struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
+
+	// Code is the HTTP status code of the server response that caused the error, if any.
+	Code int `json:"-"`
+
+	// Details holds additional typed information about the error, when available.
+	// For instance, a failed optimistic lock will set a *ConflictError.
+	Details interface{} `json:"-"`
+
+	// RequestID is the ID of the request that caused the error, if any. See RequestIDHeader.
+	RequestID string `json:"-"`
+
+	// TransactionID is the ID the server gave to the request that caused the error, if any.
+	// See TransactionIDHeaders.
+	TransactionID string `json:"-"`
+
+	// Labels are the labels of the session that sent the request that caused the error, if any.
+	// See Session.SetLabels.
+	Labels Labels `json:"-"`
 }
 
+// ErrReadOnly is returned by any mutating operation performed on a read only Session.
+var ErrReadOnly = NewBambouError("Read only session", "the session is read only and cannot modify any object")
+
 func NewBambouError(title, description string) *Error {
 	return &Error{
 		Title:       title,
@@ -52,6 +73,16 @@ func NewBambouError(title, description string) *Error {
 	}
 }
 
+// newHTTPError returns a new *Error caused by a server response with the given status code.
+func newHTTPError(code int, title, description string) *Error {
+
+	return &Error{
+		Title:       title,
+		Description: description,
+		Code:        code,
+	}
+}
+
 func NewError(code int, description string) *Error {
 	return &Error{
 		Title:       fmt.Sprintf("Error code: %d", code),
@@ -0,0 +1,352 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package eventgen provides a bambou.EventTransport sending scripted notifications
+// to a bambou.PushCenter, with controllable UUIDs, timing, duplicates and gaps, to
+// test the event driven applications deterministically:
+//
+//	generator := eventgen.New()
+//	generator.Create(enterprise).Duplicate().Gap().Update(enterprise)
+//	pushCenter := bambou.NewPushCenterWithTransport(generator)
+//	pushCenter.Start()
+//	<-generator.Done()
+package eventgen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// DefaultStart is the default time of the first event.
+var DefaultStart = time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultTick is the default duration between the received times of two notifications.
+const DefaultTick = time.Second
+
+// step is a step of the script of a Generator.
+type step struct {
+	delay        time.Duration
+	notification *bambou.Notification
+	err          *bambou.Error
+	gap          bool
+}
+
+// Generator is a bambou.EventTransport sending the notifications of its script,
+// in order, then waiting for the context to be done. It is safe to add steps
+// to the script while a PushCenter consumes it.
+type Generator struct {
+	steps    []*step
+	last     *bambou.Notification
+	delay    time.Duration
+	clock    time.Time
+	tick     time.Duration
+	seq      int
+	requests []string
+	added    chan struct{}
+	done     chan struct{}
+	lock     sync.Mutex
+}
+
+// New returns a new *Generator with an empty script.
+func New() *Generator {
+
+	return &Generator{
+		clock: DefaultStart,
+		tick:  DefaultTick,
+		added: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// SetClock sets the received time of the next event, and the duration
+// added to it after each notification.
+func (g *Generator) SetClock(next time.Time, tick time.Duration) *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.clock = next
+	g.tick = tick
+
+	return g
+}
+
+// Rewind moves the received time of the next event back by the given duration,
+// so the next events are received out of order.
+func (g *Generator) Rewind(d time.Duration) *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.clock = g.clock.Add(-d)
+
+	return g
+}
+
+// Wait delays the next step of the script by the given duration.
+func (g *Generator) Wait(d time.Duration) *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.delay += d
+
+	return g
+}
+
+// Create adds a notification with a CREATE event of the given entities,
+// that must have the same Identity.
+func (g *Generator) Create(entities ...bambou.Identifiable) *Generator {
+
+	return g.Event(bambou.EventTypeCreate, entities...)
+}
+
+// Update adds a notification with an UPDATE event of the given entities,
+// that must have the same Identity.
+func (g *Generator) Update(entities ...bambou.Identifiable) *Generator {
+
+	return g.Event(bambou.EventTypeUpdate, entities...)
+}
+
+// Delete adds a notification with a DELETE event of the given entities,
+// that must have the same Identity.
+func (g *Generator) Delete(entities ...bambou.Identifiable) *Generator {
+
+	return g.Event(bambou.EventTypeDelete, entities...)
+}
+
+// Event adds a notification with an event of the given type of the given entities,
+// that must have the same Identity. The notification gets the next generated UUID.
+func (g *Generator) Event(eventType string, entities ...bambou.Identifiable) *Generator {
+
+	event := &bambou.Event{
+		Type:            eventType,
+		UpdateMechanism: "DEFAULT",
+	}
+
+	for _, entity := range entities {
+		event.EntityType = entity.Identity().Name
+		event.DataMap = append(event.DataMap, dataOf(entity))
+	}
+
+	return g.Notify(&bambou.Notification{Events: bambou.EventsList{event}})
+}
+
+// Notify adds the given notification. It is given the next generated UUID if it has none,
+// and its events are given the received time of the clock if they have none.
+func (g *Generator) Notify(notification *bambou.Notification) *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if notification.UUID == "" {
+		g.seq++
+		notification.UUID = fmt.Sprintf("event-%d", g.seq)
+	}
+
+	for _, event := range notification.Events {
+		if event.ReceivedTime == 0 {
+			event.ReceivedTime = g.clock.UnixNano() / int64(time.Millisecond)
+		}
+	}
+	g.clock = g.clock.Add(g.tick)
+
+	g.last = notification
+	g.add(&step{notification: notification})
+
+	return g
+}
+
+// Duplicate adds the previous notification again, with the same UUID, as the server
+// does when the client resumes from an event ID older than the last one it received.
+func (g *Generator) Duplicate() *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.last != nil {
+		g.add(&step{notification: g.last})
+	}
+
+	return g
+}
+
+// Gap makes the next request of the events fail as if the server did not know
+// the given last event ID anymore, which requires the client to resync.
+// Events are lost: the request is only failed if the client gives a last event ID.
+func (g *Generator) Gap() *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.add(&step{gap: true})
+
+	return g
+}
+
+// Fail makes the next request of the events fail with the given error.
+func (g *Generator) Fail(err *bambou.Error) *Generator {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.add(&step{err: err})
+
+	return g
+}
+
+// Done returns a channel closed when all the steps of the script have been consumed.
+// The channel is replaced when steps are added after that.
+func (g *Generator) Done() <-chan struct{} {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.done
+}
+
+// Requests returns the last event IDs given by the client, for each request of the events.
+func (g *Generator) Requests() []string {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return append([]string{}, g.requests...)
+}
+
+// NextEventWithContext implements the bambou.EventTransport interface.
+func (g *Generator) NextEventWithContext(ctx context.Context, channel bambou.NotificationsChannel, lastEventID string) *bambou.Error {
+
+	step, err := g.next(ctx, lastEventID)
+	if err != nil {
+		return err
+	}
+
+	if step.delay > 0 {
+		timer := time.NewTimer(step.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return bambou.NewBambouError("Canceled", ctx.Err().Error())
+		}
+	}
+
+	if step.gap {
+		if lastEventID == "" {
+			return nil
+		}
+		return &bambou.Error{
+			Code:        http.StatusGone,
+			Title:       "Gone",
+			Description: fmt.Sprintf("event %s is not valid anymore", lastEventID),
+		}
+	}
+
+	if step.err != nil {
+		return step.err
+	}
+
+	select {
+	case channel <- step.notification:
+		return nil
+	case <-ctx.Done():
+		return bambou.NewBambouError("Canceled", ctx.Err().Error())
+	}
+}
+
+// NextEvent sends the next notification of the script to the given channel.
+func (g *Generator) NextEvent(channel bambou.NotificationsChannel, lastEventID string) *bambou.Error {
+
+	return g.NextEventWithContext(context.Background(), channel, lastEventID)
+}
+
+// next records the given request and pops the next step of the script,
+// waiting for one to be added until the given context is done.
+func (g *Generator) next(ctx context.Context, lastEventID string) (*step, *bambou.Error) {
+
+	g.lock.Lock()
+	g.requests = append(g.requests, lastEventID)
+
+	for len(g.steps) == 0 {
+		added := g.added
+		g.lock.Unlock()
+
+		select {
+		case <-added:
+		case <-ctx.Done():
+			return nil, bambou.NewBambouError("Canceled", ctx.Err().Error())
+		}
+
+		g.lock.Lock()
+	}
+
+	step := g.steps[0]
+	g.steps = g.steps[1:]
+	if len(g.steps) == 0 {
+		close(g.done)
+	}
+	g.lock.Unlock()
+
+	return step, nil
+}
+
+// add adds the given step to the script, with the pending delay.
+func (g *Generator) add(s *step) {
+
+	if len(g.steps) == 0 {
+		select {
+		case <-g.done:
+			g.done = make(chan struct{})
+		default:
+		}
+	}
+
+	s.delay = g.delay
+	g.delay = 0
+	g.steps = append(g.steps, s)
+
+	close(g.added)
+	g.added = make(chan struct{})
+}
+
+// dataOf returns the attributes of the given entity.
+func dataOf(entity bambou.Identifiable) map[string]interface{} {
+
+	data := map[string]interface{}{}
+
+	encoded, err := json.Marshal(entity)
+	if err == nil {
+		json.Unmarshal(encoded, &data)
+	}
+
+	return data
+}
+
+var _ bambou.EventTransport = (*Generator)(nil)
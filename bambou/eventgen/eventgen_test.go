@@ -0,0 +1,199 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package eventgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var subnetIdentity = bambou.Identity{Name: "subnet", Category: "subnets"}
+
+type subnet struct {
+	ID   string `json:"ID"`
+	Name string `json:"name"`
+}
+
+func (o *subnet) Identity() bambou.Identity { return subnetIdentity }
+func (o *subnet) Identifier() string        { return o.ID }
+func (o *subnet) SetIdentifier(ID string)   { o.ID = ID }
+
+// startPushCenter starts a push center consuming the given generator, and returns
+// it with the channels receiving the handled events and the resync requests.
+func startPushCenter(g *Generator, configure func(*bambou.PushCenter)) (*bambou.PushCenter, chan *bambou.Event, chan *bambou.Error) {
+
+	events := make(chan *bambou.Event, 100)
+	resyncs := make(chan *bambou.Error, 100)
+
+	p := bambou.NewPushCenterWithTransport(g)
+	p.SetReconnectBackoff(time.Millisecond, time.Millisecond)
+	p.RegisterHandlerForIdentity(func(e *bambou.Event) { events <- e }, subnetIdentity)
+	p.SetResyncRequiredHandler(func(err *bambou.Error) { resyncs <- err })
+	if configure != nil {
+		configure(p)
+	}
+	p.Start()
+
+	return p, events, resyncs
+}
+
+func TestGenerator_Script(t *testing.T) {
+
+	Convey("Given I have a generator with duplicates and a gap", t, func() {
+
+		s := &subnet{ID: "1", Name: "front"}
+		g := New()
+		g.Create(s).Duplicate().Gap().Update(s)
+
+		Convey("When a push center with deduplication consumes it", func() {
+
+			p, events, resyncs := startPushCenter(g, func(p *bambou.PushCenter) { p.SetDeduplicationWindow(10) })
+			defer p.Stop()
+
+			create := <-events
+			update := <-events
+			resync := <-resyncs
+
+			Convey("Then the duplicate should be dropped and the gap should require a resync", func() {
+				So(create.Type, ShouldEqual, bambou.EventTypeCreate)
+				So(create.DataMap[0]["name"], ShouldEqual, "front")
+				So(update.Type, ShouldEqual, bambou.EventTypeUpdate)
+				So(resync.Code, ShouldEqual, 410)
+				So(g.Requests()[:4], ShouldResemble, []string{"", "event-1", "event-1", ""})
+				So(len(events), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a push center without deduplication consumes it", func() {
+
+			p, events, _ := startPushCenter(g, nil)
+			defer p.Stop()
+
+			<-g.Done()
+			first, second := <-events, <-events
+
+			Convey("Then the duplicate should be handled twice", func() {
+				So(first.Type, ShouldEqual, bambou.EventTypeCreate)
+				So(second.Type, ShouldEqual, bambou.EventTypeCreate)
+			})
+		})
+	})
+}
+
+func TestGenerator_Timing(t *testing.T) {
+
+	Convey("Given I have a generator", t, func() {
+
+		g := New()
+
+		Convey("When I add notifications", func() {
+
+			g.SetClock(time.Unix(10, 0), 2*time.Second)
+			g.Create(&subnet{ID: "1"}).Create(&subnet{ID: "2"})
+
+			channel := make(bambou.NotificationsChannel, 2)
+			g.NextEvent(channel, "")
+			g.NextEvent(channel, "event-1")
+			first, second := <-channel, <-channel
+
+			Convey("Then their events should be received at the time of the clock", func() {
+				So(first.UUID, ShouldEqual, "event-1")
+				So(first.Events[0].ReceivedTime, ShouldEqual, 10000)
+				So(second.UUID, ShouldEqual, "event-2")
+				So(second.Events[0].ReceivedTime, ShouldEqual, 12000)
+			})
+		})
+
+		Convey("When I rewind the clock and a push center checking the order consumes it", func() {
+
+			g.Create(&subnet{ID: "1"}).Rewind(5 * time.Second).Create(&subnet{ID: "2"})
+
+			p, _, resyncs := startPushCenter(g, func(p *bambou.PushCenter) { p.SetOrderingCheck(true) })
+			defer p.Stop()
+
+			Convey("Then a resync should be required", func() {
+				So((<-resyncs).Title, ShouldEqual, "Events out of order")
+			})
+		})
+
+		Convey("When I delay a notification", func() {
+
+			g.Wait(30 * time.Millisecond).Delete(&subnet{ID: "1"})
+
+			start := time.Now()
+			channel := make(bambou.NotificationsChannel, 1)
+			err := g.NextEvent(channel, "")
+
+			Convey("Then it should be sent after the delay", func() {
+				So(err, ShouldBeNil)
+				So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 30*time.Millisecond)
+				So((<-channel).Events[0].Type, ShouldEqual, bambou.EventTypeDelete)
+			})
+		})
+
+		Convey("When I script a failure", func() {
+
+			failure := bambou.NewBambouError("failure", "")
+			g.Fail(failure)
+
+			err := g.NextEvent(make(bambou.NotificationsChannel, 1), "")
+
+			Convey("Then the request should fail", func() {
+				So(err, ShouldEqual, failure)
+			})
+		})
+
+		Convey("When I wait for an event with an empty script", func() {
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := g.NextEventWithContext(ctx, make(bambou.NotificationsChannel, 1), "")
+
+			Convey("Then the request should return when the context is done", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When a step is added while waiting", func() {
+
+			channel := make(bambou.NotificationsChannel, 1)
+			errs := make(chan *bambou.Error, 1)
+			go func() { errs <- g.NextEvent(channel, "") }()
+
+			time.Sleep(10 * time.Millisecond)
+			g.Create(&subnet{ID: "1"})
+
+			Convey("Then it should be sent", func() {
+				So(<-errs, ShouldBeNil)
+				So((<-channel).UUID, ShouldEqual, "event-1")
+				_, open := <-g.Done()
+				So(open, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// BackpressurePolicy defines what the PushCenter does with a new notification
+// when its buffer is full.
+type BackpressurePolicy int
+
+// Supported BackpressurePolicy.
+const (
+	// BackpressureBlock waits for the handlers to make room in the buffer.
+	// The PushCenter stops receiving notifications in the meantime.
+	BackpressureBlock BackpressurePolicy = iota
+
+	// BackpressureDropOldest drops the oldest buffered notification.
+	BackpressureDropOldest
+
+	// BackpressureDropNewest drops the new notification.
+	BackpressureDropNewest
+)
+
+// eventQueue buffers the notifications between the reception and the dispatch.
+type eventQueue struct {
+	buffer  chan *Notification
+	policy  BackpressurePolicy
+	dropped uint64
+}
+
+// newEventQueue returns a new *eventQueue of the given size and policy.
+func newEventQueue(size int, policy BackpressurePolicy) *eventQueue {
+
+	return &eventQueue{
+		buffer: make(chan *Notification, size),
+		policy: policy,
+	}
+}
+
+// push adds the given notification to the queue according to the policy.
+// It returns false if the given context is done while blocking.
+func (q *eventQueue) push(ctx context.Context, notification *Notification) bool {
+
+	switch q.policy {
+
+	case BackpressureDropNewest:
+		select {
+		case q.buffer <- notification:
+		default:
+			q.drop(notification)
+		}
+
+	case BackpressureDropOldest:
+		for {
+			select {
+			case q.buffer <- notification:
+				return true
+			default:
+			}
+
+			select {
+			case oldest := <-q.buffer:
+				q.drop(oldest)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case q.buffer <- notification:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
+
+// drop counts the events of the given notification as dropped.
+func (q *eventQueue) drop(notification *Notification) {
+
+	atomic.AddUint64(&q.dropped, uint64(len(notification.Events)))
+}
+
+// droppedEvents returns the number of events dropped so far.
+func (q *eventQueue) droppedEvents() uint64 {
+
+	return atomic.LoadUint64(&q.dropped)
+}
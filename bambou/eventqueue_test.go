@@ -0,0 +1,109 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestNotification(UUID string) *Notification {
+
+	n := NewNotification()
+	n.UUID = UUID
+	n.Events = EventsList{&Event{EntityType: "fake"}, &Event{EntityType: "fake"}}
+
+	return n
+}
+
+func TestEventQueue_push(t *testing.T) {
+
+	Convey("Given I have a full queue dropping the newest notifications", t, func() {
+
+		q := newEventQueue(1, BackpressureDropNewest)
+		q.push(context.Background(), newTestNotification("a"))
+
+		Convey("When I push a notification", func() {
+
+			ok := q.push(context.Background(), newTestNotification("b"))
+
+			Convey("Then it should be dropped", func() {
+				So(ok, ShouldBeTrue)
+				So(q.droppedEvents(), ShouldEqual, 2)
+				So((<-q.buffer).UUID, ShouldEqual, "a")
+			})
+		})
+	})
+
+	Convey("Given I have a full queue dropping the oldest notifications", t, func() {
+
+		q := newEventQueue(1, BackpressureDropOldest)
+		q.push(context.Background(), newTestNotification("a"))
+
+		Convey("When I push a notification", func() {
+
+			ok := q.push(context.Background(), newTestNotification("b"))
+
+			Convey("Then the oldest one should be dropped", func() {
+				So(ok, ShouldBeTrue)
+				So(q.droppedEvents(), ShouldEqual, 2)
+				So((<-q.buffer).UUID, ShouldEqual, "b")
+			})
+		})
+	})
+
+	Convey("Given I have a full blocking queue", t, func() {
+
+		q := newEventQueue(1, BackpressureBlock)
+		q.push(context.Background(), newTestNotification("a"))
+
+		Convey("When I push a notification with a context that expires", func() {
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			ok := q.push(ctx, newTestNotification("b"))
+
+			Convey("Then it should give up", func() {
+				So(ok, ShouldBeFalse)
+				So(q.droppedEvents(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I push a notification and make room in the queue", func() {
+
+			done := make(chan bool)
+			go func() { done <- q.push(context.Background(), newTestNotification("b")) }()
+			first := <-q.buffer
+
+			Convey("Then it should be queued", func() {
+				So(<-done, ShouldBeTrue)
+				So(first.UUID, ShouldEqual, "a")
+				So((<-q.buffer).UUID, ShouldEqual, "b")
+			})
+		})
+	})
+}
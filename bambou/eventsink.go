@@ -0,0 +1,53 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import "encoding/json"
+
+// EventSink is the interface of the external systems the events can be published to,
+// like message buses. Register Publish on a PushCenter as a FallibleEventHandler to
+// forward the events:
+//
+//	pushCenter.RegisterFallibleHandlerForIdentity(sink.Publish, bambou.AllIdentity)
+type EventSink interface {
+	Publish(*Event) error
+}
+
+// EncodeEvent returns the JSON representation of the given event, as published by the EventSinks.
+func EncodeEvent(event *Event) ([]byte, error) {
+
+	return json.Marshal(event)
+}
+
+// EventEntityID returns the ID of the first entity of the given event, if any.
+func EventEntityID(event *Event) string {
+
+	if len(event.DataMap) == 0 {
+		return ""
+	}
+
+	ID, _ := event.DataMap[0]["ID"].(string)
+
+	return ID
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventSink_EncodeEvent(t *testing.T) {
+
+	Convey("Given I have an event", t, func() {
+
+		e := &Event{EntityType: "fake", Type: EventTypeCreate, UpdateMechanism: "DEFAULT", ReceivedTime: 42, DataMap: []map[string]interface{}{{"ID": "x"}}}
+
+		Convey("When I encode it", func() {
+
+			data, err := EncodeEvent(e)
+
+			Convey("Then it should be encoded as received", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"entities":[{"ID":"x"}],"entityType":"fake","eventReceivedTime":42,"type":"CREATE","updateMechanism":"DEFAULT"}`)
+			})
+		})
+	})
+}
+
+func TestEventSink_EventEntityID(t *testing.T) {
+
+	Convey("Given I have events", t, func() {
+
+		Convey("Then EventEntityID should return the ID of the first entity", func() {
+			So(EventEntityID(&Event{DataMap: []map[string]interface{}{{"ID": "x"}, {"ID": "y"}}}), ShouldEqual, "x")
+			So(EventEntityID(&Event{}), ShouldEqual, "")
+		})
+	})
+}
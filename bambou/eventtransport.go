@@ -0,0 +1,267 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// EventTransport is the interface of objects that can receive the notifications
+// sent by the server. NextEventWithContext must send the next notification that occurs
+// after the given last event ID to the given channel, and return as soon as the given
+// context is done. A *Session implements EventTransport using long polling.
+type EventTransport interface {
+	NextEventWithContext(context.Context, NotificationsChannel, string) *Error
+}
+
+// sendNotification sends the given notification to the given channel if it contains events,
+// unless the given context is done first.
+func sendNotification(ctx context.Context, channel NotificationsChannel, notification *Notification) *Error {
+
+	if len(notification.Events) == 0 {
+		return nil
+	}
+
+	select {
+	case channel <- notification:
+		return nil
+	case <-ctx.Done():
+		return newCanceledError(ctx)
+	}
+}
+
+// newCanceledError returns the *Error returned when the given context is done.
+func newCanceledError(ctx context.Context) *Error {
+
+	return NewBambouError("Canceled", ctx.Err().Error())
+}
+
+// watchContext calls the given function if the given context is done before
+// the returned function is called.
+func watchContext(ctx context.Context, canceled func()) func() {
+
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			canceled()
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// WebSocketTransport is an EventTransport that receives the notifications over a WebSocket.
+// The connection is established on the first call to NextEvent, and re-established
+// after any error, resuming from the given last event ID.
+type WebSocketTransport struct {
+	session *Session
+	conn    *websocket.Conn
+	lock    sync.Mutex
+}
+
+// NewWebSocketTransport returns a new *WebSocketTransport that will use the given Session
+// to locate and authenticate against the server.
+func NewWebSocketTransport(session *Session) *WebSocketTransport {
+
+	return &WebSocketTransport{
+		session: session,
+	}
+}
+
+// NextEvent receives the next notification from the WebSocket and sends it to the given channel.
+func (t *WebSocketTransport) NextEvent(channel NotificationsChannel, lastEventID string) *Error {
+
+	return t.NextEventWithContext(context.Background(), channel, lastEventID)
+}
+
+// NextEventWithContext works like NextEvent, but returns as soon as the given context is done.
+// In that case, the connection is closed.
+func (t *WebSocketTransport) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
+	conn, berr := t.connection(ctx, lastEventID)
+	if berr != nil {
+		return berr
+	}
+
+	notification := NewNotification()
+	stopWatching := watchContext(ctx, func() { conn.Close() })
+	err := conn.ReadJSON(notification)
+	stopWatching()
+
+	if err != nil {
+		t.Close()
+		if ctx.Err() != nil {
+			return newCanceledError(ctx)
+		}
+		return NewBambouError("WebSocket error", err.Error())
+	}
+
+	return sendNotification(ctx, channel, notification)
+}
+
+// Close closes the underlying WebSocket connection, if any.
+func (t *WebSocketTransport) Close() error {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.conn == nil {
+		return nil
+	}
+
+	err := t.conn.Close()
+	t.conn = nil
+
+	return err
+}
+
+// connection returns the current connection or establishes a new one.
+func (t *WebSocketTransport) connection(ctx context.Context, lastEventID string) (*websocket.Conn, *Error) {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.conn != nil {
+		return t.conn, nil
+	}
+
+	u, err := url.Parse(t.session.URL + "/events")
+	if err != nil {
+		return nil, NewBambouError("WebSocket error", err.Error())
+	}
+
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	if lastEventID != "" {
+		u.RawQuery = url.Values{"uuid": []string{lastEventID}}.Encode()
+	}
+
+	request, _ := http.NewRequest("GET", u.String(), nil)
+	if berr := t.session.prepareHeaders(request, nil); berr != nil {
+		return nil, berr
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", request.Header.Get("Authorization"))
+	header.Set("X-Nuage-Organization", request.Header.Get("X-Nuage-Organization"))
+	header.Set("User-Agent", request.Header.Get("User-Agent"))
+	if token := request.Header.Get(TokenHeader); token != "" {
+		header.Set(TokenHeader, token)
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+		Jar:              t.session.client.Jar,
+	}
+	if tr := t.session.transport; tr != nil {
+		dialer.TLSClientConfig = tr.TLSClientConfig
+		dialer.Proxy = tr.Proxy
+		dialer.NetDialContext = tr.DialContext
+	}
+
+	t.session.getLogger().Debugf("WebSocket dial: %s", u)
+
+	conn, response, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, newCanceledError(ctx)
+		}
+		if response != nil {
+			return nil, newHTTPError(response.StatusCode, "WebSocket handshake error", response.Status)
+		}
+		return nil, NewBambouError("WebSocket error", err.Error())
+	}
+
+	t.conn = conn
+
+	return conn, nil
+}
+
+// AutoEventTransport is an EventTransport that uses a WebSocketTransport if the
+// server supports it, and falls back to long polling otherwise.
+type AutoEventTransport struct {
+	session   *Session
+	websocket *WebSocketTransport
+	longPoll  bool
+	lock      sync.Mutex
+}
+
+// NewAutoEventTransport returns a new *AutoEventTransport using the given Session.
+func NewAutoEventTransport(session *Session) *AutoEventTransport {
+
+	return &AutoEventTransport{
+		session:   session,
+		websocket: NewWebSocketTransport(session),
+	}
+}
+
+// NextEvent receives the next notification using the negotiated transport.
+// If the server rejects the WebSocket handshake, the transport switches
+// to long polling for good.
+func (t *AutoEventTransport) NextEvent(channel NotificationsChannel, lastEventID string) *Error {
+
+	return t.NextEventWithContext(context.Background(), channel, lastEventID)
+}
+
+// NextEventWithContext works like NextEvent, but returns as soon as the given context is done.
+func (t *AutoEventTransport) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
+	if t.UsesLongPolling() {
+		return t.session.NextEventWithContext(ctx, channel, lastEventID)
+	}
+
+	berr := t.websocket.NextEventWithContext(ctx, channel, lastEventID)
+	if berr == nil || berr.Code == 0 {
+		return berr
+	}
+
+	t.session.getLogger().Infof("WebSocket not supported by the server (%s), falling back to long polling", berr.Description)
+
+	t.lock.Lock()
+	t.longPoll = true
+	t.lock.Unlock()
+
+	return t.session.NextEventWithContext(ctx, channel, lastEventID)
+}
+
+// UsesLongPolling returns true if the transport fell back to long polling.
+func (t *AutoEventTransport) UsesLongPolling() bool {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.longPoll
+}
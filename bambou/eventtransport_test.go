@@ -0,0 +1,199 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventTransport_WebSocketTransport(t *testing.T) {
+
+	Convey("Given I have a WebSocket server sending notifications", t, func() {
+
+		requests := make(chan *http.Request, 10)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			requests <- r
+
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"uuid": "a", "events": []}`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"uuid": "b", "events": [{"type": "CREATE", "entityType": "fake", "entities": [{"ID": "x"}]}]}`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		transport := NewWebSocketTransport(session)
+		defer transport.Close()
+
+		channel := make(NotificationsChannel, 1)
+
+		Convey("When I get the next events", func() {
+
+			err1 := transport.NextEvent(channel, "last")
+			err2 := transport.NextEvent(channel, "a")
+
+			Convey("Then errors should be nil", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+			})
+
+			Convey("Then I should receive only the notification with events", func() {
+				So(len(channel), ShouldEqual, 1)
+				So((<-channel).UUID, ShouldEqual, "b")
+			})
+
+			Convey("Then only one authenticated connection should be established from the last event ID", func() {
+				So(len(requests), ShouldEqual, 1)
+				r := <-requests
+				So(r.URL.Path, ShouldEqual, "/events")
+				So(r.URL.Query().Get("uuid"), ShouldEqual, "last")
+				So(r.Header.Get("Authorization"), ShouldEqual, "XREST dXNlcm5hbWU6cGFzc3dvcmQ=")
+				So(r.Header.Get("X-Nuage-Organization"), ShouldEqual, "organization")
+			})
+
+			Convey("When the server closes the connection", func() {
+
+				err := transport.NextEvent(channel, "b")
+
+				Convey("Then err should not be nil", func() {
+					So(err, ShouldNotBeNil)
+				})
+
+				Convey("Then the next call should reconnect", func() {
+					transport.NextEvent(channel, "b")
+					So(len(requests), ShouldEqual, 2)
+				})
+			})
+		})
+	})
+}
+
+func TestEventTransport_AutoEventTransport(t *testing.T) {
+
+	Convey("Given I have a server that doesn't support WebSocket", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if websocket.IsWebSocketUpgrade(r) {
+				http.Error(w, "nope", http.StatusMethodNotAllowed)
+				return
+			}
+			fmt.Fprint(w, `{"uuid": "a", "events": [{"type": "CREATE", "entityType": "fake", "entities": [{"ID": "x"}]}]}`)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		transport := NewAutoEventTransport(session)
+		channel := make(NotificationsChannel, 1)
+
+		Convey("When I get the next event", func() {
+
+			err := transport.NextEvent(channel, "")
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then I should receive the notification using long polling", func() {
+				So((<-channel).UUID, ShouldEqual, "a")
+				So(transport.UsesLongPolling(), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given I have a server that supports WebSocket", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"uuid": "a", "events": [{"type": "CREATE", "entityType": "fake", "entities": [{"ID": "x"}]}]}`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		transport := NewAutoEventTransport(session)
+		channel := make(NotificationsChannel, 1)
+
+		Convey("When I get the next event", func() {
+
+			err := transport.NextEvent(channel, "")
+
+			Convey("Then I should receive the notification using WebSocket", func() {
+				So(err, ShouldBeNil)
+				So((<-channel).UUID, ShouldEqual, "a")
+				So(transport.UsesLongPolling(), ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestEventTransport_PushCenter(t *testing.T) {
+
+	Convey("Given I have a push center using a WebSocket transport", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"uuid": "a", "events": [{"type": "CREATE", "entityType": "fake", "entities": [{"ID": "x"}]}]}`))
+			conn.ReadMessage()
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		transport := NewWebSocketTransport(session)
+		defer transport.Close()
+
+		received := make(chan *Event, 1)
+		p := NewPushCenterWithTransport(transport)
+		p.RegisterHandlerForIdentity(func(e *Event) { received <- e }, FakeIdentity)
+
+		Convey("When I start it", func() {
+
+			p.Start()
+			e := <-received
+			p.Stop()
+
+			Convey("Then I should receive the event", func() {
+				So(e.DataMap[0]["ID"], ShouldEqual, "x")
+			})
+		})
+	})
+}
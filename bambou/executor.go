@@ -0,0 +1,250 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultExecutorWorkers is the number of operations run concurrently by the
+// Executor used when none is given to the bulk functions.
+const DefaultExecutorWorkers = 8
+
+// ItemResult is the result of one of the operations run by an Executor.
+type ItemResult struct {
+
+	// Index is the index of the operation.
+	Index int
+
+	// Attempts is the number of times the operation was tried. It is 0 if the
+	// operation was not started because the context was done.
+	Attempts int
+
+	// Err is the error returned by the last attempt, if any.
+	Err *Error
+}
+
+// BatchResult contains the results of all the operations run by an Executor, in order.
+type BatchResult struct {
+	Results []ItemResult
+}
+
+// BatchError is set as the Details of the *Error returned by BatchResult.Err.
+type BatchError struct {
+
+	// Failures are the results of the operations that failed.
+	Failures []ItemResult
+}
+
+// Failures returns the results of the operations that failed.
+func (r *BatchResult) Failures() []ItemResult {
+
+	var failures []ItemResult
+
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+
+	return failures
+}
+
+// Succeeded returns the number of operations that succeeded.
+func (r *BatchResult) Succeeded() int {
+
+	return len(r.Results) - len(r.Failures())
+}
+
+// Err returns nil if all the operations succeeded, or an *Error describing the
+// first failure, with a BatchError as Details.
+func (r *BatchResult) Err() *Error {
+
+	failures := r.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return &Error{
+		Title:       "Batch error",
+		Description: fmt.Sprintf("%d of %d operations failed, first at index %d: %s", len(failures), len(r.Results), failures[0].Index, failures[0].Err),
+		Details:     &BatchError{Failures: failures},
+	}
+}
+
+// RetryPolicy returns true if an operation that failed with the given error should be tried again.
+type RetryPolicy func(*Error) bool
+
+// IsTransientError is the default RetryPolicy of the Executors. It returns true
+// for the errors of the HTTP client, and for the 429, 502, 503 and 504 statuses.
+func IsTransientError(err *Error) bool {
+
+	switch err.Code {
+	case 0:
+		return err.Title == "HTTP client error"
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// Executor runs batches of operations on a bounded number of goroutines, retrying
+// the operations failing with transient errors, and aggregates their results.
+// It is safe to run several batches concurrently with the same Executor, each
+// batch being bounded on its own.
+type Executor struct {
+	workers     int
+	retries     int
+	backoff     time.Duration
+	maxElapsed  time.Duration
+	retryPolicy RetryPolicy
+	clock       Clock
+}
+
+// NewExecutor returns a new *Executor running the given number of operations
+// concurrently, without retries.
+func NewExecutor(workers int) *Executor {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Executor{
+		workers:     workers,
+		retryPolicy: IsTransientError,
+	}
+}
+
+// SetRetries makes the Executor try the failed operations again, up to the given
+// number of times, waiting the given backoff before the first retry and doubling
+// it before each of the next ones. Only the errors accepted by the RetryPolicy are
+// retried: beware that a creation may have succeeded on the server even if its
+// response was lost.
+func (e *Executor) SetRetries(retries int, backoff time.Duration) {
+
+	e.retries = retries
+	e.backoff = backoff
+}
+
+// SetRetryBudget bounds the time spent retrying each operation: no retry is started
+// once the given duration has elapsed since the first attempt of the operation, and
+// the backoffs are shortened to fit in it. Under a sustained outage, the batches then
+// fail in a predictable time, whatever the number of retries. 0 removes the budget.
+func (e *Executor) SetRetryBudget(maxElapsed time.Duration) {
+
+	e.maxElapsed = maxElapsed
+}
+
+// SetClock sets the Clock timing the retries. The default is SystemClock.
+func (e *Executor) SetClock(clock Clock) {
+
+	e.clock = clock
+}
+
+// SetRetryPolicy sets the function deciding which errors are retried.
+// The default is IsTransientError.
+func (e *Executor) SetRetryPolicy(policy RetryPolicy) {
+
+	e.retryPolicy = policy
+}
+
+// Run calls the given function with every index from 0 to count-1, on at most the
+// number of workers of the Executor at a time, and returns the result of each call.
+// Once the given context is done, the operations not started yet are skipped with
+// a cancellation error.
+func (e *Executor) Run(ctx context.Context, count int, operation func(ctx context.Context, index int) *Error) *BatchResult {
+
+	result := &BatchResult{Results: make([]ItemResult, count)}
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < e.workers && i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result.Results[index] = e.runItem(ctx, index, operation)
+			}
+		}()
+	}
+
+	for index := 0; index < count; index++ {
+		if ctx.Err() != nil {
+			result.Results[index] = ItemResult{Index: index, Err: newCanceledError(ctx)}
+			continue
+		}
+		select {
+		case indexes <- index:
+		case <-ctx.Done():
+			result.Results[index] = ItemResult{Index: index, Err: newCanceledError(ctx)}
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return result
+}
+
+// runItem runs the operation with the given index, with retries.
+func (e *Executor) runItem(ctx context.Context, index int, operation func(ctx context.Context, index int) *Error) ItemResult {
+
+	item := ItemResult{Index: index}
+	backoff := e.backoff
+	clock := clockOrSystem(e.clock)
+	start := clock.Now()
+
+	for {
+		item.Attempts++
+		item.Err = operation(ctx, index)
+
+		if item.Err == nil || item.Attempts > e.retries || e.retryPolicy == nil || !e.retryPolicy(item.Err) {
+			return item
+		}
+
+		wait := backoff
+		if e.maxElapsed > 0 {
+			remaining := e.maxElapsed - clock.Now().Sub(start)
+			if remaining <= 0 {
+				return item
+			}
+			if wait > remaining {
+				wait = remaining
+			}
+		}
+
+		timer := clock.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return item
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExecutor_Run(t *testing.T) {
+
+	Convey("Given I have an executor with 3 workers", t, func() {
+
+		executor := NewExecutor(3)
+
+		Convey("When I run many slow operations", func() {
+
+			var running, maxRunning int32
+			result := executor.Run(context.Background(), 20, func(ctx context.Context, index int) *Error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+
+			Convey("Then at most 3 should run at a time", func() {
+				So(atomic.LoadInt32(&maxRunning), ShouldEqual, 3)
+				So(len(result.Results), ShouldEqual, 20)
+				So(result.Succeeded(), ShouldEqual, 20)
+				So(result.Err(), ShouldBeNil)
+			})
+		})
+
+		Convey("When some operations fail", func() {
+
+			result := executor.Run(context.Background(), 5, func(ctx context.Context, index int) *Error {
+				if index%2 == 1 {
+					return newHTTPError(409, "HTTP error", "conflict")
+				}
+				return nil
+			})
+
+			Convey("Then the results should be aggregated in order", func() {
+				So(result.Succeeded(), ShouldEqual, 3)
+				So(len(result.Failures()), ShouldEqual, 2)
+				So(result.Results[1].Index, ShouldEqual, 1)
+				So(result.Results[1].Err.Code, ShouldEqual, 409)
+
+				err := result.Err()
+				So(err.Description, ShouldStartWith, "2 of 5 operations failed, first at index 1")
+				So(len(err.Details.(*BatchError).Failures), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I set retries and operations fail with transient errors", func() {
+
+			executor.SetRetries(3, time.Millisecond)
+
+			var lock sync.Mutex
+			attempts := map[int]int{}
+			result := executor.Run(context.Background(), 2, func(ctx context.Context, index int) *Error {
+				lock.Lock()
+				defer lock.Unlock()
+				attempts[index]++
+				if index == 0 && attempts[index] < 3 {
+					return newHTTPError(503, "HTTP error", "unavailable")
+				}
+				if index == 1 {
+					return newHTTPError(400, "HTTP error", "bad request")
+				}
+				return nil
+			})
+
+			Convey("Then only the transient errors should be retried", func() {
+				So(result.Results[0].Err, ShouldBeNil)
+				So(result.Results[0].Attempts, ShouldEqual, 3)
+				So(result.Results[1].Err.Code, ShouldEqual, 400)
+				So(result.Results[1].Attempts, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I set a retry budget and an operation keeps failing", func() {
+
+			executor.SetRetries(1000, 5*time.Millisecond)
+			executor.SetRetryBudget(50 * time.Millisecond)
+
+			start := time.Now()
+			result := executor.Run(context.Background(), 1, func(ctx context.Context, index int) *Error {
+				return newHTTPError(503, "HTTP error", "unavailable")
+			})
+			elapsed := time.Since(start)
+
+			Convey("Then it should stop retrying once the budget is spent", func() {
+				So(result.Results[0].Err.Code, ShouldEqual, 503)
+				So(result.Results[0].Attempts, ShouldBeLessThan, 1000)
+				So(elapsed, ShouldBeGreaterThanOrEqualTo, 50*time.Millisecond)
+				So(elapsed, ShouldBeLessThan, time.Second)
+			})
+		})
+
+		Convey("When the context is canceled during the batch", func() {
+
+			ctx, cancel := context.WithCancel(context.Background())
+			var started int32
+			result := executor.Run(ctx, 10, func(ctx context.Context, index int) *Error {
+				if atomic.AddInt32(&started, 1) == 3 {
+					cancel()
+				}
+				return nil
+			})
+
+			Convey("Then the operations not started should be skipped", func() {
+				skipped := result.Failures()
+				So(len(skipped), ShouldBeGreaterThan, 0)
+				So(skipped[0].Attempts, ShouldEqual, 0)
+				So(skipped[0].Err.Title, ShouldEqual, "Canceled")
+				So(int(atomic.LoadInt32(&started))+len(skipped), ShouldEqual, 10)
+			})
+		})
+	})
+}
+
+func TestExecutor_IsTransientError(t *testing.T) {
+
+	Convey("Given I have errors", t, func() {
+
+		Convey("Then the transient ones should be recognized", func() {
+			So(IsTransientError(NewBambouError("HTTP client error", "connection reset")), ShouldBeTrue)
+			So(IsTransientError(newHTTPError(429, "HTTP error", "")), ShouldBeTrue)
+			So(IsTransientError(newHTTPError(504, "HTTP error", "")), ShouldBeTrue)
+			So(IsTransientError(newHTTPError(500, "HTTP error", "")), ShouldBeFalse)
+			So(IsTransientError(NewBambouError("JSON error", "")), ShouldBeFalse)
+		})
+	})
+}
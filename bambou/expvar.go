@@ -0,0 +1,196 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// SessionStats are the statistics of a Session.
+type SessionStats struct {
+	URL              string `json:"URL"`
+	InFlightRequests int    `json:"inFlightRequests"`
+	Requests         uint64 `json:"requests"`
+	FailedRequests   uint64 `json:"failedRequests"`
+	Retries          uint64 `json:"retries"`
+	TrackedVersions  int    `json:"trackedVersions"`
+	DryRunRequests   int    `json:"dryRunRequests"`
+	ReadOnly         bool   `json:"readOnly"`
+	DryRun           bool   `json:"dryRun"`
+}
+
+// Stats returns the current statistics of the session.
+func (s *Session) Stats() SessionStats {
+
+	s.dryRunRecorder.lock.Lock()
+	dryRunRequests := len(s.dryRunRecorder.requests)
+	s.dryRunRecorder.lock.Unlock()
+
+	return SessionStats{
+		URL:              s.URL,
+		InFlightRequests: int(atomic.LoadInt32(&s.inFlight)),
+		Requests:         atomic.LoadUint64(&s.requests),
+		FailedRequests:   atomic.LoadUint64(&s.failures),
+		Retries:          atomic.LoadUint64(&s.retries),
+		TrackedVersions:  s.versions.len(),
+		DryRunRequests:   dryRunRequests,
+		ReadOnly:         s.readOnly,
+		DryRun:           s.dryRun,
+	}
+}
+
+// PushCenterStats are the statistics of the event loop of a PushCenter.
+type PushCenterStats struct {
+	Running       bool   `json:"running"`
+	LastEventID   string `json:"lastEventID"`
+	QueuedEvents  int    `json:"queuedEvents"`
+	DroppedEvents uint64 `json:"droppedEvents"`
+	Subscriptions int    `json:"subscriptions"`
+	Workers       int    `json:"workers"`
+}
+
+// Stats returns the current statistics of the PushCenter.
+func (p *PushCenter) Stats() PushCenterStats {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	stats := PushCenterStats{
+		Running:       p.isRunning,
+		LastEventID:   p.lastEventID,
+		Subscriptions: len(p.subscriptions),
+		Workers:       p.workers,
+	}
+
+	if p.queue != nil {
+		stats.QueuedEvents = len(p.queue.buffer)
+		stats.DroppedEvents = p.queue.droppedEvents()
+	}
+
+	return stats
+}
+
+// Sizer is implemented by the caches whose size can be published, like the Store.
+type Sizer interface {
+	Len() int
+}
+
+// Expvar publishes the statistics of sessions, PushCenters and caches as a single
+// expvar variable, so they are served by the /debug/vars endpoint with the other
+// expvar variables. The variable is a JSON object like:
+//
+//	{
+//	    "sessions": {"main": {"URL": "https://vsd:8443", "requests": 12, ...}},
+//	    "pushCenters": {"main": {"running": true, "lastEventID": "...", ...}},
+//	    "caches": {"domains": 42}
+//	}
+type Expvar struct {
+	sessions    map[string]*Session
+	pushCenters map[string]*PushCenter
+	caches      map[string]Sizer
+	lock        sync.RWMutex
+}
+
+// PublishExpvar publishes a new *Expvar under the given name and returns it.
+// Like expvar.Publish, it panics if the name is already used.
+func PublishExpvar(name string) *Expvar {
+
+	e := &Expvar{
+		sessions:    map[string]*Session{},
+		pushCenters: map[string]*PushCenter{},
+		caches:      map[string]Sizer{},
+	}
+
+	expvar.Publish(name, expvar.Func(e.snapshot))
+
+	return e
+}
+
+// AddSession publishes the statistics of the given session under the given name.
+func (e *Expvar) AddSession(name string, session *Session) {
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.sessions[name] = session
+}
+
+// AddPushCenter publishes the statistics of the given PushCenter under the given name.
+func (e *Expvar) AddPushCenter(name string, pushCenter *PushCenter) {
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.pushCenters[name] = pushCenter
+}
+
+// AddCache publishes the size of the given cache under the given name.
+func (e *Expvar) AddCache(name string, cache Sizer) {
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.caches[name] = cache
+}
+
+// Remove stops publishing the session, PushCenter or cache with the given name.
+func (e *Expvar) Remove(name string) {
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.sessions, name)
+	delete(e.pushCenters, name)
+	delete(e.caches, name)
+}
+
+// snapshot returns the current value of the variable.
+func (e *Expvar) snapshot() interface{} {
+
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	sessions := make(map[string]SessionStats, len(e.sessions))
+	for name, session := range e.sessions {
+		sessions[name] = session.Stats()
+	}
+
+	pushCenters := make(map[string]PushCenterStats, len(e.pushCenters))
+	for name, pushCenter := range e.pushCenters {
+		pushCenters[name] = pushCenter.Stats()
+	}
+
+	caches := make(map[string]int, len(e.caches))
+	for name, cache := range e.caches {
+		caches[name] = cache.Len()
+	}
+
+	return map[string]interface{}{
+		"sessions":    sessions,
+		"pushCenters": pushCenters,
+		"caches":      caches,
+	}
+}
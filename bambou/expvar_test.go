@@ -0,0 +1,136 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExpvar_Stats(t *testing.T) {
+
+	Convey("Given I have a session", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/fakes/missing":
+				w.WriteHeader(http.StatusInternalServerError)
+			case "/fakes/choice":
+				if r.URL.Query().Get("responseChoice") == "" {
+					w.WriteHeader(http.StatusMultipleChoices)
+					return
+				}
+				fallthrough
+			default:
+				w.Write([]byte(`[{"ID": "xxx"}]`))
+			}
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I send successful, retried and failed requests", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			session.FetchEntity(NewFakeObject("choice"))
+			session.FetchEntity(NewFakeObject("missing"))
+
+			stats := session.Stats()
+
+			Convey("Then the statistics should count them", func() {
+				So(stats.URL, ShouldEqual, ts.URL)
+				So(stats.Requests, ShouldEqual, 4)
+				So(stats.Retries, ShouldEqual, 1)
+				So(stats.FailedRequests, ShouldEqual, 1)
+				So(stats.InFlightRequests, ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given I have a PushCenter", t, func() {
+
+		p := NewPushCenterWithTransport(nil)
+		p.SetWorkers(2)
+
+		Convey("When I get its statistics", func() {
+
+			stats := p.Stats()
+
+			Convey("Then they should describe the event loop", func() {
+				So(stats.Running, ShouldBeFalse)
+				So(stats.Workers, ShouldEqual, 2)
+				So(stats.QueuedEvents, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+// publishedExpvars counts the variables published by TestExpvar_PublishExpvar.
+var publishedExpvars int
+
+func TestExpvar_PublishExpvar(t *testing.T) {
+
+	// The expvar names are global to the process: every run publishes its own.
+	publishedExpvars++
+	name := fmt.Sprintf("bambou_test_%d", publishedExpvars)
+	e := PublishExpvar(name)
+
+	Convey("Given I publish an expvar variable", t, func() {
+
+		store := NewStore()
+		store.Add(NewFakeObject("xxx"), "")
+
+		e.AddSession("main", NewSession("username", "password", "organization", "https://vsd", NewFakeRootObject()))
+		e.AddPushCenter("events", NewPushCenterWithTransport(nil))
+		e.AddCache("store", store)
+
+		Convey("When I read the variable", func() {
+
+			var value map[string]map[string]interface{}
+			err := json.Unmarshal([]byte(expvar.Get(name).String()), &value)
+
+			Convey("Then it should contain the statistics", func() {
+				So(err, ShouldBeNil)
+				So(value["sessions"]["main"].(map[string]interface{})["URL"], ShouldEqual, "https://vsd")
+				So(value["pushCenters"]["events"].(map[string]interface{})["running"], ShouldBeFalse)
+				So(value["caches"]["store"], ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I remove an entry and read the variable", func() {
+
+			e.Remove("store")
+
+			Convey("Then it should be gone", func() {
+				So(expvar.Get(name).String(), ShouldContainSubstring, `"caches":{}`)
+			})
+		})
+	})
+}
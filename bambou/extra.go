@@ -0,0 +1,183 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ExtraAttributesHolder is implemented by the objects keeping the attributes returned
+// by the server that their model does not know, for instance because the server runs
+// a newer version of the API. The Session sets them when it decodes the objects and
+// sends them back when it saves them, so they are not lost during the round trips.
+// Embed ExtraAttributes in a model to implement it.
+type ExtraAttributesHolder interface {
+	GetExtraAttributes() map[string]interface{}
+	SetExtraAttributes(map[string]interface{})
+}
+
+// ExtraAttributes implements ExtraAttributesHolder. The numbers of the extra
+// attributes are json.Numbers, so they are sent back as they have been received.
+type ExtraAttributes struct {
+	extraAttributes map[string]interface{}
+}
+
+// GetExtraAttributes returns the attributes unknown to the model, by name.
+func (e *ExtraAttributes) GetExtraAttributes() map[string]interface{} {
+
+	return e.extraAttributes
+}
+
+// SetExtraAttributes sets the attributes unknown to the model, by name.
+func (e *ExtraAttributes) SetExtraAttributes(attributes map[string]interface{}) {
+
+	e.extraAttributes = attributes
+}
+
+var extraAttributesHolderType = reflect.TypeOf((*ExtraAttributesHolder)(nil)).Elem()
+
+var (
+	knownAttributesCache = map[reflect.Type]map[string]struct{}{}
+	knownAttributesLock  sync.RWMutex
+)
+
+// knownAttributes returns the lower case JSON names of the attributes of the given struct type.
+func knownAttributes(t reflect.Type) map[string]struct{} {
+
+	knownAttributesLock.RLock()
+	names, ok := knownAttributesCache[t]
+	knownAttributesLock.RUnlock()
+
+	if ok {
+		return names
+	}
+
+	names = map[string]struct{}{}
+	collectKnownAttributes(t, names)
+
+	knownAttributesLock.Lock()
+	knownAttributesCache[t] = names
+	knownAttributesLock.Unlock()
+
+	return names
+}
+
+func collectKnownAttributes(t reflect.Type, names map[string]struct{}) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			collectKnownAttributes(field.Type, names)
+			continue
+		}
+
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		names[strings.ToLower(fieldName(field))] = struct{}{}
+	}
+}
+
+// captureExtraAttributes sets the extra attributes of the objects of the given list,
+// that are ExtraAttributesHolders, from the given JSON list they have been decoded from.
+// The list can be an IdentifiablesList or a pointer to a slice.
+func captureExtraAttributes(data []byte, list interface{}) {
+
+	v := reflect.Indirect(reflect.ValueOf(list))
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return
+	}
+
+	if t := v.Type().Elem(); t.Kind() != reflect.Interface && !t.Implements(extraAttributesHolderType) {
+		return
+	}
+
+	var items []map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&items); err != nil {
+		return
+	}
+
+	for i := 0; i < v.Len() && i < len(items); i++ {
+
+		item := v.Index(i)
+		if item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+
+		if item.Kind() != reflect.Ptr || item.IsNil() || item.Type().Elem().Kind() != reflect.Struct {
+			continue
+		}
+
+		holder, ok := item.Interface().(ExtraAttributesHolder)
+		if !ok {
+			continue
+		}
+
+		known := knownAttributes(item.Type().Elem())
+
+		var extra map[string]interface{}
+		for name, value := range items[i] {
+			if _, ok := known[strings.ToLower(name)]; !ok {
+				if extra == nil {
+					extra = map[string]interface{}{}
+				}
+				extra[name] = value
+			}
+		}
+
+		holder.SetExtraAttributes(extra)
+	}
+}
+
+// addExtraAttributes adds the extra attributes of the given object, if it is an
+// ExtraAttributesHolder, to its given JSON encoding.
+func addExtraAttributes(object interface{}, data []byte) ([]byte, error) {
+
+	holder, ok := object.(ExtraAttributesHolder)
+	if !ok || len(holder.GetExtraAttributes()) == 0 {
+		return data, nil
+	}
+
+	attributes := map[string]interface{}{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+
+	for name, value := range holder.GetExtraAttributes() {
+		if _, ok := attributes[name]; !ok {
+			attributes[name] = value
+		}
+	}
+
+	return json.Marshal(attributes)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type extraObject struct {
+	FakeObject
+	ExtraAttributes
+
+	Description string `json:"description"`
+	Ignored     string `json:"-"`
+}
+
+func TestExtra_captureExtraAttributes(t *testing.T) {
+
+	Convey("Given I have a listing with unknown attributes", t, func() {
+
+		data := []byte(`[{"ID": "1", "Description": "d", "vlan": 12345678901234567, "Ignored": "x"}, {"ID": "2"}]`)
+
+		Convey("When I capture them in a slice", func() {
+
+			var list []*extraObject
+			json.Unmarshal(data, &list)
+			captureExtraAttributes(data, &list)
+
+			Convey("Then the objects should keep them", func() {
+				So(list[0].GetExtraAttributes(), ShouldResemble, map[string]interface{}{"vlan": json.Number("12345678901234567"), "Ignored": "x"})
+				So(list[1].GetExtraAttributes(), ShouldBeNil)
+			})
+		})
+
+		Convey("When I capture them in an IdentifiablesList", func() {
+
+			o := &extraObject{}
+			list := IdentifiablesList{o}
+			json.Unmarshal(data, &list)
+			captureExtraAttributes(data, list)
+
+			Convey("Then the object should keep them", func() {
+				So(o.GetExtraAttributes(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When I capture them in objects that cannot hold them", func() {
+
+			var list []*FakeObject
+			json.Unmarshal(data, &list)
+
+			Convey("Then nothing should happen", func() {
+				So(func() { captureExtraAttributes(data, &list) }, ShouldNotPanic)
+			})
+		})
+	})
+}
+
+func TestExtra_addExtraAttributes(t *testing.T) {
+
+	Convey("Given I have an object with extra attributes", t, func() {
+
+		o := &extraObject{FakeObject: FakeObject{ID: "1"}}
+		o.SetExtraAttributes(map[string]interface{}{"vlan": json.Number("12345678901234567"), "ID": "other"})
+
+		Convey("When I add them to its encoding", func() {
+
+			data, _ := json.Marshal(o)
+			data, err := addExtraAttributes(o, data)
+
+			Convey("Then they should be encoded, without overriding the known attributes", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"ID":"1","description":"","name":"","vlan":12345678901234567}`)
+			})
+		})
+	})
+}
+
+func TestExtra_Session(t *testing.T) {
+
+	Convey("Given I have a server with an object with attributes unknown to the model", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "x", "description": "d", "newAttribute": "new"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch it, then save it", func() {
+
+			o := &extraObject{FakeObject: FakeObject{ID: "x"}}
+			ferr := session.FetchEntity(o)
+			ts.add("", map[string]interface{}{"ID": "x"})
+			serr := session.SaveEntity(o)
+
+			Convey("Then the unknown attributes should be kept", func() {
+				So(ferr, ShouldBeNil)
+				So(serr, ShouldBeNil)
+				So(o.GetExtraAttributes(), ShouldResemble, map[string]interface{}{"newAttribute": "new"})
+				So(ts.get("x")["newAttribute"], ShouldEqual, "new")
+				So(ts.get("x")["description"], ShouldEqual, "d")
+			})
+		})
+	})
+}
@@ -0,0 +1,812 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package fakevsd provides an in memory fake VSD server, implementing enough of
+// the REST semantics of the VSD API for bambou to run integration tests without
+// a real VSD: authentication, API key rotation, CRUD, assignation, filtering,
+// ordering, pagination and events.
+//
+// The server does not know the models: the categories of the objects are mapped
+// to their names with the identities registered with bambou.RegisterIdentity,
+// or by removing their trailing "s".
+package fakevsd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// DefaultEventTimeout is the default duration the server waits for events
+// before answering a request of the events with no event.
+const DefaultEventTimeout = 5 * time.Second
+
+// DefaultRootName is the default name of the root object.
+const DefaultRootName = "me"
+
+var filterRegexp = regexp.MustCompile(`^\s*(\w+)\s*(==|!=|contains)\s*(?:"([^"]*)"|'([^']*)'|(\S+))\s*$`)
+
+// object is an object stored by the Server.
+type object struct {
+	category   string
+	parentID   string
+	seq        int
+	attributes map[string]interface{}
+}
+
+// event is an event recorded by the Server.
+type event struct {
+	uuid  string
+	event *bambou.Event
+}
+
+// Server is an in memory fake VSD server.
+type Server struct {
+	*httptest.Server
+
+	username     string
+	password     string
+	organization string
+	apiKey       string
+	rootName     string
+	rootID       string
+	eventTimeout time.Duration
+
+	objects     map[string]*object
+	assignments map[string][]string
+	events      []event
+	newEvents   chan struct{}
+	seq         int
+	lock        sync.Mutex
+}
+
+// New starts and returns a new *Server accepting the given credentials.
+// Call Close to stop it.
+func New(username, password, organization string) *Server {
+
+	s := &Server{
+		username:     username,
+		password:     password,
+		organization: organization,
+		apiKey:       newUUID(),
+		rootName:     DefaultRootName,
+		rootID:       newUUID(),
+		eventTimeout: DefaultEventTimeout,
+		objects:      map[string]*object{},
+		assignments:  map[string][]string{},
+		newEvents:    make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// SetRootName sets the name of the root object. The default is DefaultRootName.
+func (s *Server) SetRootName(name string) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rootName = name
+}
+
+// SetEventTimeout sets the duration the server waits for events before answering
+// a request of the events with no event. The default is DefaultEventTimeout.
+func (s *Server) SetEventTimeout(timeout time.Duration) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.eventTimeout = timeout
+}
+
+// APIKey returns the API key returned to the authenticated clients.
+func (s *Server) APIKey() string {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.apiKey
+}
+
+// Add adds an object with the given attributes and category under the object with
+// the given parent ID, or under the root if it is empty, and returns its ID.
+// The ID is generated if the attributes have none. No event is sent.
+func (s *Server) Add(parentID string, category string, attributes map[string]interface{}) string {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.add(parentID, category, attributes).attributes["ID"].(string)
+}
+
+// Load adds the objects of the given trees under their parent, the root object for the
+// top level ones, and sets the IDs of the objects of the trees to the IDs of the stored ones.
+// No event is sent. See bambou.LoadFixture.
+func (s *Server) Load(nodes ...*bambou.TreeNode) error {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.load("", nodes)
+}
+
+// LoadFile adds the objects of the trees of the fixture file at the given path. See Load.
+func (s *Server) LoadFile(path string) error {
+
+	nodes, err := bambou.LoadFixtureFile(path)
+	if err != nil {
+		return err
+	}
+
+	return s.Load(nodes...)
+}
+
+// Get returns a copy of the attributes of the object with the given ID, or nil.
+func (s *Server) Get(ID string) map[string]interface{} {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	o, ok := s.objects[ID]
+	if !ok {
+		return nil
+	}
+
+	return copyAttributes(o.attributes)
+}
+
+// Count returns the number of objects of the given category.
+func (s *Server) Count(category string) int {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	count := 0
+	for _, o := range s.objects {
+		if o.category == category {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if !s.authenticate(r) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "invalid credentials")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	if len(parts) == 1 && parts[0] == "events" && r.Method == http.MethodGet {
+		s.serveEvents(w, r)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch {
+
+	case len(parts) == 1 && parts[0] == s.rootName && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, []interface{}{s.root()})
+
+	case len(parts) == 1:
+		s.serveChildren(w, r, "", parts[0])
+
+	case len(parts) == 2 && parts[0] == s.rootName && parts[1] == "apikey":
+		s.serveAPIKey(w, r)
+
+	case len(parts) == 2:
+		s.serveObject(w, r, parts[0], parts[1])
+
+	case len(parts) == 3:
+		parent, ok := s.objects[parts[1]]
+		if !ok || parent.category != parts[0] {
+			writeError(w, http.StatusNotFound, "Object not found", fmt.Sprintf("%s %s does not exist", parts[0], parts[1]))
+			return
+		}
+		s.serveChildren(w, r, parts[1], parts[2])
+
+	default:
+		writeError(w, http.StatusNotFound, "Not found", r.URL.Path)
+	}
+}
+
+// authenticate returns true if the given request has valid credentials.
+func (s *Server) authenticate(r *http.Request) bool {
+
+	if r.Header.Get("X-Nuage-Organization") != s.organization {
+		return false
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "XREST ") {
+		return false
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization, "XREST "))
+	if err != nil {
+		return false
+	}
+
+	credentials := strings.SplitN(string(data), ":", 2)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(credentials) == 2 && credentials[0] == s.username && (credentials[1] == s.password || credentials[1] == s.apiKey)
+}
+
+func (s *Server) root() map[string]interface{} {
+
+	return map[string]interface{}{
+		"ID":             s.rootID,
+		"APIKey":         s.apiKey,
+		"userName":       s.username,
+		"enterpriseName": s.organization,
+	}
+}
+
+// serveAPIKey serves the requests of the API key of the root user: PUT replaces
+// it with a new one, and DELETE revokes it.
+func (s *Server) serveAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+
+	case http.MethodPut:
+		s.apiKey = newUUID()
+		writeJSON(w, http.StatusOK, []interface{}{s.root()})
+
+	case http.MethodDelete:
+		s.apiKey = newUUID()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", r.Method)
+	}
+}
+
+// serveObject serves the requests of the object with the given category and ID.
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, category string, ID string) {
+
+	o, ok := s.objects[ID]
+	if !ok || o.category != category {
+		writeError(w, http.StatusNotFound, "Object not found", fmt.Sprintf("%s %s does not exist", category, ID))
+		return
+	}
+
+	switch r.Method {
+
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, []interface{}{o.attributes})
+
+	case http.MethodPut:
+		attributes, ok := readAttributes(w, r)
+		if !ok {
+			return
+		}
+
+		if name, ok := attributes["name"]; ok && name != o.attributes["name"] && s.nameTaken(o.parentID, o.category, name) {
+			writeError(w, http.StatusConflict, "Duplicate name", fmt.Sprintf("another %s is named %v", s.name(category), name))
+			return
+		}
+
+		for key, value := range attributes {
+			if !isSystemAttribute(key) {
+				o.attributes[key] = value
+			}
+		}
+		o.attributes["lastUpdatedDate"] = now()
+
+		s.record(bambou.EventTypeUpdate, o)
+		writeJSON(w, http.StatusOK, []interface{}{o.attributes})
+
+	case http.MethodDelete:
+		s.remove(o)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", r.Method)
+	}
+}
+
+// serveChildren serves the requests of the children of the given category
+// of the object with the given parent ID, or of the root if it is empty.
+func (s *Server) serveChildren(w http.ResponseWriter, r *http.Request, parentID string, category string) {
+
+	switch r.Method {
+
+	case http.MethodGet:
+		s.serveList(w, r, parentID, category)
+
+	case http.MethodPost:
+		attributes, ok := readAttributes(w, r)
+		if !ok {
+			return
+		}
+
+		if name, ok := attributes["name"]; ok && s.nameTaken(parentID, category, name) {
+			writeError(w, http.StatusConflict, "Duplicate name", fmt.Sprintf("another %s is named %v", s.name(category), name))
+			return
+		}
+
+		for key := range attributes {
+			if isSystemAttribute(key) {
+				delete(attributes, key)
+			}
+		}
+
+		o := s.add(parentID, category, attributes)
+		s.record(bambou.EventTypeCreate, o)
+		writeJSON(w, http.StatusCreated, []interface{}{o.attributes})
+
+	case http.MethodPut:
+		var IDs []string
+		if err := json.NewDecoder(r.Body).Decode(&IDs); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid body", err.Error())
+			return
+		}
+
+		for _, ID := range IDs {
+			if o, ok := s.objects[ID]; !ok || o.category != category {
+				writeError(w, http.StatusNotFound, "Object not found", fmt.Sprintf("%s %s does not exist", category, ID))
+				return
+			}
+		}
+
+		s.assignments[parentID+"/"+category] = IDs
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", r.Method)
+	}
+}
+
+// serveList serves the list of the children of the given category of the object
+// with the given parent ID, filtered, ordered and paginated as requested.
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, parentID string, category string) {
+
+	match, err := parseFilter(r.Header.Get("X-Nuage-Filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	var list []*object
+	for _, o := range s.children(parentID, category) {
+		if match(o.attributes) {
+			list = append(list, o)
+		}
+	}
+
+	sortObjects(list, r.Header.Get("X-Nuage-OrderBy"))
+
+	page, _ := strconv.Atoi(r.Header.Get("X-Nuage-Page"))
+	pageSize, err := strconv.Atoi(r.Header.Get("X-Nuage-PageSize"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 50
+	}
+
+	w.Header().Set("X-Nuage-Count", strconv.Itoa(len(list)))
+	w.Header().Set("X-Nuage-Page", strconv.Itoa(page))
+	w.Header().Set("X-Nuage-PageSize", strconv.Itoa(pageSize))
+
+	start, end := page*pageSize, (page+1)*pageSize
+	if start >= len(list) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if end > len(list) {
+		end = len(list)
+	}
+
+	items := make([]interface{}, 0, end-start)
+	for _, o := range list[start:end] {
+		items = append(items, o.attributes)
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+// serveEvents serves the events following the one with the requested UUID,
+// waiting for them if there are none yet.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+
+	s.lock.Lock()
+	timeout := s.eventTimeout
+	last := r.URL.Query().Get("uuid")
+	if !s.knownEvent(last) {
+		// Without a known UUID, only the events sent from now on are returned.
+		last = s.lastEvent()
+	}
+	s.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+
+		s.lock.Lock()
+		notification := s.eventsAfter(last)
+		newEvents := s.newEvents
+		s.lock.Unlock()
+
+		if len(notification.Events) > 0 {
+			writeJSON(w, http.StatusOK, notification)
+			return
+		}
+
+		select {
+		case <-newEvents:
+		case <-timer.C:
+			notification.UUID = last
+			writeJSON(w, http.StatusOK, notification)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// knownEvent returns true if there is an event with the given UUID.
+func (s *Server) knownEvent(uuid string) bool {
+
+	for _, e := range s.events {
+		if e.uuid == uuid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastEvent returns the UUID of the last event, or "" if there is none.
+func (s *Server) lastEvent() string {
+
+	if len(s.events) == 0 {
+		return ""
+	}
+
+	return s.events[len(s.events)-1].uuid
+}
+
+// eventsAfter returns the notification of the events following the one with the given UUID,
+// or of all the events if it is empty.
+func (s *Server) eventsAfter(uuid string) *bambou.Notification {
+
+	notification := bambou.NewNotification()
+
+	start := 0
+	for i, e := range s.events {
+		if e.uuid == uuid {
+			start = i + 1
+		}
+	}
+
+	for _, e := range s.events[start:] {
+		notification.Events = append(notification.Events, e.event)
+		notification.UUID = e.uuid
+	}
+
+	return notification
+}
+
+// record records an event of the given type for the given object, and wakes up the requests waiting for events.
+func (s *Server) record(eventType string, o *object) {
+
+	s.events = append(s.events, event{
+		uuid: newUUID(),
+		event: &bambou.Event{
+			Type:            eventType,
+			EntityType:      s.name(o.category),
+			UpdateMechanism: "DEFAULT",
+			ReceivedTime:    now(),
+			DataMap:         []map[string]interface{}{copyAttributes(o.attributes)},
+		},
+	})
+
+	close(s.newEvents)
+	s.newEvents = make(chan struct{})
+}
+
+// add stores a new object.
+func (s *Server) add(parentID string, category string, attributes map[string]interface{}) *object {
+
+	attributes = copyAttributes(attributes)
+
+	if ID, _ := attributes["ID"].(string); ID == "" {
+		attributes["ID"] = newUUID()
+	}
+
+	parentType := s.rootName
+	if parent, ok := s.objects[parentID]; ok {
+		parentType = s.name(parent.category)
+	}
+
+	if parentID == "" {
+		attributes["parentID"] = s.rootID
+	} else {
+		attributes["parentID"] = parentID
+	}
+	attributes["parentType"] = parentType
+	attributes["owner"] = s.rootID
+	attributes["creationDate"] = now()
+	attributes["lastUpdatedDate"] = now()
+
+	s.seq++
+	o := &object{
+		category:   category,
+		parentID:   parentID,
+		seq:        s.seq,
+		attributes: attributes,
+	}
+	s.objects[attributes["ID"].(string)] = o
+
+	return o
+}
+
+// load stores the objects of the given trees under the object with the given ID, recursively.
+func (s *Server) load(parentID string, nodes []*bambou.TreeNode) error {
+
+	for _, node := range nodes {
+
+		data, err := json.Marshal(node.Object)
+		if err != nil {
+			return err
+		}
+
+		attributes := map[string]interface{}{}
+		if err := json.Unmarshal(data, &attributes); err != nil {
+			return err
+		}
+
+		ID := s.add(parentID, node.Object.Identity().Category, attributes).attributes["ID"].(string)
+		node.Object.SetIdentifier(ID)
+
+		if err := s.load(ID, node.Children); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remove removes the given object and its descendants.
+func (s *Server) remove(o *object) {
+
+	ID := o.attributes["ID"].(string)
+
+	for _, child := range s.objects {
+		if child.parentID == ID {
+			s.remove(child)
+		}
+	}
+
+	delete(s.objects, ID)
+
+	for key, IDs := range s.assignments {
+		var kept []string
+		for _, assigned := range IDs {
+			if assigned != ID {
+				kept = append(kept, assigned)
+			}
+		}
+		s.assignments[key] = kept
+	}
+
+	s.record(bambou.EventTypeDelete, o)
+}
+
+// children returns the children and assigned objects of the given category of the object with the given ID.
+func (s *Server) children(parentID string, category string) []*object {
+
+	var children []*object
+	for _, o := range s.objects {
+		if o.parentID == parentID && o.category == category {
+			children = append(children, o)
+		}
+	}
+
+	for _, ID := range s.assignments[parentID+"/"+category] {
+		if o, ok := s.objects[ID]; ok && o.parentID != parentID {
+			children = append(children, o)
+		}
+	}
+
+	return children
+}
+
+// nameTaken returns true if a child of the given category of the given parent has the given name.
+func (s *Server) nameTaken(parentID string, category string, name interface{}) bool {
+
+	for _, o := range s.objects {
+		if o.parentID == parentID && o.category == category && o.attributes["name"] == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// name returns the name of the identity of the given category.
+func (s *Server) name(category string) string {
+
+	if identity, ok := bambou.IdentityFromCategory(category); ok {
+		return identity.Name
+	}
+
+	return strings.TrimSuffix(category, "s")
+}
+
+// parseFilter returns a function matching the attributes matching the given filter.
+// The filter is a list of comparisons with ==, != or contains, joined by and.
+func parseFilter(filter string) (func(map[string]interface{}) bool, error) {
+
+	type comparison struct {
+		attribute string
+		operator  string
+		value     string
+	}
+
+	var comparisons []comparison
+	if strings.TrimSpace(filter) != "" {
+		for _, expression := range regexp.MustCompile(`(?i)\s+and\s+`).Split(filter, -1) {
+
+			m := filterRegexp.FindStringSubmatch(expression)
+			if m == nil {
+				return nil, fmt.Errorf("unsupported expression %q", expression)
+			}
+
+			comparisons = append(comparisons, comparison{attribute: m[1], operator: m[2], value: m[3] + m[4] + m[5]})
+		}
+	}
+
+	return func(attributes map[string]interface{}) bool {
+
+		for _, c := range comparisons {
+
+			value := ""
+			if v, ok := attributes[c.attribute]; ok && v != nil {
+				value = fmt.Sprint(v)
+			}
+
+			switch c.operator {
+			case "==":
+				if value != c.value {
+					return false
+				}
+			case "!=":
+				if value == c.value {
+					return false
+				}
+			case "contains":
+				if !strings.Contains(value, c.value) {
+					return false
+				}
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// sortObjects sorts the given objects by the given order, like "name DESC",
+// or by creation order if it is empty.
+func sortObjects(list []*object, orderBy string) {
+
+	fields := strings.Fields(orderBy)
+	if len(fields) == 0 {
+		sort.Slice(list, func(i, j int) bool { return list[i].seq < list[j].seq })
+		return
+	}
+
+	descending := len(fields) > 1 && strings.EqualFold(fields[1], "DESC")
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := fmt.Sprint(list[i].attributes[fields[0]]), fmt.Sprint(list[j].attributes[fields[0]])
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+}
+
+func readAttributes(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+
+	attributes := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&attributes); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid body", err.Error())
+		return nil, false
+	}
+
+	return attributes, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format of the VSD.
+func writeError(w http.ResponseWriter, status int, title string, description string) {
+
+	writeJSON(w, status, &bambou.VsdErrorList{
+		VsdErrors: []bambou.VsdError{
+			{Descriptions: []bambou.Error{{Title: title, Description: description}}},
+		},
+		VsdErrorCode: status,
+	})
+}
+
+func isSystemAttribute(name string) bool {
+
+	for _, system := range bambou.SystemAttributes {
+		if name == system {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyAttributes(attributes map[string]interface{}) map[string]interface{} {
+
+	c := make(map[string]interface{}, len(attributes))
+	for key, value := range attributes {
+		c[key] = value
+	}
+
+	return c
+}
+
+func now() int64 {
+
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func newUUID() string {
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
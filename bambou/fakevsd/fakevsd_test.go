@@ -0,0 +1,383 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package fakevsd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	rootIdentity       = bambou.Identity{Name: "me", Category: "me"}
+	enterpriseIdentity = bambou.Identity{Name: "enterprise", Category: "enterprises"}
+	userIdentity       = bambou.Identity{Name: "user", Category: "users"}
+)
+
+type root struct {
+	ID  string `json:"ID,omitempty"`
+	Key string `json:"APIKey,omitempty"`
+}
+
+func (o *root) Identity() bambou.Identity { return rootIdentity }
+func (o *root) Identifier() string        { return o.ID }
+func (o *root) SetIdentifier(ID string)   { o.ID = ID }
+func (o *root) APIKey() string            { return o.Key }
+func (o *root) SetAPIKey(key string)      { o.Key = key }
+
+type enterprise struct {
+	ID          string `json:"ID,omitempty"`
+	ParentID    string `json:"parentID,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+func (o *enterprise) Identity() bambou.Identity { return enterpriseIdentity }
+func (o *enterprise) Identifier() string        { return o.ID }
+func (o *enterprise) SetIdentifier(ID string)   { o.ID = ID }
+
+type user struct {
+	ID   string `json:"ID,omitempty"`
+	Name string `json:"name"`
+}
+
+func (o *user) Identity() bambou.Identity { return userIdentity }
+func (o *user) Identifier() string        { return o.ID }
+func (o *user) SetIdentifier(ID string)   { o.ID = ID }
+
+func TestFakeVSD_Authentication(t *testing.T) {
+
+	Convey("Given I have a fake VSD", t, func() {
+
+		s := New("admin", "secret", "csp")
+		defer s.Close()
+
+		Convey("When I start a session with valid credentials", func() {
+
+			r := &root{}
+			err := bambou.NewSession("admin", "secret", "csp", s.URL, r).Start()
+
+			Convey("Then I should get an API key", func() {
+				So(err, ShouldBeNil)
+				So(r.Key, ShouldEqual, s.APIKey())
+				So(r.ID, ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When I regenerate and revoke the API key of a session", func() {
+
+			r := &root{}
+			session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+			So(session.Start(), ShouldBeNil)
+			first := r.Key
+
+			regenerated := session.RegenerateAPIKey()
+			second := r.Key
+			revoked := session.RevokeAPIKey()
+
+			Convey("Then the keys should be replaced on the server", func() {
+				So(regenerated, ShouldBeNil)
+				So(second, ShouldNotEqual, first)
+				So(revoked, ShouldBeNil)
+				So(s.APIKey(), ShouldNotEqual, second)
+			})
+
+			Convey("Then the revoked key should not be accepted", func() {
+				r.Key = second
+				err := session.FetchEntity(r)
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, 401)
+			})
+		})
+
+		Convey("When I start a session with invalid credentials", func() {
+
+			err := bambou.NewSession("admin", "wrong", "csp", s.URL, &root{}).Start()
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, 401)
+			})
+		})
+	})
+}
+
+func TestFakeVSD_CRUD(t *testing.T) {
+
+	Convey("Given I have a session on a fake VSD", t, func() {
+
+		s := New("admin", "secret", "csp")
+		defer s.Close()
+
+		r := &root{}
+		session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+		So(session.Start(), ShouldBeNil)
+
+		Convey("When I create an enterprise", func() {
+
+			e := &enterprise{Name: "acme"}
+			err := session.CreateChild(r, e)
+
+			Convey("Then it should be created", func() {
+				So(err, ShouldBeNil)
+				So(e.ID, ShouldNotBeEmpty)
+				So(e.ParentID, ShouldEqual, r.ID)
+				So(s.Count("enterprises"), ShouldEqual, 1)
+			})
+
+			Convey("When I create another one with the same name", func() {
+
+				err := session.CreateChild(r, &enterprise{Name: "acme"})
+
+				Convey("Then I should get a conflict", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Code, ShouldEqual, 409)
+				})
+			})
+
+			Convey("When I save, fetch and delete it", func() {
+
+				e.Description = "updated"
+				serr := session.SaveEntity(e)
+
+				fetched := &enterprise{ID: e.ID}
+				ferr := session.FetchEntity(fetched)
+
+				derr := session.DeleteEntity(e)
+				gerr := session.FetchEntity(&enterprise{ID: e.ID})
+
+				Convey("Then it should be updated, then deleted", func() {
+					So(serr, ShouldBeNil)
+					So(ferr, ShouldBeNil)
+					So(fetched.Description, ShouldEqual, "updated")
+					So(derr, ShouldBeNil)
+					So(gerr, ShouldNotBeNil)
+					So(gerr.Code, ShouldEqual, 404)
+				})
+			})
+
+			Convey("When I assign it users", func() {
+
+				ID := s.Add("", "users", map[string]interface{}{"name": "john"})
+				err := session.AssignChildren(e, []bambou.Identifiable{&user{ID: ID}}, userIdentity)
+
+				var users []*user
+				ferr := session.FetchChildren(e, userIdentity, &users, nil)
+
+				Convey("Then they should be its children", func() {
+					So(err, ShouldBeNil)
+					So(ferr, ShouldBeNil)
+					So(users, ShouldHaveLength, 1)
+					So(users[0].Name, ShouldEqual, "john")
+				})
+			})
+		})
+	})
+}
+
+func TestFakeVSD_List(t *testing.T) {
+
+	Convey("Given I have a fake VSD with enterprises", t, func() {
+
+		s := New("admin", "secret", "csp")
+		defer s.Close()
+
+		for _, name := range []string{"e", "d", "c", "b", "a"} {
+			s.Add("", "enterprises", map[string]interface{}{"name": name, "description": "n-" + name})
+		}
+
+		r := &root{}
+		session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+		So(session.Start(), ShouldBeNil)
+
+		Convey("When I fetch them with a filter", func() {
+
+			var enterprises []*enterprise
+			info := bambou.NewFetchingInfo()
+			info.Filter = `name == "c" and description contains "n-"`
+			err := session.FetchChildren(r, enterpriseIdentity, &enterprises, info)
+
+			Convey("Then I should get the matching ones", func() {
+				So(err, ShouldBeNil)
+				So(enterprises, ShouldHaveLength, 1)
+				So(enterprises[0].Name, ShouldEqual, "c")
+			})
+		})
+
+		Convey("When I fetch a page of them ordered by name", func() {
+
+			var enterprises []*enterprise
+			info := bambou.NewFetchingInfo()
+			info.Page = 1
+			info.PageSize = 2
+			info.OrderBy = "name ASC"
+			err := session.FetchChildren(r, enterpriseIdentity, &enterprises, info)
+
+			Convey("Then I should get the page", func() {
+				So(err, ShouldBeNil)
+				So(enterprises, ShouldHaveLength, 2)
+				So(enterprises[0].Name, ShouldEqual, "c")
+				So(enterprises[1].Name, ShouldEqual, "d")
+				So(info.TotalCount, ShouldEqual, 5)
+			})
+		})
+
+		Convey("When I fetch a page after the last one", func() {
+
+			var enterprises []*enterprise
+			info := bambou.NewFetchingInfo()
+			info.Page = 5
+			err := session.FetchChildren(r, enterpriseIdentity, &enterprises, info)
+
+			Convey("Then I should get nothing", func() {
+				So(err, ShouldBeNil)
+				So(enterprises, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I fetch them with an invalid filter", func() {
+
+			var enterprises []*enterprise
+			info := bambou.NewFetchingInfo()
+			info.Filter = "name ~ a"
+			err := session.FetchChildren(r, enterpriseIdentity, &enterprises, info)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, 400)
+			})
+		})
+	})
+}
+
+func TestFakeVSD_Load(t *testing.T) {
+
+	Convey("Given I have a fake VSD", t, func() {
+
+		s := New("admin", "secret", "csp")
+		defer s.Close()
+
+		Convey("When I load a fixture of an enterprise with users", func() {
+
+			bambou.RegisterIdentity(enterpriseIdentity, func() bambou.Identifiable { return &enterprise{} })
+			bambou.RegisterIdentity(userIdentity, func() bambou.Identifiable { return &user{} })
+			defer bambou.UnregisterIdentity(enterpriseIdentity)
+			defer bambou.UnregisterIdentity(userIdentity)
+
+			nodes, err := bambou.LoadFixture(strings.NewReader(`
+type: enterprise
+attributes:
+  name: acme
+children:
+- type: user
+  attributes:
+    name: alice
+- type: user
+  attributes:
+    name: bob
+`))
+			So(err, ShouldBeNil)
+
+			lerr := s.Load(nodes...)
+
+			r := &root{}
+			session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+			So(session.Start(), ShouldBeNil)
+
+			var users []*user
+			ferr := session.FetchChildren(nodes[0].Object, userIdentity, &users, nil)
+
+			Convey("Then the objects should be stored under their parents", func() {
+				So(lerr, ShouldBeNil)
+				So(nodes[0].Object.Identifier(), ShouldNotBeEmpty)
+				So(s.Count("enterprises"), ShouldEqual, 1)
+				So(ferr, ShouldBeNil)
+				So(users, ShouldHaveLength, 2)
+				So(users[0].ID, ShouldEqual, nodes[0].Children[0].Object.Identifier())
+				So(s.Get(users[1].ID)["parentType"], ShouldEqual, "enterprise")
+			})
+		})
+	})
+}
+
+func TestFakeVSD_Events(t *testing.T) {
+
+	Convey("Given I have a session on a fake VSD", t, func() {
+
+		s := New("admin", "secret", "csp")
+		defer s.Close()
+		s.SetEventTimeout(100 * time.Millisecond)
+
+		r := &root{}
+		session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+		So(session.Start(), ShouldBeNil)
+
+		Convey("When I wait for events while an enterprise is created and deleted", func() {
+
+			channel := make(bambou.NotificationsChannel, 10)
+			errs := make(chan *bambou.Error, 1)
+			go func() { errs <- session.NextEvent(channel, "") }()
+
+			time.Sleep(20 * time.Millisecond)
+			e := &enterprise{Name: "acme"}
+			session.CreateChild(r, e)
+
+			var notification *bambou.Notification
+			select {
+			case notification = <-channel:
+			case <-time.After(time.Second):
+			}
+
+			session.DeleteEntity(e)
+			nerr := session.NextEvent(channel, notification.UUID)
+
+			Convey("Then I should get the events", func() {
+				So(<-errs, ShouldBeNil)
+				So(notification.Events, ShouldHaveLength, 1)
+				So(notification.Events[0].Type, ShouldEqual, bambou.EventTypeCreate)
+				So(notification.Events[0].EntityType, ShouldEqual, "enterprise")
+				So(notification.Events[0].DataMap[0]["name"], ShouldEqual, "acme")
+
+				So(nerr, ShouldBeNil)
+				next := <-channel
+				So(next.Events, ShouldHaveLength, 1)
+				So(next.Events[0].Type, ShouldEqual, bambou.EventTypeDelete)
+			})
+		})
+
+		Convey("When I wait for events and there is none", func() {
+
+			channel := make(bambou.NotificationsChannel, 1)
+			err := session.NextEvent(channel, "")
+
+			Convey("Then I should get nothing after the timeout", func() {
+				So(err, ShouldBeNil)
+				So(channel, ShouldBeEmpty)
+			})
+		})
+	})
+}
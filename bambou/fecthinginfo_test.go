@@ -24,6 +24,8 @@
 package bambou
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -61,3 +63,65 @@ func TestFetchingInfo_String(t *testing.T) {
 		})
 	})
 }
+
+func TestFetchingInfo_Params(t *testing.T) {
+
+	Convey("Given I have a server recording the query of the requests", t, func() {
+
+		var queries []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.RawQuery)
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch children with query parameters", func() {
+
+			info := NewFetchingInfo()
+			info.Params = map[string]string{"embedded": "true", "q": "a b&c=d"}
+
+			var dest []*FakeObject
+			err := session.FetchChildren(NewFakeObject("1"), FakeIdentity, &dest, info)
+
+			Convey("Then the parameters should be escaped in the query", func() {
+				So(err, ShouldBeNil)
+				So(queries, ShouldResemble, []string{"embedded=true&q=a+b%26c%3Dd"})
+			})
+		})
+
+		Convey("When I fetch raw children with a parameter of the URL", func() {
+
+			RegisterChildrenURL(FakeIdentity, FakeIdentity, "/fakes/{parentID}/fakes?archived=false&view=full")
+			defer RegisterChildrenURL(FakeIdentity, FakeIdentity, "")
+
+			info := NewFetchingInfo()
+			info.Params = map[string]string{"archived": "true"}
+
+			_, _, err := session.FetchChildrenRaw(NewFakeObject("1"), FakeIdentity, info)
+
+			Convey("Then the parameters should replace the ones of the URL", func() {
+				So(err, ShouldBeNil)
+				So(queries, ShouldResemble, []string{"archived=true&view=full"})
+			})
+		})
+
+		Convey("When I fetch cached children with different parameters", func() {
+
+			session.SetCache(NewCache(0, 0))
+
+			var dest []*FakeObject
+			for _, archived := range []string{"true", "false", "true"} {
+				info := NewFetchingInfo()
+				info.Params = map[string]string{"archived": archived}
+				So(session.FetchChildren(NewFakeObject("1"), FakeIdentity, &dest, info), ShouldBeNil)
+			}
+
+			Convey("Then each set of parameters should be cached separately", func() {
+				So(queries, ShouldResemble, []string{"archived=true", "archived=false"})
+			})
+		})
+	})
+}
@@ -23,7 +23,10 @@
 
 package bambou
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+)
 
 // FetchingInfo is a structure that contains differents values about
 // the fetching of children, either for the user to set, of for the server
@@ -38,6 +41,14 @@ type FetchingInfo struct {
 	Page       int
 	PageSize   int
 	TotalCount int
+
+	// Params are the query parameters of the request, for the endpoints taking
+	// some, like embedded=true or archived=true. They are escaped when sent.
+	Params map[string]string
+
+	// DecodeErrors are the errors decoding the objects of the listing, set by the
+	// sessions with a tolerant decoding. See Session.SetTolerantDecoding.
+	DecodeErrors []*DecodeError
 }
 
 // NewFetchingInfo returns a new *FetchingInfo
@@ -49,6 +60,17 @@ func NewFetchingInfo() *FetchingInfo {
 	}
 }
 
+// query returns the given query with the parameters of the FetchingInfo, which replace
+// the ones with the same name.
+func (f *FetchingInfo) query(query url.Values) url.Values {
+
+	for name, value := range f.Params {
+		query.Set(name, value)
+	}
+
+	return query
+}
+
 // String returns the string representation of the FetchingInfo.
 func (f *FetchingInfo) String() string {
 
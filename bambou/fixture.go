@@ -0,0 +1,104 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LoadFixture reads trees of objects from the given reader, to describe the objects of tests
+// declaratively. The fixture is written in YAML or JSON, as a list of TreeNode, as a single
+// TreeNode, or as a bundle written by ExportTree:
+//
+//	# a domain with two subnets
+//	- type: domain
+//	  attributes:
+//	    name: default
+//	  children:
+//	  - type: subnet
+//	    attributes:
+//	      name: front
+//	  - type: subnet
+//	    attributes:
+//	      name: back
+//
+// The types of the objects must be registered: see RegisterIdentity.
+func LoadFixture(r io.Reader) ([]*TreeNode, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var shape interface{}
+	if err := yaml.Unmarshal(data, &shape); err != nil {
+		return nil, err
+	}
+
+	switch v := shape.(type) {
+
+	case nil:
+		return nil, nil
+
+	case []interface{}:
+		var nodes []*TreeNode
+		if err := yaml.Unmarshal(data, &nodes); err != nil {
+			return nil, err
+		}
+		return nodes, nil
+
+	case map[interface{}]interface{}:
+		if _, ok := v["nodes"]; ok {
+			bundle := &struct {
+				Nodes []*TreeNode `yaml:"nodes"`
+			}{}
+			if err := yaml.Unmarshal(data, bundle); err != nil {
+				return nil, err
+			}
+			return bundle.Nodes, nil
+		}
+	}
+
+	node := &TreeNode{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+
+	return []*TreeNode{node}, nil
+}
+
+// LoadFixtureFile reads trees of objects from the fixture file at the given path. See LoadFixture.
+func LoadFixtureFile(path string) ([]*TreeNode, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadFixture(bytes.NewReader(data))
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFixture_LoadFixture(t *testing.T) {
+
+	Convey("Given I have a registered identity", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return NewFakeObject("") })
+		defer UnregisterIdentity(FakeIdentity)
+
+		Convey("When I load a YAML list of trees", func() {
+
+			nodes, err := LoadFixture(strings.NewReader(`
+- type: fake
+  attributes:
+    name: domain
+  children:
+  - type: fake
+    attributes:
+      name: subnet1
+  - type: fake
+    attributes:
+      name: subnet2
+- type: fake
+  attributes:
+    ID: "2"
+    name: other
+`))
+
+			Convey("Then I should get the trees", func() {
+				So(err, ShouldBeNil)
+				So(len(nodes), ShouldEqual, 2)
+				So(nodes[0].Object.(*FakeObject).Name, ShouldEqual, "domain")
+				So(len(nodes[0].Children), ShouldEqual, 2)
+				So(nodes[0].Children[1].Object.(*FakeObject).Name, ShouldEqual, "subnet2")
+				So(nodes[1].Object.Identifier(), ShouldEqual, "2")
+			})
+		})
+
+		Convey("When I load a single JSON tree", func() {
+
+			nodes, err := LoadFixture(strings.NewReader(`{"type": "fake", "attributes": {"name": "domain"}, "children": [{"type": "fake", "attributes": {"name": "subnet"}}]}`))
+
+			Convey("Then I should get the tree", func() {
+				So(err, ShouldBeNil)
+				So(len(nodes), ShouldEqual, 1)
+				So(nodes[0].Children[0].Object.(*FakeObject).Name, ShouldEqual, "subnet")
+			})
+		})
+
+		Convey("When I load a bundle written by ExportTree", func() {
+
+			nodes, err := LoadFixture(strings.NewReader(`{"version": 1, "nodes": [{"type": "fake", "attributes": {"ID": "1", "name": "a"}}]}`))
+
+			Convey("Then I should get its trees", func() {
+				So(err, ShouldBeNil)
+				So(len(nodes), ShouldEqual, 1)
+				So(nodes[0].Object.Identifier(), ShouldEqual, "1")
+			})
+		})
+
+		Convey("When I load an empty fixture", func() {
+
+			nodes, err := LoadFixture(strings.NewReader(""))
+
+			Convey("Then I should get no tree", func() {
+				So(err, ShouldBeNil)
+				So(nodes, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I load a fixture with an unregistered type", func() {
+
+			_, err := LoadFixture(strings.NewReader("- type: unknown\n"))
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I load a fixture file", func() {
+
+			dir, _ := ioutil.TempDir("", "fixture")
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "fixture.yaml")
+			ioutil.WriteFile(path, []byte("type: fake\nattributes:\n  name: file\n"), 0644)
+
+			nodes, err := LoadFixtureFile(path)
+			_, merr := LoadFixtureFile(filepath.Join(dir, "missing.yaml"))
+
+			Convey("Then I should get its trees", func() {
+				So(err, ShouldBeNil)
+				So(nodes[0].Object.(*FakeObject).Name, ShouldEqual, "file")
+				So(merr, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrphanPredicate returns true if the given object, child of the given parent, is an orphan.
+type OrphanPredicate func(object Identifiable, parent Identifiable) bool
+
+// OrphanConfirmation is called with the orphans found by a GarbageCollector before
+// deleting them. They are deleted only if it returns true.
+type OrphanConfirmation func(orphans []*Orphan) bool
+
+// Orphan is an orphaned object found by a GarbageCollector.
+type Orphan struct {
+	Object Identifiable
+	Parent Identifiable
+
+	// Reason is the error that occurred when deleting the object, if any.
+	Reason string
+}
+
+// GarbageCollectionReport is the report of a garbage collection.
+type GarbageCollectionReport struct {
+	DryRun bool
+
+	// Canceled is true if the confirmation refused the deletion of the orphans.
+	Canceled bool
+
+	// Scanned is the number of objects checked by the predicate.
+	Scanned int
+
+	// Orphans are the orphans found, in pre-order.
+	Orphans []*Orphan
+
+	// Deleted are the orphans that have been deleted.
+	Deleted []*Orphan
+
+	// Failed are the orphans the server failed to delete.
+	Failed []*Orphan
+}
+
+// String returns a summary of the report.
+func (r *GarbageCollectionReport) String() string {
+
+	return fmt.Sprintf("<GarbageCollectionReport scanned: %d, orphans: %d, deleted: %d, failed: %d>", r.Scanned, len(r.Orphans), len(r.Deleted), len(r.Failed))
+}
+
+// GarbageCollector deletes the orphaned objects of a subtree, like the objects
+// a controller created before crashing in the middle of a provisioning.
+//
+// The subtree is described by a TreeLayout, and every object of it is checked by
+// the OrphanPredicate. The descendants of an orphan are not checked, as the server
+// deletes them with it.
+type GarbageCollector struct {
+	storer       Storer
+	layout       TreeLayout
+	predicate    OrphanPredicate
+	confirmation OrphanConfirmation
+	dryRun       bool
+}
+
+// NewGarbageCollector returns a new *GarbageCollector deleting the objects matching
+// the given predicate in the subtrees described by the given layout. See TreeLayout.
+func NewGarbageCollector(storer Storer, layout TreeLayout, predicate OrphanPredicate) *GarbageCollector {
+
+	return &GarbageCollector{
+		storer:    storer,
+		layout:    layout,
+		predicate: predicate,
+	}
+}
+
+// SetDryRun sets if the garbage collection only reports the orphans, without deleting them.
+func (c *GarbageCollector) SetDryRun(dryRun bool) {
+
+	c.dryRun = dryRun
+}
+
+// SetConfirmation sets the function confirming the deletion of the orphans found.
+// It is not called in dry run, or if no orphan is found.
+func (c *GarbageCollector) SetConfirmation(confirmation OrphanConfirmation) {
+
+	c.confirmation = confirmation
+}
+
+// Collect finds the orphans of the subtree of the given parent and deletes them,
+// unless in dry run or not confirmed. It returns an error if the subtree cannot be
+// read, or if some orphans failed to be deleted.
+func (c *GarbageCollector) Collect(parent Identifiable) (*GarbageCollectionReport, *Error) {
+
+	report := &GarbageCollectionReport{DryRun: c.dryRun}
+
+	if err := c.scan(parent, report); err != nil {
+		return report, err
+	}
+
+	if c.dryRun || len(report.Orphans) == 0 {
+		return report, nil
+	}
+
+	if c.confirmation != nil && !c.confirmation(report.Orphans) {
+		report.Canceled = true
+		return report, nil
+	}
+
+	for _, orphan := range report.Orphans {
+
+		if err := c.storer.DeleteEntity(orphan.Object); err != nil {
+			orphan.Reason = err.Description
+			report.Failed = append(report.Failed, orphan)
+			continue
+		}
+
+		report.Deleted = append(report.Deleted, orphan)
+	}
+
+	if len(report.Failed) > 0 {
+		return report, NewBambouError("Garbage collection error", fmt.Sprintf("%d orphans failed to be deleted, first: %s", len(report.Failed), report.Failed[0].Reason))
+	}
+
+	return report, nil
+}
+
+// scan finds the orphans of the subtree of the given object, in pre-order.
+func (c *GarbageCollector) scan(object Identifiable, report *GarbageCollectionReport) *Error {
+
+	for _, identity := range c.layout[object.Identity().Name] {
+
+		children, err := fetchRegisteredChildren(c.storer, object, identity)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+
+			report.Scanned++
+
+			if c.predicate(child, object) {
+				report.Orphans = append(report.Orphans, &Orphan{Object: child, Parent: object})
+				continue
+			}
+
+			if err := c.scan(child, report); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExternalIDOrphans returns an OrphanPredicate matching the objects whose external ID
+// starts with the given prefix and is not known by the given function, typically
+// looking the ID up in the source of truth of a controller.
+func ExternalIDOrphans(prefix string, known func(externalID string) bool) OrphanPredicate {
+
+	return func(object Identifiable, parent Identifiable) bool {
+
+		attributes, err := attributesOf(object)
+		if err != nil {
+			return false
+		}
+
+		externalID, _ := attributes["externalID"].(string)
+		if externalID == "" || !strings.HasPrefix(externalID, prefix) {
+			return false
+		}
+
+		return !known(externalID)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type externalObject struct {
+	FakeObject
+
+	ExternalID string `json:"externalID,omitempty"`
+}
+
+func TestGarbageCollector_Collect(t *testing.T) {
+
+	Convey("Given I have a server with objects created by a controller", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &externalObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "p", "name": "parent"})
+		ts.add("p", map[string]interface{}{"ID": "a", "name": "a", "externalID": "ctl-1"})
+		ts.add("a", map[string]interface{}{"ID": "b", "name": "b", "externalID": "ctl-2"})
+		ts.add("p", map[string]interface{}{"ID": "c", "name": "c", "externalID": "ctl-3"})
+		ts.add("c", map[string]interface{}{"ID": "d", "name": "d", "externalID": "ctl-4"})
+		ts.add("p", map[string]interface{}{"ID": "e", "name": "e", "externalID": "other-1"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		known := map[string]bool{"ctl-1": true}
+		collector := NewGarbageCollector(session, TreeLayout{"fake": {FakeIdentity}}, ExternalIDOrphans("ctl-", func(ID string) bool { return known[ID] }))
+
+		Convey("When I collect the orphans in dry run", func() {
+
+			collector.SetDryRun(true)
+			report, err := collector.Collect(NewFakeObject("p"))
+
+			Convey("Then the orphans should be reported but not deleted", func() {
+				So(err, ShouldBeNil)
+				So(report.DryRun, ShouldBeTrue)
+				So(report.Scanned, ShouldEqual, 4)
+				So(report.Orphans, ShouldHaveLength, 2)
+				So(report.Orphans[0].Object.Identifier(), ShouldEqual, "b")
+				So(report.Orphans[0].Parent.Identifier(), ShouldEqual, "a")
+				So(report.Orphans[1].Object.Identifier(), ShouldEqual, "c")
+				So(report.Deleted, ShouldBeEmpty)
+				So(ts.count(), ShouldEqual, 6)
+			})
+		})
+
+		Convey("When I collect the orphans with a confirmation", func() {
+
+			var confirmed []*Orphan
+			collector.SetConfirmation(func(orphans []*Orphan) bool {
+				confirmed = orphans
+				return true
+			})
+			report, err := collector.Collect(NewFakeObject("p"))
+
+			Convey("Then the confirmed orphans should be deleted", func() {
+				So(err, ShouldBeNil)
+				So(confirmed, ShouldHaveLength, 2)
+				So(report.Deleted, ShouldHaveLength, 2)
+				So(ts.get("b"), ShouldBeNil)
+				So(ts.get("c"), ShouldBeNil)
+				So(ts.get("a"), ShouldNotBeNil)
+				So(ts.get("e"), ShouldNotBeNil)
+				So(report.String(), ShouldEqual, "<GarbageCollectionReport scanned: 4, orphans: 2, deleted: 2, failed: 0>")
+			})
+		})
+
+		Convey("When the confirmation refuses the deletion", func() {
+
+			collector.SetConfirmation(func([]*Orphan) bool { return false })
+			report, err := collector.Collect(NewFakeObject("p"))
+
+			Convey("Then nothing should be deleted", func() {
+				So(err, ShouldBeNil)
+				So(report.Canceled, ShouldBeTrue)
+				So(report.Deleted, ShouldBeEmpty)
+				So(ts.count(), ShouldEqual, 6)
+			})
+		})
+
+		Convey("When an orphan fails to be deleted", func() {
+
+			collector = NewGarbageCollector(session, TreeLayout{"fake": {FakeIdentity}}, func(object Identifiable, _ Identifiable) bool { return object.Identifier() == "b" })
+			session.SetReadOnly(true)
+			report, err := collector.Collect(NewFakeObject("p"))
+
+			Convey("Then the failure should be reported", func() {
+				So(err, ShouldNotBeNil)
+				So(report.Failed, ShouldHaveLength, 1)
+				So(report.Failed[0].Reason, ShouldNotBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package golden compares the requests sent by a bambou.Session to golden files,
+// to lock down the wire compatibility of the SDKs and of their consumers:
+//
+//	recorder := golden.NewRecorder()
+//	session.SetRequestHook(recorder.Capture)
+//	...
+//	if err := recorder.Compare("testdata/create_enterprise.golden", *update); err != nil {
+//		t.Error(err)
+//	}
+//
+// The requests are written in a stable text form: the URLs are stripped of their
+// scheme and host, the headers that vary between runs are left out, and the JSON
+// bodies are indented with sorted keys.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// IgnoredHeaders are the headers left out of the golden form of the requests,
+// because they vary between runs.
+var IgnoredHeaders = []string{"Authorization", bambou.TokenHeader, bambou.OTPHeader, "Content-Length", "User-Agent", "Accept-Encoding", bambou.RequestIDHeader}
+
+// Recorder records the requests sent by a bambou.Session.
+type Recorder struct {
+	requests []*bambou.CapturedRequest
+	lock     sync.Mutex
+}
+
+// NewRecorder returns a new *Recorder.
+func NewRecorder() *Recorder {
+
+	return &Recorder{}
+}
+
+// Capture records the given request. It is meant to be given to bambou.Session.SetRequestHook.
+func (r *Recorder) Capture(request *bambou.CapturedRequest) {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.requests = append(r.requests, request)
+}
+
+// Requests returns the recorded requests.
+func (r *Recorder) Requests() []*bambou.CapturedRequest {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]*bambou.CapturedRequest{}, r.requests...)
+}
+
+// Reset forgets the recorded requests.
+func (r *Recorder) Reset() {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.requests = nil
+}
+
+// Compare compares the recorded requests to the golden file at the given path. See Compare.
+func (r *Recorder) Compare(path string, update bool) error {
+
+	return Compare(path, Format(r.Requests()...), update)
+}
+
+// Format returns the golden form of the given requests.
+func Format(requests ...*bambou.CapturedRequest) []byte {
+
+	buffer := &bytes.Buffer{}
+
+	for i, request := range requests {
+
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+
+		fmt.Fprintf(buffer, "%s %s\n", request.Method, requestURI(request.URL))
+
+		var names []string
+		for name := range request.Headers {
+			if !isIgnoredHeader(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, value := range request.Headers[name] {
+				fmt.Fprintf(buffer, "%s: %s\n", name, value)
+			}
+		}
+
+		if body := formatBody(request.Body); len(body) > 0 {
+			buffer.WriteString("\n")
+			buffer.Write(body)
+			buffer.WriteString("\n")
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+// Compare compares the given data to the content of the golden file at the given path,
+// and returns an error showing the first different line if they differ. If update is
+// true, the golden file is written with the given data instead, and its directory created.
+func Compare(path string, data []byte, update bool) error {
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, data, 0644)
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(expected, data) {
+		return nil
+	}
+
+	expectedLines := strings.Split(string(expected), "\n")
+	actualLines := strings.Split(string(data), "\n")
+
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+
+		if e != a {
+			return fmt.Errorf("%s:%d: expected %q, got %q", path, i+1, e, a)
+		}
+	}
+
+	return fmt.Errorf("%s: content differs", path)
+}
+
+// requestURI returns the given URL without its scheme and host.
+func requestURI(rawURL string) string {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return u.RequestURI()
+}
+
+// formatBody returns the given body indented with sorted keys if it is JSON, or as is.
+func formatBody(body []byte) []byte {
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	indented, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return body
+	}
+
+	return indented
+}
+
+func isIgnoredHeader(name string) bool {
+
+	for _, ignored := range IgnoredHeaders {
+		if strings.EqualFold(ignored, name) {
+			return true
+		}
+	}
+
+	return false
+}
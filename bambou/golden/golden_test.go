@@ -0,0 +1,181 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var enterpriseIdentity = bambou.Identity{Name: "enterprise", Category: "enterprises"}
+
+type root struct {
+	ID  string `json:"ID,omitempty"`
+	Key string `json:"APIKey,omitempty"`
+}
+
+func (o *root) Identity() bambou.Identity { return bambou.Identity{Name: "me", Category: "me"} }
+func (o *root) Identifier() string        { return o.ID }
+func (o *root) SetIdentifier(ID string)   { o.ID = ID }
+func (o *root) APIKey() string            { return o.Key }
+func (o *root) SetAPIKey(key string)      { o.Key = key }
+
+type enterprise struct {
+	ID          string `json:"ID,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+func (o *enterprise) Identity() bambou.Identity { return enterpriseIdentity }
+func (o *enterprise) Identifier() string        { return o.ID }
+func (o *enterprise) SetIdentifier(ID string)   { o.ID = ID }
+
+func TestGolden_Format(t *testing.T) {
+
+	Convey("Given I have captured requests", t, func() {
+
+		requests := []*bambou.CapturedRequest{
+			{
+				Method:  "POST",
+				URL:     "https://vsd:8443/nuage/api/v5_0/enterprises?responseChoice=1",
+				Headers: map[string][]string{"Authorization": {"XREST abc"}, "X-Nuage-Organization": {"csp"}, "Content-Type": {"application/json"}},
+				Body:    []byte(`{"name":"acme","description":"d"}`),
+			},
+			{
+				Method: "DELETE",
+				URL:    "https://vsd:8443/nuage/api/v5_0/enterprises/1",
+				Body:   []byte("not json"),
+			},
+		}
+
+		Convey("When I format them", func() {
+
+			data := Format(requests...)
+
+			Convey("Then I should get their stable form", func() {
+				So(string(data), ShouldEqual, `POST /nuage/api/v5_0/enterprises?responseChoice=1
+Content-Type: application/json
+X-Nuage-Organization: csp
+
+{
+  "description": "d",
+  "name": "acme"
+}
+
+DELETE /nuage/api/v5_0/enterprises/1
+
+not json
+`)
+			})
+		})
+	})
+}
+
+func TestGolden_Compare(t *testing.T) {
+
+	Convey("Given I have a golden file path", t, func() {
+
+		dir, _ := ioutil.TempDir("", "golden")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "testdata", "requests.golden")
+
+		Convey("When I update it and compare the same data", func() {
+
+			uerr := Compare(path, []byte("a\nb\n"), true)
+			err := Compare(path, []byte("a\nb\n"), false)
+
+			Convey("Then they should match", func() {
+				So(uerr, ShouldBeNil)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("When I compare different data", func() {
+
+				err := Compare(path, []byte("a\nc\n"), false)
+
+				Convey("Then I should get the first different line", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEndWith, `requests.golden:2: expected "b", got "c"`)
+				})
+			})
+		})
+
+		Convey("When I compare to a missing golden file", func() {
+
+			err := Compare(path, []byte("a"), false)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestGolden_Recorder(t *testing.T) {
+
+	Convey("Given I have a session on a fake VSD recording its requests", t, func() {
+
+		s := fakevsd.New("admin", "secret", "csp")
+		defer s.Close()
+
+		r := &root{}
+		session := bambou.NewSession("admin", "secret", "csp", s.URL, r)
+		So(session.Start(), ShouldBeNil)
+
+		recorder := NewRecorder()
+		session.SetRequestHook(recorder.Capture)
+
+		dir, _ := ioutil.TempDir("", "golden")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "create.golden")
+
+		Convey("When I record the creation of an enterprise and compare another one", func() {
+
+			session.CreateChild(r, &enterprise{Name: "acme", Description: "first"})
+			uerr := recorder.Compare(path, true)
+
+			recorder.Reset()
+			session.CreateChild(r, &enterprise{Name: "acme2", Description: "first"})
+			err := recorder.Compare(path, false)
+
+			golden, _ := ioutil.ReadFile(path)
+
+			Convey("Then the golden file should lock down the request", func() {
+				So(uerr, ShouldBeNil)
+				So(strings.HasPrefix(string(golden), "POST /enterprises\n"), ShouldBeTrue)
+				So(string(golden), ShouldNotContainSubstring, "Authorization")
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "acme2")
+				So(len(recorder.Requests()), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,393 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package grpcfacade provides a gRPC server exposing the operations of a bambou
+// Session, so that the services written in any language can use a VSD through a
+// single gateway process. The service is defined in storerpb/storer.proto.
+//
+// The objects are exchanged with their ReST name and their attributes by JSON name,
+// so the server does not need the models of the API.
+package grpcfacade
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/grpcfacade/storerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultEventBuffer is the default number of events buffered for each stream of events.
+const DefaultEventBuffer = 100
+
+// Server implements the storerpb.StorerServer interface with a Session.
+type Server struct {
+	storerpb.UnimplementedStorerServer
+
+	session     *bambou.Session
+	pushCenter  *bambou.PushCenter
+	eventBuffer int
+}
+
+// NewServer returns a new *Server using the given started session. The events are
+// streamed from the given PushCenter, which must be started by the caller; they
+// are not available if it is nil.
+func NewServer(session *bambou.Session, pushCenter *bambou.PushCenter) *Server {
+
+	return &Server{
+		session:     session,
+		pushCenter:  pushCenter,
+		eventBuffer: DefaultEventBuffer,
+	}
+}
+
+// SetEventBuffer sets the number of events buffered for each stream of events.
+// The events are dropped for the clients too slow to receive them.
+// The default is DefaultEventBuffer.
+func (s *Server) SetEventBuffer(size int) {
+
+	s.eventBuffer = size
+}
+
+// Register registers the server on the given gRPC server.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+
+	storerpb.RegisterStorerServer(registrar, s)
+}
+
+// FetchEntity implements the storerpb.StorerServer interface. The root object is
+// returned without the API key of the session.
+func (s *Server) FetchEntity(ctx context.Context, request *storerpb.FetchEntityRequest) (*storerpb.Object, error) {
+
+	if request.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the type is missing")
+	}
+
+	root := s.session.Root()
+	if request.GetType() == root.Identity().Name {
+		return s.rootObject(root)
+	}
+
+	if request.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the ID is missing")
+	}
+
+	object := bambou.NewObject(identityOf(request.GetType()), request.GetId())
+	if berr := s.session.FetchEntity(object); berr != nil {
+		return nil, statusOf(berr)
+	}
+
+	return objectOf(object)
+}
+
+// FetchChildren implements the storerpb.StorerServer interface.
+func (s *Server) FetchChildren(ctx context.Context, request *storerpb.FetchChildrenRequest) (*storerpb.FetchChildrenResponse, error) {
+
+	if request.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the type is missing")
+	}
+
+	info := bambou.NewFetchingInfo()
+	info.Filter = request.GetFilter()
+	info.OrderBy = request.GetOrderBy()
+	if request.GetPageSize() > 0 {
+		info.Page = int(request.GetPage())
+		info.PageSize = int(request.GetPageSize())
+	}
+
+	objects, berr := bambou.FetchObjects(s.session, s.parentOf(request.GetParent()), identityOf(request.GetType()), info)
+	if berr != nil {
+		return nil, statusOf(berr)
+	}
+
+	response := &storerpb.FetchChildrenResponse{
+		Objects:    make([]*storerpb.Object, 0, len(objects)),
+		TotalCount: int32(info.TotalCount),
+	}
+
+	for _, object := range objects {
+		o, err := objectOf(object)
+		if err != nil {
+			return nil, err
+		}
+		response.Objects = append(response.Objects, o)
+	}
+
+	return response, nil
+}
+
+// Save implements the storerpb.StorerServer interface.
+func (s *Server) Save(ctx context.Context, request *storerpb.SaveRequest) (*storerpb.Object, error) {
+
+	object, err := fromObject(request.GetObject())
+	if err != nil {
+		return nil, err
+	}
+
+	if object.Identifier() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the ID is missing")
+	}
+
+	if berr := s.session.SaveEntity(object); berr != nil {
+		return nil, statusOf(berr)
+	}
+
+	return objectOf(object)
+}
+
+// CreateChild implements the storerpb.StorerServer interface.
+func (s *Server) CreateChild(ctx context.Context, request *storerpb.CreateChildRequest) (*storerpb.Object, error) {
+
+	object, err := fromObject(request.GetObject())
+	if err != nil {
+		return nil, err
+	}
+
+	if berr := s.session.CreateChild(s.parentOf(request.GetParent()), object); berr != nil {
+		return nil, statusOf(berr)
+	}
+
+	return objectOf(object)
+}
+
+// Delete implements the storerpb.StorerServer interface.
+func (s *Server) Delete(ctx context.Context, request *storerpb.DeleteRequest) (*storerpb.DeleteResponse, error) {
+
+	if request.GetType() == "" || request.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the type or the ID is missing")
+	}
+
+	if berr := s.session.DeleteEntity(bambou.NewObject(identityOf(request.GetType()), request.GetId())); berr != nil {
+		return nil, statusOf(berr)
+	}
+
+	return &storerpb.DeleteResponse{}, nil
+}
+
+// Events implements the storerpb.StorerServer interface. It streams the events
+// received by the PushCenter until the client cancels the stream.
+func (s *Server) Events(request *storerpb.EventsRequest, stream storerpb.Storer_EventsServer) error {
+
+	if s.pushCenter == nil {
+		return status.Error(codes.Unimplemented, "the events are disabled")
+	}
+
+	filter := bambou.SubscriptionFilter{EventTypes: request.GetEventTypes()}
+	for _, name := range request.GetTypes() {
+		filter.Identities = append(filter.Identities, identityOf(name))
+	}
+
+	subscription := s.pushCenter.Subscribe(filter, s.eventBuffer)
+	defer subscription.Unsubscribe()
+
+	// The headers are sent now, so that the clients know they are subscribed.
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+
+		case <-stream.Context().Done():
+			return nil
+
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return status.Error(codes.Unavailable, "the events are stopped")
+			}
+
+			e, err := eventOf(event)
+			if err != nil {
+				return err
+			}
+
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parentOf returns the object designated by the given reference, or the root object if it is nil.
+func (s *Server) parentOf(reference *storerpb.Reference) bambou.Identifiable {
+
+	if reference.GetType() == "" {
+		return s.session.Root()
+	}
+
+	return bambou.NewObject(identityOf(reference.GetType()), reference.GetId())
+}
+
+// rootObject returns the given root object without its bambou.RedactedAttributes,
+// like its API key.
+func (s *Server) rootObject(root bambou.Rootable) (*storerpb.Object, error) {
+
+	object := bambou.NewObject(root.Identity(), "")
+	if r, ok := root.(*bambou.RootObject); ok {
+		for name, value := range r.Attributes {
+			if !bambou.IsRedactedAttribute(name) {
+				object.Set(name, value)
+			}
+		}
+	}
+
+	return objectOf(object)
+}
+
+// objectOf returns the storerpb.Object of the given Object.
+func objectOf(object *bambou.Object) (*storerpb.Object, error) {
+
+	attributes, err := structOf(object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storerpb.Object{
+		Type:       object.Identity().Name,
+		Id:         object.Identifier(),
+		Attributes: attributes,
+	}, nil
+}
+
+// fromObject returns the Object of the given storerpb.Object.
+func fromObject(o *storerpb.Object) (*bambou.Object, error) {
+
+	if o.GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the type of the object is missing")
+	}
+
+	object := bambou.NewObject(identityOf(o.GetType()), "")
+
+	if o.GetAttributes() != nil {
+		data, err := o.GetAttributes().MarshalJSON()
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid attributes: %s", err)
+		}
+		if err := object.UnmarshalJSON(data); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid attributes: %s", err)
+		}
+	}
+
+	if o.GetId() != "" {
+		object.SetIdentifier(o.GetId())
+	}
+
+	return object, nil
+}
+
+// eventOf returns the storerpb.Event of the given Event.
+func eventOf(event *bambou.Event) (*storerpb.Event, error) {
+
+	e := &storerpb.Event{
+		Type:            event.Type,
+		EntityType:      event.EntityType,
+		UpdateMechanism: event.UpdateMechanism,
+		ReceivedTime:    event.ReceivedTime,
+	}
+
+	for _, data := range event.DataMap {
+		entity, err := structOf(data)
+		if err != nil {
+			return nil, err
+		}
+		e.Entities = append(e.Entities, entity)
+	}
+
+	return e, nil
+}
+
+// structOf returns the structpb.Struct of the JSON encoding of the given value.
+// The values are encoded as JSON first, because the decoded numbers are json.Number.
+func structOf(v interface{}) (*structpb.Struct, error) {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot encode the attributes: %s", err)
+	}
+
+	s := &structpb.Struct{}
+	if err := s.UnmarshalJSON(data); err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot encode the attributes: %s", err)
+	}
+
+	return s, nil
+}
+
+// statusOf returns the gRPC status error of the given *bambou.Error. The errors that
+// are not caused by a response of the server are reported as unavailable.
+func statusOf(berr *bambou.Error) error {
+
+	message := berr.Title
+	if berr.Description != "" {
+		message += ": " + berr.Description
+	}
+
+	if berr == bambou.ErrReadOnly {
+		return status.Error(codes.PermissionDenied, message)
+	}
+
+	code := codes.Unknown
+	switch {
+	case berr.Code == 0:
+		code = codes.Unavailable
+	case berr.Code == http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case berr.Code == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case berr.Code == http.StatusForbidden:
+		code = codes.PermissionDenied
+	case berr.Code == http.StatusNotFound:
+		code = codes.NotFound
+	case berr.Code == http.StatusConflict:
+		code = codes.AlreadyExists
+	case berr.Code == http.StatusPreconditionFailed:
+		code = codes.FailedPrecondition
+	case berr.Code == http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case berr.Code >= http.StatusInternalServerError:
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, message)
+}
+
+// identityOf returns the Identity with the given name. The names not registered
+// with bambou.RegisterIdentity are naively pluralized to get their category.
+func identityOf(name string) bambou.Identity {
+
+	if identity, ok := bambou.IdentityFromName(name); ok {
+		return identity
+	}
+
+	if strings.HasSuffix(name, "y") {
+		return bambou.Identity{Name: name, Category: strings.TrimSuffix(name, "y") + "ies"}
+	}
+
+	return bambou.Identity{Name: name, Category: name + "s"}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package grpcfacade
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/eventgen"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	"github.com/nuagenetworks/go-bambou/bambou/grpcfacade/storerpb"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// serve serves the given server on an in memory listener, and returns a client
+// of it and a function stopping everything.
+func serve(server *Server) (storerpb.StorerClient, func()) {
+
+	listener := bufconn.Listen(1 << 20)
+
+	s := grpc.NewServer()
+	server.Register(s)
+	go s.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	So(err, ShouldBeNil)
+
+	return storerpb.NewStorerClient(conn), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func TestServer_Storer(t *testing.T) {
+
+	Convey("Given I have a gRPC facade of a server", t, func() {
+
+		vsd := fakevsd.New("admin", "secret", "csp")
+		defer vsd.Close()
+
+		ID := vsd.Add("", "enterprises", map[string]interface{}{"name": "first", "count": 3})
+		vsd.Add("", "enterprises", map[string]interface{}{"name": "second"})
+
+		session := bambou.NewSession("admin", "secret", "csp", vsd.URL, bambou.NewRootObject(bambou.Identity{Name: "me", Category: "me"}))
+		So(session.Start(), ShouldBeNil)
+		session.Root().(*bambou.RootObject).Set("password", "secret")
+
+		client, stop := serve(NewServer(session, nil))
+		defer stop()
+
+		ctx := context.Background()
+
+		Convey("When I fetch the root object", func() {
+			root, err := client.FetchEntity(ctx, &storerpb.FetchEntityRequest{Type: "me"})
+
+			Convey("Then it should not have the API key nor the other redacted attributes", func() {
+				So(err, ShouldBeNil)
+				So(root.Attributes.AsMap()["userName"], ShouldEqual, "admin")
+				So(root.Attributes.AsMap(), ShouldNotContainKey, "APIKey")
+				So(root.Attributes.AsMap(), ShouldNotContainKey, "password")
+			})
+		})
+
+		Convey("When I fetch an object", func() {
+			object, err := client.FetchEntity(ctx, &storerpb.FetchEntityRequest{Type: "enterprise", Id: ID})
+
+			Convey("Then it should be fetched with its attributes", func() {
+				So(err, ShouldBeNil)
+				So(object.Type, ShouldEqual, "enterprise")
+				So(object.Id, ShouldEqual, ID)
+				So(object.Attributes.AsMap()["name"], ShouldEqual, "first")
+				So(object.Attributes.AsMap()["count"], ShouldEqual, 3)
+			})
+		})
+
+		Convey("When I fetch an object that does not exist", func() {
+			_, err := client.FetchEntity(ctx, &storerpb.FetchEntityRequest{Type: "enterprise", Id: "nope"})
+
+			Convey("Then the error should be not found", func() {
+				So(status.Code(err), ShouldEqual, codes.NotFound)
+			})
+		})
+
+		Convey("When I fetch an object without ID", func() {
+			_, err := client.FetchEntity(ctx, &storerpb.FetchEntityRequest{Type: "enterprise"})
+
+			Convey("Then the argument should be invalid", func() {
+				So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+			})
+		})
+
+		Convey("When I fetch children with a filter", func() {
+			response, err := client.FetchChildren(ctx, &storerpb.FetchChildrenRequest{Type: "enterprise", Filter: "name == 'second'"})
+
+			Convey("Then the filtered children should be returned", func() {
+				So(err, ShouldBeNil)
+				So(len(response.Objects), ShouldEqual, 1)
+				So(response.Objects[0].Attributes.AsMap()["name"], ShouldEqual, "second")
+				So(response.TotalCount, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I fetch a page of children", func() {
+			response, err := client.FetchChildren(ctx, &storerpb.FetchChildrenRequest{Type: "enterprise", Page: 1, PageSize: 1})
+
+			Convey("Then only the page should be returned", func() {
+				So(err, ShouldBeNil)
+				So(len(response.Objects), ShouldEqual, 1)
+				So(response.Objects[0].Attributes.AsMap()["name"], ShouldEqual, "second")
+				So(response.TotalCount, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I create, save and delete a child of an object", func() {
+			attributes, _ := structpb.NewStruct(map[string]interface{}{"name": "domain"})
+			created, err := client.CreateChild(ctx, &storerpb.CreateChildRequest{
+				Parent: &storerpb.Reference{Type: "enterprise", Id: ID},
+				Object: &storerpb.Object{Type: "domain", Attributes: attributes},
+			})
+			So(err, ShouldBeNil)
+			parentID := vsd.Get(created.Id)["parentID"]
+
+			created.Attributes.Fields["description"] = structpb.NewStringValue("the domain")
+			saved, err := client.Save(ctx, &storerpb.SaveRequest{Object: created})
+			So(err, ShouldBeNil)
+			description := vsd.Get(created.Id)["description"]
+
+			_, err = client.Delete(ctx, &storerpb.DeleteRequest{Type: "domain", Id: created.Id})
+			So(err, ShouldBeNil)
+
+			Convey("Then the changes should be made on the server", func() {
+				So(created.Id, ShouldNotBeEmpty)
+				So(parentID, ShouldEqual, ID)
+				So(saved.Attributes.AsMap()["description"], ShouldEqual, "the domain")
+				So(description, ShouldEqual, "the domain")
+				So(vsd.Get(created.Id), ShouldBeNil)
+			})
+		})
+
+		Convey("When I save an object of a read only session", func() {
+			session.SetReadOnly(true)
+			_, err := client.Save(ctx, &storerpb.SaveRequest{Object: &storerpb.Object{Type: "enterprise", Id: ID}})
+
+			Convey("Then the permission should be denied", func() {
+				So(status.Code(err), ShouldEqual, codes.PermissionDenied)
+			})
+		})
+
+		Convey("When I request the events", func() {
+			stream, err := client.Events(ctx, &storerpb.EventsRequest{})
+			So(err, ShouldBeNil)
+			_, err = stream.Recv()
+
+			Convey("Then they should be unimplemented", func() {
+				So(status.Code(err), ShouldEqual, codes.Unimplemented)
+			})
+		})
+	})
+}
+
+func TestServer_Events(t *testing.T) {
+
+	Convey("Given I have a gRPC facade of a push center", t, func() {
+
+		domain := bambou.NewObject(bambou.Identity{Name: "domain", Category: "domains"}, "1")
+		domain.Set("name", "domain")
+		enterprise := bambou.NewObject(bambou.Identity{Name: "enterprise", Category: "enterprises"}, "2")
+
+		g := eventgen.New()
+		g.Create(enterprise).Create(domain).Delete(domain)
+
+		p := bambou.NewPushCenterWithTransport(g)
+		defer p.Stop()
+
+		session := bambou.NewSession("admin", "secret", "csp", "https://vsd", bambou.NewRootObject(bambou.Identity{Name: "me", Category: "me"}))
+		client, stop := serve(NewServer(session, p))
+		defer stop()
+
+		Convey("When I request the creations of domains", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			stream, err := client.Events(ctx, &storerpb.EventsRequest{Types: []string{"domain"}, EventTypes: []string{bambou.EventTypeCreate}})
+			So(err, ShouldBeNil)
+			_, err = stream.Header()
+			So(err, ShouldBeNil)
+
+			So(p.Start(), ShouldBeNil)
+			event, err := stream.Recv()
+
+			Convey("Then only the creation of the domain should be streamed", func() {
+				So(err, ShouldBeNil)
+				So(event.Type, ShouldEqual, bambou.EventTypeCreate)
+				So(event.EntityType, ShouldEqual, "domain")
+				So(len(event.Entities), ShouldEqual, 1)
+				So(event.Entities[0].AsMap()["name"], ShouldEqual, "domain")
+			})
+		})
+	})
+}
@@ -0,0 +1,361 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: storer.proto
+
+// Package bambou.storer.v1 exposes the operations of a bambou Storer, so that the
+// services written in any language can use a VSD through a single gateway process.
+// See the grpcfacade package for the server.
+
+package storerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Storer_FetchEntity_FullMethodName   = "/bambou.storer.v1.Storer/FetchEntity"
+	Storer_FetchChildren_FullMethodName = "/bambou.storer.v1.Storer/FetchChildren"
+	Storer_Save_FullMethodName          = "/bambou.storer.v1.Storer/Save"
+	Storer_CreateChild_FullMethodName   = "/bambou.storer.v1.Storer/CreateChild"
+	Storer_Delete_FullMethodName        = "/bambou.storer.v1.Storer/Delete"
+	Storer_Events_FullMethodName        = "/bambou.storer.v1.Storer/Events"
+)
+
+// StorerClient is the client API for Storer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StorerClient interface {
+	// FetchEntity returns the object with the given type and ID.
+	FetchEntity(ctx context.Context, in *FetchEntityRequest, opts ...grpc.CallOption) (*Object, error)
+	// FetchChildren returns the children of the given type of the given parent.
+	FetchChildren(ctx context.Context, in *FetchChildrenRequest, opts ...grpc.CallOption) (*FetchChildrenResponse, error)
+	// Save saves the attributes of the given object, and returns the saved object.
+	Save(ctx context.Context, in *SaveRequest, opts ...grpc.CallOption) (*Object, error)
+	// CreateChild creates the given object under the given parent, and returns the created object.
+	CreateChild(ctx context.Context, in *CreateChildRequest, opts ...grpc.CallOption) (*Object, error)
+	// Delete deletes the object with the given type and ID.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Events streams the events of the objects of the given types, or of all the objects.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Storer_EventsClient, error)
+}
+
+type storerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStorerClient(cc grpc.ClientConnInterface) StorerClient {
+	return &storerClient{cc}
+}
+
+func (c *storerClient) FetchEntity(ctx context.Context, in *FetchEntityRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, Storer_FetchEntity_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storerClient) FetchChildren(ctx context.Context, in *FetchChildrenRequest, opts ...grpc.CallOption) (*FetchChildrenResponse, error) {
+	out := new(FetchChildrenResponse)
+	err := c.cc.Invoke(ctx, Storer_FetchChildren_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storerClient) Save(ctx context.Context, in *SaveRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, Storer_Save_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storerClient) CreateChild(ctx context.Context, in *CreateChildRequest, opts ...grpc.CallOption) (*Object, error) {
+	out := new(Object)
+	err := c.cc.Invoke(ctx, Storer_CreateChild_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storerClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, Storer_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storerClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Storer_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Storer_ServiceDesc.Streams[0], Storer_Events_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storerEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Storer_EventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type storerEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *storerEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StorerServer is the server API for Storer service.
+// All implementations must embed UnimplementedStorerServer
+// for forward compatibility
+type StorerServer interface {
+	// FetchEntity returns the object with the given type and ID.
+	FetchEntity(context.Context, *FetchEntityRequest) (*Object, error)
+	// FetchChildren returns the children of the given type of the given parent.
+	FetchChildren(context.Context, *FetchChildrenRequest) (*FetchChildrenResponse, error)
+	// Save saves the attributes of the given object, and returns the saved object.
+	Save(context.Context, *SaveRequest) (*Object, error)
+	// CreateChild creates the given object under the given parent, and returns the created object.
+	CreateChild(context.Context, *CreateChildRequest) (*Object, error)
+	// Delete deletes the object with the given type and ID.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Events streams the events of the objects of the given types, or of all the objects.
+	Events(*EventsRequest, Storer_EventsServer) error
+	mustEmbedUnimplementedStorerServer()
+}
+
+// UnimplementedStorerServer must be embedded to have forward compatible implementations.
+type UnimplementedStorerServer struct {
+}
+
+func (UnimplementedStorerServer) FetchEntity(context.Context, *FetchEntityRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchEntity not implemented")
+}
+func (UnimplementedStorerServer) FetchChildren(context.Context, *FetchChildrenRequest) (*FetchChildrenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchChildren not implemented")
+}
+func (UnimplementedStorerServer) Save(context.Context, *SaveRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Save not implemented")
+}
+func (UnimplementedStorerServer) CreateChild(context.Context, *CreateChildRequest) (*Object, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateChild not implemented")
+}
+func (UnimplementedStorerServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedStorerServer) Events(*EventsRequest, Storer_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedStorerServer) mustEmbedUnimplementedStorerServer() {}
+
+// UnsafeStorerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StorerServer will
+// result in compilation errors.
+type UnsafeStorerServer interface {
+	mustEmbedUnimplementedStorerServer()
+}
+
+func RegisterStorerServer(s grpc.ServiceRegistrar, srv StorerServer) {
+	s.RegisterService(&Storer_ServiceDesc, srv)
+}
+
+func _Storer_FetchEntity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchEntityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorerServer).FetchEntity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storer_FetchEntity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorerServer).FetchEntity(ctx, req.(*FetchEntityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storer_FetchChildren_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchChildrenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorerServer).FetchChildren(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storer_FetchChildren_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorerServer).FetchChildren(ctx, req.(*FetchChildrenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storer_Save_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorerServer).Save(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storer_Save_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorerServer).Save(ctx, req.(*SaveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storer_CreateChild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateChildRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorerServer).CreateChild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storer_CreateChild_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorerServer).CreateChild(ctx, req.(*CreateChildRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storer_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorerServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storer_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorerServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storer_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorerServer).Events(m, &storerEventsServer{stream})
+}
+
+type Storer_EventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type storerEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *storerEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Storer_ServiceDesc is the grpc.ServiceDesc for Storer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Storer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bambou.storer.v1.Storer",
+	HandlerType: (*StorerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FetchEntity",
+			Handler:    _Storer_FetchEntity_Handler,
+		},
+		{
+			MethodName: "FetchChildren",
+			Handler:    _Storer_FetchChildren_Handler,
+		},
+		{
+			MethodName: "Save",
+			Handler:    _Storer_Save_Handler,
+		},
+		{
+			MethodName: "CreateChild",
+			Handler:    _Storer_CreateChild_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Storer_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Storer_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "storer.proto",
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"time"
+)
+
+// handlerJob is a call of an EventHandler with an Event.
+type handlerJob struct {
+	policy  handlerPolicy
+	handler EventHandler
+	event   *Event
+}
+
+// handlerPool calls the EventHandlers on a fixed number of goroutines.
+type handlerPool struct {
+	jobs chan handlerJob
+	done <-chan struct{}
+}
+
+// newHandlerPool starts a new *handlerPool of the given number of workers
+// that stop when the given done channel is closed.
+func newHandlerPool(workers int, done <-chan struct{}) *handlerPool {
+
+	pool := &handlerPool{
+		jobs: make(chan handlerJob),
+		done: done,
+	}
+
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+
+	return pool
+}
+
+// submit waits for a worker to call the given handler with the given event using the given policy.
+func (h *handlerPool) submit(policy handlerPolicy, handler EventHandler, event *Event) {
+
+	select {
+	case h.jobs <- handlerJob{policy: policy, handler: handler, event: event}:
+	case <-h.done:
+	}
+}
+
+func (h *handlerPool) work() {
+
+	for {
+		select {
+		case job := <-h.jobs:
+			job.policy.run(job.handler, job.event)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// callHandler calls the given handler with the given event, recovering from panics
+// and returning the error of the handlers made with infallible.
+// If the given timeout is not 0, it stops waiting for the handler after the timeout,
+// timed by the given Clock. The handler keeps running in the background in that case.
+func callHandler(handler EventHandler, event *Event, timeout time.Duration, clock Clock) error {
+
+	if timeout <= 0 {
+		return safeCallHandler(handler, event)
+	}
+
+	timer := clock.NewTimer(timeout)
+	defer timer.Stop()
+
+	done := make(chan error, 1)
+	go func() { done <- safeCallHandler(handler, event) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C():
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// safeCallHandler calls the given handler with the given event, returning an error if it panics.
+func safeCallHandler(handler EventHandler, event *Event) (err error) {
+
+	defer func() {
+		r := recover()
+		if failure, ok := r.(*handlerFailure); ok {
+			err = failure.err
+		} else if r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	handler(event)
+
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandlerPool_callHandler(t *testing.T) {
+
+	Convey("Given I have an event", t, func() {
+
+		e := &Event{EntityType: "fake"}
+
+		Convey("When I call a handler that succeeds", func() {
+
+			var received *Event
+			err := callHandler(func(e *Event) { received = e }, e, 0, SystemClock)
+
+			Convey("Then the handler should be called", func() {
+				So(err, ShouldBeNil)
+				So(received, ShouldEqual, e)
+			})
+		})
+
+		Convey("When I call a handler that panics", func() {
+
+			err := callHandler(func(*Event) { panic("woops") }, e, 0, SystemClock)
+
+			Convey("Then the panic should be returned as an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "panic: woops")
+			})
+		})
+
+		Convey("When I call a handler that is too slow", func() {
+
+			unblock := make(chan bool)
+			defer close(unblock)
+			err := callHandler(func(*Event) { <-unblock }, e, 10*time.Millisecond, SystemClock)
+
+			Convey("Then a timeout error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "timed out after 10ms")
+			})
+		})
+
+		Convey("When I call a handler that panics with a timeout", func() {
+
+			err := callHandler(func(*Event) { panic("woops") }, e, time.Second, SystemClock)
+
+			Convey("Then the panic should be returned as an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "panic: woops")
+			})
+		})
+	})
+}
+
+func TestHandlerPool_submit(t *testing.T) {
+
+	Convey("Given I have a pool of 2 workers", t, func() {
+
+		done := make(chan struct{})
+		defer close(done)
+		pool := newHandlerPool(2, done)
+
+		Convey("When I submit a slow handler and a fast handler", func() {
+
+			unblock := make(chan bool)
+			calls := make(chan string, 2)
+
+			pool.submit(handlerPolicy{}, func(*Event) { <-unblock; calls <- "slow" }, &Event{})
+			pool.submit(handlerPolicy{}, func(*Event) { calls <- "fast" }, &Event{})
+
+			first := <-calls
+			close(unblock)
+
+			Convey("Then the fast handler should not wait for the slow one", func() {
+				So(first, ShouldEqual, "fast")
+				So(<-calls, ShouldEqual, "slow")
+			})
+		})
+	})
+}
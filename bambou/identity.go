@@ -23,10 +23,14 @@
 
 package bambou
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+)
 
 // IdentifiablesList is a list of objects implementing the Identifiable interface.
-type IdentifiablesList []Identifiable
+// It is the List of Identifiables, and has its helpers.
+type IdentifiablesList = List[Identifiable]
 
 // Identifiable is the interface that object which have Identity
 // must implement.
@@ -44,6 +48,11 @@ type Identifiable interface {
 
 // Rootable is the interface that must be implemented by the root object of the API.
 // A Rootable also implements the Identifiable interface.
+//
+// The implementations do not need to be safe for concurrent use: the Session
+// serializes its reads and writes of the API key, which happen concurrently with
+// the requests, like when it authenticates again. The code using the root object
+// of a Session while it is used must go through Session.APIKey and Session.SetAPIKey.
 type Rootable interface {
 	Identifiable
 
@@ -74,3 +83,15 @@ var AllIdentity = Identity{
 	Name:     "__all__",
 	Category: "__all__",
 }
+
+// newIdentifiableLike returns a new zero value instance of the concrete
+// type of the given Identifiable. The given Identifiable must be a pointer.
+func newIdentifiableLike(o Identifiable) Identifiable {
+
+	t := reflect.TypeOf(o)
+	if t.Kind() != reflect.Ptr {
+		return nil
+	}
+
+	return reflect.New(t.Elem()).Interface().(Identifiable)
+}
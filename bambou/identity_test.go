@@ -46,3 +46,23 @@ func TestIdentity_String(t *testing.T) {
 		})
 	})
 }
+
+func TestIdentity_newIdentifiableLike(t *testing.T) {
+
+	Convey("Given I have an object", t, func() {
+		o := NewFakeObject("xxx")
+
+		Convey("When I create a new object like it", func() {
+			n := newIdentifiableLike(o)
+
+			Convey("Then it should have the same type", func() {
+				So(n, ShouldHaveSameTypeAs, o)
+			})
+
+			Convey("Then it should be a different empty instance", func() {
+				So(n, ShouldNotEqual, o)
+				So(n.Identifier(), ShouldEqual, "")
+			})
+		})
+	})
+}
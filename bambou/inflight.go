@@ -0,0 +1,125 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// inFlightRequests tracks the requests of a session that are not done, to cancel
+// them when the session is reset or closed.
+type inFlightRequests struct {
+	cancels map[int]context.CancelFunc
+	next    int
+	lock    sync.Mutex
+}
+
+// track returns a copy of the given request that is canceled by cancelAll, and
+// the function to call when the request is done.
+func (r *inFlightRequests) track(request *http.Request) (*http.Request, func()) {
+
+	ctx, cancel := context.WithCancel(request.Context())
+
+	r.lock.Lock()
+	if r.cancels == nil {
+		r.cancels = map[int]context.CancelFunc{}
+	}
+	r.next++
+	ID := r.next
+	r.cancels[ID] = cancel
+	r.lock.Unlock()
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			r.lock.Lock()
+			delete(r.cancels, ID)
+			r.lock.Unlock()
+			cancel()
+		})
+	}
+
+	return request.WithContext(ctx), done
+}
+
+// cancelAll cancels all the tracked requests.
+func (r *inFlightRequests) cancelAll() {
+
+	r.lock.Lock()
+	cancels := r.cancels
+	r.cancels = nil
+	r.lock.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// trackedBody is the body of the response of a tracked request, that is done when it is closed.
+type trackedBody struct {
+	io.ReadCloser
+	done func()
+}
+
+// Close implements the io.Closer interface.
+func (b *trackedBody) Close() error {
+
+	err := b.ReadCloser.Close()
+	b.done()
+
+	return err
+}
+
+// sendTracked sends the given request, that has the given request ID, canceling it
+// if the session is reset or closed before its response is read.
+func (s *Session) sendTracked(request *http.Request, info *FetchingInfo, requestID string) (*http.Response, *Error) {
+
+	tracked, done := s.inFlightRequests.track(request)
+
+	response, berr := s.sendWithID(tracked, info, requestID)
+	if berr != nil {
+		canceled := tracked.Context().Err() != nil && request.Context().Err() == nil
+		done()
+		if canceled {
+			return nil, &Error{
+				Title:       "Request canceled",
+				Description: "the session has been reset or closed",
+				RequestID:   requestID,
+			}
+		}
+		return nil, berr
+	}
+
+	if response.Body == nil {
+		done()
+		return response, nil
+	}
+
+	response.Body = &trackedBody{ReadCloser: response.Body, done: done}
+
+	return response, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_InFlightRequests(t *testing.T) {
+
+	Convey("Given I have a server answering slowly", t, func() {
+
+		received := make(chan struct{}, 10)
+		release := make(chan struct{})
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/fakes/slow" {
+				received <- struct{}{}
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID": "fast", "name": "object"}]`))
+		}))
+		defer ts.Close()
+		defer close(release)
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		fetch := func() chan *Error {
+			errs := make(chan *Error, 1)
+			go func() { errs <- session.FetchEntity(NewFakeObject("slow")) }()
+			<-received
+			return errs
+		}
+
+		Convey("When I reset the session during a request", func() {
+
+			errs := fetch()
+			session.Reset()
+
+			Convey("Then the request should be canceled", func() {
+				var err *Error
+				select {
+				case err = <-errs:
+				case <-time.After(5 * time.Second):
+				}
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Request canceled")
+				So(err.RequestID, ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When I close the session during a request", func() {
+
+			errs := fetch()
+			session.Close()
+
+			Convey("Then the request should be canceled", func() {
+				var err *Error
+				select {
+				case err = <-errs:
+				case <-time.After(5 * time.Second):
+				}
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Request canceled")
+			})
+		})
+
+		Convey("When requests are done", func() {
+
+			So(session.FetchEntity(NewFakeObject("fast")), ShouldBeNil)
+			So(session.FetchEntity(NewFakeObject("fast")), ShouldBeNil)
+
+			Convey("Then they should not be tracked anymore", func() {
+				session.inFlightRequests.lock.Lock()
+				defer session.inFlightRequests.lock.Unlock()
+				So(session.inFlightRequests.cancels, ShouldBeEmpty)
+			})
+
+			Convey("Then the session should still work after a reset", func() {
+				session.Reset()
+				So(session.FetchEntity(NewFakeObject("fast")), ShouldBeNil)
+			})
+		})
+	})
+}
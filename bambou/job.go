@@ -0,0 +1,77 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Status of a job as reported by the server.
+const (
+	JobStatusRunning = "RUNNING"
+	JobStatusSuccess = "SUCCESS"
+	JobStatusFailed  = "FAILED"
+)
+
+// JobStatus is an OperationStatusFunc for server jobs.
+// The status and the result of the job are read from its "status" and "result"
+// attributes. If the job failed, the returned *Error contains the result payload as Details.
+func JobStatus(job Identifiable) (bool, interface{}, *Error) {
+
+	attributes, err := attributesOf(job)
+	if err != nil {
+		return false, nil, err
+	}
+
+	result := attributes["result"]
+
+	switch attributes["status"] {
+	case JobStatusSuccess:
+		return true, result, nil
+	case JobStatusFailed:
+		return true, nil, &Error{
+			Title:       "Job failed",
+			Description: fmt.Sprintf("%s %s failed: %v", job.Identity().Name, job.Identifier(), result),
+			Details:     result,
+		}
+	}
+
+	return false, nil, nil
+}
+
+// WaitForJob creates the given job under the given parent, then fetches it every
+// pollInterval until its status becomes SUCCESS or FAILED, the given timeout
+// expires or the given context is done. On success, the result payload is returned.
+// See JobStatus and StartOperation.
+func WaitForJob(ctx context.Context, storer Storer, parent Identifiable, job Identifiable, pollInterval time.Duration, timeout time.Duration) (interface{}, *Error) {
+
+	operation, err := startOperation(ctx, storer, parent, job, pollInterval, timeout, JobStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	return operation.Wait()
+}
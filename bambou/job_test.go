@@ -0,0 +1,172 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var fakeJobIdentity = Identity{"job", "jobs"}
+
+type fakeJob struct {
+	ID      string      `json:"ID,omitempty"`
+	Command string      `json:"command,omitempty"`
+	Status  string      `json:"status,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+func (o *fakeJob) Identity() Identity      { return fakeJobIdentity }
+func (o *fakeJob) Identifier() string      { return o.ID }
+func (o *fakeJob) SetIdentifier(ID string) { o.ID = ID }
+
+func newFakeJobServer(polls int, finalStatus string) *httptest.Server {
+
+	var lock sync.Mutex
+	count := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == "POST" {
+			fmt.Fprint(w, `[{"ID": "job1", "command": "EXPORT", "status": "RUNNING"}]`)
+			return
+		}
+
+		count++
+		if count < polls {
+			fmt.Fprint(w, `[{"ID": "job1", "command": "EXPORT", "status": "RUNNING"}]`)
+			return
+		}
+
+		fmt.Fprintf(w, `[{"ID": "job1", "command": "EXPORT", "status": "%s", "result": {"value": 42}}]`, finalStatus)
+	}))
+}
+
+func TestJob_WaitForJob(t *testing.T) {
+
+	Convey("Given I have a parent and a job", t, func() {
+
+		parent := NewFakeObject("parent")
+		job := &fakeJob{Command: "EXPORT"}
+
+		Convey("When I wait for a job that succeeds", func() {
+
+			ts := newFakeJobServer(2, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			result, err := WaitForJob(context.Background(), session, parent, job, 10*time.Millisecond, time.Second)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the result should be returned", func() {
+				So(result, ShouldResemble, map[string]interface{}{"value": float64(42)})
+			})
+
+			Convey("Then the job should be up to date", func() {
+				So(job.ID, ShouldEqual, "job1")
+				So(job.Status, ShouldEqual, JobStatusSuccess)
+			})
+		})
+
+		Convey("When I wait for a job with the cache of the session enabled", func() {
+
+			ts := newFakeJobServer(3, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+			session.SetCache(NewCache(time.Hour, 100))
+
+			result, err := WaitForJob(context.Background(), session, parent, job, 10*time.Millisecond, time.Second)
+
+			Convey("Then the job should be polled from the server", func() {
+				So(err, ShouldBeNil)
+				So(result, ShouldNotBeNil)
+				So(job.Status, ShouldEqual, JobStatusSuccess)
+			})
+		})
+
+		Convey("When I wait for a job that fails", func() {
+
+			ts := newFakeJobServer(1, JobStatusFailed)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			result, err := WaitForJob(context.Background(), session, parent, job, 10*time.Millisecond, time.Second)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Job failed")
+				So(err.Details, ShouldResemble, map[string]interface{}{"value": float64(42)})
+			})
+
+			Convey("Then result should be nil", func() {
+				So(result, ShouldBeNil)
+			})
+		})
+
+		Convey("When I wait for a job that never completes", func() {
+
+			ts := newFakeJobServer(1000, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			_, err := WaitForJob(context.Background(), session, parent, job, 10*time.Millisecond, 50*time.Millisecond)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Operation timeout")
+			})
+		})
+
+		Convey("When I wait for a job with a cancelled context", func() {
+
+			ts := newFakeJobServer(1000, JobStatusSuccess)
+			defer ts.Close()
+			session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := WaitForJob(ctx, session, parent, job, 10*time.Millisecond, 0)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JournalEntry is a notification recorded in an EventJournal.
+type JournalEntry struct {
+	Time         time.Time     `json:"time"`
+	Notification *Notification `json:"notification"`
+}
+
+// EventJournal records the received notifications in a file, one JSON
+// JournalEntry per line. When the file exceeds the maximum size, it is
+// rotated: the file is renamed with the suffix .1, the previous .1 file
+// becomes .2, and so on up to the maximum number of backups.
+type EventJournal struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	lock       sync.Mutex
+}
+
+// NewEventJournal opens the EventJournal writing to the file at the given path.
+// The file is rotated when it exceeds the given size in bytes, keeping the given
+// number of backups. A size of 0 disables the rotation.
+func NewEventJournal(path string, maxSize int64, maxBackups int) (*EventJournal, error) {
+
+	j := &EventJournal{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Record appends the given notification to the journal.
+func (j *EventJournal) Record(notification *Notification) error {
+
+	line, err := json.Marshal(&JournalEntry{Time: time.Now(), Notification: notification})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("the event journal is closed")
+	}
+
+	if j.maxSize > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+
+	return err
+}
+
+// Close closes the journal file.
+func (j *EventJournal) Close() error {
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Close()
+	j.file = nil
+
+	return err
+}
+
+func (j *EventJournal) open() error {
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	j.file = file
+	j.size = info.Size()
+
+	return nil
+}
+
+func (j *EventJournal) rotate() error {
+
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.file = nil
+
+	if j.maxBackups > 0 {
+
+		os.Remove(fmt.Sprintf("%s.%d", j.path, j.maxBackups))
+
+		for i := j.maxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", j.path, i), fmt.Sprintf("%s.%d", j.path, i+1))
+		}
+
+		if err := os.Rename(j.path, j.path+".1"); err != nil {
+			return err
+		}
+
+	} else if err := os.Remove(j.path); err != nil {
+		return err
+	}
+
+	return j.open()
+}
+
+// ReadEventJournal calls the given function with each entry of the given journal, in order.
+// It stops at the first error returned by the function.
+func ReadEventJournal(r io.Reader, fn func(*JournalEntry) error) error {
+
+	decoder := json.NewDecoder(r)
+
+	for {
+
+		entry := &JournalEntry{}
+		if err := decoder.Decode(entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
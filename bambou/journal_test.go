@@ -0,0 +1,145 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJournal_Record(t *testing.T) {
+
+	Convey("Given I have a journal", t, func() {
+
+		dir, _ := ioutil.TempDir("", "bambou")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "events.log")
+
+		j, err := NewEventJournal(path, 0, 0)
+		So(err, ShouldBeNil)
+		defer j.Close()
+
+		Convey("When I record notifications", func() {
+
+			n1 := newTestNotification("a")
+			n2 := newTestNotification("b")
+			err1 := j.Record(n1)
+			err2 := j.Record(n2)
+
+			Convey("Then err should be nil", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+			})
+
+			Convey("Then the journal should contain one line per notification", func() {
+				data, _ := ioutil.ReadFile(path)
+				So(strings.Count(string(data), "\n"), ShouldEqual, 2)
+			})
+
+			Convey("When I read the journal", func() {
+
+				var UUIDs []string
+				f, _ := os.Open(path)
+				defer f.Close()
+				err := ReadEventJournal(f, func(entry *JournalEntry) error {
+					UUIDs = append(UUIDs, entry.Notification.UUID)
+					So(entry.Time.IsZero(), ShouldBeFalse)
+					return nil
+				})
+
+				Convey("Then I should get the notifications in order", func() {
+					So(err, ShouldBeNil)
+					So(UUIDs, ShouldResemble, []string{"a", "b"})
+				})
+			})
+
+			Convey("When I read the journal with a function that fails", func() {
+
+				f, _ := os.Open(path)
+				defer f.Close()
+				calls := 0
+				err := ReadEventJournal(f, func(entry *JournalEntry) error { calls++; return errors.New("woops") })
+
+				Convey("Then it should stop at the first error", func() {
+					So(err, ShouldNotBeNil)
+					So(calls, ShouldEqual, 1)
+				})
+			})
+		})
+
+		Convey("When I record a notification after closing the journal", func() {
+
+			j.Close()
+			err := j.Record(newTestNotification("a"))
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given I have a journal with a small maximum size", t, func() {
+
+		dir, _ := ioutil.TempDir("", "bambou")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "events.log")
+
+		j, _ := NewEventJournal(path, 10, 2)
+		defer j.Close()
+
+		Convey("When I record more notifications than the backups can keep", func() {
+
+			for _, UUID := range []string{"a", "b", "c", "d"} {
+				So(j.Record(newTestNotification(UUID)), ShouldBeNil)
+			}
+
+			Convey("Then the journal should be rotated", func() {
+				current, _ := ioutil.ReadFile(path)
+				first, _ := ioutil.ReadFile(path + ".1")
+				second, _ := ioutil.ReadFile(path + ".2")
+				_, err := os.Stat(path + ".3")
+
+				So(string(current), ShouldContainSubstring, `"uuid":"d"`)
+				So(string(first), ShouldContainSubstring, `"uuid":"c"`)
+				So(string(second), ShouldContainSubstring, `"uuid":"b"`)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given I cannot create the journal file", t, func() {
+
+		_, err := NewEventJournal("/does/not/exist/events.log", 0, 0)
+
+		Convey("Then err should not be nil", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package kafkasink provides a bambou.EventSink publishing the events to Kafka.
+package kafkasink
+
+import (
+	"context"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/segmentio/kafka-go"
+)
+
+// DefaultTimeout is the default maximum duration of the publication of an event.
+const DefaultTimeout = 10 * time.Second
+
+// Writer is the interface of the Kafka writers. It is implemented by *kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+var _ Writer = (*kafka.Writer)(nil)
+
+// Publisher is a bambou.EventSink publishing every event as a message keyed
+// by the ID of its entity, so the events about an entity stay in order.
+// The entity type and event type are set as headers of the message.
+// The topic is the one configured on the Writer.
+type Publisher struct {
+	writer  Writer
+	timeout time.Duration
+}
+
+// New returns a new *Publisher using the given Writer.
+func New(writer Writer) *Publisher {
+
+	return &Publisher{
+		writer:  writer,
+		timeout: DefaultTimeout,
+	}
+}
+
+// SetTimeout sets the maximum duration of the publication of an event.
+func (p *Publisher) SetTimeout(timeout time.Duration) {
+
+	p.timeout = timeout
+}
+
+// Publish publishes the given event.
+func (p *Publisher) Publish(event *bambou.Event) error {
+
+	data, err := bambou.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(bambou.EventEntityID(event)),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "entityType", Value: []byte(event.EntityType)},
+			{Key: "type", Value: []byte(event.Type)},
+		},
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package kafkasink
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+
+	w.messages = append(w.messages, msgs...)
+
+	return w.err
+}
+
+func TestKafkaSink_Publish(t *testing.T) {
+
+	Convey("Given I have a publisher", t, func() {
+
+		writer := &fakeWriter{}
+		p := New(writer)
+		e := &bambou.Event{EntityType: "enterprise", Type: bambou.EventTypeUpdate, DataMap: []map[string]interface{}{{"ID": "x"}}}
+
+		Convey("When I publish an event", func() {
+
+			err := p.Publish(e)
+
+			Convey("Then it should be written as a message keyed by the entity ID", func() {
+				So(err, ShouldBeNil)
+				So(len(writer.messages), ShouldEqual, 1)
+				So(string(writer.messages[0].Key), ShouldEqual, "x")
+				So(string(writer.messages[0].Value), ShouldContainSubstring, `"entities":[{"ID":"x"}]`)
+				So(writer.messages[0].Headers, ShouldResemble, []kafka.Header{
+					{Key: "entityType", Value: []byte("enterprise")},
+					{Key: "type", Value: []byte("UPDATE")},
+				})
+			})
+		})
+
+		Convey("When I publish an event and the writer fails", func() {
+
+			writer.err = errors.New("woops")
+			err := p.Publish(e)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetLabels sets the labels of the session, like the name of the cluster, the tenant
+// or the tool, to tell the sessions apart: they prefix the logs of the session, are
+// added to the labels of its metrics, and are set to the errors of its requests. The
+// labels of the metrics take precedence over the labels of the session with the same
+// name. Passing nil removes the labels. It must be called before the session is used.
+func (s *Session) SetLabels(labels Labels) {
+
+	s.labels = nil
+	if len(labels) > 0 {
+		s.labels = copyLabels(labels)
+	}
+}
+
+// Labels returns a copy of the labels of the session.
+func (s *Session) Labels() Labels {
+
+	if s.labels == nil {
+		return nil
+	}
+
+	return copyLabels(s.labels)
+}
+
+// copyLabels returns a copy of the given labels.
+func copyLabels(labels Labels) Labels {
+
+	copied := make(Labels, len(labels))
+	for name, value := range labels {
+		copied[name] = value
+	}
+
+	return copied
+}
+
+// String returns the labels as name=value pairs sorted by name, separated by spaces.
+func (l Labels) String() string {
+
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + l[name]
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// labeledLogger is a LeveledLogger prefixing the logs with the labels of a session.
+type labeledLogger struct {
+	next   LeveledLogger
+	prefix string
+}
+
+// Debugf implements the LeveledLogger interface.
+func (l *labeledLogger) Debugf(format string, args ...interface{}) {
+
+	l.next.Debugf(l.prefix+format, args...)
+}
+
+// Infof implements the LeveledLogger interface.
+func (l *labeledLogger) Infof(format string, args ...interface{}) {
+
+	l.next.Infof(l.prefix+format, args...)
+}
+
+// Warnf implements the LeveledLogger interface.
+func (l *labeledLogger) Warnf(format string, args ...interface{}) {
+
+	l.next.Warnf(l.prefix+format, args...)
+}
+
+// Errorf implements the LeveledLogger interface.
+func (l *labeledLogger) Errorf(format string, args ...interface{}) {
+
+	l.next.Errorf(l.prefix+format, args...)
+}
+
+// labeledMetrics is a Metrics adding the labels of a session to the labels of the metrics.
+type labeledMetrics struct {
+	next   Metrics
+	labels Labels
+}
+
+// AddCounter implements the Metrics interface.
+func (m *labeledMetrics) AddCounter(name string, labels Labels, value float64) {
+
+	m.next.AddCounter(name, m.with(labels), value)
+}
+
+// SetGauge implements the Metrics interface.
+func (m *labeledMetrics) SetGauge(name string, labels Labels, value float64) {
+
+	m.next.SetGauge(name, m.with(labels), value)
+}
+
+// ObserveDuration implements the Metrics interface.
+func (m *labeledMetrics) ObserveDuration(name string, labels Labels, duration time.Duration) {
+
+	m.next.ObserveDuration(name, m.with(labels), duration)
+}
+
+// with returns the given labels with the labels of the session.
+func (m *labeledMetrics) with(labels Labels) Labels {
+
+	merged := copyLabels(m.labels)
+	for name, value := range labels {
+		merged[name] = value
+	}
+
+	return merged
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_Labels(t *testing.T) {
+
+	Convey("Given I have a labeled session", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/missing") {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		logger := &recordingLogger{}
+		metrics := NewMemoryMetrics()
+
+		labels := Labels{"cluster": "paris", "tool": "exporter"}
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetLabels(labels)
+		session.SetLogger(logger)
+		session.SetMetrics(metrics)
+
+		labels["cluster"] = "changed"
+
+		u, _ := url.Parse(ts.URL + "/fakes/1")
+		identity := session.metricIdentity(u)
+
+		Convey("When I send requests", func() {
+
+			fetchErr := session.FetchEntity(NewFakeObject("1"))
+			missingErr := session.FetchEntity(NewFakeObject("missing"))
+
+			Convey("Then the labels should be copied", func() {
+				So(session.Labels(), ShouldResemble, Labels{"cluster": "paris", "tool": "exporter"})
+			})
+
+			Convey("Then the logs should be prefixed with the labels", func() {
+				So(fetchErr, ShouldBeNil)
+				So(logger.count(), ShouldBeGreaterThan, 0)
+				for _, line := range logger.logs {
+					So(line, ShouldContainSubstring, "[cluster=paris tool=exporter] ")
+				}
+			})
+
+			Convey("Then the metrics should have the labels", func() {
+				So(metrics.Counter(MetricRequests, Labels{"cluster": "paris", "tool": "exporter", "method": "GET", "identity": identity, "status": "200"}), ShouldEqual, 1)
+				So(metrics.Counter(MetricRequests, Labels{"method": "GET", "identity": identity, "status": "200"}), ShouldEqual, 0)
+			})
+
+			Convey("Then the errors should have the labels", func() {
+				So(missingErr, ShouldNotBeNil)
+				So(missingErr.Labels, ShouldResemble, Labels{"cluster": "paris", "tool": "exporter"})
+				So(missingErr.Error(), ShouldContainSubstring, "paris")
+			})
+		})
+
+		Convey("When I remove the labels", func() {
+
+			session.SetLabels(nil)
+			err := session.FetchEntity(NewFakeObject("missing"))
+
+			Convey("Then nothing should be labeled", func() {
+				So(session.Labels(), ShouldBeNil)
+				So(err.Labels, ShouldBeNil)
+				So(metrics.Counter(MetricRequests, Labels{"method": "GET", "identity": identity, "status": "500"}), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given I have labels", t, func() {
+
+		labels := Labels{"b": "2", "a": "1"}
+
+		Convey("Then their string should be sorted by name", func() {
+			So(labels.String(), ShouldEqual, "a=1 b=2")
+		})
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import "sync"
+
+// LeveledLogger is the interface of the loggers bambou writes its logs to.
+// *logrus.Logger, logrus.FieldLogger and *zap.SugaredLogger implement it as is.
+// See the logrusadapter, zapadapter and slogadapter packages for adapters.
+type LeveledLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NopLogger is a LeveledLogger discarding all the logs. It is the default logger.
+type NopLogger struct{}
+
+// Debugf implements the LeveledLogger interface.
+func (NopLogger) Debugf(format string, args ...interface{}) {}
+
+// Infof implements the LeveledLogger interface.
+func (NopLogger) Infof(format string, args ...interface{}) {}
+
+// Warnf implements the LeveledLogger interface.
+func (NopLogger) Warnf(format string, args ...interface{}) {}
+
+// Errorf implements the LeveledLogger interface.
+func (NopLogger) Errorf(format string, args ...interface{}) {}
+
+var (
+	defaultLeveledLogger     LeveledLogger = NopLogger{}
+	defaultLeveledLoggerLock sync.RWMutex
+)
+
+// SetDefaultLogger sets the LeveledLogger used by the sessions that have no logger of
+// their own, and by the components that are not bound to a session. Use nil to restore
+// the NopLogger.
+func SetDefaultLogger(logger LeveledLogger) {
+
+	if logger == nil {
+		logger = NopLogger{}
+	}
+
+	defaultLeveledLoggerLock.Lock()
+	defer defaultLeveledLoggerLock.Unlock()
+
+	defaultLeveledLogger = logger
+}
+
+// DefaultLogger returns the LeveledLogger set with SetDefaultLogger.
+func DefaultLogger() LeveledLogger {
+
+	defaultLeveledLoggerLock.RLock()
+	defer defaultLeveledLoggerLock.RUnlock()
+
+	return defaultLeveledLogger
+}
+
+// SetLogger sets the LeveledLogger of the session. Use nil to use the default logger.
+func (s *Session) SetLogger(logger LeveledLogger) {
+
+	s.leveledLogger = logger
+}
+
+// getLogger returns the LeveledLogger of the session, or the default one, prefixing
+// the logs with the labels of the session.
+func (s *Session) getLogger() LeveledLogger {
+
+	logger := s.leveledLogger
+	if logger == nil {
+		logger = DefaultLogger()
+	}
+
+	if len(s.labels) > 0 {
+		return &labeledLogger{next: logger, prefix: "[" + s.labels.String() + "] "}
+	}
+
+	return logger
+}
+
+// loggerOf returns the LeveledLogger of the given Storer if it is a *Session, or the default one.
+func loggerOf(storer interface{}) LeveledLogger {
+
+	if session, ok := storer.(*Session); ok && session != nil {
+		return session.getLogger()
+	}
+
+	return DefaultLogger()
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordingLogger is a LeveledLogger recording the logs.
+type recordingLogger struct {
+	logs []string
+	lock sync.Mutex
+}
+
+func (l *recordingLogger) log(level string, format string, args ...interface{}) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.logs = append(l.logs, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) { l.log("debug", format, args...) }
+func (l *recordingLogger) Infof(format string, args ...interface{})  { l.log("info", format, args...) }
+func (l *recordingLogger) Warnf(format string, args ...interface{})  { l.log("warn", format, args...) }
+func (l *recordingLogger) Errorf(format string, args ...interface{}) { l.log("error", format, args...) }
+
+func (l *recordingLogger) count() int {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return len(l.logs)
+}
+
+func TestLeveledLogger_Session(t *testing.T) {
+
+	Convey("Given I have a session", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"ID": "xxx", "name": "remote"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch an entity without logger", func() {
+
+			defaultLogger := &recordingLogger{}
+			SetDefaultLogger(defaultLogger)
+			defer SetDefaultLogger(nil)
+
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then the default logger should get the logs", func() {
+				So(defaultLogger.count(), ShouldBeGreaterThan, 0)
+				So(defaultLogger.logs[0], ShouldStartWith, "debug request headers=")
+			})
+		})
+
+		Convey("When I fetch an entity with a logger", func() {
+
+			defaultLogger := &recordingLogger{}
+			SetDefaultLogger(defaultLogger)
+			defer SetDefaultLogger(nil)
+
+			logger := &recordingLogger{}
+			session.SetLogger(logger)
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then only the logger of the session should get the logs", func() {
+				So(logger.count(), ShouldBeGreaterThan, 0)
+				So(defaultLogger.count(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I reset the default logger", func() {
+
+			SetDefaultLogger(nil)
+
+			Convey("Then it should be the NopLogger", func() {
+				So(DefaultLogger(), ShouldResemble, NopLogger{})
+				So(session.getLogger(), ShouldResemble, NopLogger{})
+			})
+		})
+	})
+}
+
+func TestLeveledLogger_PushCenter(t *testing.T) {
+
+	Convey("Given I have a push center on a session with a logger", t, func() {
+
+		session := NewSession("username", "password", "organization", "https://vsd", NewFakeRootObject())
+		sessionLogger := &recordingLogger{}
+		session.SetLogger(sessionLogger)
+		p := NewPushCenter(session)
+
+		Convey("When it logs", func() {
+
+			p.currentLogger().Infof("message")
+
+			Convey("Then the logger of the session should get the logs", func() {
+				So(sessionLogger.logs, ShouldResemble, []string{"info message"})
+			})
+		})
+
+		Convey("When I set its logger and it logs", func() {
+
+			logger := &recordingLogger{}
+			p.SetLogger(logger)
+			p.currentLogger().Warnf("message")
+
+			Convey("Then its logger should get the logs", func() {
+				So(logger.logs, ShouldResemble, []string{"warn message"})
+				So(sessionLogger.logs, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// List is a list of objects of the same type, like the destination given to
+// FetchChildren. Its helpers keep the type of the objects, so they need no type
+// assertion. IdentifiablesList is the List of objects of any type.
+type List[T Identifiable] []T
+
+// NewIdentifiablesList returns a new IdentifiablesList containing the objects
+// of the given slice, or pointer to a slice, like the destination given to
+// FetchChildren. It returns nil if the elements of the slice are not Identifiables.
+func NewIdentifiablesList(slice interface{}) IdentifiablesList {
+
+	v := reflect.Indirect(reflect.ValueOf(slice))
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	list := make(IdentifiablesList, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+
+		object, ok := v.Index(i).Interface().(Identifiable)
+		if !ok {
+			return nil
+		}
+
+		list = append(list, object)
+	}
+
+	return list
+}
+
+// Into sets the given pointer to a slice, like the destination given to
+// FetchChildren, to the objects of the list.
+func (l List[T]) Into(dest interface{}) *Error {
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return NewBambouError("Invalid destination", fmt.Sprintf("%T is not a pointer to a slice", dest))
+	}
+
+	elemType := v.Elem().Type().Elem()
+	slice := reflect.MakeSlice(v.Elem().Type(), len(l), len(l))
+
+	for i, object := range l {
+
+		value := reflect.ValueOf(object)
+		if !value.Type().AssignableTo(elemType) {
+			return NewBambouError("Invalid destination", fmt.Sprintf("%T cannot contain %T", dest, object))
+		}
+
+		slice.Index(i).Set(value)
+	}
+
+	v.Elem().Set(slice)
+
+	return nil
+}
+
+// FetchList fetches the children of the given identity of the given parent into a new List.
+func FetchList[T Identifiable](storer Storer, parent Identifiable, identity Identity, info *FetchingInfo) (List[T], *Error) {
+
+	var list List[T]
+	if err := storer.FetchChildren(parent, identity, &list, info); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ListOf returns a new List with the objects of the given IdentifiablesList,
+// that must all be of the type of the elements of the List.
+func ListOf[T Identifiable](l IdentifiablesList) (List[T], *Error) {
+
+	list := make(List[T], 0, len(l))
+	for _, object := range l {
+
+		typed, ok := object.(T)
+		if !ok {
+			return nil, NewBambouError("Invalid list", fmt.Sprintf("%T is not a %T", object, typed))
+		}
+
+		list = append(list, typed)
+	}
+
+	return list, nil
+}
+
+// Identifiables returns a new IdentifiablesList with the objects of the list.
+func (l List[T]) Identifiables() IdentifiablesList {
+
+	list := make(IdentifiablesList, 0, len(l))
+	for _, object := range l {
+		list = append(list, object)
+	}
+
+	return list
+}
+
+// Filter returns a new list with the objects of the list for which the given function returns true.
+func (l List[T]) Filter(keep func(T) bool) List[T] {
+
+	filtered := List[T]{}
+	for _, object := range l {
+		if keep(object) {
+			filtered = append(filtered, object)
+		}
+	}
+
+	return filtered
+}
+
+// SortBy returns a new list with the objects of the list sorted with the given less function.
+// The sort is stable.
+func (l List[T]) SortBy(less func(a, b T) bool) List[T] {
+
+	sorted := append(List[T]{}, l...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+
+	return sorted
+}
+
+// IndexByID returns the objects of the list by ID.
+func (l List[T]) IndexByID() map[string]T {
+
+	index := make(map[string]T, len(l))
+	for _, object := range l {
+		index[object.Identifier()] = object
+	}
+
+	return index
+}
+
+// IndexByName returns the objects of the list by value of their name attribute.
+// The objects without name are ignored. If several objects have the same name,
+// the last one is kept.
+func (l List[T]) IndexByName() map[string]T {
+
+	index := make(map[string]T, len(l))
+	for _, object := range l {
+		if name := nameOf(object); name != "" {
+			index[name] = object
+		}
+	}
+
+	return index
+}
+
+// GroupBy returns the objects of the list grouped by the key returned by the given function.
+// The objects keep their order in each group.
+func (l List[T]) GroupBy(key func(T) string) map[string]List[T] {
+
+	groups := map[string]List[T]{}
+	for _, object := range l {
+		k := key(object)
+		groups[k] = append(groups[k], object)
+	}
+
+	return groups
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestList_Conversions(t *testing.T) {
+
+	Convey("Given I have a slice of objects", t, func() {
+
+		objects := []*FakeObject{{ID: "1"}, {ID: "2"}}
+
+		Convey("When I make a list of it", func() {
+
+			list := NewIdentifiablesList(&objects)
+
+			Convey("Then I should get the objects", func() {
+				So(list, ShouldHaveLength, 2)
+				So(list[1], ShouldEqual, objects[1])
+			})
+
+			Convey("When I put it back into a slice", func() {
+
+				var dest []*FakeObject
+				err := list.Into(&dest)
+
+				Convey("Then I should get the same slice", func() {
+					So(err, ShouldBeNil)
+					So(dest, ShouldResemble, objects)
+				})
+			})
+
+			Convey("When I put it into a slice of the wrong type", func() {
+
+				var dest []*FakeRootObject
+				err := list.Into(&dest)
+
+				Convey("Then I should get an error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("When I put it into something that is not a slice", func() {
+
+				err := list.Into(objects)
+
+				Convey("Then I should get an error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+		})
+
+		Convey("When I make a list of something that is not a slice of Identifiables", func() {
+
+			Convey("Then I should get nil", func() {
+				So(NewIdentifiablesList([]string{"a"}), ShouldBeNil)
+				So(NewIdentifiablesList(objects[0]), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestList_Helpers(t *testing.T) {
+
+	Convey("Given I have a list", t, func() {
+
+		list := IdentifiablesList{
+			&FakeObject{ID: "1", Name: "b"},
+			&FakeObject{ID: "2", Name: "a"},
+			&FakeObject{ID: "3", Name: "b"},
+			&FakeObject{ID: "4"},
+		}
+
+		Convey("Then I can filter it", func() {
+			filtered := list.Filter(func(o Identifiable) bool { return o.(*FakeObject).Name == "b" })
+			So(filtered, ShouldResemble, IdentifiablesList{list[0], list[2]})
+			So(list, ShouldHaveLength, 4)
+		})
+
+		Convey("Then I can sort it", func() {
+			sorted := list.SortBy(func(a, b Identifiable) bool { return a.(*FakeObject).Name < b.(*FakeObject).Name })
+			So(sorted, ShouldResemble, IdentifiablesList{list[3], list[1], list[0], list[2]})
+			So(list[0].Identifier(), ShouldEqual, "1")
+		})
+
+		Convey("Then I can index it by ID", func() {
+			index := list.IndexByID()
+			So(index, ShouldHaveLength, 4)
+			So(index["3"], ShouldEqual, list[2])
+		})
+
+		Convey("Then I can index it by name", func() {
+			index := list.IndexByName()
+			So(index, ShouldHaveLength, 2)
+			So(index["b"], ShouldEqual, list[2])
+		})
+
+		Convey("Then I can group it", func() {
+			groups := list.GroupBy(func(o Identifiable) string { return o.(*FakeObject).Name })
+			So(groups, ShouldHaveLength, 3)
+			So(groups["b"], ShouldResemble, IdentifiablesList{list[0], list[2]})
+		})
+	})
+}
+
+func TestList_Generic(t *testing.T) {
+
+	Convey("Given I have a typed list", t, func() {
+
+		list := List[*FakeObject]{
+			{ID: "1", Name: "b"},
+			{ID: "2", Name: "a"},
+			{ID: "3", Name: "b"},
+			{ID: "4"},
+		}
+
+		Convey("Then I can filter it", func() {
+			filtered := list.Filter(func(o *FakeObject) bool { return o.Name == "b" })
+			So(filtered, ShouldResemble, List[*FakeObject]{list[0], list[2]})
+			So(list, ShouldHaveLength, 4)
+		})
+
+		Convey("Then I can sort it", func() {
+			sorted := list.SortBy(func(a, b *FakeObject) bool { return a.Name < b.Name })
+			So(sorted, ShouldResemble, List[*FakeObject]{list[3], list[1], list[0], list[2]})
+			So(list[0].ID, ShouldEqual, "1")
+		})
+
+		Convey("Then I can index it by ID and by name", func() {
+			So(list.IndexByID()["3"], ShouldEqual, list[2])
+			So(list.IndexByName(), ShouldHaveLength, 2)
+			So(list.IndexByName()["b"].ID, ShouldEqual, "3")
+		})
+
+		Convey("Then I can group it", func() {
+			groups := list.GroupBy(func(o *FakeObject) string { return o.Name })
+			So(groups, ShouldHaveLength, 3)
+			So(groups["b"], ShouldResemble, List[*FakeObject]{list[0], list[2]})
+		})
+
+		Convey("Then I can convert it from and to an IdentifiablesList", func() {
+			identifiables := list.Identifiables()
+			So(identifiables, ShouldHaveLength, 4)
+			So(identifiables[0], ShouldEqual, list[0])
+
+			converted, err := ListOf[*FakeObject](identifiables)
+			So(err, ShouldBeNil)
+			So(converted, ShouldResemble, list)
+
+			_, err = ListOf[*FakeObject](IdentifiablesList{NewFakeRootObject()})
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given I have a server with children", t, func() {
+
+		fs := newFakeServer()
+		defer fs.Close()
+
+		fs.add("p", map[string]interface{}{"ID": "a", "name": "a"})
+		fs.add("p", map[string]interface{}{"ID": "b", "name": "b"})
+
+		session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+
+		Convey("When I fetch them into a typed list", func() {
+			list, err := FetchList[*FakeObject](session, NewFakeObject("p"), FakeIdentity, nil)
+
+			Convey("Then I should get the typed objects", func() {
+				So(err, ShouldBeNil)
+				So(list, ShouldHaveLength, 2)
+				So(list.IndexByName()["b"].ID, ShouldEqual, "b")
+			})
+		})
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// EntityVersion contains the version information of an Identifiable
+// as it was last seen on the server.
+type EntityVersion struct {
+	LastUpdatedDate int64
+	ETag            string
+}
+
+// ConflictsWith returns true if the receiver represents a server version
+// that has been modified since the given version has been retrieved.
+func (v EntityVersion) ConflictsWith(other EntityVersion) bool {
+
+	if v.ETag != "" && other.ETag != "" && v.ETag != other.ETag {
+		return true
+	}
+
+	return v.LastUpdatedDate > other.LastUpdatedDate
+}
+
+// String returns the string representation of the EntityVersion.
+func (v EntityVersion) String() string {
+
+	return fmt.Sprintf("<EntityVersion lastupdateddate: %d, etag: %s>", v.LastUpdatedDate, v.ETag)
+}
+
+// ConflictError is set as the Details of the *Error returned by SaveEntity
+// when optimistic locking is enabled and the server copy of the object
+// is newer than the one that has been fetched.
+// It contains both versions so the caller can merge them.
+type ConflictError struct {
+	Local         Identifiable
+	LocalVersion  EntityVersion
+	Remote        json.RawMessage
+	RemoteVersion EntityVersion
+}
+
+// DefaultMaxTrackedVersions is the number of versions a session records at most
+// in the optimistic locking mode, unless set with SetMaxTrackedVersions.
+const DefaultMaxTrackedVersions = 10000
+
+// versionStore keeps track of the EntityVersion of the fetched objects. The least
+// recently used versions are forgotten when it holds more than its maximum.
+type versionStore struct {
+	versions    map[string]*list.Element
+	lru         *list.List
+	maxVersions int
+	lock        sync.Mutex
+}
+
+// trackedVersion is a version recorded in a versionStore.
+type trackedVersion struct {
+	key     string
+	version EntityVersion
+}
+
+func (vs *versionStore) key(identity Identity, ID string) string {
+
+	return identity.Name + "/" + ID
+}
+
+func (vs *versionStore) set(identity Identity, ID string, version EntityVersion) {
+
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+
+	if vs.versions == nil {
+		vs.versions = map[string]*list.Element{}
+		vs.lru = list.New()
+	}
+
+	key := vs.key(identity, ID)
+	if element, ok := vs.versions[key]; ok {
+		vs.lru.Remove(element)
+	}
+	vs.versions[key] = vs.lru.PushFront(&trackedVersion{key: key, version: version})
+
+	vs.evict()
+}
+
+func (vs *versionStore) get(identity Identity, ID string) (EntityVersion, bool) {
+
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+
+	element, ok := vs.versions[vs.key(identity, ID)]
+	if !ok {
+		return EntityVersion{}, false
+	}
+
+	vs.lru.MoveToFront(element)
+
+	return element.Value.(*trackedVersion).version, true
+}
+
+func (vs *versionStore) forget(identity Identity, ID string) {
+
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+
+	if element, ok := vs.versions[vs.key(identity, ID)]; ok {
+		vs.lru.Remove(element)
+		delete(vs.versions, element.Value.(*trackedVersion).key)
+	}
+}
+
+// setMax sets the maximum number of versions of the store and forgets the least
+// recently used ones if needed. A maximum of 0 does not limit the number of versions.
+func (vs *versionStore) setMax(max int) {
+
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+
+	vs.maxVersions = max
+	vs.evict()
+}
+
+// len returns the number of versions of the store.
+func (vs *versionStore) len() int {
+
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+
+	return len(vs.versions)
+}
+
+// evict forgets the least recently used versions while the store holds more than its maximum.
+func (vs *versionStore) evict() {
+
+	for vs.maxVersions > 0 && vs.lru != nil && vs.lru.Len() > vs.maxVersions {
+		element := vs.lru.Back()
+		vs.lru.Remove(element)
+		delete(vs.versions, element.Value.(*trackedVersion).key)
+	}
+}
+
+// versionsFromBody extracts the EntityVersion of each object contained
+// in the given server response body, indexed by ID.
+// The given etag is only used if the body contains a single object.
+func versionsFromBody(body []byte, etag string) map[string]EntityVersion {
+
+	var items []map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&items); err != nil {
+		return nil
+	}
+
+	versions := map[string]EntityVersion{}
+	for _, item := range items {
+
+		ID, _ := item["ID"].(string)
+		if ID == "" {
+			continue
+		}
+
+		version := EntityVersion{}
+
+		switch d := item["lastUpdatedDate"].(type) {
+		case json.Number:
+			version.LastUpdatedDate, _ = strconv.ParseInt(d.String(), 10, 64)
+		case string:
+			version.LastUpdatedDate, _ = strconv.ParseInt(d, 10, 64)
+		}
+
+		if len(items) == 1 {
+			version.ETag = etag
+		}
+
+		versions[ID] = version
+	}
+
+	return versions
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLocking_EntityVersion(t *testing.T) {
+
+	Convey("Given I have two versions", t, func() {
+
+		v1 := EntityVersion{LastUpdatedDate: 1}
+		v2 := EntityVersion{LastUpdatedDate: 2}
+
+		Convey("Then the newest should conflict with the oldest", func() {
+			So(v2.ConflictsWith(v1), ShouldBeTrue)
+		})
+
+		Convey("Then the oldest should not conflict with the newest", func() {
+			So(v1.ConflictsWith(v2), ShouldBeFalse)
+		})
+
+		Convey("When both versions have a different ETag", func() {
+
+			v1.ETag = "a"
+			v2 = EntityVersion{LastUpdatedDate: 1, ETag: "b"}
+
+			Convey("Then they should conflict", func() {
+				So(v2.ConflictsWith(v1), ShouldBeTrue)
+			})
+		})
+
+		Convey("Then the string representation should be correct", func() {
+			So(v1.String(), ShouldEqual, "<EntityVersion lastupdateddate: 1, etag: >")
+		})
+	})
+}
+
+func TestLocking_versionsFromBody(t *testing.T) {
+
+	Convey("Given I have a body with several objects", t, func() {
+
+		body := []byte(`[{"ID": "a", "lastUpdatedDate": 1500000000000}, {"ID": "b", "lastUpdatedDate": "42"}, {"name": "noid"}]`)
+
+		Convey("When I extract the versions", func() {
+
+			versions := versionsFromBody(body, "etag")
+
+			Convey("Then I should get 2 versions", func() {
+				So(len(versions), ShouldEqual, 2)
+			})
+
+			Convey("Then the numeric date should be parsed", func() {
+				So(versions["a"].LastUpdatedDate, ShouldEqual, 1500000000000)
+			})
+
+			Convey("Then the string date should be parsed", func() {
+				So(versions["b"].LastUpdatedDate, ShouldEqual, 42)
+			})
+
+			Convey("Then the ETag should not be used", func() {
+				So(versions["a"].ETag, ShouldEqual, "")
+			})
+		})
+
+		Convey("When I extract the versions of an invalid body", func() {
+
+			versions := versionsFromBody([]byte(`nope`), "")
+
+			Convey("Then versions should be nil", func() {
+				So(versions, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestLocking_versionStore(t *testing.T) {
+
+	Convey("Given I have a version store with a maximum of 2 versions", t, func() {
+
+		identity := Identity{Name: "fakeobject", Category: "fakeobjects"}
+		vs := &versionStore{maxVersions: 2}
+		vs.set(identity, "1", EntityVersion{LastUpdatedDate: 1})
+		vs.set(identity, "2", EntityVersion{LastUpdatedDate: 2})
+
+		Convey("When I record a third version after reading the first one", func() {
+
+			vs.get(identity, "1")
+			vs.set(identity, "3", EntityVersion{LastUpdatedDate: 3})
+
+			Convey("Then the least recently used version should be forgotten", func() {
+				So(vs.len(), ShouldEqual, 2)
+				_, ok := vs.get(identity, "2")
+				So(ok, ShouldBeFalse)
+				version, ok := vs.get(identity, "1")
+				So(ok, ShouldBeTrue)
+				So(version.LastUpdatedDate, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I lower the maximum to 1", func() {
+
+			vs.setMax(1)
+
+			Convey("Then only the last version should be kept", func() {
+				So(vs.len(), ShouldEqual, 1)
+				_, ok := vs.get(identity, "2")
+				So(ok, ShouldBeTrue)
+			})
+		})
+
+		Convey("When I forget a version", func() {
+
+			vs.forget(identity, "1")
+
+			Convey("Then it should not be recorded anymore", func() {
+				So(vs.len(), ShouldEqual, 1)
+				_, ok := vs.get(identity, "1")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestLocking_SaveEntity(t *testing.T) {
+
+	Convey("Given I have a server and a session", t, func() {
+
+		lastUpdatedDate := 1
+		saved := false
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "PUT" {
+				saved = true
+			}
+			fmt.Fprintf(w, `[{"ID": "xxx", "name": "name", "lastUpdatedDate": %d}]`, lastUpdatedDate)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetOptimisticLocking(true)
+
+		e := NewFakeObject("xxx")
+		session.FetchEntity(e)
+
+		Convey("When I save the object and the server copy has not changed", func() {
+
+			err := session.SaveEntity(e)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the object should be saved", func() {
+				So(saved, ShouldBeTrue)
+			})
+		})
+
+		Convey("When I save the object and the server copy is newer", func() {
+
+			lastUpdatedDate = 2
+			err := session.SaveEntity(e)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Conflict")
+			})
+
+			Convey("Then the object should not be saved", func() {
+				So(saved, ShouldBeFalse)
+			})
+
+			Convey("Then the details should contain both versions", func() {
+				conflict, ok := err.Details.(*ConflictError)
+				So(ok, ShouldBeTrue)
+				So(conflict.Local, ShouldEqual, e)
+				So(conflict.LocalVersion.LastUpdatedDate, ShouldEqual, 1)
+				So(conflict.RemoteVersion.LastUpdatedDate, ShouldEqual, 2)
+				So(string(conflict.Remote), ShouldContainSubstring, `"lastUpdatedDate": 2`)
+			})
+		})
+
+		Convey("When I disable optimistic locking and save the object and the server copy is newer", func() {
+
+			session.SetOptimisticLocking(false)
+			lastUpdatedDate = 2
+			err := session.SaveEntity(e)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the object should be saved", func() {
+				So(saved, ShouldBeTrue)
+			})
+		})
+	})
+}
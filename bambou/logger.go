@@ -23,20 +23,39 @@
 
 package bambou
 
-import (
-	"github.com/ccding/go-logging/logging"
-)
+// Logger returns a LeveledLogger writing to the default logger, the one set with
+// SetDefaultLogger at the time of each log.
+//
+// Deprecated: Logger used to return a go-logging logger. Use DefaultLogger,
+// SetDefaultLogger or Session.SetLogger instead.
+func Logger() LeveledLogger {
 
-var defaultLogger *logging.Logger
+	return defaultLoggerWrapper{}
+}
+
+// defaultLoggerWrapper is the LeveledLogger returned by Logger.
+type defaultLoggerWrapper struct{}
+
+// Debugf implements the LeveledLogger interface.
+func (defaultLoggerWrapper) Debugf(format string, args ...interface{}) {
+
+	DefaultLogger().Debugf(format, args...)
+}
 
-// Logger returns the defaut Bambou logger.
-func Logger() *logging.Logger {
+// Infof implements the LeveledLogger interface.
+func (defaultLoggerWrapper) Infof(format string, args ...interface{}) {
+
+	DefaultLogger().Infof(format, args...)
+}
+
+// Warnf implements the LeveledLogger interface.
+func (defaultLoggerWrapper) Warnf(format string, args ...interface{}) {
+
+	DefaultLogger().Warnf(format, args...)
+}
 
-	if defaultLogger == nil {
-		logger, _ := logging.SimpleLogger("bambou")
-		defaultLogger = logger
-		logger.SetLevel(logging.NOTSET)
-	}
+// Errorf implements the LeveledLogger interface.
+func (defaultLoggerWrapper) Errorf(format string, args ...interface{}) {
 
-	return defaultLogger
+	DefaultLogger().Errorf(format, args...)
 }
@@ -26,21 +26,29 @@ package bambou
 import (
 	"testing"
 
-	"github.com/ccding/go-logging/logging"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestLogger_Logger(t *testing.T) {
 
 	Convey("Given I retrieve the Logger", t, func() {
+
 		l := Logger()
 
-		Convey("Then the Level should be logging.ERROR", func() {
-			So(l.Level(), ShouldEqual, logging.ERROR)
-		})
+		Convey("When I set the default logger and log to it", func() {
+
+			recorder := &recordingLogger{}
+			SetDefaultLogger(recorder)
+			defer SetDefaultLogger(nil)
+
+			l.Debugf("debug %d", 1)
+			l.Infof("info %d", 2)
+			l.Warnf("warn %d", 3)
+			l.Errorf("error %d", 4)
 
-		Convey("Then the Name should be 'bambou", func() {
-			So(l.Name(), ShouldEqual, "bambou")
+			Convey("Then the logs should be written to the default logger", func() {
+				So(recorder.logs, ShouldResemble, []string{"debug debug 1", "info info 2", "warn warn 3", "error error 4"})
+			})
 		})
 	})
 }
@@ -0,0 +1,56 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package logrusadapter adapts the logrus loggers to bambou.StructuredLogger.
+package logrusadapter
+
+import (
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is a bambou.StructuredLogger writing to a logrus logger.
+type Logger struct {
+	logrus.FieldLogger
+}
+
+// New returns a new *Logger writing to the given logrus logger,
+// with the given fields added to every entry.
+func New(logger logrus.FieldLogger, fields logrus.Fields) *Logger {
+
+	if len(fields) > 0 {
+		logger = logger.WithFields(fields)
+	}
+
+	return &Logger{
+		FieldLogger: logger,
+	}
+}
+
+// DebugFields implements the bambou.StructuredLogger interface.
+func (l *Logger) DebugFields(message string, fields bambou.Fields) {
+
+	l.WithFields(logrus.Fields(fields)).Debug(message)
+}
+
+var _ bambou.StructuredLogger = (*Logger)(nil)
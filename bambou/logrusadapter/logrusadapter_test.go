@@ -0,0 +1,75 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package logrusadapter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLogrusAdapter_New(t *testing.T) {
+
+	Convey("Given I have a logrus logger", t, func() {
+
+		buffer := &bytes.Buffer{}
+		logger := logrus.New()
+		logger.SetOutput(buffer)
+		logger.SetLevel(logrus.InfoLevel)
+		logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+		Convey("When I log through an adapter with fields", func() {
+
+			adapter := New(logger, logrus.Fields{"component": "bambou"})
+			adapter.Debugf("hidden")
+			adapter.Warnf("value is %d", 42)
+
+			Convey("Then the logger should get the entries above its level with the fields", func() {
+				So(buffer.String(), ShouldEqual, "level=warning msg=\"value is 42\" component=bambou\n")
+			})
+		})
+
+		Convey("When I log fields at the debug level", func() {
+
+			logger.SetLevel(logrus.DebugLevel)
+			New(logger, nil).DebugFields("request", bambou.Fields{"method": "GET", "status": 200})
+
+			Convey("Then the logger should get them as fields", func() {
+				So(buffer.String(), ShouldEqual, "level=debug msg=request method=GET status=200\n")
+			})
+		})
+
+		Convey("When I log through an adapter without fields", func() {
+
+			New(logger, nil).Errorf("failure")
+
+			Convey("Then the logger should get the entries", func() {
+				So(buffer.String(), ShouldEqual, "level=error msg=failure\n")
+			})
+		})
+	})
+}
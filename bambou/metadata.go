@@ -0,0 +1,180 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// MetadataIdentity is the Identity of the metadata.
+var MetadataIdentity = Identity{
+	Name:     "metadata",
+	Category: "metadatas",
+}
+
+// GlobalMetadataIdentity is the Identity of the global metadata.
+var GlobalMetadataIdentity = Identity{
+	Name:     "globalmetadata",
+	Category: "globalmetadatas",
+}
+
+// Metadata is a metadata, or a global metadata, attached to an object.
+// By convention, its value is stored base64 encoded in its blob:
+// see Value and SetValue.
+type Metadata struct {
+	ID                          string   `json:"ID,omitempty"`
+	Name                        string   `json:"name"`
+	Description                 string   `json:"description,omitempty"`
+	Blob                        string   `json:"blob"`
+	MetadataTagIDs              []string `json:"metadataTagIDs,omitempty"`
+	NetworkNotificationDisabled bool     `json:"networkNotificationDisabled"`
+
+	global bool
+}
+
+// NewMetadata returns a new *Metadata with the given name and value.
+func NewMetadata(name string, value []byte) *Metadata {
+
+	m := &Metadata{Name: name}
+	m.SetValue(value)
+
+	return m
+}
+
+// Identity returns the Identity of the metadata.
+func (m *Metadata) Identity() Identity {
+
+	if m.global {
+		return GlobalMetadataIdentity
+	}
+
+	return MetadataIdentity
+}
+
+// Identifier returns the ID of the metadata.
+func (m *Metadata) Identifier() string {
+
+	return m.ID
+}
+
+// SetIdentifier sets the ID of the metadata.
+func (m *Metadata) SetIdentifier(ID string) {
+
+	m.ID = ID
+}
+
+// Value returns the decoded value of the blob of the metadata.
+func (m *Metadata) Value() ([]byte, error) {
+
+	return base64.StdEncoding.DecodeString(m.Blob)
+}
+
+// SetValue sets the blob of the metadata to the encoded given value.
+func (m *Metadata) SetValue(value []byte) {
+
+	m.Blob = base64.StdEncoding.EncodeToString(value)
+}
+
+// GetMetadata returns the metadata of the given object with the given name, or nil if there is none.
+func GetMetadata(storer Storer, object Identifiable, name string) (*Metadata, *Error) {
+
+	return getMetadata(storer, object, name, false)
+}
+
+// SetMetadata sets the metadata of the given object with the given name to the given value.
+// The metadata is created if it does not exist.
+func SetMetadata(storer Storer, object Identifiable, name string, value []byte) *Error {
+
+	return setMetadata(storer, object, name, value, false)
+}
+
+// DeleteMetadata deletes the metadata of the given object with the given name, if any.
+func DeleteMetadata(storer Storer, object Identifiable, name string) *Error {
+
+	return deleteMetadata(storer, object, name, false)
+}
+
+// GetGlobalMetadata returns the global metadata of the given object with the given name, or nil if there is none.
+func GetGlobalMetadata(storer Storer, object Identifiable, name string) (*Metadata, *Error) {
+
+	return getMetadata(storer, object, name, true)
+}
+
+// SetGlobalMetadata sets the global metadata of the given object with the given name to the given value.
+// The global metadata is created if it does not exist.
+func SetGlobalMetadata(storer Storer, object Identifiable, name string, value []byte) *Error {
+
+	return setMetadata(storer, object, name, value, true)
+}
+
+// DeleteGlobalMetadata deletes the global metadata of the given object with the given name, if any.
+func DeleteGlobalMetadata(storer Storer, object Identifiable, name string) *Error {
+
+	return deleteMetadata(storer, object, name, true)
+}
+
+func getMetadata(storer Storer, object Identifiable, name string, global bool) (*Metadata, *Error) {
+
+	filter := fmt.Sprintf(`name == "%s"`, strings.Replace(name, `"`, `\"`, -1))
+
+	existing, err := findChild(storer, object, &Metadata{global: global}, filter)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	metadata := existing.(*Metadata)
+	metadata.global = global
+
+	return metadata, nil
+}
+
+func setMetadata(storer Storer, object Identifiable, name string, value []byte, global bool) *Error {
+
+	metadata, err := getMetadata(storer, object, name, global)
+	if err != nil {
+		return err
+	}
+
+	if metadata == nil {
+		metadata = NewMetadata(name, value)
+		metadata.global = global
+		return storer.CreateChild(object, metadata)
+	}
+
+	metadata.SetValue(value)
+
+	return storer.SaveEntity(metadata)
+}
+
+func deleteMetadata(storer Storer, object Identifiable, name string, global bool) *Error {
+
+	metadata, err := getMetadata(storer, object, name, global)
+	if err != nil || metadata == nil {
+		return err
+	}
+
+	return storer.DeleteEntity(metadata)
+}
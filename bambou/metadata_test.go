@@ -0,0 +1,144 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetadata_Value(t *testing.T) {
+
+	Convey("Given I have a metadata", t, func() {
+
+		m := NewMetadata("key", []byte("value"))
+
+		Convey("Then its value should be base64 encoded in its blob", func() {
+			So(m.Blob, ShouldEqual, "dmFsdWU=")
+			value, err := m.Value()
+			So(err, ShouldBeNil)
+			So(string(value), ShouldEqual, "value")
+		})
+
+		Convey("Then its identity should depend on its kind", func() {
+			So(m.Identity(), ShouldResemble, MetadataIdentity)
+			m.global = true
+			So(m.Identity(), ShouldResemble, GlobalMetadataIdentity)
+		})
+
+		Convey("When its blob is not base64", func() {
+
+			m.Blob = "not base64!"
+			_, err := m.Value()
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestMetadata_GetSetDelete(t *testing.T) {
+
+	Convey("Given I have a server with an object", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "x"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		o := NewFakeObject("x")
+
+		Convey("When I get a metadata that does not exist", func() {
+
+			m, err := GetMetadata(session, o, "key")
+
+			Convey("Then I should get nil", func() {
+				So(err, ShouldBeNil)
+				So(m, ShouldBeNil)
+			})
+		})
+
+		Convey("When I set a metadata", func() {
+
+			err := SetMetadata(session, o, "key", []byte("v1"))
+
+			Convey("Then it should be created", func() {
+				So(err, ShouldBeNil)
+				So(ts.count(), ShouldEqual, 2)
+				So(ts.methods(), ShouldResemble, []string{"GET", "POST"})
+			})
+
+			Convey("When I set it again and get it", func() {
+
+				err := SetMetadata(session, o, "key", []byte("v2"))
+				m, gerr := GetMetadata(session, o, "key")
+
+				Convey("Then it should be updated", func() {
+					So(err, ShouldBeNil)
+					So(gerr, ShouldBeNil)
+					So(ts.count(), ShouldEqual, 2)
+					value, _ := m.Value()
+					So(string(value), ShouldEqual, "v2")
+				})
+			})
+
+			Convey("When I delete it", func() {
+
+				err := DeleteMetadata(session, o, "key")
+
+				Convey("Then it should be deleted", func() {
+					So(err, ShouldBeNil)
+					So(ts.count(), ShouldEqual, 1)
+				})
+			})
+		})
+
+		Convey("When I delete a metadata that does not exist", func() {
+
+			err := DeleteMetadata(session, o, "key")
+
+			Convey("Then nothing should happen", func() {
+				So(err, ShouldBeNil)
+				So(ts.methods(), ShouldResemble, []string{"GET"})
+			})
+		})
+
+		Convey("When I set a global metadata", func() {
+
+			err := SetGlobalMetadata(session, o, "key", []byte("v"))
+			m, gerr := GetGlobalMetadata(session, o, "key")
+			derr := DeleteGlobalMetadata(session, o, "key")
+
+			Convey("Then it should be handled as a global metadata", func() {
+				So(err, ShouldBeNil)
+				So(gerr, ShouldBeNil)
+				So(derr, ShouldBeNil)
+				So(m.Identity(), ShouldResemble, GlobalMetadataIdentity)
+				So(ts.count(), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the metrics reported by the PushCenter.
+const (
+	MetricEventsReceived   = "bambou_events_received_total"
+	MetricEventDispatch    = "bambou_event_dispatch_seconds"
+	MetricEventLag         = "bambou_event_lag_seconds"
+	MetricEventReconnects  = "bambou_event_stream_reconnects_total"
+	MetricEventStreamStale = "bambou_event_stream_stale_total"
+)
+
+// Labels are the labels of a metric.
+type Labels map[string]string
+
+// Metrics is the interface of the metrics backends bambou reports to.
+type Metrics interface {
+
+	// AddCounter adds the given value to the counter with the given name and labels.
+	AddCounter(name string, labels Labels, value float64)
+
+	// SetGauge sets the gauge with the given name and labels to the given value.
+	SetGauge(name string, labels Labels, value float64)
+
+	// ObserveDuration records the given duration in the histogram with the given name and labels.
+	ObserveDuration(name string, labels Labels, duration time.Duration)
+}
+
+// MemoryMetrics is a Metrics keeping the values in memory.
+// It is meant to be inspected by tests or debugging tools.
+type MemoryMetrics struct {
+	counters  map[string]float64
+	gauges    map[string]float64
+	durations map[string][]time.Duration
+	lock      sync.RWMutex
+}
+
+// NewMemoryMetrics returns a new *MemoryMetrics.
+func NewMemoryMetrics() *MemoryMetrics {
+
+	return &MemoryMetrics{
+		counters:  map[string]float64{},
+		gauges:    map[string]float64{},
+		durations: map[string][]time.Duration{},
+	}
+}
+
+// AddCounter adds the given value to the counter with the given name and labels.
+func (m *MemoryMetrics) AddCounter(name string, labels Labels, value float64) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.counters[metricKey(name, labels)] += value
+}
+
+// SetGauge sets the gauge with the given name and labels to the given value.
+func (m *MemoryMetrics) SetGauge(name string, labels Labels, value float64) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.gauges[metricKey(name, labels)] = value
+}
+
+// ObserveDuration records the given duration.
+func (m *MemoryMetrics) ObserveDuration(name string, labels Labels, duration time.Duration) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := metricKey(name, labels)
+	m.durations[key] = append(m.durations[key], duration)
+}
+
+// Counter returns the value of the counter with the given name and labels.
+func (m *MemoryMetrics) Counter(name string, labels Labels) float64 {
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.counters[metricKey(name, labels)]
+}
+
+// Gauge returns the value of the gauge with the given name and labels.
+func (m *MemoryMetrics) Gauge(name string, labels Labels) float64 {
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.gauges[metricKey(name, labels)]
+}
+
+// Durations returns the durations recorded with the given name and labels.
+func (m *MemoryMetrics) Durations(name string, labels Labels) []time.Duration {
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return append([]time.Duration{}, m.durations[metricKey(name, labels)]...)
+}
+
+// Snapshot returns the current values of all the counters and gauges,
+// keyed by name{label="value",...}.
+func (m *MemoryMetrics) Snapshot() map[string]float64 {
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	snapshot := make(map[string]float64, len(m.counters)+len(m.gauges))
+	for key, value := range m.counters {
+		snapshot[key] = value
+	}
+	for key, value := range m.gauges {
+		snapshot[key] = value
+	}
+
+	return snapshot
+}
+
+// metricKey returns the key of the metric with the given name and labels.
+func metricKey(name string, labels Labels) string {
+
+	if len(labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + `="` + labels[key] + `"`
+	}
+
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetrics_MemoryMetrics(t *testing.T) {
+
+	Convey("Given I have memory metrics", t, func() {
+
+		m := NewMemoryMetrics()
+
+		Convey("When I add counters", func() {
+
+			m.AddCounter("c", Labels{"b": "2", "a": "1"}, 1)
+			m.AddCounter("c", Labels{"a": "1", "b": "2"}, 2)
+			m.AddCounter("c", nil, 5)
+
+			Convey("Then the values should be summed per labels", func() {
+				So(m.Counter("c", Labels{"a": "1", "b": "2"}), ShouldEqual, 3)
+				So(m.Counter("c", nil), ShouldEqual, 5)
+				So(m.Counter("other", nil), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I set gauges", func() {
+
+			m.SetGauge("g", Labels{"a": "1"}, 1)
+			m.SetGauge("g", Labels{"a": "1"}, 4)
+
+			Convey("Then the last value should be kept", func() {
+				So(m.Gauge("g", Labels{"a": "1"}), ShouldEqual, 4)
+			})
+
+			Convey("Then the snapshot should contain it", func() {
+				So(m.Snapshot(), ShouldResemble, map[string]float64{`g{a="1"}`: 4})
+			})
+		})
+
+		Convey("When I observe durations", func() {
+
+			m.ObserveDuration("d", nil, time.Second)
+			m.ObserveDuration("d", nil, time.Minute)
+
+			Convey("Then all the durations should be recorded", func() {
+				So(m.Durations("d", nil), ShouldResemble, []time.Duration{time.Second, time.Minute})
+			})
+		})
+	})
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+)
+
+// MigrationItem is an object handled by a Migrator.
+type MigrationItem struct {
+	Identity Identity
+
+	// SourceID is the ID of the object on the source.
+	SourceID string
+
+	// SourceParentID is the ID of the parent of the object on the source.
+	SourceParentID string
+
+	// DestinationID is the ID of the created object on the destination,
+	// empty in dry run or if it has not been created.
+	DestinationID string
+
+	// Reason is the reason why the object has been skipped, or the error that
+	// occurred when creating it.
+	Reason string
+}
+
+// MigrationReport is the report of a migration.
+type MigrationReport struct {
+	DryRun bool
+
+	// Created are the objects created on the destination, or that would be
+	// in dry run, in the order of their creation.
+	Created []*MigrationItem
+
+	// Skipped are the objects that have not been migrated, because they are
+	// not supported or depend on an object that has not been migrated.
+	Skipped []*MigrationItem
+
+	// Failed are the objects the destination failed to create.
+	Failed []*MigrationItem
+
+	// IDs are the IDs of the created objects on the destination, by their ID on the source.
+	IDs map[string]string
+}
+
+// String returns a summary of the report.
+func (r *MigrationReport) String() string {
+
+	verb := "created"
+	if r.DryRun {
+		verb = "to create"
+	}
+
+	return fmt.Sprintf("<MigrationReport %s: %d, skipped: %d, failed: %d>", verb, len(r.Created), len(r.Skipped), len(r.Failed))
+}
+
+// Migrator copies a tree of objects read from a source Storer to a destination
+// Storer, like another VSD or another enterprise.
+//
+// The objects are created parents first, and after the objects of the tree they
+// reference: an attribute of an object equal to the ID of another object of the
+// tree makes it depend on that object, and is set to the ID of its copy on the
+// destination. The objects depending on each other are skipped, as are the
+// objects of the identities that are not registered or explicitly skipped, and
+// their descendants and dependents.
+//
+// The assignments of the objects are not migrated.
+type Migrator struct {
+	source      Storer
+	destination Storer
+	layout      TreeLayout
+	skipped     map[string]string
+	dryRun      bool
+}
+
+// NewMigrator returns a new *Migrator copying the subtrees described by the given
+// layout from the given source to the given destination. See TreeLayout.
+func NewMigrator(source Storer, destination Storer, layout TreeLayout) *Migrator {
+
+	return &Migrator{
+		source:      source,
+		destination: destination,
+		layout:      layout,
+		skipped:     map[string]string{},
+	}
+}
+
+// SetDryRun sets if the migration only reads the source and reports what it would
+// create, without modifying the destination.
+func (m *Migrator) SetDryRun(dryRun bool) {
+
+	m.dryRun = dryRun
+}
+
+// Skip sets the Migrator to skip the objects of the given identity, and their
+// descendants and dependents, for the given reason.
+func (m *Migrator) Skip(identity Identity, reason string) {
+
+	m.skipped[identity.Name] = reason
+}
+
+// migrationNode is an object read from the source.
+type migrationNode struct {
+	item         *MigrationItem
+	object       Identifiable
+	parent       *migrationNode
+	dependencies []*migrationNode
+	clone        Identifiable
+	done         bool
+}
+
+// Migrate copies the subtree of the given source parent under the given destination
+// parent, and returns the report of the migration. It returns an error if the source
+// cannot be read, or if some objects failed to be created. The migration is not
+// transactional: if it fails, the objects already created are left as is.
+func (m *Migrator) Migrate(sourceParent Identifiable, destinationParent Identifiable) (*MigrationReport, *Error) {
+
+	report := &MigrationReport{DryRun: m.dryRun, IDs: map[string]string{}}
+
+	var nodes []*migrationNode
+	if err := m.read(sourceParent, nil, &nodes, report); err != nil {
+		return report, err
+	}
+
+	m.resolveDependencies(nodes)
+
+	for _, node := range m.order(nodes, report) {
+
+		if reason := m.blocked(node); reason != "" {
+			node.item.Reason = reason
+			report.Skipped = append(report.Skipped, node.item)
+			continue
+		}
+
+		if m.dryRun {
+			node.done = true
+			report.Created = append(report.Created, node.item)
+			continue
+		}
+
+		clone, err := remappedClone(node.object, report.IDs)
+		if err == nil {
+			parent := destinationParent
+			if node.parent != nil {
+				parent = node.parent.clone
+			}
+			err = m.destination.CreateChild(parent, clone)
+		}
+
+		if err != nil {
+			node.item.Reason = err.Description
+			report.Failed = append(report.Failed, node.item)
+			continue
+		}
+
+		node.clone = clone
+		node.done = true
+		node.item.DestinationID = clone.Identifier()
+		report.IDs[node.item.SourceID] = clone.Identifier()
+		report.Created = append(report.Created, node.item)
+	}
+
+	if len(report.Failed) > 0 {
+		return report, NewBambouError("Migration error", fmt.Sprintf("%d objects failed to be created, first: %s", len(report.Failed), report.Failed[0].Reason))
+	}
+
+	return report, nil
+}
+
+// read reads the subtree of the given object from the source, in pre-order.
+// The objects of the unsupported identities are reported as skipped.
+func (m *Migrator) read(object Identifiable, parent *migrationNode, nodes *[]*migrationNode, report *MigrationReport) *Error {
+
+	for _, identity := range m.layout[object.Identity().Name] {
+
+		reason, skipped := m.skipped[identity.Name]
+		if !skipped && NewIdentifiable(identity.Name) == nil {
+			reason, skipped = fmt.Sprintf("%s is not registered", identity.Name), true
+		}
+
+		if skipped {
+			report.Skipped = append(report.Skipped, &MigrationItem{
+				Identity:       identity,
+				SourceParentID: object.Identifier(),
+				Reason:         reason,
+			})
+			continue
+		}
+
+		children, err := fetchRegisteredChildren(m.source, object, identity)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+
+			node := &migrationNode{
+				item: &MigrationItem{
+					Identity:       identity,
+					SourceID:       child.Identifier(),
+					SourceParentID: object.Identifier(),
+				},
+				object: child,
+				parent: parent,
+			}
+			*nodes = append(*nodes, node)
+
+			if err := m.read(child, node, nodes, report); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveDependencies sets the dependencies of the given nodes: their parent,
+// and the objects of the tree they reference.
+func (m *Migrator) resolveDependencies(nodes []*migrationNode) {
+
+	byID := make(map[string]*migrationNode, len(nodes))
+	for _, node := range nodes {
+		if node.item.SourceID != "" {
+			byID[node.item.SourceID] = node
+		}
+	}
+
+	for _, node := range nodes {
+
+		if node.parent != nil {
+			node.dependencies = append(node.dependencies, node.parent)
+		}
+
+		attributes, err := attributesOf(node.object)
+		if err != nil {
+			continue
+		}
+
+		for _, name := range SystemAttributes {
+			delete(attributes, name)
+		}
+
+		for _, value := range attributes {
+			for _, ID := range referencedIDs(value, byID) {
+				if dependency := byID[ID]; dependency != node && dependency != node.parent {
+					node.dependencies = append(node.dependencies, dependency)
+				}
+			}
+		}
+	}
+}
+
+// order returns the given nodes ordered so that each node comes after its dependencies,
+// keeping the pre-order otherwise. The nodes in or depending on a dependency cycle
+// are reported as skipped.
+func (m *Migrator) order(nodes []*migrationNode, report *MigrationReport) []*migrationNode {
+
+	ordered := make([]*migrationNode, 0, len(nodes))
+	placed := make(map[*migrationNode]bool, len(nodes))
+	remaining := nodes
+
+	for len(remaining) > 0 {
+
+		var next []*migrationNode
+		for _, node := range remaining {
+
+			ready := true
+			for _, dependency := range node.dependencies {
+				if !placed[dependency] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				placed[node] = true
+				ordered = append(ordered, node)
+			} else {
+				next = append(next, node)
+			}
+		}
+
+		if len(next) == len(remaining) {
+			for _, node := range next {
+				node.item.Reason = "in or depending on a dependency cycle"
+				report.Skipped = append(report.Skipped, node.item)
+			}
+			break
+		}
+
+		remaining = next
+	}
+
+	return ordered
+}
+
+// blocked returns the reason why the given node cannot be migrated, if one of its
+// dependencies has not been migrated, or an empty string.
+func (m *Migrator) blocked(node *migrationNode) string {
+
+	for _, dependency := range node.dependencies {
+		if !dependency.done {
+			return fmt.Sprintf("depends on %s %s that is not migrated", dependency.item.Identity.Name, dependency.item.SourceID)
+		}
+	}
+
+	return ""
+}
+
+// referencedIDs returns the keys of the given map found in the given decoded JSON value,
+// where remapIDs replaces them.
+func referencedIDs(value interface{}, IDs map[string]*migrationNode) []string {
+
+	var found []string
+
+	switch v := value.(type) {
+	case string:
+		if _, ok := IDs[v]; ok {
+			found = append(found, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			found = append(found, referencedIDs(item, IDs)...)
+		}
+	}
+
+	return found
+}
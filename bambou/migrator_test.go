@@ -0,0 +1,152 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type migratedObject struct {
+	FakeObject
+
+	TemplateID string `json:"templateID,omitempty"`
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+
+	Convey("Given I have a source and a destination", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &migratedObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		source := newFakeServer()
+		defer source.Close()
+		destination := newFakeServer()
+		defer destination.Close()
+
+		// a references b, created after it in the pre-order, and c is a child of b.
+		source.add("p", map[string]interface{}{"ID": "a", "name": "a", "templateID": "b"})
+		source.add("p", map[string]interface{}{"ID": "b", "name": "b"})
+		source.add("b", map[string]interface{}{"ID": "c", "name": "c", "templateID": "a"})
+
+		sourceSession := NewSession("username", "password", "organization", source.URL, NewFakeRootObject())
+		destinationSession := NewSession("username", "password", "organization", destination.URL, NewFakeRootObject())
+
+		migrator := NewMigrator(sourceSession, destinationSession, TreeLayout{"fake": {FakeIdentity}})
+
+		Convey("When I migrate the tree", func() {
+
+			report, err := migrator.Migrate(NewFakeObject("p"), NewFakeObject("q"))
+
+			Convey("Then the objects should be created in the order of their dependencies", func() {
+				So(err, ShouldBeNil)
+				So(len(report.Created), ShouldEqual, 3)
+				So(report.Created[0].SourceID, ShouldEqual, "b")
+				So(report.Created[1].SourceID, ShouldEqual, "a")
+				So(report.Created[2].SourceID, ShouldEqual, "c")
+				So(destination.count(), ShouldEqual, 3)
+			})
+
+			Convey("Then the references should be remapped", func() {
+				a := destination.get(report.IDs["a"])
+				c := destination.get(report.IDs["c"])
+				So(a["templateID"], ShouldEqual, report.IDs["b"])
+				So(c["templateID"], ShouldEqual, report.IDs["a"])
+				So(c["parentID"], ShouldBeNil)
+			})
+		})
+
+		Convey("When I migrate the tree in dry run", func() {
+
+			migrator.SetDryRun(true)
+			report, err := migrator.Migrate(NewFakeObject("p"), NewFakeObject("q"))
+
+			Convey("Then the destination should not be modified", func() {
+				So(err, ShouldBeNil)
+				So(report.DryRun, ShouldBeTrue)
+				So(len(report.Created), ShouldEqual, 3)
+				So(report.Created[0].DestinationID, ShouldBeEmpty)
+				So(destination.count(), ShouldEqual, 0)
+				So(report.String(), ShouldEqual, "<MigrationReport to create: 3, skipped: 0, failed: 0>")
+			})
+		})
+
+		Convey("When an object fails to be created", func() {
+
+			destination.failOn = "b"
+			report, err := migrator.Migrate(NewFakeObject("p"), NewFakeObject("q"))
+
+			Convey("Then its descendants and dependents should be skipped", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Migration error")
+				So(len(report.Failed), ShouldEqual, 1)
+				So(report.Failed[0].SourceID, ShouldEqual, "b")
+				So(len(report.Skipped), ShouldEqual, 2)
+				So(report.Skipped[0].Reason, ShouldEqual, "depends on fake b that is not migrated")
+				So(destination.count(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When objects depend on each other", func() {
+
+			source.add("p", map[string]interface{}{"ID": "d", "name": "d", "templateID": "e"})
+			source.add("p", map[string]interface{}{"ID": "e", "name": "e", "templateID": "d"})
+			report, err := migrator.Migrate(NewFakeObject("p"), NewFakeObject("q"))
+
+			Convey("Then they should be skipped", func() {
+				So(err, ShouldBeNil)
+				So(len(report.Created), ShouldEqual, 3)
+				So(len(report.Skipped), ShouldEqual, 2)
+				So(report.Skipped[0].Reason, ShouldEqual, "in or depending on a dependency cycle")
+			})
+		})
+
+		Convey("When I skip an identity", func() {
+
+			migrator.Skip(FakeIdentity, "not needed")
+			report, err := migrator.Migrate(NewFakeObject("p"), NewFakeObject("q"))
+
+			Convey("Then it should be reported as skipped", func() {
+				So(err, ShouldBeNil)
+				So(len(report.Created), ShouldEqual, 0)
+				So(len(report.Skipped), ShouldEqual, 1)
+				So(report.Skipped[0].Reason, ShouldEqual, "not needed")
+				So(report.Skipped[0].SourceParentID, ShouldEqual, "p")
+			})
+		})
+
+		Convey("When an identity of the layout is not registered", func() {
+
+			UnregisterIdentity(FakeIdentity)
+			report, err := migrator.Migrate(NewFakeObject("p"), NewFakeObject("q"))
+
+			Convey("Then it should be reported as unsupported", func() {
+				So(err, ShouldBeNil)
+				So(report.Skipped[0].Reason, ShouldEqual, "fake is not registered")
+			})
+		})
+	})
+}
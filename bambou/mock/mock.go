@@ -0,0 +1,508 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package mock provides a scriptable bambou.Storer, to unit test the
+// applications using bambou without a server.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// The names of the methods of the Storer, used to record the calls and to inject errors.
+const (
+	MethodStart          = "Start"
+	MethodReset          = "Reset"
+	MethodFetchEntity    = "FetchEntity"
+	MethodSaveEntity     = "SaveEntity"
+	MethodDeleteEntity   = "DeleteEntity"
+	MethodFetchChildren  = "FetchChildren"
+	MethodCreateChild    = "CreateChild"
+	MethodAssignChildren = "AssignChildren"
+	MethodNextEvent      = "NextEvent"
+)
+
+// Call is a recorded call of a method of the Storer.
+type Call struct {
+	Method   string
+	Object   bambou.Identifiable
+	Children []bambou.Identifiable
+	Identity bambou.Identity
+	Info     *bambou.FetchingInfo
+}
+
+// Handler is the prototype of the functions handling the calls of a method of the Storer
+// instead of its default behavior. See Storer.Handle.
+type Handler func(call Call) *bambou.Error
+
+// Storer is a scriptable bambou.Storer keeping the objects in memory.
+// It records the calls of its methods, can be programmed with the objects
+// and children to return, and can be made to fail per method and Identity.
+// It also implements bambou.EventTransport, returning the notifications
+// pushed with PushNotification.
+type Storer struct {
+	root          bambou.Rootable
+	entities      map[string]bambou.Identifiable
+	children      map[string][]bambou.Identifiable
+	errors        map[string]*bambou.Error
+	handlers      map[string]Handler
+	calls         []Call
+	notifications chan *bambou.Notification
+	nextID        int
+	lock          sync.Mutex
+}
+
+// NewStorer returns a new *Storer with the given root object.
+func NewStorer(root bambou.Rootable) *Storer {
+
+	return &Storer{
+		root:          root,
+		entities:      map[string]bambou.Identifiable{},
+		children:      map[string][]bambou.Identifiable{},
+		errors:        map[string]*bambou.Error{},
+		handlers:      map[string]Handler{},
+		notifications: make(chan *bambou.Notification, 1024),
+	}
+}
+
+// SetEntity sets the objects returned by FetchEntity for their ID.
+func (s *Storer) SetEntity(objects ...bambou.Identifiable) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, object := range objects {
+		s.entities[object.Identifier()] = object
+	}
+}
+
+// SetChildren sets the children of the given Identity of the given parent returned
+// by FetchChildren. Use nil as parent for the children of the root object.
+// The children are also returned by FetchEntity.
+func (s *Storer) SetChildren(parent bambou.Identifiable, identity bambou.Identity, children ...bambou.Identifiable) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.children[childrenKey(parent, identity)] = append([]bambou.Identifiable{}, children...)
+
+	for _, child := range children {
+		s.entities[child.Identifier()] = child
+	}
+}
+
+// Load adds the objects of the given trees to the children of their parent, the root
+// object for the top level ones. The objects are given an ID if they have none.
+// See bambou.LoadFixture.
+func (s *Storer) Load(nodes ...*bambou.TreeNode) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.load(nil, nodes)
+}
+
+// LoadFile adds the objects of the trees of the fixture file at the given path. See Load.
+func (s *Storer) LoadFile(path string) error {
+
+	nodes, err := bambou.LoadFixtureFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.Load(nodes...)
+
+	return nil
+}
+
+// FailOn makes the given method fail with the given error for the objects of the given Identity.
+// Use bambou.AllIdentity to make it fail for all the identities, and nil to stop making it fail.
+// The Identity of FetchChildren and AssignChildren is the Identity of the children.
+func (s *Storer) FailOn(method string, identity bambou.Identity, err *bambou.Error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := method + "/" + identity.Name
+	if err == nil {
+		delete(s.errors, key)
+		return
+	}
+
+	s.errors[key] = err
+}
+
+// Handle sets the given Handler to handle the calls of the given method instead of the Storer.
+// The calls are still recorded, and the injected errors still returned first. Use nil to
+// restore the default behavior.
+func (s *Storer) Handle(method string, handler Handler) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if handler == nil {
+		delete(s.handlers, method)
+		return
+	}
+
+	s.handlers[method] = handler
+}
+
+// PushNotification queues the given notification to be returned by NextEvent.
+func (s *Storer) PushNotification(notification *bambou.Notification) {
+
+	s.notifications <- notification
+}
+
+// Calls returns the recorded calls. If methods are given, only the calls of those methods are returned.
+func (s *Storer) Calls(methods ...string) []Call {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	calls := []Call{}
+	for _, call := range s.calls {
+		if len(methods) == 0 || contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// ClearCalls forgets the recorded calls.
+func (s *Storer) ClearCalls() {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.calls = nil
+}
+
+// Start implements the bambou.Storer interface.
+func (s *Storer) Start() *bambou.Error {
+
+	_, err := s.call(Call{Method: MethodStart})
+
+	return err
+}
+
+// Reset implements the bambou.Storer interface.
+func (s *Storer) Reset() {
+
+	s.call(Call{Method: MethodReset})
+}
+
+// Root implements the bambou.Storer interface.
+func (s *Storer) Root() bambou.Rootable {
+
+	return s.root
+}
+
+// FetchEntity implements the bambou.Storer interface.
+// It copies the object set with SetEntity, saved or created with the same ID
+// into the given object, or fails with a 404 error if there is none.
+func (s *Storer) FetchEntity(object bambou.Identifiable) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodFetchEntity, Object: object, Identity: object.Identity()})
+	if handled || err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entity, ok := s.entities[object.Identifier()]
+	if !ok {
+		return newNotFoundError(object)
+	}
+
+	return copyObject(entity, object)
+}
+
+// SaveEntity implements the bambou.Storer interface.
+// The object then replaces the object with the same ID.
+func (s *Storer) SaveEntity(object bambou.Identifiable) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodSaveEntity, Object: object, Identity: object.Identity()})
+	if handled || err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.entities[object.Identifier()]; !ok {
+		return newNotFoundError(object)
+	}
+
+	s.replace(object)
+
+	return nil
+}
+
+// DeleteEntity implements the bambou.Storer interface.
+// The object is then removed from the objects and the children.
+func (s *Storer) DeleteEntity(object bambou.Identifiable) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodDeleteEntity, Object: object, Identity: object.Identity()})
+	if handled || err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.entities[object.Identifier()]; !ok {
+		return newNotFoundError(object)
+	}
+
+	delete(s.entities, object.Identifier())
+	for key, children := range s.children {
+		s.children[key] = without(children, object.Identifier())
+	}
+
+	return nil
+}
+
+// FetchChildren implements the bambou.Storer interface.
+// It sets the given destination, that must be a pointer to a slice,
+// to the children set with SetChildren or created.
+func (s *Storer) FetchChildren(parent bambou.Identifiable, identity bambou.Identity, dest interface{}, info *bambou.FetchingInfo) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodFetchChildren, Object: parent, Identity: identity, Info: info})
+	if handled || err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	children := s.children[childrenKey(parent, identity)]
+	if info != nil {
+		info.TotalCount = len(children)
+	}
+
+	return bambou.IdentifiablesList(children).Into(dest)
+}
+
+// CreateChild implements the bambou.Storer interface.
+// The child is given an ID if it has none, and is added to the children of the parent.
+func (s *Storer) CreateChild(parent bambou.Identifiable, child bambou.Identifiable) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodCreateChild, Object: parent, Children: []bambou.Identifiable{child}, Identity: child.Identity()})
+	if handled || err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.add(parent, child)
+
+	return nil
+}
+
+// AssignChildren implements the bambou.Storer interface.
+// The children then replace the children of the given Identity of the parent.
+func (s *Storer) AssignChildren(parent bambou.Identifiable, children []bambou.Identifiable, identity bambou.Identity) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodAssignChildren, Object: parent, Children: children, Identity: identity})
+	if handled || err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.children[childrenKey(parent, identity)] = append([]bambou.Identifiable{}, children...)
+
+	return nil
+}
+
+// NextEvent implements the bambou.Storer interface.
+// It sends the next notification pushed with PushNotification to the given channel,
+// and blocks until there is one.
+func (s *Storer) NextEvent(channel bambou.NotificationsChannel, lastEventID string) *bambou.Error {
+
+	return s.NextEventWithContext(context.Background(), channel, lastEventID)
+}
+
+// NextEventWithContext implements the bambou.EventTransport interface.
+// It works like NextEvent, but returns as soon as the given context is done.
+func (s *Storer) NextEventWithContext(ctx context.Context, channel bambou.NotificationsChannel, lastEventID string) *bambou.Error {
+
+	handled, err := s.call(Call{Method: MethodNextEvent})
+	if handled || err != nil {
+		return err
+	}
+
+	select {
+	case notification := <-s.notifications:
+		select {
+		case channel <- notification:
+			return nil
+		case <-ctx.Done():
+			return bambou.NewBambouError("Canceled", ctx.Err().Error())
+		}
+	case <-ctx.Done():
+		return bambou.NewBambouError("Canceled", ctx.Err().Error())
+	}
+}
+
+// call records the given call, and returns the error injected for it, if any.
+// If a Handler is set for the method, it is called and handled is true.
+func (s *Storer) call(call Call) (handled bool, err *bambou.Error) {
+
+	s.lock.Lock()
+	s.calls = append(s.calls, call)
+
+	err, ok := s.errors[call.Method+"/"+call.Identity.Name]
+	if !ok {
+		err = s.errors[call.Method+"/"+bambou.AllIdentity.Name]
+	}
+
+	handler := s.handlers[call.Method]
+	s.lock.Unlock()
+
+	if err != nil {
+		return true, err
+	}
+
+	if handler != nil {
+		return true, handler(call)
+	}
+
+	return false, nil
+}
+
+// replace replaces the object with the same ID as the given one.
+func (s *Storer) replace(object bambou.Identifiable) {
+
+	s.entities[object.Identifier()] = object
+
+	for _, children := range s.children {
+		for i, child := range children {
+			if child.Identifier() == object.Identifier() {
+				children[i] = object
+			}
+		}
+	}
+}
+
+// add adds the given child to the children of the given parent, and gives it an ID if it has none.
+func (s *Storer) add(parent bambou.Identifiable, child bambou.Identifiable) {
+
+	if child.Identifier() == "" {
+		s.nextID++
+		child.SetIdentifier(fmt.Sprintf("mock-%d", s.nextID))
+	}
+
+	key := childrenKey(parent, child.Identity())
+	s.children[key] = append(s.children[key], child)
+	s.entities[child.Identifier()] = child
+}
+
+// load adds the objects of the given trees under the given parent, recursively.
+func (s *Storer) load(parent bambou.Identifiable, nodes []*bambou.TreeNode) {
+
+	for _, node := range nodes {
+		s.add(parent, node.Object)
+		s.load(node.Object, node.Children)
+	}
+}
+
+// childrenKey returns the key of the children of the given Identity of the given parent.
+func childrenKey(parent bambou.Identifiable, identity bambou.Identity) string {
+
+	if _, ok := parent.(bambou.Rootable); ok || parent == nil {
+		return "/" + identity.Name
+	}
+
+	return parent.Identifier() + "/" + identity.Name
+}
+
+// copyObject copies the given source object into the given destination object.
+func copyObject(source bambou.Identifiable, dest bambou.Identifiable) *bambou.Error {
+
+	sv, dv := reflect.ValueOf(source), reflect.ValueOf(dest)
+	if sv.Type() == dv.Type() && sv.Kind() == reflect.Ptr {
+		dv.Elem().Set(sv.Elem())
+		return nil
+	}
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		return bambou.NewBambouError("JSON error", err.Error())
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return bambou.NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	return nil
+}
+
+func newNotFoundError(object bambou.Identifiable) *bambou.Error {
+
+	return &bambou.Error{
+		Code:        http.StatusNotFound,
+		Title:       "Not found",
+		Description: fmt.Sprintf("%s %s does not exist", object.Identity().Name, object.Identifier()),
+	}
+}
+
+func without(objects []bambou.Identifiable, ID string) []bambou.Identifiable {
+
+	var filtered []bambou.Identifiable
+	for _, object := range objects {
+		if object.Identifier() != ID {
+			filtered = append(filtered, object)
+		}
+	}
+
+	return filtered
+}
+
+func contains(list []string, s string) bool {
+
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	_ bambou.Storer         = (*Storer)(nil)
+	_ bambou.EventTransport = (*Storer)(nil)
+)
@@ -0,0 +1,296 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var testIdentity = bambou.Identity{Name: "test", Category: "tests"}
+
+type testObject struct {
+	ID   string `json:"ID"`
+	Name string `json:"name"`
+}
+
+func (o *testObject) Identity() bambou.Identity { return testIdentity }
+func (o *testObject) Identifier() string        { return o.ID }
+func (o *testObject) SetIdentifier(ID string)   { o.ID = ID }
+
+type testRoot struct {
+	testObject
+}
+
+func (o *testRoot) Identity() bambou.Identity { return bambou.Identity{Name: "root", Category: "root"} }
+func (o *testRoot) APIKey() string            { return "" }
+func (o *testRoot) SetAPIKey(string)          {}
+
+func TestMock_CRUD(t *testing.T) {
+
+	Convey("Given I have a mock storer with children", t, func() {
+
+		root := &testRoot{}
+		s := NewStorer(root)
+		s.SetChildren(nil, testIdentity, &testObject{ID: "1", Name: "one"}, &testObject{ID: "2", Name: "two"})
+
+		Convey("Then I can use it as a storer", func() {
+			So(s.Root(), ShouldEqual, root)
+			So(s.Start(), ShouldBeNil)
+		})
+
+		Convey("When I fetch the children", func() {
+
+			var children []*testObject
+			info := bambou.NewFetchingInfo()
+			err := s.FetchChildren(root, testIdentity, &children, info)
+
+			Convey("Then I should get them", func() {
+				So(err, ShouldBeNil)
+				So(children, ShouldHaveLength, 2)
+				So(children[1].Name, ShouldEqual, "two")
+				So(info.TotalCount, ShouldEqual, 2)
+			})
+
+			Convey("Then the call should be recorded", func() {
+				calls := s.Calls(MethodFetchChildren)
+				So(calls, ShouldHaveLength, 1)
+				So(calls[0].Object, ShouldEqual, root)
+				So(calls[0].Identity, ShouldResemble, testIdentity)
+				So(calls[0].Info, ShouldEqual, info)
+			})
+		})
+
+		Convey("When I fetch a child", func() {
+
+			o := &testObject{ID: "1"}
+			err := s.FetchEntity(o)
+
+			Convey("Then I should get it", func() {
+				So(err, ShouldBeNil)
+				So(o.Name, ShouldEqual, "one")
+			})
+		})
+
+		Convey("When I fetch an object that does not exist", func() {
+
+			err := s.FetchEntity(&testObject{ID: "3"})
+
+			Convey("Then I should get a 404 error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, 404)
+			})
+		})
+
+		Convey("When I create, save and delete a grandchild", func() {
+
+			parent := &testObject{ID: "1"}
+			o := &testObject{Name: "new"}
+			cerr := s.CreateChild(parent, o)
+			o.Name = "renamed"
+			serr := s.SaveEntity(o)
+
+			var children []*testObject
+			s.FetchChildren(parent, testIdentity, &children, nil)
+
+			derr := s.DeleteEntity(o)
+			var after []*testObject
+			s.FetchChildren(parent, testIdentity, &after, nil)
+
+			Convey("Then the storer should be updated", func() {
+				So(cerr, ShouldBeNil)
+				So(serr, ShouldBeNil)
+				So(derr, ShouldBeNil)
+				So(o.ID, ShouldEqual, "mock-1")
+				So(children, ShouldHaveLength, 1)
+				So(children[0].Name, ShouldEqual, "renamed")
+				So(after, ShouldBeEmpty)
+				So(len(s.Calls()), ShouldEqual, 5)
+			})
+		})
+
+		Convey("When I assign children", func() {
+
+			err := s.AssignChildren(root, []bambou.Identifiable{&testObject{ID: "3"}}, testIdentity)
+
+			var children []*testObject
+			s.FetchChildren(nil, testIdentity, &children, nil)
+
+			Convey("Then they should replace the children", func() {
+				So(err, ShouldBeNil)
+				So(children, ShouldHaveLength, 1)
+				So(children[0].ID, ShouldEqual, "3")
+			})
+		})
+
+		Convey("When I clear the calls", func() {
+
+			s.Reset()
+			s.ClearCalls()
+
+			Convey("Then there should be no calls", func() {
+				So(s.Calls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestMock_Scripting(t *testing.T) {
+
+	Convey("Given I have a mock storer", t, func() {
+
+		s := NewStorer(&testRoot{})
+		s.SetEntity(&testObject{ID: "1"})
+		failure := bambou.NewBambouError("failure", "")
+
+		Convey("When I make a method fail for an identity", func() {
+
+			s.FailOn(MethodSaveEntity, testIdentity, failure)
+
+			Convey("Then it should fail for that identity", func() {
+				So(s.SaveEntity(&testObject{ID: "1"}), ShouldEqual, failure)
+				So(s.FetchEntity(&testObject{ID: "1"}), ShouldBeNil)
+			})
+
+			Convey("When I stop making it fail", func() {
+
+				s.FailOn(MethodSaveEntity, testIdentity, nil)
+
+				Convey("Then it should succeed", func() {
+					So(s.SaveEntity(&testObject{ID: "1"}), ShouldBeNil)
+				})
+			})
+		})
+
+		Convey("When I make a method fail for all identities", func() {
+
+			s.FailOn(MethodStart, bambou.AllIdentity, failure)
+
+			Convey("Then it should fail", func() {
+				So(s.Start(), ShouldEqual, failure)
+			})
+		})
+
+		Convey("When I handle a method", func() {
+
+			var handled Call
+			s.Handle(MethodFetchEntity, func(call Call) *bambou.Error {
+				handled = call
+				call.Object.(*testObject).Name = "handled"
+				return nil
+			})
+
+			o := &testObject{ID: "unknown"}
+			err := s.FetchEntity(o)
+
+			Convey("Then the handler should be called instead", func() {
+				So(err, ShouldBeNil)
+				So(handled.Object, ShouldEqual, o)
+				So(o.Name, ShouldEqual, "handled")
+			})
+		})
+	})
+}
+
+func TestMock_Load(t *testing.T) {
+
+	Convey("Given I have a mock storer", t, func() {
+
+		root := &testRoot{}
+		s := NewStorer(root)
+
+		Convey("When I load trees of objects", func() {
+
+			parent := &testObject{ID: "p", Name: "parent"}
+			s.Load(bambou.NewTreeNode(parent,
+				bambou.NewTreeNode(&testObject{Name: "one"}),
+				bambou.NewTreeNode(&testObject{Name: "two"}),
+			))
+
+			var children []*testObject
+			err := s.FetchChildren(parent, testIdentity, &children, nil)
+
+			var top []*testObject
+			s.FetchChildren(root, testIdentity, &top, nil)
+
+			Convey("Then I should be able to fetch them", func() {
+				So(err, ShouldBeNil)
+				So(children, ShouldHaveLength, 2)
+				So(children[0].ID, ShouldEqual, "mock-1")
+				So(children[1].Name, ShouldEqual, "two")
+				So(top, ShouldHaveLength, 1)
+				So(s.FetchEntity(&testObject{ID: "mock-2"}), ShouldBeNil)
+			})
+		})
+
+		Convey("When I load a fixture file that does not exist", func() {
+
+			err := s.LoadFile("does-not-exist.yaml")
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestMock_Events(t *testing.T) {
+
+	Convey("Given I have a mock storer with a notification", t, func() {
+
+		s := NewStorer(&testRoot{})
+		notification := bambou.NewNotification()
+		s.PushNotification(notification)
+
+		Convey("When I get the next event", func() {
+
+			channel := make(bambou.NotificationsChannel, 1)
+			err := s.NextEvent(channel, "")
+
+			Convey("Then I should get the notification", func() {
+				So(err, ShouldBeNil)
+				So(<-channel, ShouldEqual, notification)
+			})
+		})
+
+		Convey("When I wait for the next event with a canceled context", func() {
+
+			channel := make(bambou.NotificationsChannel, 1)
+			s.NextEvent(channel, "")
+			<-channel
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := s.NextEventWithContext(ctx, channel, "")
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
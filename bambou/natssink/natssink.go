@@ -0,0 +1,72 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package natssink provides a bambou.EventSink publishing the events to NATS.
+package natssink
+
+import (
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// Conn is the interface of the NATS connections. It is implemented by *nats.Conn.
+type Conn interface {
+	Publish(subject string, data []byte) error
+}
+
+var _ Conn = (*nats.Conn)(nil)
+
+// Publisher is a bambou.EventSink publishing every event to the subject
+// <prefix>.<entityType>.<eventType>, like nuage.enterprise.create.
+type Publisher struct {
+	conn   Conn
+	prefix string
+}
+
+// New returns a new *Publisher using the given connection and subject prefix.
+func New(conn Conn, prefix string) *Publisher {
+
+	return &Publisher{
+		conn:   conn,
+		prefix: prefix,
+	}
+}
+
+// Subject returns the subject the given event is published to.
+func (p *Publisher) Subject(event *bambou.Event) string {
+
+	return p.prefix + "." + event.EntityType + "." + strings.ToLower(event.Type)
+}
+
+// Publish publishes the given event.
+func (p *Publisher) Publish(event *bambou.Event) error {
+
+	data, err := bambou.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	return p.conn.Publish(p.Subject(event), data)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package natssink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeConn struct {
+	subjects []string
+	data     []string
+	err      error
+}
+
+func (c *fakeConn) Publish(subject string, data []byte) error {
+
+	c.subjects = append(c.subjects, subject)
+	c.data = append(c.data, string(data))
+
+	return c.err
+}
+
+func TestNATSSink_Publish(t *testing.T) {
+
+	Convey("Given I have a publisher", t, func() {
+
+		conn := &fakeConn{}
+		p := New(conn, "nuage")
+		e := &bambou.Event{EntityType: "enterprise", Type: bambou.EventTypeCreate, DataMap: []map[string]interface{}{{"ID": "x"}}}
+
+		Convey("When I publish an event", func() {
+
+			err := p.Publish(e)
+
+			Convey("Then it should be published to the subject of the event", func() {
+				So(err, ShouldBeNil)
+				So(conn.subjects, ShouldResemble, []string{"nuage.enterprise.create"})
+				So(conn.data[0], ShouldContainSubstring, `"entities":[{"ID":"x"}]`)
+			})
+		})
+
+		Convey("When I publish an event and the connection fails", func() {
+
+			conn.err = errors.New("woops")
+			err := p.Publish(e)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -23,20 +23,68 @@
 
 package bambou
 
+import "encoding/json"
+
+// Types of Event sent by the server.
+const (
+	EventTypeCreate = "CREATE"
+	EventTypeUpdate = "UPDATE"
+	EventTypeDelete = "DELETE"
+)
+
 // EventsList represents a list of *Event.
 type EventsList []*Event
 
 // Event represents one item of a Notification.
 // It will contain data from the server regarding the object that has
 // been created, deleted, or modified.
+// If the Identity of the EntityType is registered, Entities contains
+// the decoded objects.
 type Event struct {
 	DataMap         []map[string]interface{} `json:"entities"`
 	Data            []byte                   `json:"-"`
+	Entities        IdentifiablesList        `json:"-"`
 	EntityType      string                   `json:"entityType"`
+	ReceivedTime    int64                    `json:"eventReceivedTime"`
 	Type            string                   `json:"type"`
 	UpdateMechanism string                   `json:"updateMechanism"`
 }
 
+// decodeEntities decodes the DataMap into Entities using the registered identities.
+// Entities is left nil if the EntityType is not registered.
+func (e *Event) decodeEntities() error {
+
+	if NewIdentifiable(e.EntityType) == nil {
+		return nil
+	}
+
+	entities := IdentifiablesList{}
+	for _, data := range e.DataMap {
+
+		entity := NewIdentifiable(e.EntityType)
+		if err := decodeEntity(data, entity); err != nil {
+			return err
+		}
+
+		entities = append(entities, entity)
+	}
+
+	e.Entities = entities
+
+	return nil
+}
+
+// decodeEntity decodes the given entity of an Event into the given Identifiable.
+func decodeEntity(data map[string]interface{}, entity Identifiable) error {
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(encoded, entity)
+}
+
 // Notification represents a collection of Event structures.
 // It also contains a identifier for the Notification.
 type Notification struct {
@@ -89,3 +89,42 @@ func TestNotification_FromJSON(t *testing.T) {
 		})
 	})
 }
+
+func TestNotification_decodeEntities(t *testing.T) {
+
+	Convey("Given I have an event with entities", t, func() {
+
+		e := &Event{EntityType: "fake", DataMap: []map[string]interface{}{{"ID": "x", "name": "a"}, {"ID": "y", "name": "b"}}}
+
+		Convey("When I decode the entities and the identity is not registered", func() {
+
+			err := e.decodeEntities()
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then Entities should be nil", func() {
+				So(e.Entities, ShouldBeNil)
+			})
+		})
+
+		Convey("When I decode the entities and the identity is registered", func() {
+
+			RegisterIdentity(FakeIdentity, func() Identifiable { return NewFakeObject("") })
+			defer UnregisterIdentity(FakeIdentity)
+
+			err := e.decodeEntities()
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then Entities should contain the decoded objects", func() {
+				So(len(e.Entities), ShouldEqual, 2)
+				So(e.Entities[0], ShouldResemble, &FakeObject{ID: "x", Name: "a"})
+				So(e.Entities[1], ShouldResemble, &FakeObject{ID: "y", Name: "b"})
+			})
+		})
+	})
+}
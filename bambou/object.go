@@ -0,0 +1,158 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Object is an Identifiable of any Identity, keeping its attributes in a map.
+// It allows to work with the objects whose models are not known at compile time,
+// for instance in command line tools.
+type Object struct {
+	identity   Identity
+	Attributes map[string]interface{}
+}
+
+// NewObject returns a new *Object of the given Identity, with the given ID if not empty.
+func NewObject(identity Identity, ID string) *Object {
+
+	o := &Object{
+		identity:   identity,
+		Attributes: map[string]interface{}{},
+	}
+
+	if ID != "" {
+		o.Attributes["ID"] = ID
+	}
+
+	return o
+}
+
+// Identity returns the Identity of the object.
+func (o *Object) Identity() Identity {
+
+	return o.identity
+}
+
+// Identifier returns the ID of the object.
+func (o *Object) Identifier() string {
+
+	ID, _ := o.Attributes["ID"].(string)
+
+	return ID
+}
+
+// SetIdentifier sets the ID of the object.
+func (o *Object) SetIdentifier(ID string) {
+
+	o.Set("ID", ID)
+}
+
+// Get returns the value of the attribute with the given name, or nil.
+func (o *Object) Get(name string) interface{} {
+
+	return o.Attributes[name]
+}
+
+// Set sets the value of the attribute with the given name.
+func (o *Object) Set(name string, value interface{}) {
+
+	if o.Attributes == nil {
+		o.Attributes = map[string]interface{}{}
+	}
+
+	o.Attributes[name] = value
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o *Object) MarshalJSON() ([]byte, error) {
+
+	if o.Attributes == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(o.Attributes)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The attributes are
+// merged into the current ones, and the numbers are decoded as json.Number.
+func (o *Object) UnmarshalJSON(data []byte) error {
+
+	attributes := map[string]interface{}{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil {
+		return err
+	}
+
+	for name, value := range attributes {
+		o.Set(name, value)
+	}
+
+	return nil
+}
+
+// RootObject is an Object implementing the Rootable interface, for the tools
+// that do not use the generated root model of the API.
+type RootObject struct {
+	Object
+}
+
+// NewRootObject returns a new *RootObject of the given Identity.
+func NewRootObject(identity Identity) *RootObject {
+
+	return &RootObject{Object: *NewObject(identity, "")}
+}
+
+// APIKey returns the API key of the root object.
+func (o *RootObject) APIKey() string {
+
+	key, _ := o.Attributes["APIKey"].(string)
+
+	return key
+}
+
+// SetAPIKey sets the API key of the root object.
+func (o *RootObject) SetAPIKey(key string) {
+
+	o.Set("APIKey", key)
+}
+
+// FetchObjects fetches the children of the given identity of the given parent as Objects.
+func FetchObjects(storer Storer, parent Identifiable, identity Identity, info *FetchingInfo) ([]*Object, *Error) {
+
+	var objects []*Object
+	if err := storer.FetchChildren(parent, identity, &objects, info); err != nil {
+		return nil, err
+	}
+
+	for _, object := range objects {
+		object.identity = identity
+	}
+
+	return objects, nil
+}
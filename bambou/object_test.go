@@ -0,0 +1,111 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestObject_Object(t *testing.T) {
+
+	Convey("Given I have an object", t, func() {
+
+		o := NewObject(FakeIdentity, "xxx")
+		o.Set("name", "a")
+
+		Convey("Then it should be identifiable", func() {
+			So(o.Identity(), ShouldResemble, FakeIdentity)
+			So(o.Identifier(), ShouldEqual, "xxx")
+			So(o.Get("name"), ShouldEqual, "a")
+		})
+
+		Convey("When I encode and decode it", func() {
+
+			data, _ := json.Marshal(o)
+			decoded := NewObject(FakeIdentity, "")
+			err := json.Unmarshal([]byte(`{"ID": "yyy", "count": 12}`), decoded)
+
+			Convey("Then it should be its attributes", func() {
+				So(string(data), ShouldEqual, `{"ID":"xxx","name":"a"}`)
+				So(err, ShouldBeNil)
+				So(decoded.Identifier(), ShouldEqual, "yyy")
+				So(decoded.Get("count"), ShouldEqual, json.Number("12"))
+			})
+		})
+	})
+}
+
+func TestObject_Session(t *testing.T) {
+
+	Convey("Given I have a session using objects", t, func() {
+
+		fs := newFakeServer()
+		defer fs.Close()
+		fs.add("parent", map[string]interface{}{"ID": "a", "name": "first"})
+
+		session := NewSession("username", "password", "organization", fs.URL, NewRootObject(Identity{Name: "me", Category: "me"}))
+
+		Convey("When I fetch children as objects", func() {
+
+			objects, err := FetchObjects(session, NewObject(FakeIdentity, "parent"), FakeIdentity, nil)
+
+			Convey("Then I should get them with their identity", func() {
+				So(err, ShouldBeNil)
+				So(len(objects), ShouldEqual, 1)
+				So(objects[0].Identity(), ShouldResemble, FakeIdentity)
+				So(objects[0].Get("name"), ShouldEqual, "first")
+			})
+		})
+
+		Convey("When I create and fetch an object", func() {
+
+			created := NewObject(FakeIdentity, "")
+			created.Set("name", "new")
+			err := session.CreateChild(NewObject(FakeIdentity, "parent"), created)
+
+			fetched := NewObject(FakeIdentity, created.Identifier())
+			session.FetchEntity(fetched)
+
+			Convey("Then it should be created", func() {
+				So(err, ShouldBeNil)
+				So(created.Identifier(), ShouldNotBeEmpty)
+				So(fetched.Get("name"), ShouldEqual, "new")
+			})
+		})
+
+		Convey("When I use a root object", func() {
+
+			root := NewRootObject(Identity{Name: "me", Category: "me"})
+			root.SetAPIKey("key")
+
+			Convey("Then it should be rootable", func() {
+				So(root.APIKey(), ShouldEqual, "key")
+				So(root.Identity().Name, ShouldEqual, "me")
+			})
+		})
+	})
+}
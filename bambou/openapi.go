@@ -0,0 +1,589 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of the exported documents.
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPIOptions are the options of the exported OpenAPI documents.
+type OpenAPIOptions struct {
+
+	// Title is the title of the API. The default is "bambou API".
+	Title string
+
+	// Version is the version of the API. The default is the version of the
+	// specifications, or "1.0".
+	Version string
+
+	// ServerURL is the URL of the API, like https://host:8443/nuage/api/v6, if known.
+	ServerURL string
+
+	// RootName is the ReST name of the root object, fetched to authenticate.
+	// The root is the root specification by default.
+	RootName string
+}
+
+// OpenAPIDocument is an OpenAPI document describing the entities and the operations
+// supported by the client. See SpecsOpenAPI and RegistryOpenAPI.
+type OpenAPIDocument struct {
+	OpenAPI    string                      `json:"openapi"`
+	Info       OpenAPIInfo                 `json:"info"`
+	Servers    []OpenAPIServer             `json:"servers,omitempty"`
+	Paths      map[string]*OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents           `json:"components"`
+	Security   []map[string][]string       `json:"security,omitempty"`
+}
+
+// OpenAPIInfo is the information about the API of an OpenAPIDocument.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIServer is a server of an OpenAPIDocument.
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIComponents are the reusable objects of an OpenAPIDocument.
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema         `json:"schemas"`
+	Parameters      map[string]*OpenAPIParameter      `json:"parameters,omitempty"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme is a security scheme of an OpenAPIDocument.
+type OpenAPISecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem holds the operations of a path.
+type OpenAPIPathItem struct {
+	Parameters []*OpenAPIParameter `json:"parameters,omitempty"`
+	Get        *OpenAPIOperation   `json:"get,omitempty"`
+	Put        *OpenAPIOperation   `json:"put,omitempty"`
+	Post       *OpenAPIOperation   `json:"post,omitempty"`
+	Delete     *OpenAPIOperation   `json:"delete,omitempty"`
+}
+
+// OpenAPIOperation is an operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a parameter of an operation, or a reference to one.
+type OpenAPIParameter struct {
+	Ref         string         `json:"$ref,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	In          string         `json:"in,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody is the body of a request.
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIHeader is a header of a response.
+type OpenAPIHeader struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIMediaType is the content of a request or a response.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema is a schema, or a reference to one.
+type OpenAPISchema struct {
+	Ref         string                    `json:"$ref,omitempty"`
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Enum        []string                  `json:"enum,omitempty"`
+	Items       *OpenAPISchema            `json:"items,omitempty"`
+	Properties  map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required    []string                  `json:"required,omitempty"`
+	ReadOnly    bool                      `json:"readOnly,omitempty"`
+	Nullable    bool                      `json:"nullable,omitempty"`
+	Deprecated  bool                      `json:"deprecated,omitempty"`
+	MinLength   *int                      `json:"minLength,omitempty"`
+	MaxLength   *int                      `json:"maxLength,omitempty"`
+	Minimum     *float64                  `json:"minimum,omitempty"`
+	Maximum     *float64                  `json:"maximum,omitempty"`
+	Pattern     string                    `json:"pattern,omitempty"`
+}
+
+// WriteJSON writes the document as indented JSON to the given writer.
+func (d *OpenAPIDocument) WriteJSON(w io.Writer) error {
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(d)
+}
+
+// SpecsOpenAPI returns the OpenAPI document of the given specifications: the
+// schemas of the entities, the operations on each entity, and the operations on
+// the children of each specification, according to their relationships.
+func SpecsOpenAPI(set *SpecSet, options OpenAPIOptions) *OpenAPIDocument {
+
+	if options.Version == "" {
+		options.Version = set.Version
+	}
+
+	d := newOpenAPIDocument(options)
+	specs := set.Specs()
+
+	for _, spec := range specs {
+		if spec.Model.Root && options.RootName == "" {
+			options.RootName = spec.Model.RESTName
+		}
+	}
+
+	for _, spec := range specs {
+
+		name := openAPIName(spec.Model.EntityName, spec.Model.RESTName)
+		identity := Identity{Name: spec.Model.RESTName, Category: spec.Model.ResourceName}
+		d.Components.Schemas[name] = specSchema(spec)
+
+		isRoot := spec.Model.RESTName == options.RootName
+		if isRoot {
+			d.addRoot(identity, name)
+		} else {
+			d.addEntity(identity, name, spec.Model.Description)
+		}
+
+		for _, child := range spec.Children {
+
+			childSpec := set.Spec(Identity{Name: child.RESTName})
+			if childSpec == nil {
+				continue
+			}
+
+			childIdentity := Identity{Name: childSpec.Model.RESTName, Category: childSpec.Model.ResourceName}
+			childName := openAPIName(childSpec.Model.EntityName, childSpec.Model.RESTName)
+			member := child.Relationship == "member"
+
+			d.addChildren(identity, name, isRoot, childIdentity, childName, child.Get, child.Create && !member, child.Update && member)
+		}
+	}
+
+	return d
+}
+
+// RegistryOpenAPI returns the OpenAPI document of the registered identities,
+// with the schemas inferred from the JSON attributes of the registered types.
+// As the registry does not know the relationships between the identities, only
+// the operations on each entity are described.
+func RegistryOpenAPI(options OpenAPIOptions) *OpenAPIDocument {
+
+	d := newOpenAPIDocument(options)
+
+	for _, identity := range RegisteredIdentities() {
+
+		name := openAPIName("", identity.Name)
+
+		object := NewIdentifiable(identity.Name)
+		if object == nil {
+			continue
+		}
+		d.Components.Schemas[name] = typeSchema(reflect.TypeOf(object))
+
+		if identity.Name == options.RootName {
+			d.addRoot(identity, name)
+		} else {
+			d.addEntity(identity, name, "")
+		}
+	}
+
+	return d
+}
+
+// newOpenAPIDocument returns a new *OpenAPIDocument with the common components.
+func newOpenAPIDocument(options OpenAPIOptions) *OpenAPIDocument {
+
+	if options.Title == "" {
+		options.Title = "bambou API"
+	}
+	if options.Version == "" {
+		options.Version = "1.0"
+	}
+
+	d := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    OpenAPIInfo{Title: options.Title, Version: options.Version},
+		Paths:   map[string]*OpenAPIPathItem{},
+		Components: OpenAPIComponents{
+			Schemas: map[string]*OpenAPISchema{
+				"Error": {
+					Type: "object",
+					Properties: map[string]*OpenAPISchema{
+						"errors":            {Type: "array", Items: &OpenAPISchema{Type: "object"}},
+						"internalErrorCode": {Type: "integer"},
+						"title":             {Type: "string"},
+						"description":       {Type: "string"},
+					},
+				},
+			},
+			Parameters: map[string]*OpenAPIParameter{
+				"organization":   {Name: "X-Nuage-Organization", In: "header", Required: true, Description: "organization of the user", Schema: &OpenAPISchema{Type: "string"}},
+				"id":             {Name: "id", In: "path", Required: true, Description: "ID of the object", Schema: &OpenAPISchema{Type: "string"}},
+				"filter":         {Name: "X-Nuage-Filter", In: "header", Description: "filter of the objects", Schema: &OpenAPISchema{Type: "string"}},
+				"orderBy":        {Name: "X-Nuage-OrderBy", In: "header", Description: "attribute to order the objects by", Schema: &OpenAPISchema{Type: "string"}},
+				"page":           {Name: "X-Nuage-Page", In: "header", Description: "page to fetch", Schema: &OpenAPISchema{Type: "integer"}},
+				"pageSize":       {Name: "X-Nuage-PageSize", In: "header", Description: "size of the page to fetch", Schema: &OpenAPISchema{Type: "integer"}},
+				"responseChoice": {Name: "responseChoice", In: "query", Description: "forces the modifications without confirmation", Schema: &OpenAPISchema{Type: "integer"}},
+			},
+			SecuritySchemes: map[string]*OpenAPISecurityScheme{
+				"XREST": {Type: "apiKey", In: "header", Name: "Authorization", Description: "XREST followed by the base64 encoding of username:password, or username:APIKey"},
+			},
+		},
+		Security: []map[string][]string{{"XREST": {}}},
+	}
+
+	if options.ServerURL != "" {
+		d.Servers = []OpenAPIServer{{URL: options.ServerURL}}
+	}
+
+	return d
+}
+
+// addRoot adds the operation authenticating by fetching the root object.
+func (d *OpenAPIDocument) addRoot(identity Identity, name string) {
+
+	d.Paths["/"+identity.Name] = &OpenAPIPathItem{
+		Parameters: []*OpenAPIParameter{openAPIParameterRef("organization")},
+		Get: &OpenAPIOperation{
+			OperationID: "authenticate",
+			Summary:     "Authenticates and returns the " + name + " with the API key",
+			Tags:        []string{name},
+			Responses:   openAPIResponses("200", "the "+name, openAPIList(name)),
+		},
+	}
+}
+
+// addEntity adds the operations on the entities with the given Identity.
+func (d *OpenAPIDocument) addEntity(identity Identity, name string, description string) {
+
+	summary := func(s string) string {
+		if description == "" {
+			return s
+		}
+		return s + ". " + description
+	}
+
+	d.Paths["/"+identity.Category+"/{id}"] = &OpenAPIPathItem{
+		Parameters: []*OpenAPIParameter{openAPIParameterRef("organization"), openAPIParameterRef("id")},
+		Get: &OpenAPIOperation{
+			OperationID: "get" + name,
+			Summary:     summary("Fetches a " + identity.Name),
+			Tags:        []string{name},
+			Responses:   openAPIResponses("200", "the "+identity.Name, openAPIList(name)),
+		},
+		Put: &OpenAPIOperation{
+			OperationID: "update" + name,
+			Summary:     "Updates a " + identity.Name,
+			Tags:        []string{name},
+			Parameters:  []*OpenAPIParameter{openAPIParameterRef("responseChoice")},
+			RequestBody: openAPIBody(&OpenAPISchema{Ref: openAPISchemaRef(name)}),
+			Responses:   openAPIResponses("200", "the updated "+identity.Name, openAPIList(name)),
+		},
+		Delete: &OpenAPIOperation{
+			OperationID: "delete" + name,
+			Summary:     "Deletes a " + identity.Name,
+			Tags:        []string{name},
+			Parameters:  []*OpenAPIParameter{openAPIParameterRef("responseChoice")},
+			Responses:   openAPIResponses("204", "the "+identity.Name+" is deleted", nil),
+		},
+	}
+}
+
+// addChildren adds the operations on the children with the given Identity of the given parent.
+func (d *OpenAPIDocument) addChildren(parent Identity, parentName string, isRoot bool, identity Identity, name string, get, create, assign bool) {
+
+	if !get && !create && !assign {
+		return
+	}
+
+	path := "/" + identity.Category
+	parameters := []*OpenAPIParameter{openAPIParameterRef("organization")}
+	prefix := ""
+	if !isRoot {
+		path = "/" + parent.Category + "/{id}" + path
+		parameters = append(parameters, openAPIParameterRef("id"))
+		prefix = parentName
+	}
+
+	item := &OpenAPIPathItem{Parameters: parameters}
+
+	if get {
+		responses := openAPIResponses("200", "the "+identity.Category, openAPIList(name))
+		responses["200"].Headers = map[string]*OpenAPIHeader{
+			"X-Nuage-Count": {Description: "total number of objects", Schema: &OpenAPISchema{Type: "integer"}},
+		}
+		responses["204"] = &OpenAPIResponse{Description: "there is no object"}
+
+		item.Get = &OpenAPIOperation{
+			OperationID: "list" + prefix + openAPIName("", identity.Category),
+			Summary:     "Fetches the " + identity.Category + " of the " + parent.Name,
+			Tags:        []string{name},
+			Parameters: []*OpenAPIParameter{
+				openAPIParameterRef("filter"),
+				openAPIParameterRef("orderBy"),
+				openAPIParameterRef("page"),
+				openAPIParameterRef("pageSize"),
+			},
+			Responses: responses,
+		}
+	}
+
+	if create {
+		item.Post = &OpenAPIOperation{
+			OperationID: "create" + prefix + name,
+			Summary:     "Creates a " + identity.Name + " under the " + parent.Name,
+			Tags:        []string{name},
+			RequestBody: openAPIBody(&OpenAPISchema{Ref: openAPISchemaRef(name)}),
+			Responses:   openAPIResponses("201", "the created "+identity.Name, openAPIList(name)),
+		}
+	}
+
+	if assign {
+		item.Put = &OpenAPIOperation{
+			OperationID: "assign" + prefix + openAPIName("", identity.Category),
+			Summary:     "Assigns the " + identity.Category + " with the given IDs to the " + parent.Name,
+			Tags:        []string{name},
+			RequestBody: openAPIBody(&OpenAPISchema{Type: "array", Items: &OpenAPISchema{Type: "string"}}),
+			Responses:   openAPIResponses("204", "the "+identity.Category+" are assigned", nil),
+		}
+	}
+
+	d.Paths[path] = item
+}
+
+// specSchema returns the schema of the given specification.
+func specSchema(spec *Spec) *OpenAPISchema {
+
+	schema := &OpenAPISchema{
+		Type:        "object",
+		Description: spec.Model.Description,
+		Properties:  map[string]*OpenAPISchema{},
+	}
+
+	for _, attribute := range spec.Attributes {
+
+		property := &OpenAPISchema{
+			Description: attribute.Description,
+			ReadOnly:    attribute.ReadOnly,
+			Deprecated:  attribute.Deprecated,
+			MinLength:   attribute.MinLength,
+			MaxLength:   attribute.MaxLength,
+			Minimum:     attribute.MinValue,
+			Maximum:     attribute.MaxValue,
+			Pattern:     attribute.AllowedChars,
+		}
+
+		switch attribute.Type {
+		case "enum":
+			property.Type = "string"
+			property.Enum = attribute.AllowedChoices
+		case "integer":
+			property.Type = "integer"
+		case "time":
+			property.Type, property.Format = "integer", "int64"
+		case "float":
+			property.Type = "number"
+		case "boolean":
+			property.Type = "boolean"
+		case "list":
+			property.Type = "array"
+			property.Items = &OpenAPISchema{Type: "object"}
+			if attribute.Subtype == "string" || attribute.Subtype == "enum" {
+				property.Items = &OpenAPISchema{Type: "string"}
+			}
+		case "object":
+			property.Type = "object"
+		default:
+			property.Type = "string"
+		}
+
+		if attribute.Required && !attribute.ReadOnly {
+			schema.Required = append(schema.Required, attribute.Name)
+		}
+
+		schema.Properties[attribute.Name] = property
+	}
+
+	return schema
+}
+
+var (
+	optionalTypeSchemas = map[reflect.Type]string{
+		reflect.TypeOf(String{}): "string",
+		reflect.TypeOf(Int{}):    "integer",
+		reflect.TypeOf(Float{}):  "number",
+		reflect.TypeOf(Bool{}):   "boolean",
+	}
+)
+
+// typeSchema returns the schema of the given Go type, as encoded in JSON.
+func typeSchema(t reflect.Type) *OpenAPISchema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if name, ok := optionalTypeSchemas[t]; ok {
+		return &OpenAPISchema{Type: name, Nullable: true}
+	}
+
+	switch t.Kind() {
+
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: typeSchema(t.Elem())}
+
+	case reflect.Struct:
+		schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+		collectTypeProperties(t, schema.Properties)
+		return schema
+	}
+
+	return &OpenAPISchema{Type: "object"}
+}
+
+// collectTypeProperties adds the schemas of the JSON attributes of the given struct type to the given properties.
+func collectTypeProperties(t reflect.Type, properties map[string]*OpenAPISchema) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			collectTypeProperties(field.Type, properties)
+			continue
+		}
+
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		properties[fieldName(field)] = typeSchema(field.Type)
+	}
+}
+
+// openAPIName returns the name of the schema of an entity.
+func openAPIName(entityName string, restName string) string {
+
+	if entityName != "" {
+		return entityName
+	}
+
+	if restName == "" {
+		return restName
+	}
+
+	return strings.ToUpper(restName[:1]) + restName[1:]
+}
+
+func openAPISchemaRef(name string) string {
+
+	return "#/components/schemas/" + name
+}
+
+func openAPIParameterRef(name string) *OpenAPIParameter {
+
+	return &OpenAPIParameter{Ref: "#/components/parameters/" + name}
+}
+
+// openAPIList returns the schema of a list of the objects of the given schema, as returned by the server.
+func openAPIList(name string) *OpenAPISchema {
+
+	return &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Ref: openAPISchemaRef(name)}}
+}
+
+func openAPIBody(schema *OpenAPISchema) *OpenAPIRequestBody {
+
+	return &OpenAPIRequestBody{
+		Required: true,
+		Content:  map[string]*OpenAPIMediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// openAPIResponses returns the responses of an operation, with the given successful
+// response and the error responses.
+func openAPIResponses(status string, description string, schema *OpenAPISchema) map[string]*OpenAPIResponse {
+
+	success := &OpenAPIResponse{Description: description}
+	if schema != nil {
+		success.Content = map[string]*OpenAPIMediaType{"application/json": {Schema: schema}}
+	}
+
+	return map[string]*OpenAPIResponse{
+		status: success,
+		"default": {
+			Description: "an error",
+			Content:     map[string]*OpenAPIMediaType{"application/json": {Schema: &OpenAPISchema{Ref: openAPISchemaRef("Error")}}},
+		},
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var regexpRefs = regexp.MustCompile(`"\$ref": "#/components/([^"]+)"`)
+
+func TestOpenAPI_SpecsOpenAPI(t *testing.T) {
+
+	Convey("Given I have a set of specifications", t, func() {
+
+		load := func(data string) *Spec {
+			spec, _ := LoadSpec(strings.NewReader(data))
+			return spec
+		}
+
+		set := NewSpecSet("6.0",
+			load(`{
+				"model": {"rest_name": "me", "resource_name": "me", "entity_name": "Me", "root": true},
+				"children": [{"rest_name": "enterprise", "relationship": "root", "get": true, "create": true}]
+			}`),
+			load(`{
+				"model": {"rest_name": "enterprise", "resource_name": "enterprises", "entity_name": "Enterprise", "description": "An enterprise."},
+				"attributes": [
+					{"name": "ID", "type": "string", "read_only": true},
+					{"name": "name", "type": "string", "required": true, "max_length": 255},
+					{"name": "kind", "type": "enum", "allowed_choices": ["A", "B"]},
+					{"name": "tags", "type": "list", "subtype": "string"},
+					{"name": "creationDate", "type": "time"}
+				],
+				"children": [
+					{"rest_name": "fake", "relationship": "child", "get": true, "create": true},
+					{"rest_name": "user", "relationship": "member", "get": true, "update": true}
+				]
+			}`),
+			load(`{"model": {"rest_name": "fake", "resource_name": "fakes", "entity_name": "Fake"}}`),
+			load(`{"model": {"rest_name": "user", "resource_name": "users", "entity_name": "User"}}`),
+		)
+
+		Convey("When I export its OpenAPI document", func() {
+
+			d := SpecsOpenAPI(set, OpenAPIOptions{ServerURL: "https://host:8443/nuage/api/v6"})
+
+			Convey("Then it should describe the entities", func() {
+				So(d.OpenAPI, ShouldEqual, OpenAPIVersion)
+				So(d.Info.Version, ShouldEqual, "6.0")
+				So(d.Servers[0].URL, ShouldEqual, "https://host:8443/nuage/api/v6")
+
+				schema := d.Components.Schemas["Enterprise"]
+				So(schema, ShouldNotBeNil)
+				So(schema.Description, ShouldEqual, "An enterprise.")
+				So(schema.Required, ShouldResemble, []string{"name"})
+				So(schema.Properties["ID"].ReadOnly, ShouldBeTrue)
+				So(*schema.Properties["name"].MaxLength, ShouldEqual, 255)
+				So(schema.Properties["kind"].Enum, ShouldResemble, []string{"A", "B"})
+				So(schema.Properties["tags"].Items.Type, ShouldEqual, "string")
+				So(schema.Properties["creationDate"].Format, ShouldEqual, "int64")
+			})
+
+			Convey("Then it should describe the operations", func() {
+				So(d.Paths["/me"].Get.OperationID, ShouldEqual, "authenticate")
+				So(d.Paths["/me/{id}"], ShouldBeNil)
+				So(d.Paths["/enterprises"].Get.OperationID, ShouldEqual, "listEnterprises")
+				So(d.Paths["/enterprises"].Post.OperationID, ShouldEqual, "createEnterprise")
+				So(d.Paths["/enterprises/{id}"].Get.OperationID, ShouldEqual, "getEnterprise")
+				So(d.Paths["/enterprises/{id}"].Put, ShouldNotBeNil)
+				So(d.Paths["/enterprises/{id}"].Delete, ShouldNotBeNil)
+				So(d.Paths["/enterprises/{id}/fakes"].Post.OperationID, ShouldEqual, "createEnterpriseFake")
+				So(d.Paths["/enterprises/{id}/users"].Put.OperationID, ShouldEqual, "assignEnterpriseUsers")
+				So(d.Paths["/enterprises/{id}/users"].Post, ShouldBeNil)
+				So(d.Paths["/enterprises/{id}/fakes"].Get.Responses["200"].Headers["X-Nuage-Count"], ShouldNotBeNil)
+			})
+
+			Convey("Then its references should be defined", func() {
+
+				var buffer bytes.Buffer
+				So(d.WriteJSON(&buffer), ShouldBeNil)
+
+				var decoded map[string]interface{}
+				So(json.Unmarshal(buffer.Bytes(), &decoded), ShouldBeNil)
+
+				for _, ref := range regexpRefs.FindAllStringSubmatch(buffer.String(), -1) {
+					path := strings.Split(ref[1], "/")
+					So(decoded["components"].(map[string]interface{})[path[0]].(map[string]interface{})[path[1]], ShouldNotBeNil)
+				}
+			})
+		})
+	})
+}
+
+func TestOpenAPI_RegistryOpenAPI(t *testing.T) {
+
+	Convey("Given I have registered identities", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &optionalObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		Convey("When I export their OpenAPI document", func() {
+
+			d := RegistryOpenAPI(OpenAPIOptions{Title: "Fakes"})
+
+			Convey("Then it should describe the registered types", func() {
+				So(d.Info.Title, ShouldEqual, "Fakes")
+
+				schema := d.Components.Schemas["Fake"]
+				So(schema, ShouldNotBeNil)
+				So(schema.Properties["ID"].Type, ShouldEqual, "string")
+				So(schema.Properties["priority"].Type, ShouldEqual, "integer")
+				So(schema.Properties["priority"].Nullable, ShouldBeTrue)
+
+				So(d.Paths["/fakes/{id}"].Get.OperationID, ShouldEqual, "getFake")
+			})
+		})
+	})
+}
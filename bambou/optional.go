@@ -0,0 +1,305 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Optional is implemented by the tri-state attribute types String, Int, Float and Bool.
+// Those types make the difference between an unset attribute, that is not sent to the
+// server and left untouched, a null attribute, that is sent as null to clear it, and an
+// attribute with a value. Their zero value is unset.
+// The unset attributes are omitted when the Session encodes an object.
+type Optional interface {
+	IsSet() bool
+	IsNull() bool
+}
+
+type optionalState int
+
+const (
+	optionalUnset optionalState = iota
+	optionalNull
+	optionalValue
+)
+
+// String is a tri-state string attribute. See Optional.
+type String struct {
+	value string
+	state optionalState
+}
+
+// NewString returns a String set to the given value.
+func NewString(value string) String { return String{value: value, state: optionalValue} }
+
+// NullString returns a String set to null.
+func NullString() String { return String{state: optionalNull} }
+
+// Value returns the value of the String, or "" if it is unset or null.
+func (s String) Value() string { return s.value }
+
+// IsSet returns true if the String is null or has a value.
+func (s String) IsSet() bool { return s.state != optionalUnset }
+
+// IsNull returns true if the String is null.
+func (s String) IsNull() bool { return s.state == optionalNull }
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s String) MarshalJSON() ([]byte, error) {
+
+	if s.state != optionalValue {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(s.value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *String) UnmarshalJSON(data []byte) error {
+
+	if string(data) == "null" {
+		*s = NullString()
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*s = NewString(value)
+
+	return nil
+}
+
+// Int is a tri-state integer attribute. See Optional.
+type Int struct {
+	value int
+	state optionalState
+}
+
+// NewInt returns an Int set to the given value.
+func NewInt(value int) Int { return Int{value: value, state: optionalValue} }
+
+// NullInt returns an Int set to null.
+func NullInt() Int { return Int{state: optionalNull} }
+
+// Value returns the value of the Int, or 0 if it is unset or null.
+func (i Int) Value() int { return i.value }
+
+// IsSet returns true if the Int is null or has a value.
+func (i Int) IsSet() bool { return i.state != optionalUnset }
+
+// IsNull returns true if the Int is null.
+func (i Int) IsNull() bool { return i.state == optionalNull }
+
+// MarshalJSON implements the json.Marshaler interface.
+func (i Int) MarshalJSON() ([]byte, error) {
+
+	if i.state != optionalValue {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(i.value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (i *Int) UnmarshalJSON(data []byte) error {
+
+	if string(data) == "null" {
+		*i = NullInt()
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*i = NewInt(value)
+
+	return nil
+}
+
+// Float is a tri-state floating point attribute. See Optional.
+type Float struct {
+	value float64
+	state optionalState
+}
+
+// NewFloat returns a Float set to the given value.
+func NewFloat(value float64) Float { return Float{value: value, state: optionalValue} }
+
+// NullFloat returns a Float set to null.
+func NullFloat() Float { return Float{state: optionalNull} }
+
+// Value returns the value of the Float, or 0 if it is unset or null.
+func (f Float) Value() float64 { return f.value }
+
+// IsSet returns true if the Float is null or has a value.
+func (f Float) IsSet() bool { return f.state != optionalUnset }
+
+// IsNull returns true if the Float is null.
+func (f Float) IsNull() bool { return f.state == optionalNull }
+
+// MarshalJSON implements the json.Marshaler interface.
+func (f Float) MarshalJSON() ([]byte, error) {
+
+	if f.state != optionalValue {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(f.value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (f *Float) UnmarshalJSON(data []byte) error {
+
+	if string(data) == "null" {
+		*f = NullFloat()
+		return nil
+	}
+
+	var value float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*f = NewFloat(value)
+
+	return nil
+}
+
+// Bool is a tri-state boolean attribute. See Optional.
+type Bool struct {
+	value bool
+	state optionalState
+}
+
+// NewBool returns a Bool set to the given value.
+func NewBool(value bool) Bool { return Bool{value: value, state: optionalValue} }
+
+// NullBool returns a Bool set to null.
+func NullBool() Bool { return Bool{state: optionalNull} }
+
+// Value returns the value of the Bool, or false if it is unset or null.
+func (b Bool) Value() bool { return b.value }
+
+// IsSet returns true if the Bool is null or has a value.
+func (b Bool) IsSet() bool { return b.state != optionalUnset }
+
+// IsNull returns true if the Bool is null.
+func (b Bool) IsNull() bool { return b.state == optionalNull }
+
+// MarshalJSON implements the json.Marshaler interface.
+func (b Bool) MarshalJSON() ([]byte, error) {
+
+	if b.state != optionalValue {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(b.value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (b *Bool) UnmarshalJSON(data []byte) error {
+
+	if string(data) == "null" {
+		*b = NullBool()
+		return nil
+	}
+
+	var value bool
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*b = NewBool(value)
+
+	return nil
+}
+
+var optionalType = reflect.TypeOf((*Optional)(nil)).Elem()
+
+// unsetAttributes returns the JSON names of the unset Optional attributes of the given object.
+func unsetAttributes(object interface{}) []string {
+
+	v := reflect.ValueOf(object)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return unsetStructAttributes(v)
+}
+
+func unsetStructAttributes(v reflect.Value) []string {
+
+	var names []string
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			names = append(names, unsetStructAttributes(v.Field(i))...)
+			continue
+		}
+
+		if field.Type.Implements(optionalType) && !v.Field(i).Interface().(Optional).IsSet() {
+			names = append(names, fieldName(field))
+		}
+	}
+
+	return names
+}
+
+// omitUnsetAttributes removes the unset Optional attributes of the given object from its given JSON encoding.
+func omitUnsetAttributes(object interface{}, data []byte) ([]byte, error) {
+
+	names := unsetAttributes(object)
+	if len(names) == 0 {
+		return data, nil
+	}
+
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		delete(attributes, name)
+	}
+
+	return json.Marshal(attributes)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type optionalObject struct {
+	FakeObject
+
+	Description String `json:"description"`
+	Priority    Int    `json:"priority"`
+	Ratio       Float  `json:"ratio"`
+	Enabled     Bool   `json:"enabled"`
+}
+
+func TestOptional_JSON(t *testing.T) {
+
+	Convey("Given I have an object with tri-state attributes", t, func() {
+
+		o := &optionalObject{Description: NewString(""), Priority: NewInt(0), Ratio: NullFloat()}
+
+		Convey("When I encode it", func() {
+
+			data, err := json.Marshal(o)
+
+			Convey("Then the unset and null attributes should be null", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"ID":"","name":"","description":"","priority":0,"ratio":null,"enabled":null}`)
+			})
+		})
+
+		Convey("When I decode values, null and missing attributes", func() {
+
+			decoded := &optionalObject{}
+			err := json.Unmarshal([]byte(`{"description": "d", "priority": 3, "ratio": null}`), decoded)
+
+			Convey("Then I should get their states", func() {
+				So(err, ShouldBeNil)
+				So(decoded.Description.IsSet(), ShouldBeTrue)
+				So(decoded.Description.Value(), ShouldEqual, "d")
+				So(decoded.Priority.Value(), ShouldEqual, 3)
+				So(decoded.Ratio.IsSet(), ShouldBeTrue)
+				So(decoded.Ratio.IsNull(), ShouldBeTrue)
+				So(decoded.Enabled.IsSet(), ShouldBeFalse)
+				So(decoded.Enabled.IsNull(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When I decode an invalid value", func() {
+
+			err := json.Unmarshal([]byte(`{"enabled": "yes"}`), &optionalObject{})
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestOptional_omitUnsetAttributes(t *testing.T) {
+
+	Convey("Given I have an object with unset attributes", t, func() {
+
+		o := &optionalObject{FakeObject: FakeObject{ID: "x"}, Priority: NewInt(1), Enabled: NullBool()}
+		data, _ := json.Marshal(o)
+
+		Convey("When I omit them", func() {
+
+			data, err := omitUnsetAttributes(o, data)
+
+			Convey("Then only the set attributes should remain", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"ID":"x","enabled":null,"name":"","priority":1}`)
+			})
+		})
+
+		Convey("When I omit them from an object without tri-state attributes", func() {
+
+			data, err := omitUnsetAttributes(NewFakeObject("x"), []byte(`{"ID":"x"}`))
+
+			Convey("Then the encoding should be untouched", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"ID":"x"}`)
+			})
+		})
+	})
+}
+
+func TestOptional_Session(t *testing.T) {
+
+	Convey("Given I have a server with an object", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "x", "description": "old", "priority": 2.0, "enabled": true})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I save it with an unset and a cleared attribute", func() {
+
+			o := &optionalObject{FakeObject: FakeObject{ID: "x"}, Description: NullString(), Enabled: NewBool(false)}
+			err := session.SaveEntity(o)
+
+			Convey("Then only the set attributes should be changed", func() {
+				So(err, ShouldBeNil)
+				So(ts.get("x")["description"], ShouldBeNil)
+				So(ts.get("x")["priority"], ShouldEqual, 2)
+				So(ts.get("x")["enabled"], ShouldEqual, false)
+				So(o.Priority.Value(), ShouldEqual, 2)
+			})
+		})
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+// OTPHeader is the header carrying the one time password of the authentication
+// when the server requires a two-factor authentication.
+const OTPHeader = "X-Nuage-OTP"
+
+// OTPFunc returns the one time password of the user, like the code of an
+// authenticator application. See Session.SetOTP.
+type OTPFunc func() (string, error)
+
+// SetOTP sets the function collecting the one time password of the user when the
+// server requires a two-factor authentication. It is called by Start, and the one
+// time password is sent in the X-Nuage-OTP header of the authentication request.
+// The following requests authenticate with the API key. Use nil to remove it.
+func (s *Session) SetOTP(otp OTPFunc) {
+
+	s.otp = otp
+}
+
+// collectOTP collects the one time password of the authentication, if the session has an OTPFunc.
+func (s *Session) collectOTP() *Error {
+
+	if s.otp == nil {
+		return nil
+	}
+
+	code, err := s.otp()
+	if err != nil {
+		return NewBambouError("Invalid Credentials", "unable to get the one time password: "+err.Error())
+	}
+
+	if code == "" {
+		return NewBambouError("Invalid Credentials", "no one time password given")
+	}
+
+	s.otpCode = code
+
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetOTP(t *testing.T) {
+
+	Convey("Given I have a server requiring a two-factor authentication", t, func() {
+
+		var codes []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			codes = append(codes, r.Header.Get(OTPHeader))
+
+			if r.URL.Path == "/root" && r.Header.Get(OTPHeader) != "123456" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path == "/root" {
+				w.Write([]byte(`[{"ID": "root", "APIKey": "key"}]`))
+				return
+			}
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		root := NewFakeRootObject()
+		session := NewSession("username", "password", "organization", ts.URL, root)
+
+		Convey("When I start the session with the one time password", func() {
+
+			var calls int
+			session.SetOTP(func() (string, error) {
+				calls++
+				return "123456", nil
+			})
+
+			err := session.Start()
+			fetchErr := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the one time password should be sent with the authentication only", func() {
+				So(err, ShouldBeNil)
+				So(fetchErr, ShouldBeNil)
+				So(calls, ShouldEqual, 1)
+				So(root.APIKey(), ShouldEqual, "key")
+				So(codes, ShouldResemble, []string{"123456", ""})
+			})
+		})
+
+		Convey("When I start the session without one time password", func() {
+
+			err := session.Start()
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+		})
+
+		Convey("When the one time password cannot be collected", func() {
+
+			session.SetOTP(func() (string, error) {
+				return "", fmt.Errorf("canceled")
+			})
+
+			err := session.Start()
+
+			Convey("Then I should get an error without authenticating", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Description, ShouldEqual, "unable to get the one time password: canceled")
+				So(codes, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestSession_OTPRedacted(t *testing.T) {
+
+	Convey("Given I have a session with a one time password, a logger and the debug dumps", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"ID": "root", "APIKey": "key"}]`))
+		}))
+		defer ts.Close()
+
+		var dump bytes.Buffer
+		logger := &recordingLogger{}
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetOTP(func() (string, error) { return "123456", nil })
+		session.SetLogger(logger)
+		session.SetDebug(&dump)
+		session.SetDebugCurl(true)
+
+		Convey("When I start the session", func() {
+
+			err := session.Start()
+			logs := strings.Join(logger.logs, "\n")
+			header := http.CanonicalHeaderKey(OTPHeader)
+
+			Convey("Then the one time password should be redacted everywhere", func() {
+				So(err, ShouldBeNil)
+				So(logs, ShouldContainSubstring, header+":REDACTED")
+				So(dump.String(), ShouldContainSubstring, "-H '"+header+": REDACTED'")
+				So(dump.String(), ShouldContainSubstring, header+": REDACTED\r\n")
+				So(logs, ShouldNotContainSubstring, "123456")
+				So(dump.String(), ShouldNotContainSubstring, "123456")
+			})
+		})
+	})
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+)
+
+// PermissionIdentity is the Identity of the permissions.
+var PermissionIdentity = Identity{
+	Name:     "permission",
+	Category: "permissions",
+}
+
+// PermissionLevel is the action a permission permits.
+type PermissionLevel string
+
+// Supported values for PermissionLevel.
+const (
+	PermissionRead        PermissionLevel = "READ"
+	PermissionUse         PermissionLevel = "USE"
+	PermissionExtend      PermissionLevel = "EXTEND"
+	PermissionDeploy      PermissionLevel = "DEPLOY"
+	PermissionInstantiate PermissionLevel = "INSTANTIATE"
+	PermissionAll         PermissionLevel = "ALL"
+)
+
+// Permission is a permission granted to an entity, like a group or a user, on an object.
+type Permission struct {
+	ID                         string          `json:"ID,omitempty"`
+	Name                       string          `json:"name,omitempty"`
+	PermittedAction            PermissionLevel `json:"permittedAction"`
+	PermittedEntityID          string          `json:"permittedEntityID"`
+	PermittedEntityName        string          `json:"permittedEntityName,omitempty"`
+	PermittedEntityType        string          `json:"permittedEntityType,omitempty"`
+	PermittedEntityDescription string          `json:"permittedEntityDescription,omitempty"`
+}
+
+// Identity returns the Identity of the permission.
+func (p *Permission) Identity() Identity {
+
+	return PermissionIdentity
+}
+
+// Identifier returns the ID of the permission.
+func (p *Permission) Identifier() string {
+
+	return p.ID
+}
+
+// SetIdentifier sets the ID of the permission.
+func (p *Permission) SetIdentifier(ID string) {
+
+	p.ID = ID
+}
+
+// ListPermissions returns the permissions granted on the given object.
+func ListPermissions(storer Storer, object Identifiable) ([]*Permission, *Error) {
+
+	return fetchPermissions(storer, object, "")
+}
+
+// GrantPermission grants the given level of permission on the given object to the given
+// entity, like a group, and returns the permission. If the entity already has a permission
+// with another level on the object, the level of the permission is changed.
+func GrantPermission(storer Storer, object Identifiable, entity Identifiable, level PermissionLevel) (*Permission, *Error) {
+
+	if entity.Identifier() == "" {
+		return nil, NewBambouError("Invalid entity", fmt.Sprintf("%s has no ID", entity.Identity().Name))
+	}
+
+	permissions, err := fetchPermissions(storer, object, entity.Identifier())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(permissions) == 0 {
+
+		permission := &Permission{
+			PermittedAction:     level,
+			PermittedEntityID:   entity.Identifier(),
+			PermittedEntityType: entity.Identity().Name,
+		}
+
+		if err := storer.CreateChild(object, permission); err != nil {
+			return nil, err
+		}
+
+		return permission, nil
+	}
+
+	permission := permissions[0]
+	if permission.PermittedAction == level {
+		return permission, nil
+	}
+
+	permission.PermittedAction = level
+	if err := storer.SaveEntity(permission); err != nil {
+		return nil, err
+	}
+
+	return permission, nil
+}
+
+// RevokePermission revokes the permissions granted on the given object to the given entity, if any.
+func RevokePermission(storer Storer, object Identifiable, entity Identifiable) *Error {
+
+	if entity.Identifier() == "" {
+		return NewBambouError("Invalid entity", fmt.Sprintf("%s has no ID", entity.Identity().Name))
+	}
+
+	permissions, err := fetchPermissions(storer, object, entity.Identifier())
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range permissions {
+		if err := storer.DeleteEntity(permission); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchPermissions returns the permissions granted on the given object,
+// to the entity with the given ID if it is not empty.
+func fetchPermissions(storer Storer, object Identifiable, entityID string) ([]*Permission, *Error) {
+
+	info := NewFetchingInfo()
+	if entityID != "" {
+		info.Filter = fmt.Sprintf(`permittedEntityID == "%s"`, entityID)
+	}
+
+	var permissions []*Permission
+	if err := storer.FetchChildren(object, PermissionIdentity, &permissions, info); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
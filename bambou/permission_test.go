@@ -0,0 +1,110 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPermission_Permissions(t *testing.T) {
+
+	Convey("Given I have a server with an object with a permission", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "x"})
+		ts.add("x", map[string]interface{}{"ID": "p1", "permittedEntityID": "g1", "permittedAction": "READ"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		o := NewFakeObject("x")
+
+		Convey("When I list the permissions", func() {
+
+			permissions, err := ListPermissions(session, o)
+
+			Convey("Then I should get them", func() {
+				So(err, ShouldBeNil)
+				So(permissions, ShouldHaveLength, 1)
+				So(permissions[0].PermittedAction, ShouldEqual, PermissionRead)
+			})
+		})
+
+		Convey("When I grant a permission to another group", func() {
+
+			permission, err := GrantPermission(session, o, NewFakeObject("g2"), PermissionUse)
+
+			Convey("Then it should be created", func() {
+				So(err, ShouldBeNil)
+				So(ts.count(), ShouldEqual, 3)
+				So(ts.get(permission.ID)["permittedEntityID"], ShouldEqual, "g2")
+				So(ts.get(permission.ID)["permittedAction"], ShouldEqual, "USE")
+				So(ts.get(permission.ID)["permittedEntityType"], ShouldEqual, "fake")
+			})
+		})
+
+		Convey("When I grant the same permission again", func() {
+
+			permission, err := GrantPermission(session, o, NewFakeObject("g1"), PermissionRead)
+
+			Convey("Then nothing should change", func() {
+				So(err, ShouldBeNil)
+				So(permission.ID, ShouldEqual, "p1")
+				So(ts.methods(), ShouldResemble, []string{"GET"})
+			})
+		})
+
+		Convey("When I grant another level to the group", func() {
+
+			permission, err := GrantPermission(session, o, NewFakeObject("g1"), PermissionAll)
+
+			Convey("Then the permission should be updated", func() {
+				So(err, ShouldBeNil)
+				So(permission.ID, ShouldEqual, "p1")
+				So(ts.get("p1")["permittedAction"], ShouldEqual, "ALL")
+			})
+		})
+
+		Convey("When I revoke the permission", func() {
+
+			err := RevokePermission(session, o, NewFakeObject("g1"))
+
+			Convey("Then it should be deleted", func() {
+				So(err, ShouldBeNil)
+				So(ts.get("p1"), ShouldBeNil)
+			})
+		})
+
+		Convey("When I grant a permission to an entity without ID", func() {
+
+			_, err := GrantPermission(session, o, NewFakeObject(""), PermissionRead)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(ts.methods(), ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// PublicKeyHash returns the pin of the public key of the given certificate: the
+// base64 encoded SHA-256 of its DER encoded SubjectPublicKeyInfo, as computed by
+//
+//	openssl x509 -in vsd.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+func PublicKeyHash(cert *x509.Certificate) string {
+
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// CertificateHash returns the pin of the given certificate: the base64 encoded
+// SHA-256 of its DER encoding.
+func CertificateHash(cert *x509.Certificate) string {
+
+	sum := sha256.Sum256(cert.Raw)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SetPinnedCertificates makes the session accept only the servers whose certificate,
+// or its public key, has one of the given pins, as returned by PublicKeyHash and
+// CertificateHash. The pins may be prefixed with "sha256/" or "sha256//", like the
+// ones of curl. It allows to connect safely to a server whose CA is not available,
+// as the certificate chain of the server is not verified. Pinning the public key
+// keeps working when the certificate is renewed with the same key. Without pins,
+// the certificate of the server is not checked. It returns an error if a pin is
+// not a valid SHA-256 hash. It must be called before the session is used.
+func (s *Session) SetPinnedCertificates(pins ...string) *Error {
+
+	if s.transport == nil {
+		return NewBambouError("Invalid session", "the transport of the session is not configurable")
+	}
+
+	pinned := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+
+		pin = strings.TrimPrefix(strings.TrimPrefix(pin, "sha256/"), "/")
+
+		sum, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(sum) != sha256.Size {
+			return NewBambouError("Invalid pin", fmt.Sprintf("%s is not a base64 encoded SHA-256 hash", pin))
+		}
+
+		pinned[pin] = true
+	}
+
+	if len(pinned) == 0 {
+		pinned = nil
+	}
+
+	s.pinnedCertificates = pinned
+	s.updateVerifyPeerCertificate()
+
+	return nil
+}
+
+// updateVerifyPeerCertificate sets the verification of the certificate of the server
+// on the TLS configuration of the session.
+func (s *Session) updateVerifyPeerCertificate() {
+
+	config := &tls.Config{}
+	if s.transport.TLSClientConfig != nil {
+		config = s.transport.TLSClientConfig.Clone()
+	}
+
+	config.VerifyPeerCertificate = nil
+	if s.pinnedCertificates != nil || s.verifyPeer != nil {
+		config.VerifyPeerCertificate = s.verifyPeerCertificate
+	}
+
+	s.transport.TLSClientConfig = config
+}
+
+// verifyPeerCertificate checks the certificate of the server against the pins of the session,
+// then with its VerifyPeerCertificateFunc. Only the leaf certificate is checked against the pins,
+// as the chain sent by the server is not verified.
+func (s *Session) verifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("the server sent no certificate")
+	}
+
+	if s.pinnedCertificates != nil {
+		if err := s.checkPins(rawCerts[0]); err != nil {
+			return err
+		}
+	}
+
+	if s.verifyPeer != nil {
+		return s.verifyPeer(rawCerts, verifiedChains)
+	}
+
+	return nil
+}
+
+// checkPins returns an error if the given DER encoded certificate matches none of the pins of the session.
+func (s *Session) checkPins(rawCert []byte) error {
+
+	leaf, err := x509.ParseCertificate(rawCert)
+	if err != nil {
+		return err
+	}
+
+	if !s.pinnedCertificates[PublicKeyHash(leaf)] && !s.pinnedCertificates[CertificateHash(leaf)] {
+		return fmt.Errorf("the certificate of the server %s matches none of the pinned certificates", leaf.Subject)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetPinnedCertificates(t *testing.T) {
+
+	Convey("Given I have a TLS server and a session", t, func() {
+
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		other := sha256.Sum256([]byte("other"))
+
+		Convey("When I pin the public key of the server", func() {
+
+			So(session.SetPinnedCertificates("sha256//"+PublicKeyHash(ts.Certificate())), ShouldBeNil)
+
+			Convey("Then the request should succeed", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+			})
+		})
+
+		Convey("When I pin the certificate of the server", func() {
+
+			So(session.SetPinnedCertificates(base64.StdEncoding.EncodeToString(other[:]), CertificateHash(ts.Certificate())), ShouldBeNil)
+
+			Convey("Then the request should succeed", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+			})
+		})
+
+		Convey("When I pin another certificate", func() {
+
+			So(session.SetPinnedCertificates(base64.StdEncoding.EncodeToString(other[:])), ShouldBeNil)
+
+			Convey("Then the request should fail", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldNotBeNil)
+			})
+
+			Convey("When I remove the pins", func() {
+
+				So(session.SetPinnedCertificates(), ShouldBeNil)
+
+				Convey("Then the request should succeed", func() {
+					So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+				})
+			})
+		})
+
+		Convey("When I pin an invalid hash", func() {
+
+			err := session.SetPinnedCertificates("sha256/abcd")
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Invalid pin")
+			})
+		})
+	})
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package prommetrics reports the metrics of bambou to Prometheus. Its Collector
+// implements bambou.Metrics, to be given to Session.SetMetrics and PushCenter.SetMetrics,
+// and prometheus.Collector, to be registered to a prometheus.Registerer:
+//
+//	collector := prommetrics.NewCollector(nil)
+//	prometheus.MustRegister(collector)
+//	session.SetMetrics(collector)
+package prommetrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultBuckets are the default buckets of the histograms, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// help are the descriptions of the known metrics.
+var help = map[string]string{
+	bambou.MetricRequests:         "Number of requests sent to the server, by method, identity and status.",
+	bambou.MetricRequestDuration:  "Duration of the requests sent to the server, by method and identity.",
+	bambou.MetricRequestRetries:   "Number of requests retried, by method and identity.",
+	bambou.MetricRequestsInFlight: "Number of requests waiting for a response.",
+	bambou.MetricRequestBytes:     "Number of bytes sent in the bodies of the requests, by method and identity.",
+	bambou.MetricResponseBytes:    "Number of bytes received in the bodies of the responses, by method and identity.",
+	bambou.MetricEventsReceived:   "Number of events received, by entity type and event type.",
+	bambou.MetricEventDispatch:    "Duration of the dispatch of the events to the handlers, by entity type.",
+	bambou.MetricEventLag:         "Lag between the reception of the last event by the server and its dispatch.",
+	bambou.MetricEventReconnects:  "Number of reconnections of the event stream.",
+	bambou.MetricEventStreamStale: "Number of times the event stream was stale.",
+}
+
+// Collector is a bambou.Metrics and a prometheus.Collector. The metrics are created
+// on their first report, with the labels they are first reported with: the reports
+// of a metric with other labels are dropped.
+type Collector struct {
+	buckets    []float64
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+	lock       sync.RWMutex
+}
+
+// NewCollector returns a new *Collector, with histograms using the given buckets,
+// or DefaultBuckets if nil.
+func NewCollector(buckets []float64) *Collector {
+
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	return &Collector{
+		buckets:    buckets,
+		counters:   map[string]*prometheus.CounterVec{},
+		gauges:     map[string]*prometheus.GaugeVec{},
+		histograms: map[string]*prometheus.HistogramVec{},
+	}
+}
+
+// AddCounter implements the bambou.Metrics interface.
+func (c *Collector) AddCounter(name string, labels bambou.Labels, value float64) {
+
+	c.lock.Lock()
+	vec, ok := c.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: helpOf(name)}, labelNames(labels))
+		c.counters[name] = vec
+	}
+	c.lock.Unlock()
+
+	if counter, err := vec.GetMetricWith(prometheus.Labels(labels)); err == nil {
+		counter.Add(value)
+	}
+}
+
+// SetGauge implements the bambou.Metrics interface.
+func (c *Collector) SetGauge(name string, labels bambou.Labels, value float64) {
+
+	c.lock.Lock()
+	vec, ok := c.gauges[name]
+	if !ok {
+		vec = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: helpOf(name)}, labelNames(labels))
+		c.gauges[name] = vec
+	}
+	c.lock.Unlock()
+
+	if gauge, err := vec.GetMetricWith(prometheus.Labels(labels)); err == nil {
+		gauge.Set(value)
+	}
+}
+
+// ObserveDuration implements the bambou.Metrics interface. The durations are observed in seconds.
+func (c *Collector) ObserveDuration(name string, labels bambou.Labels, duration time.Duration) {
+
+	c.lock.Lock()
+	vec, ok := c.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: helpOf(name), Buckets: c.buckets}, labelNames(labels))
+		c.histograms[name] = vec
+	}
+	c.lock.Unlock()
+
+	if histogram, err := vec.GetMetricWith(prometheus.Labels(labels)); err == nil {
+		histogram.Observe(duration.Seconds())
+	}
+}
+
+// Describe implements the prometheus.Collector interface. The metrics are created
+// when they are first reported, so the Collector is unchecked and describes none.
+func (c *Collector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements the prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for _, vec := range c.counters {
+		vec.Collect(ch)
+	}
+
+	for _, vec := range c.gauges {
+		vec.Collect(ch)
+	}
+
+	for _, vec := range c.histograms {
+		vec.Collect(ch)
+	}
+}
+
+// helpOf returns the description of the metric with the given name.
+func helpOf(name string) string {
+
+	if h, ok := help[name]; ok {
+		return h
+	}
+
+	return strings.Replace(name, "_", " ", -1)
+}
+
+// labelNames returns the sorted names of the given labels.
+func labelNames(labels bambou.Labels) []string {
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+var (
+	_ bambou.Metrics       = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
@@ -0,0 +1,100 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package prommetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type root struct {
+	ID  string `json:"ID,omitempty"`
+	Key string `json:"APIKey,omitempty"`
+}
+
+func (o *root) Identity() bambou.Identity { return bambou.Identity{Name: "me", Category: "me"} }
+func (o *root) Identifier() string        { return o.ID }
+func (o *root) SetIdentifier(ID string)   { o.ID = ID }
+func (o *root) APIKey() string            { return o.Key }
+func (o *root) SetAPIKey(key string)      { o.Key = key }
+
+func TestCollector_Metrics(t *testing.T) {
+
+	Convey("Given I have a registered collector", t, func() {
+
+		collector := NewCollector(nil)
+		registry := prometheus.NewPedanticRegistry()
+		So(registry.Register(collector), ShouldBeNil)
+
+		Convey("When I report metrics", func() {
+
+			collector.AddCounter("test_total", bambou.Labels{"a": "1"}, 2)
+			collector.AddCounter("test_total", bambou.Labels{"a": "1"}, 3)
+			collector.AddCounter("test_total", bambou.Labels{"b": "1"}, 10)
+			collector.SetGauge("test_gauge", nil, 7)
+			collector.ObserveDuration("test_seconds", bambou.Labels{"a": "1"}, 200*time.Millisecond)
+
+			families, err := registry.Gather()
+
+			Convey("Then they should be collected", func() {
+				So(err, ShouldBeNil)
+				So(families, ShouldHaveLength, 3)
+				So(testutil.ToFloat64(collector.counters["test_total"].WithLabelValues("1")), ShouldEqual, 5)
+				So(testutil.ToFloat64(collector.gauges["test_gauge"].WithLabelValues()), ShouldEqual, 7)
+				So(families[1].GetMetric()[0].GetHistogram().GetSampleSum(), ShouldEqual, 0.2)
+			})
+		})
+	})
+}
+
+func TestCollector_Session(t *testing.T) {
+
+	Convey("Given I have a session on a fake VSD reporting to a collector", t, func() {
+
+		s := fakevsd.New("admin", "secret", "csp")
+		defer s.Close()
+
+		collector := NewCollector(nil)
+		session := bambou.NewSession("admin", "secret", "csp", s.URL, &root{})
+		session.SetMetrics(collector)
+
+		Convey("When I start it", func() {
+
+			So(session.Start(), ShouldBeNil)
+
+			Convey("Then the request should be counted", func() {
+				So(testutil.ToFloat64(collector.counters[bambou.MetricRequests].With(prometheus.Labels{"method": "GET", "identity": "me", "status": "200"})), ShouldEqual, 1)
+				So(testutil.ToFloat64(collector.counters[bambou.MetricResponseBytes].With(prometheus.Labels{"method": "GET", "identity": "me"})), ShouldBeGreaterThan, 0)
+				So(testutil.ToFloat64(collector.gauges[bambou.MetricRequestsInFlight].WithLabelValues()), ShouldEqual, 0)
+				So(testutil.CollectAndCount(collector, bambou.MetricRequestDuration), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// AttributeChange represents the change of the value of an attribute.
+type AttributeChange struct {
+	Old interface{}
+	New interface{}
+}
+
+// ApplyResult summarizes the changes made by ApplyChild.
+type ApplyResult struct {
+	Created bool
+	Changes map[string]AttributeChange
+}
+
+// Changed returns true if the object has been created or modified.
+func (r *ApplyResult) Changed() bool {
+
+	return r.Created || len(r.Changes) > 0
+}
+
+// String returns the string representation of the ApplyResult.
+func (r *ApplyResult) String() string {
+
+	if r.Created {
+		return "<ApplyResult created>"
+	}
+
+	var names []string
+	for name := range r.Changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Sprintf("<ApplyResult changed: %v>", names)
+}
+
+// EnsureChild makes sure a child matching the given filter exists under the given parent.
+// The filter is a natural key filter, like `name == "default"`, that must match at most one object.
+// If a matching child exists, it is copied into the given child and created is false.
+// Otherwise, the given child is created under the parent and created is true.
+func EnsureChild(storer Storer, parent Identifiable, child Identifiable, filter string) (created bool, err *Error) {
+
+	existing, err := findChild(storer, parent, child, filter)
+	if err != nil {
+		return false, err
+	}
+
+	if existing != nil {
+		reflect.ValueOf(child).Elem().Set(reflect.ValueOf(existing).Elem())
+		return false, nil
+	}
+
+	if err := storer.CreateChild(parent, child); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// findChild returns the only child of the given parent with the same type
+// as the given child matching the given filter, or nil if there is none.
+func findChild(storer Storer, parent Identifiable, child Identifiable, filter string) (Identifiable, *Error) {
+
+	t := reflect.TypeOf(child)
+	if t.Kind() != reflect.Ptr {
+		return nil, NewBambouError("Invalid object", fmt.Sprintf("%s must be a pointer", child.Identity().Name))
+	}
+
+	dest := reflect.New(reflect.SliceOf(t))
+
+	info := NewFetchingInfo()
+	info.Filter = filter
+
+	if err := storer.FetchChildren(parent, child.Identity(), dest.Interface(), info); err != nil {
+		return nil, err
+	}
+
+	switch dest.Elem().Len() {
+	case 0:
+		return nil, nil
+	case 1:
+		return dest.Elem().Index(0).Interface().(Identifiable), nil
+	default:
+		return nil, NewBambouError("Ambiguous filter", fmt.Sprintf("%d %s match the filter '%s'", dest.Elem().Len(), child.Identity().Category, filter))
+	}
+}
+
+// ApplyChild makes sure a child matching the given filter exists under the given parent
+// and has the attributes of the given desired object.
+// If no child matches, the desired object is created. Otherwise, the attributes
+// of the desired object that differ from the existing child are saved on the
+// server. Only the attributes the desired object is encoded with are compared:
+// the ones omitted, like the empty ones tagged omitempty and the unset Optional
+// ones, are left untouched. Use the Optional types to set false, 0 or null.
+// The desired object is populated with the resulting server state.
+func ApplyChild(storer Storer, parent Identifiable, desired Identifiable, filter string) (*ApplyResult, *Error) {
+
+	existing, err := findChild(storer, parent, desired, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		if err := storer.CreateChild(parent, desired); err != nil {
+			return nil, err
+		}
+		return &ApplyResult{Created: true}, nil
+	}
+
+	changes, patched, err := diffAttributes(codecOf(storer), desired, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ApplyResult{Changes: changes}
+	if len(result.Changes) > 0 {
+		if err := storer.SaveEntity(patched); err != nil {
+			return nil, err
+		}
+	}
+
+	reflect.ValueOf(desired).Elem().Set(reflect.ValueOf(patched).Elem())
+
+	return result, nil
+}
+
+// diffAttributes returns the attributes of the given desired object that differ from
+// the given existing object, and the existing object patched with them, encoded and
+// decoded with the given Codec. Only the attributes the desired object is encoded
+// with are compared, the omitted ones are ignored.
+func diffAttributes(codec Codec, desired Identifiable, existing Identifiable) (map[string]AttributeChange, Identifiable, *Error) {
+
+	desiredAttributes, err := encodedAttributes(codec, desired)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existingAttributes, err := encodedAttributes(codec, existing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := map[string]AttributeChange{}
+	for name, value := range desiredAttributes {
+
+		if name == "ID" {
+			continue
+		}
+
+		if !reflect.DeepEqual(existingAttributes[name], value) {
+			changes[name] = AttributeChange{Old: existingAttributes[name], New: value}
+			existingAttributes[name] = value
+		}
+	}
+
+	patched := newIdentifiableLike(desired)
+	data, merr := codec.Marshal(existingAttributes)
+	if merr != nil {
+		return nil, nil, NewBambouError("JSON error", merr.Error())
+	}
+	if err := codec.Unmarshal(data, patched); err != nil {
+		return nil, nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+	patched.SetIdentifier(existing.Identifier())
+
+	return changes, patched, nil
+}
+
+// encodedAttributes returns the attributes of the given object as encoded with the given Codec.
+func encodedAttributes(codec Codec, object Identifiable) (map[string]interface{}, *Error) {
+
+	data, err := encodeObject(codec, object)
+	if err != nil {
+		return nil, NewBambouError("JSON error", err.Error())
+	}
+
+	attributes := map[string]interface{}{}
+	if err := codec.Unmarshal(data, &attributes); err != nil {
+		return nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	return attributes, nil
+}
+
+// attributesOf returns the JSON attributes of the given object.
+func attributesOf(object interface{}) (map[string]interface{}, *Error) {
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, NewBambouError("JSON error", err.Error())
+	}
+
+	attributes := map[string]interface{}{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	return attributes, nil
+}
+
+// isZeroAttribute returns true if the given decoded JSON value is a zero value.
+func isZeroAttribute(value interface{}) bool {
+
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProvisioning_EnsureChild(t *testing.T) {
+
+	Convey("Given I have a server with an existing child", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "parent", "name": "parent"})
+		ts.add("parent", map[string]interface{}{"ID": "existing", "name": "existing"})
+		ts.add("parent", map[string]interface{}{"ID": "dup1", "name": "dup"})
+		ts.add("parent", map[string]interface{}{"ID": "dup2", "name": "dup"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		parent := NewFakeObject("parent")
+
+		Convey("When I ensure a child that already exists", func() {
+
+			child := &FakeObject{Name: "existing"}
+			created, err := EnsureChild(session, parent, child, `name == "existing"`)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then created should be false", func() {
+				So(created, ShouldBeFalse)
+			})
+
+			Convey("Then the child should be populated with the existing one", func() {
+				So(child.ID, ShouldEqual, "existing")
+			})
+		})
+
+		Convey("When I ensure a child that does not exist", func() {
+
+			child := &FakeObject{Name: "new"}
+			created, err := EnsureChild(session, parent, child, `name == "new"`)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then created should be true", func() {
+				So(created, ShouldBeTrue)
+			})
+
+			Convey("Then the child should be created", func() {
+				So(child.ID, ShouldNotEqual, "")
+				So(ts.get(child.ID)["name"], ShouldEqual, "new")
+			})
+
+			Convey("When I ensure it again", func() {
+
+				again := &FakeObject{Name: "new"}
+				created, err := EnsureChild(session, parent, again, `name == "new"`)
+
+				Convey("Then it should not be created twice", func() {
+					So(err, ShouldBeNil)
+					So(created, ShouldBeFalse)
+					So(again.ID, ShouldEqual, child.ID)
+				})
+			})
+		})
+
+		Convey("When I ensure a child with an ambiguous filter", func() {
+
+			created, err := EnsureChild(session, parent, &FakeObject{Name: "dup"}, `name == "dup"`)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(created, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestProvisioning_ApplyChild(t *testing.T) {
+
+	Convey("Given I have a server with an existing child", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "parent", "name": "parent"})
+		ts.add("parent", map[string]interface{}{"ID": "existing", "name": "existing", "description": "old"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		parent := NewFakeObject("parent")
+
+		Convey("When I apply a child that does not exist", func() {
+
+			desired := &FakeObject{Name: "new"}
+			result, err := ApplyChild(session, parent, desired, `name == "new"`)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the result should indicate a creation", func() {
+				So(result.Created, ShouldBeTrue)
+				So(result.Changed(), ShouldBeTrue)
+				So(result.String(), ShouldEqual, "<ApplyResult created>")
+			})
+
+			Convey("Then the child should be created", func() {
+				So(ts.get(desired.ID), ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I apply a child identical to the existing one", func() {
+
+			desired := &FakeObject{Name: "existing"}
+			result, err := ApplyChild(session, parent, desired, `name == "existing"`)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the result should not indicate any change", func() {
+				So(result.Changed(), ShouldBeFalse)
+			})
+
+			Convey("Then the desired object should have the ID of the existing one", func() {
+				So(desired.ID, ShouldEqual, "existing")
+			})
+
+			Convey("Then the server should not have received any update", func() {
+				So(ts.methods(), ShouldNotContain, "PUT")
+			})
+		})
+
+		Convey("When I apply a child with a different attribute", func() {
+
+			desired := &FakeObject{Name: "renamed"}
+			result, err := ApplyChild(session, parent, desired, `description == "old"`)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the result should contain the change", func() {
+				So(result.Created, ShouldBeFalse)
+				So(result.Changes["name"], ShouldResemble, AttributeChange{Old: "existing", New: "renamed"})
+				So(result.String(), ShouldEqual, "<ApplyResult changed: [name]>")
+			})
+
+			Convey("Then the server copy should be updated and keep its other attributes", func() {
+				So(ts.get("existing")["name"], ShouldEqual, "renamed")
+				So(ts.get("existing")["description"], ShouldEqual, "old")
+			})
+		})
+
+		Convey("When I apply a child that sets attributes to their zero value", func() {
+
+			desired := &optionalObject{FakeObject: FakeObject{Name: "existing"}, Description: NewString(""), Enabled: NewBool(false)}
+			result, err := ApplyChild(session, parent, desired, `name == "existing"`)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the result should contain the set attributes only", func() {
+				So(result.Changes, ShouldHaveLength, 2)
+				So(result.Changes["description"], ShouldResemble, AttributeChange{Old: "old", New: ""})
+				So(result.Changes["enabled"], ShouldResemble, AttributeChange{Old: nil, New: false})
+			})
+
+			Convey("Then the server copy should be updated", func() {
+				So(ts.get("existing")["description"], ShouldEqual, "")
+				So(ts.get("existing")["enabled"], ShouldEqual, false)
+				So(ts.get("existing"), ShouldNotContainKey, "priority")
+			})
+		})
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// SetProxy sets the proxy the session connects to the server through: an http,
+// https or socks5 URL, with the credentials of the proxy as user info if any.
+// A nil URL restores the default, the proxy given by the environment. See
+// http.ProxyFromEnvironment. It must be called before the session is used.
+func (s *Session) SetProxy(proxyURL *url.URL) *Error {
+
+	if s.transport == nil {
+		return NewBambouError("Invalid session", "the transport of the session is not configurable")
+	}
+
+	if proxyURL == nil {
+		s.transport.Proxy = http.ProxyFromEnvironment
+		return nil
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return NewBambouError("Invalid proxy", fmt.Sprintf("unsupported proxy scheme %q", proxyURL.Scheme))
+	}
+
+	s.transport.Proxy = http.ProxyURL(proxyURL)
+
+	return nil
+}
+
+// SetSOCKS5Proxy sets the session to connect to the server through the SOCKS5 proxy
+// at the given address, like a bastion of a lab. The session authenticates with the
+// given username and password if the username is not empty. The host names are
+// resolved by the proxy. See SetProxy.
+func (s *Session) SetSOCKS5Proxy(address, username, password string) *Error {
+
+	proxyURL := &url.URL{Scheme: "socks5", Host: address}
+	if username != "" {
+		proxyURL.User = url.UserPassword(username, password)
+	}
+
+	return s.SetProxy(proxyURL)
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// socks5Server is a minimal SOCKS5 proxy supporting the CONNECT command, without
+// authentication or with a username and password.
+type socks5Server struct {
+	listener net.Listener
+	username string
+	password string
+	targets  []string
+	lock     sync.Mutex
+}
+
+func newSOCKS5Server(username, password string) *socks5Server {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &socks5Server{listener: listener, username: username, password: password}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *socks5Server) Close() { s.listener.Close() }
+
+func (s *socks5Server) connected() []string {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.targets...)
+}
+
+func (s *socks5Server) serve(conn net.Conn) {
+
+	defer conn.Close()
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	if s.username == "" {
+		conn.Write([]byte{5, 0})
+	} else {
+		conn.Write([]byte{5, 2})
+		if !s.authenticate(conn) {
+			return
+		}
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		io.ReadFull(conn, length)
+		name := make([]byte, length[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+
+	s.lock.Lock()
+	s.targets = append(s.targets, target)
+	s.lock.Unlock()
+
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func (s *socks5Server) authenticate(conn net.Conn) bool {
+
+	header := make([]byte, 2)
+	io.ReadFull(conn, header)
+	username := make([]byte, header[1])
+	io.ReadFull(conn, username)
+	length := make([]byte, 1)
+	io.ReadFull(conn, length)
+	password := make([]byte, length[0])
+	io.ReadFull(conn, password)
+
+	if string(username) != s.username || string(password) != s.password {
+		conn.Write([]byte{1, 1})
+		return false
+	}
+
+	conn.Write([]byte{1, 0})
+	return true
+}
+
+func TestSession_SetSOCKS5Proxy(t *testing.T) {
+
+	Convey("Given I have a server reachable through a SOCKS5 proxy", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "1", "name": "object"})
+
+		proxy := newSOCKS5Server("bastion", "secret")
+		defer proxy.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch an object through the proxy", func() {
+
+			So(session.SetSOCKS5Proxy(proxy.listener.Addr().String(), "bastion", "secret"), ShouldBeNil)
+			object := NewFakeObject("1")
+			err := session.FetchEntity(object)
+
+			Convey("Then the request should go through the proxy", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "object")
+				u, _ := url.Parse(ts.URL)
+				So(proxy.connected(), ShouldContain, u.Host)
+			})
+		})
+
+		Convey("When I fetch an object through the proxy with a wrong password", func() {
+
+			So(session.SetSOCKS5Proxy(proxy.listener.Addr().String(), "bastion", "wrong"), ShouldBeNil)
+			err := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(proxy.connected(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I set a proxy with an unsupported scheme", func() {
+
+			err := session.SetProxy(&url.URL{Scheme: "ftp", Host: "proxy:21"})
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I reset the proxy", func() {
+
+			So(session.SetSOCKS5Proxy(proxy.listener.Addr().String(), "", ""), ShouldBeNil)
+			So(session.SetProxy(nil), ShouldBeNil)
+			err := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the requests should not go through the proxy", func() {
+				So(err, ShouldBeNil)
+				So(proxy.connected(), ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -25,10 +25,30 @@ package bambou
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
 )
 
+// Default delays between two reconnection attempts of the PushCenter.
+const (
+	DefaultPushCenterMinBackoff = 1 * time.Second
+	DefaultPushCenterMaxBackoff = 1 * time.Minute
+)
+
+// ResyncRequiredHandler is the prototype of the function called when the
+// PushCenter lost track of the event stream and events may have been missed.
+// The given error has the error returned by the server as Details.
+type ResyncRequiredHandler func(*Error)
+
+// StaleHandler is the prototype of the function called when the PushCenter
+// did not receive anything from the server for too long.
+type StaleHandler func()
+
 // NotificationsChannel is used to received notification from the session
 type NotificationsChannel chan *Notification
 
@@ -39,27 +59,72 @@ type EventHandler func(*Event)
 type eventHandlers map[string]EventHandler
 
 // PushCenter is a structure that allows the user to deal with notifications.
-// You can register multiple handlers for several Identity. When a notification
-// is sent by the server and the Identity of its content matches one of the
-// registered handler, this handler will be called.
+// You can register multiple handlers for several Identity, and optionally for
+// a given type of event. When a notification is sent by the server and the
+// Identity of its content matches one of the registered handler, this handler
+// will be called.
 type PushCenter struct {
 	isRunning bool
 	Channel   NotificationsChannel
 
 	handlers      eventHandlers
+	typedHandlers eventHandlers
 	defaultHander EventHandler
+	filter        map[string]bool
+	lastEventID   string
+	dedup         *deduplicator
+	checkOrder    bool
+	lastEventTime int64
+	resyncHandler ResyncRequiredHandler
+	staleTimeout  time.Duration
+	staleHandler  StaleHandler
+	checkpointer  EventCheckpointer
+	cache         CacheInvalidator
+	journal       *EventJournal
+	metrics       Metrics
+	logger        LeveledLogger
+	subscriptions map[int]*Subscription
+	nextSubID     int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	bufferSize    int
+	backpressure  BackpressurePolicy
+	queue         *eventQueue
+	workers       int
+	handlerPolicy handlerPolicy
+	pool          *handlerPool
 	stop          chan bool
-	session       *Session
+	cancel        context.CancelFunc
+	transport     EventTransport
+	clock         Clock
+	lock          sync.RWMutex
 }
 
-// NewPushCenter creates a new PushCenter.
+// NewPushCenter creates a new PushCenter receiving the notifications
+// using the long polling of the given Session. The PushCenter is stopped
+// when the session is closed. See Session.Close.
 func NewPushCenter(session *Session) *PushCenter {
 
+	p := NewPushCenterWithTransport(session)
+	if session != nil {
+		session.addPushCenter(p)
+	}
+
+	return p
+}
+
+// NewPushCenterWithTransport creates a new PushCenter receiving the notifications
+// using the given EventTransport.
+func NewPushCenterWithTransport(transport EventTransport) *PushCenter {
+
 	return &PushCenter{
-		Channel:  make(NotificationsChannel),
-		stop:     make(chan bool),
-		handlers: eventHandlers{},
-		session:  session,
+		Channel:       make(NotificationsChannel),
+		stop:          make(chan bool),
+		handlers:      eventHandlers{},
+		typedHandlers: eventHandlers{},
+		minBackoff:    DefaultPushCenterMinBackoff,
+		maxBackoff:    DefaultPushCenterMaxBackoff,
+		transport:     transport,
 	}
 }
 
@@ -69,6 +134,9 @@ func NewPushCenter(session *Session) *PushCenter {
 // the previous handler will be silently overwriten.
 func (p *PushCenter) RegisterHandlerForIdentity(handler EventHandler, identity Identity) {
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	if identity.Name == AllIdentity.Name {
 		p.defaultHander = handler
 		return
@@ -80,6 +148,9 @@ func (p *PushCenter) RegisterHandlerForIdentity(handler EventHandler, identity I
 // UnregisterHandlerForIdentity unegisters the given EventHandler for the given Entity Identity.
 func (p *PushCenter) UnregisterHandlerForIdentity(identity Identity) {
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	if identity.Name == AllIdentity.Name {
 		p.defaultHander = nil
 		return
@@ -93,6 +164,9 @@ func (p *PushCenter) UnregisterHandlerForIdentity(identity Identity) {
 // HasHandlerForIdentity verifies if the given identity has a registered handler.
 func (p *PushCenter) HasHandlerForIdentity(identity Identity) bool {
 
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
 	if identity.Name == AllIdentity.Name {
 		return p.defaultHander != nil
 	}
@@ -100,56 +174,745 @@ func (p *PushCenter) HasHandlerForIdentity(identity Identity) bool {
 	return exists
 }
 
+// RegisterHandlerForIdentityAndEventType registers the given EventHandler for the events
+// of the given type (see EventTypeCreate, EventTypeUpdate, EventTypeDelete) about the given Identity.
+// You can pass the bambou.AllIdentity as identity to register the handler for the
+// events of the given type about all identities.
+// Handlers registered for a type of event are called in addition to the handlers
+// registered for the whole Identity.
+func (p *PushCenter) RegisterHandlerForIdentityAndEventType(handler EventHandler, identity Identity, eventType string) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.typedHandlers[typedHandlerKey(identity.Name, eventType)] = handler
+}
+
+// UnregisterHandlerForIdentityAndEventType unregisters the EventHandler for the given type of event
+// about the given Identity.
+func (p *PushCenter) UnregisterHandlerForIdentityAndEventType(identity Identity, eventType string) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.typedHandlers, typedHandlerKey(identity.Name, eventType))
+}
+
+// HasHandlerForIdentityAndEventType verifies if the given type of event about the given identity
+// has a registered handler.
+func (p *PushCenter) HasHandlerForIdentityAndEventType(identity Identity, eventType string) bool {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	_, exists := p.typedHandlers[typedHandlerKey(identity.Name, eventType)]
+	return exists
+}
+
+// RegisterFallibleHandlerForIdentity works like RegisterHandlerForIdentity with a handler that
+// can fail. See SetHandlerRetries and SetDeadLetterHandler.
+func (p *PushCenter) RegisterFallibleHandlerForIdentity(handler FallibleEventHandler, identity Identity) {
+
+	p.RegisterHandlerForIdentity(infallible(handler), identity)
+}
+
+// RegisterFallibleHandlerForIdentityAndEventType works like RegisterHandlerForIdentityAndEventType
+// with a handler that can fail. See SetHandlerRetries and SetDeadLetterHandler.
+func (p *PushCenter) RegisterFallibleHandlerForIdentityAndEventType(handler FallibleEventHandler, identity Identity, eventType string) {
+
+	p.RegisterHandlerForIdentityAndEventType(infallible(handler), identity, eventType)
+}
+
+// SetIdentityFilter restricts the events processed by the PushCenter to the ones
+// about the given identities. All other events are dropped as soon as the
+// notification is received, before being decoded or given to any handler.
+// Calling SetIdentityFilter without any identity removes the filter.
+func (p *PushCenter) SetIdentityFilter(identities ...Identity) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(identities) == 0 {
+		p.filter = nil
+		return
+	}
+
+	p.filter = map[string]bool{}
+	for _, identity := range identities {
+		p.filter[identity.Name] = true
+	}
+}
+
+// acceptsEvent returns true if the given event passes the identity filter.
+func (p *PushCenter) acceptsEvent(event *Event) bool {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.filter == nil || p.filter[event.EntityType]
+}
+
+// SetDeduplicationWindow makes the PushCenter drop the notifications whose UUID
+// is one of the given number of last received UUIDs. Reconnections may deliver
+// the same notification twice. Passing 0 disables the deduplication.
+func (p *PushCenter) SetDeduplicationWindow(size int) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if size <= 0 {
+		p.dedup = nil
+		return
+	}
+
+	p.dedup = newDeduplicator(size)
+}
+
+// SetOrderingCheck enables or disables the ordering check of the events.
+// When enabled, receiving an event older than the last received event calls the
+// ResyncRequiredHandler with an Error having an *EventOrderError as Details, as
+// some events may have been missed or applied in the wrong order.
+// The event is dispatched anyway.
+func (p *PushCenter) SetOrderingCheck(enabled bool) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.checkOrder = enabled
+	p.lastEventTime = 0
+}
+
+// isDuplicate returns true if the given notification has already been received.
+func (p *PushCenter) isDuplicate(notification *Notification) bool {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.dedup != nil && p.dedup.isDuplicate(notification.UUID)
+}
+
+// checkEventOrder verifies the given event is not older than the last received event.
+func (p *PushCenter) checkEventOrder(notification *Notification, event *Event) {
+
+	p.lock.Lock()
+
+	if !p.checkOrder || event.ReceivedTime == 0 {
+		p.lock.Unlock()
+		return
+	}
+
+	if event.ReceivedTime >= p.lastEventTime {
+		p.lastEventTime = event.ReceivedTime
+		p.lock.Unlock()
+		return
+	}
+
+	handler := p.resyncHandler
+	cause := &EventOrderError{
+		NotificationUUID: notification.UUID,
+		LastReceivedTime: p.lastEventTime,
+		ReceivedTime:     event.ReceivedTime,
+	}
+	p.lock.Unlock()
+
+	p.currentLogger().Warnf("Events received out of order, resync required: %s", cause)
+
+	if handler != nil {
+		handler(&Error{Title: "Events out of order", Description: cause.Error(), Details: cause})
+	}
+}
+
+// SetBuffer makes the PushCenter buffer up to the given number of notifications
+// between their reception and the call of the handlers, so slow handlers do not
+// delay the reception. When the buffer is full, the given BackpressurePolicy applies.
+// Passing a size of 0 disables the buffer: the handlers are called as soon as a
+// notification is received. SetBuffer takes effect on the next call to Start.
+func (p *PushCenter) SetBuffer(size int, policy BackpressurePolicy) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bufferSize = size
+	p.backpressure = policy
+}
+
+// DroppedEvents returns the number of events dropped by the BackpressurePolicy
+// since the last call to Start.
+func (p *PushCenter) DroppedEvents() uint64 {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.queue == nil {
+		return 0
+	}
+
+	return p.queue.droppedEvents()
+}
+
+// SetWorkers makes the PushCenter call the handlers on the given number of goroutines,
+// so a slow handler does not delay the other ones. The handlers may then be called
+// concurrently, and not in the order of the events. Passing 0 makes the PushCenter
+// call the handlers one after the other. SetWorkers takes effect on the next call to Start.
+func (p *PushCenter) SetWorkers(workers int) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.workers = workers
+}
+
+// SetHandlerTimeout sets the maximum duration the PushCenter waits for a handler.
+// After the timeout, the failure is logged and the PushCenter moves on, while the
+// handler keeps running in the background. Passing 0 removes the timeout.
+// In any case, a panicking handler is recovered and its failure is logged.
+func (p *PushCenter) SetHandlerTimeout(timeout time.Duration) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.handlerPolicy.timeout = timeout
+}
+
+// SetHandlerRetries sets the number of times the PushCenter calls a handler again
+// after it failed to process an event. A handler fails if it panics, times out, or
+// if it is a FallibleEventHandler returning an error.
+func (p *PushCenter) SetHandlerRetries(retries int) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.handlerPolicy.retries = retries
+}
+
+// SetDeadLetterHandler sets the function receiving the events a handler failed
+// to process after all the retries. Without a DeadLetterHandler, the failure is
+// logged and the event is dropped.
+func (p *PushCenter) SetDeadLetterHandler(handler DeadLetterHandler) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.handlerPolicy.deadLetter = handler
+}
+
+// SetReconnectBackoff sets the minimum and maximum delays between two attempts
+// to reconnect to the event stream after an error. The delay starts at min and
+// doubles after every consecutive error, up to max.
+func (p *PushCenter) SetReconnectBackoff(min time.Duration, max time.Duration) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.minBackoff = min
+	p.maxBackoff = max
+}
+
+// SetResyncRequiredHandler sets the function to call when the server does not
+// recognize the last event ID anymore. When this happens, the PushCenter resumes
+// from the current events, and the consumers must do a full resynchronization
+// as some events may have been missed.
+func (p *PushCenter) SetResyncRequiredHandler(handler ResyncRequiredHandler) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.resyncHandler = handler
+}
+
+// SetStaleTimeout sets the maximum duration without receiving anything from the
+// server. After that, the PushCenter considers the connection as lost, calls the
+// StaleHandler and reconnects. With long polling, the server answers without event
+// when its own timeout expires, so the duration must be longer than this timeout.
+// Passing 0 disables the detection.
+func (p *PushCenter) SetStaleTimeout(timeout time.Duration) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.staleTimeout = timeout
+}
+
+// SetClock sets the Clock timing the reconnections, the stale timeout, the timeout
+// of the handlers and the dispatch of the events.
+// The default is SystemClock.
+func (p *PushCenter) SetClock(clock Clock) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.clock = clock
+}
+
+// SetStaleHandler sets the function to call when the stale timeout expires.
+func (p *PushCenter) SetStaleHandler(handler StaleHandler) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.staleHandler = handler
+}
+
+// SetCheckpointer sets the EventCheckpointer used to persist the last event ID.
+// When the PushCenter starts, it resumes the event stream from the event ID
+// loaded from the checkpointer, and it saves the event ID of every notification
+// it receives.
+func (p *PushCenter) SetCheckpointer(checkpointer EventCheckpointer) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.checkpointer = checkpointer
+}
+
+// SetCache sets the client-side cache the PushCenter keeps coherent with the server.
+// The cached entities are invalidated on DELETE and UPDATE events, before the
+// handlers are called. If the cache is a CacheUpdater and the Identity of the
+// entities is registered, updated entities are refreshed instead.
+// Passing nil removes the cache.
+func (p *PushCenter) SetCache(cache CacheInvalidator) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.cache = cache
+}
+
+// SetJournal sets the EventJournal recording every notification received from
+// the server, including the duplicated ones. Passing nil removes the journal.
+func (p *PushCenter) SetJournal(journal *EventJournal) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.journal = journal
+}
+
+// Replay calls the registered handlers with the notifications of the given
+// journal, as if they were received from the server. The replayed notifications
+// are not recorded in the journal. The PushCenter does not need to be started.
+func (p *PushCenter) Replay(r io.Reader) error {
+
+	return ReadEventJournal(r, func(entry *JournalEntry) error {
+		p.dispatch(entry.Notification)
+		return nil
+	})
+}
+
+// SetMetrics sets the Metrics the PushCenter reports to: the number of events received
+// per entity type and event type, the dispatch duration, the lag between the reception
+// of the events by the server and their dispatch, and the number of reconnections.
+// Passing nil disables the reporting.
+func (p *PushCenter) SetMetrics(metrics Metrics) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.metrics = metrics
+}
+
+// currentMetrics returns the Metrics, if any.
+func (p *PushCenter) currentMetrics() Metrics {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.metrics
+}
+
+// SetLogger sets the LeveledLogger of the PushCenter. Use nil to use the logger
+// of its Session, if its transport is a *Session, or the default logger.
+func (p *PushCenter) SetLogger(logger LeveledLogger) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.logger = logger
+}
+
+// currentLogger returns the LeveledLogger of the PushCenter.
+func (p *PushCenter) currentLogger() LeveledLogger {
+
+	p.lock.RLock()
+	logger := p.logger
+	p.lock.RUnlock()
+
+	if logger != nil {
+		return logger
+	}
+
+	return loggerOf(p.transport)
+}
+
+// LastEventID returns the identifier of the last notification received by the PushCenter.
+func (p *PushCenter) LastEventID() string {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.lastEventID
+}
+
+// IsRunning returns true if the PushCenter is started.
+func (p *PushCenter) IsRunning() bool {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.isRunning
+}
+
+// handlersForEvent returns the list of handlers to call for the given event.
+func (p *PushCenter) handlersForEvent(event *Event) []EventHandler {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var handlers []EventHandler
+
+	if p.defaultHander != nil {
+		handlers = append(handlers, p.defaultHander)
+	}
+
+	if handler, exists := p.typedHandlers[typedHandlerKey(AllIdentity.Name, event.Type)]; exists {
+		handlers = append(handlers, handler)
+	}
+
+	if handler, exists := p.handlers[event.EntityType]; exists {
+		handlers = append(handlers, handler)
+	}
+
+	if handler, exists := p.typedHandlers[typedHandlerKey(event.EntityType, event.Type)]; exists {
+		handlers = append(handlers, handler)
+	}
+
+	return handlers
+}
+
+// record records the given notification in the journal, if any.
+func (p *PushCenter) record(notification *Notification) {
+
+	p.lock.RLock()
+	journal := p.journal
+	p.lock.RUnlock()
+
+	if journal == nil {
+		return
+	}
+
+	if err := journal.Record(notification); err != nil {
+		p.currentLogger().Errorf("Unable to record the notification %s in the journal: %s", notification.UUID, err)
+	}
+}
+
+// dispatch calls the registered handlers for each event of the given notification.
+func (p *PushCenter) dispatch(notification *Notification) {
+
+	if p.isDuplicate(notification) {
+		p.currentLogger().Debugf("Dropping duplicate notification %s", notification.UUID)
+		return
+	}
+
+	p.setLastEventID(notification.UUID)
+
+	metrics := p.currentMetrics()
+
+	p.lock.RLock()
+	clock := clockOrSystem(p.clock)
+	p.lock.RUnlock()
+
+	for _, event := range notification.Events {
+
+		start := clock.Now()
+
+		if metrics != nil {
+			metrics.AddCounter(MetricEventsReceived, Labels{"entity_type": event.EntityType, "type": event.Type}, 1)
+			if event.ReceivedTime != 0 {
+				lag := start.Sub(time.Unix(0, event.ReceivedTime*int64(time.Millisecond)))
+				metrics.SetGauge(MetricEventLag, nil, lag.Seconds())
+			}
+		}
+
+		p.checkEventOrder(notification, event)
+
+		if !p.acceptsEvent(event) {
+			continue
+		}
+
+		if len(event.DataMap) > 0 {
+			buffer := &bytes.Buffer{}
+			if err := json.NewEncoder(buffer).Encode(event.DataMap[0]); err != nil {
+				continue
+			}
+			event.Data = buffer.Bytes()
+		}
+
+		if err := event.decodeEntities(); err != nil {
+			p.currentLogger().Errorf("Unable to decode the entities of a %s event: %s", event.EntityType, err)
+		}
+
+		p.lock.RLock()
+		pool, policy, cache := p.pool, p.handlerPolicy, p.cache
+		policy.clock = clock
+		p.lock.RUnlock()
+
+		if cache != nil {
+			invalidateCache(cache, event)
+		}
+
+		for _, handler := range p.handlersForEvent(event) {
+			if pool != nil {
+				pool.submit(policy, handler, event)
+			} else {
+				policy.run(handler, event)
+			}
+		}
+
+		p.deliver(event)
+
+		if metrics != nil {
+			metrics.ObserveDuration(MetricEventDispatch, Labels{"entity_type": event.EntityType}, clock.Now().Sub(start))
+		}
+	}
+}
+
+// setLastEventID sets the last event ID and saves it using the checkpointer, if any.
+func (p *PushCenter) setLastEventID(eventID string) {
+
+	p.lock.Lock()
+	p.lastEventID = eventID
+	checkpointer := p.checkpointer
+	p.lock.Unlock()
+
+	if checkpointer == nil {
+		return
+	}
+
+	if err := checkpointer.Save(eventID); err != nil {
+		p.currentLogger().Errorf("Unable to save the last event ID: %s", err)
+	}
+}
+
+func typedHandlerKey(name string, eventType string) string {
+
+	return name + "|" + eventType
+}
+
 // Start starts the Push Center.
 func (p *PushCenter) Start() error {
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	if p.isRunning {
 		return errors.New("the push center is already started")
 	}
 
+	if p.checkpointer != nil {
+		eventID, err := p.checkpointer.Load()
+		if err != nil {
+			return err
+		}
+		p.lastEventID = eventID
+	}
+
 	p.isRunning = true
 
-	go func() {
-		lastEventID := ""
-		for {
-			go p.session.NextEvent(p.Channel, lastEventID)
-			select {
-			case notification := <-p.Channel:
-				for _, event := range notification.Events {
-
-					buffer := &bytes.Buffer{}
-					if err := json.NewEncoder(buffer).Encode(event.DataMap[0]); err != nil {
-						continue
-					}
-					event.Data = buffer.Bytes()
-
-					lastEventID = notification.UUID
-					if p.defaultHander != nil {
-						p.defaultHander(event)
-					}
-
-					if handler, exists := p.handlers[event.EntityType]; exists {
-						handler(event)
-					}
-				}
-			case <-p.stop:
-				return
-			}
-		}
-	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.pool = nil
+	if p.workers > 0 {
+		p.pool = newHandlerPool(p.workers, ctx.Done())
+	}
+
+	p.queue = nil
+	if p.bufferSize > 0 {
+		p.queue = newEventQueue(p.bufferSize, p.backpressure)
+		go p.process(ctx, p.queue)
+	}
+
+	go p.listen(ctx, p.queue)
 
 	return nil
 }
 
+// listen receives the notifications from the server and dispatches them until the PushCenter is stopped.
+// After an error, it reconnects with an exponential backoff, resuming from the last event ID.
+// The pending request is canceled as soon as the given context is done.
+// If the given queue is not nil, the notifications are pushed to it instead of
+// being dispatched.
+func (p *PushCenter) listen(ctx context.Context, queue *eventQueue) {
+
+	var delay time.Duration
+
+	for {
+		var running bool
+		if delay, running = p.receive(ctx, queue, delay); !running {
+			return
+		}
+	}
+}
+
+// receive waits for the next notification and handles it. The given delay is the delay
+// waited after the previous error. It returns the delay to give to the next call, and
+// false if the PushCenter is stopped.
+func (p *PushCenter) receive(ctx context.Context, queue *eventQueue, delay time.Duration) (time.Duration, bool) {
+
+	lastEventID := p.LastEventID()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan *Error, 1)
+	go func() { errs <- p.transport.NextEventWithContext(ctx, p.Channel, lastEventID) }()
+
+	p.lock.RLock()
+	staleTimeout, staleHandler, clock := p.staleTimeout, p.staleHandler, clockOrSystem(p.clock)
+	p.lock.RUnlock()
+
+	var stale <-chan time.Time
+	if staleTimeout > 0 {
+		timer := clock.NewTimer(staleTimeout)
+		defer timer.Stop()
+		stale = timer.C()
+	}
+
+	select {
+
+	case notification := <-p.Channel:
+		p.record(notification)
+		if queue == nil {
+			p.dispatch(notification)
+		} else if !queue.push(ctx, notification) {
+			// The context is only done when stopping.
+			<-p.stop
+			return 0, false
+		}
+		return 0, true
+
+	case err := <-errs:
+
+		if err == nil {
+			return 0, true
+		}
+
+		if lastEventID != "" && isStaleEventIDError(err) {
+			p.currentLogger().Warnf("Event ID %s is not valid anymore, resync required: %s", lastEventID, err.Description)
+			p.resetEventID(err)
+			p.countReconnect()
+			return delay, true
+		}
+
+		delay = p.nextBackoff(delay)
+		p.currentLogger().Warnf("Unable to get the next event, reconnecting in %s: %s", delay, err.Description)
+		p.countReconnect()
+
+		timer := clock.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C():
+			return delay, true
+		case <-p.stop:
+			return delay, false
+		}
+
+	case <-stale:
+		// Returning cancels the pending request.
+		p.currentLogger().Warnf("Nothing received from the server for %s, reconnecting", staleTimeout)
+		if staleHandler != nil {
+			staleHandler()
+		}
+		if metrics := p.currentMetrics(); metrics != nil {
+			metrics.AddCounter(MetricEventStreamStale, nil, 1)
+		}
+		p.countReconnect()
+		return delay, true
+
+	case <-p.stop:
+		return delay, false
+	}
+}
+
+// process dispatches the notifications of the given queue until the given context is done.
+func (p *PushCenter) process(ctx context.Context, queue *eventQueue) {
+
+	for {
+		select {
+		case notification := <-queue.buffer:
+			p.dispatch(notification)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// countReconnect reports a reconnection to the Metrics, if any.
+func (p *PushCenter) countReconnect() {
+
+	if metrics := p.currentMetrics(); metrics != nil {
+		metrics.AddCounter(MetricEventReconnects, nil, 1)
+	}
+}
+
+// nextBackoff returns the delay to wait after the given previous delay.
+func (p *PushCenter) nextBackoff(previous time.Duration) time.Duration {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if previous < p.minBackoff {
+		return p.minBackoff
+	}
+
+	if next := previous * 2; next < p.maxBackoff {
+		return next
+	}
+
+	return p.maxBackoff
+}
+
+// resetEventID forgets the last event ID and calls the resync required handler.
+func (p *PushCenter) resetEventID(cause *Error) {
+
+	p.setLastEventID("")
+
+	p.lock.RLock()
+	handler := p.resyncHandler
+	p.lock.RUnlock()
+
+	if handler != nil {
+		handler(&Error{
+			Title:       "Event channel lost",
+			Description: "the last event ID is not valid anymore, resync required",
+			Code:        cause.Code,
+			Details:     cause,
+		})
+	}
+}
+
+// isStaleEventIDError returns true if the given error means the server
+// doesn't know the given last event ID.
+func isStaleEventIDError(err *Error) bool {
+
+	return err.Code == http.StatusBadRequest || err.Code == http.StatusNotFound || err.Code == http.StatusGone
+}
+
 // Stop stops a running PushCenter.
 func (p *PushCenter) Stop() error {
 
+	p.lock.Lock()
 	if !p.isRunning {
+		p.lock.Unlock()
 		return errors.New("the push center is not started")
 	}
+	p.isRunning = false
+	cancel := p.cancel
+	p.lock.Unlock()
 
+	cancel()
 	p.stop <- true
-	p.isRunning = false
 
 	return nil
 }
@@ -25,10 +25,13 @@ package bambou
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -117,6 +120,454 @@ func TestPushCenter_HandlersRegistration(t *testing.T) {
 	})
 }
 
+func TestPushCenter_TypedHandlersRegistration(t *testing.T) {
+
+	Convey("Given I create a new PushCenter and a handler", t, func() {
+
+		p := NewPushCenter(nil)
+		h := func(*Event) {}
+
+		Convey("When I register the handler for an identity and an event type", func() {
+			p.RegisterHandlerForIdentityAndEventType(h, FakeIdentity, EventTypeCreate)
+
+			Convey("Then it should be registered for that identity and event type", func() {
+				So(p.HasHandlerForIdentityAndEventType(FakeIdentity, EventTypeCreate), ShouldBeTrue)
+			})
+
+			Convey("Then it should not be registered for another event type", func() {
+				So(p.HasHandlerForIdentityAndEventType(FakeIdentity, EventTypeDelete), ShouldBeFalse)
+			})
+
+			Convey("Then it should not be registered for the whole identity", func() {
+				So(p.HasHandlerForIdentity(FakeIdentity), ShouldBeFalse)
+			})
+
+			Convey("When I unregister the handler for that identity and event type", func() {
+
+				p.UnregisterHandlerForIdentityAndEventType(FakeIdentity, EventTypeCreate)
+
+				Convey("Then it should not be registered anymore", func() {
+					So(p.HasHandlerForIdentityAndEventType(FakeIdentity, EventTypeCreate), ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
+
+func TestPushCenter_dispatch(t *testing.T) {
+
+	Convey("Given I create a new PushCenter and register handlers", t, func() {
+
+		p := NewPushCenter(nil)
+		var calls []string
+
+		p.RegisterHandlerForIdentity(func(*Event) { calls = append(calls, "all") }, AllIdentity)
+		p.RegisterHandlerForIdentity(func(*Event) { calls = append(calls, "fake") }, FakeIdentity)
+		p.RegisterHandlerForIdentityAndEventType(func(*Event) { calls = append(calls, "all-create") }, AllIdentity, EventTypeCreate)
+		p.RegisterHandlerForIdentityAndEventType(func(*Event) { calls = append(calls, "fake-create") }, FakeIdentity, EventTypeCreate)
+		p.RegisterHandlerForIdentityAndEventType(func(*Event) { calls = append(calls, "fake-delete") }, FakeIdentity, EventTypeDelete)
+
+		Convey("When I dispatch a notification with a create event", func() {
+
+			n := NewNotification()
+			n.UUID = "uuid"
+			n.Events = EventsList{&Event{EntityType: "fake", Type: EventTypeCreate, DataMap: []map[string]interface{}{{"ID": "x"}}}}
+			p.dispatch(n)
+
+			Convey("Then the matching handlers should be called in order", func() {
+				So(calls, ShouldResemble, []string{"all", "all-create", "fake", "fake-create"})
+			})
+
+			Convey("Then the last event ID should be set", func() {
+				So(p.LastEventID(), ShouldEqual, "uuid")
+			})
+		})
+
+		Convey("When I dispatch a notification with an event without entities", func() {
+
+			n := NewNotification()
+			n.Events = EventsList{&Event{EntityType: "fake", Type: EventTypeUpdate}}
+
+			Convey("Then it should not panic", func() {
+				So(func() { p.dispatch(n) }, ShouldNotPanic)
+				So(calls, ShouldResemble, []string{"all", "fake"})
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetIdentityFilter(t *testing.T) {
+
+	Convey("Given I create a new PushCenter with a default handler", t, func() {
+
+		p := NewPushCenter(nil)
+		var received []string
+		p.RegisterHandlerForIdentity(func(e *Event) { received = append(received, e.EntityType) }, AllIdentity)
+
+		n := NewNotification()
+		n.UUID = "uuid"
+		n.Events = EventsList{
+			&Event{EntityType: "fake", DataMap: []map[string]interface{}{{"ID": "x"}}},
+			&Event{EntityType: "other", DataMap: []map[string]interface{}{{"ID": "y"}}},
+		}
+
+		Convey("When I set an identity filter and dispatch a notification", func() {
+
+			p.SetIdentityFilter(FakeIdentity)
+			p.dispatch(n)
+
+			Convey("Then only the events about the filtered identities should be received", func() {
+				So(received, ShouldResemble, []string{"fake"})
+			})
+
+			Convey("Then the dropped events should not be decoded", func() {
+				So(n.Events[1].Data, ShouldBeNil)
+			})
+
+			Convey("Then the last event ID should still be set", func() {
+				So(p.LastEventID(), ShouldEqual, "uuid")
+			})
+		})
+
+		Convey("When I remove the filter and dispatch a notification", func() {
+
+			p.SetIdentityFilter(FakeIdentity)
+			p.SetIdentityFilter()
+			p.dispatch(n)
+
+			Convey("Then all events should be received", func() {
+				So(received, ShouldResemble, []string{"fake", "other"})
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetDeduplicationWindow(t *testing.T) {
+
+	Convey("Given I create a new PushCenter with a default handler", t, func() {
+
+		p := NewPushCenter(nil)
+		var received []string
+		p.RegisterHandlerForIdentity(func(e *Event) { received = append(received, e.EntityType) }, AllIdentity)
+
+		n := NewNotification()
+		n.UUID = "uuid"
+		n.Events = EventsList{&Event{EntityType: "fake"}}
+
+		Convey("When I dispatch the same notification twice without deduplication", func() {
+
+			p.dispatch(n)
+			p.dispatch(n)
+
+			Convey("Then the handler should be called twice", func() {
+				So(received, ShouldResemble, []string{"fake", "fake"})
+			})
+		})
+
+		Convey("When I set a deduplication window and dispatch the same notification twice", func() {
+
+			p.SetDeduplicationWindow(10)
+			p.dispatch(n)
+			p.dispatch(n)
+
+			Convey("Then the handler should be called once", func() {
+				So(received, ShouldResemble, []string{"fake"})
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetOrderingCheck(t *testing.T) {
+
+	Convey("Given I create a new PushCenter with a resync handler", t, func() {
+
+		p := NewPushCenter(nil)
+		var resyncs []*Error
+		var received []int64
+		p.SetResyncRequiredHandler(func(err *Error) { resyncs = append(resyncs, err) })
+		p.RegisterHandlerForIdentity(func(e *Event) { received = append(received, e.ReceivedTime) }, AllIdentity)
+
+		n := NewNotification()
+		n.UUID = "uuid"
+		n.Events = EventsList{&Event{EntityType: "fake", ReceivedTime: 2}, &Event{EntityType: "fake", ReceivedTime: 1}}
+
+		Convey("When I dispatch events out of order without ordering check", func() {
+
+			p.dispatch(n)
+
+			Convey("Then the resync handler should not be called", func() {
+				So(resyncs, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I enable the ordering check and dispatch events out of order", func() {
+
+			p.SetOrderingCheck(true)
+			p.dispatch(n)
+
+			Convey("Then the resync handler should be called with an EventOrderError", func() {
+				So(len(resyncs), ShouldEqual, 1)
+				So(resyncs[0].Details, ShouldResemble, &EventOrderError{NotificationUUID: "uuid", LastReceivedTime: 2, ReceivedTime: 1})
+			})
+
+			Convey("Then all events should be dispatched", func() {
+				So(received, ShouldResemble, []int64{2, 1})
+			})
+		})
+
+		Convey("When I enable the ordering check and dispatch events in order", func() {
+
+			p.SetOrderingCheck(true)
+			n.Events = EventsList{&Event{EntityType: "fake", ReceivedTime: 1}, &Event{EntityType: "fake", ReceivedTime: 1}, &Event{EntityType: "fake", ReceivedTime: 2}}
+			p.dispatch(n)
+
+			Convey("Then the resync handler should not be called", func() {
+				So(resyncs, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetBuffer(t *testing.T) {
+
+	Convey("Given I have a push center with a buffer dropping the newest notifications and a slow handler", t, func() {
+
+		channel := make(chan *Notification)
+		transport := &channelTransport{notifications: channel}
+		p := NewPushCenterWithTransport(transport)
+		p.SetBuffer(1, BackpressureDropNewest)
+
+		started := make(chan bool, 10)
+		unblock := make(chan bool)
+		received := make(chan string, 10)
+		p.RegisterHandlerForIdentity(func(e *Event) {
+			started <- true
+			<-unblock
+			received <- e.DataMap[0]["ID"].(string)
+		}, FakeIdentity)
+
+		p.Start()
+		defer p.Stop()
+
+		Convey("When I receive more notifications than the handler can process", func() {
+
+			for _, ID := range []string{"a", "b", "c"} {
+				n := NewNotification()
+				n.UUID = ID
+				n.Events = EventsList{&Event{EntityType: "fake", DataMap: []map[string]interface{}{{"ID": ID}}}}
+				channel <- n
+
+				// Wait for the handler to be busy with the first notification.
+				if ID == "a" {
+					<-started
+				}
+			}
+
+			// Wait for the last notification to be processed by the push center.
+			channel <- NewNotification()
+			close(unblock)
+
+			Convey("Then the notifications that did not fit in the buffer should be dropped", func() {
+				So(<-received, ShouldEqual, "a")
+				So(<-received, ShouldEqual, "b")
+				So(p.DroppedEvents(), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetWorkers(t *testing.T) {
+
+	Convey("Given I have a push center with 2 workers, a slow handler and a panicking handler", t, func() {
+
+		channel := make(chan *Notification)
+		p := NewPushCenterWithTransport(&channelTransport{notifications: channel})
+		p.SetWorkers(2)
+
+		unblock := make(chan bool)
+		received := make(chan string, 10)
+		p.RegisterHandlerForIdentity(func(e *Event) { <-unblock; received <- "slow" }, FakeIdentity)
+		p.RegisterHandlerForIdentity(func(e *Event) { panic("woops") }, Identity{Name: "other", Category: "others"})
+		p.RegisterHandlerForIdentityAndEventType(func(e *Event) { received <- "fast" }, FakeIdentity, EventTypeCreate)
+
+		p.Start()
+		defer p.Stop()
+
+		Convey("When I receive events", func() {
+
+			n := NewNotification()
+			n.Events = EventsList{&Event{EntityType: "other"}, &Event{EntityType: "fake", Type: EventTypeCreate}}
+			channel <- n
+
+			first := <-received
+			close(unblock)
+
+			Convey("Then the panic should be recovered and the fast handler should not wait for the slow one", func() {
+				So(first, ShouldEqual, "fast")
+				So(<-received, ShouldEqual, "slow")
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetDeadLetterHandler(t *testing.T) {
+
+	Convey("Given I have a push center with a failing handler and a dead letter handler", t, func() {
+
+		p := NewPushCenter(nil)
+		p.SetHandlerRetries(1)
+
+		calls := 0
+		p.RegisterFallibleHandlerForIdentityAndEventType(func(*Event) error { calls++; return errors.New("woops") }, FakeIdentity, EventTypeDelete)
+
+		var letters []*DeadLetter
+		p.SetDeadLetterHandler(func(d *DeadLetter) { letters = append(letters, d) })
+
+		Convey("When I dispatch an event", func() {
+
+			n := NewNotification()
+			n.Events = EventsList{&Event{EntityType: "fake", Type: EventTypeDelete}}
+			p.dispatch(n)
+
+			Convey("Then the handler should be retried", func() {
+				So(calls, ShouldEqual, 2)
+			})
+
+			Convey("Then the event should be sent to the dead letter handler", func() {
+				So(len(letters), ShouldEqual, 1)
+				So(letters[0].Error.Error(), ShouldEqual, "woops")
+				So(letters[0].Attempts, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetCache(t *testing.T) {
+
+	Convey("Given I have a push center with a cache", t, func() {
+
+		p := NewPushCenter(nil)
+		cache := &fakeInvalidator{}
+		p.SetCache(cache)
+
+		var cached []string
+		p.RegisterHandlerForIdentity(func(*Event) { cached = append([]string{}, cache.invalidated...) }, FakeIdentity)
+
+		Convey("When I dispatch a delete event", func() {
+
+			n := NewNotification()
+			n.Events = EventsList{&Event{EntityType: "fake", Type: EventTypeDelete, DataMap: []map[string]interface{}{{"ID": "x"}}}}
+			p.dispatch(n)
+
+			Convey("Then the entity should be invalidated before the handlers are called", func() {
+				So(cached, ShouldResemble, []string{"fake/x"})
+			})
+		})
+	})
+}
+
+func TestPushCenter_Replay(t *testing.T) {
+
+	Convey("Given I have a push center and a journal", t, func() {
+
+		p := NewPushCenter(nil)
+		var received []string
+		p.RegisterHandlerForIdentity(func(e *Event) { received = append(received, e.Type) }, FakeIdentity)
+
+		journal := `{"time": "2017-01-01T00:00:00Z", "notification": {"uuid": "a", "events": [{"type": "CREATE", "entityType": "fake", "entities": [{"ID": "x"}]}]}}
+{"time": "2017-01-01T00:00:01Z", "notification": {"uuid": "b", "events": [{"type": "DELETE", "entityType": "fake", "entities": [{"ID": "x"}]}]}}
+`
+
+		Convey("When I replay the journal", func() {
+
+			err := p.Replay(strings.NewReader(journal))
+
+			Convey("Then the handlers should be called", func() {
+				So(err, ShouldBeNil)
+				So(received, ShouldResemble, []string{EventTypeCreate, EventTypeDelete})
+				So(p.LastEventID(), ShouldEqual, "b")
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetStaleTimeout(t *testing.T) {
+
+	Convey("Given I have a push center with a server that never answers", t, func() {
+
+		transport := &silentTransport{}
+		p := NewPushCenterWithTransport(transport)
+		p.SetStaleTimeout(10 * time.Millisecond)
+
+		stale := make(chan bool, 10)
+		p.SetStaleHandler(func() { stale <- true })
+
+		Convey("When I start the push center", func() {
+
+			p.Start()
+			<-stale
+			<-stale
+			p.Stop()
+
+			Convey("Then the push center should have reconnected", func() {
+				So(transport.callCount(), ShouldBeGreaterThanOrEqualTo, 2)
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetMetrics(t *testing.T) {
+
+	Convey("Given I have a push center with metrics", t, func() {
+
+		p := NewPushCenter(nil)
+		m := NewMemoryMetrics()
+		p.SetMetrics(m)
+
+		Convey("When I dispatch events", func() {
+
+			n := NewNotification()
+			n.Events = EventsList{
+				&Event{EntityType: "fake", Type: EventTypeCreate, ReceivedTime: time.Now().Add(-time.Minute).UnixNano() / int64(time.Millisecond)},
+				&Event{EntityType: "fake", Type: EventTypeCreate},
+				&Event{EntityType: "fake", Type: EventTypeDelete},
+			}
+			p.dispatch(n)
+
+			Convey("Then the received events should be counted", func() {
+				So(m.Counter(MetricEventsReceived, Labels{"entity_type": "fake", "type": EventTypeCreate}), ShouldEqual, 2)
+				So(m.Counter(MetricEventsReceived, Labels{"entity_type": "fake", "type": EventTypeDelete}), ShouldEqual, 1)
+			})
+
+			Convey("Then the lag should be reported", func() {
+				So(m.Gauge(MetricEventLag, nil), ShouldBeGreaterThanOrEqualTo, 60)
+			})
+
+			Convey("Then the dispatch durations should be reported", func() {
+				So(len(m.Durations(MetricEventDispatch, Labels{"entity_type": "fake"})), ShouldEqual, 3)
+			})
+		})
+
+		Convey("When the stream is stale", func() {
+
+			p.transport = &silentTransport{}
+			p.SetStaleTimeout(10 * time.Millisecond)
+			stale := make(chan bool, 10)
+			p.SetStaleHandler(func() { stale <- true })
+
+			p.Start()
+			<-stale
+			<-stale
+			p.Stop()
+
+			Convey("Then the reconnections should be counted", func() {
+				So(m.Counter(MetricEventReconnects, nil), ShouldBeGreaterThanOrEqualTo, 1)
+				So(m.Counter(MetricEventStreamStale, nil), ShouldBeGreaterThanOrEqualTo, 1)
+			})
+		})
+	})
+}
+
 func TestPushCenter_Start(t *testing.T) {
 
 	Convey("Given I create a new PushCenter and resgister a handler", t, func() {
@@ -205,6 +656,139 @@ func TestPushCenter_Start(t *testing.T) {
 	})
 }
 
+func TestPushCenter_Reconnect(t *testing.T) {
+
+	Convey("Given I have a server that fails before sending events", t, func() {
+
+		var lock sync.Mutex
+		c := 0
+		var uuids []string
+		received := make(chan *Event, 10)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			c++
+			uuids = append(uuids, r.URL.Query().Get("uuid"))
+
+			switch c {
+			case 1, 2:
+				http.Error(w, "woops", http.StatusInternalServerError)
+			case 3:
+				fmt.Fprint(w, `{"uuid": "a", "events": [{"type": "CREATE", "entityType": "fake", "updateMechanism": "DEFAULT", "entities": [{"ID": "x"}]}]}`)
+			case 4:
+				http.Error(w, "unknown uuid", http.StatusBadRequest)
+			case 5:
+				fmt.Fprint(w, `{"uuid": "b", "events": [{"type": "CREATE", "entityType": "fake", "updateMechanism": "DEFAULT", "entities": [{"ID": "y"}]}]}`)
+			default:
+				time.Sleep(100 * time.Millisecond)
+				fmt.Fprint(w, `{"uuid": "b", "events": []}`)
+			}
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		p := NewPushCenter(session)
+		p.SetReconnectBackoff(10*time.Millisecond, 20*time.Millisecond)
+		p.RegisterHandlerForIdentity(func(e *Event) { received <- e }, FakeIdentity)
+
+		resyncs := make(chan *Error, 1)
+		p.SetResyncRequiredHandler(func(err *Error) { resyncs <- err })
+
+		Convey("When I start the push center", func() {
+
+			p.Start()
+			e1 := <-received
+			resync := <-resyncs
+			e2 := <-received
+			p.Stop()
+
+			Convey("Then I should receive the events after reconnecting", func() {
+				So(e1.DataMap[0]["ID"], ShouldEqual, "x")
+				So(e2.DataMap[0]["ID"], ShouldEqual, "y")
+			})
+
+			Convey("Then the resync handler should have been called", func() {
+				So(resync.Title, ShouldEqual, "Event channel lost")
+				So(resync.Code, ShouldEqual, http.StatusBadRequest)
+			})
+
+			Convey("Then the push center should have resumed from the right event IDs", func() {
+				lock.Lock()
+				defer lock.Unlock()
+				So(uuids[:5], ShouldResemble, []string{"", "", "", "a", ""})
+			})
+		})
+	})
+}
+
+func TestPushCenter_SetCheckpointer(t *testing.T) {
+
+	Convey("Given I have a server and a checkpointer with a saved event ID", t, func() {
+
+		uuids := make(chan string, 10)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uuids <- r.URL.Query().Get("uuid")
+			if r.URL.Query().Get("uuid") == "saved" {
+				fmt.Fprint(w, `{"uuid": "next", "events": [{"type": "CREATE", "entityType": "fake", "updateMechanism": "DEFAULT", "entities": [{"ID": "x"}]}]}`)
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+			fmt.Fprint(w, `{"uuid": "next", "events": []}`)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		checkpointer := NewMemoryCheckpointer()
+		checkpointer.Save("saved")
+
+		received := make(chan *Event, 1)
+		p := NewPushCenter(session)
+		p.SetCheckpointer(checkpointer)
+		p.RegisterHandlerForIdentity(func(e *Event) { received <- e }, FakeIdentity)
+
+		Convey("When I start the push center and receive an event", func() {
+
+			p.Start()
+			<-received
+			first := <-uuids
+			second := <-uuids
+			p.Stop()
+
+			Convey("Then it should have resumed from the saved event ID", func() {
+				So(first, ShouldEqual, "saved")
+				So(second, ShouldEqual, "next")
+			})
+
+			Convey("Then the new event ID should be saved", func() {
+				eventID, _ := checkpointer.Load()
+				So(eventID, ShouldEqual, "next")
+			})
+		})
+	})
+}
+
+func TestPushCenter_nextBackoff(t *testing.T) {
+
+	Convey("Given I have a push center with a backoff between 1s and 5s", t, func() {
+
+		p := NewPushCenter(nil)
+		p.SetReconnectBackoff(time.Second, 5*time.Second)
+
+		Convey("Then the backoff should grow exponentially up to the maximum", func() {
+			So(p.nextBackoff(0), ShouldEqual, time.Second)
+			So(p.nextBackoff(time.Second), ShouldEqual, 2*time.Second)
+			So(p.nextBackoff(2*time.Second), ShouldEqual, 4*time.Second)
+			So(p.nextBackoff(4*time.Second), ShouldEqual, 5*time.Second)
+		})
+	})
+}
+
 func TestPushCenter_Stop(t *testing.T) {
 
 	Convey("Given I have a started Push Center", t, func() {
@@ -225,6 +809,10 @@ func TestPushCenter_Stop(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("Then it should be running", func() {
+			So(p.IsRunning(), ShouldBeTrue)
+		})
+
 		Convey("When I start it again", func() {
 
 			err = p.Start()
@@ -253,3 +841,83 @@ func TestPushCenter_Stop(t *testing.T) {
 		})
 	})
 }
+
+// blockingTransport is an EventTransport waiting for its context to be done.
+type blockingTransport struct {
+	returned chan *Error
+}
+
+func (t *blockingTransport) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
+	<-ctx.Done()
+	err := newCanceledError(ctx)
+	t.returned <- err
+
+	return err
+}
+
+func TestPushCenter_StopCancels(t *testing.T) {
+
+	Convey("Given I have a Push Center waiting for the next event", t, func() {
+
+		transport := &blockingTransport{returned: make(chan *Error, 1)}
+		p := NewPushCenterWithTransport(transport)
+		p.Start()
+
+		Convey("When I stop the push center", func() {
+
+			p.Stop()
+
+			var err *Error
+			select {
+			case err = <-transport.returned:
+			case <-time.After(time.Second):
+			}
+
+			Convey("Then the pending request should be canceled", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Canceled")
+			})
+		})
+	})
+}
+
+// channelTransport is an EventTransport sending the notifications of a channel.
+type channelTransport struct {
+	notifications chan *Notification
+}
+
+func (t *channelTransport) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
+	select {
+	case notification := <-t.notifications:
+		return sendNotification(ctx, channel, notification)
+	case <-ctx.Done():
+		return newCanceledError(ctx)
+	}
+}
+
+// silentTransport is an EventTransport that never receives anything.
+type silentTransport struct {
+	calls int
+	lock  sync.Mutex
+}
+
+func (t *silentTransport) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
+	t.lock.Lock()
+	t.calls++
+	t.lock.Unlock()
+
+	<-ctx.Done()
+
+	return newCanceledError(ctx)
+}
+
+func (t *silentTransport) callCount() int {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.calls
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// FetchEntityRaw fetches the given object from the server and returns its JSON as is,
+// with the headers of the response, without decoding it: the object only gives the URL.
+// It allows the tools that only pass the JSON along, like exporters and proxies, to keep
+// the attributes their models do not know. The cache of the session is not used.
+func (s *Session) FetchEntityRaw(object Identifiable) (json.RawMessage, http.Header, *Error) {
+
+	url, berr := s.getPersonalURL(object)
+	if berr != nil {
+		return nil, nil, berr
+	}
+
+	body, header, release, berr := s.getChildrenBody(url, nil)
+	if berr != nil {
+		return nil, nil, berr
+	}
+	defer release()
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, nil, NewBambouError("JSON unmarshalling error", err.Error())
+	}
+
+	if len(list) == 0 {
+		return nil, nil, newHTTPError(http.StatusNotFound, "Object not found", "the server returned no "+object.Identity().Name)
+	}
+
+	return append(json.RawMessage(nil), list[0]...), header, nil
+}
+
+// FetchChildrenRaw fetches the children of the given identity of the given parent, with
+// the given fetching information, and returns their JSON as is, with the headers of the
+// response, without decoding them. The fetching information is filled like with
+// FetchChildren, and can be nil. The cache of the session is not used.
+func (s *Session) FetchChildrenRaw(parent Identifiable, identity Identity, info *FetchingInfo) ([]json.RawMessage, http.Header, *Error) {
+
+	url, berr := s.getURLForChildrenIdentity(parent, identity)
+	if berr != nil {
+		return nil, nil, berr
+	}
+
+	body, header, release, berr := s.getChildrenBody(url, info)
+	if berr != nil {
+		return nil, nil, berr
+	}
+	defer release()
+
+	if len(body) == 0 {
+		return nil, header, nil
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, nil, NewBambouError("JSON unmarshalling error", err.Error())
+	}
+
+	// The elements reference the pooled body.
+	for i := range list {
+		list[i] = append(json.RawMessage(nil), list[i]...)
+	}
+
+	return list, header, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_FetchRaw(t *testing.T) {
+
+	Convey("Given I have a server returning attributes unknown to the models", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Nuage-Count", "2")
+			switch r.URL.Path {
+			case "/fakes/1":
+				w.Write([]byte(`[{"ID": "1", "name": "one", "newAttribute": {"nested": true}}]`))
+			case "/fakes/empty":
+				w.Write([]byte(`[]`))
+			case "/fakes/1/fakes":
+				w.Write([]byte(`[{"ID": "2", "name": "two", "newAttribute": 2}, {"ID": "3", "name": 3}]`))
+			case "/fakes/2/fakes":
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.Write([]byte(`{"not": "a list"}`))
+			}
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch an object as raw JSON", func() {
+
+			raw, header, err := session.FetchEntityRaw(NewFakeObject("1"))
+
+			Convey("Then I should get its JSON as is", func() {
+				So(err, ShouldBeNil)
+				So(string(raw), ShouldEqual, `{"ID": "1", "name": "one", "newAttribute": {"nested": true}}`)
+				So(header.Get("X-Nuage-Count"), ShouldEqual, "2")
+			})
+		})
+
+		Convey("When I fetch an object the server does not return", func() {
+
+			_, _, err := session.FetchEntityRaw(NewFakeObject("empty"))
+
+			Convey("Then I should get a not found error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		Convey("When I fetch an object that is not a list", func() {
+
+			_, _, err := session.FetchEntityRaw(NewFakeObject("other"))
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "JSON unmarshalling error")
+			})
+		})
+
+		Convey("When I fetch children as raw JSON", func() {
+
+			info := NewFetchingInfo()
+			raws, header, err := session.FetchChildrenRaw(NewFakeObject("1"), FakeIdentity, info)
+
+			Convey("Then I should get their JSON as is, even if the models could not decode it", func() {
+				So(err, ShouldBeNil)
+				So(raws, ShouldHaveLength, 2)
+				So(string(raws[0]), ShouldEqual, `{"ID": "2", "name": "two", "newAttribute": 2}`)
+				So(string(raws[1]), ShouldEqual, `{"ID": "3", "name": 3}`)
+				So(header.Get("X-Nuage-Count"), ShouldEqual, "2")
+				So(info.TotalCount, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I fetch children without content", func() {
+
+			raws, _, err := session.FetchChildrenRaw(NewFakeObject("2"), FakeIdentity, nil)
+
+			Convey("Then I should get no children", func() {
+				So(err, ShouldBeNil)
+				So(raws, ShouldBeEmpty)
+			})
+		})
+	})
+}
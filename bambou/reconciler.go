@@ -0,0 +1,410 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultReconcilerKey is the attribute matching the desired objects with the live ones by default.
+const DefaultReconcilerKey = "name"
+
+// PlanAction is the action of a PlannedChange.
+type PlanAction string
+
+// The actions of a PlannedChange.
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+)
+
+// PlannedChange is a change to apply to the live objects.
+type PlannedChange struct {
+	Action PlanAction
+
+	// Path designates the object by its ancestors and itself in the manifest,
+	// like enterprise[name=acme]/domain[name=default].
+	Path string
+
+	Identity Identity
+
+	// ID is the ID of the live object, empty for a creation.
+	ID string
+
+	// Changes are the changed attributes of an update.
+	Changes map[string]AttributeChange
+
+	object Identifiable
+	parent *planTarget
+}
+
+// planTarget is an object of the plan, live or to create, whose ID
+// is known when its creation is applied.
+type planTarget struct {
+	object Identifiable
+}
+
+// Plan is the list of changes making the live objects match a manifest. See Reconciler.
+type Plan struct {
+	Changes []*PlannedChange
+}
+
+// HasChanges returns true if the live objects drifted from the manifest.
+func (p *Plan) HasChanges() bool {
+
+	return len(p.Changes) > 0
+}
+
+// String returns a human readable representation of the plan.
+func (p *Plan) String() string {
+
+	if !p.HasChanges() {
+		return "No changes.\n"
+	}
+
+	var b strings.Builder
+	symbols := map[PlanAction]string{PlanCreate: "+", PlanUpdate: "~", PlanDelete: "-"}
+	counts := map[PlanAction]int{}
+
+	for _, change := range p.Changes {
+
+		counts[change.Action]++
+
+		fmt.Fprintf(&b, "%s %s %s", symbols[change.Action], change.Action, change.Path)
+		if change.ID != "" {
+			fmt.Fprintf(&b, " (%s)", change.ID)
+		}
+		b.WriteString("\n")
+
+		names := make([]string, 0, len(change.Changes))
+		for name := range change.Changes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			oldValue, _ := json.Marshal(change.Changes[name].Old)
+			newValue, _ := json.Marshal(change.Changes[name].New)
+			fmt.Fprintf(&b, "    %s: %s => %s\n", name, oldValue, newValue)
+		}
+	}
+
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.\n", counts[PlanCreate], counts[PlanUpdate], counts[PlanDelete])
+
+	return b.String()
+}
+
+// DecodeManifest reads the desired objects from the YAML or JSON of the given reader:
+// a tree, or a list of trees, in the format of TreeNode.
+func DecodeManifest(r io.Reader) ([]*TreeNode, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*TreeNode
+	if err := yaml.Unmarshal(data, &nodes); err == nil {
+		return nodes, nil
+	}
+
+	node := &TreeNode{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+
+	return []*TreeNode{node}, nil
+}
+
+// Reconciler makes the live objects match a manifest of desired objects, in two
+// steps: Plan computes the changes to make, and Apply makes them.
+//
+// The desired objects are matched with the live children of the same identity of
+// their parent by their key attribute, DefaultReconcilerKey unless set with SetKey.
+// The unmatched desired objects are created, and the attributes of the matched ones
+// that differ are updated. Like with ApplyChild, the attributes omitted from the
+// encoding of the manifest objects are left untouched. With SetPrune, the live
+// children of the identities of the manifest that are not in it are deleted. The
+// live objects without key are ignored.
+type Reconciler struct {
+	storer Storer
+	keys   map[string]string
+	prune  bool
+
+	// layout, if set, gives the identities of the children pruned under each
+	// live parent even if the manifest has none of them.
+	layout TreeLayout
+}
+
+// NewReconciler returns a new *Reconciler of the objects of the given Storer.
+func NewReconciler(storer Storer) *Reconciler {
+
+	return &Reconciler{
+		storer: storer,
+		keys:   map[string]string{},
+	}
+}
+
+// SetKey sets the attribute matching the desired objects of the given identity with the live ones.
+func (r *Reconciler) SetKey(identity Identity, attribute string) {
+
+	r.keys[identity.Name] = attribute
+}
+
+// SetPrune sets if the live objects that are not in the manifest are deleted.
+// Only the children of the identities present under the same parent in the manifest are deleted.
+func (r *Reconciler) SetPrune(prune bool) {
+
+	r.prune = prune
+}
+
+// Plan returns the changes making the children of the given parent match the given manifest.
+func (r *Reconciler) Plan(parent Identifiable, manifest []*TreeNode) (*Plan, *Error) {
+
+	plan := &Plan{}
+	if err := r.planChildren(plan, &planTarget{object: parent}, true, "", manifest); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Apply applies the changes of the given plan, in order, and stops at the first error.
+// The plan is not checked against the live objects again.
+func (r *Reconciler) Apply(plan *Plan) *Error {
+
+	for _, change := range plan.Changes {
+
+		var err *Error
+
+		switch change.Action {
+		case PlanCreate:
+			err = r.storer.CreateChild(change.parent.object, change.object)
+		case PlanUpdate:
+			err = r.storer.SaveEntity(change.object)
+		case PlanDelete:
+			err = r.storer.DeleteEntity(change.object)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planChildren plans the changes of the children of the given parent. If the parent is
+// not live, all its children are created.
+func (r *Reconciler) planChildren(plan *Plan, parent *planTarget, live bool, path string, nodes []*TreeNode) *Error {
+
+	var identities []Identity
+	byIdentity := map[string][]*TreeNode{}
+	for _, node := range nodes {
+		name := node.Object.Identity().Name
+		if _, ok := byIdentity[name]; !ok {
+			identities = append(identities, node.Object.Identity())
+		}
+		byIdentity[name] = append(byIdentity[name], node)
+	}
+
+	if live && r.prune {
+		for _, identity := range r.layout[parent.object.Identity().Name] {
+			if _, ok := byIdentity[identity.Name]; !ok {
+				identities = append(identities, identity)
+				byIdentity[identity.Name] = nil
+			}
+		}
+	}
+
+	var deletions []*PlannedChange
+
+	for _, identity := range identities {
+
+		key := r.key(identity)
+
+		existing := map[string]Identifiable{}
+		var order []string
+		if live {
+			children, err := fetchRegisteredChildren(r.storer, parent.object, identity)
+			if err != nil {
+				return err
+			}
+			for _, child := range children {
+				value, err := keyOf(child, key)
+				if err != nil {
+					return err
+				}
+				if value == "" {
+					continue
+				}
+				if _, ok := existing[value]; ok {
+					return NewBambouError("Ambiguous key", fmt.Sprintf("several %s under %s have the %s %s", identity.Category, pathOrParent(path), key, value))
+				}
+				existing[value] = child
+				order = append(order, value)
+			}
+		}
+
+		desired := map[string]bool{}
+
+		for _, node := range byIdentity[identity.Name] {
+
+			value, err := keyOf(node.Object, key)
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				return NewBambouError("Invalid manifest", fmt.Sprintf("a %s under %s has no %s", identity.Name, pathOrParent(path), key))
+			}
+			if desired[value] {
+				return NewBambouError("Invalid manifest", fmt.Sprintf("several %s under %s have the %s %s", identity.Category, pathOrParent(path), key, value))
+			}
+			desired[value] = true
+
+			nodePath := fmt.Sprintf("%s[%s=%s]", identity.Name, key, value)
+			if path != "" {
+				nodePath = path + "/" + nodePath
+			}
+
+			target := &planTarget{}
+
+			if current, ok := existing[value]; ok {
+
+				changes, patched, err := diffAttributes(codecOf(r.storer), node.Object, current)
+				if err != nil {
+					return err
+				}
+
+				target.object = current
+				if len(changes) > 0 {
+					plan.Changes = append(plan.Changes, &PlannedChange{
+						Action:   PlanUpdate,
+						Path:     nodePath,
+						Identity: identity,
+						ID:       current.Identifier(),
+						Changes:  changes,
+						object:   patched,
+						parent:   parent,
+					})
+				}
+
+				if err := r.planChildren(plan, target, true, nodePath, node.Children); err != nil {
+					return err
+				}
+				continue
+			}
+
+			object, err := Clone(node.Object)
+			if err != nil {
+				return err
+			}
+
+			target.object = object
+			plan.Changes = append(plan.Changes, &PlannedChange{
+				Action:   PlanCreate,
+				Path:     nodePath,
+				Identity: identity,
+				object:   object,
+				parent:   parent,
+			})
+
+			if err := r.planChildren(plan, target, false, nodePath, node.Children); err != nil {
+				return err
+			}
+		}
+
+		if !r.prune {
+			continue
+		}
+
+		for _, value := range order {
+
+			if desired[value] {
+				continue
+			}
+
+			nodePath := fmt.Sprintf("%s[%s=%s]", identity.Name, key, value)
+			if path != "" {
+				nodePath = path + "/" + nodePath
+			}
+
+			deletions = append(deletions, &PlannedChange{
+				Action:   PlanDelete,
+				Path:     nodePath,
+				Identity: identity,
+				ID:       existing[value].Identifier(),
+				object:   existing[value],
+				parent:   parent,
+			})
+		}
+	}
+
+	plan.Changes = append(plan.Changes, deletions...)
+
+	return nil
+}
+
+// key returns the key attribute of the given identity.
+func (r *Reconciler) key(identity Identity) string {
+
+	if key, ok := r.keys[identity.Name]; ok {
+		return key
+	}
+
+	return DefaultReconcilerKey
+}
+
+// keyOf returns the value of the given key attribute of the given object.
+func keyOf(object Identifiable, key string) (string, *Error) {
+
+	attributes, err := attributesOf(object)
+	if err != nil {
+		return "", err
+	}
+
+	if attributes[key] == nil {
+		return "", nil
+	}
+
+	return fmt.Sprint(attributes[key]), nil
+}
+
+// pathOrParent returns the given path, or "the parent" if it is empty.
+func pathOrParent(path string) string {
+
+	if path == "" {
+		return "the parent"
+	}
+
+	return path
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type reconciledObject struct {
+	FakeObject
+
+	Description string `json:"description,omitempty"`
+}
+
+const reconcilerManifest = `
+- type: fake
+  attributes:
+    name: a
+    description: new
+  children:
+  - type: fake
+    attributes:
+      name: c
+- type: fake
+  attributes:
+    name: b
+- type: fake
+  attributes:
+    name: d
+  children:
+  - type: fake
+    attributes:
+      name: e
+`
+
+func TestReconciler_Plan(t *testing.T) {
+
+	Convey("Given I have live objects and a manifest", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &reconciledObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		fs := newFakeServer()
+		defer fs.Close()
+
+		fs.add("p", map[string]interface{}{"ID": "a", "name": "a", "description": "old"})
+		fs.add("p", map[string]interface{}{"ID": "b", "name": "b"})
+		fs.add("p", map[string]interface{}{"ID": "x", "name": "x"})
+
+		session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+		reconciler := NewReconciler(session)
+
+		manifest, err := DecodeManifest(strings.NewReader(reconcilerManifest))
+		So(err, ShouldBeNil)
+
+		Convey("When I plan the changes", func() {
+
+			plan, berr := reconciler.Plan(NewFakeObject("p"), manifest)
+
+			Convey("Then I should get the changes to make", func() {
+				So(berr, ShouldBeNil)
+				So(plan.HasChanges(), ShouldBeTrue)
+				So(plan.String(), ShouldEqual, strings.Join([]string{
+					"~ update fake[name=a] (a)",
+					`    description: "old" => "new"`,
+					"+ create fake[name=a]/fake[name=c]",
+					"+ create fake[name=d]",
+					"+ create fake[name=d]/fake[name=e]",
+					"Plan: 3 to create, 1 to update, 0 to delete.",
+					"",
+				}, "\n"))
+				So(fs.count(), ShouldEqual, 3)
+			})
+		})
+
+		Convey("When I plan the changes with pruning", func() {
+
+			reconciler.SetPrune(true)
+			plan, berr := reconciler.Plan(NewFakeObject("p"), manifest)
+
+			Convey("Then the objects that are not in the manifest should be deleted", func() {
+				So(berr, ShouldBeNil)
+				So(len(plan.Changes), ShouldEqual, 5)
+				So(plan.Changes[4].Action, ShouldEqual, PlanDelete)
+				So(plan.Changes[4].ID, ShouldEqual, "x")
+			})
+
+			Convey("When I apply them", func() {
+
+				berr := reconciler.Apply(plan)
+				again, _ := reconciler.Plan(NewFakeObject("p"), manifest)
+
+				Convey("Then the live objects should match the manifest", func() {
+					So(berr, ShouldBeNil)
+					So(fs.get("a")["description"], ShouldEqual, "new")
+					So(fs.get("x"), ShouldBeNil)
+					So(fs.count(), ShouldEqual, 5)
+					So(fs.parents[plan.Changes[1].object.Identifier()], ShouldEqual, "a")
+					So(fs.parents[plan.Changes[3].object.Identifier()], ShouldEqual, plan.Changes[2].object.Identifier())
+					So(again.HasChanges(), ShouldBeFalse)
+					So(again.String(), ShouldEqual, "No changes.\n")
+				})
+			})
+		})
+
+		Convey("When I plan a manifest with a duplicate key", func() {
+
+			manifest, _ := DecodeManifest(strings.NewReader(`[{"type": "fake", "attributes": {"name": "a"}}, {"type": "fake", "attributes": {"name": "a"}}]`))
+			_, berr := reconciler.Plan(NewFakeObject("p"), manifest)
+
+			Convey("Then it should fail", func() {
+				So(berr, ShouldNotBeNil)
+				So(berr.Description, ShouldEqual, "several fakes under the parent have the name a")
+			})
+		})
+
+		Convey("When I plan a manifest with a missing key", func() {
+
+			reconciler.SetKey(FakeIdentity, "description")
+			manifest, _ := DecodeManifest(strings.NewReader(`{"type": "fake", "attributes": {"name": "a"}}`))
+			_, berr := reconciler.Plan(NewFakeObject("p"), manifest)
+
+			Convey("Then it should fail", func() {
+				So(len(manifest), ShouldEqual, 1)
+				So(berr, ShouldNotBeNil)
+				So(berr.Description, ShouldEqual, "a fake under the parent has no description")
+			})
+		})
+	})
+}
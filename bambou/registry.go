@@ -0,0 +1,119 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"sort"
+	"sync"
+)
+
+// IdentifiableFactory is the prototype of a function returning a new Identifiable.
+type IdentifiableFactory func() Identifiable
+
+type registryEntry struct {
+	identity Identity
+	factory  IdentifiableFactory
+}
+
+var (
+	registryByName     = map[string]registryEntry{}
+	registryByCategory = map[string]registryEntry{}
+	registryLock       sync.RWMutex
+)
+
+// RegisterIdentity registers the given factory for the given Identity.
+// Registering the identities allows bambou to instantiate the right concrete
+// type when it only knows the name or the category of an object, for instance
+// when decoding events. Registering an already registered Identity replaces
+// the previous factory.
+func RegisterIdentity(identity Identity, factory IdentifiableFactory) {
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	entry := registryEntry{identity: identity, factory: factory}
+	registryByName[identity.Name] = entry
+	registryByCategory[identity.Category] = entry
+}
+
+// UnregisterIdentity unregisters the given Identity.
+func UnregisterIdentity(identity Identity) {
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	delete(registryByName, identity.Name)
+	delete(registryByCategory, identity.Category)
+}
+
+// IdentityFromName returns the registered Identity with the given name.
+func IdentityFromName(name string) (Identity, bool) {
+
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	entry, ok := registryByName[name]
+	return entry.identity, ok
+}
+
+// IdentityFromCategory returns the registered Identity with the given category.
+func IdentityFromCategory(category string) (Identity, bool) {
+
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	entry, ok := registryByCategory[category]
+	return entry.identity, ok
+}
+
+// NewIdentifiable returns a new Identifiable for the registered Identity with the given name,
+// or nil if there is no such Identity.
+func NewIdentifiable(name string) Identifiable {
+
+	registryLock.RLock()
+	entry, ok := registryByName[name]
+	registryLock.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	return entry.factory()
+}
+
+// RegisteredIdentities returns the list of registered identities sorted by name.
+func RegisteredIdentities() []Identity {
+
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	identities := make([]Identity, 0, len(registryByName))
+	for _, entry := range registryByName {
+		identities = append(identities, entry.identity)
+	}
+
+	sort.Slice(identities, func(i, j int) bool { return identities[i].Name < identities[j].Name })
+
+	return identities
+}
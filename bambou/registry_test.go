@@ -0,0 +1,72 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegistry_RegisterIdentity(t *testing.T) {
+
+	Convey("Given I register an identity", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return NewFakeObject("") })
+		defer UnregisterIdentity(FakeIdentity)
+
+		Convey("Then I should find it by name", func() {
+			identity, ok := IdentityFromName("fake")
+			So(ok, ShouldBeTrue)
+			So(identity, ShouldResemble, FakeIdentity)
+		})
+
+		Convey("Then I should find it by category", func() {
+			identity, ok := IdentityFromCategory("fakes")
+			So(ok, ShouldBeTrue)
+			So(identity, ShouldResemble, FakeIdentity)
+		})
+
+		Convey("Then I should be able to instantiate it", func() {
+			So(NewIdentifiable("fake"), ShouldHaveSameTypeAs, NewFakeObject(""))
+		})
+
+		Convey("Then it should be in the list of registered identities", func() {
+			So(RegisteredIdentities(), ShouldContain, FakeIdentity)
+		})
+
+		Convey("When I unregister it", func() {
+
+			UnregisterIdentity(FakeIdentity)
+
+			Convey("Then I should not find it anymore", func() {
+				_, ok := IdentityFromName("fake")
+				So(ok, ShouldBeFalse)
+				_, ok = IdentityFromCategory("fakes")
+				So(ok, ShouldBeFalse)
+				So(NewIdentifiable("fake"), ShouldBeNil)
+			})
+		})
+	})
+}
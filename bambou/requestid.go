@@ -0,0 +1,80 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the ID the Session generates for every request,
+// so the logs of the client and the audit logs of the server can be correlated.
+const RequestIDHeader = "X-Request-ID"
+
+// TransactionIDHeaders are the headers of the responses the Session reads the ID
+// the server gives to the requests from, in order.
+var TransactionIDHeaders = []string{"X-Nuage-TransactionID", "X-Transaction-ID"}
+
+// setRequestID sets a new request ID to the given request if it has none,
+// and returns its request ID.
+func setRequestID(request *http.Request) string {
+
+	if requestID := request.Header.Get(RequestIDHeader); requestID != "" {
+		return requestID
+	}
+
+	requestID := newRequestID()
+	request.Header.Set(RequestIDHeader, requestID)
+
+	return requestID
+}
+
+// transactionID returns the ID the server gave to the request of the given response, if any.
+func transactionID(response *http.Response) string {
+
+	if response == nil {
+		return ""
+	}
+
+	for _, header := range TransactionIDHeaders {
+		if ID := response.Header.Get(header); ID != "" {
+			return ID
+		}
+	}
+
+	return ""
+}
+
+// newRequestID returns a new random version 4 UUID.
+func newRequestID() string {
+
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequestID_Session(t *testing.T) {
+
+	Convey("Given I have a session to a server giving transaction IDs", t, func() {
+
+		var requestIDs []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+			w.Header().Set("X-Nuage-TransactionID", "tx-1")
+
+			switch r.URL.Path {
+			case "/fakes/missing":
+				w.WriteHeader(http.StatusInternalServerError)
+			case "/fakes/choice":
+				if r.URL.Query().Get("responseChoice") == "" {
+					w.WriteHeader(http.StatusMultipleChoices)
+					return
+				}
+				fallthrough
+			default:
+				w.Write([]byte(`[{"ID": "xxx"}]`))
+			}
+		}))
+		defer ts.Close()
+
+		logger := &structuredLogger{}
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetLogger(logger)
+
+		Convey("When I fetch two entities", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			session.FetchEntity(NewFakeObject("yyy"))
+
+			Convey("Then each request should have its own request ID", func() {
+				So(len(requestIDs), ShouldEqual, 2)
+				So(requestIDs[0], ShouldNotEqual, requestIDs[1])
+			})
+
+			Convey("Then the IDs should be logged", func() {
+				So(logger.fields[0]["request_id"], ShouldEqual, requestIDs[0])
+				So(logger.fields[1]["request_id"], ShouldEqual, requestIDs[0])
+				So(logger.fields[1]["transaction_id"], ShouldEqual, "tx-1")
+			})
+		})
+
+		Convey("When a request is retried with a response choice", func() {
+
+			session.FetchEntity(NewFakeObject("choice"))
+
+			Convey("Then the retry should keep the request ID", func() {
+				So(len(requestIDs), ShouldEqual, 2)
+				So(requestIDs[1], ShouldEqual, requestIDs[0])
+			})
+		})
+
+		Convey("When a request fails", func() {
+
+			err := session.FetchEntity(NewFakeObject("missing"))
+
+			Convey("Then the error should have the request and transaction IDs", func() {
+				So(err, ShouldNotBeNil)
+				So(err.RequestID, ShouldEqual, requestIDs[0])
+				So(err.TransactionID, ShouldEqual, "tx-1")
+			})
+		})
+
+		Convey("When the session is read only", func() {
+
+			session.SetReadOnly(true)
+			err := session.DeleteEntity(NewFakeObject("xxx"))
+
+			Convey("Then the shared read only error should not get any ID", func() {
+				So(err, ShouldEqual, ErrReadOnly)
+				So(ErrReadOnly.RequestID, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestRequestID_newRequestID(t *testing.T) {
+
+	Convey("Given I generate request IDs", t, func() {
+
+		ID := newRequestID()
+
+		Convey("Then they should be version 4 UUIDs", func() {
+			So(regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$").MatchString(ID), ShouldBeTrue)
+			So(newRequestID(), ShouldNotEqual, ID)
+		})
+	})
+}
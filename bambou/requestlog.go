@@ -0,0 +1,336 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxLoggedBodySize is the default maximum number of bytes of the logged bodies.
+const DefaultMaxLoggedBodySize = 4096
+
+// redactedValue replaces the values of the redacted headers and attributes in the logs.
+const redactedValue = "REDACTED"
+
+// RedactedHeaders are the headers whose values are redacted from the logs.
+var RedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", TokenHeader, OTPHeader}
+
+// RedactedAttributes are the JSON attributes whose values are redacted from the logged bodies, at any depth.
+var RedactedAttributes = []string{"APIKey", "password", "token"}
+
+// Fields are the structured fields of a log entry.
+type Fields map[string]interface{}
+
+// StructuredLogger is implemented by the LeveledLogger that can log structured fields.
+// The requests and responses are logged as fields to them, and as key=value pairs
+// to the other loggers.
+type StructuredLogger interface {
+	LeveledLogger
+	DebugFields(message string, fields Fields)
+}
+
+// SetBodyLogging enables or disables the logging of the bodies of the requests and responses,
+// at the debug level. The bodies are redacted, and truncated to the given maximum size, or to
+// DefaultMaxLoggedBodySize if it is not positive. The bodies are not logged by default.
+func (s *Session) SetBodyLogging(enabled bool, maxSize int) {
+
+	if maxSize <= 0 {
+		maxSize = DefaultMaxLoggedBodySize
+	}
+
+	s.bodyLogging = enabled
+	s.maxLoggedBodySize = maxSize
+}
+
+// logRequest logs the given request.
+func (s *Session) logRequest(request *http.Request) {
+
+	fields := Fields{
+		"method":     request.Method,
+		"url":        request.URL.String(),
+		"headers":    redactHeaders(request.Header),
+		"request_id": request.Header.Get(RequestIDHeader),
+	}
+
+	if s.bodyLogging && request.Body != nil {
+		body, _ := ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		fields["body"] = s.loggedBody(body)
+	}
+
+	logFields(s.getLogger(), "request", fields)
+}
+
+// logResponse logs the given response, received after the given duration.
+// If body logging is enabled, the beginning of the body is read and put back in front
+// of the rest, so that the body is never read further than the logged size here.
+func (s *Session) logResponse(request *http.Request, response *http.Response, duration time.Duration) {
+
+	fields := Fields{
+		"method":     request.Method,
+		"url":        request.URL.String(),
+		"status":     response.StatusCode,
+		"duration":   duration.String(),
+		"headers":    redactHeaders(response.Header),
+		"request_id": request.Header.Get(RequestIDHeader),
+	}
+
+	if transaction := transactionID(response); transaction != "" {
+		fields["transaction_id"] = transaction
+	}
+
+	if s.bodyLogging && response.Body != nil {
+		if isStreamingResponse(response) {
+			fields["body"] = "(stream not logged)"
+		} else {
+			fields["body"] = s.loggedResponseBody(response)
+		}
+	}
+
+	logFields(s.getLogger(), "response", fields)
+}
+
+// loggedBody returns the given body redacted and truncated to the maximum logged size.
+func (s *Session) loggedBody(body []byte) string {
+
+	logged := redactBody(body)
+
+	if len(logged) > s.maxLoggedBodySize {
+		return fmt.Sprintf("%s... (%d bytes truncated)", logged[:s.maxLoggedBodySize], len(logged)-s.maxLoggedBodySize)
+	}
+
+	return logged
+}
+
+// logFields logs the given message and fields at the debug level to the given logger.
+func logFields(logger LeveledLogger, message string, fields Fields) {
+
+	if structured, ok := logger.(StructuredLogger); ok {
+		structured.DebugFields(message, fields)
+		return
+	}
+
+	logger.Debugf("%s %s", message, formatFields(fields))
+}
+
+// formatFields returns the given fields as key=value pairs, sorted by key.
+func formatFields(fields Fields) string {
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + formatValue(fields[key])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// formatValue returns the given value, quoted if it contains spaces or quotes.
+func formatValue(value interface{}) string {
+
+	var s string
+
+	switch v := value.(type) {
+	case string:
+		s = v
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = key + ":" + v[key]
+		}
+		s = "{" + strings.Join(items, ", ") + "}"
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if strings.ContainsAny(s, " \t\n\"") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// redactHeaders returns the given headers, with their values joined, and the
+// values of the RedactedHeaders redacted.
+func redactHeaders(headers http.Header) map[string]string {
+
+	redacted := make(map[string]string, len(headers))
+
+	for name, values := range headers {
+		if isRedacted(name, RedactedHeaders) {
+			redacted[name] = redactedValue
+		} else {
+			redacted[name] = strings.Join(values, ", ")
+		}
+	}
+
+	return redacted
+}
+
+// loggedResponseBody reads at most the maximum logged size of the body of the given
+// response, puts it back in front of the rest of the body, and returns it redacted.
+func (s *Session) loggedResponseBody(response *http.Response) string {
+
+	prefix, _ := ioutil.ReadAll(io.LimitReader(response.Body, int64(s.maxLoggedBodySize)+1))
+	response.Body = &readCloser{
+		Reader: io.MultiReader(bytes.NewReader(prefix), response.Body),
+		Closer: response.Body,
+	}
+
+	if len(prefix) <= s.maxLoggedBodySize {
+		return s.loggedBody(prefix)
+	}
+
+	logged := redactPartialBody(prefix[:s.maxLoggedBodySize])
+	if response.ContentLength > 0 {
+		return fmt.Sprintf("%s... (%d bytes truncated)", logged, response.ContentLength-int64(s.maxLoggedBodySize))
+	}
+
+	return logged + "... (truncated)"
+}
+
+// readCloser reads from a Reader and closes a Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// isStreamingResponse returns true if the body of the given response is a stream of
+// events, that must not be read to its end.
+func isStreamingResponse(response *http.Response) bool {
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+
+	return mediaType == "text/event-stream"
+}
+
+// redactPartialBody returns the given beginning of a JSON body, that cannot be decoded,
+// with the values of the RedactedAttributes redacted.
+func redactPartialBody(body []byte) string {
+
+	if len(RedactedAttributes) == 0 {
+		return string(bytes.TrimSpace(body))
+	}
+
+	names := make([]string, 0, len(RedactedAttributes))
+	for _, name := range RedactedAttributes {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+
+	attributes := regexp.MustCompile(`("(?i:` + strings.Join(names, "|") + `)")\s*:\s*(?:"(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`)
+
+	return string(bytes.TrimSpace(attributes.ReplaceAll(body, []byte(`${1}:"`+redactedValue+`"`))))
+}
+
+// redactBody returns the given body with the values of the RedactedAttributes redacted if it is JSON.
+// Bodies that are not JSON are returned as is.
+func redactBody(body []byte) string {
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return string(bytes.TrimSpace(body))
+	}
+
+	if !redactValue(value) {
+		return string(bytes.TrimSpace(body))
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return string(bytes.TrimSpace(body))
+	}
+
+	return string(data)
+}
+
+// redactValue redacts the values of the RedactedAttributes of the given decoded
+// JSON value, and returns true if any was.
+func redactValue(value interface{}) bool {
+
+	redacted := false
+
+	switch v := value.(type) {
+
+	case map[string]interface{}:
+		for key, item := range v {
+			if isRedacted(key, RedactedAttributes) {
+				if item != nil && item != "" {
+					v[key] = redactedValue
+					redacted = true
+				}
+				continue
+			}
+			redacted = redactValue(item) || redacted
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			redacted = redactValue(item) || redacted
+		}
+	}
+
+	return redacted
+}
+
+// IsRedactedAttribute returns true if the JSON attribute with the given name is one
+// of the RedactedAttributes, whose values must not be exposed.
+func IsRedactedAttribute(name string) bool {
+
+	return isRedacted(name, RedactedAttributes)
+}
+
+func isRedacted(name string, names []string) bool {
+
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// structuredLogger is a StructuredLogger recording the fields of the logs.
+type structuredLogger struct {
+	recordingLogger
+	fields []Fields
+}
+
+func (l *structuredLogger) DebugFields(message string, fields Fields) {
+
+	l.log("debug", "%s", message)
+	l.fields = append(l.fields, fields)
+}
+
+func TestRequestLog_RedactPartialBody(t *testing.T) {
+
+	Convey("Given I have the beginning of a JSON body", t, func() {
+
+		body := []byte(`[{"ID": "xxx", "apikey": "secret-key", "nested": {"password" :"hun`)
+
+		Convey("When I redact it", func() {
+			redacted := redactPartialBody(body)
+
+			Convey("Then the values of the redacted attributes should be redacted", func() {
+				So(redacted, ShouldEqual, `[{"ID": "xxx", "apikey":"REDACTED", "nested": {"password":"REDACTED"`)
+			})
+		})
+	})
+}
+
+func TestRequestLog_Session(t *testing.T) {
+
+	Convey("Given I have a session with a logger", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"ID": "xxx", "name": "remote", "APIKey": "secret-key", "description": "` + strings.Repeat("a", 100) + `"}]`))
+		}))
+		defer ts.Close()
+
+		logger := &recordingLogger{}
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetLogger(logger)
+
+		Convey("When I fetch an entity", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			logs := strings.Join(logger.logs, "\n")
+
+			Convey("Then the request and response should be logged without secrets nor bodies", func() {
+				So(len(logger.logs), ShouldEqual, 2)
+				So(logger.logs[0], ShouldStartWith, "debug request ")
+				So(logger.logs[0], ShouldContainSubstring, "method=GET")
+				So(logger.logs[0], ShouldContainSubstring, "Authorization:REDACTED")
+				So(logger.logs[1], ShouldStartWith, "debug response ")
+				So(logger.logs[1], ShouldContainSubstring, "status=200")
+				So(logs, ShouldNotContainSubstring, "XREST")
+				So(logs, ShouldNotContainSubstring, "body=")
+			})
+		})
+
+		Convey("When I enable body logging and save an entity", func() {
+
+			session.SetBodyLogging(true, 60)
+			session.SaveEntity(&FakeObject{ID: "xxx", Name: "local"})
+
+			Convey("Then the bodies should be logged redacted and truncated", func() {
+				So(logger.logs[0], ShouldContainSubstring, `name\":\"local\"`)
+				So(logger.logs[1], ShouldContainSubstring, `\"APIKey\":\"REDACTED\"`)
+				So(logger.logs[1], ShouldContainSubstring, "bytes truncated)")
+				So(strings.Join(logger.logs, "\n"), ShouldNotContainSubstring, "secret-key")
+			})
+		})
+
+		Convey("When I enable body logging and fetch an entity with a long body", func() {
+
+			session.SetBodyLogging(true, 20)
+			object := NewFakeObject("xxx")
+			err := session.FetchEntity(object)
+
+			Convey("Then the whole body should still be decoded", func() {
+				So(err, ShouldBeNil)
+				So(object.Name, ShouldEqual, "remote")
+				So(logger.logs[1], ShouldContainSubstring, "bytes truncated)")
+			})
+		})
+
+		Convey("When I log a response streaming events", func() {
+
+			session.SetBodyLogging(true, 60)
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+			go writer.Write([]byte("data: {}\n\n"))
+
+			response := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}},
+				Body:       reader,
+			}
+			request, _ := http.NewRequest("GET", ts.URL+"/events", nil)
+			session.logResponse(request, response, time.Second)
+
+			Convey("Then the stream should be left unread", func() {
+				data := make([]byte, 10)
+				n, _ := io.ReadFull(response.Body, data)
+				So(string(data[:n]), ShouldEqual, "data: {}\n\n")
+				So(logger.logs[0], ShouldContainSubstring, "(stream not logged)")
+			})
+		})
+
+		Convey("When I use a structured logger", func() {
+
+			structured := &structuredLogger{}
+			session.SetLogger(structured)
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then it should get the fields", func() {
+				So(len(structured.fields), ShouldEqual, 2)
+				So(structured.fields[0]["method"], ShouldEqual, "GET")
+				So(structured.fields[0]["headers"].(map[string]string)["Authorization"], ShouldEqual, "REDACTED")
+				So(structured.fields[1]["status"], ShouldEqual, 200)
+			})
+		})
+	})
+}
+
+func TestRequestLog_Redaction(t *testing.T) {
+
+	Convey("Given I have bodies with secrets", t, func() {
+
+		Convey("When I redact a JSON body with nested secrets", func() {
+
+			body := redactBody([]byte(`{"name":"a","Password":"p","children":[{"token":"t","APIKey":""}]}`))
+
+			Convey("Then the secrets should be redacted at any depth", func() {
+				So(body, ShouldEqual, `{"Password":"REDACTED","children":[{"APIKey":"","token":"REDACTED"}],"name":"a"}`)
+			})
+		})
+
+		Convey("When I redact a body that is not JSON", func() {
+
+			body := redactBody([]byte("password=p\n"))
+
+			Convey("Then it should be returned as is", func() {
+				So(body, ShouldEqual, "password=p")
+			})
+		})
+
+		Convey("When I check whether attributes are redacted", func() {
+
+			Convey("Then the RedactedAttributes should be, whatever their case", func() {
+				So(IsRedactedAttribute("APIKey"), ShouldBeTrue)
+				So(IsRedactedAttribute("Password"), ShouldBeTrue)
+				So(IsRedactedAttribute("userName"), ShouldBeFalse)
+			})
+		})
+
+		Convey("When I format fields", func() {
+
+			s := formatFields(Fields{"b": "two words", "a": 1, "c": map[string]string{"Y": "2", "X": "1"}})
+
+			Convey("Then they should be sorted and quoted when needed", func() {
+				So(s, ShouldEqual, `a=1 b="two words" c="{X:1, Y:2}"`)
+			})
+		})
+	})
+}
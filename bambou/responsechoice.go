@@ -0,0 +1,123 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DefaultResponseChoice is the responseChoice of the new sessions.
+const DefaultResponseChoice = 1
+
+// responseChoiceParameter is the query parameter confirming a modification to the server.
+const responseChoiceParameter = "responseChoice"
+
+// SetResponseChoice sets the value of the responseChoice parameter the session sends
+// to confirm the modifications the server asks a confirmation for with a 300 Multiple
+// Choices response, like the deletion of an object with children. The updates and
+// deletions are always confirmed, and the other requests are sent again with the
+// parameter when the server asks for it. A choice of 0 disables the confirmations:
+// the 300 responses are then returned as errors.
+func (s *Session) SetResponseChoice(choice int) {
+
+	s.responseChoice = choice
+}
+
+// ResponseChoice returns the value of the responseChoice parameter of the session.
+func (s *Session) ResponseChoice() int {
+
+	return s.responseChoice
+}
+
+// withResponseChoice returns the given URL with the responseChoice parameter of the session, if any,
+// keeping its other query parameters. The URLs that cannot be parsed are returned as is.
+func (s *Session) withResponseChoice(rawURL string) string {
+
+	if s.responseChoice == 0 {
+		return rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	query.Set(responseChoiceParameter, strconv.Itoa(s.responseChoice))
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// responseChoiceRequest returns a copy of the given request answered with a 300 Multiple
+// Choices response, with the responseChoice parameter and a new copy of its body.
+func (s *Session) responseChoiceRequest(request *http.Request) (*http.Request, *Error) {
+
+	query := request.URL.Query()
+	if s.responseChoice == 0 || query.Get(responseChoiceParameter) != "" {
+		return nil, newHTTPError(http.StatusMultipleChoices, "Multiple choices", fmt.Sprintf("the server requires a confirmation of %s %s", request.Method, request.URL.Path))
+	}
+
+	retry := request.Clone(request.Context())
+	query.Set(responseChoiceParameter, strconv.Itoa(s.responseChoice))
+	retry.URL.RawQuery = query.Encode()
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, NewBambouError("HTTP transaction error", err.Error())
+		}
+		retry.Body = body
+	}
+
+	return retry, nil
+}
+
+// replayable makes the body of the given request readable again with GetBody,
+// so that the request can be sent again.
+func replayable(request *http.Request) *Error {
+
+	if request.Body == nil || request.Body == http.NoBody || request.GetBody != nil {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return nil
+}
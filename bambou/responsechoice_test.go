@@ -0,0 +1,156 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// choiceRequest is a request received by a server asking for confirmations.
+type choiceRequest struct {
+	method string
+	query  string
+	body   string
+}
+
+func TestSession_ResponseChoice(t *testing.T) {
+
+	Convey("Given I have a server asking to confirm the modifications", t, func() {
+
+		var requests []choiceRequest
+		var lock sync.Mutex
+		always := false
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+
+			lock.Lock()
+			requests = append(requests, choiceRequest{method: r.Method, query: r.URL.RawQuery, body: string(body)})
+			confirm := always
+			lock.Unlock()
+
+			if confirm || r.URL.Query().Get("responseChoice") == "" {
+				w.WriteHeader(http.StatusMultipleChoices)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "POST" {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`[{"ID": "1", "name": "created"}]`))
+			}
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I create an object", func() {
+
+			object := &FakeObject{Name: "created"}
+			err := session.CreateChild(NewFakeRootObject(), object)
+
+			Convey("Then it should be sent again with the confirmation and the same body", func() {
+				So(err, ShouldBeNil)
+				So(object.ID, ShouldEqual, "1")
+				So(requests, ShouldHaveLength, 2)
+				So(requests[0].query, ShouldBeEmpty)
+				So(requests[1].query, ShouldEqual, "responseChoice=1")
+				So(requests[1].body, ShouldNotBeEmpty)
+				So(requests[1].body, ShouldEqual, requests[0].body)
+				So(session.Stats().Retries, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I create an object with the body logging and debug enabled", func() {
+
+			session.SetBodyLogging(true, 0)
+			session.SetDebug(ioutil.Discard)
+			err := session.CreateChild(NewFakeRootObject(), &FakeObject{Name: "created"})
+
+			Convey("Then the body sent again should not be corrupt", func() {
+				So(err, ShouldBeNil)
+				So(requests, ShouldHaveLength, 2)
+				So(requests[1].body, ShouldEqual, requests[0].body)
+			})
+		})
+
+		Convey("When I save an object with another response choice", func() {
+
+			session.SetResponseChoice(2)
+			err := session.SaveEntity(&FakeObject{ID: "1", Name: "saved"})
+
+			Convey("Then it should be confirmed with the choice", func() {
+				So(err, ShouldBeNil)
+				So(session.ResponseChoice(), ShouldEqual, 2)
+				So(requests, ShouldHaveLength, 1)
+				So(requests[0].query, ShouldEqual, "responseChoice=2")
+			})
+		})
+
+		Convey("When I modify objects with the confirmations disabled", func() {
+
+			session.SetResponseChoice(0)
+			cerr := session.CreateChild(NewFakeRootObject(), &FakeObject{Name: "created"})
+			derr := session.DeleteEntity(NewFakeObject("1"))
+
+			Convey("Then the confirmations should be errors", func() {
+				So(cerr, ShouldNotBeNil)
+				So(cerr.Code, ShouldEqual, http.StatusMultipleChoices)
+				So(derr, ShouldNotBeNil)
+				So(derr.Code, ShouldEqual, http.StatusMultipleChoices)
+				So(requests, ShouldHaveLength, 2)
+				So(requests[1].query, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the server asks for a confirmation again", func() {
+
+			lock.Lock()
+			always = true
+			lock.Unlock()
+			err := session.CreateChild(NewFakeRootObject(), &FakeObject{Name: "created"})
+
+			Convey("Then it should not be sent a third time", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusMultipleChoices)
+				So(requests, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When I add the confirmation to a URL with a query", func() {
+
+			url := session.withResponseChoice(ts.URL + "/objects/1?filter=name")
+
+			Convey("Then the confirmation should be added to the query", func() {
+				So(url, ShouldEqual, ts.URL+"/objects/1?filter=name&responseChoice=1")
+			})
+		})
+	})
+}
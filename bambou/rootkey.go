@@ -0,0 +1,57 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+// APIKey returns the API key of the root object of the session. Unlike the APIKey
+// method of the root object, it is safe to call while the session is used.
+func (s *Session) APIKey() string {
+
+	s.apiKeyLock.RLock()
+	defer s.apiKeyLock.RUnlock()
+
+	return s.root.APIKey()
+}
+
+// SetAPIKey sets the API key of the root object of the session. Unlike the SetAPIKey
+// method of the root object, it is safe to call while the session is used.
+func (s *Session) SetAPIKey(key string) {
+
+	s.apiKeyLock.Lock()
+	defer s.apiKeyLock.Unlock()
+
+	s.root.SetAPIKey(key)
+}
+
+// lockRoot locks the API key of the root object if the given object is a Rootable,
+// before the object is decoded, and returns the function unlocking it.
+func (s *Session) lockRoot(object Identifiable) func() {
+
+	if _, ok := object.(Rootable); !ok {
+		return func() {}
+	}
+
+	s.apiKeyLock.Lock()
+
+	return s.apiKeyLock.Unlock
+}
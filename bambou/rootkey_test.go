@@ -0,0 +1,95 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_APIKeyConcurrency(t *testing.T) {
+
+	Convey("Given I have a session with a root object that is not safe for concurrent use", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/root" {
+				w.Write([]byte(`[{"ID": "root", "APIKey": "key"}]`))
+				return
+			}
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		root := NewRootObject(FakeRootIdentity)
+		session := NewSession("username", "password", "organization", ts.URL, root)
+
+		Convey("When I authenticate, forget the API key and send requests concurrently", func() {
+
+			var wg sync.WaitGroup
+			errs := make(chan *Error, 40)
+
+			for i := 0; i < 10; i++ {
+				wg.Add(4)
+				go func() {
+					defer wg.Done()
+					errs <- session.FetchEntity(root)
+				}()
+				go func() {
+					defer wg.Done()
+					errs <- session.FetchEntity(NewFakeObject("1"))
+				}()
+				go func(i int) {
+					defer wg.Done()
+					session.SetAPIKey(fmt.Sprintf("key-%d", i))
+				}(i)
+				go func() {
+					defer wg.Done()
+					session.APIKey()
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			Convey("Then the requests should succeed without data race", func() {
+				for err := range errs {
+					So(err, ShouldBeNil)
+				}
+			})
+
+			Convey("When I start the session again", func() {
+
+				So(session.Start(), ShouldBeNil)
+
+				Convey("Then the session should have the API key of the server", func() {
+					So(session.APIKey(), ShouldEqual, "key")
+				})
+			})
+		})
+	})
+}
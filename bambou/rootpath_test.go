@@ -0,0 +1,88 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetRootPath(t *testing.T) {
+
+	Convey("Given I have a server authenticating on /me", t, func() {
+
+		var paths []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			if r.URL.Path != "/me" && r.URL.Path != "/me/apikey" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`[{"ID": "root", "APIKey": "key"}]`))
+		}))
+		defer ts.Close()
+
+		root := NewFakeRootObject()
+		session := NewSession("username", "password", "organization", ts.URL, root)
+
+		Convey("When I start the session with the default root path", func() {
+
+			err := session.Start()
+
+			Convey("Then the authentication should fail", func() {
+				So(session.RootPath(), ShouldEqual, "root")
+				So(err, ShouldNotBeNil)
+				So(paths, ShouldResemble, []string{"/root"})
+			})
+		})
+
+		Convey("When I start the session with the root path of the server", func() {
+
+			session.SetRootPath("/me/")
+			err := session.Start()
+			regenerateErr := session.RegenerateAPIKey()
+
+			Convey("Then the root object should be fetched from that path", func() {
+				So(session.RootPath(), ShouldEqual, "me")
+				So(err, ShouldBeNil)
+				So(regenerateErr, ShouldBeNil)
+				So(root.APIKey(), ShouldEqual, "key")
+				So(paths, ShouldResemble, []string{"/me", "/me/apikey"})
+			})
+
+			Convey("When I restore the default root path", func() {
+
+				session.SetRootPath("")
+
+				Convey("Then it should be the name of the identity of the root object", func() {
+					So(session.RootPath(), ShouldEqual, "root")
+				})
+			})
+		})
+	})
+}
@@ -24,20 +24,17 @@
 package bambou
 
 import (
-  "os"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
-	"io/ioutil"
+	"fmt"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
-	"time"
-  "fmt"
-
-	log "github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
 )
 
 var currentSession Storer
@@ -66,7 +63,8 @@ type Storer interface {
 
 // Session represents a user session. It provides the entire
 // communication layer with the backend. It must implement the Operationable interface.
-// A session can be authenticated via 1) TLS certificates or 2) user + password (different API endpoints)
+// A session can be authenticated via 1) TLS certificates, 2) user + password (different API endpoints)
+// or 3) a token issued out of band
 type Session struct {
 	root         Rootable
 	Certificate  *tls.Certificate
@@ -75,6 +73,50 @@ type Session struct {
 	Organization string
 	URL          string
 	client       *http.Client
+	transport    *http.Transport
+
+	optimisticLocking bool
+	versions          versionStore
+	dryRun            bool
+	dryRunRecorder    dryRunRecorder
+	readOnly          bool
+	specs             *SpecSet
+	codec             Codec
+	leveledLogger     LeveledLogger
+	bodyLogging       bool
+	maxLoggedBodySize int
+	metrics           Metrics
+	inFlight          int32
+	requests          uint64
+	failures          uint64
+	retries           uint64
+	requestHook       RequestHook
+	auditHook         AuditHook
+	debug             *debugWriter
+	debugCurl         bool
+	fetches           *fetchGroup
+	cache             *Cache
+	compression       *compressionTransport
+	endpoints         *endpointTransport
+	responseChoice    int
+	closing           int32
+	closed            int32
+	pushCenters       []*PushCenter
+	lock              sync.Mutex
+	inFlightRequests  inFlightRequests
+
+	pinnedCertificates map[string]bool
+	verifyPeer         VerifyPeerCertificateFunc
+	token              *tokenAuth
+	otp                OTPFunc
+	otpCode            string
+	apiKeyLock         sync.RWMutex
+	clock              Clock
+	application        string
+	userAgent          string
+	labels             Labels
+	tolerantDecoding   bool
+	rootPath           string
 }
 
 // NewSession returns a new *Session
@@ -83,15 +125,9 @@ type Session struct {
 // Authentication using user + password
 func NewSession(username, password, organization, url string, root Rootable) *Session {
 
-	tr := &http.Transport{
-		Proxy:               http.ProxyFromEnvironment,
-		TLSHandshakeTimeout: 10 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-	}
-  log.SetOutput(os.Stderr)
-  log.SetLevel(log.TraceLevel)
+	tr := newTransport(&tls.Config{
+		InsecureSkipVerify: true,
+	})
 
 	return &Session{
 		Username:     username,
@@ -100,27 +136,29 @@ func NewSession(username, password, organization, url string, root Rootable) *Se
 		URL:          url,
 		root:         root,
 		client:       &http.Client{Transport: tr},
+		transport:    tr,
+
+		responseChoice: DefaultResponseChoice,
+		versions:       versionStore{maxVersions: DefaultMaxTrackedVersions},
 	}
 }
 
 func NewX509Session(cert *tls.Certificate, url string, root Rootable) *Session {
 
-	tr := &http.Transport{
-		Proxy:               http.ProxyFromEnvironment,
-		TLSHandshakeTimeout: 10 * time.Second,
-		TLSClientConfig: &tls.Config{
-			Certificates:       []tls.Certificate{*cert},
-			InsecureSkipVerify: true,
-		},
-	}
-  log.SetOutput(os.Stderr)
-  log.SetLevel(log.TraceLevel)
+	tr := newTransport(&tls.Config{
+		Certificates:       []tls.Certificate{*cert},
+		InsecureSkipVerify: true,
+	})
 
 	return &Session{
 		Certificate: cert,
 		URL:         url,
 		root:        root,
 		client:      &http.Client{Transport: tr},
+		transport:   tr,
+
+		responseChoice: DefaultResponseChoice,
+		versions:       versionStore{maxVersions: DefaultMaxTrackedVersions},
 	}
 }
 
@@ -130,6 +168,124 @@ func (s *Session) SetInsecureSkipVerify(skip bool) *Error {
 	return nil
 }
 
+// WrapTransport replaces the http.RoundTripper sending the requests of the session
+// with the one returned by the given function, that is given the current one.
+// It allows to record, replay, alter or observe the requests and responses.
+func (s *Session) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+
+	s.client.Transport = wrap(s.client.Transport)
+}
+
+// SetClock sets the Clock the expiry of the token of the session is checked with, the
+// durations of its requests are measured with, and its operations and WaitFor are
+// polled with. The default is SystemClock. See NewTokenSession.
+func (s *Session) SetClock(clock Clock) {
+
+	s.clock = clock
+}
+
+// SetOptimisticLocking enables or disables the optimistic locking mode.
+// When enabled, the session records the version of every object it receives
+// from the server, and SaveEntity refuses to save an object whose server copy
+// is newer than the recorded one. In that case, the returned *Error has a
+// *ConflictError as Details.
+func (s *Session) SetOptimisticLocking(enabled bool) {
+
+	s.optimisticLocking = enabled
+}
+
+// SetMaxTrackedVersions sets the number of object versions the session records at most
+// in the optimistic locking mode, DefaultMaxTrackedVersions by default. The versions of
+// the least recently fetched or saved objects are forgotten first, and saving them is
+// then not checked for conflicts. A maximum of 0 does not limit the number of versions.
+func (s *Session) SetMaxTrackedVersions(max int) {
+
+	s.versions.setMax(max)
+}
+
+// SetReadOnly enables or disables the read only mode.
+// In read only mode, any mutating operation (SaveEntity, CreateChild,
+// DeleteEntity, AssignChildren...) fails with ErrReadOnly before anything
+// is sent to the server. This takes precedence over the dry run mode.
+func (s *Session) SetReadOnly(readOnly bool) {
+
+	s.readOnly = readOnly
+}
+
+// ReadOnly returns true if the session is in read only mode.
+func (s *Session) ReadOnly() bool {
+
+	return s.readOnly
+}
+
+// SetSpecs sets the specifications the objects are validated against before
+// being created or saved, in addition to their validate tags.
+// The warnings of the validation are logged. Use nil to disable it.
+func (s *Session) SetSpecs(specs *SpecSet) {
+
+	s.specs = specs
+}
+
+// SetCodec sets the Codec used to encode and decode the objects.
+// The default is JSONCodec. See FastCodec.
+func (s *Session) SetCodec(codec Codec) {
+
+	s.codec = codec
+}
+
+// getCodec returns the Codec of the session.
+func (s *Session) getCodec() Codec {
+
+	if s.codec == nil {
+		return JSONCodec
+	}
+
+	return s.codec
+}
+
+// encode encodes the given object with the Codec of the session,
+// without its unset Optional attributes, and with its extra attributes.
+func (s *Session) encode(object Identifiable) ([]byte, error) {
+
+	return encodeObject(s.getCodec(), object)
+}
+
+// encodeObject encodes the given object with the given Codec, without its unset
+// Optional attributes and with its extra attributes.
+func encodeObject(codec Codec, object Identifiable) ([]byte, error) {
+
+	data, err := codec.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = omitUnsetAttributes(object, data); err != nil {
+		return nil, err
+	}
+
+	return addExtraAttributes(object, data)
+}
+
+// validate validates the given object with Validate and the specifications, if any.
+func (s *Session) validate(object Identifiable, creating bool) *Error {
+
+	errs := Validate(object)
+
+	if s.specs != nil {
+		specErrs, warnings := s.specs.Validate(object, creating)
+		for _, warning := range warnings {
+			s.getLogger().Warnf("Validation of %s: %s", object.Identity().Name, warning)
+		}
+		errs = append(errs, specErrs...)
+	}
+
+	if len(errs) > 0 {
+		return newValidationError(errs)
+	}
+
+	return nil
+}
+
 // Used for user & password based authentication
 func (s *Session) makeAuthorizationHeaders() (string, *Error) {
 
@@ -141,7 +297,7 @@ func (s *Session) makeAuthorizationHeaders() (string, *Error) {
 		return "", NewBambouError("Invalid Credentials", "No root user set")
 	}
 
-	key := s.root.APIKey()
+	key := s.APIKey()
 	if s.Password == "" && key == "" {
 		return "", NewBambouError("Invalid Credentials", "No password or authentication token given")
 	}
@@ -155,7 +311,16 @@ func (s *Session) makeAuthorizationHeaders() (string, *Error) {
 
 func (s *Session) prepareHeaders(request *http.Request, info *FetchingInfo) *Error {
 
-	if s.Certificate == nil { // We're using user & password based authentication
+	if s.token != nil { // We're using token based authentication
+
+		token, err := s.token.current(clockOrSystem(s.clock).Now())
+		if err != nil {
+			return err
+		}
+		request.Header.Set(TokenHeader, token)
+		request.Header.Set("X-Nuage-Organization", s.Organization)
+
+	} else if s.Certificate == nil { // We're using user & password based authentication
 
 		authString, err := s.makeAuthorizationHeaders()
 		if err != nil {
@@ -163,9 +328,14 @@ func (s *Session) prepareHeaders(request *http.Request, info *FetchingInfo) *Err
 		}
 		request.Header.Set("Authorization", authString)
 		request.Header.Set("X-Nuage-Organization", s.Organization)
+
+		if s.otpCode != "" {
+			request.Header.Set(OTPHeader, s.otpCode)
+		}
 	}
 
 	// Common headers
+	request.Header.Set("User-Agent", s.UserAgent())
 	request.Header.Set("X-Nuage-PageSize", "50")
 	request.Header.Set("Content-Type", "application/json")
 
@@ -173,6 +343,10 @@ func (s *Session) prepareHeaders(request *http.Request, info *FetchingInfo) *Err
 		return nil
 	}
 
+	if len(info.Params) > 0 {
+		request.URL.RawQuery = info.query(request.URL.Query()).Encode()
+	}
+
 	if info.Filter != "" {
 		request.Header.Set("X-Nuage-Filter", info.Filter)
 	}
@@ -215,19 +389,62 @@ func (s *Session) readHeaders(response *http.Response, info *FetchingInfo) {
 
 func (s *Session) send(request *http.Request, info *FetchingInfo) (*http.Response, *Error) {
 
-	s.prepareHeaders(request, info)
+	if s.Closed() {
+		return nil, ErrSessionClosed
+	}
+
+	if berr := s.prepareHeaders(request, info); berr != nil {
+		return nil, berr
+	}
+
+	if s.readOnly && request.Method != "GET" {
+		return nil, ErrReadOnly
+	}
+
+	return s.sendTracked(request, info, setRequestID(request))
+}
+
+// sendWithID sends the given request, that has the given request ID, and
+// sets the request ID and the transaction ID to the error it returns, if any.
+func (s *Session) sendWithID(request *http.Request, info *FetchingInfo, requestID string) (_ *http.Response, berr *Error) {
+
+	var transaction string
+
+	defer func() {
+		if berr != nil && berr.RequestID == "" {
+			atomic.AddUint64(&s.failures, 1)
+			berr.RequestID = requestID
+			berr.TransactionID = transaction
+			berr.Labels = s.labels
+		}
+	}()
 
-	log.Debugf("Request Method URL: %s %s", request.Method, request.URL)
-	log.Debugf("Request Headers: %s", request.Header)
+	s.captureRequest(request)
+	s.logRequest(request)
+	s.dumpRequest(request)
 
+	if s.dryRun && request.Method != "GET" {
+		return s.dryRunRecorder.record(request, s.getLogger()), nil
+	}
+
+	if berr := replayable(request); berr != nil {
+		return nil, berr
+	}
+
+	clock := clockOrSystem(s.clock)
+	start := clock.Now()
+	endRequestMetrics := s.startRequestMetrics(request)
 	response, err := s.client.Do(request)
+	endRequestMetrics(response, err)
 
 	if err != nil {
 		return response, NewBambouError("HTTP client error", err.Error())
 	}
 
-	log.Debugf("Response Status: %s", response.Status)
-	log.Debugf("Response Headers: %s", response.Header)
+	transaction = transactionID(response)
+	duration := clock.Now().Sub(start)
+	s.logResponse(request, response, duration)
+	s.dumpResponse(request, response, duration)
 
 	switch response.StatusCode {
 
@@ -236,32 +453,93 @@ func (s *Session) send(request *http.Request, info *FetchingInfo) (*http.Respons
 		return response, nil
 
 	case http.StatusMultipleChoices:
-		defer response.Body.Close()
-		newURL := request.URL.String() + "?responseChoice=1"
-		request.URL, _ = url.Parse(newURL)
-		return s.send(request, info)
+		response.Body.Close()
+		retry, berr := s.responseChoiceRequest(request)
+		if berr != nil {
+			return nil, berr
+		}
+		s.reportRetry(retry)
+		return s.sendWithID(retry, info, requestID)
 
 	case http.StatusConflict, http.StatusNotFound:
 		var vsdresp VsdErrorList
 		defer response.Body.Close()
 
-		body, _ := ioutil.ReadAll(response.Body)
-		log.Debugf("Response Body: %s", string(body))
+		received := readBody(response)
+		defer putBuffer(received)
+		body := received.Bytes()
 
 		if err := json.Unmarshal(body, &vsdresp); err != nil {
-			return nil, NewBambouError("JSON unmarshalling error", err.Error())
+			return nil, newHTTPError(response.StatusCode, "JSON unmarshalling error", err.Error())
 		}
 		// Check if there is an _actual_ VSD response -- we may get a bogus 40x from e.g. tests
 		if len(vsdresp.VsdErrors) == 0 {
-			return nil, NewBambouError("Non-VSD server HTTP error", response.Status)
+			return nil, newHTTPError(response.StatusCode, "Non-VSD server HTTP error", response.Status)
 		} else { // Valid VSD response
-			return nil, NewBambouError("vsd response error", fmt.Sprintf("%#s", vsdresp))
+			return nil, newHTTPError(response.StatusCode, "vsd response error", fmt.Sprintf("%#s", vsdresp))
 			//return nil, NewBambouError(vsdresp.VsdErrors[0].Descriptions[0].Title, vsdresp.VsdErrors[0].Descriptions[0].Description)
 		}
 
 	default:
 		defer response.Body.Close()
-		return nil, NewBambouError("HTTP error", response.Status)
+		return nil, newHTTPError(response.StatusCode, "HTTP error", response.Status)
+	}
+}
+
+func (s *Session) recordVersions(identity Identity, body []byte, etag string) {
+
+	if !s.optimisticLocking {
+		return
+	}
+
+	for ID, version := range versionsFromBody(body, etag) {
+		s.versions.set(identity, ID, version)
+	}
+}
+
+func (s *Session) checkVersion(object Identifiable, url string) *Error {
+
+	local, ok := s.versions.get(object.Identity(), object.Identifier())
+	if !ok {
+		return nil
+	}
+
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	response, berr := s.send(request, nil)
+	if berr != nil {
+		return berr
+	}
+	defer response.Body.Close()
+
+	received := readBody(response)
+	defer putBuffer(received)
+	body := received.Bytes()
+
+	remote, ok := versionsFromBody(body, response.Header.Get("ETag"))[object.Identifier()]
+	if !ok || !remote.ConflictsWith(local) {
+		return nil
+	}
+
+	var items []json.RawMessage
+	json.Unmarshal(body, &items)
+
+	conflict := &ConflictError{
+		Local:         object,
+		LocalVersion:  local,
+		RemoteVersion: remote,
+	}
+	if len(items) > 0 {
+		conflict.Remote = items[0]
+	}
+
+	return &Error{
+		Title:       "Conflict",
+		Description: fmt.Sprintf("%s %s has been modified on the server since it has been fetched", object.Identity().Name, object.Identifier()),
+		Details:     conflict,
 	}
 }
 
@@ -273,6 +551,9 @@ func (s *Session) getGeneralURL(o Identifiable) string {
 func (s *Session) getPersonalURL(o Identifiable) (string, *Error) {
 
 	if _, ok := o.(Rootable); ok {
+		if s.rootPath != "" {
+			return s.URL + "/" + s.rootPath, nil
+		}
 		return s.URL + "/" + o.Identity().Name, nil
 	}
 
@@ -285,6 +566,10 @@ func (s *Session) getPersonalURL(o Identifiable) (string, *Error) {
 
 func (s *Session) getURLForChildrenIdentity(o Identifiable, childrenIdentity Identity) (string, *Error) {
 
+	if template, ok := childrenURLTemplate(o.Identity(), childrenIdentity); ok {
+		return s.expandChildrenURL(template, o, childrenIdentity)
+	}
+
 	if _, ok := o.(Rootable); ok {
 		return s.URL + "/" + childrenIdentity.Category, nil
 	}
@@ -309,6 +594,11 @@ func (s *Session) Start() *Error {
 
 	currentSession = s
 
+	if berr := s.collectOTP(); berr != nil {
+		return berr
+	}
+	defer func() { s.otpCode = "" }()
+
 	berr := s.FetchEntity(s.root)
 
 	if berr != nil {
@@ -318,81 +608,118 @@ func (s *Session) Start() *Error {
 	return nil
 }
 
-// Reset resets the session.
+// Reset resets the session. The requests in flight are canceled.
+// It is no longer the current session if it was.
 func (s *Session) Reset() {
 
-	s.root.SetAPIKey("")
+	s.inFlightRequests.cancelAll()
+	s.SetAPIKey("")
 
-	currentSession = nil
+	if currentSession == s {
+		currentSession = nil
+	}
 }
 
 // FetchEntity fetchs the given Identifiable from the server.
 func (s *Session) FetchEntity(object Identifiable) *Error {
 
-  log.Debug("starting fetchentity")
 	url, berr := s.getPersonalURL(object)
 	if berr != nil {
 		return berr
 	}
-  log.Debug("after url")
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return NewBambouError("HTTP transaction error", err.Error())
-	}
-  log.Debug("after getreqbuild")
+	// The root object is the authentication: it carries the API key, and must
+	// always be fetched from the server, never cached, shared or versioned.
+	_, isRoot := object.(Rootable)
 
-	response, berr := s.send(request, nil)
+	var body []byte
+	var etag string
+	var release func()
+	if isRoot {
+		body, etag, release, berr = s.getBody(url)
+	} else {
+		body, etag, release, berr = s.fetchEntityBody(url, object.Identity(), object.Identifier())
+	}
 	if berr != nil {
 		return berr
 	}
-  log.Debug("after send")
-	defer response.Body.Close()
-
-	body, _ := ioutil.ReadAll(response.Body)
-	log.Debugf("Response Body: %s", string(body))
+	defer release()
 
+	unlock := s.lockRoot(object)
 	arr := IdentifiablesList{object} // trick for weird api..
-	if err := json.Unmarshal(body, &arr); err != nil {
+	err := s.getCodec().Unmarshal(body, &arr)
+	if err == nil {
+		captureExtraAttributes(body, arr)
+	}
+	unlock()
+	if err != nil {
 		return NewBambouError("JSON unmarshalling error", err.Error())
 	}
 
+	if !isRoot {
+		s.recordVersions(object.Identity(), body, etag)
+	}
+
 	return nil
 }
 
 // SaveEntity saves the given Identifiable into the server.
+// The object is validated first: see Validate and SetSpecs.
 func (s *Session) SaveEntity(object Identifiable) *Error {
 
+	if berr := s.validate(object, false); berr != nil {
+		return berr
+	}
+
 	url, berr := s.getPersonalURL(object)
 	if berr != nil {
 		return berr
 	}
 
-	buffer := &bytes.Buffer{}
-	if err := json.NewEncoder(buffer).Encode(object); err != nil {
+	if s.optimisticLocking {
+		if berr := s.checkVersion(object, url); berr != nil {
+			return berr
+		}
+	}
+
+	data, err := s.encode(object)
+	if err != nil {
 		return NewBambouError("JSON error", err.Error())
 	}
+	buffer := bytes.NewBuffer(data)
 
-	url = url + "?responseChoice=1"
+	url = s.withResponseChoice(url)
 	request, err := http.NewRequest("PUT", url, buffer)
 	if err != nil {
 		return NewBambouError("HTTP transaction error", err.Error())
 	}
 
 	response, berr := s.send(request, nil)
+	s.audit(AuditUpdate, object, nil, request, data, berr)
+	s.invalidateCache(object.Identity(), object.Identifier())
 	if berr != nil {
 		return berr
 	}
 	defer response.Body.Close()
 
-	body, _ := ioutil.ReadAll(response.Body)
-	log.Debugf("Response Body: %s", string(body))
+	received := readBody(response)
+	defer putBuffer(received)
+	body := received.Bytes()
 
 	dest := IdentifiablesList{object}
 	if len(body) > 0 {
-		if err := json.Unmarshal(body, &dest); err != nil {
+		unlock := s.lockRoot(object)
+		err := s.getCodec().Unmarshal(body, &dest)
+		if err == nil {
+			captureExtraAttributes(body, dest)
+		}
+		unlock()
+		if err != nil {
 			return NewBambouError("JSON Unmarshaling error", err.Error())
 		}
+		s.recordVersions(object.Identity(), body, response.Header.Get("ETag"))
+	} else {
+		s.versions.forget(object.Identity(), object.Identifier())
 	}
 
 	return nil
@@ -406,7 +733,7 @@ func (s *Session) DeleteEntity(object Identifiable) *Error {
 		return berr
 	}
 
-	url = url + "?responseChoice=1"
+	url = s.withResponseChoice(url)
 	request, err := http.NewRequest("DELETE", url, nil)
 
 	if err != nil {
@@ -414,11 +741,15 @@ func (s *Session) DeleteEntity(object Identifiable) *Error {
 	}
 
 	response, berr := s.send(request, nil)
+	s.audit(AuditDelete, object, nil, request, nil, berr)
+	s.invalidateCache(object.Identity(), object.Identifier())
 	if berr != nil {
 		return berr
 	}
 	defer response.Body.Close()
 
+	s.versions.forget(object.Identity(), object.Identifier())
+
 	return nil
 }
 
@@ -430,43 +761,53 @@ func (s *Session) FetchChildren(parent Identifiable, identity Identity, dest int
 		return berr
 	}
 
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return NewBambouError("HTTP transaction error", err.Error())
-	}
-
-	response, berr := s.send(request, info)
+	body, release, berr := s.fetchChildrenBody(url, identity, info)
 	if berr != nil {
 		return berr
 	}
-	defer response.Body.Close()
+	defer release()
 
-	body, _ := ioutil.ReadAll(response.Body)
-	log.Debugf("Response Body: %s", string(body))
-
-	if response.StatusCode == http.StatusNoContent || response.ContentLength == 0 {
+	if len(body) == 0 {
 		return nil
 	}
 
-	if err := json.Unmarshal(body, &dest); err != nil {
-		return NewBambouError("HTTP Unmarshaling error", err.Error())
+	if info != nil {
+		info.DecodeErrors = nil
+	}
+
+	if err := s.getCodec().Unmarshal(body, dest); err != nil {
+		if !s.tolerantDecoding {
+			return NewBambouError("HTTP Unmarshaling error", err.Error())
+		}
+		if body, berr = s.decodeTolerantly(body, dest, info); berr != nil {
+			return berr
+		}
 	}
+	captureExtraAttributes(body, dest)
+
+	s.recordVersions(identity, body, "")
 
 	return nil
 }
 
 // CreateChild creates a new child Identifiable under the given parent Identifiable in the server.
+// The child is validated first: see Validate and SetSpecs.
 func (s *Session) CreateChild(parent Identifiable, child Identifiable) *Error {
 
+	if berr := s.validate(child, true); berr != nil {
+		return berr
+	}
+
 	url, berr := s.getURLForChildrenIdentity(parent, child.Identity())
 	if berr != nil {
 		return berr
 	}
 
-	buffer := &bytes.Buffer{}
-	if err := json.NewEncoder(buffer).Encode(child); err != nil {
+	data, err := s.encode(child)
+	if err != nil {
 		return NewBambouError("JSON error", err.Error())
 	}
+	buffer := bytes.NewBuffer(data)
 
 	request, err := http.NewRequest("POST", url, buffer)
 	if err != nil {
@@ -474,18 +815,25 @@ func (s *Session) CreateChild(parent Identifiable, child Identifiable) *Error {
 	}
 
 	response, berr := s.send(request, nil)
+	s.invalidateCachedListings(child.Identity())
 	if berr != nil {
+		s.audit(AuditCreate, child, parent, request, data, berr)
 		return berr
 	}
 	defer response.Body.Close()
+	defer s.audit(AuditCreate, child, parent, request, data, nil) // once the child has its ID
 
-	body, _ := ioutil.ReadAll(response.Body)
-	log.Debugf("Response Body: %s", string(body))
+	received := readBody(response)
+	defer putBuffer(received)
+	body := received.Bytes()
 
 	dest := IdentifiablesList{child}
-	if err := json.Unmarshal(body, &dest); err != nil {
+	if err := s.getCodec().Unmarshal(body, &dest); err != nil {
 		return NewBambouError("JSON Unmarshaling error", err.Error())
 	}
+	captureExtraAttributes(body, dest)
+
+	s.recordVersions(child.Identity(), body, response.Header.Get("ETag"))
 
 	return nil
 }
@@ -517,6 +865,8 @@ func (s *Session) AssignChildren(parent Identifiable, children []Identifiable, i
 	}
 
 	response, berr := s.send(request, nil)
+	s.auditAssign(parent, identity, ids, request, berr)
+	s.invalidateCachedListings(identity)
 	if berr != nil {
 		return berr
 	}
@@ -529,6 +879,13 @@ func (s *Session) AssignChildren(parent Identifiable, children []Identifiable, i
 // send it to the correct channel.
 func (s *Session) NextEvent(channel NotificationsChannel, lastEventID string) *Error {
 
+	return s.NextEventWithContext(context.Background(), channel, lastEventID)
+}
+
+// NextEventWithContext works like NextEvent, but the request is canceled
+// as soon as the given context is done.
+func (s *Session) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
 	currentURL := s.URL + "/events"
 	if lastEventID != "" {
 		currentURL += "?uuid=" + lastEventID
@@ -539,20 +896,22 @@ func (s *Session) NextEvent(channel NotificationsChannel, lastEventID string) *E
 		return NewBambouError("HTTP transaction error", err.Error())
 	}
 
-	response, berr := s.send(request, nil)
+	response, berr := s.send(request.WithContext(ctx), nil)
 	if berr != nil {
+		if ctx.Err() != nil {
+			return newCanceledError(ctx)
+		}
 		return berr
 	}
 	defer response.Body.Close()
 
 	notification := NewNotification()
 	if err := json.NewDecoder(response.Body).Decode(notification); err != nil {
+		if ctx.Err() != nil {
+			return newCanceledError(ctx)
+		}
 		return NewBambouError("JSON error", err.Error())
 	}
 
-	if len(notification.Events) > 0 {
-		channel <- notification
-	}
-
-	return nil
+	return sendNotification(ctx, channel, notification)
 }
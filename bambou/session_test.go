@@ -26,6 +26,7 @@ package bambou
 import (
 	"fmt"
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -100,6 +101,66 @@ func TestSession_SetInsecureSkipVerify(t *testing.T) {
 	})
 }
 
+func TestSession_SetReadOnly(t *testing.T) {
+
+	Convey("Given I create a new read only Session", t, func() {
+
+		called := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called++
+			fmt.Fprint(w, `[{"ID": "xxx"}]`)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetReadOnly(true)
+
+		Convey("Then ReadOnly should be true", func() {
+			So(session.ReadOnly(), ShouldBeTrue)
+		})
+
+		Convey("When I perform mutating operations", func() {
+
+			e := NewFakeObject("xxx")
+			errs := []*Error{
+				session.SaveEntity(e),
+				session.DeleteEntity(e),
+				session.CreateChild(e, NewFakeObject("")),
+				session.AssignChildren(e, []Identifiable{e}, FakeIdentity),
+			}
+
+			Convey("Then they should all fail with ErrReadOnly", func() {
+				for _, err := range errs {
+					So(err, ShouldEqual, ErrReadOnly)
+				}
+			})
+
+			Convey("Then the server should not be called", func() {
+				So(called, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I also enable the dry run mode and save an entity", func() {
+
+			session.SetDryRun(true)
+			err := session.SaveEntity(NewFakeObject("xxx"))
+
+			Convey("Then err should be ErrReadOnly", func() {
+				So(err, ShouldEqual, ErrReadOnly)
+			})
+		})
+
+		Convey("When I fetch an entity", func() {
+
+			err := session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
 /*
 	Privates
 */
@@ -1115,6 +1176,40 @@ func TestSession_NextEvent(t *testing.T) {
 	})
 }
 
+func TestSession_NextEventWithContext(t *testing.T) {
+
+	Convey("Given I have a server that never responds", t, func() {
+
+		unblock := make(chan bool)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+		}))
+		defer ts.Close()
+		defer close(unblock)
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I use NextEventWithContext and cancel the context", func() {
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errs := make(chan *Error, 1)
+			go func() { errs <- session.NextEventWithContext(ctx, make(NotificationsChannel), "x") }()
+			cancel()
+
+			var err *Error
+			select {
+			case err = <-errs:
+			case <-time.After(time.Second):
+			}
+
+			Convey("Then it should return a canceled error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Canceled")
+			})
+		})
+	})
+}
+
 /*
 	Send
 */
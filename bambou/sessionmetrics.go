@@ -0,0 +1,160 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Names of the metrics reported by the Session.
+const (
+	MetricRequests         = "bambou_requests_total"
+	MetricRequestDuration  = "bambou_request_duration_seconds"
+	MetricRequestRetries   = "bambou_request_retries_total"
+	MetricRequestsInFlight = "bambou_requests_in_flight"
+	MetricRequestBytes     = "bambou_request_bytes_total"
+	MetricResponseBytes    = "bambou_response_bytes_total"
+)
+
+// SetMetrics sets the Metrics the session reports to: the number of requests per method,
+// identity and status, their duration, the number of retries, the number of requests in
+// flight, and the number of bytes sent and received. Passing nil disables the reporting.
+func (s *Session) SetMetrics(metrics Metrics) {
+
+	s.metrics = metrics
+}
+
+// getMetrics returns the Metrics of the session, adding the labels of the session if any.
+func (s *Session) getMetrics() Metrics {
+
+	if s.metrics == nil || len(s.labels) == 0 {
+		return s.metrics
+	}
+
+	return &labeledMetrics{next: s.metrics, labels: s.labels}
+}
+
+// startRequestMetrics reports the start of the given request, and returns the function
+// to call with its response or error to report its end.
+func (s *Session) startRequestMetrics(request *http.Request) func(*http.Response, error) {
+
+	atomic.AddUint64(&s.requests, 1)
+	inFlight := atomic.AddInt32(&s.inFlight, 1)
+
+	metrics := s.getMetrics()
+	if metrics == nil {
+		return func(*http.Response, error) { atomic.AddInt32(&s.inFlight, -1) }
+	}
+
+	clock := clockOrSystem(s.clock)
+	start := clock.Now()
+	labels := Labels{"method": request.Method, "identity": s.metricIdentity(request.URL)}
+
+	metrics.SetGauge(MetricRequestsInFlight, nil, float64(inFlight))
+	if request.ContentLength > 0 {
+		metrics.AddCounter(MetricRequestBytes, labels, float64(request.ContentLength))
+	}
+
+	return func(response *http.Response, err error) {
+
+		metrics.SetGauge(MetricRequestsInFlight, nil, float64(atomic.AddInt32(&s.inFlight, -1)))
+		metrics.ObserveDuration(MetricRequestDuration, labels, clock.Now().Sub(start))
+
+		status := "error"
+		if err == nil {
+			status = strconv.Itoa(response.StatusCode)
+			response.Body = &countingBody{ReadCloser: response.Body, metrics: metrics, labels: labels}
+		}
+
+		metrics.AddCounter(MetricRequests, Labels{"method": labels["method"], "identity": labels["identity"], "status": status}, 1)
+	}
+}
+
+// reportRetry reports a retry of the given request.
+func (s *Session) reportRetry(request *http.Request) {
+
+	atomic.AddUint64(&s.retries, 1)
+
+	metrics := s.getMetrics()
+	if metrics == nil {
+		return
+	}
+
+	metrics.AddCounter(MetricRequestRetries, Labels{"method": request.Method, "identity": s.metricIdentity(request.URL)}, 1)
+}
+
+// metricIdentity returns the name of the Identity targeted by the request with the given URL:
+// the last category of its path, converted to the name of the registered Identity if any.
+func (s *Session) metricIdentity(u *url.URL) string {
+
+	path := u.Path
+	if base, err := url.Parse(s.URL); err == nil {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	category := segments[len(segments)-1]
+	if len(segments)%2 == 0 {
+		category = segments[len(segments)-2]
+	}
+
+	if identity, ok := IdentityFromCategory(category); ok {
+		return identity.Name
+	}
+
+	return category
+}
+
+// countingBody is a response body reporting the number of bytes read from it when it is closed.
+type countingBody struct {
+	io.ReadCloser
+	metrics Metrics
+	labels  Labels
+	count   int64
+}
+
+// Read implements the io.Reader interface.
+func (b *countingBody) Read(p []byte) (int, error) {
+
+	n, err := b.ReadCloser.Read(p)
+	b.count += int64(n)
+
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (b *countingBody) Close() error {
+
+	if b.metrics != nil {
+		b.metrics.AddCounter(MetricResponseBytes, b.labels, float64(b.count))
+		b.metrics = nil
+	}
+
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSessionMetrics_Requests(t *testing.T) {
+
+	Convey("Given I have a session reporting metrics", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Query().Get("responseChoice") == "" && r.URL.Path == "/fakes/retry":
+				w.WriteHeader(http.StatusMultipleChoices)
+			case r.Method == "POST":
+				w.WriteHeader(http.StatusInternalServerError)
+			default:
+				w.Write([]byte(`[{"ID": "xxx", "name": "remote"}]`))
+			}
+		}))
+		defer ts.Close()
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return NewFakeObject("") })
+		defer UnregisterIdentity(FakeIdentity)
+
+		metrics := NewMemoryMetrics()
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetMetrics(metrics)
+
+		Convey("When I fetch an entity and its children", func() {
+
+			session.FetchEntity(NewFakeObject("xxx"))
+			var children []*FakeObject
+			session.FetchChildren(NewFakeObject("xxx"), FakeIdentity, &children, nil)
+
+			Convey("Then the requests should be reported", func() {
+				So(metrics.Counter(MetricRequests, Labels{"method": "GET", "identity": "fake", "status": "200"}), ShouldEqual, 2)
+				So(metrics.Durations(MetricRequestDuration, Labels{"method": "GET", "identity": "fake"}), ShouldHaveLength, 2)
+				So(metrics.Counter(MetricResponseBytes, Labels{"method": "GET", "identity": "fake"}), ShouldEqual, 66)
+				So(metrics.Gauge(MetricRequestsInFlight, nil), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I create a child and the server fails", func() {
+
+			session.CreateChild(NewFakeObject("xxx"), &FakeObject{Name: "name"})
+
+			Convey("Then the failure and the bytes sent should be reported", func() {
+				So(metrics.Counter(MetricRequests, Labels{"method": "POST", "identity": "fake", "status": "500"}), ShouldEqual, 1)
+				So(metrics.Counter(MetricRequestBytes, Labels{"method": "POST", "identity": "fake"}), ShouldBeGreaterThan, 0)
+			})
+		})
+
+		Convey("When I fetch an entity and the server asks to confirm", func() {
+
+			session.FetchEntity(NewFakeObject("retry"))
+
+			Convey("Then the retry should be reported", func() {
+				So(metrics.Counter(MetricRequestRetries, Labels{"method": "GET", "identity": "fake"}), ShouldEqual, 1)
+				So(metrics.Counter(MetricRequests, Labels{"method": "GET", "identity": "fake", "status": "300"}), ShouldEqual, 1)
+				So(metrics.Counter(MetricRequests, Labels{"method": "GET", "identity": "fake", "status": "200"}), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the server is unreachable", func() {
+
+			ts.Close()
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then the error should be reported", func() {
+				So(metrics.Counter(MetricRequests, Labels{"method": "GET", "identity": "fake", "status": "error"}), ShouldEqual, 1)
+			})
+		})
+	})
+}
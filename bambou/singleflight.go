@@ -0,0 +1,157 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"sync"
+)
+
+// fetchCall is a GET request in flight, whose result is shared by all the callers
+// asking for the same URL meanwhile.
+type fetchCall struct {
+	done    sync.WaitGroup
+	body    []byte
+	etag    string
+	err     *Error
+	waiters int
+}
+
+// fetchGroup coalesces the concurrent GET requests to the same URL.
+type fetchGroup struct {
+	calls map[string]*fetchCall
+	lock  sync.Mutex
+}
+
+// do calls the given function once for all the concurrent calls with the same key,
+// and returns its result to all of them. Each caller gets its own copy of the error.
+func (g *fetchGroup) do(key string, fn func() ([]byte, string, *Error)) ([]byte, string, *Error) {
+
+	g.lock.Lock()
+
+	if call, ok := g.calls[key]; ok {
+		call.waiters++
+		g.lock.Unlock()
+		call.done.Wait()
+		return call.body, call.etag, copyError(call.err)
+	}
+
+	call := &fetchCall{}
+	call.done.Add(1)
+	if g.calls == nil {
+		g.calls = map[string]*fetchCall{}
+	}
+	g.calls[key] = call
+	g.lock.Unlock()
+
+	call.body, call.etag, call.err = fn()
+
+	g.lock.Lock()
+	delete(g.calls, key)
+	g.lock.Unlock()
+	call.done.Done()
+
+	return call.body, call.etag, copyError(call.err)
+}
+
+// waitersFor returns the number of callers waiting for the call with the given key.
+func (g *fetchGroup) waitersFor(key string) int {
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if call, ok := g.calls[key]; ok {
+		return call.waiters
+	}
+
+	return 0
+}
+
+// copyError returns a copy of the given error, if any.
+func copyError(err *Error) *Error {
+
+	if err == nil {
+		return nil
+	}
+
+	copied := *err
+
+	return &copied
+}
+
+// SetFetchCoalescing enables or disables the coalescing of the concurrent fetches.
+// When enabled, the calls to FetchEntity for an entity that is already being
+// fetched by another goroutine wait for its response, instead of sending the same
+// request again, and decode it into their own object. It saves many requests to
+// controllers reacting to bursts of events about the same entities.
+func (s *Session) SetFetchCoalescing(enabled bool) {
+
+	if enabled {
+		s.fetches = &fetchGroup{}
+	} else {
+		s.fetches = nil
+	}
+}
+
+// fetchBody returns the body and ETag of the response to a GET request to the given URL.
+// The given function must be called once the body is decoded.
+func (s *Session) fetchBody(url string) ([]byte, string, func(), *Error) {
+
+	if s.fetches == nil {
+		return s.getBody(url)
+	}
+
+	body, etag, berr := s.fetches.do(url, func() ([]byte, string, *Error) {
+
+		body, etag, release, berr := s.getBody(url)
+		if berr != nil {
+			return nil, "", berr
+		}
+		defer release()
+
+		return append([]byte(nil), body...), etag, nil
+	})
+
+	return body, etag, func() {}, berr
+}
+
+// getBody sends a GET request to the given URL and returns the body and ETag of
+// the response. The body is a pooled buffer, released by the given function.
+func (s *Session) getBody(url string) ([]byte, string, func(), *Error) {
+
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, "", nil, NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	response, berr := s.send(request, nil)
+	if berr != nil {
+		return nil, "", nil, berr
+	}
+	defer response.Body.Close()
+
+	received := readBody(response)
+
+	return received.Bytes(), response.Header.Get("ETag"), func() { putBuffer(received) }, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSingleflight_Session(t *testing.T) {
+
+	Convey("Given I have a session to a slow server", t, func() {
+
+		var hits int32
+		release := make(chan bool)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			<-release
+			if r.URL.Path == "/fakes/missing" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`[{"ID": "xxx", "name": "remote"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetFetchCoalescing(true)
+
+		fetchAll := func(ID string) ([]*FakeObject, []*Error) {
+
+			objects := make([]*FakeObject, 10)
+			errs := make([]*Error, 10)
+			var wg sync.WaitGroup
+			for i := range objects {
+				objects[i] = NewFakeObject(ID)
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs[i] = session.FetchEntity(objects[i])
+				}(i)
+			}
+
+			url := ts.URL + "/fakes/" + ID
+			deadline := time.Now().Add(5 * time.Second)
+			for session.fetches.waitersFor(url) < 9 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+
+			return objects, errs
+		}
+
+		Convey("When many goroutines fetch the same entity", func() {
+
+			objects, errs := fetchAll("xxx")
+
+			Convey("Then a single request should be sent", func() {
+				So(atomic.LoadInt32(&hits), ShouldEqual, 1)
+			})
+
+			Convey("Then every object should get the response", func() {
+				for i, object := range objects {
+					So(errs[i], ShouldBeNil)
+					So(object.Name, ShouldEqual, "remote")
+				}
+			})
+		})
+
+		Convey("When many goroutines fetch an entity that fails", func() {
+
+			_, errs := fetchAll("missing")
+
+			Convey("Then every goroutine should get its own copy of the error", func() {
+				So(atomic.LoadInt32(&hits), ShouldEqual, 1)
+				So(errs[0], ShouldNotBeNil)
+				So(errs[0].Code, ShouldEqual, 500)
+				So(errs[0], ShouldNotPointTo, errs[1])
+			})
+		})
+
+		Convey("When I disable the coalescing and fetch an entity twice", func() {
+
+			session.SetFetchCoalescing(false)
+			close(release)
+			session.FetchEntity(NewFakeObject("xxx"))
+			session.FetchEntity(NewFakeObject("xxx"))
+
+			Convey("Then two requests should be sent", func() {
+				So(atomic.LoadInt32(&hits), ShouldEqual, 2)
+			})
+		})
+	})
+}
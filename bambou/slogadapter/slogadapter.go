@@ -0,0 +1,107 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build go1.21
+
+// Package slogadapter adapts the log/slog loggers to bambou.StructuredLogger.
+package slogadapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// Logger is a bambou.StructuredLogger writing to a *slog.Logger.
+type Logger struct {
+	logger *slog.Logger
+}
+
+// New returns a new *Logger writing to the given *slog.Logger, or to slog.Default() if nil.
+func New(logger *slog.Logger) *Logger {
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Logger{
+		logger: logger,
+	}
+}
+
+// Debugf implements the bambou.LeveledLogger interface.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+
+	l.log(slog.LevelDebug, format, args...)
+}
+
+// Infof implements the bambou.LeveledLogger interface.
+func (l *Logger) Infof(format string, args ...interface{}) {
+
+	l.log(slog.LevelInfo, format, args...)
+}
+
+// Warnf implements the bambou.LeveledLogger interface.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+
+	l.log(slog.LevelWarn, format, args...)
+}
+
+// Errorf implements the bambou.LeveledLogger interface.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+
+	l.log(slog.LevelError, format, args...)
+}
+
+// DebugFields implements the bambou.StructuredLogger interface.
+func (l *Logger) DebugFields(message string, fields bambou.Fields) {
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, len(keys))
+	for i, key := range keys {
+		attrs[i] = slog.Any(key, fields[key])
+	}
+
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, message, attrs...)
+}
+
+// log formats the message, if the level is enabled, and writes it.
+func (l *Logger) log(level slog.Level, format string, args ...interface{}) {
+
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	l.logger.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+var _ bambou.StructuredLogger = (*Logger)(nil)
@@ -0,0 +1,92 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build go1.21
+
+package slogadapter
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSlogAdapter_New(t *testing.T) {
+
+	Convey("Given I have a slog logger", t, func() {
+
+		buffer := &bytes.Buffer{}
+		handler := slog.NewTextHandler(buffer, &slog.HandlerOptions{
+			Level: slog.LevelInfo,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+		adapter := New(slog.New(handler))
+
+		Convey("When I log through the adapter", func() {
+
+			adapter.Debugf("hidden")
+			adapter.Infof("value is %d", 42)
+			adapter.Warnf("warning")
+			adapter.Errorf("failure")
+
+			Convey("Then the logger should get the entries above its level", func() {
+				So(buffer.String(), ShouldEqual, "level=INFO msg=\"value is 42\"\nlevel=WARN msg=warning\nlevel=ERROR msg=failure\n")
+			})
+		})
+
+		Convey("When I log fields at the debug level", func() {
+
+			handler := slog.NewTextHandler(buffer, &slog.HandlerOptions{
+				Level: slog.LevelDebug,
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+			New(slog.New(handler)).DebugFields("request", bambou.Fields{"status": 200, "method": "GET"})
+
+			Convey("Then the logger should get them as attributes", func() {
+				So(buffer.String(), ShouldEqual, "level=DEBUG msg=request method=GET status=200\n")
+			})
+		})
+
+		Convey("When I create an adapter without logger", func() {
+
+			adapter := New(nil)
+
+			Convey("Then it should use the default logger", func() {
+				So(adapter.logger, ShouldEqual, slog.Default())
+			})
+		})
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Snapshot is the state of an object and of its subtree at a point in time, taken
+// by a Snapshotter. It can be encoded as JSON; the identities of its objects must
+// be registered to decode it. See RegisterIdentity.
+type Snapshot struct {
+	Time time.Time `json:"time"`
+
+	// Object is the object with its subtree.
+	Object *TreeNode `json:"object"`
+}
+
+// Snapshotter takes snapshots of objects, like a domain or an enterprise, and
+// restores them, for instance to revert a bad policy push.
+//
+// A restore is planned like a Reconciler with pruning, matching the objects by ID:
+// the attributes of the objects of the snapshot are restored, the objects deleted
+// since the snapshot are recreated with new IDs, and the objects created since
+// the snapshot are deleted. The attributes with a zero value in the snapshot are
+// left untouched, and the assignments are not restored.
+type Snapshotter struct {
+	storer Storer
+	layout TreeLayout
+}
+
+// NewSnapshotter returns a new *Snapshotter of the subtrees described by the given layout.
+// See TreeLayout.
+func NewSnapshotter(storer Storer, layout TreeLayout) *Snapshotter {
+
+	return &Snapshotter{
+		storer: storer,
+		layout: layout,
+	}
+}
+
+// Snapshot fetches the given object and its subtree, and returns their snapshot.
+// The given object is not modified.
+func (s *Snapshotter) Snapshot(object Identifiable) (*Snapshot, *Error) {
+
+	current, err := s.fetch(object)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := FetchTree(s.storer, current, s.layout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		Time:   time.Now(),
+		Object: NewTreeNode(current, children...),
+	}, nil
+}
+
+// PlanRestore returns the changes restoring the object of the given snapshot and its
+// subtree. The object itself must still exist.
+func (s *Snapshotter) PlanRestore(snapshot *Snapshot) (*Plan, *Error) {
+
+	if snapshot.Object == nil || snapshot.Object.Object == nil {
+		return nil, NewBambouError("Invalid snapshot", "the snapshot has no object")
+	}
+
+	current, err := s.fetch(snapshot.Object.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := restorable(snapshot.Object.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, patched, err := diffAttributes(codecOf(s.storer), desired, current)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := current.Identity()
+	path := fmt.Sprintf("%s[ID=%s]", identity.Name, current.Identifier())
+
+	plan := &Plan{}
+	if len(changes) > 0 {
+		plan.Changes = append(plan.Changes, &PlannedChange{
+			Action:   PlanUpdate,
+			Path:     path,
+			Identity: identity,
+			ID:       current.Identifier(),
+			Changes:  changes,
+			object:   patched,
+		})
+	}
+
+	nodes, err := restorableNodes(snapshot.Object.Children)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reconciler().planChildren(plan, &planTarget{object: current}, true, path, nodes); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Restore restores the object of the given snapshot and its subtree, and returns the
+// applied plan. See PlanRestore. The restore is not transactional: if it fails, the
+// changes already applied are left as is.
+func (s *Snapshotter) Restore(snapshot *Snapshot) (*Plan, *Error) {
+
+	plan, err := s.PlanRestore(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reconciler().Apply(plan); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+// reconciler returns the Reconciler planning the restores, matching the objects by
+// ID and pruning the children of all the identities of the layout.
+func (s *Snapshotter) reconciler() *Reconciler {
+
+	r := NewReconciler(s.storer)
+	r.SetPrune(true)
+	r.layout = s.layout
+
+	for _, identities := range s.layout {
+		for _, identity := range identities {
+			r.SetKey(identity, "ID")
+		}
+	}
+
+	return r
+}
+
+// fetch returns a fetched copy of the given object.
+func (s *Snapshotter) fetch(object Identifiable) (Identifiable, *Error) {
+
+	current := newIdentifiableLike(object)
+	if current == nil {
+		return nil, NewBambouError("Invalid object", fmt.Sprintf("%s must be a pointer", object.Identity().Name))
+	}
+	current.SetIdentifier(object.Identifier())
+
+	if err := s.storer.FetchEntity(current); err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// restorable returns a copy of the given object without its system attributes
+// but its ID, which cannot be restored.
+func restorable(object Identifiable) (Identifiable, *Error) {
+
+	attributes, err := attributesOf(object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range SystemAttributes {
+		if name != "ID" {
+			delete(attributes, name)
+		}
+	}
+
+	clone := newIdentifiableLike(object)
+	data, _ := json.Marshal(attributes)
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	return clone, nil
+}
+
+// restorableNodes returns a copy of the given trees with restorable objects.
+func restorableNodes(nodes []*TreeNode) ([]*TreeNode, *Error) {
+
+	copies := make([]*TreeNode, 0, len(nodes))
+	for _, node := range nodes {
+
+		object, err := restorable(node.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		children, err := restorableNodes(node.Children)
+		if err != nil {
+			return nil, err
+		}
+
+		copies = append(copies, NewTreeNode(object, children...))
+	}
+
+	return copies, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type describedObject struct {
+	FakeObject
+
+	Description  string `json:"description,omitempty"`
+	ParentID     string `json:"parentID,omitempty"`
+	CreationDate int    `json:"creationDate,omitempty"`
+}
+
+func TestSnapshotter_Restore(t *testing.T) {
+
+	Convey("Given I have a snapshot of an object with a subtree", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &describedObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "p", "name": "domain", "description": "good", "creationDate": 1})
+		ts.add("p", map[string]interface{}{"ID": "a", "name": "a", "description": "allow", "parentID": "p", "creationDate": 1})
+		ts.add("a", map[string]interface{}{"ID": "b", "name": "b", "parentID": "a", "creationDate": 1})
+		ts.add("p", map[string]interface{}{"ID": "c", "name": "c", "parentID": "p", "creationDate": 1})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		snapshotter := NewSnapshotter(session, TreeLayout{"fake": {FakeIdentity}})
+
+		snapshot, err := snapshotter.Snapshot(&describedObject{FakeObject: FakeObject{ID: "p"}})
+		So(err, ShouldBeNil)
+
+		Convey("When nothing changed", func() {
+
+			plan, err := snapshotter.PlanRestore(snapshot)
+
+			Convey("Then the restore should have no change", func() {
+				So(err, ShouldBeNil)
+				So(plan.HasChanges(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When a bad change is pushed and I restore the snapshot", func() {
+
+			ts.lock.Lock()
+			ts.objects["p"]["description"] = "bad"
+			ts.objects["a"]["description"] = "deny"
+			ts.objects["a"]["creationDate"] = 2
+			delete(ts.objects, "b")
+			delete(ts.objects, "c")
+			ts.lock.Unlock()
+			ts.add("p", map[string]interface{}{"ID": "d", "name": "d"})
+			ts.add("a", map[string]interface{}{"ID": "e", "name": "e"})
+
+			plan, err := snapshotter.Restore(snapshot)
+
+			Convey("Then the object and its subtree should be restored", func() {
+				So(err, ShouldBeNil)
+				So(plan.String(), ShouldContainSubstring, "Plan: 2 to create, 2 to update, 2 to delete.")
+				So(ts.get("p")["description"], ShouldEqual, "good")
+				So(ts.get("a")["description"], ShouldEqual, "allow")
+				So(ts.get("d"), ShouldBeNil)
+				So(ts.get("e"), ShouldBeNil)
+
+				ts.lock.Lock()
+				names := map[string]string{}
+				for ID, object := range ts.objects {
+					names[object["name"].(string)] = ts.parents[ID]
+				}
+				ts.lock.Unlock()
+				So(names, ShouldResemble, map[string]string{"domain": "", "a": "p", "b": "a", "c": "p"})
+			})
+		})
+
+		Convey("When I encode and decode the snapshot", func() {
+
+			data, err := json.Marshal(snapshot)
+			So(err, ShouldBeNil)
+
+			decoded := &Snapshot{}
+			err = json.Unmarshal(data, decoded)
+
+			Convey("Then I should get the same snapshot", func() {
+				So(err, ShouldBeNil)
+				So(decoded.Time.Equal(snapshot.Time), ShouldBeTrue)
+				So(decoded.Object, ShouldResemble, snapshot.Object)
+			})
+		})
+
+		Convey("When the object does not exist anymore", func() {
+
+			ts.lock.Lock()
+			delete(ts.objects, "p")
+			ts.lock.Unlock()
+			_, err := snapshotter.PlanRestore(snapshot)
+
+			Convey("Then the restore should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
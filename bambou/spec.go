@@ -0,0 +1,354 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// SpecAttribute is the specification of an attribute, as defined in the Monolithe specifications.
+type SpecAttribute struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Type           string   `json:"type"`
+	Subtype        string   `json:"subtype"`
+	Required       bool     `json:"required"`
+	ReadOnly       bool     `json:"read_only"`
+	CreationOnly   bool     `json:"creation_only"`
+	Deprecated     bool     `json:"deprecated"`
+	MinLength      *int     `json:"min_length"`
+	MaxLength      *int     `json:"max_length"`
+	MinValue       *float64 `json:"min_value"`
+	MaxValue       *float64 `json:"max_value"`
+	AllowedChars   string   `json:"allowed_chars"`
+	AllowedChoices []string `json:"allowed_choices"`
+}
+
+// SpecModel is the description of the model of a Spec.
+type SpecModel struct {
+	RESTName     string   `json:"rest_name"`
+	ResourceName string   `json:"resource_name"`
+	EntityName   string   `json:"entity_name"`
+	Description  string   `json:"description"`
+	Root         bool     `json:"root"`
+	Extends      []string `json:"extends"`
+}
+
+// SpecChild is the description of a relationship between a Spec and one of its children.
+// The relationship is "child" for the children created under their parent, and "member"
+// for the objects assigned to it.
+type SpecChild struct {
+	RESTName     string `json:"rest_name"`
+	Relationship string `json:"relationship"`
+	Get          bool   `json:"get"`
+	Create       bool   `json:"create"`
+	Update       bool   `json:"update"`
+	Delete       bool   `json:"delete"`
+}
+
+// Spec is the specification of an Identity, as defined in the Monolithe specifications.
+type Spec struct {
+	Model      SpecModel        `json:"model"`
+	Attributes []*SpecAttribute `json:"attributes"`
+	Children   []*SpecChild     `json:"children"`
+}
+
+// Attribute returns the specification of the attribute with the given name, or nil.
+func (s *Spec) Attribute(name string) *SpecAttribute {
+
+	for _, attribute := range s.Attributes {
+		if attribute.Name == name {
+			return attribute
+		}
+	}
+
+	return nil
+}
+
+// LoadSpec reads a Spec from the given reader.
+func LoadSpec(r io.Reader) (*Spec, error) {
+
+	spec := &Spec{}
+	if err := json.NewDecoder(r).Decode(spec); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// SpecSet is a set of specifications of the same API version, used to validate
+// the objects against the specifications of the server they are sent to.
+// See Session.SetSpecs.
+type SpecSet struct {
+	Version string
+	specs   map[string]*Spec
+	lock    sync.RWMutex
+}
+
+// NewSpecSet returns a new *SpecSet containing the given specifications.
+func NewSpecSet(version string, specs ...*Spec) *SpecSet {
+
+	set := &SpecSet{
+		Version: version,
+		specs:   map[string]*Spec{},
+	}
+
+	for _, spec := range specs {
+		set.specs[spec.Model.RESTName] = spec
+	}
+
+	return set
+}
+
+// LoadSpecSet loads the specifications of the given directory, like a checkout of the
+// VSD API specifications. The version is read from the api.info file, if any.
+// The abstract specifications, with a name starting with @, are merged into the
+// specifications extending them.
+func LoadSpecSet(dir string) (*SpecSet, error) {
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.spec"))
+	if err != nil {
+		return nil, err
+	}
+
+	abstracts := map[string]*Spec{}
+	var specs []*Spec
+
+	for _, path := range paths {
+
+		spec, err := loadSpecFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+
+		if name := strings.TrimSuffix(filepath.Base(path), ".spec"); strings.HasPrefix(name, "@") {
+			abstracts[name] = spec
+			continue
+		}
+
+		specs = append(specs, spec)
+	}
+
+	for _, spec := range specs {
+		for _, name := range spec.Model.Extends {
+			abstract, ok := abstracts[name]
+			if !ok {
+				return nil, fmt.Errorf("%s extends unknown specification %s", spec.Model.RESTName, name)
+			}
+			for _, attribute := range abstract.Attributes {
+				if spec.Attribute(attribute.Name) == nil {
+					spec.Attributes = append(spec.Attributes, attribute)
+				}
+			}
+		}
+	}
+
+	var info struct {
+		Version string `json:"version"`
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "api.info")); err == nil {
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("api.info: %s", err)
+		}
+	}
+
+	return NewSpecSet(info.Version, specs...), nil
+}
+
+func loadSpecFile(path string) (*Spec, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadSpec(file)
+}
+
+// Spec returns the specification of the given Identity, or nil.
+func (s *SpecSet) Spec(identity Identity) *Spec {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.specs[identity.Name]
+}
+
+// Specs returns the specifications of the set, sorted by ReST name.
+func (s *SpecSet) Specs() []*Spec {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	specs := make([]*Spec, 0, len(s.specs))
+	for _, spec := range s.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Model.RESTName < specs[j].Model.RESTName })
+
+	return specs
+}
+
+// IsWritable returns true if the given attribute of the given Identity can be set
+// when creating the object if creating is true, or when updating it otherwise.
+func (s *SpecSet) IsWritable(identity Identity, name string, creating bool) bool {
+
+	spec := s.Spec(identity)
+	if spec == nil {
+		return false
+	}
+
+	attribute := spec.Attribute(name)
+	if attribute == nil || attribute.ReadOnly {
+		return false
+	}
+
+	return creating || !attribute.CreationOnly
+}
+
+// Validate validates the attributes of the given object against its specification.
+// The object is validated for a creation if creating is true, or for an update otherwise.
+// It returns the errors, and warnings about the attributes that are deprecated, unknown
+// in this version of the API, or not writable. Objects without specification are not validated.
+func (s *SpecSet) Validate(object Identifiable, creating bool) (ValidationErrors, []string) {
+
+	spec := s.Spec(object.Identity())
+	if spec == nil {
+		return nil, nil
+	}
+
+	attributes, berr := attributesOf(object)
+	if berr != nil {
+		return nil, nil
+	}
+
+	var errs ValidationErrors
+	var warnings []string
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		if isZeroAttribute(attributes[name]) {
+			continue
+		}
+
+		attribute := spec.Attribute(name)
+
+		switch {
+		case attribute == nil:
+			warnings = append(warnings, fmt.Sprintf("%s is not an attribute of %s in version %s", name, spec.Model.RESTName, s.Version))
+		case attribute.Deprecated:
+			warnings = append(warnings, fmt.Sprintf("%s of %s is deprecated", name, spec.Model.RESTName))
+		case !attribute.ReadOnly && !creating && attribute.CreationOnly:
+			warnings = append(warnings, fmt.Sprintf("%s of %s can only be set on creation", name, spec.Model.RESTName))
+		}
+	}
+
+	for _, attribute := range spec.Attributes {
+
+		if attribute.ReadOnly || (!creating && attribute.CreationOnly) {
+			continue
+		}
+
+		value := attributes[attribute.Name]
+
+		if isZeroAttribute(value) {
+			if attribute.Required {
+				errs = append(errs, &FieldError{Field: attribute.Name, Rule: "required", Message: "is required"})
+			}
+			continue
+		}
+
+		errs = append(errs, checkSpecAttribute(attribute, value)...)
+	}
+
+	return errs, warnings
+}
+
+// checkSpecAttribute returns the errors of the given value of the given attribute.
+func checkSpecAttribute(attribute *SpecAttribute, value interface{}) ValidationErrors {
+
+	var errs ValidationErrors
+	fail := func(rule string, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Field: attribute.Name, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch v := value.(type) {
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if attribute.MinLength != nil && length < *attribute.MinLength {
+			fail("minlength", "must be at least %d long", *attribute.MinLength)
+		}
+		if attribute.MaxLength != nil && length > *attribute.MaxLength {
+			fail("maxlength", "must be at most %d long", *attribute.MaxLength)
+		}
+		if len(attribute.AllowedChoices) > 0 && !containsString(attribute.AllowedChoices, v) {
+			fail("enum", "must be one of %s", strings.Join(attribute.AllowedChoices, ", "))
+		}
+		if attribute.AllowedChars != "" {
+			if re, err := compilePattern(attribute.AllowedChars); err != nil {
+				DefaultLogger().Warnf("Invalid allowed_chars of %s: %s", attribute.Name, err)
+			} else if !re.MatchString(v) {
+				fail("pattern", "must match %s", attribute.AllowedChars)
+			}
+		}
+
+	case float64:
+		if attribute.MinValue != nil && v < *attribute.MinValue {
+			fail("min", "must be at least %v", *attribute.MinValue)
+		}
+		if attribute.MaxValue != nil && v > *attribute.MaxValue {
+			fail("max", "must be at most %v", *attribute.MaxValue)
+		}
+	}
+
+	return errs
+}
+
+func containsString(list []string, s string) bool {
+
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
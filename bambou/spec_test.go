@@ -0,0 +1,228 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const fakeSpec = `{
+    "model": {"rest_name": "fake", "resource_name": "fakes", "entity_name": "Fake", "extends": ["@base"]},
+    "attributes": [
+        {"name": "name", "type": "string", "required": true, "min_length": 2, "max_length": 5, "allowed_chars": "^[a-z]+$"},
+        {"name": "kind", "type": "enum", "creation_only": true, "allowed_choices": ["A", "B"]},
+        {"name": "size", "type": "integer", "min_value": 1, "max_value": 10},
+        {"name": "legacy", "type": "string", "deprecated": true},
+        {"name": "status", "type": "string", "read_only": true, "required": true}
+    ]
+}`
+
+const baseSpec = `{
+    "model": {"rest_name": "@base"},
+    "attributes": [{"name": "ID", "type": "string", "read_only": true}]
+}`
+
+type specObject struct {
+	FakeObject
+
+	Kind   string `json:"kind,omitempty"`
+	Size   int    `json:"size,omitempty"`
+	Legacy string `json:"legacy,omitempty"`
+	Status string `json:"status,omitempty"`
+	Other  string `json:"other,omitempty"`
+}
+
+func TestSpec_LoadSpecSet(t *testing.T) {
+
+	Convey("Given I have a directory of specifications", t, func() {
+
+		dir, _ := ioutil.TempDir("", "specs")
+		defer os.RemoveAll(dir)
+
+		ioutil.WriteFile(filepath.Join(dir, "fake.spec"), []byte(fakeSpec), 0644)
+		ioutil.WriteFile(filepath.Join(dir, "@base.spec"), []byte(baseSpec), 0644)
+		ioutil.WriteFile(filepath.Join(dir, "api.info"), []byte(`{"version": "6.0"}`), 0644)
+
+		Convey("When I load it", func() {
+
+			set, err := LoadSpecSet(dir)
+
+			Convey("Then I should get the specifications with their abstracts merged", func() {
+				So(err, ShouldBeNil)
+				So(set.Version, ShouldEqual, "6.0")
+				So(set.Spec(FakeIdentity), ShouldNotBeNil)
+				So(set.Spec(FakeIdentity).Attributes, ShouldHaveLength, 6)
+				So(set.Spec(FakeIdentity).Attribute("ID").ReadOnly, ShouldBeTrue)
+				So(set.Spec(Identity{Name: "@base"}), ShouldBeNil)
+				So(set.Specs(), ShouldHaveLength, 1)
+				So(set.Specs()[0].Model.EntityName, ShouldEqual, "Fake")
+			})
+		})
+
+		Convey("When I load it with an unknown abstract", func() {
+
+			os.Remove(filepath.Join(dir, "@base.spec"))
+			_, err := LoadSpecSet(dir)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I load it with an invalid specification", func() {
+
+			ioutil.WriteFile(filepath.Join(dir, "bad.spec"), []byte("{"), 0644)
+			_, err := LoadSpecSet(dir)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestSpec_IsWritable(t *testing.T) {
+
+	Convey("Given I have a specification set", t, func() {
+
+		spec, _ := LoadSpec(strings.NewReader(fakeSpec))
+		set := NewSpecSet("6.0", spec)
+
+		Convey("Then the writable attributes should be correct", func() {
+			So(set.IsWritable(FakeIdentity, "name", false), ShouldBeTrue)
+			So(set.IsWritable(FakeIdentity, "kind", true), ShouldBeTrue)
+			So(set.IsWritable(FakeIdentity, "kind", false), ShouldBeFalse)
+			So(set.IsWritable(FakeIdentity, "status", true), ShouldBeFalse)
+			So(set.IsWritable(FakeIdentity, "unknown", true), ShouldBeFalse)
+			So(set.IsWritable(Identity{Name: "unknown"}, "name", true), ShouldBeFalse)
+		})
+	})
+}
+
+func TestSpec_Validate(t *testing.T) {
+
+	Convey("Given I have a specification set", t, func() {
+
+		spec, _ := LoadSpec(strings.NewReader(fakeSpec))
+		set := NewSpecSet("6.0", spec)
+
+		Convey("When I validate a valid object", func() {
+
+			errs, warnings := set.Validate(&specObject{FakeObject: FakeObject{Name: "abc"}, Kind: "A", Size: 3}, true)
+
+			Convey("Then I should get neither errors nor warnings", func() {
+				So(errs, ShouldBeEmpty)
+				So(warnings, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I validate an invalid object", func() {
+
+			errs, _ := set.Validate(&specObject{FakeObject: FakeObject{Name: "ABCDEF"}, Kind: "C", Size: 11}, true)
+
+			Convey("Then I should get the errors", func() {
+				So(errs, ShouldHaveLength, 4)
+				So(errs[0].Rule, ShouldEqual, "maxlength")
+				So(errs[1].Rule, ShouldEqual, "pattern")
+				So(errs[2].Rule, ShouldEqual, "enum")
+				So(errs[3].Rule, ShouldEqual, "max")
+			})
+		})
+
+		Convey("When I validate an object without the required attributes", func() {
+
+			errs, _ := set.Validate(&specObject{}, true)
+
+			Convey("Then I should get an error for the writable ones only", func() {
+				So(errs, ShouldHaveLength, 1)
+				So(errs[0].Field, ShouldEqual, "name")
+				So(errs[0].Rule, ShouldEqual, "required")
+			})
+		})
+
+		Convey("When I validate an update of an object with questionable attributes", func() {
+
+			errs, warnings := set.Validate(&specObject{FakeObject: FakeObject{Name: "abc"}, Kind: "C", Legacy: "x", Other: "y"}, false)
+
+			Convey("Then I should get warnings, and no errors for the attributes set on creation", func() {
+				So(errs, ShouldBeEmpty)
+				So(warnings, ShouldHaveLength, 3)
+				So(warnings[0], ShouldContainSubstring, "kind")
+				So(warnings[1], ShouldContainSubstring, "legacy")
+				So(warnings[2], ShouldContainSubstring, "version 6.0")
+			})
+		})
+
+		Convey("When I validate an object without specification", func() {
+
+			errs, warnings := set.Validate(&FakeRootObject{}, true)
+
+			Convey("Then I should get nothing", func() {
+				So(errs, ShouldBeNil)
+				So(warnings, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestSpec_Session(t *testing.T) {
+
+	Convey("Given I have a session with specifications", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "x"})
+
+		spec, _ := LoadSpec(strings.NewReader(fakeSpec))
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetSpecs(NewSpecSet("6.0", spec))
+
+		Convey("When I create an object violating them", func() {
+
+			err := session.CreateChild(NewFakeObject("x"), &specObject{FakeObject: FakeObject{Name: "abc"}, Kind: "C"})
+
+			Convey("Then I should get a validation error without any request", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Validation error")
+				So(err.Details.(ValidationErrors)[0].Field, ShouldEqual, "kind")
+				So(ts.methods(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I save the same object", func() {
+
+			err := session.SaveEntity(&specObject{FakeObject: FakeObject{ID: "x", Name: "abc"}, Kind: "C"})
+
+			Convey("Then the creation only attribute should not be validated", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
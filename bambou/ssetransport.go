@@ -0,0 +1,220 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEHeartbeatTimeout is the default maximum duration without receiving
+// anything from the server, including heartbeats, before the SSETransport
+// considers the stream as lost.
+const DefaultSSEHeartbeatTimeout = 2 * time.Minute
+
+// SSETransport is an EventTransport that receives the notifications as Server-Sent Events.
+// The stream is opened on the first call to NextEvent and re-opened after any error,
+// resuming from the given last event ID using the Last-Event-ID header.
+// Comment lines sent by the server are handled as heartbeats.
+type SSETransport struct {
+	session          *Session
+	heartbeatTimeout time.Duration
+	body             io.ReadCloser
+	cancel           context.CancelFunc
+	reader           *bufio.Reader
+	lock             sync.Mutex
+}
+
+// NewSSETransport returns a new *SSETransport that will use the given Session
+// to locate and authenticate against the server.
+func NewSSETransport(session *Session) *SSETransport {
+
+	return &SSETransport{
+		session:          session,
+		heartbeatTimeout: DefaultSSEHeartbeatTimeout,
+	}
+}
+
+// SetHeartbeatTimeout sets the maximum duration without receiving anything from the server.
+func (t *SSETransport) SetHeartbeatTimeout(timeout time.Duration) {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.heartbeatTimeout = timeout
+}
+
+// NextEvent reads the next event or heartbeat from the stream. If it is an event
+// with a notification containing events, the notification is sent to the given channel.
+func (t *SSETransport) NextEvent(channel NotificationsChannel, lastEventID string) *Error {
+
+	return t.NextEventWithContext(context.Background(), channel, lastEventID)
+}
+
+// NextEventWithContext works like NextEvent, but returns as soon as the given context is done.
+// In that case, the stream is closed.
+func (t *SSETransport) NextEventWithContext(ctx context.Context, channel NotificationsChannel, lastEventID string) *Error {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.reader == nil {
+		if berr := t.open(ctx, lastEventID); berr != nil {
+			return berr
+		}
+	}
+
+	var data bytes.Buffer
+	var eventID string
+
+	body := t.body
+
+	for {
+
+		timer := time.AfterFunc(t.heartbeatTimeout, func() { body.Close() })
+		stopWatching := watchContext(ctx, func() { body.Close() })
+		line, err := t.reader.ReadString('\n')
+		stopWatching()
+		timer.Stop()
+
+		if err != nil {
+			t.close()
+			if ctx.Err() != nil {
+				return newCanceledError(ctx)
+			}
+			return NewBambouError("SSE error", err.Error())
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+
+		case line == "" && data.Len() == 0:
+			continue
+
+		case line == "":
+			notification := NewNotification()
+			if err := json.Unmarshal(data.Bytes(), notification); err != nil {
+				return NewBambouError("JSON error", err.Error())
+			}
+			if notification.UUID == "" {
+				notification.UUID = eventID
+			}
+			return sendNotification(ctx, channel, notification)
+
+		case strings.HasPrefix(line, ":"):
+			if data.Len() == 0 {
+				return nil
+			}
+
+		default:
+			field, value := line, ""
+			if i := strings.Index(line, ":"); i >= 0 {
+				field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+			}
+
+			switch field {
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			case "id":
+				eventID = value
+			}
+		}
+	}
+}
+
+// Close closes the stream, if any.
+func (t *SSETransport) Close() error {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.close()
+}
+
+func (t *SSETransport) close() error {
+
+	if t.body == nil {
+		return nil
+	}
+
+	err := t.body.Close()
+	t.cancel()
+	t.body = nil
+	t.cancel = nil
+	t.reader = nil
+
+	return err
+}
+
+// open opens the stream. Only the opening is canceled if the given context is done,
+// as the stream outlives the call to NextEventWithContext.
+func (t *SSETransport) open(ctx context.Context, lastEventID string) *Error {
+
+	currentURL := t.session.URL + "/events"
+	if lastEventID != "" {
+		currentURL += "?" + url.Values{"uuid": []string{lastEventID}}.Encode()
+	}
+
+	request, err := http.NewRequest("GET", currentURL, nil)
+	if err != nil {
+		return NewBambouError("HTTP transaction error", err.Error())
+	}
+
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stopWatching := watchContext(ctx, cancel)
+	response, berr := t.session.send(request.WithContext(streamCtx), nil)
+	stopWatching()
+
+	if berr != nil {
+		cancel()
+		if ctx.Err() != nil {
+			return newCanceledError(ctx)
+		}
+		return berr
+	}
+
+	t.body = response.Body
+	t.cancel = cancel
+	t.reader = bufio.NewReader(response.Body)
+
+	return nil
+}
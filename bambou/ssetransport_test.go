@@ -0,0 +1,114 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSSETransport_NextEvent(t *testing.T) {
+
+	Convey("Given I have a server sending Server-Sent Events", t, func() {
+
+		requests := make(chan *http.Request, 10)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			requests <- r
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ": heartbeat\n\n")
+			fmt.Fprint(w, "id: a\ndata: {\"events\": [{\"type\": \"CREATE\", \"entityType\": \"fake\",\n")
+			fmt.Fprint(w, "data: \"entities\": [{\"ID\": \"x\"}]}]}\n\n")
+			fmt.Fprint(w, "data: {\"uuid\": \"b\", \"events\": []}\n\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		transport := NewSSETransport(session)
+		transport.SetHeartbeatTimeout(50 * time.Millisecond)
+		defer transport.Close()
+
+		channel := make(NotificationsChannel, 1)
+
+		Convey("When I read the heartbeat", func() {
+
+			err := transport.NextEvent(channel, "last")
+
+			Convey("Then err should be nil and nothing should be received", func() {
+				So(err, ShouldBeNil)
+				So(len(channel), ShouldEqual, 0)
+			})
+
+			Convey("Then the stream should be opened from the last event ID", func() {
+				r := <-requests
+				So(r.URL.Query().Get("uuid"), ShouldEqual, "last")
+				So(r.Header.Get("Last-Event-ID"), ShouldEqual, "last")
+				So(r.Header.Get("Accept"), ShouldEqual, "text/event-stream")
+			})
+
+			Convey("When I read the next event", func() {
+
+				err := transport.NextEvent(channel, "last")
+
+				Convey("Then I should receive the multi line notification with the event ID", func() {
+					So(err, ShouldBeNil)
+					n := <-channel
+					So(n.UUID, ShouldEqual, "a")
+					So(n.Events[0].DataMap[0]["ID"], ShouldEqual, "x")
+				})
+
+				Convey("When I read the empty notification and then nothing is sent", func() {
+
+					err1 := transport.NextEvent(channel, "a")
+					err2 := transport.NextEvent(channel, "a")
+
+					Convey("Then the empty notification should not be sent", func() {
+						So(err1, ShouldBeNil)
+						So(len(channel), ShouldEqual, 1)
+					})
+
+					Convey("Then the heartbeat timeout should close the stream", func() {
+						So(err2, ShouldNotBeNil)
+					})
+
+					Convey("Then the next call should re-open the stream from the last event ID", func() {
+						<-requests
+						transport.NextEvent(channel, "b")
+						r := <-requests
+						So(r.Header.Get("Last-Event-ID"), ShouldEqual, "b")
+					})
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,341 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// storeEntry is an object of a Store with its index keys.
+type storeEntry struct {
+	object   Identifiable
+	parentID string
+	name     string
+}
+
+// storedEntry is the persisted form of a storeEntry.
+type storedEntry struct {
+	Identity string          `json:"identity"`
+	ParentID string          `json:"parentID"`
+	Object   json.RawMessage `json:"object"`
+}
+
+// Store is an in-memory mirror of the objects of the tracked identities,
+// indexed by ID, name and parent ID. It is populated with Fetch, kept up to date
+// by registering HandleEvent on a PushCenter, and can be persisted with Save and Load.
+type Store struct {
+	factories map[string]IdentifiableFactory
+	entries   map[string]*storeEntry
+	byName    map[string]map[string]bool
+	byParent  map[string]map[string]bool
+	lock      sync.RWMutex
+}
+
+// NewStore returns a new empty *Store.
+func NewStore() *Store {
+
+	return &Store{
+		factories: map[string]IdentifiableFactory{},
+		entries:   map[string]*storeEntry{},
+		byName:    map[string]map[string]bool{},
+		byParent:  map[string]map[string]bool{},
+	}
+}
+
+// Track makes the Store mirror the objects of the given Identity.
+// The given factory must return a new pointer to the concrete type of the objects.
+func (s *Store) Track(identity Identity, factory IdentifiableFactory) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.factories[identity.Name] = factory
+}
+
+// Fetch lists the children of the given tracked Identity of the given parent and adds them to the Store.
+func (s *Store) Fetch(storer Storer, parent Identifiable, identity Identity) *Error {
+
+	s.lock.RLock()
+	factory, ok := s.factories[identity.Name]
+	s.lock.RUnlock()
+
+	if !ok {
+		return NewBambouError("Untracked identity", fmt.Sprintf("%s is not tracked by the store", identity.Name))
+	}
+
+	dest := reflect.New(reflect.SliceOf(reflect.TypeOf(factory())))
+	if err := storer.FetchChildren(parent, identity, dest.Interface(), nil); err != nil {
+		return err
+	}
+
+	parentID := ""
+	if parent != nil {
+		parentID = parent.Identifier()
+	}
+
+	for i := 0; i < dest.Elem().Len(); i++ {
+		s.Add(dest.Elem().Index(i).Interface().(Identifiable), parentID)
+	}
+
+	return nil
+}
+
+// Add adds or replaces the given object, child of the object with the given ID.
+func (s *Store) Add(object Identifiable, parentID string) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.add(object, parentID)
+}
+
+// Remove removes the object with the given ID.
+func (s *Store) Remove(ID string) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.remove(ID)
+}
+
+// Get returns the object with the given ID, or nil if there is none.
+func (s *Store) Get(ID string) Identifiable {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if entry, ok := s.entries[ID]; ok {
+		return entry.object
+	}
+
+	return nil
+}
+
+// GetByName returns the objects of the given Identity with the given name, sorted by ID.
+func (s *Store) GetByName(identity Identity, name string) IdentifiablesList {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.list(s.byName[nameKey(identity.Name, name)], identity)
+}
+
+// Children returns the objects of the given Identity that are children of the object
+// with the given ID, sorted by ID.
+func (s *Store) Children(parentID string, identity Identity) IdentifiablesList {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.list(s.byParent[parentID], identity)
+}
+
+// Len returns the number of objects in the Store.
+func (s *Store) Len() int {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.entries)
+}
+
+// HandleEvent applies the given event to the Store. Events about untracked identities are ignored.
+func (s *Store) HandleEvent(event *Event) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	factory, ok := s.factories[event.EntityType]
+	if !ok {
+		return
+	}
+
+	for _, data := range event.DataMap {
+
+		ID, _ := data["ID"].(string)
+		if ID == "" {
+			continue
+		}
+
+		if event.Type == EventTypeDelete {
+			s.remove(ID)
+			continue
+		}
+
+		object := factory()
+		if err := decodeEntity(data, object); err != nil {
+			DefaultLogger().Errorf("Unable to decode the %s of a %s event: %s", event.EntityType, event.Type, err)
+			continue
+		}
+
+		parentID, ok := data["parentID"].(string)
+		if entry, exists := s.entries[ID]; !ok && exists {
+			parentID = entry.parentID
+		}
+
+		s.add(object, parentID)
+	}
+}
+
+// Save writes the content of the Store to the given writer as JSON.
+func (s *Store) Save(w io.Writer) error {
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	IDs := make([]string, 0, len(s.entries))
+	for ID := range s.entries {
+		IDs = append(IDs, ID)
+	}
+	sort.Strings(IDs)
+
+	stored := make([]storedEntry, 0, len(IDs))
+	for _, ID := range IDs {
+
+		entry := s.entries[ID]
+
+		data, err := json.Marshal(entry.object)
+		if err != nil {
+			return err
+		}
+
+		stored = append(stored, storedEntry{Identity: entry.object.Identity().Name, ParentID: entry.parentID, Object: data})
+	}
+
+	return json.NewEncoder(w).Encode(stored)
+}
+
+// Load adds the objects written by Save to the Store.
+// The objects of untracked identities are ignored.
+func (s *Store) Load(r io.Reader) error {
+
+	var stored []storedEntry
+	if err := json.NewDecoder(r).Decode(&stored); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, entry := range stored {
+
+		factory, ok := s.factories[entry.Identity]
+		if !ok {
+			continue
+		}
+
+		object := factory()
+		if err := json.Unmarshal(entry.Object, object); err != nil {
+			return err
+		}
+
+		s.add(object, entry.ParentID)
+	}
+
+	return nil
+}
+
+func (s *Store) add(object Identifiable, parentID string) {
+
+	ID := object.Identifier()
+	s.remove(ID)
+
+	entry := &storeEntry{object: object, parentID: parentID, name: nameOf(object)}
+	s.entries[ID] = entry
+
+	key := nameKey(object.Identity().Name, entry.name)
+	if s.byName[key] == nil {
+		s.byName[key] = map[string]bool{}
+	}
+	s.byName[key][ID] = true
+
+	if s.byParent[parentID] == nil {
+		s.byParent[parentID] = map[string]bool{}
+	}
+	s.byParent[parentID][ID] = true
+}
+
+func (s *Store) remove(ID string) {
+
+	entry, ok := s.entries[ID]
+	if !ok {
+		return
+	}
+
+	delete(s.entries, ID)
+
+	key := nameKey(entry.object.Identity().Name, entry.name)
+	if delete(s.byName[key], ID); len(s.byName[key]) == 0 {
+		delete(s.byName, key)
+	}
+
+	if delete(s.byParent[entry.parentID], ID); len(s.byParent[entry.parentID]) == 0 {
+		delete(s.byParent, entry.parentID)
+	}
+}
+
+// list returns the objects of the given Identity with the given IDs, sorted by ID.
+func (s *Store) list(IDs map[string]bool, identity Identity) IdentifiablesList {
+
+	var sorted []string
+	for ID := range IDs {
+		if s.entries[ID].object.Identity().Name == identity.Name {
+			sorted = append(sorted, ID)
+		}
+	}
+	sort.Strings(sorted)
+
+	list := make(IdentifiablesList, 0, len(sorted))
+	for _, ID := range sorted {
+		list = append(list, s.entries[ID].object)
+	}
+
+	return list
+}
+
+func nameKey(identityName string, name string) string {
+
+	return identityName + "|" + name
+}
+
+// nameOf returns the value of the name attribute of the given object, if any.
+func nameOf(object Identifiable) string {
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return ""
+	}
+
+	var attributes struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(data, &attributes)
+
+	return attributes.Name
+}
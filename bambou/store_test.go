@@ -0,0 +1,210 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newFakeStore() *Store {
+
+	s := NewStore()
+	s.Track(FakeIdentity, func() Identifiable { return &FakeObject{} })
+
+	return s
+}
+
+func TestStore_Fetch(t *testing.T) {
+
+	Convey("Given I have a server with children and a store", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("parent", map[string]interface{}{"ID": "a", "name": "a"})
+		ts.add("parent", map[string]interface{}{"ID": "b", "name": "b"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		s := newFakeStore()
+
+		Convey("When I fetch the children", func() {
+
+			err := s.Fetch(session, NewFakeObject("parent"), FakeIdentity)
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the children should be in the store", func() {
+				So(s.Len(), ShouldEqual, 2)
+				So(s.Get("a").(*FakeObject).Name, ShouldEqual, "a")
+				So(len(s.Children("parent", FakeIdentity)), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I fetch an untracked identity", func() {
+
+			err := s.Fetch(session, NewFakeObject("parent"), FakeRootIdentity)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Untracked identity")
+			})
+		})
+	})
+}
+
+func TestStore_Indexes(t *testing.T) {
+
+	Convey("Given I have a store with objects", t, func() {
+
+		s := newFakeStore()
+		s.Add(&FakeObject{ID: "a", Name: "x"}, "p1")
+		s.Add(&FakeObject{ID: "b", Name: "x"}, "p1")
+		s.Add(&FakeObject{ID: "c", Name: "y"}, "p2")
+
+		Convey("Then I should find the objects by ID", func() {
+			So(s.Get("a"), ShouldResemble, &FakeObject{ID: "a", Name: "x"})
+			So(s.Get("z"), ShouldBeNil)
+		})
+
+		Convey("Then I should find the objects by name", func() {
+			So(s.GetByName(FakeIdentity, "x"), ShouldResemble, IdentifiablesList{&FakeObject{ID: "a", Name: "x"}, &FakeObject{ID: "b", Name: "x"}})
+			So(s.GetByName(FakeRootIdentity, "x"), ShouldBeEmpty)
+		})
+
+		Convey("Then I should find the objects by parent", func() {
+			So(s.Children("p2", FakeIdentity), ShouldResemble, IdentifiablesList{&FakeObject{ID: "c", Name: "y"}})
+		})
+
+		Convey("When I replace an object", func() {
+
+			s.Add(&FakeObject{ID: "a", Name: "y"}, "p2")
+
+			Convey("Then the indexes should be updated", func() {
+				So(len(s.GetByName(FakeIdentity, "x")), ShouldEqual, 1)
+				So(len(s.GetByName(FakeIdentity, "y")), ShouldEqual, 2)
+				So(len(s.Children("p1", FakeIdentity)), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I remove an object", func() {
+
+			s.Remove("c")
+
+			Convey("Then it should not be found anymore", func() {
+				So(s.Get("c"), ShouldBeNil)
+				So(s.GetByName(FakeIdentity, "y"), ShouldBeEmpty)
+				So(s.Children("p2", FakeIdentity), ShouldBeEmpty)
+				So(s.Len(), ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestStore_HandleEvent(t *testing.T) {
+
+	Convey("Given I have a store with an object", t, func() {
+
+		s := newFakeStore()
+		s.Add(&FakeObject{ID: "a", Name: "a"}, "p1")
+
+		Convey("When I handle a create event", func() {
+
+			s.HandleEvent(newFakeEvent(EventTypeCreate, map[string]interface{}{"ID": "b", "name": "b", "parentID": "p1"}))
+
+			Convey("Then the object should be added", func() {
+				So(len(s.Children("p1", FakeIdentity)), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I handle an update event without parentID", func() {
+
+			s.HandleEvent(newFakeEvent(EventTypeUpdate, map[string]interface{}{"ID": "a", "name": "a2"}))
+
+			Convey("Then the object should be updated and keep its parent", func() {
+				So(s.Get("a").(*FakeObject).Name, ShouldEqual, "a2")
+				So(len(s.Children("p1", FakeIdentity)), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I handle a delete event", func() {
+
+			s.HandleEvent(newFakeEvent(EventTypeDelete, map[string]interface{}{"ID": "a"}))
+
+			Convey("Then the object should be removed", func() {
+				So(s.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When I handle an event about an untracked identity", func() {
+
+			s.HandleEvent(&Event{EntityType: "other", Type: EventTypeCreate, DataMap: []map[string]interface{}{{"ID": "b"}}})
+
+			Convey("Then it should be ignored", func() {
+				So(s.Len(), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+
+	Convey("Given I have a store with objects", t, func() {
+
+		s := newFakeStore()
+		s.Add(&FakeObject{ID: "a", Name: "a"}, "p1")
+		s.Add(&FakeObject{ID: "b", Name: "b"}, "p2")
+
+		Convey("When I save it and load it in another store", func() {
+
+			buffer := &bytes.Buffer{}
+			err1 := s.Save(buffer)
+
+			other := newFakeStore()
+			err2 := other.Load(buffer)
+
+			Convey("Then err should be nil", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+			})
+
+			Convey("Then the other store should have the same objects", func() {
+				So(other.Len(), ShouldEqual, 2)
+				So(other.Children("p2", FakeIdentity), ShouldResemble, IdentifiablesList{&FakeObject{ID: "b", Name: "b"}})
+			})
+		})
+
+		Convey("When I load invalid data", func() {
+
+			err := s.Load(bytes.NewBufferString("not json"))
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import "sync/atomic"
+
+// SubscriptionFilter selects the events delivered to a Subscription.
+// Empty Identities or EventTypes match all identities or all types of event.
+type SubscriptionFilter struct {
+	Identities []Identity
+	EventTypes []string
+}
+
+// matches returns true if the given event passes the filter.
+func (f SubscriptionFilter) matches(event *Event) bool {
+
+	return f.matchesIdentity(event.EntityType) && f.matchesEventType(event.Type)
+}
+
+func (f SubscriptionFilter) matchesIdentity(name string) bool {
+
+	if len(f.Identities) == 0 {
+		return true
+	}
+
+	for _, identity := range f.Identities {
+		if identity.Name == name || identity.Name == AllIdentity.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f SubscriptionFilter) matchesEventType(eventType string) bool {
+
+	if len(f.EventTypes) == 0 {
+		return true
+	}
+
+	for _, t := range f.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Subscription receives the events of a PushCenter matching its filter on its own channel.
+// Many subscriptions can share the event stream of a single PushCenter.
+type Subscription struct {
+	ID         int
+	filter     SubscriptionFilter
+	events     chan *Event
+	dropped    uint64
+	pushCenter *PushCenter
+}
+
+// Events returns the channel receiving the events. It is closed by Unsubscribe.
+func (s *Subscription) Events() <-chan *Event {
+
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the channel was full.
+func (s *Subscription) Dropped() uint64 {
+
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Unsubscribe stops the delivery of the events and closes the channel.
+func (s *Subscription) Unsubscribe() {
+
+	s.pushCenter.unsubscribe(s)
+}
+
+// deliver sends the given event to the channel if it matches the filter.
+// The event is dropped if the channel is full.
+func (s *Subscription) deliver(event *Event) {
+
+	if !s.filter.matches(event) {
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// Subscribe returns a new Subscription receiving the events matching the given filter on a channel
+// of the given size. The events are delivered after the handlers are called, and never block the
+// PushCenter: when the channel is full, the events are dropped. See Subscription.Dropped.
+func (p *PushCenter) Subscribe(filter SubscriptionFilter, size int) *Subscription {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.nextSubID++
+
+	subscription := &Subscription{
+		ID:         p.nextSubID,
+		filter:     filter,
+		events:     make(chan *Event, size),
+		pushCenter: p,
+	}
+
+	if p.subscriptions == nil {
+		p.subscriptions = map[int]*Subscription{}
+	}
+	p.subscriptions[subscription.ID] = subscription
+
+	return subscription
+}
+
+// Subscriptions returns the number of active subscriptions.
+func (p *PushCenter) Subscriptions() int {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return len(p.subscriptions)
+}
+
+func (p *PushCenter) unsubscribe(subscription *Subscription) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.subscriptions[subscription.ID]; !ok {
+		return
+	}
+
+	delete(p.subscriptions, subscription.ID)
+	close(subscription.events)
+}
+
+// deliver sends the given event to the subscriptions.
+func (p *PushCenter) deliver(event *Event) {
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for _, subscription := range p.subscriptions {
+		subscription.deliver(event)
+	}
+}
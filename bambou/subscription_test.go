@@ -0,0 +1,108 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSubscription_SubscriptionFilter(t *testing.T) {
+
+	Convey("Given I have filters", t, func() {
+
+		all := SubscriptionFilter{}
+		fakes := SubscriptionFilter{Identities: []Identity{FakeIdentity}}
+		deletes := SubscriptionFilter{EventTypes: []string{EventTypeDelete}}
+		fakeDeletes := SubscriptionFilter{Identities: []Identity{FakeIdentity}, EventTypes: []string{EventTypeDelete}}
+
+		create := &Event{EntityType: "fake", Type: EventTypeCreate}
+		otherDelete := &Event{EntityType: "other", Type: EventTypeDelete}
+
+		Convey("Then they should match the right events", func() {
+			So(all.matches(create), ShouldBeTrue)
+			So(all.matches(otherDelete), ShouldBeTrue)
+			So(fakes.matches(create), ShouldBeTrue)
+			So(fakes.matches(otherDelete), ShouldBeFalse)
+			So(deletes.matches(create), ShouldBeFalse)
+			So(deletes.matches(otherDelete), ShouldBeTrue)
+			So(fakeDeletes.matches(create), ShouldBeFalse)
+			So(fakeDeletes.matches(otherDelete), ShouldBeFalse)
+		})
+	})
+}
+
+func TestSubscription_Subscribe(t *testing.T) {
+
+	Convey("Given I have a push center with subscriptions", t, func() {
+
+		p := NewPushCenter(nil)
+		fakes := p.Subscribe(SubscriptionFilter{Identities: []Identity{FakeIdentity}}, 10)
+		all := p.Subscribe(SubscriptionFilter{}, 1)
+
+		Convey("Then the push center should have 2 subscriptions", func() {
+			So(p.Subscriptions(), ShouldEqual, 2)
+			So(fakes.ID, ShouldNotEqual, all.ID)
+		})
+
+		Convey("When I dispatch events", func() {
+
+			n := NewNotification()
+			n.Events = EventsList{&Event{EntityType: "fake"}, &Event{EntityType: "other"}}
+			p.dispatch(n)
+
+			Convey("Then each subscription should receive the matching events", func() {
+				So(len(fakes.Events()), ShouldEqual, 1)
+				So((<-fakes.Events()).EntityType, ShouldEqual, "fake")
+				So((<-all.Events()).EntityType, ShouldEqual, "fake")
+			})
+
+			Convey("Then the events that did not fit in the channel should be dropped", func() {
+				So(fakes.Dropped(), ShouldEqual, 0)
+				So(all.Dropped(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I unsubscribe", func() {
+
+			fakes.Unsubscribe()
+			fakes.Unsubscribe()
+
+			n := NewNotification()
+			n.Events = EventsList{&Event{EntityType: "fake"}}
+			p.dispatch(n)
+
+			Convey("Then the channel should be closed", func() {
+				_, ok := <-fakes.Events()
+				So(ok, ShouldBeFalse)
+				So(p.Subscriptions(), ShouldEqual, 1)
+			})
+
+			Convey("Then the other subscriptions should still receive the events", func() {
+				So(len(all.Events()), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// This file contains helpers for the authors of Terraform providers, and of the
+// tools that similarly keep track of the objects they manage by a single string.
+
+// CompositeIDSeparator separates the parts of a CompositeID.
+const CompositeIDSeparator = "/"
+
+// CompositeID identifies an object along with its parent, so that it can be
+// recreated or imported knowing only its string representation,
+// parentType/parentID/ID, or ID for an object without parent.
+type CompositeID struct {
+	ParentType string
+	ParentID   string
+	ID         string
+}
+
+// NewCompositeID returns the CompositeID of the given object under the given parent, that can be nil.
+func NewCompositeID(object Identifiable, parent Identifiable) CompositeID {
+
+	c := CompositeID{ID: object.Identifier()}
+
+	if parent != nil {
+		if _, ok := parent.(Rootable); !ok {
+			c.ParentType = parent.Identity().Name
+			c.ParentID = parent.Identifier()
+		}
+	}
+
+	return c
+}
+
+// CompositeIDOf returns the CompositeID of the given object, using its parentType
+// and parentID attributes as returned by the server.
+func CompositeIDOf(object Identifiable) CompositeID {
+
+	c := CompositeID{ID: object.Identifier()}
+
+	if attributes, err := attributesOf(object); err == nil {
+		c.ParentType, _ = attributes["parentType"].(string)
+		c.ParentID, _ = attributes["parentID"].(string)
+	}
+
+	if c.ParentType == "" || c.ParentID == "" {
+		c.ParentType, c.ParentID = "", ""
+	}
+
+	return c
+}
+
+// ParseCompositeID parses the string representation of a CompositeID.
+func ParseCompositeID(s string) (CompositeID, *Error) {
+
+	parts := strings.Split(s, CompositeIDSeparator)
+
+	var c CompositeID
+	switch len(parts) {
+	case 1:
+		c = CompositeID{ID: parts[0]}
+	case 3:
+		c = CompositeID{ParentType: parts[0], ParentID: parts[1], ID: parts[2]}
+	default:
+		return CompositeID{}, NewBambouError("Invalid ID", fmt.Sprintf("%q is neither an ID nor a parentType/parentID/ID", s))
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return CompositeID{}, NewBambouError("Invalid ID", fmt.Sprintf("%q has an empty part", s))
+		}
+	}
+
+	return c, nil
+}
+
+// String returns the string representation of the CompositeID.
+func (c CompositeID) String() string {
+
+	if c.ParentType == "" {
+		return c.ID
+	}
+
+	return strings.Join([]string{c.ParentType, c.ParentID, c.ID}, CompositeIDSeparator)
+}
+
+// Parent returns a new object of the registered type of the parent, with its ID
+// set, or nil if there is no parent or its type is not registered.
+func (c CompositeID) Parent() Identifiable {
+
+	if c.ParentType == "" {
+		return nil
+	}
+
+	parent := NewIdentifiable(c.ParentType)
+	if parent != nil {
+		parent.SetIdentifier(c.ParentID)
+	}
+
+	return parent
+}
+
+// ImportObject fetches the object of the given registered identity designated by the
+// given ID or string representation of a CompositeID, and returns it with its complete
+// CompositeID. If the given ID has a parent, the object must be one of its children.
+func ImportObject(storer Storer, identity Identity, ID string) (Identifiable, CompositeID, *Error) {
+
+	c, err := ParseCompositeID(ID)
+	if err != nil {
+		return nil, CompositeID{}, err
+	}
+
+	object := NewIdentifiable(identity.Name)
+	if object == nil {
+		return nil, CompositeID{}, NewBambouError("Unregistered identity", fmt.Sprintf("%s is not registered", identity.Name))
+	}
+	object.SetIdentifier(c.ID)
+
+	if err := storer.FetchEntity(object); err != nil {
+		return nil, CompositeID{}, err
+	}
+
+	fetched := CompositeIDOf(object)
+
+	if c.ParentType != "" && fetched.ParentType != "" && (fetched.ParentType != c.ParentType || fetched.ParentID != c.ParentID) {
+		return nil, CompositeID{}, NewBambouError("Invalid ID", fmt.Sprintf("%s %s is not a child of %s %s", identity.Name, c.ID, c.ParentType, c.ParentID))
+	}
+
+	if fetched.ParentType == "" {
+		fetched = c
+	}
+
+	return object, fetched, nil
+}
+
+// WaitCondition is the prototype of a function telling if a fetched object reached the expected state.
+type WaitCondition func(Identifiable) (bool, *Error)
+
+// WaitFor fetches the given object every pollInterval until the given condition is true,
+// the given timeout expires or the given context is done. The condition is checked on
+// the given object first. The poll interval must be positive. It polls like the
+// AsyncOperation, without creating the object.
+func WaitFor(ctx context.Context, storer Storer, object Identifiable, pollInterval time.Duration, timeout time.Duration, condition WaitCondition) *Error {
+
+	operation, err := newAsyncOperation(storer, object, pollInterval, timeout, func(object Identifiable) (bool, interface{}, *Error) {
+		done, err := condition(object)
+		return done, nil, err
+	})
+	if err != nil {
+		return err
+	}
+
+	operation.start(ctx)
+
+	if _, err = operation.Wait(); err != nil && operation.stopped != nil {
+		return NewBambouError("Wait timeout", fmt.Sprintf("%s %s did not reach the expected state: %s", object.Identity().Name, object.Identifier(), operation.stopped))
+	}
+
+	return err
+}
+
+// WaitForAttribute fetches the given object every pollInterval until its attribute with
+// the given JSON name equals one of the given values, the given timeout expires or the
+// given context is done. The values are compared as JSON, so that 1 equals 1.0.
+func WaitForAttribute(ctx context.Context, storer Storer, object Identifiable, attribute string, pollInterval time.Duration, timeout time.Duration, values ...interface{}) *Error {
+
+	expected := make([]interface{}, len(values))
+	for i, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return NewBambouError("JSON error", err.Error())
+		}
+		json.Unmarshal(data, &expected[i])
+	}
+
+	var last interface{}
+	err := WaitFor(ctx, storer, object, pollInterval, timeout, func(object Identifiable) (bool, *Error) {
+
+		attributes, err := attributesOf(object)
+		if err != nil {
+			return false, err
+		}
+
+		last = attributes[attribute]
+		for _, value := range expected {
+			if reflect.DeepEqual(last, value) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+
+	if err != nil && err.Title == "Wait timeout" {
+		err.Description = fmt.Sprintf("%s of %s %s is %v: %s", attribute, object.Identity().Name, object.Identifier(), last, err.Description)
+	}
+
+	return err
+}
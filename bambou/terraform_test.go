@@ -0,0 +1,314 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type providedObject struct {
+	FakeObject
+
+	ParentType string `json:"parentType,omitempty"`
+	ParentID   string `json:"parentID,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Progress   int    `json:"progress,omitempty"`
+}
+
+func TestCompositeID_String(t *testing.T) {
+
+	Convey("Given I have composite IDs", t, func() {
+
+		Convey("Then an ID with a parent should be parentType/parentID/ID", func() {
+			So(CompositeID{ParentType: "enterprise", ParentID: "p", ID: "x"}.String(), ShouldEqual, "enterprise/p/x")
+		})
+
+		Convey("Then an ID without parent should be the ID", func() {
+			So(CompositeID{ID: "x"}.String(), ShouldEqual, "x")
+		})
+
+		Convey("Then the ID of a child of the root should have no parent", func() {
+			So(NewCompositeID(NewFakeObject("x"), NewFakeRootObject()), ShouldResemble, CompositeID{ID: "x"})
+		})
+
+		Convey("Then the ID of a child of an object should have its parent", func() {
+			So(NewCompositeID(NewFakeObject("x"), NewFakeObject("p")), ShouldResemble, CompositeID{ParentType: "fake", ParentID: "p", ID: "x"})
+		})
+
+		Convey("Then the ID of a fetched object should use its parent attributes", func() {
+			So(CompositeIDOf(&providedObject{FakeObject: FakeObject{ID: "x"}, ParentType: "fake", ParentID: "p"}).String(), ShouldEqual, "fake/p/x")
+			So(CompositeIDOf(&providedObject{FakeObject: FakeObject{ID: "x"}, ParentType: "fake"}).String(), ShouldEqual, "x")
+		})
+	})
+}
+
+func TestCompositeID_Parse(t *testing.T) {
+
+	Convey("Given I parse composite IDs", t, func() {
+
+		Convey("Then a complete ID should be parsed", func() {
+			c, err := ParseCompositeID("fake/p/x")
+			So(err, ShouldBeNil)
+			So(c, ShouldResemble, CompositeID{ParentType: "fake", ParentID: "p", ID: "x"})
+		})
+
+		Convey("Then a bare ID should be parsed", func() {
+			c, err := ParseCompositeID("x")
+			So(err, ShouldBeNil)
+			So(c, ShouldResemble, CompositeID{ID: "x"})
+		})
+
+		Convey("Then invalid IDs should be rejected", func() {
+			for _, s := range []string{"", "fake/x", "fake//x", "a/b/c/d"} {
+				_, err := ParseCompositeID(s)
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Invalid ID")
+			}
+		})
+
+		Convey("Then the parent should be a registered object", func() {
+			RegisterIdentity(FakeIdentity, func() Identifiable { return &providedObject{} })
+			defer UnregisterIdentity(FakeIdentity)
+
+			c, _ := ParseCompositeID("fake/p/x")
+			So(c.Parent().Identifier(), ShouldEqual, "p")
+			So(CompositeID{ID: "x"}.Parent(), ShouldBeNil)
+			So(CompositeID{ParentType: "unknown", ParentID: "p", ID: "x"}.Parent(), ShouldBeNil)
+		})
+	})
+}
+
+func TestImportObject(t *testing.T) {
+
+	Convey("Given I have objects on the server", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &providedObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		fs := newFakeServer()
+		defer fs.Close()
+
+		fs.add("p", map[string]interface{}{"ID": "x", "name": "x", "parentType": "fake", "parentID": "p"})
+		fs.add("", map[string]interface{}{"ID": "y", "name": "y"})
+
+		session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+
+		Convey("When I import an object by its bare ID", func() {
+			object, c, err := ImportObject(session, FakeIdentity, "x")
+
+			Convey("Then it should be fetched with its complete ID", func() {
+				So(err, ShouldBeNil)
+				So(object.(*providedObject).Name, ShouldEqual, "x")
+				So(c.String(), ShouldEqual, "fake/p/x")
+			})
+		})
+
+		Convey("When I import an object by its composite ID", func() {
+			_, c, err := ImportObject(session, FakeIdentity, "fake/p/x")
+
+			Convey("Then it should be fetched", func() {
+				So(err, ShouldBeNil)
+				So(c.String(), ShouldEqual, "fake/p/x")
+			})
+		})
+
+		Convey("When I import an object without parent attributes by its composite ID", func() {
+			_, c, err := ImportObject(session, FakeIdentity, "fake/q/y")
+
+			Convey("Then the given ID should be kept", func() {
+				So(err, ShouldBeNil)
+				So(c.String(), ShouldEqual, "fake/q/y")
+			})
+		})
+
+		Convey("When I import an object under the wrong parent", func() {
+			_, _, err := ImportObject(session, FakeIdentity, "fake/q/x")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Description, ShouldEqual, "fake x is not a child of fake q")
+			})
+		})
+
+		Convey("When I import a missing object", func() {
+			_, _, err := ImportObject(session, FakeIdentity, "z")
+
+			Convey("Then the error of the server should be returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I import an object of an unregistered identity", func() {
+			_, _, err := ImportObject(session, Identity{Name: "unknown", Category: "unknowns"}, "x")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Unregistered identity")
+			})
+		})
+	})
+}
+
+func TestWaitForAttribute(t *testing.T) {
+
+	Convey("Given I have an object changing on the server", t, func() {
+
+		fs := newFakeServer()
+		defer fs.Close()
+
+		fs.add("", map[string]interface{}{"ID": "x", "status": "PENDING", "progress": 0})
+
+		session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+		object := &providedObject{FakeObject: FakeObject{ID: "x"}, Status: "PENDING"}
+
+		// becomeReady changes the object on the server after a while, and returns a
+		// channel closed once it is done.
+		becomeReady := func() chan struct{} {
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				time.Sleep(30 * time.Millisecond)
+				fs.lock.Lock()
+				fs.objects["x"]["status"] = "READY"
+				fs.objects["x"]["progress"] = 100
+				fs.lock.Unlock()
+			}()
+
+			return done
+		}
+
+		Convey("When I wait for the attribute to have one of the expected values", func() {
+			done := becomeReady()
+			err := WaitForAttribute(context.Background(), session, object, "status", 5*time.Millisecond, time.Second, "READY", "FAILED")
+			<-done
+
+			Convey("Then the object should be in the expected state", func() {
+				So(err, ShouldBeNil)
+				So(object.Status, ShouldEqual, "READY")
+			})
+		})
+
+		Convey("When I wait for the attribute with the cache of the session enabled", func() {
+			session.SetCache(NewCache(time.Hour, 100))
+			So(session.FetchEntity(object), ShouldBeNil)
+			done := becomeReady()
+			err := WaitForAttribute(context.Background(), session, object, "status", 5*time.Millisecond, time.Second, "READY")
+			<-done
+
+			Convey("Then the object should be polled from the server", func() {
+				So(err, ShouldBeNil)
+				So(object.Status, ShouldEqual, "READY")
+			})
+		})
+
+		Convey("When I wait for a numeric attribute", func() {
+			done := becomeReady()
+			err := WaitForAttribute(context.Background(), session, object, "progress", 5*time.Millisecond, time.Second, 100.0)
+			<-done
+
+			Convey("Then the values should be compared as JSON", func() {
+				So(err, ShouldBeNil)
+				So(object.Progress, ShouldEqual, 100)
+			})
+		})
+
+		Convey("When the attribute does not change in time", func() {
+			err := WaitForAttribute(context.Background(), session, object, "status", 5*time.Millisecond, 10*time.Millisecond, "DELETED")
+
+			Convey("Then it should time out with the last value", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Wait timeout")
+				So(err.Description, ShouldStartWith, "status of fake x is PENDING")
+			})
+		})
+
+		Convey("When the object disappears", func() {
+			fs.lock.Lock()
+			delete(fs.objects, "x")
+			fs.lock.Unlock()
+
+			err := WaitForAttribute(context.Background(), session, object, "status", 5*time.Millisecond, time.Second, "READY")
+
+			Convey("Then the error of the server should be returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestWaitFor(t *testing.T) {
+
+	Convey("Given I have an object", t, func() {
+
+		fs := newFakeServer()
+		defer fs.Close()
+
+		fs.add("", map[string]interface{}{"ID": "x"})
+
+		session := NewSession("username", "password", "organization", fs.URL, NewFakeRootObject())
+		object := NewFakeObject("x")
+
+		Convey("When the condition is already true", func() {
+			err := WaitFor(context.Background(), session, object, time.Millisecond, time.Second, func(Identifiable) (bool, *Error) { return true, nil })
+
+			Convey("Then nothing should be fetched", func() {
+				So(err, ShouldBeNil)
+				So(fs.methods(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the condition fails", func() {
+			err := WaitFor(context.Background(), session, object, time.Millisecond, time.Second, func(Identifiable) (bool, *Error) { return false, NewBambouError("Failed", "") })
+
+			Convey("Then its error should be returned", func() {
+				So(err.Title, ShouldEqual, "Failed")
+			})
+		})
+
+		Convey("When the poll interval is not positive", func() {
+			err := WaitFor(context.Background(), session, object, 0, time.Second, func(Identifiable) (bool, *Error) { return false, nil })
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Invalid poll interval")
+			})
+		})
+
+		Convey("When the context is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := WaitFor(ctx, session, object, time.Millisecond, 0, func(Identifiable) (bool, *Error) { return false, nil })
+
+			Convey("Then it should stop waiting", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Wait timeout")
+			})
+		})
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Time is a time.Time encoded as milliseconds since epoch, like the
+// creationDate or lastUpdatedDate attributes of the VSD objects.
+// Depending on its version, the VSD returns those as numbers or as strings:
+// Time accepts both, as well as null and RFC 3339 strings. A zero Time
+// is encoded as null.
+type Time struct {
+	time.Time
+}
+
+// NewTime returns a new Time for the given time.Time.
+func NewTime(t time.Time) Time {
+
+	return Time{Time: t}
+}
+
+// TimeFromMillis returns a new Time for the given milliseconds since epoch.
+func TimeFromMillis(ms int64) Time {
+
+	return Time{Time: time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))}
+}
+
+// Millis returns the milliseconds since epoch of the Time, or 0 if it is zero.
+func (t Time) Millis() int64 {
+
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t Time) MarshalJSON() ([]byte, error) {
+
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(strconv.FormatInt(t.Millis(), 10)), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *Time) UnmarshalJSON(data []byte) error {
+
+	data = bytes.TrimSpace(data)
+
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		s, err := strconv.Unquote(string(data))
+		if err != nil {
+			return fmt.Errorf("invalid time %s: %s", data, err)
+		}
+		return t.parse(s)
+	}
+
+	return t.parse(string(data))
+}
+
+// parse sets the Time from the given string, that can be empty, milliseconds or RFC 3339.
+func (t *Time) parse(s string) error {
+
+	if s == "" {
+		*t = Time{}
+		return nil
+	}
+
+	if ms, err := strconv.ParseFloat(s, 64); err == nil {
+		*t = TimeFromMillis(int64(ms))
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("invalid time %q: not milliseconds since epoch nor RFC 3339", s)
+	}
+
+	*t = Time{Time: parsed}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type timedObject struct {
+	CreationDate    Time  `json:"creationDate"`
+	LastUpdatedDate *Time `json:"lastUpdatedDate,omitempty"`
+}
+
+func TestTime_UnmarshalJSON(t *testing.T) {
+
+	Convey("Given I have the various encodings of a date", t, func() {
+
+		expected := time.Date(2017, 7, 14, 2, 40, 0, 123*int(time.Millisecond), time.UTC)
+
+		for _, data := range []string{
+			`1500000000123`,
+			`1500000000123.0`,
+			`"1500000000123"`,
+			`"2017-07-14T02:40:00.123Z"`,
+		} {
+
+			Convey("When I decode "+data, func() {
+
+				o := &timedObject{}
+				err := json.Unmarshal([]byte(`{"creationDate": `+data+`}`), o)
+
+				Convey("Then I should get the date", func() {
+					So(err, ShouldBeNil)
+					So(o.CreationDate.Equal(expected), ShouldBeTrue)
+					So(o.CreationDate.Millis(), ShouldEqual, 1500000000123)
+				})
+			})
+		}
+
+		for _, data := range []string{`null`, `""`} {
+
+			Convey("When I decode "+data, func() {
+
+				o := &timedObject{CreationDate: NewTime(expected)}
+				err := json.Unmarshal([]byte(`{"creationDate": `+data+`}`), o)
+
+				Convey("Then I should get a zero time", func() {
+					So(err, ShouldBeNil)
+					So(o.CreationDate.IsZero(), ShouldBeTrue)
+					So(o.CreationDate.Millis(), ShouldEqual, 0)
+				})
+			})
+		}
+
+		Convey("When I decode an invalid date", func() {
+
+			err := json.Unmarshal([]byte(`{"creationDate": "yesterday"}`), &timedObject{})
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestTime_MarshalJSON(t *testing.T) {
+
+	Convey("Given I have an object with dates", t, func() {
+
+		updated := TimeFromMillis(1500000000123)
+		o := &timedObject{LastUpdatedDate: &updated}
+
+		Convey("When I encode it", func() {
+
+			data, err := json.Marshal(o)
+
+			Convey("Then the dates should be milliseconds, or null if zero", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"creationDate":null,"lastUpdatedDate":1500000000123}`)
+			})
+		})
+	})
+}
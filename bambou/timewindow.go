@@ -0,0 +1,84 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"time"
+)
+
+// The date attributes of the VSD objects, for the time windows.
+const (
+	CreationDateAttribute    = "creationDate"
+	LastUpdatedDateAttribute = "lastUpdatedDate"
+)
+
+// TimeWindowFilter returns the filter keeping the objects whose given date attribute,
+// like CreationDateAttribute, is in the given time window: after or at the start, and
+// before the end. The dates are converted to milliseconds since epoch, as the server
+// expects. A zero start or end leaves the window open on that side, and an empty string
+// is returned if both are zero.
+func TimeWindowFilter(attribute string, start, end time.Time) string {
+
+	switch {
+	case start.IsZero() && end.IsZero():
+		return ""
+	case end.IsZero():
+		return fmt.Sprintf("%s >= %d", attribute, NewTime(start).Millis())
+	case start.IsZero():
+		return fmt.Sprintf("%s < %d", attribute, NewTime(end).Millis())
+	default:
+		return fmt.Sprintf("%s >= %d and %s < %d", attribute, NewTime(start).Millis(), attribute, NewTime(end).Millis())
+	}
+}
+
+// AddTimeWindow restricts the fetched objects to the ones whose given date attribute
+// is in the given time window. See TimeWindowFilter. The window is combined with the
+// current filter, if any.
+func (f *FetchingInfo) AddTimeWindow(attribute string, start, end time.Time) {
+
+	window := TimeWindowFilter(attribute, start, end)
+
+	switch {
+	case window == "":
+	case f.Filter == "":
+		f.Filter = window
+	default:
+		f.Filter = "(" + f.Filter + ") and " + window
+	}
+}
+
+// CreatedBetween restricts the fetched objects to the ones created in the given time window,
+// like the alarms of the last hour. See AddTimeWindow.
+func (f *FetchingInfo) CreatedBetween(start, end time.Time) {
+
+	f.AddTimeWindow(CreationDateAttribute, start, end)
+}
+
+// UpdatedBetween restricts the fetched objects to the ones last updated in the given time window.
+// See AddTimeWindow.
+func (f *FetchingInfo) UpdatedBetween(start, end time.Time) {
+
+	f.AddTimeWindow(LastUpdatedDateAttribute, start, end)
+}
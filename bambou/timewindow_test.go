@@ -0,0 +1,96 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTimeWindowFilter(t *testing.T) {
+
+	Convey("Given I have a time window", t, func() {
+
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600))
+		end := start.Add(time.Hour + time.Millisecond)
+
+		Convey("Then the filter should compare the attribute with the milliseconds since epoch", func() {
+			So(TimeWindowFilter(CreationDateAttribute, start, end), ShouldEqual, "creationDate >= 1577833200000 and creationDate < 1577836800001")
+		})
+
+		Convey("Then the filter of an open window should have one bound", func() {
+			So(TimeWindowFilter(LastUpdatedDateAttribute, start, time.Time{}), ShouldEqual, "lastUpdatedDate >= 1577833200000")
+			So(TimeWindowFilter(LastUpdatedDateAttribute, time.Time{}, end), ShouldEqual, "lastUpdatedDate < 1577836800001")
+			So(TimeWindowFilter(LastUpdatedDateAttribute, time.Time{}, time.Time{}), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestFetchingInfo_AddTimeWindow(t *testing.T) {
+
+	Convey("Given I have a server recording the filters", t, func() {
+
+		var filters []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			filters = append(filters, r.Header.Get("X-Nuage-Filter"))
+			w.Write([]byte(`[]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		start := time.Unix(1500000000, 0)
+		end := start.Add(time.Hour)
+
+		Convey("When I fetch the children created in a time window", func() {
+
+			info := NewFetchingInfo()
+			info.CreatedBetween(start, end)
+
+			var dest []*FakeObject
+			err := session.FetchChildren(NewFakeObject("1"), FakeIdentity, &dest, info)
+
+			Convey("Then the window should be sent as a filter", func() {
+				So(err, ShouldBeNil)
+				So(filters, ShouldResemble, []string{"creationDate >= 1500000000000 and creationDate < 1500003600000"})
+			})
+		})
+
+		Convey("When I add an update window to a filter", func() {
+
+			info := NewFetchingInfo()
+			info.Filter = `severity == "MAJOR" or severity == "CRITICAL"`
+			info.UpdatedBetween(start, time.Time{})
+			info.AddTimeWindow(CreationDateAttribute, time.Time{}, time.Time{})
+
+			Convey("Then the window should be combined with the filter", func() {
+				So(info.Filter, ShouldEqual, `(severity == "MAJOR" or severity == "CRITICAL") and lastUpdatedDate >= 1500000000000`)
+			})
+		})
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLSOptions are the TLS options of the connections of a Session to the server.
+// See Session.SetTLSOptions.
+type TLSOptions struct {
+
+	// MinVersion is the minimum TLS version accepted, like tls.VersionTLS12.
+	// 0 means the default of crypto/tls.
+	MinVersion uint16
+
+	// MaxVersion is the maximum TLS version accepted. 0 means the latest version supported.
+	MaxVersion uint16
+
+	// CipherSuites are the cipher suites accepted for the versions up to TLS 1.2,
+	// like tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384. The cipher suites of TLS 1.3
+	// are not configurable. Empty means the defaults of crypto/tls.
+	CipherSuites []uint16
+}
+
+// SetTLSOptions sets the TLS options of the connections of the session, to comply
+// with the security policies instead of relying on the defaults of crypto/tls.
+// The other settings, like the certificate of the session, are kept. It returns an
+// error if the versions or cipher suites are unknown. It must be called before the
+// session is used.
+func (s *Session) SetTLSOptions(options TLSOptions) *Error {
+
+	if s.transport == nil {
+		return NewBambouError("Invalid session", "the transport of the session is not configurable")
+	}
+
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	config := &tls.Config{}
+	if s.transport.TLSClientConfig != nil {
+		config = s.transport.TLSClientConfig.Clone()
+	}
+
+	config.MinVersion = options.MinVersion
+	config.MaxVersion = options.MaxVersion
+	config.CipherSuites = append([]uint16(nil), options.CipherSuites...)
+
+	s.transport.TLSClientConfig = config
+
+	return nil
+}
+
+// TLSOptions returns the TLS options of the connections of the session.
+func (s *Session) TLSOptions() TLSOptions {
+
+	if s.transport == nil || s.transport.TLSClientConfig == nil {
+		return TLSOptions{}
+	}
+
+	config := s.transport.TLSClientConfig
+
+	return TLSOptions{
+		MinVersion:   config.MinVersion,
+		MaxVersion:   config.MaxVersion,
+		CipherSuites: append([]uint16(nil), config.CipherSuites...),
+	}
+}
+
+// validate returns an error if the versions or cipher suites of the options are unknown.
+func (o TLSOptions) validate() *Error {
+
+	for _, version := range []uint16{o.MinVersion, o.MaxVersion} {
+		switch version {
+		case 0, tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13:
+		default:
+			return NewBambouError("Invalid TLS options", fmt.Sprintf("unknown TLS version 0x%04x", version))
+		}
+	}
+
+	if o.MinVersion != 0 && o.MaxVersion != 0 && o.MinVersion > o.MaxVersion {
+		return NewBambouError("Invalid TLS options", fmt.Sprintf("the minimum version %s is above the maximum version %s", tls.VersionName(o.MinVersion), tls.VersionName(o.MaxVersion)))
+	}
+
+	known := map[uint16]bool{}
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[suite.ID] = true
+	}
+
+	for _, ID := range o.CipherSuites {
+		if !known[ID] {
+			return NewBambouError("Invalid TLS options", fmt.Sprintf("unknown cipher suite 0x%04x", ID))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetTLSOptions(t *testing.T) {
+
+	Convey("Given I have a server accepting only TLS 1.2 with one cipher suite", t, func() {
+
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		ts.TLS = &tls.Config{
+			MaxVersion:   tls.VersionTLS12,
+			CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		}
+		ts.StartTLS()
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I connect with TLS 1.2 and the cipher suite of the server", func() {
+
+			err := session.SetTLSOptions(TLSOptions{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+			})
+			So(err, ShouldBeNil)
+
+			Convey("Then the request should succeed", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+				So(session.TLSOptions().MinVersion, ShouldEqual, tls.VersionTLS12)
+				So(session.TLSOptions().CipherSuites, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When I require TLS 1.3", func() {
+
+			So(session.SetTLSOptions(TLSOptions{MinVersion: tls.VersionTLS13}), ShouldBeNil)
+
+			Convey("Then the request should fail", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I accept only another cipher suite", func() {
+
+			So(session.SetTLSOptions(TLSOptions{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}}), ShouldBeNil)
+
+			Convey("Then the request should fail", func() {
+				So(session.FetchEntity(NewFakeObject("1")), ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I set invalid options", func() {
+
+			unknownVersion := session.SetTLSOptions(TLSOptions{MinVersion: 0x0200})
+			inverted := session.SetTLSOptions(TLSOptions{MinVersion: tls.VersionTLS13, MaxVersion: tls.VersionTLS12})
+			unknownSuite := session.SetTLSOptions(TLSOptions{CipherSuites: []uint16{0xffff}})
+
+			Convey("Then I should get errors", func() {
+				So(unknownVersion, ShouldNotBeNil)
+				So(inverted, ShouldNotBeNil)
+				So(unknownSuite, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given I have a X509 session", t, func() {
+
+		cert := &tls.Certificate{Certificate: [][]byte{{1}}}
+		session := NewX509Session(cert, "https://vsd", NewFakeRootObject())
+
+		Convey("When I set its TLS options", func() {
+
+			So(session.SetTLSOptions(TLSOptions{MinVersion: tls.VersionTLS12}), ShouldBeNil)
+
+			Convey("Then its certificate should be kept", func() {
+				So(session.transport.TLSClientConfig.Certificates, ShouldHaveLength, 1)
+				So(session.transport.TLSClientConfig.InsecureSkipVerify, ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenHeader is the header carrying the token of the sessions created with NewTokenSession.
+const TokenHeader = "X-Nuage-Token"
+
+// TokenRenewalFunc returns a new token for a session created with NewTokenSession,
+// and its expiry, zero if unknown. See Session.SetTokenRenewal.
+type TokenRenewalFunc func() (token string, expiry time.Time, err error)
+
+// tokenAuth is the token of a session created with NewTokenSession.
+type tokenAuth struct {
+	token  string
+	expiry time.Time
+	renew  TokenRenewalFunc
+	lock   sync.Mutex
+}
+
+// NewTokenSession returns a new *Session authenticating with the given token, issued
+// out of band, sent in the X-Nuage-Token header, instead of a password or a certificate.
+// The expiry of the token may be zero if it is unknown. See SetTokenRenewal to renew
+// the token when it expires.
+func NewTokenSession(token string, expiry time.Time, organization, url string, root Rootable) *Session {
+
+	tr := newTransport(&tls.Config{
+		InsecureSkipVerify: true,
+	})
+
+	return &Session{
+		Organization: organization,
+		URL:          url,
+		root:         root,
+		client:       &http.Client{Transport: tr},
+		transport:    tr,
+		token:        &tokenAuth{token: token, expiry: expiry},
+
+		responseChoice: DefaultResponseChoice,
+		versions:       versionStore{maxVersions: DefaultMaxTrackedVersions},
+	}
+}
+
+// SetTokenRenewal sets the function called to get a new token when the token of the
+// session has expired, before a request is sent. It is called once per expiry, even
+// if several requests are sent concurrently. It only applies to the sessions created
+// with NewTokenSession.
+func (s *Session) SetTokenRenewal(renew TokenRenewalFunc) {
+
+	if s.token == nil {
+		return
+	}
+
+	s.token.lock.Lock()
+	defer s.token.lock.Unlock()
+
+	s.token.renew = renew
+}
+
+// TokenExpiry returns the expiry of the token of the session, zero if it is unknown
+// or if the session was not created with NewTokenSession.
+func (s *Session) TokenExpiry() time.Time {
+
+	if s.token == nil {
+		return time.Time{}
+	}
+
+	s.token.lock.Lock()
+	defer s.token.lock.Unlock()
+
+	return s.token.expiry
+}
+
+// TokenExpired returns true if the token of the session has expired.
+func (s *Session) TokenExpired() bool {
+
+	expiry := s.TokenExpiry()
+
+	return !expiry.IsZero() && !clockOrSystem(s.clock).Now().Before(expiry)
+}
+
+// RenewToken replaces the token of the session with the one returned by the function
+// set with SetTokenRenewal, even if it has not expired yet.
+func (s *Session) RenewToken() *Error {
+
+	if s.token == nil {
+		return NewBambouError("Invalid session", "the session does not authenticate with a token")
+	}
+
+	s.token.lock.Lock()
+	defer s.token.lock.Unlock()
+
+	return s.token.renewLocked()
+}
+
+// current returns the token at the given time, renewed first if it has expired and can be renewed.
+func (t *tokenAuth) current(now time.Time) (string, *Error) {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.expiry.IsZero() && !now.Before(t.expiry) {
+		if t.renew == nil {
+			return "", NewBambouError("Invalid Credentials", "the authentication token has expired")
+		}
+		if err := t.renewLocked(); err != nil {
+			return "", err
+		}
+	}
+
+	return t.token, nil
+}
+
+// renewLocked renews the token. The lock must be held.
+func (t *tokenAuth) renewLocked() *Error {
+
+	if t.renew == nil {
+		return NewBambouError("Invalid Credentials", "the authentication token cannot be renewed")
+	}
+
+	token, expiry, err := t.renew()
+	if err != nil {
+		return NewBambouError("Invalid Credentials", "unable to renew the authentication token: "+err.Error())
+	}
+
+	if token == "" {
+		return NewBambouError("Invalid Credentials", "the renewed authentication token is empty")
+	}
+
+	t.token, t.expiry = token, expiry
+
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_Token(t *testing.T) {
+
+	Convey("Given I have a server accepting tokens", t, func() {
+
+		var lock sync.Mutex
+		var tokens []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			tokens = append(tokens, r.Header.Get(TokenHeader))
+			lock.Unlock()
+
+			if r.Header.Get("Authorization") != "" || r.Header.Get("X-Nuage-Organization") != "organization" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		Convey("When I start a session with a token", func() {
+
+			session := NewTokenSession("token", time.Time{}, "organization", ts.URL, NewFakeRootObject())
+			err := session.Start()
+
+			Convey("Then the token should be sent in the X-Nuage-Token header", func() {
+				So(err, ShouldBeNil)
+				So(tokens, ShouldResemble, []string{"token"})
+			})
+
+			Convey("Then the token should not expire", func() {
+				So(session.TokenExpiry().IsZero(), ShouldBeTrue)
+				So(session.TokenExpired(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When I use an expired token that cannot be renewed", func() {
+
+			session := NewTokenSession("token", time.Now().Add(-time.Minute), "organization", ts.URL, NewFakeRootObject())
+			err := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then I should get an error without sending the request", func() {
+				So(session.TokenExpired(), ShouldBeTrue)
+				So(err, ShouldNotBeNil)
+				So(err.Description, ShouldEqual, "the authentication token has expired")
+				So(tokens, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I use an expired token that can be renewed", func() {
+
+			expiry := time.Now().Add(time.Hour)
+			var renewals int
+
+			session := NewTokenSession("token", time.Now().Add(-time.Minute), "organization", ts.URL, NewFakeRootObject())
+			session.SetTokenRenewal(func() (string, time.Time, error) {
+				renewals++
+				return fmt.Sprintf("renewed-%d", renewals), expiry, nil
+			})
+
+			first := session.FetchEntity(NewFakeObject("1"))
+			second := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the token should be renewed once", func() {
+				So(first, ShouldBeNil)
+				So(second, ShouldBeNil)
+				So(renewals, ShouldEqual, 1)
+				So(tokens, ShouldResemble, []string{"renewed-1", "renewed-1"})
+				So(session.TokenExpiry(), ShouldEqual, expiry)
+				So(session.TokenExpired(), ShouldBeFalse)
+			})
+
+			Convey("When I renew the token explicitly", func() {
+
+				So(session.RenewToken(), ShouldBeNil)
+				So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+
+				Convey("Then the new token should be sent", func() {
+					So(tokens[len(tokens)-1], ShouldEqual, "renewed-2")
+				})
+			})
+		})
+
+		Convey("When the renewal of the token fails", func() {
+
+			session := NewTokenSession("token", time.Now().Add(-time.Minute), "organization", ts.URL, NewFakeRootObject())
+			session.SetTokenRenewal(func() (string, time.Time, error) {
+				return "", time.Time{}, fmt.Errorf("service unavailable")
+			})
+			err := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Description, ShouldEqual, "unable to renew the authentication token: service unavailable")
+				So(tokens, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given I have a password session", t, func() {
+
+		session := NewSession("username", "password", "organization", "https://vsd", NewFakeRootObject())
+
+		Convey("When I renew its token", func() {
+
+			err := session.RenewToken()
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(session.TokenExpiry().IsZero(), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// DecodeError is the error decoding an object of a listing, with a tolerant decoding.
+// See Session.SetTolerantDecoding.
+type DecodeError struct {
+
+	// Index is the index of the object in the listing.
+	Index int
+
+	// ID is the ID of the object, if it could be read.
+	ID string
+
+	// Raw is the JSON of the object.
+	Raw json.RawMessage
+
+	// Err is the error decoding the object.
+	Err error
+}
+
+// Error returns the string representation of the DecodeError.
+func (e *DecodeError) Error() string {
+
+	if e.ID != "" {
+		return fmt.Sprintf("unable to decode the object %d (%s): %s", e.Index, e.ID, e.Err)
+	}
+
+	return fmt.Sprintf("unable to decode the object %d: %s", e.Index, e.Err)
+}
+
+// SetTolerantDecoding enables or disables the tolerant decoding of the listings. By
+// default, a single object that cannot be decoded, like one with an attribute of an
+// unexpected type, fails the whole FetchChildren. With the tolerant decoding, the
+// objects of such a listing are decoded one by one: FetchChildren returns the objects
+// decoded successfully, and the errors of the others are set to the DecodeErrors of
+// the FetchingInfo, and logged. The destination must be a slice of pointers or structs.
+func (s *Session) SetTolerantDecoding(enabled bool) {
+
+	s.tolerantDecoding = enabled
+}
+
+// decodeTolerantly decodes the objects of the given listing one by one into the given
+// destination, a pointer to a slice, sets the errors to the given information, and
+// returns the listing of the objects decoded successfully.
+func (s *Session) decodeTolerantly(body []byte, dest interface{}, info *FetchingInfo) ([]byte, *Error) {
+
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return nil, NewBambouError("Invalid destination", fmt.Sprintf("%T is not a pointer to a slice", dest))
+	}
+	slice = slice.Elem()
+
+	elemType := slice.Type().Elem()
+	if elemType.Kind() == reflect.Interface {
+		return nil, NewBambouError("Invalid destination", fmt.Sprintf("%T cannot be decoded tolerantly", dest))
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, NewBambouError("HTTP Unmarshaling error", err.Error())
+	}
+
+	// The strict decoding may have filled the destination partially.
+	slice.Set(reflect.MakeSlice(slice.Type(), 0, len(raws)))
+
+	var decoded []json.RawMessage
+	var errs []*DecodeError
+
+	for i, raw := range raws {
+
+		var item reflect.Value
+		if elemType.Kind() == reflect.Ptr {
+			item = reflect.New(elemType.Elem())
+		} else {
+			item = reflect.New(elemType)
+		}
+
+		if err := s.getCodec().Unmarshal(raw, item.Interface()); err != nil {
+			errs = append(errs, &DecodeError{Index: i, ID: rawID(raw), Raw: append(json.RawMessage(nil), raw...), Err: err})
+			continue
+		}
+
+		if elemType.Kind() != reflect.Ptr {
+			item = item.Elem()
+		}
+
+		slice.Set(reflect.Append(slice, item))
+		decoded = append(decoded, raw)
+	}
+
+	for _, err := range errs {
+		s.getLogger().Warnf("Skipping an object of the listing: %s", err)
+	}
+
+	if info != nil {
+		info.DecodeErrors = errs
+	}
+
+	listing, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, NewBambouError("JSON error", err.Error())
+	}
+
+	return listing, nil
+}
+
+// rawID returns the ID of the given JSON object, or an empty string.
+func rawID(raw json.RawMessage) string {
+
+	var object struct {
+		ID interface{} `json:"ID"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil || object.ID == nil {
+		return ""
+	}
+
+	return fmt.Sprint(object.ID)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetTolerantDecoding(t *testing.T) {
+
+	Convey("Given I have a server returning a listing with a malformed object", t, func() {
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"ID": "1", "name": "one"}, {"ID": "2", "name": 2}, {"ID": "3", "name": "three"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I fetch the children with the strict decoding", func() {
+
+			var dest []*FakeObject
+			err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &dest, NewFetchingInfo())
+
+			Convey("Then the whole listing should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "HTTP Unmarshaling error")
+			})
+		})
+
+		Convey("When I fetch the children with the tolerant decoding", func() {
+
+			logger := &recordingLogger{}
+			session.SetLogger(logger)
+			session.SetTolerantDecoding(true)
+
+			var dest []*FakeObject
+			info := NewFetchingInfo()
+			err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &dest, info)
+
+			Convey("Then I should get the objects decoded successfully", func() {
+				So(err, ShouldBeNil)
+				So(dest, ShouldHaveLength, 2)
+				So(dest[0].Name, ShouldEqual, "one")
+				So(dest[1].Name, ShouldEqual, "three")
+			})
+
+			Convey("Then I should get the errors of the other objects", func() {
+				So(info.DecodeErrors, ShouldHaveLength, 1)
+				So(info.DecodeErrors[0].Index, ShouldEqual, 1)
+				So(info.DecodeErrors[0].ID, ShouldEqual, "2")
+				So(string(info.DecodeErrors[0].Raw), ShouldEqual, `{"ID": "2", "name": 2}`)
+				So(info.DecodeErrors[0].Error(), ShouldStartWith, "unable to decode the object 1 (2): ")
+				So(logger.count(), ShouldBeGreaterThan, 0)
+			})
+
+			Convey("When I fetch them into a slice of structs without information", func() {
+
+				var structs []FakeObject
+				err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &structs, nil)
+
+				Convey("Then I should get the objects decoded successfully", func() {
+					So(err, ShouldBeNil)
+					So(structs, ShouldHaveLength, 2)
+				})
+			})
+
+			Convey("When I fetch them into a slice of interfaces", func() {
+
+				var identifiables IdentifiablesList
+				err := session.FetchChildren(NewFakeObject("parent"), FakeIdentity, &identifiables, nil)
+
+				Convey("Then I should get an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Title, ShouldEqual, "Invalid destination")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import "fmt"
+
+// TransactionError is set as the Details of the *Error returned by
+// Transaction.Commit when one of the operations fails.
+type TransactionError struct {
+	// Index is the index of the operation that failed.
+	Index int
+
+	// Cause is the error returned by the failed operation.
+	Cause *Error
+
+	// RollbackErrors contains the errors that occured during the rollback, if any.
+	RollbackErrors []*Error
+}
+
+type transactionOperation struct {
+	name    string
+	execute func() (func() *Error, *Error)
+}
+
+// Transaction records a sequence of creations, updates and deletions
+// and executes them in order when committed.
+// As the server doesn't support transactions, if one of the operations fails,
+// compensating operations are executed in reverse order to rollback the ones
+// that have already been executed: created objects are deleted, saved objects
+// are restored from a snapshot taken before saving, and deleted objects are
+// created again from a snapshot (with a new ID). The snapshots are fetched from
+// the server, never from the cache, and with optimistic locking, restoring them
+// overrides the changes made since they were taken.
+type Transaction struct {
+	storer     Storer
+	operations []transactionOperation
+}
+
+// NewTransaction returns a new *Transaction that will use the given Storer.
+func NewTransaction(storer Storer) *Transaction {
+
+	return &Transaction{
+		storer: storer,
+	}
+}
+
+// CreateChild records the creation of the given child under the given parent.
+func (t *Transaction) CreateChild(parent Identifiable, child Identifiable) {
+
+	t.operations = append(t.operations, transactionOperation{
+		name: fmt.Sprintf("create %s", child.Identity().Name),
+		execute: func() (func() *Error, *Error) {
+
+			if err := t.storer.CreateChild(parent, child); err != nil {
+				return nil, err
+			}
+
+			return func() *Error { return t.storer.DeleteEntity(child) }, nil
+		},
+	})
+}
+
+// SaveEntity records the update of the given object.
+func (t *Transaction) SaveEntity(object Identifiable) {
+
+	t.operations = append(t.operations, transactionOperation{
+		name: fmt.Sprintf("save %s %s", object.Identity().Name, object.Identifier()),
+		execute: func() (func() *Error, *Error) {
+
+			snapshot, err := t.snapshot(object)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := t.storer.SaveEntity(object); err != nil {
+				return nil, err
+			}
+
+			return func() *Error {
+				if err := t.rebase(snapshot); err != nil {
+					return err
+				}
+				return t.storer.SaveEntity(snapshot)
+			}, nil
+		},
+	})
+}
+
+// DeleteEntity records the deletion of the given object.
+// The parent is used to create the object again in case of rollback.
+func (t *Transaction) DeleteEntity(parent Identifiable, object Identifiable) {
+
+	t.operations = append(t.operations, transactionOperation{
+		name: fmt.Sprintf("delete %s %s", object.Identity().Name, object.Identifier()),
+		execute: func() (func() *Error, *Error) {
+
+			snapshot, err := t.snapshot(object)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := t.storer.DeleteEntity(object); err != nil {
+				return nil, err
+			}
+
+			return func() *Error {
+				snapshot.SetIdentifier("")
+				return t.storer.CreateChild(parent, snapshot)
+			}, nil
+		},
+	})
+}
+
+// Len returns the number of recorded operations.
+func (t *Transaction) Len() int {
+
+	return len(t.operations)
+}
+
+// Commit executes all the recorded operations in order.
+// If one of them fails, the already executed operations are rolled back
+// and the returned *Error has a *TransactionError as Details.
+// The recorded operations are cleared once the transaction is committed.
+func (t *Transaction) Commit() *Error {
+
+	operations := t.operations
+	t.operations = nil
+
+	var compensations []func() *Error
+
+	for i, operation := range operations {
+
+		compensation, err := operation.execute()
+		if err == nil {
+			compensations = append(compensations, compensation)
+			continue
+		}
+
+		details := &TransactionError{
+			Index: i,
+			Cause: err,
+		}
+
+		for j := len(compensations) - 1; j >= 0; j-- {
+			if rerr := compensations[j](); rerr != nil {
+				details.RollbackErrors = append(details.RollbackErrors, rerr)
+			}
+		}
+
+		return &Error{
+			Title:       "Transaction error",
+			Description: fmt.Sprintf("unable to %s: %s (%d rollback errors)", operation.name, err.Description, len(details.RollbackErrors)),
+			Details:     details,
+		}
+	}
+
+	return nil
+}
+
+func (t *Transaction) snapshot(object Identifiable) (Identifiable, *Error) {
+
+	snapshot := newIdentifiableLike(object)
+	if snapshot == nil {
+		return nil, NewBambouError("Transaction error", fmt.Sprintf("unable to snapshot %s: not a pointer", object.Identity().Name))
+	}
+
+	snapshot.SetIdentifier(object.Identifier())
+	if err := refreshEntity(t.storer, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// rebase records the current version of the object of the given snapshot when the Storer
+// is a Session with optimistic locking, so that restoring the snapshot does not conflict
+// with the changes it undoes.
+func (t *Transaction) rebase(snapshot Identifiable) *Error {
+
+	session, ok := t.storer.(*Session)
+	if !ok || !session.optimisticLocking {
+		return nil
+	}
+
+	current := newIdentifiableLike(snapshot)
+	current.SetIdentifier(snapshot.Identifier())
+
+	return session.RefreshEntity(current)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransaction_Commit(t *testing.T) {
+
+	Convey("Given I have a server with existing objects and a transaction", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "parent", "name": "parent"})
+		ts.add("parent", map[string]interface{}{"ID": "saved", "name": "before"})
+		ts.add("parent", map[string]interface{}{"ID": "deleted", "name": "deleted"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		parent := NewFakeObject("parent")
+
+		tx := NewTransaction(session)
+
+		created := &FakeObject{Name: "created"}
+		saved := &FakeObject{ID: "saved", Name: "after"}
+		deleted := NewFakeObject("deleted")
+
+		tx.CreateChild(parent, created)
+		tx.SaveEntity(saved)
+		tx.DeleteEntity(parent, deleted)
+
+		Convey("Then the transaction should have 3 operations", func() {
+			So(tx.Len(), ShouldEqual, 3)
+		})
+
+		Convey("When I commit it with success", func() {
+
+			err := tx.Commit()
+
+			Convey("Then err should be nil", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the operations should be cleared", func() {
+				So(tx.Len(), ShouldEqual, 0)
+			})
+
+			Convey("Then the objects should be created, saved and deleted", func() {
+				So(created.ID, ShouldNotEqual, "")
+				So(ts.get(created.ID), ShouldNotBeNil)
+				So(ts.get("saved")["name"], ShouldEqual, "after")
+				So(ts.get("deleted"), ShouldBeNil)
+			})
+		})
+
+		Convey("When I commit it and the last operation fails", func() {
+
+			tx.CreateChild(parent, &FakeObject{Name: "failing"})
+			ts.failOn = "failing"
+
+			err := tx.Commit()
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Transaction error")
+			})
+
+			Convey("Then the details should indicate the failed operation", func() {
+				details, ok := err.Details.(*TransactionError)
+				So(ok, ShouldBeTrue)
+				So(details.Index, ShouldEqual, 3)
+				So(details.Cause, ShouldNotBeNil)
+				So(len(details.RollbackErrors), ShouldEqual, 0)
+			})
+
+			Convey("Then the created object should be deleted", func() {
+				So(ts.get(created.ID), ShouldBeNil)
+			})
+
+			Convey("Then the saved object should be restored", func() {
+				So(ts.get("saved")["name"], ShouldEqual, "before")
+			})
+
+			Convey("Then the deleted object should be created again", func() {
+				So(ts.count(), ShouldEqual, 3)
+			})
+		})
+	})
+}
+
+func TestTransaction_CommitWithOptimisticLocking(t *testing.T) {
+
+	Convey("Given I have a session with optimistic locking and a cache, and a transaction saving an object", t, func() {
+
+		fs := newFakeServer()
+		defer fs.Close()
+		fs.add("", map[string]interface{}{"ID": "parent", "name": "parent"})
+		fs.add("parent", map[string]interface{}{"ID": "saved", "name": "before", "lastUpdatedDate": 1})
+
+		// The object is changed again when the next operation is sent, before the rollback.
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				fs.lock.Lock()
+				fs.objects["saved"]["lastUpdatedDate"] = 3
+				fs.lock.Unlock()
+			}
+			fs.handle(w, r)
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		session.SetOptimisticLocking(true)
+		session.SetCache(NewCache(time.Hour, 100))
+
+		cached := NewFakeObject("saved")
+		So(session.FetchEntity(cached), ShouldBeNil)
+		fs.lock.Lock()
+		fs.objects["saved"]["name"] = "changed"
+		fs.objects["saved"]["lastUpdatedDate"] = 2
+		fs.lock.Unlock()
+
+		tx := NewTransaction(session)
+		tx.SaveEntity(&FakeObject{ID: "saved", Name: "after"})
+		tx.CreateChild(NewFakeObject("parent"), &FakeObject{Name: "failing"})
+		fs.failOn = "failing"
+
+		Convey("When I commit it and the last operation fails", func() {
+
+			err := tx.Commit()
+
+			Convey("Then the saved object should be restored as it was on the server", func() {
+				So(err, ShouldNotBeNil)
+				details := err.Details.(*TransactionError)
+				So(details.Index, ShouldEqual, 1)
+				So(details.RollbackErrors, ShouldBeEmpty)
+				So(fs.get("saved")["name"], ShouldEqual, "changed")
+			})
+		})
+	})
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TreeLayout describes the children to fetch in a tree: it maps the name of
+// an Identity to the identities of the children to fetch under its objects.
+// The identities must be registered: see RegisterIdentity.
+type TreeLayout map[string][]Identity
+
+// treeBundleVersion is the version of the format of the bundles written by ExportTree.
+const treeBundleVersion = 1
+
+// treeBundle is the portable representation of a subtree written by ExportTree.
+type treeBundle struct {
+	Version int         `json:"version"`
+	Nodes   []*TreeNode `json:"nodes"`
+}
+
+// jsonTreeNode is the JSON representation of a TreeNode.
+type jsonTreeNode struct {
+	Type       string          `json:"type"`
+	Attributes json.RawMessage `json:"attributes"`
+	Children   []*TreeNode     `json:"children,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n TreeNode) MarshalJSON() ([]byte, error) {
+
+	if n.Object == nil {
+		return nil, fmt.Errorf("tree node without object")
+	}
+
+	attributes, err := json.Marshal(n.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&jsonTreeNode{
+		Type:       n.Object.Identity().Name,
+		Attributes: attributes,
+		Children:   n.Children,
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *TreeNode) UnmarshalJSON(data []byte) error {
+
+	node := &jsonTreeNode{}
+	if err := json.Unmarshal(data, node); err != nil {
+		return err
+	}
+
+	object := NewIdentifiable(node.Type)
+	if object == nil {
+		return fmt.Errorf("unregistered type %q", node.Type)
+	}
+
+	if len(node.Attributes) > 0 {
+		if err := json.Unmarshal(node.Attributes, object); err != nil {
+			return err
+		}
+	}
+
+	n.Object = object
+	n.Children = node.Children
+
+	return nil
+}
+
+// FetchTree fetches the subtree of the given parent described by the given layout,
+// and returns the trees of its children, in the order of the layout, then of the server.
+func FetchTree(storer Storer, parent Identifiable, layout TreeLayout) ([]*TreeNode, *Error) {
+
+	var nodes []*TreeNode
+
+	for _, identity := range layout[parent.Identity().Name] {
+
+		children, err := fetchRegisteredChildren(storer, parent, identity)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+
+			subtree, err := FetchTree(storer, child, layout)
+			if err != nil {
+				return nil, err
+			}
+
+			nodes = append(nodes, NewTreeNode(child, subtree...))
+		}
+	}
+
+	return nodes, nil
+}
+
+// ExportTree fetches the subtree of the given parent described by the given layout,
+// and writes it to the given writer as a portable JSON bundle. See ImportTree.
+func ExportTree(storer Storer, parent Identifiable, layout TreeLayout, w io.Writer) *Error {
+
+	nodes, err := FetchTree(storer, parent, layout)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(w).Encode(&treeBundle{Version: treeBundleVersion, Nodes: nodes}); err != nil {
+		return NewBambouError("JSON error", err.Error())
+	}
+
+	return nil
+}
+
+// ImportTree reads a bundle written by ExportTree from the given reader and recreates
+// its objects under the given parent, parents before children, in the order of the bundle.
+// The system attributes of the objects are dropped, and the attributes referencing an object
+// of the bundle created before are remapped to its new ID.
+// It returns the new IDs of the created objects, by their ID in the bundle.
+// The import is not transactional: if it fails, the objects already created are left as is.
+func ImportTree(storer Storer, r io.Reader, parent Identifiable) (map[string]string, *Error) {
+
+	bundle := &treeBundle{}
+	if err := json.NewDecoder(r).Decode(bundle); err != nil {
+		return nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	if bundle.Version != treeBundleVersion {
+		return nil, NewBambouError("Invalid bundle", fmt.Sprintf("unsupported bundle version %d", bundle.Version))
+	}
+
+	IDs := map[string]string{}
+	if err := importTreeNodes(storer, bundle.Nodes, parent, IDs); err != nil {
+		return IDs, err
+	}
+
+	return IDs, nil
+}
+
+// importTreeNodes creates the objects of the given nodes under the given parent, recursively.
+func importTreeNodes(storer Storer, nodes []*TreeNode, parent Identifiable, IDs map[string]string) *Error {
+
+	for _, node := range nodes {
+
+		object, err := remappedClone(node.Object, IDs)
+		if err != nil {
+			return err
+		}
+
+		if err := storer.CreateChild(parent, object); err != nil {
+			return err
+		}
+
+		if ID := node.Object.Identifier(); ID != "" {
+			IDs[ID] = object.Identifier()
+		}
+
+		if err := importTreeNodes(storer, node.Children, object, IDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remappedClone returns a clone of the given object, without its system attributes,
+// in which the attributes equal to one of the given old IDs are replaced by the new ID.
+func remappedClone(object Identifiable, IDs map[string]string) (Identifiable, *Error) {
+
+	attributes, err := attributesOf(object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range SystemAttributes {
+		delete(attributes, name)
+	}
+
+	for name, value := range attributes {
+		attributes[name] = remapIDs(value, IDs)
+	}
+
+	clone := newIdentifiableLike(object)
+	data, _ := json.Marshal(attributes)
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, NewBambouError("JSON Unmarshaling error", err.Error())
+	}
+
+	return clone, nil
+}
+
+// remapIDs returns the given decoded JSON value with the given old IDs replaced by the new ones.
+func remapIDs(value interface{}, IDs map[string]string) interface{} {
+
+	switch v := value.(type) {
+	case string:
+		if ID, ok := IDs[v]; ok {
+			return ID
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = remapIDs(item, IDs)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type referencingObject struct {
+	FakeObject
+
+	AssociatedID string `json:"associatedID,omitempty"`
+	Owner        string `json:"owner,omitempty"`
+}
+
+func TestTree_JSON(t *testing.T) {
+
+	Convey("Given I have a registered identity", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &referencingObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		Convey("When I marshal and unmarshal a tree", func() {
+
+			tree := NewTreeNode(&referencingObject{FakeObject: FakeObject{ID: "1"}}, NewTreeNode(&referencingObject{AssociatedID: "1"}))
+			data, err := json.Marshal(tree)
+
+			decoded := &TreeNode{}
+			derr := json.Unmarshal(data, decoded)
+
+			Convey("Then I should get the same tree", func() {
+				So(err, ShouldBeNil)
+				So(derr, ShouldBeNil)
+				So(decoded, ShouldResemble, tree)
+			})
+		})
+
+		Convey("When I unmarshal a tree with an unregistered type", func() {
+
+			err := json.Unmarshal([]byte(`{"type": "unknown"}`), &TreeNode{})
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestTree_ExportImport(t *testing.T) {
+
+	Convey("Given I have a server with a subtree", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return &referencingObject{} })
+		defer UnregisterIdentity(FakeIdentity)
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "p", "name": "parent"})
+		ts.add("p", map[string]interface{}{"ID": "a", "name": "a", "owner": "me"})
+		ts.add("a", map[string]interface{}{"ID": "b", "name": "b", "associatedID": "a"})
+		ts.add("p", map[string]interface{}{"ID": "c", "name": "c", "associatedID": "b"})
+		ts.add("", map[string]interface{}{"ID": "dst", "name": "destination"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		layout := TreeLayout{"fake": {FakeIdentity}}
+
+		Convey("When I fetch the tree", func() {
+
+			nodes, err := FetchTree(session, NewFakeObject("p"), layout)
+
+			Convey("Then I should get the subtree", func() {
+				So(err, ShouldBeNil)
+				So(nodes, ShouldHaveLength, 2)
+				So(nodes[0].Object.Identifier(), ShouldEqual, "a")
+				So(nodes[0].Children, ShouldHaveLength, 1)
+				So(nodes[0].Children[0].Object.Identifier(), ShouldEqual, "b")
+				So(nodes[1].Object.Identifier(), ShouldEqual, "c")
+				So(nodes[1].Children, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I export it and import it elsewhere", func() {
+
+			buffer := &bytes.Buffer{}
+			err := ExportTree(session, NewFakeObject("p"), layout, buffer)
+			So(err, ShouldBeNil)
+
+			IDs, err := ImportTree(session, buffer, NewFakeObject("dst"))
+
+			Convey("Then the subtree should be recreated with remapped IDs", func() {
+				So(err, ShouldBeNil)
+				So(IDs, ShouldHaveLength, 3)
+
+				a, b, c := ts.get(IDs["a"]), ts.get(IDs["b"]), ts.get(IDs["c"])
+				So(a["name"], ShouldEqual, "a")
+				So(a["owner"], ShouldBeNil)
+				So(ts.parents[IDs["a"]], ShouldEqual, "dst")
+				So(b["associatedID"], ShouldEqual, IDs["a"])
+				So(ts.parents[IDs["b"]], ShouldEqual, IDs["a"])
+				So(c["associatedID"], ShouldEqual, IDs["b"])
+				So(ts.parents[IDs["c"]], ShouldEqual, "dst")
+			})
+		})
+
+		Convey("When I import an invalid bundle", func() {
+
+			_, err := ImportTree(session, strings.NewReader(`{"version": 2, "nodes": []}`), NewFakeObject("dst"))
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Invalid bundle")
+				So(ts.methods(), ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath is the path of the module of bambou.
+const modulePath = "github.com/nuagenetworks/go-bambou"
+
+var (
+	version     string
+	versionOnce sync.Once
+)
+
+// Version returns the version of bambou built in the program, like v1.2.3,
+// or "devel" if it is unknown.
+func Version() string {
+
+	versionOnce.Do(func() {
+
+		version = "devel"
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		module := &info.Main
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+			}
+		}
+
+		if module.Path == modulePath && module.Version != "" && module.Version != "(devel)" {
+			version = module.Version
+		}
+	})
+
+	return version
+}
+
+// DefaultUserAgent returns the User-Agent sent by default: go-bambou and its version.
+func DefaultUserAgent() string {
+
+	return "go-bambou/" + Version()
+}
+
+// SetApplication adds the given application and its version to the User-Agent sent
+// by the session, like "go-bambou/v1.2.3 vsd-exporter/2.0", so that the access logs
+// of the server attribute the requests to the tool sending them. The version may be
+// empty. An empty name removes the application.
+func (s *Session) SetApplication(name, version string) {
+
+	s.application = name
+	if name != "" && version != "" {
+		s.application += "/" + version
+	}
+}
+
+// SetUserAgent replaces the User-Agent sent by the session. An empty User-Agent
+// restores the default one, with the application set with SetApplication.
+func (s *Session) SetUserAgent(userAgent string) {
+
+	s.userAgent = userAgent
+}
+
+// UserAgent returns the User-Agent sent by the session.
+func (s *Session) UserAgent() string {
+
+	if s.userAgent != "" {
+		return s.userAgent
+	}
+
+	if s.application != "" {
+		return DefaultUserAgent() + " " + s.application
+	}
+
+	return DefaultUserAgent()
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_UserAgent(t *testing.T) {
+
+	Convey("Given I have a server recording the User-Agent", t, func() {
+
+		userAgents := make(chan string, 1)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAgents <- r.Header.Get("User-Agent")
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		Convey("When I send a request", func() {
+
+			So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+
+			Convey("Then the default User-Agent should be sent", func() {
+				So(<-userAgents, ShouldEqual, "go-bambou/"+Version())
+				So(Version(), ShouldNotBeEmpty)
+				So(DefaultUserAgent(), ShouldStartWith, "go-bambou/")
+			})
+		})
+
+		Convey("When I set the application of the session", func() {
+
+			session.SetApplication("vsd-exporter", "2.0")
+			So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+
+			Convey("Then the application should be added to the User-Agent", func() {
+				userAgent := <-userAgents
+				So(userAgent, ShouldStartWith, "go-bambou/")
+				So(strings.HasSuffix(userAgent, " vsd-exporter/2.0"), ShouldBeTrue)
+			})
+
+			Convey("When I remove the application", func() {
+
+				session.SetApplication("", "")
+
+				Convey("Then the default User-Agent should be used", func() {
+					So(session.UserAgent(), ShouldEqual, DefaultUserAgent())
+				})
+			})
+		})
+
+		Convey("When I override the User-Agent", func() {
+
+			session.SetApplication("vsd-exporter", "")
+			session.SetUserAgent("custom/1.0")
+			So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+
+			Convey("Then it should be sent as is", func() {
+				So(<-userAgents, ShouldEqual, "custom/1.0")
+			})
+
+			Convey("When I restore the default User-Agent", func() {
+
+				session.SetUserAgent("")
+
+				Convey("Then the application should be added to the default User-Agent", func() {
+					So(session.UserAgent(), ShouldEqual, DefaultUserAgent()+" vsd-exporter")
+				})
+			})
+		})
+	})
+}
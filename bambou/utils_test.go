@@ -1,6 +1,15 @@
 package bambou
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
 
 /*
    Fake Exposed
@@ -70,3 +79,149 @@ func (o *UnmarshalableFakeObject) UnmarshalJSON([]byte) error {
 func (o *UnmarshalableFakeObject) MarshalJSON() ([]byte, error) {
 	return nil, fmt.Errorf("error marshalling")
 }
+
+/*
+   Fake Server
+*/
+
+var fakeServerFilterRegexp = regexp.MustCompile(`^(\w+) == "(.*)"$`)
+
+// fakeServer is a minimal in memory implementation of the REST api used by the tests.
+type fakeServer struct {
+	*httptest.Server
+
+	objects  map[string]map[string]interface{}
+	parents  map[string]string
+	requests []string
+	failOn   string
+	nextID   int
+	lock     sync.Mutex
+}
+
+func newFakeServer() *fakeServer {
+
+	fs := &fakeServer{
+		objects: map[string]map[string]interface{}{},
+		parents: map[string]string{},
+	}
+	fs.Server = httptest.NewServer(http.HandlerFunc(fs.handle))
+
+	return fs
+}
+
+func (fs *fakeServer) add(parentID string, object map[string]interface{}) {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	fs.objects[object["ID"].(string)] = object
+	fs.parents[object["ID"].(string)] = parentID
+}
+
+func (fs *fakeServer) get(ID string) map[string]interface{} {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return fs.objects[ID]
+}
+
+func (fs *fakeServer) count() int {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return len(fs.objects)
+}
+
+func (fs *fakeServer) methods() []string {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return append([]string{}, fs.requests...)
+}
+
+func (fs *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	fs.requests = append(fs.requests, r.Method)
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	last := parts[len(parts)-1]
+	_, isObject := fs.objects[last]
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	if fs.failOn != "" && body != nil && body["name"] == fs.failOn {
+		http.Error(w, "failed on purpose", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+
+	case isObject && r.Method == "GET":
+		json.NewEncoder(w).Encode([]interface{}{fs.objects[last]})
+
+	case isObject && r.Method == "PUT":
+		for k, v := range body {
+			fs.objects[last][k] = v
+		}
+		fs.objects[last]["ID"] = last
+		json.NewEncoder(w).Encode([]interface{}{fs.objects[last]})
+
+	case isObject && r.Method == "DELETE":
+		delete(fs.objects, last)
+		delete(fs.parents, last)
+
+	case r.Method == "POST":
+		fs.nextID++
+		ID := fmt.Sprintf("id-%d", fs.nextID)
+		body["ID"] = ID
+		fs.objects[ID] = body
+		if len(parts) > 1 {
+			fs.parents[ID] = parts[len(parts)-2]
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode([]interface{}{body})
+
+	case r.Method == "GET":
+		parentID := ""
+		if len(parts) > 1 {
+			parentID = parts[len(parts)-2]
+		}
+		filter := fakeServerFilterRegexp.FindStringSubmatch(r.Header.Get("X-Nuage-Filter"))
+
+		var IDs []string
+		for ID, object := range fs.objects {
+			if fs.parents[ID] != parentID {
+				continue
+			}
+			if filter != nil && fmt.Sprint(object[filter[1]]) != filter[2] {
+				continue
+			}
+			IDs = append(IDs, ID)
+		}
+		sort.Strings(IDs)
+
+		if len(IDs) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var out []interface{}
+		for _, ID := range IDs {
+			out = append(out, fs.objects[ID])
+		}
+		w.Header().Set("X-Nuage-Count", fmt.Sprint(len(out)))
+		json.NewEncoder(w).Encode(out)
+
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
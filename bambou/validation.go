@@ -0,0 +1,300 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes an attribute that does not pass a validation rule.
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// Error returns the string representation of the FieldError.
+func (e *FieldError) Error() string {
+
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors is a list of FieldError.
+type ValidationErrors []*FieldError
+
+// Error returns the string representation of the ValidationErrors.
+func (e ValidationErrors) Error() string {
+
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// Validatable is the interface of the objects having their own validation logic,
+// run in addition to the rules of their validate tags.
+type Validatable interface {
+	Validate() ValidationErrors
+}
+
+// Validate validates the attributes of the given object, which must be a pointer to a struct.
+// The rules are given by the validate tag of the fields, as a comma separated list of:
+//
+//	required      the value must not be the zero value
+//	minlength=N   the string or list must have at least N elements
+//	maxlength=N   the string or list must have at most N elements
+//	enum=A|B|C    the string must be one of the given values
+//	pattern=RE    the string must match the given regular expression
+//
+// The pattern rule must be the last one, as the expression can contain commas.
+// Except required, the rules are not checked on empty values.
+// If the object is Validatable, its Validate method is called too.
+// The fields are named after their JSON names in the returned errors.
+func Validate(object interface{}) ValidationErrors {
+
+	var errs ValidationErrors
+
+	v := reflect.ValueOf(object)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Struct {
+		errs = validateStruct(v)
+	}
+
+	if validatable, ok := object.(Validatable); ok {
+		errs = append(errs, validatable.Validate()...)
+	}
+
+	return errs
+}
+
+// newValidationError returns the *Error returned when the given ValidationErrors are not empty.
+func newValidationError(errs ValidationErrors) *Error {
+
+	return &Error{
+		Title:       "Validation error",
+		Description: errs.Error(),
+		Details:     errs,
+	}
+}
+
+func validateStruct(v reflect.Value) ValidationErrors {
+
+	var errs ValidationErrors
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			errs = append(errs, validateStruct(v.Field(i))...)
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		errs = append(errs, validateField(fieldName(field), v.Field(i), tag)...)
+	}
+
+	return errs
+}
+
+func validateField(name string, value reflect.Value, tag string) ValidationErrors {
+
+	var errs ValidationErrors
+
+	for _, rule := range parseRules(tag) {
+
+		if rule.name == "required" {
+			if isZeroValue(value) {
+				errs = append(errs, &FieldError{Field: name, Rule: rule.name, Message: "is required"})
+			}
+			continue
+		}
+
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		if isZeroValue(value) {
+			continue
+		}
+
+		if err := checkRule(rule, value); err != "" {
+			errs = append(errs, &FieldError{Field: name, Rule: rule.name, Message: err})
+		}
+	}
+
+	return errs
+}
+
+// checkRule returns the error message if the given value does not pass the given rule.
+func checkRule(rule validationRule, value reflect.Value) string {
+
+	switch rule.name {
+
+	case "minlength", "maxlength":
+		limit, err := strconv.Atoi(rule.arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s rule %q", rule.name, rule.arg)
+		}
+
+		length := 0
+		switch value.Kind() {
+		case reflect.String:
+			length = utf8.RuneCountInString(value.String())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			length = value.Len()
+		default:
+			return fmt.Sprintf("%s does not apply to %s", rule.name, value.Kind())
+		}
+
+		if rule.name == "minlength" && length < limit {
+			return fmt.Sprintf("must be at least %d long", limit)
+		}
+		if rule.name == "maxlength" && length > limit {
+			return fmt.Sprintf("must be at most %d long", limit)
+		}
+
+	case "enum":
+		s := fmt.Sprint(value.Interface())
+		for _, allowed := range strings.Split(rule.arg, "|") {
+			if s == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Replace(rule.arg, "|", ", ", -1))
+
+	case "pattern":
+		re, err := compilePattern(rule.arg)
+		if err != nil {
+			return fmt.Sprintf("invalid pattern rule %q", rule.arg)
+		}
+		if !re.MatchString(fmt.Sprint(value.Interface())) {
+			return fmt.Sprintf("must match %s", rule.arg)
+		}
+
+	default:
+		return fmt.Sprintf("unknown rule %q", rule.name)
+	}
+
+	return ""
+}
+
+type validationRule struct {
+	name string
+	arg  string
+}
+
+func parseRules(tag string) []validationRule {
+
+	var rules []validationRule
+
+	for tag != "" {
+
+		var part string
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		rule := validationRule{name: strings.TrimSpace(part)}
+		if i := strings.Index(part, "="); i >= 0 {
+			rule.name, rule.arg = strings.TrimSpace(part[:i]), part[i+1:]
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+var (
+	patterns     = map[string]*regexp.Regexp{}
+	patternsLock sync.Mutex
+)
+
+// compilePattern compiles the given expression once.
+func compilePattern(expr string) (*regexp.Regexp, error) {
+
+	patternsLock.Lock()
+	defer patternsLock.Unlock()
+
+	if re, ok := patterns[expr]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patterns[expr] = re
+
+	return re, nil
+}
+
+// fieldName returns the JSON name of the given field.
+func fieldName(field reflect.StructField) string {
+
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+
+	return field.Name
+}
+
+// isZeroValue returns true if the given value is the zero value of its type, or an empty list.
+func isZeroValue(v reflect.Value) bool {
+
+	if !v.IsValid() {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type validatedObject struct {
+	FakeObject
+
+	Type        string   `json:"type" validate:"required,enum=A|B"`
+	Description string   `json:"description" validate:"maxlength=5"`
+	Address     string   `json:"address" validate:"minlength=2,pattern=^[0-9.]+(,[0-9.]+)?$"`
+	Tags        []string `json:"tags" validate:"required,maxlength=2"`
+	Count       *int     `json:"count" validate:"required"`
+	Nothing     string   `validate:"-"`
+	secret      string   `validate:"required"`
+	custom      ValidationErrors
+}
+
+func (o *validatedObject) Validate() ValidationErrors {
+
+	return o.custom
+}
+
+func newValidObject() *validatedObject {
+
+	count := 0
+	return &validatedObject{FakeObject: FakeObject{ID: "x"}, Type: "A", Description: "short", Address: "1.2,3.4", Tags: []string{"t"}, Count: &count}
+}
+
+func TestValidation_Validate(t *testing.T) {
+
+	Convey("Given I have a valid object", t, func() {
+
+		o := newValidObject()
+
+		Convey("Then it should pass the validation", func() {
+			So(Validate(o), ShouldBeEmpty)
+		})
+
+		Convey("When I remove the required attributes", func() {
+
+			o.Type = ""
+			o.Tags = []string{}
+			o.Count = nil
+
+			Convey("Then I should get an error per attribute", func() {
+				So(Validate(o), ShouldResemble, ValidationErrors{
+					{Field: "type", Rule: "required", Message: "is required"},
+					{Field: "tags", Rule: "required", Message: "is required"},
+					{Field: "count", Rule: "required", Message: "is required"},
+				})
+			})
+		})
+
+		Convey("When I break the other rules", func() {
+
+			o.Type = "C"
+			o.Description = "too long"
+			o.Address = "a"
+			o.Tags = []string{"a", "b", "c"}
+
+			errs := Validate(o)
+
+			Convey("Then I should get an error per rule", func() {
+				So(errs.Error(), ShouldEqual, "type: must be one of A, B, description: must be at most 5 long, address: must be at least 2 long, address: must match ^[0-9.]+(,[0-9.]+)?$, tags: must be at most 2 long")
+			})
+		})
+
+		Convey("When the custom validation fails", func() {
+
+			o.custom = ValidationErrors{{Field: "name", Rule: "custom", Message: "is wrong"}}
+
+			Convey("Then I should get its errors", func() {
+				So(Validate(o), ShouldResemble, o.custom)
+			})
+		})
+	})
+
+	Convey("Given I have an object without rules", t, func() {
+
+		Convey("Then it should pass the validation", func() {
+			So(Validate(NewFakeObject("x")), ShouldBeEmpty)
+			So(Validate(nil), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestValidation_parseRules(t *testing.T) {
+
+	Convey("Given I have a tag with a pattern containing commas", t, func() {
+
+		rules := parseRules("required,maxlength=3,pattern=^a,b$")
+
+		Convey("Then the pattern should be kept whole", func() {
+			So(rules, ShouldResemble, []validationRule{{name: "required"}, {name: "maxlength", arg: "3"}, {name: "pattern", arg: "^a,b$"}})
+		})
+	})
+}
+
+func TestValidation_Session(t *testing.T) {
+
+	Convey("Given I have a server and an invalid object", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("", map[string]interface{}{"ID": "x"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		o := newValidObject()
+		o.Type = "C"
+
+		Convey("When I save it", func() {
+
+			err := session.SaveEntity(o)
+
+			Convey("Then I should get a validation error without any request", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Validation error")
+				So(err.Details, ShouldHaveSameTypeAs, ValidationErrors{})
+				So(ts.methods(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When I create it", func() {
+
+			err := session.CreateChild(NewFakeObject("x"), o)
+
+			Convey("Then I should get a validation error without any request", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Title, ShouldEqual, "Validation error")
+				So(ts.methods(), ShouldBeEmpty)
+			})
+		})
+	})
+}
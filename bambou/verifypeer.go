@@ -0,0 +1,51 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/x509"
+)
+
+// VerifyPeerCertificateFunc verifies the certificate of the server. It is given the
+// DER encoded certificates sent by the server, the leaf first, and the chains verified
+// by crypto/tls, empty as the sessions do not verify the chain of the server.
+// Returning an error aborts the connection. See tls.Config.VerifyPeerCertificate.
+type VerifyPeerCertificateFunc func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+
+// SetVerifyPeerCertificate sets the function verifying the certificate of the server,
+// to check it with custom rules, like the SAN patterns of the nodes of a VSD cluster,
+// or with an internal trust service. It is called for the sessions authenticating with
+// a password or a certificate, after the pins set with SetPinnedCertificates are checked.
+// Use nil to remove it. It must be called before the session is used.
+func (s *Session) SetVerifyPeerCertificate(verify VerifyPeerCertificateFunc) *Error {
+
+	if s.transport == nil {
+		return NewBambouError("Invalid session", "the transport of the session is not configurable")
+	}
+
+	s.verifyPeer = verify
+	s.updateVerifyPeerCertificate()
+
+	return nil
+}
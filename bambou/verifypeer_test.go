@@ -0,0 +1,115 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSession_SetVerifyPeerCertificate(t *testing.T) {
+
+	Convey("Given I have a TLS server", t, func() {
+
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"ID": "1", "name": "object"}]`))
+		}))
+		defer ts.Close()
+
+		var calls int
+		verifySAN := func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			calls++
+			leaf, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			return leaf.VerifyHostname("example.com")
+		}
+
+		sessions := map[string]*Session{
+			"password": NewSession("username", "password", "organization", ts.URL, NewFakeRootObject()),
+			"X509":     NewX509Session(&tls.Certificate{Certificate: [][]byte{{1}}}, ts.URL, NewFakeRootObject()),
+		}
+
+		for mode, session := range sessions {
+
+			session := session
+
+			Convey(fmt.Sprintf("When I verify the SAN of the server with a %s session", mode), func() {
+
+				So(session.SetVerifyPeerCertificate(verifySAN), ShouldBeNil)
+				err := session.FetchEntity(NewFakeObject("1"))
+
+				Convey("Then the request should succeed", func() {
+					So(err, ShouldBeNil)
+					So(calls, ShouldEqual, 1)
+				})
+			})
+
+			Convey(fmt.Sprintf("When I reject the certificate of the server with a %s session", mode), func() {
+
+				So(session.SetVerifyPeerCertificate(func([][]byte, [][]*x509.Certificate) error {
+					return fmt.Errorf("untrusted")
+				}), ShouldBeNil)
+				err := session.FetchEntity(NewFakeObject("1"))
+
+				Convey("Then the request should fail", func() {
+					So(err, ShouldNotBeNil)
+				})
+
+				Convey("When I remove the verification", func() {
+
+					So(session.SetVerifyPeerCertificate(nil), ShouldBeNil)
+
+					Convey("Then the request should succeed", func() {
+						So(session.FetchEntity(NewFakeObject("1")), ShouldBeNil)
+					})
+				})
+			})
+		}
+
+		Convey("When I pin another certificate and set a verification", func() {
+
+			session := sessions["password"]
+			other := sha256.Sum256([]byte("other"))
+			So(session.SetPinnedCertificates(base64.StdEncoding.EncodeToString(other[:])), ShouldBeNil)
+			So(session.SetVerifyPeerCertificate(verifySAN), ShouldBeNil)
+			err := session.FetchEntity(NewFakeObject("1"))
+
+			Convey("Then the request should fail before the verification", func() {
+				So(err, ShouldNotBeNil)
+				So(calls, ShouldEqual, 0)
+			})
+		})
+	})
+}
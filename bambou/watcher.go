@@ -0,0 +1,201 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// WatchHandlers contains the functions called by a Watcher when an object
+// is added, updated or deleted. Any of them can be nil.
+type WatchHandlers struct {
+	OnAdd    func(Identifiable)
+	OnUpdate func(old Identifiable, new Identifiable)
+	OnDelete func(Identifiable)
+}
+
+// Watcher maintains a local copy of the children of a given Identity of a parent.
+// It lists the children once with Sync, then keeps the copy up to date with the
+// events given to HandleEvent, calling the WatchHandlers on every change.
+//
+// HandleEvent must be registered on the PushCenter before calling Sync, so
+// no event is missed in between. The events received before the end of Sync
+// are applied after the listing:
+//
+//	watcher := bambou.NewWatcher(session, parent, identity, factory, handlers)
+//	pushCenter.RegisterHandlerForIdentity(watcher.HandleEvent, identity)
+//	pushCenter.Start()
+//	err := watcher.Sync()
+type Watcher struct {
+	storer   Storer
+	parent   Identifiable
+	identity Identity
+	factory  IdentifiableFactory
+	handlers WatchHandlers
+	objects  map[string]Identifiable
+	pending  []*Event
+	synced   bool
+	lock     sync.Mutex
+}
+
+// NewWatcher returns a new *Watcher of the children of the given Identity of the given parent.
+// The given factory must return a new pointer to the concrete type of the children.
+func NewWatcher(storer Storer, parent Identifiable, identity Identity, factory IdentifiableFactory, handlers WatchHandlers) *Watcher {
+
+	return &Watcher{
+		storer:   storer,
+		parent:   parent,
+		identity: identity,
+		factory:  factory,
+		handlers: handlers,
+		objects:  map[string]Identifiable{},
+	}
+}
+
+// Sync lists the children and populates the local copy, calling OnAdd for each of them.
+// The events received in the meantime are then applied.
+func (w *Watcher) Sync() *Error {
+
+	dest := reflect.New(reflect.SliceOf(reflect.TypeOf(w.factory())))
+	if err := w.storer.FetchChildren(w.parent, w.identity, dest.Interface(), nil); err != nil {
+		return err
+	}
+
+	for i := 0; i < dest.Elem().Len(); i++ {
+		w.apply(EventTypeCreate, dest.Elem().Index(i).Interface().(Identifiable))
+	}
+
+	w.lock.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.synced = true
+	w.lock.Unlock()
+
+	for _, event := range pending {
+		w.HandleEvent(event)
+	}
+
+	return nil
+}
+
+// HasSynced returns true once Sync succeeded.
+func (w *Watcher) HasSynced() bool {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.synced
+}
+
+// HandleEvent applies the given event to the local copy. Events about other
+// identities or other parents are ignored.
+func (w *Watcher) HandleEvent(event *Event) {
+
+	if event.EntityType != w.identity.Name {
+		return
+	}
+
+	w.lock.Lock()
+	if !w.synced {
+		w.pending = append(w.pending, event)
+		w.lock.Unlock()
+		return
+	}
+	w.lock.Unlock()
+
+	for _, data := range event.DataMap {
+
+		if parentID, ok := data["parentID"].(string); ok && w.parent != nil && w.parent.Identifier() != "" && parentID != w.parent.Identifier() {
+			continue
+		}
+
+		object := w.factory()
+		if err := decodeEntity(data, object); err != nil {
+			loggerOf(w.storer).Errorf("Unable to decode the %s of a %s event: %s", w.identity.Name, event.Type, err)
+			continue
+		}
+
+		w.apply(event.Type, object)
+	}
+}
+
+// Get returns the object with the given ID, or nil if there is none.
+func (w *Watcher) Get(ID string) Identifiable {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.objects[ID]
+}
+
+// List returns all the objects sorted by ID.
+func (w *Watcher) List() IdentifiablesList {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	list := make(IdentifiablesList, 0, len(w.objects))
+	for _, object := range w.objects {
+		list = append(list, object)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Identifier() < list[j].Identifier() })
+
+	return list
+}
+
+// apply applies the given type of event about the given object and calls the handlers.
+func (w *Watcher) apply(eventType string, object Identifiable) {
+
+	ID := object.Identifier()
+
+	w.lock.Lock()
+	old, exists := w.objects[ID]
+	if eventType == EventTypeDelete {
+		delete(w.objects, ID)
+	} else {
+		w.objects[ID] = object
+	}
+	w.lock.Unlock()
+
+	switch {
+
+	case eventType == EventTypeDelete:
+		if exists && w.handlers.OnDelete != nil {
+			w.handlers.OnDelete(old)
+		}
+
+	case exists:
+		if w.handlers.OnUpdate != nil {
+			w.handlers.OnUpdate(old, object)
+		}
+
+	default:
+		if w.handlers.OnAdd != nil {
+			w.handlers.OnAdd(object)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newFakeEvent(eventType string, data ...map[string]interface{}) *Event {
+
+	return &Event{EntityType: "fake", Type: eventType, DataMap: data}
+}
+
+func TestWatcher_Sync(t *testing.T) {
+
+	Convey("Given I have a server with children and a watcher", t, func() {
+
+		ts := newFakeServer()
+		defer ts.Close()
+		ts.add("parent", map[string]interface{}{"ID": "a", "name": "a"})
+		ts.add("parent", map[string]interface{}{"ID": "b", "name": "b"})
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+
+		var added, deleted []string
+		var updated [][2]string
+		w := NewWatcher(session, NewFakeObject("parent"), FakeIdentity, func() Identifiable { return &FakeObject{} }, WatchHandlers{
+			OnAdd: func(o Identifiable) { added = append(added, o.Identifier()) },
+			OnUpdate: func(old Identifiable, new Identifiable) {
+				updated = append(updated, [2]string{old.(*FakeObject).Name, new.(*FakeObject).Name})
+			},
+			OnDelete: func(o Identifiable) { deleted = append(deleted, o.Identifier()) },
+		})
+
+		Convey("When I receive an event before syncing", func() {
+
+			w.HandleEvent(newFakeEvent(EventTypeUpdate, map[string]interface{}{"ID": "a", "name": "a2"}))
+
+			Convey("Then it should not be applied", func() {
+				So(w.HasSynced(), ShouldBeFalse)
+				So(w.Get("a"), ShouldBeNil)
+				So(updated, ShouldBeEmpty)
+			})
+
+			Convey("When I sync", func() {
+
+				err := w.Sync()
+
+				Convey("Then err should be nil", func() {
+					So(err, ShouldBeNil)
+					So(w.HasSynced(), ShouldBeTrue)
+				})
+
+				Convey("Then OnAdd should be called for the children", func() {
+					So(added, ShouldResemble, []string{"a", "b"})
+				})
+
+				Convey("Then the pending event should be applied", func() {
+					So(updated, ShouldResemble, [][2]string{{"a", "a2"}})
+					So(w.Get("a").(*FakeObject).Name, ShouldEqual, "a2")
+				})
+			})
+		})
+
+		Convey("When I sync and receive events", func() {
+
+			w.Sync()
+			w.HandleEvent(newFakeEvent(EventTypeCreate, map[string]interface{}{"ID": "c", "name": "c"}))
+			w.HandleEvent(newFakeEvent(EventTypeDelete, map[string]interface{}{"ID": "b"}))
+			w.HandleEvent(newFakeEvent(EventTypeDelete, map[string]interface{}{"ID": "unknown"}))
+			w.HandleEvent(newFakeEvent(EventTypeCreate, map[string]interface{}{"ID": "d", "parentID": "other"}))
+			w.HandleEvent(&Event{EntityType: "other", Type: EventTypeCreate, DataMap: []map[string]interface{}{{"ID": "e"}}})
+
+			Convey("Then the handlers should be called", func() {
+				So(added, ShouldResemble, []string{"a", "b", "c"})
+				So(deleted, ShouldResemble, []string{"b"})
+			})
+
+			Convey("Then the local copy should be up to date", func() {
+				So(len(w.List()), ShouldEqual, 2)
+				So(w.List()[0].Identifier(), ShouldEqual, "a")
+				So(w.List()[1].Identifier(), ShouldEqual, "c")
+			})
+		})
+	})
+
+	Convey("Given I have a server that fails and a watcher", t, func() {
+
+		ts := newFakeServer()
+		ts.Close()
+
+		session := NewSession("username", "password", "organization", ts.URL, NewFakeRootObject())
+		w := NewWatcher(session, NewFakeObject("parent"), FakeIdentity, func() Identifiable { return &FakeObject{} }, WatchHandlers{})
+
+		Convey("When I sync", func() {
+
+			err := w.Sync()
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(w.HasSynced(), ShouldBeFalse)
+			})
+		})
+	})
+}
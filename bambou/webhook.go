@@ -0,0 +1,221 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default retry settings of the WebhookForwarder.
+const (
+	DefaultWebhookRetries = 3
+	DefaultWebhookBackoff = 1 * time.Second
+)
+
+// WebhookSignatureHeader is the header containing the signature of the body
+// of the requests sent by the WebhookForwarder. See VerifyWebhookSignature.
+const WebhookSignatureHeader = "X-Bambou-Signature"
+
+// WebhookMetrics contains the delivery counters of a WebhookForwarder.
+type WebhookMetrics struct {
+	Delivered uint64
+	Failed    uint64
+	Retried   uint64
+}
+
+// WebhookForwarder is an EventSink that POSTs the events as JSON to a list of endpoints.
+// If a secret is set, the requests are signed with HMAC-SHA256 in the WebhookSignatureHeader.
+// A failed delivery is retried with an exponential backoff.
+type WebhookForwarder struct {
+	endpoints []string
+	secret    []byte
+	client    *http.Client
+	retries   int
+	backoff   time.Duration
+	delivered uint64
+	failed    uint64
+	retried   uint64
+	clock     Clock
+	lock      sync.RWMutex
+}
+
+// NewWebhookForwarder returns a new *WebhookForwarder signing with the given secret
+// and posting to the given endpoints. The secret can be nil.
+func NewWebhookForwarder(secret []byte, endpoints ...string) *WebhookForwarder {
+
+	return &WebhookForwarder{
+		endpoints: endpoints,
+		secret:    secret,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		retries:   DefaultWebhookRetries,
+		backoff:   DefaultWebhookBackoff,
+	}
+}
+
+// SetRetries sets the number of retries of a failed delivery and the delay before the first retry.
+func (f *WebhookForwarder) SetRetries(retries int, backoff time.Duration) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.retries = retries
+	f.backoff = backoff
+}
+
+// SetClock sets the Clock timing the retries. The default is SystemClock.
+func (f *WebhookForwarder) SetClock(clock Clock) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.clock = clock
+}
+
+// SetHTTPClient sets the *http.Client used to deliver the events.
+func (f *WebhookForwarder) SetHTTPClient(client *http.Client) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.client = client
+}
+
+// Metrics returns the current delivery counters.
+func (f *WebhookForwarder) Metrics() WebhookMetrics {
+
+	return WebhookMetrics{
+		Delivered: atomic.LoadUint64(&f.delivered),
+		Failed:    atomic.LoadUint64(&f.failed),
+		Retried:   atomic.LoadUint64(&f.retried),
+	}
+}
+
+// Publish delivers the given event to all the endpoints. It returns an error
+// if the delivery to any of the endpoints failed after all the retries.
+func (f *WebhookForwarder) Publish(event *Event) error {
+
+	body, err := EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, endpoint := range f.endpoints {
+		if err := f.deliver(endpoint, event, body); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", endpoint, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to deliver the event: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// deliver posts the given body to the given endpoint, retrying on failure.
+func (f *WebhookForwarder) deliver(endpoint string, event *Event, body []byte) error {
+
+	f.lock.RLock()
+	client, retries, delay, clock := f.client, f.retries, f.backoff, clockOrSystem(f.clock)
+	f.lock.RUnlock()
+
+	var err error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+
+		if attempt > 0 {
+			atomic.AddUint64(&f.retried, 1)
+			DefaultLogger().Warnf("Retrying the delivery to %s in %s: %s", endpoint, delay, err)
+			<-clock.NewTimer(delay).C()
+			delay *= 2
+		}
+
+		if err = f.post(client, endpoint, event, body); err == nil {
+			atomic.AddUint64(&f.delivered, 1)
+			return nil
+		}
+	}
+
+	atomic.AddUint64(&f.failed, 1)
+
+	return err
+}
+
+func (f *WebhookForwarder) post(client *http.Client, endpoint string, event *Event, body []byte) error {
+
+	request, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Bambou-Entity-Type", event.EntityType)
+	request.Header.Set("X-Bambou-Event-Type", event.Type)
+	if f.secret != nil {
+		request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(f.secret, body))
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return nil
+}
+
+// SignWebhookPayload returns the signature of the given body with the given secret,
+// as sent by the WebhookForwarder.
+func SignWebhookPayload(secret []byte, body []byte) string {
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature returns true if the given signature is the signature of the given
+// body with the given secret. Receivers of the WebhookForwarder requests should use it to
+// authenticate them.
+func VerifyWebhookSignature(secret []byte, body []byte, signature string) bool {
+
+	return hmac.Equal([]byte(SignWebhookPayload(secret, body)), []byte(signature))
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhook_Publish(t *testing.T) {
+
+	Convey("Given I have an endpoint and a forwarder", t, func() {
+
+		var lock sync.Mutex
+		var bodies []string
+		var signatures []string
+		failures := 0
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if failures > 0 {
+				failures--
+				http.Error(w, "woops", http.StatusInternalServerError)
+				return
+			}
+
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			signatures = append(signatures, r.Header.Get(WebhookSignatureHeader))
+		}))
+		defer ts.Close()
+
+		f := NewWebhookForwarder([]byte("secret"), ts.URL)
+		f.SetRetries(2, time.Millisecond)
+		e := &Event{EntityType: "fake", Type: EventTypeCreate, DataMap: []map[string]interface{}{{"ID": "x"}}}
+
+		Convey("When I publish an event", func() {
+
+			err := f.Publish(e)
+
+			Convey("Then it should be delivered and signed", func() {
+				So(err, ShouldBeNil)
+				So(len(bodies), ShouldEqual, 1)
+				So(VerifyWebhookSignature([]byte("secret"), []byte(bodies[0]), signatures[0]), ShouldBeTrue)
+				So(VerifyWebhookSignature([]byte("other"), []byte(bodies[0]), signatures[0]), ShouldBeFalse)
+			})
+
+			Convey("Then the metrics should be updated", func() {
+				So(f.Metrics(), ShouldResemble, WebhookMetrics{Delivered: 1})
+			})
+		})
+
+		Convey("When I publish an event and the endpoint fails once", func() {
+
+			failures = 1
+			err := f.Publish(e)
+
+			Convey("Then it should be delivered after a retry", func() {
+				So(err, ShouldBeNil)
+				So(len(bodies), ShouldEqual, 1)
+				So(f.Metrics(), ShouldResemble, WebhookMetrics{Delivered: 1, Retried: 1})
+			})
+		})
+
+		Convey("When I publish an event and the endpoint keeps failing", func() {
+
+			failures = 10
+			err := f.Publish(e)
+
+			Convey("Then err should not be nil", func() {
+				So(err, ShouldNotBeNil)
+				So(f.Metrics(), ShouldResemble, WebhookMetrics{Failed: 1, Retried: 2})
+			})
+		})
+	})
+}
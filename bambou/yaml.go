@@ -0,0 +1,232 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// TreeNode is a node of a tree of objects.
+// A TreeNode can be marshaled to and unmarshaled from YAML, with yaml.Marshal
+// and yaml.Unmarshal, as:
+//
+//	type: enterprise
+//	attributes:
+//	  name: acme
+//	children:
+//	- type: domain
+//	  attributes:
+//	    name: default
+//
+// The types of the objects must be registered to be unmarshaled: see RegisterIdentity.
+type TreeNode struct {
+	Object   Identifiable
+	Children []*TreeNode
+}
+
+// NewTreeNode returns a new *TreeNode for the given object, with the given children.
+func NewTreeNode(object Identifiable, children ...*TreeNode) *TreeNode {
+
+	return &TreeNode{
+		Object:   object,
+		Children: children,
+	}
+}
+
+// yamlTreeNode is the YAML representation of a TreeNode.
+type yamlTreeNode struct {
+	Type       string                 `yaml:"type"`
+	Attributes map[string]interface{} `yaml:"attributes,omitempty"`
+	Children   []*TreeNode            `yaml:"children,omitempty"`
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (n TreeNode) MarshalYAML() (interface{}, error) {
+
+	if n.Object == nil {
+		return nil, fmt.Errorf("tree node without object")
+	}
+
+	attributes, err := yamlAttributesOf(n.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &yamlTreeNode{
+		Type:       n.Object.Identity().Name,
+		Attributes: attributes,
+		Children:   n.Children,
+	}, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (n *TreeNode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	node := &yamlTreeNode{}
+	if err := unmarshal(node); err != nil {
+		return err
+	}
+
+	object := NewIdentifiable(node.Type)
+	if object == nil {
+		return fmt.Errorf("unregistered type %q", node.Type)
+	}
+
+	if err := setYAMLAttributes(object, node.Attributes); err != nil {
+		return err
+	}
+
+	n.Object = object
+	n.Children = node.Children
+
+	return nil
+}
+
+// EncodeYAML writes the attributes of the given object as YAML to the given writer.
+// The attributes are named after their JSON names.
+func EncodeYAML(w io.Writer, object Identifiable) error {
+
+	attributes, err := yamlAttributesOf(object)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+// DecodeYAML reads the YAML attributes of the given object from the given reader.
+func DecodeYAML(r io.Reader, object Identifiable) error {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+
+	return setYAMLAttributes(object, attributes)
+}
+
+// EncodeTreeYAML writes the given tree as YAML to the given writer.
+func EncodeTreeYAML(w io.Writer, tree *TreeNode) error {
+
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+// DecodeTreeYAML reads a tree from the YAML of the given reader.
+func DecodeTreeYAML(r io.Reader) (*TreeNode, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &TreeNode{}
+	if err := yaml.Unmarshal(data, tree); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// yamlAttributesOf returns the attributes of the given object that are not null.
+func yamlAttributesOf(object Identifiable) (map[string]interface{}, error) {
+
+	attributes, berr := attributesOf(object)
+	if berr != nil {
+		return nil, berr
+	}
+
+	for name, value := range attributes {
+		if value == nil {
+			delete(attributes, name)
+		}
+	}
+
+	return attributes, nil
+}
+
+// setYAMLAttributes sets the given decoded YAML attributes to the given object.
+func setYAMLAttributes(object Identifiable, attributes map[string]interface{}) error {
+
+	data, err := json.Marshal(jsonCompatible(attributes))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, object)
+}
+
+// jsonCompatible converts the maps decoded from YAML, that have interface{}
+// keys, to maps with string keys that can be marshaled to JSON.
+func jsonCompatible(value interface{}) interface{} {
+
+	switch v := value.(type) {
+
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return m
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = jsonCompatible(item)
+		}
+		return m
+
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = jsonCompatible(item)
+		}
+		return l
+	}
+
+	return value
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package bambou
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type yamlObject struct {
+	FakeObject
+
+	Enabled bool                   `json:"enabled"`
+	Tags    []string               `json:"tags"`
+	Options map[string]interface{} `json:"options"`
+	Parent  *string                `json:"parentID"`
+}
+
+func TestYAML_EncodeDecode(t *testing.T) {
+
+	Convey("Given I have an object", t, func() {
+
+		o := &yamlObject{FakeObject: FakeObject{ID: "x", Name: "name"}, Enabled: true, Tags: []string{"a", "b"}, Options: map[string]interface{}{"size": 2.0}}
+
+		Convey("When I encode it", func() {
+
+			buffer := &bytes.Buffer{}
+			err := EncodeYAML(buffer, o)
+
+			Convey("Then I should get its attributes as YAML, without the null ones", func() {
+				So(err, ShouldBeNil)
+				So(buffer.String(), ShouldEqual, "ID: x\nenabled: true\nname: name\noptions:\n  size: 2\ntags:\n- a\n- b\n")
+			})
+
+			Convey("When I decode it", func() {
+
+				decoded := &yamlObject{}
+				err := DecodeYAML(buffer, decoded)
+
+				Convey("Then I should get the same object", func() {
+					So(err, ShouldBeNil)
+					So(decoded, ShouldResemble, o)
+				})
+			})
+		})
+
+		Convey("When I decode invalid YAML", func() {
+
+			err := DecodeYAML(strings.NewReader("name: [a"), &yamlObject{})
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestYAML_Tree(t *testing.T) {
+
+	Convey("Given I have a tree", t, func() {
+
+		RegisterIdentity(FakeIdentity, func() Identifiable { return NewFakeObject("") })
+		defer UnregisterIdentity(FakeIdentity)
+
+		tree := NewTreeNode(&FakeObject{Name: "root"},
+			NewTreeNode(&FakeObject{ID: "1", Name: "child"},
+				NewTreeNode(&FakeObject{Name: "grandchild"}),
+			),
+		)
+
+		Convey("When I encode it", func() {
+
+			buffer := &bytes.Buffer{}
+			err := EncodeTreeYAML(buffer, tree)
+
+			Convey("Then I should get the tree as YAML", func() {
+				So(err, ShouldBeNil)
+				So(buffer.String(), ShouldEqual, `type: fake
+attributes:
+  ID: ""
+  name: root
+children:
+- type: fake
+  attributes:
+    ID: "1"
+    name: child
+  children:
+  - type: fake
+    attributes:
+      ID: ""
+      name: grandchild
+`)
+			})
+
+			Convey("When I decode it", func() {
+
+				decoded, err := DecodeTreeYAML(buffer)
+
+				Convey("Then I should get the same tree", func() {
+					So(err, ShouldBeNil)
+					So(decoded, ShouldResemble, tree)
+				})
+			})
+		})
+
+		Convey("When I unmarshal a tree with an unregistered type", func() {
+
+			node := &TreeNode{}
+			err := yaml.Unmarshal([]byte("type: unknown\n"), node)
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When I marshal a node without object", func() {
+
+			_, err := yaml.Marshal(&TreeNode{})
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package zapadapter adapts the zap loggers to bambou.StructuredLogger.
+package zapadapter
+
+import (
+	"sort"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"go.uber.org/zap"
+)
+
+// Logger is a bambou.StructuredLogger writing to a zap logger.
+type Logger struct {
+	*zap.SugaredLogger
+}
+
+// New returns a new *Logger writing to the given zap logger, under the "bambou" name.
+func New(logger *zap.Logger) *Logger {
+
+	return &Logger{
+		SugaredLogger: logger.Named("bambou").Sugar(),
+	}
+}
+
+// DebugFields implements the bambou.StructuredLogger interface.
+func (l *Logger) DebugFields(message string, fields bambou.Fields) {
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key, fields[key])
+	}
+
+	l.Debugw(message, pairs...)
+}
+
+var _ bambou.StructuredLogger = (*Logger)(nil)
@@ -0,0 +1,66 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package zapadapter
+
+import (
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapAdapter_New(t *testing.T) {
+
+	Convey("Given I have a zap logger", t, func() {
+
+		core, logs := observer.New(zapcore.InfoLevel)
+		adapter := New(zap.New(core))
+
+		Convey("When I log through the adapter", func() {
+
+			adapter.Debugf("hidden")
+			adapter.Infof("value is %d", 42)
+
+			Convey("Then the logger should get the entries above its level", func() {
+				So(logs.Len(), ShouldEqual, 1)
+				So(logs.All()[0].Message, ShouldEqual, "value is 42")
+				So(logs.All()[0].LoggerName, ShouldEqual, "bambou")
+			})
+		})
+
+		Convey("When I log fields at the debug level", func() {
+
+			core, logs := observer.New(zapcore.DebugLevel)
+			New(zap.New(core)).DebugFields("request", bambou.Fields{"status": 200, "method": "GET"})
+
+			Convey("Then the logger should get them as fields", func() {
+				So(logs.Len(), ShouldEqual, 1)
+				So(logs.All()[0].ContextMap(), ShouldResemble, map[string]interface{}{"method": "GET", "status": int64(200)})
+			})
+		})
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// eventMirror keeps the last events received by the proxy, and serves them to
+// the clients like the events endpoint of the server. The UUIDs of the events
+// are their sequence numbers.
+type eventMirror struct {
+	events    []*bambou.Event
+	base      uint64
+	next      uint64
+	size      int
+	timeout   time.Duration
+	newEvents chan struct{}
+	lock      sync.Mutex
+}
+
+// newEventMirror returns a new *eventMirror keeping the given number of events,
+// and answering with no event after the given timeout.
+func newEventMirror(size int, timeout time.Duration) *eventMirror {
+
+	return &eventMirror{
+		size:      size,
+		timeout:   timeout,
+		newEvents: make(chan struct{}),
+	}
+}
+
+// run adds the events received on the given channel until it is closed.
+func (m *eventMirror) run(events <-chan *bambou.Event) {
+
+	for event := range events {
+		m.add(event)
+	}
+}
+
+// add adds the given event, and wakes up the requests waiting for events.
+func (m *eventMirror) add(event *bambou.Event) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.events = append(m.events, event)
+	m.next++
+
+	if len(m.events) > m.size {
+		m.events = m.events[1:]
+		m.base++
+	}
+
+	close(m.newEvents)
+	m.newEvents = make(chan struct{})
+}
+
+// reset forgets the events, so that the clients asking for the following ones
+// are told to resynchronize.
+func (m *eventMirror) reset() {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.events = nil
+	m.next++
+	m.base = m.next
+}
+
+// after returns the notification of the events following the one with the given
+// sequence number, or false if the events following it are not kept anymore.
+func (m *eventMirror) after(last uint64) (*bambou.Notification, bool) {
+
+	if last < m.base || last > m.next {
+		return nil, false
+	}
+
+	notification := bambou.NewNotification()
+	notification.Events = append(notification.Events, m.events[last-m.base:]...)
+	notification.UUID = strconv.FormatUint(m.next, 10)
+
+	return notification, true
+}
+
+// ServeHTTP serves the events following the one with the requested UUID, waiting
+// for them if there are none yet. Without UUID, the events received from now on are
+// served, and an unknown UUID is answered with http.StatusGone.
+func (m *eventMirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	uuid := r.URL.Query().Get("uuid")
+
+	m.lock.Lock()
+	last := m.next
+	m.lock.Unlock()
+
+	if uuid != "" {
+		var err error
+		if last, err = strconv.ParseUint(uuid, 10, 64); err != nil {
+			writeError(w, newError(http.StatusGone, "Unknown event", fmt.Sprintf("event %s is unknown", uuid)))
+			return
+		}
+	}
+
+	timer := time.NewTimer(m.timeout)
+	defer timer.Stop()
+
+	for {
+
+		m.lock.Lock()
+		notification, ok := m.after(last)
+		newEvents := m.newEvents
+		m.lock.Unlock()
+
+		if !ok {
+			writeError(w, newError(http.StatusGone, "Unknown event", fmt.Sprintf("event %s is not kept anymore", uuid)))
+			return
+		}
+
+		if len(notification.Events) > 0 {
+			writeJSON(w, http.StatusOK, notification)
+			return
+		}
+
+		select {
+		case <-newEvents:
+		case <-timer.C:
+			writeJSON(w, http.StatusOK, notification)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
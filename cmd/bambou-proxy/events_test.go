@@ -0,0 +1,111 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// getEvents requests the events of the given mirror following the given UUID.
+func getEvents(m *eventMirror, uuid string) (int, *bambou.Notification) {
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/events?uuid="+uuid, nil))
+
+	notification := bambou.NewNotification()
+	json.Unmarshal(w.Body.Bytes(), notification)
+
+	return w.Code, notification
+}
+
+func TestEventMirror_ServeHTTP(t *testing.T) {
+
+	Convey("Given I have an event mirror keeping two events", t, func() {
+
+		m := newEventMirror(2, 10*time.Millisecond)
+
+		Convey("When I request the events without UUID", func() {
+			code, notification := getEvents(m, "")
+
+			Convey("Then I should get no event after the timeout", func() {
+				So(code, ShouldEqual, http.StatusOK)
+				So(notification.Events, ShouldBeEmpty)
+				So(notification.UUID, ShouldEqual, "0")
+			})
+		})
+
+		Convey("When events are received", func() {
+			m.add(&bambou.Event{EntityType: "a"})
+			m.add(&bambou.Event{EntityType: "b"})
+
+			Convey("Then the events following a UUID should be served", func() {
+				code, notification := getEvents(m, "1")
+				So(code, ShouldEqual, http.StatusOK)
+				So(len(notification.Events), ShouldEqual, 1)
+				So(notification.Events[0].EntityType, ShouldEqual, "b")
+				So(notification.UUID, ShouldEqual, "2")
+			})
+
+			Convey("Then an evicted UUID should be gone", func() {
+				m.add(&bambou.Event{EntityType: "c"})
+				code, _ := getEvents(m, "0")
+				So(code, ShouldEqual, http.StatusGone)
+			})
+
+			Convey("Then an invalid UUID should be gone", func() {
+				code, _ := getEvents(m, "x")
+				So(code, ShouldEqual, http.StatusGone)
+				code, _ = getEvents(m, "3")
+				So(code, ShouldEqual, http.StatusGone)
+			})
+
+			Convey("Then the UUIDs should be gone after a reset", func() {
+				m.reset()
+				code, _ := getEvents(m, "2")
+				So(code, ShouldEqual, http.StatusGone)
+			})
+		})
+
+		Convey("When an event is received while waiting", func() {
+			m.timeout = time.Second
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				m.add(&bambou.Event{EntityType: "a"})
+			}()
+			code, notification := getEvents(m, "0")
+
+			Convey("Then it should be served", func() {
+				So(code, ShouldEqual, http.StatusOK)
+				So(len(notification.Events), ShouldEqual, 1)
+			})
+		})
+	})
+}
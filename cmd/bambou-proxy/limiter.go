@@ -0,0 +1,105 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter limits the rate and the concurrency of the requests sent to the server.
+type limiter struct {
+	interval time.Duration
+	next     time.Time
+	slots    chan struct{}
+	lock     sync.Mutex
+}
+
+// newLimiter returns a new *limiter allowing the given number of requests per second,
+// and the given number of concurrent requests. 0 does not limit them.
+func newLimiter(rate float64, concurrency int) *limiter {
+
+	l := &limiter{}
+
+	if rate > 0 {
+		l.interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	if concurrency > 0 {
+		l.slots = make(chan struct{}, concurrency)
+	}
+
+	return l
+}
+
+// wait waits until a request can be sent, and returns true, or returns false if the
+// given context is done first. done must be called once the request is answered when
+// it returns true.
+func (l *limiter) wait(ctx context.Context) bool {
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if l.interval == 0 {
+		return true
+	}
+
+	l.lock.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.lock.Unlock()
+
+	if delay == 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		l.done()
+		return false
+	}
+}
+
+// done releases the slot taken by wait.
+func (l *limiter) done() {
+
+	if l.slots != nil {
+		<-l.slots
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Command bambou-proxy serves the ReST API of a VSD, or of a compatible server,
+// on a local address, so that many small tools can share a single authenticated,
+// rate limited and cached connection to the server.
+//
+// Usage:
+//
+//	bambou-proxy [flags]
+//
+// The tools use the proxy like the server itself, with the same API path, for
+// instance http://localhost:8080/nuage/api/v5_0 for a server URL ending with
+// /nuage/api/v5_0. The proxy does not check their credentials: it authenticates
+// with its own, and must only listen on addresses the tools are trusted to reach.
+//
+// The entities and the listings are cached for --cache-ttl, and the events of the
+// server invalidate the cached objects as soon as they are changed by others. The
+// proxy serves the events it receives on its own events endpoint, keeping the last
+// --event-buffer ones, so that the tools do not open one push channel each.
+//
+// The connection flags default to the BAMBOU_URL, BAMBOU_USERNAME,
+// BAMBOU_PASSWORD, BAMBOU_ORGANIZATION, BAMBOU_CERT and BAMBOU_KEY
+// environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/cmd/internal/cli"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// options holds the flags.
+type options struct {
+	cli.Connection
+	listen       string
+	cacheTTL     time.Duration
+	cacheSize    int
+	events       bool
+	eventBuffer  int
+	eventTimeout time.Duration
+	rate         float64
+	concurrency  int
+	readOnly     bool
+}
+
+func main() {
+
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run runs the proxy with the given arguments until it is interrupted, and returns the exit code.
+func run(args []string, stderr io.Writer) int {
+
+	opts, code := parseOptions(args, stderr)
+	if opts == nil {
+		return code
+	}
+
+	listener, err := net.Listen("tcp", opts.listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "bambou-proxy: %s\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stderr, "bambou-proxy: proxying %s on %s\n", opts.URL, listener.Addr())
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := serve(ctx, opts, listener); err != nil {
+		fmt.Fprintf(stderr, "bambou-proxy: %s\n", cli.ErrorMessage(err))
+		return exitError
+	}
+
+	return exitOK
+}
+
+// parseOptions parses the given arguments. It returns nil and the exit code if the proxy must not run.
+func parseOptions(args []string, stderr io.Writer) (*options, int) {
+
+	opts := &options{}
+
+	flags := flag.NewFlagSet("bambou-proxy", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	opts.AddFlags(flags)
+	flags.StringVar(&opts.listen, "listen", "localhost:8080", "address to listen on")
+	flags.DurationVar(&opts.cacheTTL, "cache-ttl", 30*time.Second, "duration the responses are cached, 0 to disable the cache")
+	flags.IntVar(&opts.cacheSize, "cache-size", 10000, "maximum number of cached responses")
+	flags.BoolVar(&opts.events, "events", true, "listen to the events of the server to invalidate the cache and serve them")
+	flags.IntVar(&opts.eventBuffer, "event-buffer", 1000, "number of events kept for the clients")
+	flags.DurationVar(&opts.eventTimeout, "event-timeout", 30*time.Second, "duration the clients wait for events")
+	flags.Float64Var(&opts.rate, "rate", 0, "maximum number of requests per second sent to the server, 0 for no limit")
+	flags.IntVar(&opts.concurrency, "concurrency", 0, "maximum number of concurrent requests sent to the server, 0 for no limit")
+	flags.BoolVar(&opts.readOnly, "read-only", false, "reject the requests modifying objects")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: bambou-proxy [flags]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, exitOK
+		}
+		return nil, exitUsage
+	}
+
+	opts.ReadPassword()
+
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "bambou-proxy: unexpected arguments: %v\n", flags.Args())
+		flags.Usage()
+		return nil, exitUsage
+	}
+
+	if opts.URL == "" {
+		fmt.Fprintln(stderr, "bambou-proxy: missing URL")
+		flags.Usage()
+		return nil, exitUsage
+	}
+
+	return opts, exitOK
+}
+
+// serve starts a session with the given options and serves the proxy on the given
+// listener until the given context is done.
+func serve(ctx context.Context, opts *options, listener net.Listener) error {
+
+	session, err := start(opts)
+	if err != nil {
+		return err
+	}
+
+	p, err := newProxy(session, opts)
+	if err != nil {
+		return err
+	}
+	defer p.stop()
+
+	server := &http.Server{Handler: p}
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.Serve(listener) }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), opts.eventTimeout+5*time.Second)
+	defer cancel()
+
+	return server.Shutdown(shutdown)
+}
+
+// start returns a new started session using the given options.
+func start(opts *options) (*bambou.Session, error) {
+
+	session, err := opts.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	session.SetReadOnly(opts.readOnly)
+
+	return session, nil
+}
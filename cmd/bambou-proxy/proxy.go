@@ -0,0 +1,356 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// proxy serves the ReST API of the server of a session.
+type proxy struct {
+	session      *bambou.Session
+	prefix       string
+	limiter      *limiter
+	events       *eventMirror
+	pushCenter   *bambou.PushCenter
+	subscription *bambou.Subscription
+}
+
+// newProxy returns a new *proxy serving the API of the given started session with
+// the given options. It caches the responses and listens to the events as requested.
+func newProxy(session *bambou.Session, opts *options) (*proxy, error) {
+
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %s", err)
+	}
+
+	p := &proxy{
+		session: session,
+		prefix:  strings.TrimSuffix(u.Path, "/"),
+		limiter: newLimiter(opts.rate, opts.concurrency),
+	}
+
+	var cache *bambou.Cache
+	if opts.cacheTTL > 0 {
+		cache = bambou.NewCache(opts.cacheTTL, opts.cacheSize)
+		session.SetCache(cache)
+	}
+
+	if !opts.events {
+		return p, nil
+	}
+
+	p.events = newEventMirror(opts.eventBuffer, opts.eventTimeout)
+	p.pushCenter = bambou.NewPushCenter(session)
+	p.pushCenter.SetResyncRequiredHandler(func(*bambou.Error) {
+		// The changes since the last event are unknown: the cached responses and the
+		// events of the clients are not valid anymore.
+		if cache != nil {
+			cache.Clear()
+		}
+		p.events.reset()
+	})
+	if cache != nil {
+		p.pushCenter.SetCache(cache)
+	}
+
+	p.subscription = p.pushCenter.Subscribe(bambou.SubscriptionFilter{}, opts.eventBuffer)
+	go p.events.run(p.subscription.Events())
+
+	if err := p.pushCenter.Start(); err != nil {
+		p.subscription.Unsubscribe()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// stop stops listening to the events.
+func (p *proxy) stop() {
+
+	if p.pushCenter == nil {
+		return
+	}
+
+	p.pushCenter.Stop()
+	p.subscription.Unsubscribe()
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.URL.Path != p.prefix && !strings.HasPrefix(r.URL.Path, p.prefix+"/") {
+		writeError(w, newError(http.StatusNotFound, "Not found", r.URL.Path))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, p.prefix), "/"), "/")
+
+	if len(parts) == 1 && parts[0] == "events" && r.Method == http.MethodGet {
+		if p.events == nil {
+			writeError(w, newError(http.StatusNotFound, "Not found", "the events are disabled"))
+			return
+		}
+		p.events.ServeHTTP(w, r)
+		return
+	}
+
+	if !p.limiter.wait(r.Context()) {
+		writeError(w, newError(http.StatusServiceUnavailable, "Request canceled", r.Context().Err().Error()))
+		return
+	}
+	defer p.limiter.done()
+
+	root := p.session.Root()
+
+	switch {
+
+	case len(parts) == 1 && parts[0] == root.Identity().Name:
+		p.serveRoot(w, r)
+
+	case len(parts) == 1 && parts[0] != "":
+		p.serveChildren(w, r, root, parts[0])
+
+	case len(parts) == 2:
+		p.serveObject(w, r, identityOf(parts[0]), parts[1])
+
+	case len(parts) == 3:
+		p.serveChildren(w, r, bambou.NewObject(identityOf(parts[0]), parts[1]), parts[2])
+
+	default:
+		writeError(w, newError(http.StatusNotFound, "Not found", r.URL.Path))
+	}
+}
+
+// serveRoot serves the root object, without the bambou.RedactedAttributes of the
+// proxy like its API key.
+func (p *proxy) serveRoot(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		writeError(w, newError(http.StatusMethodNotAllowed, "Method not allowed", r.Method))
+		return
+	}
+
+	attributes := map[string]interface{}{}
+	if root, ok := p.session.Root().(*bambou.RootObject); ok {
+		for name, value := range root.Attributes {
+			if !bambou.IsRedactedAttribute(name) {
+				attributes[name] = value
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, []interface{}{attributes})
+}
+
+// serveObject serves the requests of the object with the given identity and ID.
+func (p *proxy) serveObject(w http.ResponseWriter, r *http.Request, identity bambou.Identity, ID string) {
+
+	object := bambou.NewObject(identity, ID)
+
+	var berr *bambou.Error
+	switch r.Method {
+
+	case http.MethodGet:
+		berr = p.session.FetchEntity(object)
+
+	case http.MethodPut:
+		if !readBody(w, r, object) {
+			return
+		}
+		object.SetIdentifier(ID)
+		berr = p.session.SaveEntity(object)
+
+	case http.MethodDelete:
+		if berr = p.session.DeleteEntity(object); berr == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+	default:
+		berr = newError(http.StatusMethodNotAllowed, "Method not allowed", r.Method)
+	}
+
+	if berr != nil {
+		writeError(w, berr)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, []interface{}{object})
+}
+
+// serveChildren serves the requests of the children of the given category of the given parent.
+func (p *proxy) serveChildren(w http.ResponseWriter, r *http.Request, parent bambou.Identifiable, category string) {
+
+	identity := identityOf(category)
+
+	switch r.Method {
+
+	case http.MethodGet:
+		p.serveList(w, r, parent, identity)
+
+	case http.MethodPost:
+		child := bambou.NewObject(identity, "")
+		if !readBody(w, r, child) {
+			return
+		}
+		if berr := p.session.CreateChild(parent, child); berr != nil {
+			writeError(w, berr)
+			return
+		}
+		writeJSON(w, http.StatusCreated, []interface{}{child})
+
+	case http.MethodPut:
+		var IDs []string
+		if !readBody(w, r, &IDs) {
+			return
+		}
+		children := make([]bambou.Identifiable, 0, len(IDs))
+		for _, ID := range IDs {
+			children = append(children, bambou.NewObject(identity, ID))
+		}
+		if berr := p.session.AssignChildren(parent, children, identity); berr != nil {
+			writeError(w, berr)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, newError(http.StatusMethodNotAllowed, "Method not allowed", r.Method))
+	}
+}
+
+// serveList serves the children of the given identity of the given parent, with
+// the filter, order and page of the request.
+func (p *proxy) serveList(w http.ResponseWriter, r *http.Request, parent bambou.Identifiable, identity bambou.Identity) {
+
+	info := fetchingInfoOf(r)
+
+	objects, berr := bambou.FetchObjects(p.session, parent, identity, info)
+	if berr != nil {
+		writeError(w, berr)
+		return
+	}
+
+	w.Header().Set("X-Nuage-Count", strconv.Itoa(info.TotalCount))
+	w.Header().Set("X-Nuage-Page", strconv.Itoa(info.Page))
+	w.Header().Set("X-Nuage-PageSize", strconv.Itoa(info.PageSize))
+
+	if len(objects) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, objects)
+}
+
+// fetchingInfoOf returns the FetchingInfo of the headers of the given request.
+func fetchingInfoOf(r *http.Request) *bambou.FetchingInfo {
+
+	info := bambou.NewFetchingInfo()
+	info.Filter = r.Header.Get("X-Nuage-Filter")
+	info.OrderBy = r.Header.Get("X-Nuage-OrderBy")
+
+	if page, err := strconv.Atoi(r.Header.Get("X-Nuage-Page")); err == nil {
+		info.Page = page
+	}
+
+	if pageSize, err := strconv.Atoi(r.Header.Get("X-Nuage-PageSize")); err == nil {
+		info.PageSize = pageSize
+	}
+
+	if r.Header.Get("X-Nuage-GroupBy") == "true" {
+		for _, attribute := range strings.Split(r.Header.Get("X-Nuage-Attributes"), ",") {
+			info.GroupBy = append(info.GroupBy, strings.TrimSpace(attribute))
+		}
+	}
+
+	return info
+}
+
+// identityOf returns the Identity with the given category. The categories not
+// registered with bambou.RegisterIdentity are naively singularized to get their name.
+func identityOf(category string) bambou.Identity {
+
+	if identity, ok := bambou.IdentityFromCategory(category); ok {
+		return identity
+	}
+
+	if strings.HasSuffix(category, "ies") {
+		return bambou.Identity{Name: strings.TrimSuffix(category, "ies") + "y", Category: category}
+	}
+
+	return bambou.Identity{Name: strings.TrimSuffix(category, "s"), Category: category}
+}
+
+// readBody decodes the JSON body of the given request into the given value.
+// It writes an error and returns false if the body is invalid.
+func readBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, newError(http.StatusBadRequest, "Invalid body", err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// newError returns a new *bambou.Error with the given status code.
+func newError(code int, title, description string) *bambou.Error {
+
+	return &bambou.Error{Title: title, Description: description, Code: code}
+}
+
+// writeError writes the given error like the VSD does. The errors that are not
+// caused by a response of the server are reported as a bad gateway.
+func writeError(w http.ResponseWriter, berr *bambou.Error) {
+
+	code := berr.Code
+	switch {
+	case berr == bambou.ErrReadOnly:
+		code = http.StatusForbidden
+	case code == 0:
+		code = http.StatusBadGateway
+	}
+
+	writeJSON(w, code, bambou.VsdErrorList{
+		VsdErrors: []bambou.VsdError{{Descriptions: []bambou.Error{*berr}}},
+	})
+}
+
+// writeJSON writes the given value as JSON with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var enterpriseIdentity = bambou.Identity{Name: "enterprise", Category: "enterprises"}
+
+// startProxy starts a proxy of the given server with the given options, and returns
+// a started session of a client of the proxy, and a function stopping everything.
+func startProxy(server *fakevsd.Server, opts *options) (*bambou.Session, func()) {
+
+	opts.URL = server.URL
+	opts.Username, opts.Password, opts.Organization = "admin", "secret", "csp"
+	opts.Root = "me"
+
+	session, err := start(opts)
+	So(err, ShouldBeNil)
+
+	p, err := newProxy(session, opts)
+	So(err, ShouldBeNil)
+
+	s := httptest.NewServer(p)
+
+	client := bambou.NewSession("anyone", "anything", "any", s.URL, bambou.NewRootObject(bambou.Identity{Name: "me", Category: "me"}))
+	So(client.Start(), ShouldBeNil)
+
+	return client, func() {
+		s.Close()
+		p.stop()
+	}
+}
+
+func TestProxy_Requests(t *testing.T) {
+
+	Convey("Given I have a proxy of a server", t, func() {
+
+		server := fakevsd.New("admin", "secret", "csp")
+		defer server.Close()
+
+		ID := server.Add("", "enterprises", map[string]interface{}{"name": "first"})
+		server.Add("", "enterprises", map[string]interface{}{"name": "second"})
+		userID := server.Add("", "users", map[string]interface{}{"userName": "user"})
+
+		client, stop := startProxy(server, &options{cacheTTL: time.Minute, cacheSize: 100})
+		defer stop()
+
+		Convey("Then the root object should not have the API key of the proxy", func() {
+			root := client.Root().(*bambou.RootObject)
+			So(root.Get("userName"), ShouldEqual, "admin")
+			So(root.APIKey(), ShouldBeEmpty)
+		})
+
+		Convey("When I fetch an object", func() {
+			object := bambou.NewObject(enterpriseIdentity, ID)
+			err := client.FetchEntity(object)
+
+			Convey("Then it should be fetched from the server", func() {
+				So(err, ShouldBeNil)
+				So(object.Get("name"), ShouldEqual, "first")
+			})
+		})
+
+		Convey("When I list objects with a filter", func() {
+			info := bambou.NewFetchingInfo()
+			info.Filter = "name == 'second'"
+			objects, err := bambou.FetchObjects(client, client.Root(), enterpriseIdentity, info)
+
+			Convey("Then the filtered objects should be listed", func() {
+				So(err, ShouldBeNil)
+				So(len(objects), ShouldEqual, 1)
+				So(objects[0].Get("name"), ShouldEqual, "second")
+				So(info.TotalCount, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When I create, update and delete an object", func() {
+			object := bambou.NewObject(enterpriseIdentity, "")
+			object.Set("name", "third")
+			So(client.CreateChild(client.Root(), object), ShouldBeNil)
+
+			object.Set("description", "the third")
+			So(client.SaveEntity(object), ShouldBeNil)
+			updated := server.Get(object.Identifier())
+
+			So(client.DeleteEntity(object), ShouldBeNil)
+
+			Convey("Then the changes should be made on the server", func() {
+				So(updated["description"], ShouldEqual, "the third")
+				So(server.Get(object.Identifier()), ShouldBeNil)
+				So(server.Count("enterprises"), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When I create a child of an object", func() {
+			child := bambou.NewObject(bambou.Identity{Name: "domain", Category: "domains"}, "")
+			child.Set("name", "domain")
+			err := client.CreateChild(bambou.NewObject(enterpriseIdentity, ID), child)
+
+			Convey("Then it should be created under the object", func() {
+				So(err, ShouldBeNil)
+				So(server.Get(child.Identifier())["parentID"], ShouldEqual, ID)
+			})
+		})
+
+		Convey("When I assign objects", func() {
+			users := bambou.Identity{Name: "user", Category: "users"}
+			err := client.AssignChildren(bambou.NewObject(enterpriseIdentity, ID), []bambou.Identifiable{bambou.NewObject(users, userID)}, users)
+
+			Convey("Then they should be assigned on the server", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When I fetch a missing object", func() {
+			err := client.FetchEntity(bambou.NewObject(enterpriseIdentity, "missing"))
+
+			Convey("Then the error of the server should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		Convey("When an object is changed on the server without event", func() {
+			object := bambou.NewObject(enterpriseIdentity, ID)
+			So(client.FetchEntity(object), ShouldBeNil)
+			server.Add("", "enterprises", map[string]interface{}{"ID": ID, "name": "changed"})
+
+			Convey("Then the cached object should be returned", func() {
+				So(client.FetchEntity(object), ShouldBeNil)
+				So(object.Get("name"), ShouldEqual, "first")
+			})
+		})
+	})
+}
+
+func TestProxy_Events(t *testing.T) {
+
+	Convey("Given I have a proxy of a server listening to its events", t, func() {
+
+		server := fakevsd.New("admin", "secret", "csp")
+		server.SetEventTimeout(50 * time.Millisecond)
+		defer server.Close()
+
+		ID := server.Add("", "enterprises", map[string]interface{}{"name": "first"})
+
+		client, stop := startProxy(server, &options{cacheTTL: time.Minute, cacheSize: 100, events: true, eventBuffer: 10, eventTimeout: 2 * time.Second})
+		defer stop()
+
+		object := bambou.NewObject(enterpriseIdentity, ID)
+		So(client.FetchEntity(object), ShouldBeNil)
+
+		other := bambou.NewSession("admin", "secret", "csp", server.URL, bambou.NewRootObject(bambou.Identity{Name: "me", Category: "me"}))
+		So(other.Start(), ShouldBeNil)
+
+		// changeObject changes the object with another client of the server, once the
+		// proxy listens to the events, and returns the next notification served by the proxy.
+		// The object is changed again when the proxy was not listening yet and missed the event.
+		changeObject := func() (*bambou.Notification, *bambou.Error) {
+
+			for attempt := 1; ; attempt++ {
+
+				channel := make(bambou.NotificationsChannel, 1)
+				errs := make(chan *bambou.Error, 1)
+				go func() { errs <- client.NextEvent(channel, "") }()
+
+				// Wait for the proxy to listen to the events.
+				time.Sleep(100 * time.Millisecond)
+
+				changed := bambou.NewObject(enterpriseIdentity, ID)
+				changed.Set("name", "changed")
+				So(other.SaveEntity(changed), ShouldBeNil)
+
+				if err := <-errs; err != nil {
+					return nil, err
+				}
+
+				// NextEvent sends the notification before returning, if it was not empty.
+				select {
+				case notification := <-channel:
+					return notification, nil
+				default:
+				}
+
+				if attempt == 5 {
+					return nil, bambou.NewBambouError("No event", "the proxy did not serve the change of the object")
+				}
+			}
+		}
+
+		Convey("When an object is changed by another client of the server", func() {
+			notification, err := changeObject()
+
+			Convey("Then the event should be served by the proxy", func() {
+				So(err, ShouldBeNil)
+				So(len(notification.Events), ShouldEqual, 1)
+				So(notification.Events[0].Type, ShouldEqual, bambou.EventTypeUpdate)
+				So(notification.Events[0].DataMap[0]["name"], ShouldEqual, "changed")
+			})
+
+			Convey("Then the cached object should be invalidated", func() {
+				So(err, ShouldBeNil)
+				So(client.FetchEntity(object), ShouldBeNil)
+				So(object.Get("name"), ShouldEqual, "changed")
+			})
+		})
+	})
+}
+
+func TestProxy_ReadOnly(t *testing.T) {
+
+	Convey("Given I have a read only proxy", t, func() {
+
+		server := fakevsd.New("admin", "secret", "csp")
+		defer server.Close()
+
+		client, stop := startProxy(server, &options{readOnly: true})
+		defer stop()
+
+		Convey("When I create an object", func() {
+			object := bambou.NewObject(enterpriseIdentity, "")
+			err := client.CreateChild(client.Root(), object)
+
+			Convey("Then it should be forbidden", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Code, ShouldEqual, http.StatusForbidden)
+				So(server.Count("enterprises"), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestLimiter_Wait(t *testing.T) {
+
+	Convey("Given I have a limiter of the rate", t, func() {
+
+		l := newLimiter(50, 0)
+
+		Convey("When I send requests", func() {
+			start := time.Now()
+			for i := 0; i < 4; i++ {
+				So(l.wait(context.Background()), ShouldBeTrue)
+				l.done()
+			}
+
+			Convey("Then they should be spaced", func() {
+				So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 60*time.Millisecond)
+			})
+		})
+	})
+
+	Convey("Given I have a limiter of the concurrency", t, func() {
+
+		l := newLimiter(0, 1)
+		So(l.wait(context.Background()), ShouldBeTrue)
+
+		Convey("When I wait for another request until a context is done", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			Convey("Then it should not be allowed", func() {
+				So(l.wait(ctx), ShouldBeFalse)
+			})
+		})
+
+		Convey("When the first request is done", func() {
+			l.done()
+
+			Convey("Then another one should be allowed", func() {
+				So(l.wait(context.Background()), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestProxy_parseOptions(t *testing.T) {
+
+	Convey("Given the password is in the environment", t, func() {
+
+		os.Setenv("BAMBOU_PASSWORD", "secret")
+		defer os.Unsetenv("BAMBOU_PASSWORD")
+
+		Convey("When I parse the flags", func() {
+			stderr := &bytes.Buffer{}
+			opts, _ := parseOptions([]string{"--url", "https://vsd"}, stderr)
+			help, code := parseOptions([]string{"--help"}, stderr)
+			missing, usage := parseOptions(nil, stderr)
+
+			Convey("Then the password should be used but never printed", func() {
+				So(opts.Password, ShouldEqual, "secret")
+				So(help, ShouldBeNil)
+				So(code, ShouldEqual, exitOK)
+				So(missing, ShouldBeNil)
+				So(usage, ShouldEqual, exitUsage)
+				So(stderr.String(), ShouldContainSubstring, "BAMBOU_PASSWORD")
+				So(stderr.String(), ShouldNotContainSubstring, "secret")
+			})
+		})
+	})
+}
@@ -0,0 +1,365 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Command bambou manipulates the entities of a ReST API served by a VSD
+// compatible server, without any generated model.
+//
+// Usage:
+//
+//	bambou [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	get <name> <ID>                     fetch an entity
+//	list <name> [flags]                 list entities
+//	create <name> [flags] [attr=value]  create an entity
+//	update <name> <ID> [attr=value]     update an entity
+//	delete <name> <ID>                  delete an entity
+//
+// The entities are designated by their ReST name, like enterprise. The names
+// not registered with bambou.RegisterIdentity are naively pluralized to get
+// their category. The attribute values are decoded as JSON when possible,
+// and used as strings otherwise.
+//
+// The output is printed as JSON, YAML, CSV or as a table with the --output flag,
+// and --query selects a part of it using a subset of the JMESPath syntax,
+// for instance:
+//
+//	bambou --output csv --query "[?name != 'csp'].{id: ID, name: name}" list enterprise
+//
+// The connection flags default to the BAMBOU_URL, BAMBOU_USERNAME,
+// BAMBOU_PASSWORD, BAMBOU_ORGANIZATION, BAMBOU_CERT and BAMBOU_KEY
+// environment variables.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/cmd/internal/cli"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// options holds the global flags.
+type options struct {
+	cli.Connection
+	output  string
+	columns string
+	query   string
+}
+
+// usageError is returned for the invalid command lines.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+
+	return e.message
+}
+
+func newUsageError(format string, args ...interface{}) error {
+
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command line with the given arguments and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+
+	opts := &options{}
+
+	flags := flag.NewFlagSet("bambou", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	opts.AddFlags(flags)
+	flags.StringVar(&opts.output, "output", "json", "output format: json, yaml, csv or table")
+	flags.StringVar(&opts.columns, "columns", "", "comma separated attributes printed by the csv and table outputs")
+	flags.StringVar(&opts.query, "query", "", "JMESPath-style expression selecting what to print, like \"[*].{id: ID, name: name}\"")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: bambou [flags] get|list|create|update|delete <name> [arguments]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	opts.ReadPassword()
+
+	if err := execute(opts, flags.Args(), stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "bambou: %s\n", cli.ErrorMessage(err))
+		if _, ok := err.(*usageError); ok {
+			flags.Usage()
+			return exitUsage
+		}
+		return exitError
+	}
+
+	return exitOK
+}
+
+// execute executes the command with the given arguments.
+func execute(opts *options, args []string, stdout, stderr io.Writer) error {
+
+	if len(args) < 2 {
+		return newUsageError("missing command or entity name")
+	}
+
+	command, identity, args := args[0], identityOf(args[1]), args[2:]
+
+	printer, err := newPrinter(opts)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+
+	case "get", "update", "delete":
+		if len(args) < 1 {
+			return newUsageError("missing ID of the %s", identity.Name)
+		}
+		if command != "update" && len(args) > 1 {
+			return newUsageError("unexpected arguments: %s", strings.Join(args[1:], " "))
+		}
+		if command == "get" {
+			return get(opts, identity, args[0], printer, stdout)
+		}
+		if command == "update" {
+			return update(opts, identity, args[0], args[1:], printer, stdout)
+		}
+		return remove(opts, identity, args[0])
+
+	case "list":
+		return list(opts, identity, args, printer, stdout, stderr)
+
+	case "create":
+		return create(opts, identity, args, printer, stdout, stderr)
+
+	default:
+		return newUsageError("unknown command %s", command)
+	}
+}
+
+// get prints the entity with the given identity and ID.
+func get(opts *options, identity bambou.Identity, ID string, printer *printer, stdout io.Writer) error {
+
+	session, err := start(opts)
+	if err != nil {
+		return err
+	}
+
+	object := bambou.NewObject(identity, ID)
+	if berr := session.FetchEntity(object); berr != nil {
+		return berr
+	}
+
+	return printer.print(stdout, object)
+}
+
+// list prints the entities with the given identity.
+func list(opts *options, identity bambou.Identity, args []string, printer *printer, stdout, stderr io.Writer) error {
+
+	info := bambou.NewFetchingInfo()
+
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	parent := flags.String("parent", "", "parent of the entities, as name/ID")
+	flags.StringVar(&info.Filter, "filter", "", "filter of the entities, like \"name == 'x'\"")
+	flags.StringVar(&info.OrderBy, "order-by", "", "attribute to order the entities by")
+	flags.IntVar(&info.Page, "page", -1, "page to fetch")
+	flags.IntVar(&info.PageSize, "page-size", -1, "size of the page to fetch")
+
+	if err := flags.Parse(args); err != nil {
+		return newUsageError("%s", err)
+	}
+	if flags.NArg() > 0 {
+		return newUsageError("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	session, err := start(opts)
+	if err != nil {
+		return err
+	}
+
+	objects, berr := bambou.FetchObjects(session, parentOf(session, *parent), identity, info)
+	if berr != nil {
+		return berr
+	}
+
+	if objects == nil {
+		objects = []*bambou.Object{}
+	}
+
+	return printer.print(stdout, objects)
+}
+
+// create creates and prints an entity with the given identity.
+func create(opts *options, identity bambou.Identity, args []string, printer *printer, stdout, stderr io.Writer) error {
+
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	parent := flags.String("parent", "", "parent of the entity, as name/ID")
+	data := flags.String("data", "", "JSON attributes of the entity")
+
+	if err := flags.Parse(args); err != nil {
+		return newUsageError("%s", err)
+	}
+
+	object := bambou.NewObject(identity, "")
+	if *data != "" {
+		if err := json.Unmarshal([]byte(*data), object); err != nil {
+			return fmt.Errorf("invalid data: %s", err)
+		}
+	}
+	if err := setAttributes(object, flags.Args()); err != nil {
+		return err
+	}
+
+	session, err := start(opts)
+	if err != nil {
+		return err
+	}
+
+	if berr := session.CreateChild(parentOf(session, *parent), object); berr != nil {
+		return berr
+	}
+
+	return printer.print(stdout, object)
+}
+
+// update updates and prints the entity with the given identity and ID.
+func update(opts *options, identity bambou.Identity, ID string, args []string, printer *printer, stdout io.Writer) error {
+
+	if len(args) == 0 {
+		return newUsageError("missing attributes to update")
+	}
+
+	object := bambou.NewObject(identity, ID)
+	if err := setAttributes(object, args); err != nil {
+		return err
+	}
+
+	session, err := start(opts)
+	if err != nil {
+		return err
+	}
+
+	if berr := session.SaveEntity(object); berr != nil {
+		return berr
+	}
+
+	return printer.print(stdout, object)
+}
+
+// remove deletes the entity with the given identity and ID.
+func remove(opts *options, identity bambou.Identity, ID string) error {
+
+	session, err := start(opts)
+	if err != nil {
+		return err
+	}
+
+	if berr := session.DeleteEntity(bambou.NewObject(identity, ID)); berr != nil {
+		return berr
+	}
+
+	return nil
+}
+
+// start returns a new started session using the given options.
+func start(opts *options) (*bambou.Session, error) {
+
+	if opts.URL == "" {
+		return nil, newUsageError("missing URL")
+	}
+
+	return opts.Start()
+}
+
+// parentOf returns the parent designated by the given name/ID,
+// or the root object of the session if it is empty.
+func parentOf(session *bambou.Session, parent string) bambou.Identifiable {
+
+	if parent == "" {
+		return session.Root()
+	}
+
+	parts := strings.SplitN(parent, "/", 2)
+	if len(parts) != 2 {
+		return bambou.NewObject(identityOf(parts[0]), "")
+	}
+
+	return bambou.NewObject(identityOf(parts[0]), parts[1])
+}
+
+// identityOf returns the Identity with the given ReST name.
+func identityOf(name string) bambou.Identity {
+
+	if identity, ok := bambou.IdentityFromName(name); ok {
+		return identity
+	}
+
+	if strings.HasSuffix(name, "y") {
+		return bambou.Identity{Name: name, Category: strings.TrimSuffix(name, "y") + "ies"}
+	}
+
+	return bambou.Identity{Name: name, Category: name + "s"}
+}
+
+// setAttributes sets the given attr=value attributes to the given object.
+func setAttributes(object *bambou.Object, args []string) error {
+
+	for _, arg := range args {
+
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return newUsageError("invalid attribute %s, expected attr=value", arg)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+
+		object.Set(parts[0], value)
+	}
+
+	return nil
+}
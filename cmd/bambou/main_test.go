@@ -0,0 +1,257 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// runCommand runs the command line with the given arguments against the given server.
+func runCommand(server *fakevsd.Server, args ...string) (int, string, string) {
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	global := []string{"--url", server.URL, "--username", "admin", "--password", "secret", "--organization", "csp"}
+	code := run(append(global, args...), stdout, stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestMain_Commands(t *testing.T) {
+
+	Convey("Given I have a server with enterprises", t, func() {
+
+		server := fakevsd.New("admin", "secret", "csp")
+		defer server.Close()
+
+		ID := server.Add("", "enterprises", map[string]interface{}{"name": "first", "description": "the first"})
+		server.Add("", "enterprises", map[string]interface{}{"name": "second"})
+		server.Add(ID, "domains", map[string]interface{}{"name": "domain"})
+
+		Convey("When I get an enterprise", func() {
+
+			code, stdout, _ := runCommand(server, "get", "enterprise", ID)
+
+			var attributes map[string]interface{}
+			json.Unmarshal([]byte(stdout), &attributes)
+
+			Convey("Then it should be printed as JSON", func() {
+				So(code, ShouldEqual, exitOK)
+				So(attributes["ID"], ShouldEqual, ID)
+				So(attributes["name"], ShouldEqual, "first")
+			})
+		})
+
+		Convey("When I list the enterprises with a filter", func() {
+
+			code, stdout, _ := runCommand(server, "list", "enterprise", "--filter", "name == 'second'")
+
+			var list []map[string]interface{}
+			json.Unmarshal([]byte(stdout), &list)
+
+			Convey("Then I should get the matching ones", func() {
+				So(code, ShouldEqual, exitOK)
+				So(len(list), ShouldEqual, 1)
+				So(list[0]["name"], ShouldEqual, "second")
+			})
+		})
+
+		Convey("When I list a page of the enterprises as a table", func() {
+
+			code, stdout, _ := runCommand(server, "--output", "table", "list", "enterprise", "--order-by", "name", "--page", "0", "--page-size", "1")
+
+			Convey("Then I should get a table of the page", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout, ShouldStartWith, "ID")
+				So(stdout, ShouldContainSubstring, "first")
+				So(stdout, ShouldContainSubstring, "the first")
+				So(stdout, ShouldNotContainSubstring, "second")
+			})
+		})
+
+		Convey("When I list the children of an enterprise as YAML", func() {
+
+			code, stdout, _ := runCommand(server, "--output", "yaml", "list", "domain", "--parent", "enterprise/"+ID)
+
+			Convey("Then I should get them", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout, ShouldStartWith, "- ")
+				So(stdout, ShouldContainSubstring, "name: domain")
+			})
+		})
+
+		Convey("When I list the enterprises as CSV", func() {
+
+			code, stdout, _ := runCommand(server, "--output", "csv", "--columns", "name,description", "list", "enterprise", "--order-by", "name")
+
+			Convey("Then I should get the columns", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout, ShouldEqual, "name,description\nfirst,the first\nsecond,\n")
+			})
+		})
+
+		Convey("When I query the enterprises", func() {
+
+			code, stdout, _ := runCommand(server, "--output", "csv", "--query", "[?name == 'second'].{id: ID, name: name}", "list", "enterprise")
+
+			Convey("Then I should get the selection", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout, ShouldStartWith, "id,name\n")
+				So(stdout, ShouldEndWith, ",second\n")
+				So(stdout, ShouldNotContainSubstring, "first")
+			})
+		})
+
+		Convey("When I query the names of the enterprises as a table", func() {
+
+			code, stdout, _ := runCommand(server, "--output", "table", "--query", "[*].name", "list", "enterprise", "--order-by", "name")
+
+			Convey("Then I should get a column of the values", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout, ShouldEqual, "value\nfirst\nsecond\n")
+			})
+		})
+
+		Convey("When I create an enterprise", func() {
+
+			code, stdout, _ := runCommand(server, "create", "enterprise", "--data", `{"description": "new"}`, "name=third", "count=2")
+
+			var attributes map[string]interface{}
+			json.Unmarshal([]byte(stdout), &attributes)
+
+			Convey("Then it should be created", func() {
+				So(code, ShouldEqual, exitOK)
+				So(server.Count("enterprises"), ShouldEqual, 3)
+				So(attributes["name"], ShouldEqual, "third")
+				So(attributes["count"], ShouldEqual, 2)
+				So(server.Get(attributes["ID"].(string))["description"], ShouldEqual, "new")
+			})
+		})
+
+		Convey("When I update an enterprise", func() {
+
+			code, _, _ := runCommand(server, "update", "enterprise", ID, "description=updated")
+
+			Convey("Then it should be updated", func() {
+				So(code, ShouldEqual, exitOK)
+				So(server.Get(ID)["description"], ShouldEqual, "updated")
+				So(server.Get(ID)["name"], ShouldEqual, "first")
+			})
+		})
+
+		Convey("When I delete an enterprise", func() {
+
+			code, _, _ := runCommand(server, "delete", "enterprise", ID)
+
+			Convey("Then it should be deleted", func() {
+				So(code, ShouldEqual, exitOK)
+				So(server.Get(ID), ShouldBeNil)
+			})
+		})
+
+		Convey("When I get an enterprise that does not exist", func() {
+
+			code, _, stderr := runCommand(server, "get", "enterprise", "nope")
+
+			Convey("Then it should fail", func() {
+				So(code, ShouldEqual, exitError)
+				So(stderr, ShouldStartWith, "bambou: ")
+			})
+		})
+
+		Convey("When I use invalid credentials", func() {
+
+			stderr := &bytes.Buffer{}
+			code := run([]string{"--url", server.URL, "--username", "admin", "--password", "wrong", "get", "enterprise", ID}, &bytes.Buffer{}, stderr)
+
+			Convey("Then it should fail", func() {
+				So(code, ShouldEqual, exitError)
+			})
+		})
+
+		Convey("When the password is in the environment", func() {
+
+			os.Setenv("BAMBOU_PASSWORD", "secret")
+			defer os.Unsetenv("BAMBOU_PASSWORD")
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			code := run([]string{"--url", server.URL, "--username", "admin", "get", "enterprise", ID}, stdout, stderr)
+			usage := run([]string{"--help"}, &bytes.Buffer{}, stderr)
+
+			Convey("Then it should be used but never printed", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout.String(), ShouldContainSubstring, "first")
+				So(usage, ShouldEqual, exitOK)
+				So(stderr.String(), ShouldContainSubstring, "BAMBOU_PASSWORD")
+				So(stderr.String(), ShouldNotContainSubstring, "secret")
+			})
+		})
+	})
+}
+
+func TestMain_Usage(t *testing.T) {
+
+	Convey("Given I have a server", t, func() {
+
+		server := fakevsd.New("admin", "secret", "csp")
+		defer server.Close()
+
+		Convey("When I run invalid command lines", func() {
+
+			missing, _, stderr := runCommand(server, "get")
+			unknown, _, _ := runCommand(server, "fetch", "enterprise")
+			noID, _, _ := runCommand(server, "delete", "enterprise")
+			output, _, _ := runCommand(server, "--output", "xml", "list", "enterprise")
+			attribute, _, _ := runCommand(server, "update", "enterprise", "xxx", "name")
+			query, _, _ := runCommand(server, "--query", "[?", "list", "enterprise")
+
+			Convey("Then they should be rejected", func() {
+				So(missing, ShouldEqual, exitUsage)
+				So(strings.Contains(stderr, "usage: bambou"), ShouldBeTrue)
+				So(unknown, ShouldEqual, exitUsage)
+				So(noID, ShouldEqual, exitUsage)
+				So(output, ShouldEqual, exitUsage)
+				So(attribute, ShouldEqual, exitUsage)
+				So(query, ShouldEqual, exitUsage)
+			})
+		})
+	})
+}
+
+func TestMain_identityOf(t *testing.T) {
+
+	Convey("Given I have unregistered ReST names", t, func() {
+
+		Convey("Then they should be pluralized", func() {
+			So(identityOf("enterprise").Category, ShouldEqual, "enterprises")
+			So(identityOf("policy").Category, ShouldEqual, "policies")
+		})
+	})
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// defaultColumns are the columns of the table output when none are given.
+var defaultColumns = []string{"ID", "name", "description"}
+
+// printer prints the objects in a given format, after applying the query if any.
+type printer struct {
+	output  string
+	columns []string
+	query   query
+}
+
+// newPrinter returns the printer of the given options.
+func newPrinter(opts *options) (*printer, error) {
+
+	p := &printer{output: opts.output}
+
+	switch opts.output {
+	case "json", "yaml", "csv", "table":
+	default:
+		return nil, newUsageError("unknown output %s", opts.output)
+	}
+
+	if opts.columns != "" {
+		p.columns = strings.Split(opts.columns, ",")
+	}
+
+	if opts.query != "" {
+		q, err := parseQuery(opts.query)
+		if err != nil {
+			return nil, newUsageError("invalid query: %s", err)
+		}
+		p.query = q
+	}
+
+	return p, nil
+}
+
+// print prints the given object or list of objects.
+func (p *printer) print(w io.Writer, v interface{}) error {
+
+	// The query and the outputs work on the plain JSON document.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	if p.query != nil {
+		document = p.query.search(document)
+	}
+
+	switch p.output {
+	case "yaml":
+		return p.printYAML(w, document)
+	case "csv":
+		return p.printCSV(w, document)
+	case "table":
+		return p.printTable(w, document)
+	default:
+		return p.printJSON(w, document)
+	}
+}
+
+func (p *printer) printJSON(w io.Writer, document interface{}) error {
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(document)
+}
+
+func (p *printer) printYAML(w io.Writer, document interface{}) error {
+
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+// printCSV prints the given document as CSV. All the attributes are printed
+// unless columns are given.
+func (p *printer) printCSV(w io.Writer, document interface{}) error {
+
+	return bambou.NewCSVExporter(p.columns...).Export(w, rowsOf(document))
+}
+
+// printTable prints the given document as a table. The default columns are
+// printed unless columns are given, or all the attributes if there is a query.
+func (p *printer) printTable(w io.Writer, document interface{}) error {
+
+	rows := rowsOf(document)
+
+	columns := p.columns
+	if len(columns) == 0 && p.query == nil {
+		columns = defaultColumns
+	}
+	if len(columns) == 0 {
+		columns = columnsOf(rows)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = tableValue(row.Get(column))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// rowsOf returns the rows of the given document: the objects of a list, or the
+// object itself. The other values are in a single value column.
+func rowsOf(document interface{}) []*bambou.Object {
+
+	list, ok := document.([]interface{})
+	if !ok {
+		list = []interface{}{document}
+		if document == nil {
+			list = nil
+		}
+	}
+
+	rows := make([]*bambou.Object, len(list))
+	for i, element := range list {
+
+		rows[i] = bambou.NewObject(bambou.Identity{}, "")
+		if attributes, ok := element.(map[string]interface{}); ok {
+			rows[i].Attributes = attributes
+		} else {
+			rows[i].Set("value", element)
+		}
+	}
+
+	return rows
+}
+
+// columnsOf returns the sorted names of the attributes of the given rows.
+func columnsOf(rows []*bambou.Object) []string {
+
+	names := map[string]struct{}{}
+	for _, row := range rows {
+		for name := range row.Attributes {
+			names[name] = struct{}{}
+		}
+	}
+
+	columns := make([]string, 0, len(names))
+	for name := range names {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	return columns
+}
+
+// tableValue returns the representation of the given value in a table.
+// Lists and objects are rendered as JSON.
+func tableValue(value interface{}) string {
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	data, _ := json.Marshal(value)
+
+	return string(data)
+}
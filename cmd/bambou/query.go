@@ -0,0 +1,634 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A query selects a part of the printed JSON document, using a subset of the
+// JMESPath syntax (http://jmespath.org):
+//
+//	name                        the name attribute of an object
+//	a.b                         sub expressions
+//	"weird name"                quoted attribute names
+//	[0], [-1]                   the elements of a list
+//	[*].name                    projections of a list
+//	[].name                     projections of a flattened list
+//	[?name == 'x'].ID           filtered projections, with ==, !=, <, <=, > and >=
+//	{id: ID, name: name}        multiselect objects
+//	[ID, name]                  multiselect lists
+//	@                           the current element
+//	expression | expression     pipes, that stop the projections
+//
+// The literals are raw strings like 'x', numbers, and JSON values like `true`.
+type query interface {
+	search(value interface{}) interface{}
+}
+
+// parseQuery parses the given query expression.
+func parseQuery(expression string) (query, error) {
+
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+
+	q, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", p.peek().text, p.peek().position)
+	}
+
+	return q, nil
+}
+
+// The kinds of tokens of a query.
+const (
+	tokenEOF = iota
+	tokenIdentifier
+	tokenRawString
+	tokenLiteral
+	tokenNumber
+	tokenOperator
+	tokenPunctuation
+)
+
+// token is a token of a query.
+type token struct {
+	kind     int
+	text     string
+	position int
+}
+
+// tokenize returns the tokens of the given query expression.
+func tokenize(expression string) ([]token, error) {
+
+	var tokens []token
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+
+		r := runes[i]
+		start := i
+
+		switch {
+
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: string(runes[start:i]), position: start})
+
+		case r == '-' || unicode.IsDigit(r):
+			i++
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), position: start})
+
+		case r == '"' || r == '\'' || r == '`':
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated %c at position %d", r, start)
+			}
+			i++
+
+			text := string(runes[start+1 : i-1])
+			switch r {
+			case '"':
+				name, err := strconv.Unquote(string(runes[start:i]))
+				if err != nil {
+					return nil, fmt.Errorf("invalid quoted name at position %d", start)
+				}
+				tokens = append(tokens, token{kind: tokenIdentifier, text: name, position: start})
+			case '\'':
+				tokens = append(tokens, token{kind: tokenRawString, text: strings.Replace(text, `\'`, `'`, -1), position: start})
+			default:
+				tokens = append(tokens, token{kind: tokenLiteral, text: strings.Replace(text, "\\`", "`", -1), position: start})
+			}
+
+		case strings.ContainsRune("=!<>", r):
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			operator := string(runes[start:i])
+			if operator == "=" || operator == "!" {
+				return nil, fmt.Errorf("invalid operator %s at position %d", operator, start)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, position: start})
+
+		case strings.ContainsRune(".[]{}(),:|*?@", r):
+			i++
+			tokens = append(tokens, token{kind: tokenPunctuation, text: string(r), position: start})
+
+		default:
+			return nil, fmt.Errorf("unexpected %c at position %d", r, start)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of query", position: len(runes)}), nil
+}
+
+// queryParser parses a list of tokens.
+type queryParser struct {
+	tokens []token
+	index  int
+}
+
+func (p *queryParser) peek() token {
+
+	return p.tokens[p.index]
+}
+
+func (p *queryParser) next() token {
+
+	t := p.tokens[p.index]
+	if t.kind != tokenEOF {
+		p.index++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is the given punctuation.
+func (p *queryParser) accept(punctuation string) bool {
+
+	if t := p.peek(); t.kind == tokenPunctuation && t.text == punctuation {
+		p.index++
+		return true
+	}
+
+	return false
+}
+
+func (p *queryParser) expect(punctuation string) error {
+
+	if !p.accept(punctuation) {
+		return fmt.Errorf("expected %s instead of %s at position %d", punctuation, p.peek().text, p.peek().position)
+	}
+
+	return nil
+}
+
+// parseExpression parses chains separated by pipes.
+func (p *queryParser) parseExpression() (query, error) {
+
+	q, err := p.parseChain(false)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("|") {
+
+		right, err := p.parseChain(false)
+		if err != nil {
+			return nil, err
+		}
+
+		q = subQuery{left: q, right: right}
+	}
+
+	return q, nil
+}
+
+// parseChain parses a sequence of steps. A projection applies the rest of the
+// chain to each element, up to a flatten that applies to the projected list.
+// The chain of a projection can be empty.
+func (p *queryParser) parseChain(projected bool) (query, error) {
+
+	var q query = currentQuery{}
+
+	for steps := 0; ; steps++ {
+
+		t := p.peek()
+
+		if t.kind == tokenIdentifier {
+			if steps > 0 {
+				return nil, fmt.Errorf("unexpected %s at position %d", t.text, t.position)
+			}
+			p.next()
+			q = fieldQuery{name: t.text}
+			continue
+		}
+
+		if t.kind != tokenPunctuation {
+			if steps == 0 && !projected {
+				return nil, fmt.Errorf("unexpected %s at position %d", t.text, t.position)
+			}
+			return q, nil
+		}
+
+		switch t.text {
+
+		case "@":
+			p.next()
+
+		case ".":
+			p.next()
+			switch n := p.peek(); {
+			case n.kind == tokenIdentifier:
+				p.next()
+				q = subQuery{left: q, right: fieldQuery{name: n.text}}
+			case n.kind == tokenPunctuation && (n.text == "{" || n.text == "["):
+				step, err := p.parseMultiselect()
+				if err != nil {
+					return nil, err
+				}
+				q = subQuery{left: q, right: step}
+			default:
+				return nil, fmt.Errorf("expected a name instead of %s at position %d", n.text, n.position)
+			}
+
+		case "{":
+			step, err := p.parseMultiselect()
+			if err != nil {
+				return nil, err
+			}
+			q = subQuery{left: q, right: step}
+
+		case "[":
+			p.next()
+			n := p.peek()
+
+			if projected && n.kind == tokenPunctuation && n.text == "]" {
+				p.index--
+				return q, nil
+			}
+
+			switch {
+
+			case n.kind == tokenNumber:
+				p.next()
+				index, _ := strconv.Atoi(n.text)
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				q = subQuery{left: q, right: indexQuery{index: index}}
+
+			case n.kind == tokenPunctuation && (n.text == "*" || n.text == "]" || n.text == "?"):
+				projection := projectionQuery{left: q, flatten: n.text == "]"}
+				if n.text == "*" {
+					p.next()
+				}
+				if n.text == "?" {
+					p.next()
+					filter, err := p.parseComparison()
+					if err != nil {
+						return nil, err
+					}
+					projection.filter = filter
+				}
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				right, err := p.parseChain(true)
+				if err != nil {
+					return nil, err
+				}
+				projection.right = right
+				q = projection
+
+			default:
+				p.index--
+				step, err := p.parseMultiselect()
+				if err != nil {
+					return nil, err
+				}
+				q = subQuery{left: q, right: step}
+			}
+
+		default:
+			return q, nil
+		}
+	}
+}
+
+// parseMultiselect parses a multiselect object or list.
+func (p *queryParser) parseMultiselect() (query, error) {
+
+	if p.accept("[") {
+
+		var items []query
+		for {
+			item, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+
+			if !p.accept(",") {
+				break
+			}
+		}
+
+		return listQuery{items: items}, p.expect("]")
+	}
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	h := hashQuery{}
+	for {
+		key := p.next()
+		if key.kind != tokenIdentifier {
+			return nil, fmt.Errorf("expected a key instead of %s at position %d", key.text, key.position)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		h.keys = append(h.keys, key.text)
+		h.values = append(h.values, value)
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	return h, p.expect("}")
+}
+
+// parseComparison parses the comparison of a filter.
+func (p *queryParser) parseComparison() (*comparison, error) {
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := p.next()
+	if operator.kind != tokenOperator {
+		return nil, fmt.Errorf("expected a comparison instead of %s at position %d", operator.text, operator.position)
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return &comparison{left: left, operator: operator.text, right: right}, nil
+}
+
+// parseOperand parses an operand of a comparison.
+func (p *queryParser) parseOperand() (query, error) {
+
+	t := p.peek()
+
+	switch t.kind {
+
+	case tokenRawString:
+		p.next()
+		return literalQuery{value: t.text}, nil
+
+	case tokenNumber:
+		p.next()
+		value, _ := strconv.ParseFloat(t.text, 64)
+		return literalQuery{value: value}, nil
+
+	case tokenLiteral:
+		p.next()
+		var value interface{}
+		if err := json.Unmarshal([]byte(t.text), &value); err != nil {
+			return nil, fmt.Errorf("invalid literal at position %d: %s", t.position, err)
+		}
+		return literalQuery{value: value}, nil
+	}
+
+	return p.parseChain(false)
+}
+
+// currentQuery returns the current element.
+type currentQuery struct{}
+
+func (q currentQuery) search(value interface{}) interface{} {
+
+	return value
+}
+
+// literalQuery returns a constant.
+type literalQuery struct {
+	value interface{}
+}
+
+func (q literalQuery) search(value interface{}) interface{} {
+
+	return q.value
+}
+
+// fieldQuery returns an attribute of an object.
+type fieldQuery struct {
+	name string
+}
+
+func (q fieldQuery) search(value interface{}) interface{} {
+
+	if object, ok := value.(map[string]interface{}); ok {
+		return object[q.name]
+	}
+
+	return nil
+}
+
+// indexQuery returns an element of a list. Negative indexes start from the end.
+type indexQuery struct {
+	index int
+}
+
+func (q indexQuery) search(value interface{}) interface{} {
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	index := q.index
+	if index < 0 {
+		index += len(list)
+	}
+	if index < 0 || index >= len(list) {
+		return nil
+	}
+
+	return list[index]
+}
+
+// subQuery applies a query to the result of another one.
+type subQuery struct {
+	left  query
+	right query
+}
+
+func (q subQuery) search(value interface{}) interface{} {
+
+	if value = q.left.search(value); value == nil {
+		return nil
+	}
+
+	return q.right.search(value)
+}
+
+// projectionQuery applies a query to the elements of a list, dropping the null results.
+type projectionQuery struct {
+	left    query
+	right   query
+	flatten bool
+	filter  *comparison
+}
+
+func (q projectionQuery) search(value interface{}) interface{} {
+
+	list, ok := q.left.search(value).([]interface{})
+	if !ok {
+		return nil
+	}
+
+	if q.flatten {
+		var flattened []interface{}
+		for _, element := range list {
+			if l, ok := element.([]interface{}); ok {
+				flattened = append(flattened, l...)
+			} else {
+				flattened = append(flattened, element)
+			}
+		}
+		list = flattened
+	}
+
+	results := []interface{}{}
+	for _, element := range list {
+
+		if q.filter != nil && !q.filter.matches(element) {
+			continue
+		}
+
+		if result := q.right.search(element); result != nil {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// hashQuery builds an object.
+type hashQuery struct {
+	keys   []string
+	values []query
+}
+
+func (q hashQuery) search(value interface{}) interface{} {
+
+	if value == nil {
+		return nil
+	}
+
+	object := make(map[string]interface{}, len(q.keys))
+	for i, key := range q.keys {
+		object[key] = q.values[i].search(value)
+	}
+
+	return object
+}
+
+// listQuery builds a list.
+type listQuery struct {
+	items []query
+}
+
+func (q listQuery) search(value interface{}) interface{} {
+
+	if value == nil {
+		return nil
+	}
+
+	list := make([]interface{}, len(q.items))
+	for i, item := range q.items {
+		list[i] = item.search(value)
+	}
+
+	return list
+}
+
+// comparison is the condition of a filter.
+type comparison struct {
+	left     query
+	operator string
+	right    query
+}
+
+// matches returns true if the given element matches the comparison.
+func (c *comparison) matches(element interface{}) bool {
+
+	left, right := c.left.search(element), c.right.search(element)
+
+	switch c.operator {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return false
+	}
+
+	switch c.operator {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// search returns the result of the given query on the given JSON document.
+func search(expression string, document string) (interface{}, error) {
+
+	q, err := parseQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	json.Unmarshal([]byte(document), &value)
+
+	return q.search(value), nil
+}
+
+func TestQuery_search(t *testing.T) {
+
+	Convey("Given I have a list of objects", t, func() {
+
+		document := `[
+			{"ID": "a", "name": "first", "count": 1, "tags": ["x", "y"], "owner": {"name": "alice"}},
+			{"ID": "b", "name": "second", "count": 5, "tags": ["z"], "weird name": true},
+			{"ID": "c", "count": 10, "tags": []}
+		]`
+
+		Convey("Then the queries should select parts of it", func() {
+
+			tests := []struct {
+				expression string
+				expected   string
+			}{
+				{`[0].name`, `"first"`},
+				{`[-1].ID`, `"c"`},
+				{`[*].name`, `["first", "second"]`},
+				{`[0].owner.name`, `"alice"`},
+				{`[1]."weird name"`, `true`},
+				{`[*].tags[0]`, `["x", "z"]`},
+				{`[*].tags[]`, `["x", "y", "z"]`},
+				{`[?name == 'second'].ID`, `["b"]`},
+				{`[?count > ` + "`2`" + `].ID`, `["b", "c"]`},
+				{`[?count <= 5].ID`, `["a", "b"]`},
+				{`[?name != ` + "`null`" + ` ] | [0].ID`, `"a"`},
+				{`[*].{id: ID, n: count}`, `[{"id": "a", "n": 1}, {"id": "b", "n": 5}, {"id": "c", "n": 10}]`},
+				{`[0].[ID, name]`, `["a", "first"]`},
+				{`@[1].ID`, `"b"`},
+				{`[5]`, `null`},
+				{`name`, `null`},
+			}
+
+			for _, test := range tests {
+
+				result, err := search(test.expression, document)
+
+				var expected interface{}
+				json.Unmarshal([]byte(test.expected), &expected)
+
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, expected)
+			}
+		})
+	})
+}
+
+func TestQuery_parseQuery(t *testing.T) {
+
+	Convey("Given I have invalid queries", t, func() {
+
+		Convey("Then they should be rejected", func() {
+
+			for _, expression := range []string{``, `[`, `a b`, `[0`, `{a}`, `[?a = 'b']`, `'x`, `a.`, `a#`} {
+				_, err := parseQuery(expression)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
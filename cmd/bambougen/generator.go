@@ -0,0 +1,505 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// generatedFile is a file written by the generator.
+type generatedFile struct {
+	name    string
+	content []byte
+}
+
+// model is the description of a generated model.
+type model struct {
+	Package     string
+	Version     string
+	Name        string
+	Plural      string
+	RESTName    string
+	Category    string
+	Description string
+	Root        bool
+	Fields      []field
+	Children    []child
+}
+
+// field is a field of a generated model.
+type field struct {
+	Name string
+	Type string
+	JSON string
+}
+
+// child is a child of a generated model.
+type child struct {
+	Name   string
+	Plural string
+	Get    bool
+	Create bool
+	Assign bool
+}
+
+// registry is the description of the generated registry.
+type registry struct {
+	Package string
+	Version string
+	Root    string
+	Models  []*model
+}
+
+// reservedNames are the names of the methods of the models, that the fields cannot use.
+var reservedNames = map[string]bool{
+	"Identity":      true,
+	"Identifier":    true,
+	"SetIdentifier": true,
+	"APIKey":        true,
+	"SetAPIKey":     true,
+	"Fetch":         true,
+	"Save":          true,
+	"Delete":        true,
+}
+
+// generate returns the files of the models of the given specifications.
+func generate(set *bambou.SpecSet, pkg string) ([]*generatedFile, error) {
+
+	specs := set.Specs()
+
+	models := make([]*model, 0, len(specs))
+	for _, spec := range specs {
+
+		m, err := newModel(set, spec, pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		models = append(models, m)
+	}
+
+	r := &registry{Package: pkg, Version: set.Version, Models: models}
+
+	files := make([]*generatedFile, 0, len(models)+1)
+	for _, m := range models {
+
+		if m.Root {
+			if r.Root != "" {
+				return nil, fmt.Errorf("%s and %s are both root specifications", r.Root, m.Name)
+			}
+			r.Root = m.Name
+		}
+
+		content, err := render(modelTemplate, m)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", m.RESTName, err)
+		}
+
+		files = append(files, &generatedFile{name: strings.ToLower(m.Name) + ".go", content: content})
+	}
+
+	content, err := render(registryTemplate, r)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %s", err)
+	}
+
+	return append(files, &generatedFile{name: "registry.go", content: content}), nil
+}
+
+// newModel returns the model of the given specification.
+func newModel(set *bambou.SpecSet, spec *bambou.Spec, pkg string) (*model, error) {
+
+	m := &model{
+		Package:     pkg,
+		Version:     set.Version,
+		Name:        goName(spec.Model.EntityName),
+		RESTName:    spec.Model.RESTName,
+		Category:    spec.Model.ResourceName,
+		Description: spec.Model.Description,
+		Root:        spec.Model.Root,
+	}
+	m.Plural = plural(m.Name)
+
+	if m.Name == "" || m.Category == "" {
+		return nil, fmt.Errorf("%s: missing entity or resource name", spec.Model.RESTName)
+	}
+
+	names := map[string]string{}
+	claim := func(name string, owner string) error {
+		if reservedNames[name] {
+			return fmt.Errorf("%s: %s cannot be named %s", m.RESTName, owner, name)
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%s: %s and %s are both named %s", m.RESTName, other, owner, name)
+		}
+		names[name] = owner
+		return nil
+	}
+
+	if spec.Attribute("ID") == nil {
+		m.Fields = append(m.Fields, field{Name: "ID", Type: "string", JSON: "ID"})
+		names["ID"] = "attribute ID"
+	}
+
+	// The ID comes first, even when it is inherited from an abstract specification.
+	attributes := make([]*bambou.SpecAttribute, 0, len(spec.Attributes))
+	for _, attribute := range spec.Attributes {
+		if attribute.Name == "ID" {
+			attributes = append([]*bambou.SpecAttribute{attribute}, attributes...)
+		} else {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	for _, attribute := range attributes {
+
+		// The API key of the root is its Token field.
+		if m.Root && attribute.Name == "APIKey" {
+			continue
+		}
+
+		f := field{Name: goName(attribute.Name), Type: goType(attribute), JSON: attribute.Name}
+		if err := claim(f.Name, "attribute "+attribute.Name); err != nil {
+			return nil, err
+		}
+
+		m.Fields = append(m.Fields, f)
+	}
+
+	if m.Root {
+		if err := claim("Token", "API key"); err != nil {
+			return nil, err
+		}
+		m.Fields = append(m.Fields, field{Name: "Token", Type: "string", JSON: "APIKey"})
+	}
+
+	for _, c := range spec.Children {
+
+		childSpec := set.Spec(bambou.Identity{Name: c.RESTName})
+		if childSpec == nil {
+			return nil, fmt.Errorf("%s: unknown child %s", m.RESTName, c.RESTName)
+		}
+
+		name := goName(childSpec.Model.EntityName)
+		ch := child{
+			Name:   name,
+			Plural: plural(name),
+			Get:    c.Get,
+			Create: c.Create && c.Relationship != "member",
+			Assign: c.Update && c.Relationship == "member",
+		}
+
+		if ch.Get || ch.Assign {
+			if err := claim(ch.Plural, "child "+c.RESTName); err != nil {
+				return nil, err
+			}
+		}
+		if ch.Create {
+			if err := claim("Create"+ch.Name, "child "+c.RESTName); err != nil {
+				return nil, err
+			}
+		}
+		if ch.Assign {
+			if err := claim("Assign"+ch.Plural, "child "+c.RESTName); err != nil {
+				return nil, err
+			}
+		}
+
+		m.Children = append(m.Children, ch)
+	}
+
+	return m, nil
+}
+
+// render executes the given template with the given data, and formats the result.
+func render(t *template.Template, data interface{}) ([]byte, error) {
+
+	var buffer bytes.Buffer
+	if err := t.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buffer.Bytes())
+}
+
+// goType returns the Go type of the given attribute.
+func goType(attribute *bambou.SpecAttribute) string {
+
+	switch attribute.Type {
+	case "integer", "time":
+		return "bambou.Int"
+	case "float":
+		return "bambou.Float"
+	case "boolean":
+		return "bambou.Bool"
+	case "list":
+		if attribute.Subtype == "string" || attribute.Subtype == "enum" {
+			return "[]string"
+		}
+		return "[]interface{}"
+	case "object":
+		return "interface{}"
+	default:
+		return "string"
+	}
+}
+
+// goName returns the exported Go name of the given specification name,
+// without the characters that cannot be part of an identifier.
+func goName(name string) string {
+
+	var b strings.Builder
+	upper := true
+
+	for _, r := range name {
+
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	s := b.String()
+	if s != "" && unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+
+	return s
+}
+
+// plural returns the plural of the given entity name.
+func plural(name string) string {
+
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiouAEIOU", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+// packageName returns a valid package name from the given directory name.
+func packageName(dir string) string {
+
+	name := strings.ToLower(goName(dir))
+	if name == "" {
+		return "models"
+	}
+
+	return name
+}
+
+// comment returns the given text as a comment, wrapped at 80 columns.
+func comment(text string) string {
+
+	var lines []string
+	line := "//"
+
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > 80 && line != "//" {
+			lines = append(lines, line)
+			line = "//"
+		}
+		line += " " + word
+	}
+
+	return strings.Join(append(lines, line), "\n")
+}
+
+var functions = template.FuncMap{
+	"comment": comment,
+}
+
+var modelTemplate = template.Must(template.New("model").Funcs(functions).Parse(`// Code generated by bambougen{{if .Version}} from the {{.Version}} specifications{{end}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/nuagenetworks/go-bambou/bambou"
+
+// {{.Name}}Identity is the Identity of the {{.RESTName}} objects.
+var {{.Name}}Identity = bambou.Identity{
+	Name:     "{{.RESTName}}",
+	Category: "{{.Category}}",
+}
+
+// {{.Plural}}List is a list of {{.Plural}}.
+type {{.Plural}}List []*{{.Name}}
+
+// {{.Plural}}Ancestor is the interface implemented by the parents of the {{.Plural}}.
+type {{.Plural}}Ancestor interface {
+	{{.Plural}}(*bambou.FetchingInfo) ({{.Plural}}List, *bambou.Error)
+}
+
+{{comment (printf "%s is the model of the %s objects. %s" .Name .RESTName .Description)}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.JSON}},omitempty"` + "`" + `
+{{- end}}
+}
+
+// New{{.Name}} returns a new *{{.Name}}.
+func New{{.Name}}() *{{.Name}} {
+
+	return &{{.Name}}{}
+}
+
+// Identity returns the Identity of the {{.Name}}.
+func (o *{{.Name}}) Identity() bambou.Identity {
+
+	return {{.Name}}Identity
+}
+
+// Identifier returns the ID of the {{.Name}}.
+func (o *{{.Name}}) Identifier() string {
+
+	return o.ID
+}
+
+// SetIdentifier sets the ID of the {{.Name}}.
+func (o *{{.Name}}) SetIdentifier(ID string) {
+
+	o.ID = ID
+}
+{{if .Root}}
+// APIKey returns the API key of the {{.Name}}.
+func (o *{{.Name}}) APIKey() string {
+
+	return o.Token
+}
+
+// SetAPIKey sets the API key of the {{.Name}}.
+func (o *{{.Name}}) SetAPIKey(key string) {
+
+	o.Token = key
+}
+{{end}}
+// Fetch fetches the {{.Name}} from the server.
+func (o *{{.Name}}) Fetch() *bambou.Error {
+
+	return bambou.CurrentSession().FetchEntity(o)
+}
+
+// Save saves the {{.Name}} into the server.
+func (o *{{.Name}}) Save() *bambou.Error {
+
+	return bambou.CurrentSession().SaveEntity(o)
+}
+
+// Delete deletes the {{.Name}} from the server.
+func (o *{{.Name}}) Delete() *bambou.Error {
+
+	return bambou.CurrentSession().DeleteEntity(o)
+}
+{{- $parent := .Name}}
+{{range .Children}}{{if or .Get .Assign}}
+// {{.Plural}} fetches the {{.Plural}} of the {{$parent}}.
+func (o *{{$parent}}) {{.Plural}}(info *bambou.FetchingInfo) ({{.Plural}}List, *bambou.Error) {
+
+	var list {{.Plural}}List
+	err := bambou.CurrentSession().FetchChildren(o, {{.Name}}Identity, &list, info)
+
+	return list, err
+}
+{{end}}{{if .Create}}
+// Create{{.Name}} creates the given {{.Name}} under the {{$parent}}.
+func (o *{{$parent}}) Create{{.Name}}(child *{{.Name}}) *bambou.Error {
+
+	return bambou.CurrentSession().CreateChild(o, child)
+}
+{{end}}{{if .Assign}}
+// Assign{{.Plural}} assigns the given {{.Plural}} to the {{$parent}}, replacing the assigned ones.
+func (o *{{$parent}}) Assign{{.Plural}}(children {{.Plural}}List) *bambou.Error {
+
+	list := make([]bambou.Identifiable, len(children))
+	for i, child := range children {
+		list[i] = child
+	}
+
+	return bambou.CurrentSession().AssignChildren(o, list, {{.Name}}Identity)
+}
+{{end}}{{end}}`))
+
+var registryTemplate = template.Must(template.New("registry").Funcs(functions).Parse(`// Code generated by bambougen{{if .Version}} from the {{.Version}} specifications{{end}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .Root}}
+	"crypto/tls"
+{{end}}
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// APIVersion is the version of the specifications the package was generated from.
+const APIVersion = "{{.Version}}"
+
+func init() {
+{{range .Models}}
+	bambou.RegisterIdentity({{.Name}}Identity, func() bambou.Identifiable { return New{{.Name}}() })
+{{- end}}
+}
+
+// Identities returns the Identities of the models of the package.
+func Identities() []bambou.Identity {
+
+	return []bambou.Identity{
+{{- range .Models}}
+		{{.Name}}Identity,
+{{- end}}
+	}
+}
+{{if .Root}}
+// NewSession returns a new *bambou.Session authenticating with the given
+// credentials, and its root object.
+func NewSession(username, password, organization, url string) (*bambou.Session, *{{.Root}}) {
+
+	root := New{{.Root}}()
+
+	return bambou.NewSession(username, password, organization, url, root), root
+}
+
+// NewX509Session returns a new *bambou.Session authenticating with the given
+// certificate, and its root object.
+func NewX509Session(cert *tls.Certificate, url string) (*bambou.Session, *{{.Root}}) {
+
+	root := New{{.Root}}()
+
+	return bambou.NewX509Session(cert, url, root), root
+}
+{{end}}`))
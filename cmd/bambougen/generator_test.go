@@ -0,0 +1,204 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var testSpecs = map[string]string{
+	"api.info": `{"version": "6.0"}`,
+	"@base.spec": `{
+		"model": {"rest_name": "@base"},
+		"attributes": [{"name": "ID", "type": "string"}, {"name": "parentID", "type": "string"}]
+	}`,
+	"me.spec": `{
+		"model": {"rest_name": "me", "resource_name": "me", "entity_name": "Me", "root": true, "extends": ["@base"]},
+		"attributes": [{"name": "APIKey", "type": "string"}, {"name": "userName", "type": "string"}],
+		"children": [
+			{"rest_name": "enterprise", "relationship": "root", "get": true, "create": true},
+			{"rest_name": "user", "relationship": "root", "get": true, "create": true}
+		]
+	}`,
+	"enterprise.spec": `{
+		"model": {"rest_name": "enterprise", "resource_name": "enterprises", "entity_name": "Enterprise", "description": "An enterprise.", "extends": ["@base"]},
+		"attributes": [
+			{"name": "name", "type": "string"},
+			{"name": "allowedForwardingClasses", "type": "list", "subtype": "enum"},
+			{"name": "DHCPLeaseInterval", "type": "integer"},
+			{"name": "LDAPEnabled", "type": "boolean"},
+			{"name": "ratio", "type": "float"}
+		],
+		"children": [
+			{"rest_name": "policy", "relationship": "child", "get": true, "create": true},
+			{"rest_name": "user", "relationship": "member", "get": true, "update": true}
+		]
+	}`,
+	"policy.spec": `{
+		"model": {"rest_name": "policy", "resource_name": "policies", "entity_name": "Policy", "extends": ["@base"]},
+		"attributes": [{"name": "name", "type": "string"}]
+	}`,
+	"user.spec": `{
+		"model": {"rest_name": "user", "resource_name": "users", "entity_name": "User", "extends": ["@base"]},
+		"attributes": [{"name": "userName", "type": "string"}]
+	}`,
+}
+
+// writeSpecs writes the given specifications in a new temporary directory.
+func writeSpecs(specs map[string]string) string {
+
+	dir, _ := ioutil.TempDir("", "specs")
+	for name, content := range specs {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	return dir
+}
+
+func TestGenerator_run(t *testing.T) {
+
+	Convey("Given I have a directory of specifications", t, func() {
+
+		specs := writeSpecs(testSpecs)
+		defer os.RemoveAll(specs)
+
+		output, _ := ioutil.TempDir("", "vspk")
+		defer os.RemoveAll(output)
+
+		Convey("When I generate the models", func() {
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			code := run([]string{"-specs", specs, "-output", output, "-package", "vspk", "-openapi", filepath.Join(output, "openapi.json")}, stdout, stderr)
+
+			// read returns the content of the given generated file, with the alignments removed.
+			read := func(name string) string {
+				data, _ := ioutil.ReadFile(filepath.Join(output, name))
+				return regexp.MustCompile(`[ \t]+`).ReplaceAllString(string(data), " ")
+			}
+
+			Convey("Then it should generate valid files", func() {
+				So(code, ShouldEqual, 0)
+				So(stderr.String(), ShouldBeEmpty)
+
+				paths, _ := filepath.Glob(filepath.Join(output, "*.go"))
+				So(len(paths), ShouldEqual, 5)
+
+				for _, path := range paths {
+					_, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+					So(err, ShouldBeNil)
+				}
+			})
+
+			Convey("Then the models should have their fields and helpers", func() {
+
+				enterprise := read("enterprise.go")
+				So(enterprise, ShouldStartWith, "// Code generated by bambougen from the 6.0 specifications. DO NOT EDIT.")
+				So(enterprise, ShouldContainSubstring, "package vspk")
+				So(enterprise, ShouldContainSubstring, "Category: \"enterprises\",")
+				So(enterprise, ShouldContainSubstring, "type Enterprise struct {\n ID string `json:\"ID,omitempty\"`")
+				So(enterprise, ShouldContainSubstring, "// Enterprise is the model of the enterprise objects. An enterprise.")
+				So(enterprise, ShouldContainSubstring, "AllowedForwardingClasses []string `json:\"allowedForwardingClasses,omitempty\"`")
+				So(enterprise, ShouldContainSubstring, "DHCPLeaseInterval bambou.Int `json:\"DHCPLeaseInterval,omitempty\"`")
+				So(enterprise, ShouldContainSubstring, "LDAPEnabled bambou.Bool")
+				So(enterprise, ShouldContainSubstring, "Ratio bambou.Float")
+				So(enterprise, ShouldContainSubstring, "func (o *Enterprise) Policies(info *bambou.FetchingInfo) (PoliciesList, *bambou.Error) {")
+				So(enterprise, ShouldContainSubstring, "func (o *Enterprise) CreatePolicy(child *Policy) *bambou.Error {")
+				So(enterprise, ShouldContainSubstring, "func (o *Enterprise) AssignUsers(children UsersList) *bambou.Error {")
+				So(enterprise, ShouldNotContainSubstring, "CreateUser")
+
+				me := read("me.go")
+				So(me, ShouldContainSubstring, "Token string `json:\"APIKey,omitempty\"`")
+				So(me, ShouldContainSubstring, "func (o *Me) SetAPIKey(key string) {")
+				So(me, ShouldContainSubstring, "func (o *Me) CreateEnterprise(child *Enterprise) *bambou.Error {")
+
+				openapi := read("openapi.json")
+				So(openapi, ShouldContainSubstring, `"operationId": "createEnterprisePolicy"`)
+
+				registry := read("registry.go")
+				So(registry, ShouldContainSubstring, "const APIVersion = \"6.0\"")
+				So(registry, ShouldContainSubstring, "bambou.RegisterIdentity(PolicyIdentity, func() bambou.Identifiable { return NewPolicy() })")
+				So(registry, ShouldContainSubstring, "func NewSession(username, password, organization, url string) (*bambou.Session, *Me) {")
+			})
+		})
+
+		Convey("When I generate the models of specifications with an unknown child", func() {
+
+			ioutil.WriteFile(filepath.Join(specs, "policy.spec"), []byte(`{
+				"model": {"rest_name": "policy", "resource_name": "policies", "entity_name": "Policy"},
+				"children": [{"rest_name": "rule", "relationship": "child", "get": true}]
+			}`), 0644)
+
+			stderr := &bytes.Buffer{}
+			code := run([]string{"-specs", specs, "-output", output}, &bytes.Buffer{}, stderr)
+
+			Convey("Then it should fail", func() {
+				So(code, ShouldEqual, 1)
+				So(stderr.String(), ShouldContainSubstring, "policy: unknown child rule")
+			})
+		})
+
+		Convey("When I generate the models of specifications with conflicting names", func() {
+
+			ioutil.WriteFile(filepath.Join(specs, "policy.spec"), []byte(`{
+				"model": {"rest_name": "policy", "resource_name": "policies", "entity_name": "Policy"},
+				"attributes": [{"name": "fetch", "type": "string"}]
+			}`), 0644)
+
+			stderr := &bytes.Buffer{}
+			code := run([]string{"-specs", specs, "-output", output}, &bytes.Buffer{}, stderr)
+
+			Convey("Then it should fail", func() {
+				So(code, ShouldEqual, 1)
+				So(stderr.String(), ShouldContainSubstring, "policy: attribute fetch cannot be named Fetch")
+			})
+		})
+	})
+}
+
+func TestGenerator_names(t *testing.T) {
+
+	Convey("Given I have specification names", t, func() {
+
+		Convey("Then they should be converted to Go names", func() {
+			So(goName("name"), ShouldEqual, "Name")
+			So(goName("IPv6Address"), ShouldEqual, "IPv6Address")
+			So(goName("ingress-acl"), ShouldEqual, "IngressAcl")
+			So(goName("8021x"), ShouldEqual, "X8021x")
+			So(plural("Policy"), ShouldEqual, "Policies")
+			So(plural("Gateway"), ShouldEqual, "Gateways")
+			So(plural("Address"), ShouldEqual, "Addresses")
+			So(plural("Enterprise"), ShouldEqual, "Enterprises")
+			So(packageName("my-models"), ShouldEqual, "mymodels")
+		})
+	})
+}
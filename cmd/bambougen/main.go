@@ -0,0 +1,145 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Command bambougen generates the Go models of an API from its Monolithe
+// specifications, like the VSD API specifications.
+//
+// Usage:
+//
+//	bambougen -specs <directory> [-output <directory>] [-package <name>] [-openapi <file>]
+//
+// For each specification, it writes a file containing the Identity of the
+// model, its Identifiable struct, its list and ancestor types, and the helpers
+// to fetch, save and delete it and to fetch, create and assign its children
+// through the current session. The root specification gets a Rootable model.
+// A registry.go file registers all the identities with bambou.RegisterIdentity,
+// and provides a NewSession function when there is a root.
+//
+// The string and enum attributes are generated as strings, and the integer,
+// float and boolean attributes as the bambou.Int, bambou.Float and bambou.Bool
+// optional types, so that their zero values can be sent.
+//
+// With -openapi, it also writes the OpenAPI document of the specifications to
+// the given file. See bambou.SpecsOpenAPI.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+func main() {
+
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command line with the given arguments and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+
+	flags := flag.NewFlagSet("bambougen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	specs := flags.String("specs", "", "directory of the specifications")
+	output := flags.String("output", ".", "directory of the generated files")
+	pkg := flags.String("package", "", "name of the generated package, the name of the output directory by default")
+	openapi := flags.String("openapi", "", "file to write the OpenAPI document of the specifications to")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if *specs == "" || flags.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: bambougen -specs <directory> [-output <directory>] [-package <name>] [-openapi <file>]")
+		flags.PrintDefaults()
+		return 2
+	}
+
+	if *pkg == "" {
+		abs, err := filepath.Abs(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "bambougen: %s\n", err)
+			return 1
+		}
+		*pkg = packageName(filepath.Base(abs))
+	}
+
+	set, err := bambou.LoadSpecSet(*specs)
+	if err != nil {
+		fmt.Fprintf(stderr, "bambougen: %s\n", err)
+		return 1
+	}
+
+	files, err := generate(set, *pkg)
+	if err != nil {
+		fmt.Fprintf(stderr, "bambougen: %s\n", err)
+		return 1
+	}
+
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		fmt.Fprintf(stderr, "bambougen: %s\n", err)
+		return 1
+	}
+
+	for _, file := range files {
+		if err := ioutil.WriteFile(filepath.Join(*output, file.name), file.content, 0644); err != nil {
+			fmt.Fprintf(stderr, "bambougen: %s\n", err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stdout, "generated %d files in %s\n", len(files), *output)
+
+	if *openapi != "" {
+		if err := writeOpenAPI(set, *openapi); err != nil {
+			fmt.Fprintf(stderr, "bambougen: %s\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "generated %s\n", *openapi)
+	}
+
+	return 0
+}
+
+// writeOpenAPI writes the OpenAPI document of the given specifications to the given file.
+func writeOpenAPI(set *bambou.SpecSet, path string) error {
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := bambou.SpecsOpenAPI(set, bambou.OpenAPIOptions{}).WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package cli holds the flags and helpers shared by the bambou commands.
+package cli
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+)
+
+// Connection holds the flags connecting a command to the server.
+type Connection struct {
+	URL          string
+	Username     string
+	Password     string
+	Organization string
+	Cert         string
+	Key          string
+	Root         string
+}
+
+// AddFlags defines the connection flags in the given flag set. They default to the
+// BAMBOU_URL, BAMBOU_USERNAME, BAMBOU_ORGANIZATION, BAMBOU_CERT and BAMBOU_KEY
+// environment variables.
+//
+// The password is not the default value of its flag, so that it is never printed with the usage:
+// ReadPassword must be called once the flags are parsed to read BAMBOU_PASSWORD.
+func (c *Connection) AddFlags(flags *flag.FlagSet) {
+
+	flags.StringVar(&c.URL, "url", os.Getenv("BAMBOU_URL"), "URL of the API, like https://host:8443/nuage/api/v5_0")
+	flags.StringVar(&c.Username, "username", os.Getenv("BAMBOU_USERNAME"), "username")
+	flags.StringVar(&c.Password, "password", "", "password, BAMBOU_PASSWORD by default")
+	flags.StringVar(&c.Organization, "organization", EnvOr("BAMBOU_ORGANIZATION", "csp"), "organization")
+	flags.StringVar(&c.Cert, "cert", os.Getenv("BAMBOU_CERT"), "client certificate file, instead of the username and password")
+	flags.StringVar(&c.Key, "key", os.Getenv("BAMBOU_KEY"), "client certificate key file")
+	flags.StringVar(&c.Root, "root", "me", "ReST name of the root object")
+}
+
+// ReadPassword sets the password to the BAMBOU_PASSWORD environment variable if it was not given.
+func (c *Connection) ReadPassword() {
+
+	if c.Password == "" {
+		c.Password = os.Getenv("BAMBOU_PASSWORD")
+	}
+}
+
+// Start returns a new started session using the connection flags.
+func (c *Connection) Start() (*bambou.Session, error) {
+
+	root := bambou.NewRootObject(bambou.Identity{Name: c.Root, Category: c.Root})
+
+	var session *bambou.Session
+	if c.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the certificate: %s", err)
+		}
+		session = bambou.NewX509Session(&cert, c.URL, root)
+	} else {
+		session = bambou.NewSession(c.Username, c.Password, c.Organization, c.URL, root)
+	}
+
+	if berr := session.Start(); berr != nil {
+		return nil, berr
+	}
+
+	return session, nil
+}
+
+// ErrorMessage returns the message of the given error.
+func ErrorMessage(err error) string {
+
+	if berr, ok := err.(*bambou.Error); ok {
+		if berr.Description == "" {
+			return berr.Title
+		}
+		return berr.Title + ": " + berr.Description
+	}
+
+	return err.Error()
+}
+
+// EnvOr returns the value of the given environment variable, or the given default value.
+func EnvOr(name, value string) string {
+
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return value
+}
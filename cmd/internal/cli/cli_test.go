@@ -0,0 +1,130 @@
+// Copyright (c) 2015, Alcatel-Lucent Inc.
+// All rights reserved.
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice, this
+//   list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+// * Neither the name of bambou nor the names of its
+//   contributors may be used to endorse or promote products derived from
+//   this software without specific prior written permission.
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/nuagenetworks/go-bambou/bambou"
+	"github.com/nuagenetworks/go-bambou/bambou/fakevsd"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConnection_AddFlags(t *testing.T) {
+
+	Convey("Given the password is in the environment", t, func() {
+
+		os.Setenv("BAMBOU_PASSWORD", "secret")
+		defer os.Unsetenv("BAMBOU_PASSWORD")
+
+		Convey("When I parse the connection flags", func() {
+			c := &Connection{}
+			usage := &bytes.Buffer{}
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(usage)
+			c.AddFlags(flags)
+			err := flags.Parse([]string{"--url", "https://vsd"})
+			c.ReadPassword()
+			flags.PrintDefaults()
+
+			Convey("Then the password should be read but never printed with the usage", func() {
+				So(err, ShouldBeNil)
+				So(c.URL, ShouldEqual, "https://vsd")
+				So(c.Password, ShouldEqual, "secret")
+				So(c.Organization, ShouldEqual, "csp")
+				So(c.Root, ShouldEqual, "me")
+				So(usage.String(), ShouldContainSubstring, "BAMBOU_PASSWORD")
+				So(usage.String(), ShouldNotContainSubstring, "secret")
+			})
+		})
+
+		Convey("When the password flag is given", func() {
+			c := &Connection{Password: "other"}
+			c.ReadPassword()
+
+			Convey("Then it should be kept", func() {
+				So(c.Password, ShouldEqual, "other")
+			})
+		})
+	})
+}
+
+func TestConnection_Start(t *testing.T) {
+
+	Convey("Given a server", t, func() {
+
+		server := fakevsd.New("admin", "secret", "csp")
+		defer server.Close()
+
+		Convey("When I start a session with valid credentials", func() {
+			c := &Connection{URL: server.URL, Username: "admin", Password: "secret", Organization: "csp", Root: "me"}
+			session, err := c.Start()
+
+			Convey("Then it should be started", func() {
+				So(err, ShouldBeNil)
+				So(session.APIKey(), ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When the certificate cannot be loaded", func() {
+			c := &Connection{URL: server.URL, Cert: "missing.pem", Key: "missing.key", Root: "me"}
+			_, err := c.Start()
+
+			Convey("Then I should get an error", func() {
+				So(err, ShouldNotBeNil)
+				So(ErrorMessage(err), ShouldStartWith, "unable to load the certificate")
+			})
+		})
+	})
+}
+
+func TestErrorMessage(t *testing.T) {
+
+	Convey("Given errors", t, func() {
+
+		Convey("Then their message should include the description when there is one", func() {
+			So(ErrorMessage(bambou.NewBambouError("Title", "")), ShouldEqual, "Title")
+			So(ErrorMessage(bambou.NewBambouError("Title", "description")), ShouldEqual, "Title: description")
+			So(ErrorMessage(errors.New("plain")), ShouldEqual, "plain")
+		})
+	})
+}
+
+func TestEnvOr(t *testing.T) {
+
+	Convey("Given an environment variable", t, func() {
+
+		os.Setenv("BAMBOU_TEST_ENV", "value")
+		defer os.Unsetenv("BAMBOU_TEST_ENV")
+
+		Convey("Then its value or the default should be returned", func() {
+			So(EnvOr("BAMBOU_TEST_ENV", "default"), ShouldEqual, "value")
+			So(EnvOr("BAMBOU_TEST_MISSING", "default"), ShouldEqual, "default")
+		})
+	})
+}